@@ -1,7 +1,5 @@
 package mpesa
 
-import "time"
-
 // DynamicQRTransactionType represents the supported transaction types for the Dynamic QR API
 type DynamicQRTransactionType string
 
@@ -20,6 +18,9 @@ const (
 	// AccountBalanceCommandID is applied when getting the account balance of a shortcode
 	AccountBalanceCommandID CommandID = "AccountBalance"
 
+	// BusinessBuyGoodsCommandID is applied for BusinessBuyGoodsRequest
+	BusinessBuyGoodsCommandID CommandID = "BusinessBuyGoods"
+
 	// BusinessPayBillCommandID is applied for BusinessPayBillRequest
 	BusinessPayBillCommandID CommandID = "BusinessPayBill"
 
@@ -35,12 +36,30 @@ const (
 
 	// TransactionStatusQueryCommandID is applied when getting the status of a transaction.
 	TransactionStatusQueryCommandID CommandID = "TransactionStatusQuery"
+
+	// TransactionReversalCommandID is applied when reversing a transaction.
+	TransactionReversalCommandID CommandID = "TransactionReversal"
 )
 
 // IdentifierType is the type of organization receiving the transaction
 type IdentifierType uint8
 
-const ShortcodeIdentifierType IdentifierType = 4
+const (
+	// MSISDNIdentifierType identifies a customer phone number.
+	MSISDNIdentifierType IdentifierType = 1
+
+	// TillNumberIdentifierType identifies a till number.
+	TillNumberIdentifierType IdentifierType = 2
+
+	// ShortcodeIdentifierType identifies an organization shortcode such as a paybill number.
+	ShortcodeIdentifierType IdentifierType = 4
+)
+
+// MerchantTillIdentifierType is the RecieverIdentifierType of a till number on Business Buy Goods requests.
+const MerchantTillIdentifierType = TillNumberIdentifierType
+
+// ReversalIdentifierType is the RecieverIdentifierType required by the reversal API.
+const ReversalIdentifierType IdentifierType = 11
 
 // TransactionType is used ti identify the type of the transaction being made.
 type TransactionType string
@@ -58,9 +77,6 @@ type (
 	AuthorizationResponse struct {
 		AccessToken string `json:"access_token"`
 		ExpiresIn   string `json:"expires_in"`
-
-		// Internal fields
-		setAt time.Time
 	}
 
 	// STKPushRequest represents the data to be provided by the user for LipaNaMpesaOnlineRequestParameters
@@ -96,6 +112,10 @@ type (
 		// PhoneNumber to receive the STK Pin Prompt which can be same as PartyA value.
 		PhoneNumber uint64 `json:"PhoneNumber"`
 
+		// Phone is an optional phone number in any format accepted by ParsePhoneNumber, e.g. 0712345678. When set,
+		// it is normalized and used as PhoneNumber and, if PartyA is not set, as PartyA.
+		Phone string `json:"-"`
+
 		// CallbackURL is a valid secure URL that is used to receive notifications from M-Pesa API. It is the endpoint
 		// to which the results will be sent by M-Pesa API.
 		CallBackURL string `json:"CallBackURL"`
@@ -110,7 +130,104 @@ type (
 		TransactionDesc string `json:"TransactionDesc"`
 	}
 
-	// Response is the response sent back by mpesa after initiating a request.
+	// STKPushResponse acknowledges an STK push. The result is sent to the CallBackURL.
+	STKPushResponse struct {
+		// MerchantRequestID is a global unique Identifier for any submitted payment request. Example: 16813-1590513-1
+		MerchantRequestID string `json:"MerchantRequestID,omitempty"`
+
+		// CheckoutRequestID is a global unique identifier of the processed checkout transaction request.
+		// Example: ws_CO_DMZ_12321_23423476
+		CheckoutRequestID string `json:"CheckoutRequestID,omitempty"`
+
+		// ResponseCode is "0" when the STK push was accepted for processing.
+		ResponseCode string `json:"ResponseCode,omitempty"`
+
+		// ResponseDescription is an acknowledgment message from the API that gives the status of the request submission.
+		ResponseDescription string `json:"ResponseDescription,omitempty"`
+
+		// CustomerMessage is a message that your system can display to the Customer as an acknowledgement of the
+		// payment request submission. Example: Success. Request accepted for processing.
+		CustomerMessage string `json:"CustomerMessage,omitempty"`
+
+		// RawBody is the body of the response as returned by Daraja.
+		RawBody []byte `json:"-"`
+	}
+
+	// STKQueryResponse is the status of an STK push.
+	STKQueryResponse struct {
+		// MerchantRequestID is the MerchantRequestID of the STK push.
+		MerchantRequestID string `json:"MerchantRequestID,omitempty"`
+
+		// CheckoutRequestID is the CheckoutRequestID of the STK push.
+		CheckoutRequestID string `json:"CheckoutRequestID,omitempty"`
+
+		// ResponseCode is "0" when the query was processed.
+		ResponseCode string `json:"ResponseCode,omitempty"`
+
+		// ResponseDescription is an acknowledgment message from the API that gives the status of the query.
+		ResponseDescription string `json:"ResponseDescription,omitempty"`
+
+		// ResultCode is the result of the STK push, "0" once it was paid. See STKResultCode.
+		ResultCode string `json:"ResultCode,omitempty"`
+
+		// ResultDesc describes the result of the STK push.
+		ResultDesc string `json:"ResultDesc,omitempty"`
+
+		// RawBody is the body of the response as returned by Daraja.
+		RawBody []byte `json:"-"`
+	}
+
+	// AsyncResponse acknowledges a request whose result is sent to its ResultURL: B2C, BusinessPayBill,
+	// BusinessBuyGoods, Reversal, GetTransactionStatus and GetAccountBalance.
+	AsyncResponse struct {
+		// ConversationID is a global unique identifier for the transaction request returned by the M-Pesa upon successful
+		// request submission. The result is sent with the same ConversationID.
+		ConversationID string `json:"ConversationID,omitempty"`
+
+		// OriginatorConversationID is a global unique identifier for the transaction request returned by the API proxy
+		// upon successful request submission.
+		OriginatorConversationID string `json:"OriginatorConversationID,omitempty"`
+
+		// ResponseCode is "0" when the request was accepted for processing.
+		ResponseCode string `json:"ResponseCode,omitempty"`
+
+		// ResponseDescription is an acknowledgment message from the API that gives the status of the request submission.
+		ResponseDescription string `json:"ResponseDescription,omitempty"`
+
+		// RawBody is the body of the response as returned by Daraja.
+		RawBody []byte `json:"-"`
+	}
+
+	// B2CResponse acknowledges a B2C payment.
+	B2CResponse = AsyncResponse
+
+	// C2BRegisterResponse acknowledges the registration of the C2B URLs.
+	C2BRegisterResponse struct {
+		// OriginatorConversationID is a global unique identifier of the request.
+		OriginatorConversationID string `json:"OriginatorConversationID,omitempty"`
+
+		// ResponseCode is "0" when the URLs were registered.
+		ResponseCode string `json:"ResponseCode,omitempty"`
+
+		// ResponseDescription describes the result of the registration.
+		ResponseDescription string `json:"ResponseDescription,omitempty"`
+
+		// RawBody is the body of the response as returned by Daraja.
+		RawBody []byte `json:"-"`
+	}
+
+	// Acknowledgement is the response of a request that starts a transaction, a *STKPushResponse or an
+	// *AsyncResponse.
+	Acknowledgement interface {
+		// acknowledgement returns the ID the result of the request is sent with and the response code and
+		// description. It is safe to call on a nil response.
+		acknowledgement() (requestID, responseCode, responseDescription string)
+	}
+
+	// Response is the union of the fields of the responses of every API.
+	//
+	// Deprecated: the APIs return their own response type, e.g. STKPushResponse, which only has the fields the API
+	// sets. Errors are returned as an *Error.
 	Response struct {
 		// CheckoutRequestID is a global unique identifier of the processed checkout transaction request.
 		// Example: ws_CO_DMZ_12321_23423476
@@ -156,6 +273,9 @@ type (
 
 		// RequestID is a unique request ID for the payment request
 		RequestID string `json:"requestId,omitempty"`
+
+		// RawBody is the body of the response as returned by Daraja.
+		RawBody []byte `json:"-"`
 	}
 
 	STKCallbackItem struct {
@@ -254,6 +374,10 @@ type (
 		// PartyB is the customer mobile number to receive the amount which should have the country code (254).
 		PartyB uint64 `json:"PartyB"`
 
+		// Phone is an optional phone number in any format accepted by ParsePhoneNumber, e.g. 0712345678. When set,
+		// it is normalized and used as PartyB.
+		Phone string `json:"-"`
+
 		// Remarks represents any additional information to be associated with the transaction.
 		Remarks string `json:"Remarks"`
 
@@ -267,6 +391,11 @@ type (
 
 		// Occasion is any additional information to be associated with the transaction.
 		Occasion string `json:"Occasion"`
+
+		// OriginatorConversationID is an optional unique ID of the request. It is echoed on the result callback and
+		// used as the idempotency key of the request when WithIdempotency is set. A UUID is generated when it is
+		// empty and returned on the Response so that the result callback can be correlated with the request.
+		OriginatorConversationID string `json:"OriginatorConversationID,omitempty"`
 	}
 
 	// ResultParameter holds additional transaction details.
@@ -327,6 +456,33 @@ type (
 		Result CallbackResult `json:"Result"`
 	}
 
+	// QueueTimeoutResult is the notification of a request that expired in the M-Pesa queue before it was processed.
+	// Unlike a CallbackResult, it has neither ResultParameters nor a TransactionID since no transaction took place.
+	QueueTimeoutResult struct {
+		// ConversationID is the ConversationID returned on the Response of the request that timed out.
+		ConversationID string `json:"ConversationID"`
+
+		// OriginatorConversationID is the OriginatorConversationID of the request that timed out.
+		OriginatorConversationID string `json:"OriginatorConversationID"`
+
+		ReferenceData ReferenceData `json:"ReferenceData"`
+
+		// ResultCode is the code of the timeout. It is never 0.
+		ResultCode int `json:"ResultCode"`
+
+		// ResultDesc describes the timeout, e.g. "The service request timed out."
+		ResultDesc string `json:"ResultDesc"`
+
+		// ResultType is 1 on timeout notifications.
+		ResultType int `json:"ResultType"`
+	}
+
+	// QueueTimeoutCallback is sent to the QueueTimeOutURL of a request that timed out in the M-Pesa queue.
+	QueueTimeoutCallback struct {
+		// Result is the root parameter that encloses the timeout notification.
+		Result QueueTimeoutResult `json:"Result"`
+	}
+
 	STKQueryRequest struct {
 		// BusinessShortCode is organizations shortcode (Paybill or Buy goods - A 5 to 7-digit account number) used to
 		// identify an organization and receive the transaction.
@@ -363,6 +519,14 @@ type (
 		// The validation URL is only called if the external validation on the registered shortcode is enabled.
 		// (By default External Validation is disabled).
 		ValidationURL string `json:"ValidationURL"`
+
+		// ConfirmationOnly registers the ConfirmationURL alone, for shortcodes whose external validation is disabled.
+		// The ValidationURL may then be empty and is not defaulted to the callback URLs of the app.
+		ConfirmationOnly bool `json:"-"`
+
+		// APIVersion is the version of the API the URLs are registered with, C2BRegisterAPIVersion1 or
+		// C2BRegisterAPIVersion2. Defaults to C2BRegisterAPIVersion1, or the version set using WithAPIVersion.
+		APIVersion string `json:"-"`
 	}
 
 	DynamicQRRequest struct {
@@ -378,8 +542,9 @@ type (
 		// ReferenceNo is the transaction reference number.
 		ReferenceNo string `json:"RefNo"`
 
-		// Size of the QR code image in pixels. QR code image will always be a square image.
-		Size string `json:"Size"`
+		// Size of the QR code image in pixels, between 100 and 1000. QR code image will always be a square image.
+		// Defaults to 300.
+		Size int `json:"Size,string"`
 
 		/*
 			TransactionType represents the type of transaction being made.
@@ -393,9 +558,12 @@ type (
 	}
 
 	DynamicQRResponse struct {
-		// ImagePath is the absolute path to the decoded base64 image
+		// ImagePath is the path to the decoded base64 image when it is saved to the directory set with WithQRImageDir.
 		ImagePath string `json:"qr_path,omitempty"`
 
+		// ImageData is the decoded base64 PNG image, set when DynamicQR is called with decodeImage.
+		ImageData []byte `json:"-"`
+
 		// ErrorCode is a predefined code that indicates the reason for request failure that is defined in the
 		// ErrorMessage. The error codes maps to specific error message.
 		ErrorCode string `json:"errorCode,omitempty"`
@@ -417,10 +585,11 @@ type (
 	}
 
 	TransactionStatusRequest struct {
-		// The CommandID for the request - TransactionStatusQueryCommandID
+		// The CommandID for the request. Defaults to TransactionStatusQueryCommandID.
 		CommandID CommandID `json:"CommandID"`
 
-		// IdentifierType is the type of organization receiving the transaction
+		// IdentifierType is the type of PartyA: MSISDNIdentifierType, TillNumberIdentifierType or
+		// ShortcodeIdentifierType, which is the default.
 		IdentifierType IdentifierType `json:"IdentifierType"`
 
 		// Initiator is the credential/username used to authenticate the transaction request.
@@ -433,7 +602,8 @@ type (
 		// upon successful request submission. If you don’t have the M-PESA transaction ID you can use this to query.
 		OriginatorConversationID string `json:"OriginatorConversationID,omitempty"`
 
-		// PartyA is an Organization/MSISDN receiving the transaction. Shortcode (6-9 digits) MSISDN (12 Digits)
+		// PartyA is the shortcode or till number (5 to 7 digits) that received the transaction or, if IdentifierType
+		// is MSISDNIdentifierType, the phone number (12 digits) in the format 2547XXXXXXXX.
 		PartyA uint `json:"PartyA"`
 
 		// QueueTimeOutURL is the endpoint that will be used by API Proxy to send notification incase the request is timed
@@ -455,10 +625,11 @@ type (
 	}
 
 	AccountBalanceRequest struct {
-		// The CommandID for the request - AccountBalanceCommandID
+		// The CommandID for the request. Defaults to AccountBalanceCommandID.
 		CommandID CommandID `json:"CommandID"`
 
-		// IdentifierType is the type of organization fetching the balance - Shortcode (Till Number Organization shortcode)
+		// IdentifierType is the type of organization fetching the balance: TillNumberIdentifierType or
+		// ShortcodeIdentifierType, which is the default.
 		IdentifierType IdentifierType `json:"IdentifierType"`
 
 		// Initiator is the credential/username used to authenticate the request.
@@ -529,4 +700,120 @@ type (
 		// For this API, only ShortcodeIdentifierType is allowed
 		SenderIdentifierType IdentifierType `json:"SenderIdentifierType"`
 	}
+
+	BusinessBuyGoodsRequest struct {
+		// AccountReference is account number to be associated with the payment. Up to 13 characters.
+		AccountReference string `json:"AccountReference"`
+
+		// Amount is the transaction amount.
+		Amount uint `json:"Amount"`
+
+		// The CommandID for the request - BusinessBuyGoodsCommandID
+		CommandID CommandID `json:"CommandID"`
+
+		// Initiator is the credential/username used to authenticate the request.
+		Initiator string `json:"Initiator"`
+
+		// Occasion is an optional paramater that is a sequence of characters up to 100
+		Occasion string `json:"Occasion"`
+
+		// PartyA is your shortcode. The shortcode from which money will be deducted.
+		PartyA uint `json:"PartyA"`
+
+		// PartyB is the till number or merchant store number to which money will be moved to.
+		PartyB uint `json:"PartyB"`
+
+		// QueueTimeOutURL is the endpoint that will be used by API Proxy to send notification incase the request is
+		//timed out while awaiting processing in the queue. Must be served via https.
+		QueueTimeOutURL string `json:"QueueTimeOutURL"`
+
+		// RecieverIdentifierType is the type of shortcode to which money is credited. Either MerchantTillIdentifierType
+		// for a till number or ShortcodeIdentifierType for a merchant store number, which is the default.
+		RecieverIdentifierType IdentifierType `json:"RecieverIdentifierType"`
+
+		// Remarks are comments that are sent along with the transaction. They are a sequence of characters up to 100
+		Remarks string `json:"Remarks"`
+
+		// Optional. Requester is the consumer’s mobile number on behalf of whom you are paying.
+		Requester int64 `json:"Requester,omitempty"`
+
+		// ResultURL is the endpoint that will be used by M-PESA to send notification upon processing of the request.
+		// Must be served via https.
+		ResultURL string `json:"ResultURL"`
+
+		// SecurityCredential is an encrypted password for the initiator to authenticate the request
+		SecurityCredential string `json:"SecurityCredential"`
+
+		// SenderIdentifierType is the type of shortcode from which money is deducted.
+		// For this API, only ShortcodeIdentifierType is allowed
+		SenderIdentifierType IdentifierType `json:"SenderIdentifierType"`
+	}
+
+	ReversalRequest struct {
+		// Amount is the amount of the transaction to reverse.
+		Amount uint `json:"Amount"`
+
+		// The CommandID for the request - TransactionReversalCommandID
+		CommandID CommandID `json:"CommandID"`
+
+		// Initiator is the credential/username used to authenticate the request.
+		Initiator string `json:"Initiator"`
+
+		// Occasion is an optional paramater that is a sequence of characters up to 100
+		Occasion string `json:"Occasion"`
+
+		// QueueTimeOutURL is the endpoint that will be used by API Proxy to send notification incase the request is
+		// timed out while awaiting processing in the queue. Must be served via https.
+		QueueTimeOutURL string `json:"QueueTimeOutURL"`
+
+		// ReceiverParty is the organization shortcode that received the transaction being reversed.
+		ReceiverParty uint `json:"ReceiverParty"`
+
+		// RecieverIdentifierType is the type of the ReceiverParty. This API supports ReversalIdentifierType only.
+		RecieverIdentifierType IdentifierType `json:"RecieverIdentifierType"`
+
+		// Remarks are comments that are sent along with the transaction. They are a sequence of characters up to 100
+		Remarks string `json:"Remarks"`
+
+		// ResultURL is the endpoint that will be used by M-PESA to send notification upon processing of the request.
+		// Must be served via https.
+		ResultURL string `json:"ResultURL"`
+
+		// SecurityCredential is an encrypted password for the initiator to authenticate the request
+		SecurityCredential string `json:"SecurityCredential"`
+
+		// TransactionID is the M-Pesa receipt number of the transaction to reverse.
+		TransactionID string `json:"TransactionID"`
+	}
+
+	// B2BExpressCheckoutCallback is the asynchronous confirmation sent to the callback URL of a B2B Express Checkout
+	// request once the merchant completes or cancels the USSD prompt. All the values are sent as strings.
+	B2BExpressCheckoutCallback struct {
+		// ResultCode is the status of the payment. 0 means the payment was successful.
+		ResultCode string `json:"resultCode"`
+
+		// ResultDesc is a message describing the ResultCode.
+		ResultDesc string `json:"resultDesc"`
+
+		// Amount paid, e.g. 71.0.
+		Amount string `json:"amount"`
+
+		// RequestID is the unique identifier of the checkout request.
+		RequestID string `json:"requestId"`
+
+		// PaymentReference is the reference sent on the checkout request.
+		PaymentReference string `json:"paymentReference,omitempty"`
+
+		// ResultType is sent on successful payments.
+		ResultType string `json:"resultType,omitempty"`
+
+		// ConversationID is the unique identifier of the payment on M-Pesa. It is only sent on successful payments.
+		ConversationID string `json:"conversationID,omitempty"`
+
+		// TransactionID is the M-Pesa receipt number. It is only sent on successful payments.
+		TransactionID string `json:"transactionId,omitempty"`
+
+		// Status of the payment, e.g. SUCCESS.
+		Status string `json:"status,omitempty"`
+	}
 )
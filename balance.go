@@ -0,0 +1,338 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Account names reported in the account balance result.
+const (
+	WorkingAccount                = "Working Account"
+	FloatAccount                  = "Float Account"
+	UtilityAccount                = "Utility Account"
+	ChargesPaidAccount            = "Charges Paid Account"
+	OrganizationSettlementAccount = "Organization Settlement Account"
+)
+
+// ErrInvalidBalanceResult is returned when an account balance result cannot be parsed.
+var ErrInvalidBalanceResult = errors.New("mpesa: invalid account balance result")
+
+type (
+	// AccountBalance is the balance of one of the accounts of a shortcode.
+	AccountBalance struct {
+		// Name of the account, e.g. WorkingAccount or UtilityAccount.
+		Name string
+
+		// Currency of the balances, e.g. KES.
+		Currency Currency
+
+		// Current is the current balance of the account.
+		Current float64
+
+		// Available is the balance that can be transacted with.
+		Available float64
+
+		// Reserved is the amount on hold.
+		Reserved float64
+
+		// Uncleared is the amount that has not cleared yet.
+		Uncleared float64
+	}
+
+	// AccountBalanceResult is the outcome of an account balance request as sent on its result callback.
+	AccountBalanceResult struct {
+		// ConversationID is the ConversationID returned on the Response of the request.
+		ConversationID string
+
+		// OriginatorConversationID is the OriginatorConversationID of the request.
+		OriginatorConversationID string
+
+		// Accounts are the balances of the accounts of the shortcode.
+		Accounts []AccountBalance
+
+		// CompletedAt is the time M-Pesa read the balances, taken from the BOCompletedTime parameter. It is zero if
+		// the parameter is missing.
+		CompletedAt time.Time
+	}
+
+	// BalanceSnapshot holds the balances of a shortcode at a point in time.
+	BalanceSnapshot struct {
+		// ShortCode is the shortcode the balances belong to.
+		ShortCode int
+
+		// Accounts are the balances of the accounts of the shortcode.
+		Accounts []AccountBalance
+
+		// ReceivedAt is the time the balances were received.
+		ReceivedAt time.Time
+	}
+
+	// BalanceThreshold is the minimum available balance expected on an account.
+	BalanceThreshold struct {
+		// Account is the name of the account the threshold applies to.
+		Account string
+
+		// Min is the lowest acceptable available balance.
+		Min float64
+	}
+
+	// BalanceAlert is raised when the available balance of an account crosses its threshold.
+	BalanceAlert struct {
+		// ShortCode is the shortcode that owns the account.
+		ShortCode int
+
+		// Account is the balance that crossed the threshold.
+		Account AccountBalance
+
+		// Threshold is the threshold that was crossed.
+		Threshold BalanceThreshold
+
+		// Below is true when the balance dropped below the threshold and false when it recovered.
+		Below bool
+	}
+
+	// BalanceWatcherConfig configures a BalanceWatcher.
+	BalanceWatcherConfig struct {
+		// InitiatorName and InitiatorPassword are the credentials of the API operator used to query the balance.
+		InitiatorName     string
+		InitiatorPassword string
+
+		// ShortCode is the shortcode whose balance is monitored.
+		ShortCode int
+
+		// QueueTimeOutURL and ResultURL are sent with every balance request. The ResultURL handler must pass the
+		// callbacks to BalanceWatcher.HandleResult.
+		QueueTimeOutURL string
+		ResultURL       string
+
+		// Interval is the time between balance requests made by Run. Defaults to 15 minutes.
+		Interval time.Duration
+
+		// Thresholds are the balances to alert on.
+		Thresholds []BalanceThreshold
+
+		// OnAlert, if set, is called when an account crosses one of its thresholds.
+		OnAlert func(alert BalanceAlert)
+
+		// OnSnapshot, if set, is called for every balance result received.
+		OnSnapshot func(snapshot BalanceSnapshot)
+
+		// OnError, if set, is called when a balance request made by Run fails.
+		OnError func(err error)
+	}
+
+	// BalanceWatcher periodically requests the account balance of a shortcode and raises alerts when the balances
+	// cross the configured thresholds, e.g. to top up the float before disbursements start failing.
+	//
+	// The account balance API is asynchronous: Poll only submits the request and the balances are delivered to the
+	// ResultURL which must be handed over to HandleResult.
+	BalanceWatcher struct {
+		app *Mpesa
+		cfg BalanceWatcherConfig
+
+		mu     sync.Mutex
+		latest *BalanceSnapshot
+		below  map[BalanceThreshold]bool
+	}
+)
+
+// Account returns the balance of the account with the provided name.
+func (s BalanceSnapshot) Account(name string) (AccountBalance, bool) {
+	for _, account := range s.Accounts {
+		if strings.EqualFold(account.Name, name) {
+			return account, true
+		}
+	}
+
+	return AccountBalance{}, false
+}
+
+// ParseAccountBalances parses the AccountBalance result parameter which is formatted as
+//
+//	Working Account|KES|46713.00|46713.00|0.00|0.00&Utility Account|KES|49217.00|49217.00|0.00|0.00
+func ParseAccountBalances(s string) ([]AccountBalance, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty balance", ErrInvalidBalanceResult)
+	}
+
+	var accounts []AccountBalance
+	for _, raw := range strings.Split(s, "&") {
+		fields := strings.Split(raw, "|")
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidBalanceResult, raw)
+		}
+
+		var amounts [4]float64
+		for i, field := range fields[2:] {
+			amount, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: %v", ErrInvalidBalanceResult, raw, err)
+			}
+			amounts[i] = amount
+		}
+
+		currency, err := ParseCurrency(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidBalanceResult, raw, err)
+		}
+
+		accounts = append(accounts, AccountBalance{
+			Name:      strings.TrimSpace(fields[0]),
+			Currency:  currency,
+			Current:   amounts[0],
+			Available: amounts[1],
+			Reserved:  amounts[2],
+			Uncleared: amounts[3],
+		})
+	}
+
+	return accounts, nil
+}
+
+// AccountBalancesFromCallback parses the balances sent on an account balance result callback.
+func AccountBalancesFromCallback(callback *Callback) ([]AccountBalance, error) {
+	if callback.Result.ResultCode != 0 {
+		return nil, fmt.Errorf("mpesa: account balance request failed: %d - %s",
+			callback.Result.ResultCode, callback.Result.ResultDesc,
+		)
+	}
+
+	return callback.Result.ResultParameters.ParsedAccountBalance()
+}
+
+// AccountBalanceResultFromCallback parses the balances and the completion time sent on an account balance result
+// callback. It fails if the request failed or the balances cannot be parsed.
+func AccountBalanceResultFromCallback(callback *Callback) (*AccountBalanceResult, error) {
+	accounts, err := AccountBalancesFromCallback(callback)
+	if err != nil {
+		return nil, err
+	}
+
+	completedAt, _ := callback.Result.ResultParameters.BOCompletedTime()
+
+	return &AccountBalanceResult{
+		ConversationID:           callback.Result.ConversationID,
+		OriginatorConversationID: callback.Result.OriginatorConversationID,
+		Accounts:                 accounts,
+		CompletedAt:              completedAt,
+	}, nil
+}
+
+// Account returns the balance of the account with the provided name.
+func (r AccountBalanceResult) Account(name string) (AccountBalance, bool) {
+	return BalanceSnapshot{Accounts: r.Accounts}.Account(name)
+}
+
+// NewBalanceWatcher creates a BalanceWatcher that queries the balances using the provided app.
+func NewBalanceWatcher(app *Mpesa, cfg BalanceWatcherConfig) *BalanceWatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Minute
+	}
+
+	return &BalanceWatcher{
+		app:   app,
+		cfg:   cfg,
+		below: make(map[BalanceThreshold]bool),
+	}
+}
+
+// Poll submits an account balance request. The balances are delivered to the configured ResultURL.
+func (w *BalanceWatcher) Poll(ctx context.Context) error {
+	_, err := w.app.GetAccountBalance(ctx, w.cfg.InitiatorPassword, AccountBalanceRequest{
+		Initiator:       w.cfg.InitiatorName,
+		PartyA:          w.cfg.ShortCode,
+		QueueTimeOutURL: w.cfg.QueueTimeOutURL,
+		Remarks:         "Balance check",
+		ResultURL:       w.cfg.ResultURL,
+	})
+	return err
+}
+
+// Run calls Poll every interval until the context is done. Poll errors are reported to OnError.
+func (w *BalanceWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Poll(ctx); err != nil && ctx.Err() == nil && w.cfg.OnError != nil {
+			w.cfg.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// HandleResult records the balances sent on the account balance result callback and raises the alerts for the
+// thresholds that were crossed since the previous result.
+func (w *BalanceWatcher) HandleResult(callback *Callback) (BalanceSnapshot, error) {
+	accounts, err := AccountBalancesFromCallback(callback)
+	if err != nil {
+		return BalanceSnapshot{}, err
+	}
+
+	snapshot := BalanceSnapshot{
+		ShortCode:  w.cfg.ShortCode,
+		Accounts:   accounts,
+		ReceivedAt: time.Now(),
+	}
+
+	var alerts []BalanceAlert
+
+	w.mu.Lock()
+	w.latest = &snapshot
+
+	for _, threshold := range w.cfg.Thresholds {
+		account, ok := snapshot.Account(threshold.Account)
+		if !ok {
+			continue
+		}
+
+		below := account.Available < threshold.Min
+		if below == w.below[threshold] {
+			continue
+		}
+
+		w.below[threshold] = below
+		alerts = append(alerts, BalanceAlert{
+			ShortCode: w.cfg.ShortCode,
+			Account:   account,
+			Threshold: threshold,
+			Below:     below,
+		})
+	}
+	w.mu.Unlock()
+
+	if w.cfg.OnSnapshot != nil {
+		w.cfg.OnSnapshot(snapshot)
+	}
+
+	if w.cfg.OnAlert != nil {
+		for _, alert := range alerts {
+			w.cfg.OnAlert(alert)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// Latest returns the most recent balances received.
+func (w *BalanceWatcher) Latest() (BalanceSnapshot, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.latest == nil {
+		return BalanceSnapshot{}, false
+	}
+
+	return *w.latest, true
+}
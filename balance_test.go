@@ -0,0 +1,164 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testBalanceCallback(balance string) *Callback {
+	return &Callback{
+		Result: CallbackResult{
+			ConversationID:           "AG_20200120_0000657265d5fa9ae5c0",
+			OriginatorConversationID: "16917-22577599-3",
+			ResultDesc:               "The service request is processed successfully.",
+			ResultParameters: ResultParameters{
+				ResultParameter: []ResultParameter{
+					{Key: "AccountBalance", Value: balance},
+					{Key: "BOCompletedTime", Value: 20200120164825},
+				},
+			},
+			TransactionID: "OAK0000000",
+		},
+	}
+}
+
+func TestParseAccountBalances(t *testing.T) {
+	accounts, err := ParseAccountBalances(
+		"Working Account|KES|46713.00|46713.00|0.00|0.00&Utility Account|KES|49217.00|49000.00|217.00|0.00",
+	)
+	require.NoError(t, err)
+	require.Equal(t, []AccountBalance{
+		{Name: WorkingAccount, Currency: "KES", Current: 46713, Available: 46713},
+		{Name: UtilityAccount, Currency: "KES", Current: 49217, Available: 49000, Reserved: 217},
+	}, accounts)
+
+	accounts, err = ParseAccountBalances("Working Account|tzs|150000.00|150000.00|0.00|0.00")
+	require.NoError(t, err)
+	require.Equal(t, CurrencyTZS, accounts[0].Currency)
+
+	for _, s := range []string{
+		"", "Working Account|KES|46713.00", "Working Account|KES|abc|0.00|0.00|0.00", "Working Account||0|0|0|0",
+	} {
+		_, err = ParseAccountBalances(s)
+		require.ErrorIs(t, err, ErrInvalidBalanceResult)
+	}
+}
+
+func TestAccountBalancesFromCallback(t *testing.T) {
+	callback := testBalanceCallback("Working Account|KES|700000.00|700000.00|0.00|0.00")
+
+	accounts, err := AccountBalancesFromCallback(callback)
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, 700000.0, accounts[0].Available)
+
+	callback.Result.ResultCode = 2001
+	callback.Result.ResultDesc = "The initiator information is invalid."
+	_, err = AccountBalancesFromCallback(callback)
+	require.ErrorContains(t, err, "The initiator information is invalid.")
+
+	_, err = AccountBalancesFromCallback(&Callback{})
+	require.ErrorIs(t, err, ErrInvalidBalanceResult)
+}
+
+func TestAccountBalanceResultFromCallback(t *testing.T) {
+	callback := testBalanceCallback(
+		"Working Account|KES|700000.00|700000.00|0.00|0.00&Utility Account|KES|228037.00|228037.00|0.00|0.00",
+	)
+
+	result, err := AccountBalanceResultFromCallback(callback)
+	require.NoError(t, err)
+	require.Equal(t, "AG_20200120_0000657265d5fa9ae5c0", result.ConversationID)
+	require.Equal(t, "16917-22577599-3", result.OriginatorConversationID)
+	require.Len(t, result.Accounts, 2)
+	require.True(t, time.Date(2020, time.January, 20, 13, 48, 25, 0, time.UTC).Equal(result.CompletedAt))
+
+	utility, ok := result.Account("utility account")
+	require.True(t, ok)
+	require.Equal(t, 228037.0, utility.Available)
+
+	_, ok = result.Account(FloatAccount)
+	require.False(t, ok)
+
+	callback.Result.ResultParameters.ResultParameter = callback.Result.ResultParameters.ResultParameter[:1]
+	result, err = AccountBalanceResultFromCallback(callback)
+	require.NoError(t, err)
+	require.True(t, result.CompletedAt.IsZero())
+
+	callback.Result.ResultCode = 2001
+	_, err = AccountBalanceResultFromCallback(callback)
+	require.Error(t, err)
+}
+
+func TestBalanceWatcher(t *testing.T) {
+	var (
+		cl     = newMockHttpClient()
+		app    = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+		alerts []BalanceAlert
+	)
+
+	mockAuth(app, cl)
+
+	watcher := NewBalanceWatcher(app, BalanceWatcherConfig{
+		InitiatorName:     "testapi",
+		InitiatorPassword: "Safaricom999!*!",
+		ShortCode:         600426,
+		QueueTimeOutURL:   "https://example.com/timeout",
+		ResultURL:         "https://example.com/result",
+		Thresholds: []BalanceThreshold{
+			{Account: UtilityAccount, Min: 10000},
+		},
+		OnAlert: func(alert BalanceAlert) {
+			alerts = append(alerts, alert)
+		},
+	})
+
+	cl.MockRequest(app.endpointAccountBalance(), func() (status int, body string) {
+		var req AccountBalanceRequest
+		require.NoError(t, json.NewDecoder(cl.requests[1].Body).Decode(&req))
+		require.Equal(t, 600426, req.PartyA)
+		require.Equal(t, "testapi", req.Initiator)
+		require.Equal(t, AccountBalanceCommandID, req.CommandID)
+
+		return http.StatusOK, `{
+			"OriginatorConversationID": "2ba8-4165-beca-292db11f9ef878061",
+			"ConversationID": "AG_20240122_2010332bae9191b3d522",
+			"ResponseCode": "0",
+			"ResponseDescription": "Accept the service request successfully."
+		}`
+	})
+
+	require.NoError(t, watcher.Poll(context.Background()))
+
+	_, ok := watcher.Latest()
+	require.False(t, ok)
+
+	_, err := watcher.HandleResult(testBalanceCallback("Utility Account|KES|20000.00|20000.00|0.00|0.00"))
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+
+	_, err = watcher.HandleResult(testBalanceCallback("Utility Account|KES|9000.00|9000.00|0.00|0.00"))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	require.True(t, alerts[0].Below)
+	require.Equal(t, 9000.0, alerts[0].Account.Available)
+
+	_, err = watcher.HandleResult(testBalanceCallback("Utility Account|KES|8000.00|8000.00|0.00|0.00"))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	snapshot, err := watcher.HandleResult(testBalanceCallback("Utility Account|KES|15000.00|15000.00|0.00|0.00"))
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	require.False(t, alerts[1].Below)
+
+	latest, ok := watcher.Latest()
+	require.True(t, ok)
+	require.Equal(t, snapshot, latest)
+	require.Equal(t, 600426, latest.ShortCode)
+}
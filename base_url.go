@@ -0,0 +1,50 @@
+package mpesa
+
+import "strings"
+
+// WithBaseURL sends the API requests to baseURL instead of the Daraja URL of the app's environment, e.g. a corporate
+// proxy or a simulator. The endpoint paths are appended to it.
+func WithBaseURL(baseURL string) Option {
+	return func(m *Mpesa) {
+		m.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithEndpointURL sends the requests made to the endpoint to rawURL. It takes precedence over WithBaseURL.
+func WithEndpointURL(endpoint Endpoint, rawURL string) Option {
+	return func(m *Mpesa) {
+		if m.endpointURLs == nil {
+			m.endpointURLs = make(map[Endpoint]string)
+		}
+		m.endpointURLs[endpoint] = rawURL
+	}
+}
+
+// WithAPIVersion sends the requests made to the endpoint to its version of the API, e.g. "v2" for
+// /mpesa/c2b/v2/registerurl, instead of v1. The request and response bodies are not changed.
+func WithAPIVersion(endpoint Endpoint, version string) Option {
+	return func(m *Mpesa) {
+		if m.apiVersions == nil {
+			m.apiVersions = make(map[Endpoint]string)
+		}
+		m.apiVersions[endpoint] = version
+	}
+}
+
+// endpointURL returns the URL the requests made to the endpoint are sent to.
+func (m *Mpesa) endpointURL(endpoint Endpoint) string {
+	if rawURL, ok := m.endpointURLs[endpoint]; ok {
+		return rawURL
+	}
+
+	path := endpoint.Path()
+	if version, ok := m.apiVersions[endpoint]; ok {
+		path = strings.Replace(path, "/v1/", "/"+version+"/", 1)
+	}
+
+	if m.baseURL != "" {
+		return m.baseURL + path
+	}
+
+	return m.Environment().BaseURL() + path
+}
@@ -0,0 +1,71 @@
+package mpesa
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBaseURL(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = newMockHttpClient()
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentProduction,
+			WithBaseURL("https://proxy.example.com/daraja/"),
+			WithEndpointURL(EndpointSTKQuery, "https://simulator.example.com/stkquery"),
+		)
+	)
+
+	require.Equal(t, "https://proxy.example.com/daraja/oauth/v1/generate?grant_type=client_credentials",
+		app.endpointAuth())
+	require.Equal(t, "https://proxy.example.com/daraja/mpesa/stkpush/v1/processrequest", app.endpointSTK())
+	require.Equal(t, "https://simulator.example.com/stkquery", app.endpointSTKQuery())
+	require.Equal(t, productionBaseURL+EndpointSTKPush.Path(),
+		NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentProduction).endpointSTK())
+
+	mockAuth(app, cl)
+	cl.MockRequest("https://simulator.example.com/stkquery", func() (status int, body string) {
+		return http.StatusOK, `{"CheckoutRequestID": "ws_CO_260520211133524545", "ResultCode": "0"}`
+	})
+
+	res, err := app.STKQuery(ctx, "passkey", STKQueryRequest{
+		BusinessShortCode: 174379,
+		CheckoutRequestID: "ws_CO_260520211133524545",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "0", res.ResultCode)
+	require.Len(t, cl.requests, 2)
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+		WithAPIVersion(EndpointC2BRegister, "v2"),
+		WithAPIVersion(EndpointB2C, "v3"),
+	)
+
+	require.Equal(t, "https://sandbox.safaricom.co.ke/mpesa/c2b/v2/registerurl", app.endpointC2BRegister())
+	require.Equal(t, "https://sandbox.safaricom.co.ke/mpesa/b2c/v3/paymentrequest", app.endpointB2C())
+	require.Equal(t, "https://sandbox.safaricom.co.ke/mpesa/stkpush/v1/processrequest", app.endpointSTK())
+
+	app = NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+		WithBaseURL("https://proxy.example.com"),
+		WithAPIVersion(EndpointC2BRegister, "v2"),
+	)
+	require.Equal(t, "https://proxy.example.com/mpesa/c2b/v2/registerurl", app.endpointC2BRegister())
+}
+
+func TestWithEndpointURL_sharedPath(t *testing.T) {
+	var (
+		cl  = newMockHttpClient()
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentProduction,
+			WithEndpointURL(EndpointBusinessBuyGoods, "https://simulator.example.com/buygoods"),
+			WithAPIVersion(EndpointBusinessBuyGoods, "v2"),
+		)
+	)
+
+	require.Equal(t, EndpointBusinessBuyGoods.Path(), EndpointBusinessPayBill.Path())
+	require.Equal(t, "https://simulator.example.com/buygoods", app.endpointBusinessBuyGoods())
+	require.Equal(t, productionBaseURL+"/mpesa/b2b/v1/paymentrequest", app.endpointBusinessPayBill())
+}
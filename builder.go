@@ -0,0 +1,171 @@
+package mpesa
+
+type (
+	// STKPushBuilder builds an STKPushRequest, e.g.
+	//
+	//	req, err := mpesa.NewSTKPush().Shortcode(174379).Amount(10).Phone("0712345678").Callback(url).Build()
+	STKPushBuilder struct {
+		req STKPushRequest
+	}
+
+	// B2CBuilder builds a B2CRequest, e.g.
+	//
+	//	req, err := mpesa.NewB2C().Shortcode(600981).Amount(10).Phone("0712345678").
+	//		ResultURLs(resultURL, timeoutURL).Build()
+	B2CBuilder struct {
+		req B2CRequest
+	}
+)
+
+// NewSTKPush returns an empty STKPushBuilder.
+func NewSTKPush() *STKPushBuilder {
+	return &STKPushBuilder{}
+}
+
+// Shortcode sets the BusinessShortCode, the paybill or head office shortcode of a till number.
+func (b *STKPushBuilder) Shortcode(shortCode uint) *STKPushBuilder {
+	b.req.BusinessShortCode = shortCode
+	return b
+}
+
+// Till sets PartyB to the till number paid. It defaults to the BusinessShortCode.
+func (b *STKPushBuilder) Till(till uint) *STKPushBuilder {
+	b.req.PartyB = till
+	return b
+}
+
+// Amount sets the amount in whole shillings.
+func (b *STKPushBuilder) Amount(amount uint) *STKPushBuilder {
+	b.req.Amount = amount
+	return b
+}
+
+// Phone sets the phone number prompted, in any format accepted by ParsePhoneNumber.
+func (b *STKPushBuilder) Phone(phone string) *STKPushBuilder {
+	b.req.Phone = phone
+	return b
+}
+
+// Callback sets the CallBackURL.
+func (b *STKPushBuilder) Callback(url string) *STKPushBuilder {
+	b.req.CallBackURL = url
+	return b
+}
+
+// Reference sets the AccountReference shown to the customer.
+func (b *STKPushBuilder) Reference(reference string) *STKPushBuilder {
+	b.req.AccountReference = reference
+	return b
+}
+
+// Description sets the TransactionDesc. It defaults to "Payment".
+func (b *STKPushBuilder) Description(desc string) *STKPushBuilder {
+	b.req.TransactionDesc = desc
+	return b
+}
+
+// TransactionType sets the TransactionType. It defaults to CustomerBuyGoodsOnline when a till number different from
+// the BusinessShortCode is set and to CustomerPayBillOnline otherwise.
+func (b *STKPushBuilder) TransactionType(t TransactionType) *STKPushBuilder {
+	b.req.TransactionType = t
+	return b
+}
+
+// Build applies the defaults, normalizes the phone number and returns the request. It returns a *ValidationError if
+// the request would be rejected by Daraja.
+func (b *STKPushBuilder) Build() (STKPushRequest, error) {
+	req := b.req
+
+	if req.PartyB == 0 {
+		req.PartyB = req.BusinessShortCode
+	}
+
+	if req.TransactionType == "" {
+		req.TransactionType = stkTransactionType(req.BusinessShortCode, req.PartyB)
+	}
+	setDefault(&req.TransactionDesc, "Payment")
+
+	if err := req.resolvePhone(); err != nil {
+		return STKPushRequest{}, err
+	}
+
+	if err := validate(req); err != nil {
+		return STKPushRequest{}, err
+	}
+
+	return req, nil
+}
+
+// NewB2C returns an empty B2CBuilder.
+func NewB2C() *B2CBuilder {
+	return &B2CBuilder{}
+}
+
+// Shortcode sets PartyA, the shortcode paying.
+func (b *B2CBuilder) Shortcode(shortCode uint) *B2CBuilder {
+	b.req.PartyA = shortCode
+	return b
+}
+
+// Initiator sets the InitiatorName.
+func (b *B2CBuilder) Initiator(name string) *B2CBuilder {
+	b.req.InitiatorName = name
+	return b
+}
+
+// Command sets the CommandID. It defaults to BusinessPaymentCommandID.
+func (b *B2CBuilder) Command(commandID CommandID) *B2CBuilder {
+	b.req.CommandID = commandID
+	return b
+}
+
+// Amount sets the amount in whole shillings.
+func (b *B2CBuilder) Amount(amount uint) *B2CBuilder {
+	b.req.Amount = amount
+	return b
+}
+
+// Phone sets the phone number paid, in any format accepted by ParsePhoneNumber.
+func (b *B2CBuilder) Phone(phone string) *B2CBuilder {
+	b.req.Phone = phone
+	return b
+}
+
+// Remarks sets the Remarks sent along with the payment.
+func (b *B2CBuilder) Remarks(remarks string) *B2CBuilder {
+	b.req.Remarks = remarks
+	return b
+}
+
+// Occasion sets the Occasion sent along with the payment.
+func (b *B2CBuilder) Occasion(occasion string) *B2CBuilder {
+	b.req.Occasion = occasion
+	return b
+}
+
+// ResultURLs sets the ResultURL and the QueueTimeOutURL.
+func (b *B2CBuilder) ResultURLs(resultURL, queueTimeOutURL string) *B2CBuilder {
+	b.req.ResultURL = resultURL
+	b.req.QueueTimeOutURL = queueTimeOutURL
+	return b
+}
+
+// Build applies the defaults, normalizes the phone number and returns the request. It returns a *ValidationError if
+// the request would be rejected by Daraja.
+func (b *B2CBuilder) Build() (B2CRequest, error) {
+	req := b.req
+
+	if req.CommandID == "" {
+		req.CommandID = BusinessPaymentCommandID
+	}
+
+	if err := req.resolvePhone(); err != nil {
+		return B2CRequest{}, err
+	}
+
+	if err := validate(req); err != nil {
+		return B2CRequest{}, err
+	}
+
+	return req, nil
+}
@@ -0,0 +1,89 @@
+package mpesa
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSTKPushBuilder(t *testing.T) {
+	t.Run("it builds a pay bill request", func(t *testing.T) {
+		req, err := NewSTKPush().Shortcode(174379).Amount(10).Phone("0712345678").
+			Callback("https://example.com/stk").Reference("INV-001").Build()
+		require.NoError(t, err)
+
+		require.Equal(t, STKPushRequest{
+			BusinessShortCode: 174379,
+			TransactionType:   CustomerPayBillOnlineTransactionType,
+			Amount:            10,
+			PartyA:            254712345678,
+			PartyB:            174379,
+			PhoneNumber:       254712345678,
+			Phone:             "0712345678",
+			CallBackURL:       "https://example.com/stk",
+			AccountReference:  "INV-001",
+			TransactionDesc:   "Payment",
+		}, req)
+	})
+
+	t.Run("it builds a buy goods request for a till number", func(t *testing.T) {
+		req, err := NewSTKPush().Shortcode(174379).Till(5678901).Amount(10).Phone("+254712345678").
+			Callback("https://example.com/stk").Reference("42").Description("Order 42").Build()
+		require.NoError(t, err)
+		require.EqualValues(t, CustomerBuyGoodsOnlineTransactionType, req.TransactionType)
+		require.Equal(t, uint(5678901), req.PartyB)
+		require.Equal(t, "Order 42", req.TransactionDesc)
+	})
+
+	t.Run("it returns the invalid fields", func(t *testing.T) {
+		_, err := NewSTKPush().Shortcode(174379).Phone("0712345678").Callback("http://example.com").Build()
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.ErrorContains(t, err, "Amount")
+		require.ErrorContains(t, err, "CallBackURL")
+	})
+
+	t.Run("it rejects an invalid phone number", func(t *testing.T) {
+		_, err := NewSTKPush().Shortcode(174379).Amount(10).Phone("12345").Callback("https://example.com").Build()
+		require.ErrorContains(t, err, "Phone")
+	})
+}
+
+func TestB2CBuilder(t *testing.T) {
+	t.Run("it builds a business payment", func(t *testing.T) {
+		req, err := NewB2C().Shortcode(600981).Initiator("testapi").Amount(100).Phone("0712 345 678").
+			Remarks("Refund").ResultURLs("https://example.com/result", "https://example.com/timeout").Build()
+		require.NoError(t, err)
+
+		require.Equal(t, B2CRequest{
+			InitiatorName:   "testapi",
+			CommandID:       BusinessPaymentCommandID,
+			Amount:          100,
+			PartyA:          600981,
+			PartyB:          254712345678,
+			Phone:           "0712 345 678",
+			Remarks:         "Refund",
+			QueueTimeOutURL: "https://example.com/timeout",
+			ResultURL:       "https://example.com/result",
+		}, req)
+	})
+
+	t.Run("it keeps the command", func(t *testing.T) {
+		req, err := NewB2C().Shortcode(600981).Command(SalaryPaymentCommandID).Amount(100).Phone("0712345678").
+			Occasion("March").ResultURLs("https://example.com/result", "https://example.com/timeout").Build()
+		require.NoError(t, err)
+		require.Equal(t, SalaryPaymentCommandID, req.CommandID)
+		require.Equal(t, "March", req.Occasion)
+	})
+
+	t.Run("it returns the invalid fields", func(t *testing.T) {
+		_, err := NewB2C().Amount(100).Phone("0712345678").Build()
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		require.ErrorContains(t, err, "PartyA")
+		require.ErrorContains(t, err, "ResultURL")
+	})
+}
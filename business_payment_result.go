@@ -0,0 +1,183 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidBusinessPaymentResult is returned when a Business Pay Bill or Business Buy Goods result cannot be parsed.
+var ErrInvalidBusinessPaymentResult = errors.New("mpesa: invalid business payment result")
+
+// BusinessPaymentResult is the result of a Business Pay Bill or Business Buy Goods payment.
+type BusinessPaymentResult struct {
+	// ConversationID is the ConversationID returned on the Response of the request.
+	ConversationID string
+
+	// OriginatorConversationID is the OriginatorConversationID of the request.
+	OriginatorConversationID string
+
+	// TransactionID is the M-Pesa receipt number of the payment.
+	TransactionID string
+
+	// Amount is the amount paid.
+	Amount Money
+
+	// Charges are the charges of the payment. They are zero when the payment was free.
+	Charges Money
+
+	// DebitAccountBalance is the balance of the account debited after the payment. It is zero if M-Pesa did not
+	// send it.
+	DebitAccountBalance Money
+
+	// InitiatorAccountCurrentBalance is the balance of the account of the initiator after the payment. It is zero if
+	// M-Pesa did not send it.
+	InitiatorAccountCurrentBalance Money
+
+	// DebitPartyAffectedAccountBalance are the balances of the debited account after the payment.
+	DebitPartyAffectedAccountBalance []AccountBalance
+
+	// ReceiverPartyPublicName is the shortcode and name of the business paid, e.g. "000000 - Biller Company".
+	ReceiverPartyPublicName string
+
+	// CompletedAt is the time the payment was completed. It is zero if the parameter is missing.
+	CompletedAt time.Time
+}
+
+// BusinessPaymentResultFromCallback parses the result callback of a Business Pay Bill or Business Buy Goods payment.
+// It fails if the payment failed or a parameter cannot be parsed.
+func BusinessPaymentResultFromCallback(callback *Callback) (*BusinessPaymentResult, error) {
+	if callback.Result.ResultCode != 0 {
+		return nil, fmt.Errorf("mpesa: business payment failed: %d - %s",
+			callback.Result.ResultCode, callback.Result.ResultDesc,
+		)
+	}
+
+	params := callback.Result.ResultParameters
+
+	currency, ok := params.Currency()
+	if !ok {
+		currency = DefaultCurrency
+	}
+
+	amount, ok := params.String(ResultParameterAmount)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing Amount parameter", ErrInvalidBusinessPaymentResult)
+	}
+
+	result := &BusinessPaymentResult{
+		ConversationID:           callback.Result.ConversationID,
+		OriginatorConversationID: callback.Result.OriginatorConversationID,
+		TransactionID:            callback.Result.TransactionID,
+	}
+
+	var err error
+	if result.Amount, err = ParseMoney(amount, currency); err != nil {
+		return nil, fmt.Errorf("%w: Amount: %v", ErrInvalidBusinessPaymentResult, err)
+	}
+
+	if charges, ok := params.DebitPartyCharges(); ok {
+		if result.Charges, err = ParseDebitPartyCharges(charges); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, balance := range map[string]*Money{
+		ResultParameterDebitAccountBalance:            &result.DebitAccountBalance,
+		ResultParameterInitiatorAccountCurrentBalance: &result.InitiatorAccountCurrentBalance,
+	} {
+		s, ok := params.String(key)
+		if !ok {
+			continue
+		}
+
+		if *balance, err = ParseResultAmount(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if s, ok := params.String(ResultParameterDebitPartyAffectedAccountBalance); ok && strings.TrimSpace(s) != "" {
+		if result.DebitPartyAffectedAccountBalance, err = ParseAccountBalances(s); err != nil {
+			return nil, err
+		}
+	}
+
+	result.ReceiverPartyPublicName, _ = params.ReceiverPartyPublicName()
+	result.CompletedAt, _ = params.TransCompletedTime()
+
+	return result, nil
+}
+
+// ParseResultAmount parses the balances sent on Business Pay Bill and Business Buy Goods results, which are
+// formatted as
+//
+//	{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}
+//
+// MinimumAmount is the amount in minor units and is preferred over BasicAmount when both are sent.
+func ParseResultAmount(s string) (Money, error) {
+	fields := make(map[string]string)
+
+	body := strings.NewReplacer("{", "", "}", "").Replace(strings.TrimSpace(s))
+	body = strings.TrimPrefix(body, "Amount=")
+
+	for _, field := range strings.Split(body, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidBusinessPaymentResult, s)
+		}
+
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+
+	currency, err := ParseCurrency(fields["CurrencyCode"])
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %v", ErrInvalidBusinessPaymentResult, s, err)
+	}
+
+	if minimum, ok := fields["MinimumAmount"]; ok {
+		minor, err := strconv.ParseInt(minimum, 10, 64)
+		if err != nil {
+			return Money{}, fmt.Errorf("%w: %q: %v", ErrInvalidBusinessPaymentResult, s, err)
+		}
+
+		return NewMoney(currency, minor), nil
+	}
+
+	amount, err := ParseMoney(fields["BasicAmount"], currency)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %v", ErrInvalidBusinessPaymentResult, s, err)
+	}
+
+	return amount, nil
+}
+
+// ParseDebitPartyCharges parses the DebitPartyCharges result parameter, which is formatted as
+//
+//	Business Buy Goods Charge|KES|77.00
+//
+// It returns zero Money in the DefaultCurrency when the parameter is empty, i.e. when the payment was free.
+func ParseDebitPartyCharges(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return NewMoney(DefaultCurrency, 0), nil
+	}
+
+	fields := strings.Split(s, "|")
+	if len(fields) != 3 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidBusinessPaymentResult, s)
+	}
+
+	currency, err := ParseCurrency(fields[1])
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %v", ErrInvalidBusinessPaymentResult, s, err)
+	}
+
+	charges, err := ParseMoney(fields[2], currency)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q: %v", ErrInvalidBusinessPaymentResult, s, err)
+	}
+
+	return charges, nil
+}
@@ -0,0 +1,110 @@
+package mpesa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBusinessPaymentResultFromCallback(t *testing.T) {
+	callback, err := UnmarshalCallback(strings.NewReader(`{
+		"Result": {
+			"ResultType": "0",
+			"ResultCode": 0,
+			"ResultDesc": "The service request is processed successfully",
+			"OriginatorConversationID": "626f6ddf-ab37-4650-b882-b1de92ec9aa4",
+			"ConversationID": "12345677dfdf89099B3",
+			"TransactionID": "QKA81LK5CY",
+			"ResultParameters": {
+				"ResultParameter": [
+					{"Key": "DebitAccountBalance", "Value": "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}"},
+					{"Key": "Amount", "Value": "190.00"},
+					{"Key": "DebitPartyAffectedAccountBalance", "Value": "Working Account|KES|346768.00|346768.00|0.00|0.00"},
+					{"Key": "TransCompletedTime", "Value": "20221110110717"},
+					{"Key": "DebitPartyCharges", "Value": "Business Buy Goods Charge|KES|77.00"},
+					{"Key": "ReceiverPartyPublicName", "Value": "000000 - Biller Company"},
+					{"Key": "Currency", "Value": "KES"},
+					{"Key": "InitiatorAccountCurrentBalance", "Value": "{Amount={CurrencyCode=KES, BasicAmount=6186.83}}"}
+				]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	result, err := BusinessPaymentResultFromCallback(callback)
+	require.NoError(t, err)
+
+	require.Equal(t, &BusinessPaymentResult{
+		ConversationID:                 "12345677dfdf89099B3",
+		OriginatorConversationID:       "626f6ddf-ab37-4650-b882-b1de92ec9aa4",
+		TransactionID:                  "QKA81LK5CY",
+		Amount:                         Shillings(190),
+		Charges:                        Shillings(77),
+		DebitAccountBalance:            NewMoney(CurrencyKES, 618683),
+		InitiatorAccountCurrentBalance: NewMoney(CurrencyKES, 618683),
+		DebitPartyAffectedAccountBalance: []AccountBalance{
+			{Name: WorkingAccount, Currency: CurrencyKES, Current: 346768, Available: 346768},
+		},
+		ReceiverPartyPublicName: "000000 - Biller Company",
+		CompletedAt:             time.Date(2022, time.November, 10, 11, 7, 17, 0, eastAfricaTime),
+	}, result)
+
+	t.Run("it fails for a failed payment", func(t *testing.T) {
+		_, err := BusinessPaymentResultFromCallback(&Callback{Result: CallbackResult{ResultCode: 2001, ResultDesc: "The initiator information is invalid."}})
+		require.EqualError(t, err, "mpesa: business payment failed: 2001 - The initiator information is invalid.")
+	})
+
+	t.Run("it fails for a malformed parameter", func(t *testing.T) {
+		_, err := BusinessPaymentResultFromCallback(&Callback{})
+		require.ErrorIs(t, err, ErrInvalidBusinessPaymentResult)
+
+		_, err = BusinessPaymentResultFromCallback(&Callback{Result: CallbackResult{
+			ResultParameters: ResultParameters{ResultParameter: []ResultParameter{
+				{Key: ResultParameterAmount, Value: "190.00"},
+				{Key: ResultParameterDebitAccountBalance, Value: "{Amount={CurrencyCode=KES, BasicAmount=lots}}"},
+			}},
+		}})
+		require.ErrorIs(t, err, ErrInvalidBusinessPaymentResult)
+	})
+}
+
+func TestParseResultAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "{Amount={CurrencyCode=KES, MinimumAmount=618683, BasicAmount=6186.83}}", want: NewMoney(CurrencyKES, 618683)},
+		{in: "{Amount={CurrencyCode=KES, BasicAmount=6186.83}}", want: NewMoney(CurrencyKES, 618683)},
+		{in: "{Amount={BasicAmount=6186.83, CurrencyCode=USD}}", want: NewMoney("USD", 618683)},
+		{in: "{Amount={CurrencyCode=KES}}", wantErr: true},
+		{in: "{Amount={BasicAmount=10}}", wantErr: true},
+		{in: "6186.83", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseResultAmount(tc.in)
+		if tc.wantErr {
+			require.ErrorIs(t, err, ErrInvalidBusinessPaymentResult, tc.in)
+			continue
+		}
+
+		require.NoError(t, err, tc.in)
+		require.Equal(t, tc.want, got, tc.in)
+	}
+}
+
+func TestParseDebitPartyCharges(t *testing.T) {
+	charges, err := ParseDebitPartyCharges("Business Pay Bill Charge|KES|77.00")
+	require.NoError(t, err)
+	require.Equal(t, Shillings(77), charges)
+
+	charges, err = ParseDebitPartyCharges("")
+	require.NoError(t, err)
+	require.Zero(t, charges.Minor)
+
+	_, err = ParseDebitPartyCharges("77.00")
+	require.ErrorIs(t, err, ErrInvalidBusinessPaymentResult)
+}
@@ -0,0 +1,142 @@
+package mpesa
+
+import (
+	"fmt"
+	"io"
+)
+
+// Result codes a C2B validation request can be rejected with.
+const (
+	C2BRejectInvalidMSISDN        = "C2B00011"
+	C2BRejectInvalidAccountNumber = "C2B00012"
+	C2BRejectInvalidAmount        = "C2B00013"
+	C2BRejectInvalidKYCDetails    = "C2B00014"
+	C2BRejectInvalidShortcode     = "C2B00015"
+	C2BRejectOtherError           = "C2B00016"
+)
+
+// C2BAccepted is the result code of an accepted C2B validation request.
+const C2BAccepted = "0"
+
+// c2bRejectionDescs are the documented descriptions of the C2BReject codes.
+var c2bRejectionDescs = map[string]string{
+	C2BRejectInvalidMSISDN:        "Invalid MSISDN",
+	C2BRejectInvalidAccountNumber: "Invalid Account Number",
+	C2BRejectInvalidAmount:        "Invalid Amount",
+	C2BRejectInvalidKYCDetails:    "Invalid KYC Details",
+	C2BRejectInvalidShortcode:     "Invalid Shortcode",
+	C2BRejectOtherError:           "Other Error",
+}
+
+type (
+	// C2BCallback is the payment sent to the ValidationURL and ConfirmationURL registered with RegisterC2BURL.
+	C2BCallback struct {
+		// TransactionType is the type of the payment, e.g. Pay Bill or Buy Goods.
+		TransactionType string `json:"TransactionType"`
+
+		// TransID is the M-Pesa receipt number of the payment.
+		TransID string `json:"TransID"`
+
+		// TransTime is the time of the payment in the format YYYYMMDDHHmmss.
+		TransTime string `json:"TransTime"`
+
+		// TransAmount is the amount paid, e.g. "10.00".
+		TransAmount string `json:"TransAmount"`
+
+		// BusinessShortCode is the shortcode that received the payment.
+		BusinessShortCode string `json:"BusinessShortCode"`
+
+		// BillRefNumber is the account number entered by the customer for Pay Bill payments.
+		BillRefNumber string `json:"BillRefNumber"`
+
+		// InvoiceNumber is the invoice number of the payment, if any.
+		InvoiceNumber string `json:"InvoiceNumber"`
+
+		// OrgAccountBalance is the balance of the shortcode after the payment. It is empty on validation requests.
+		OrgAccountBalance string `json:"OrgAccountBalance"`
+
+		// ThirdPartyTransID is an optional ID that can be set in the validation response and is echoed on the
+		// confirmation.
+		ThirdPartyTransID string `json:"ThirdPartyTransID"`
+
+		// MSISDN is the phone number of the customer. It may be masked or hashed.
+		MSISDN string `json:"MSISDN"`
+
+		// FirstName, MiddleName and LastName are the names of the customer.
+		FirstName  string `json:"FirstName"`
+		MiddleName string `json:"MiddleName"`
+		LastName   string `json:"LastName"`
+	}
+
+	// C2BValidationRequest is the payment sent to the ValidationURL before it is completed. It must be answered with
+	// a C2BValidationResponse built with AcceptC2BPayment or RejectC2BPayment.
+	C2BValidationRequest = C2BCallback
+
+	// C2BConfirmationRequest is the payment sent to the ConfirmationURL once it is completed.
+	C2BConfirmationRequest = C2BCallback
+
+	// C2BValidationResponse is the response to a C2B validation request. ResultCode is "0" to accept the payment or
+	// one of the C2BReject codes to reject it.
+	C2BValidationResponse struct {
+		ResultCode        string `json:"ResultCode"`
+		ResultDesc        string `json:"ResultDesc"`
+		ThirdPartyTransID string `json:"ThirdPartyTransID,omitempty"`
+	}
+
+	// C2BRejection is returned by a C2B validation function to reject the payment with a specific result code.
+	C2BRejection struct {
+		// Code is one of the C2BReject codes. Defaults to C2BRejectOtherError.
+		Code string
+
+		// Desc describes why the payment was rejected. Defaults to "Rejected".
+		Desc string
+	}
+)
+
+func (e *C2BRejection) Error() string {
+	return fmt.Sprintf("mpesa: c2b payment rejected: %s %s", e.Code, e.Desc)
+}
+
+// UnmarshalC2BCallback decodes the provided value to C2BCallback.
+func UnmarshalC2BCallback(r io.Reader) (*C2BCallback, error) {
+	return decode[C2BCallback](r, false)
+}
+
+// UnmarshalC2BValidation decodes the provided value to C2BValidationRequest.
+func UnmarshalC2BValidation(r io.Reader) (*C2BValidationRequest, error) {
+	return UnmarshalC2BCallback(r)
+}
+
+// UnmarshalC2BConfirmation decodes the provided value to C2BConfirmationRequest.
+func UnmarshalC2BConfirmation(r io.Reader) (*C2BConfirmationRequest, error) {
+	return UnmarshalC2BCallback(r)
+}
+
+// AcceptC2BPayment returns the response that accepts a C2B payment. The optional thirdPartyTransID is echoed on the
+// confirmation request.
+func AcceptC2BPayment(thirdPartyTransID string) C2BValidationResponse {
+	return C2BValidationResponse{
+		ResultCode:        C2BAccepted,
+		ResultDesc:        "Accepted",
+		ThirdPartyTransID: thirdPartyTransID,
+	}
+}
+
+// RejectC2BPayment returns the response that rejects a C2B payment with one of the C2BReject codes. The code defaults
+// to C2BRejectOtherError and the desc to "Rejected".
+func RejectC2BPayment(code, desc string) C2BValidationResponse {
+	if code == "" || code == C2BAccepted {
+		code = C2BRejectOtherError
+	}
+
+	if desc == "" {
+		desc = "Rejected"
+	}
+
+	return C2BValidationResponse{ResultCode: code, ResultDesc: desc}
+}
+
+// Accepted returns true if the response accepts the payment.
+func (r C2BValidationResponse) Accepted() bool {
+	return r.ResultCode == C2BAccepted
+}
@@ -0,0 +1,75 @@
+package mpesa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalC2BValidation(t *testing.T) {
+	const body = `{
+		"TransactionType": "Pay Bill",
+		"TransID": "RKTQDM7W6S",
+		"TransTime": "20191122063845",
+		"TransAmount": "10",
+		"BusinessShortCode": "600638",
+		"BillRefNumber": "invoice008",
+		"OrgAccountBalance": "49197.00",
+		"MSISDN": "25470****149",
+		"FirstName": "John"
+	}`
+
+	req, err := UnmarshalC2BValidation(strings.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, "RKTQDM7W6S", req.TransID)
+	require.Equal(t, "10", req.TransAmount)
+	require.Equal(t, "invoice008", req.BillRefNumber)
+	require.Equal(t, "25470****149", req.MSISDN)
+
+	confirmation, err := UnmarshalC2BConfirmation(strings.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, "49197.00", confirmation.OrgAccountBalance)
+
+	_, err = UnmarshalC2BValidation(strings.NewReader(`{"TransID":`))
+	require.Error(t, err)
+}
+
+func TestC2BValidationResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		res      C2BValidationResponse
+		want     C2BValidationResponse
+		accepted bool
+	}{
+		{
+			name:     "it accepts the payment",
+			res:      AcceptC2BPayment("1234567890"),
+			want:     C2BValidationResponse{ResultCode: "0", ResultDesc: "Accepted", ThirdPartyTransID: "1234567890"},
+			accepted: true,
+		},
+		{
+			name: "it rejects the payment with the code",
+			res:  RejectC2BPayment(C2BRejectInvalidAccountNumber, "Invalid Account Number"),
+			want: C2BValidationResponse{ResultCode: "C2B00012", ResultDesc: "Invalid Account Number"},
+		},
+		{
+			name: "it defaults the rejection code and description",
+			res:  RejectC2BPayment("", ""),
+			want: C2BValidationResponse{ResultCode: "C2B00016", ResultDesc: "Rejected"},
+		},
+		{
+			name: "it does not reject with the accepted code",
+			res:  RejectC2BPayment("0", "Invalid Amount"),
+			want: C2BValidationResponse{ResultCode: "C2B00016", ResultDesc: "Invalid Amount"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.res)
+			require.Equal(t, tc.accepted, tc.res.Accepted())
+		})
+	}
+}
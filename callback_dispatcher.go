@@ -0,0 +1,223 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrSTKPushAborted is returned by AwaitSTKPush when the STK push is cancelled with CancelSTKPush.
+var ErrSTKPushAborted = errors.New("mpesa: stk push aborted")
+
+// unclaimedCallbackTTL is how long a callback that arrived before anyone awaited it is kept.
+const unclaimedCallbackTTL = 10 * time.Minute
+
+// CallbackDispatcher correlates the asynchronous callbacks with the requests that caused them, so that a server can
+// await the result of an STK push or a B2C request:
+//
+//	mux.Handle("/mpesa/stk", dispatcher.STKPushHandler())
+//	res, err := app.STKPush(ctx, passkey, req)
+//	callback, err := dispatcher.AwaitSTKPush(ctx, res.CheckoutRequestID)
+//
+// Callbacks that arrive before they are awaited are kept for 10 minutes, so the result is not missed when the callback
+// is faster than the response of the request.
+type CallbackDispatcher struct {
+	// StateMachine, if set, marks the transactions of the STK pushes cancelled with CancelSTKPush as aborted.
+	StateMachine *TransactionStateMachine
+
+	stk     *callbackRegistry[STKPushCallback]
+	results *callbackRegistry[Callback]
+}
+
+// NewCallbackDispatcher creates an empty CallbackDispatcher.
+func NewCallbackDispatcher() *CallbackDispatcher {
+	return &CallbackDispatcher{
+		stk:     newCallbackRegistry[STKPushCallback](),
+		results: newCallbackRegistry[Callback](),
+	}
+}
+
+// STKPushHandler returns a http.Handler for the CallBackURL of STK push requests that dispatches the callbacks.
+func (d *CallbackDispatcher) STKPushHandler() http.Handler {
+	return STKPushCallbackHandler(func(_ context.Context, callback *STKPushCallback) error {
+		d.DispatchSTKPush(callback)
+		return nil
+	})
+}
+
+// ResultHandler returns a http.Handler for the ResultURL and QueueTimeOutURL of B2C, reversal, transaction status,
+// account balance and business payment requests that dispatches the callbacks.
+func (d *CallbackDispatcher) ResultHandler() http.Handler {
+	return ResultCallbackHandler(func(_ context.Context, callback *Callback) error {
+		d.DispatchResult(callback)
+		return nil
+	})
+}
+
+// DispatchSTKPush resolves the requests awaiting the callback by its CheckoutRequestID. It returns false if none was
+// awaiting it, in which case the callback is kept for a later AwaitSTKPush.
+func (d *CallbackDispatcher) DispatchSTKPush(callback *STKPushCallback) bool {
+	return d.stk.dispatch(callback.Body.STKCallback.CheckoutRequestID, callback)
+}
+
+// DispatchResult resolves the requests awaiting the callback by its ConversationID. It returns false if none was
+// awaiting it, in which case the callback is kept for a later AwaitResult.
+func (d *CallbackDispatcher) DispatchResult(callback *Callback) bool {
+	return d.results.dispatch(callback.Result.ConversationID, callback)
+}
+
+// AwaitSTKPush blocks until the callback of the STK push with the checkoutRequestID is dispatched or ctx is done.
+func (d *CallbackDispatcher) AwaitSTKPush(ctx context.Context, checkoutRequestID string) (*STKPushCallback, error) {
+	return d.stk.await(ctx, checkoutRequestID)
+}
+
+// AwaitResult blocks until the callback of the request with the conversationID is dispatched or ctx is done.
+func (d *CallbackDispatcher) AwaitResult(ctx context.Context, conversationID string) (*Callback, error) {
+	return d.results.await(ctx, conversationID)
+}
+
+// CancelSTKPush stops awaiting the callback of the STK push with the checkoutRequestID: AwaitSTKPush returns
+// ErrSTKPushAborted and the transaction, if StateMachine is set, is marked as aborted. Daraja cannot withdraw the
+// prompt, so the customer may still complete the payment and its callback is dispatched as usual.
+func (d *CallbackDispatcher) CancelSTKPush(ctx context.Context, checkoutRequestID string) error {
+	d.stk.cancel(checkoutRequestID, ErrSTKPushAborted)
+
+	if d.StateMachine == nil {
+		return nil
+	}
+
+	_, err := d.StateMachine.Abort(ctx, checkoutRequestID)
+	return err
+}
+
+// STKPush sends the STK push using app and waits for its callback.
+func (d *CallbackDispatcher) STKPush(
+	ctx context.Context, app Client, passkey string, req STKPushRequest,
+) (*STKPushResponse, *STKPushCallback, error) {
+	res, err := app.STKPush(ctx, passkey, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	callback, err := d.AwaitSTKPush(ctx, res.CheckoutRequestID)
+	return res, callback, err
+}
+
+// B2C sends the B2C payment using app and waits for its result.
+func (d *CallbackDispatcher) B2C(
+	ctx context.Context, app Client, initiatorPwd string, req B2CRequest,
+) (*B2CResponse, *Callback, error) {
+	res, err := app.B2C(ctx, initiatorPwd, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	callback, err := d.AwaitResult(ctx, res.ConversationID)
+	return res, callback, err
+}
+
+// callbackRegistry holds the callbacks of type T awaited or received, keyed by the ID of their request.
+type callbackRegistry[T any] struct {
+	mu        sync.Mutex
+	waiters   map[string][]chan callbackResult[T]
+	unclaimed map[string]unclaimedCallback[T]
+	now       func() time.Time
+}
+
+// callbackResult is the callback, or the error, a waiter is resolved with.
+type callbackResult[T any] struct {
+	callback *T
+	err      error
+}
+
+type unclaimedCallback[T any] struct {
+	callback   *T
+	receivedAt time.Time
+}
+
+func newCallbackRegistry[T any]() *callbackRegistry[T] {
+	return &callbackRegistry[T]{
+		waiters:   make(map[string][]chan callbackResult[T]),
+		unclaimed: make(map[string]unclaimedCallback[T]),
+		now:       time.Now,
+	}
+}
+
+func (r *callbackRegistry[T]) dispatch(id string, callback *T) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for key, c := range r.unclaimed {
+		if now.Sub(c.receivedAt) > unclaimedCallbackTTL {
+			delete(r.unclaimed, key)
+		}
+	}
+
+	waiters, ok := r.waiters[id]
+	if !ok {
+		r.unclaimed[id] = unclaimedCallback[T]{callback: callback, receivedAt: now}
+		return false
+	}
+
+	delete(r.waiters, id)
+	for _, ch := range waiters {
+		ch <- callbackResult[T]{callback: callback}
+	}
+
+	return true
+}
+
+// cancel resolves the waiters of id with err.
+func (r *callbackRegistry[T]) cancel(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, ch := range r.waiters[id] {
+		ch <- callbackResult[T]{err: err}
+	}
+	delete(r.waiters, id)
+}
+
+func (r *callbackRegistry[T]) await(ctx context.Context, id string) (*T, error) {
+	r.mu.Lock()
+	if c, ok := r.unclaimed[id]; ok {
+		delete(r.unclaimed, id)
+		r.mu.Unlock()
+		return c.callback, nil
+	}
+
+	ch := make(chan callbackResult[T], 1)
+	r.waiters[id] = append(r.waiters[id], ch)
+	r.mu.Unlock()
+
+	select {
+	case res := <-ch:
+		return res.callback, res.err
+	case <-ctx.Done():
+		r.remove(id, ch)
+		return nil, ctx.Err()
+	}
+}
+
+// remove stops ch from awaiting the callback of id.
+func (r *callbackRegistry[T]) remove(id string, ch chan callbackResult[T]) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	waiters := r.waiters[id]
+	for i, waiter := range waiters {
+		if waiter == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+
+	if len(waiters) == 0 {
+		delete(r.waiters, id)
+		return
+	}
+	r.waiters[id] = waiters
+}
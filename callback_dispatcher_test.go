@@ -0,0 +1,142 @@
+package mpesa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	stkCallback := func(checkoutRequestID string) *STKPushCallback {
+		return &STKPushCallback{Body: STKPushCallbackBody{STKCallback: STKCallback{
+			CheckoutRequestID: checkoutRequestID,
+			ResultCode:        1032,
+		}}}
+	}
+
+	t.Run("it resolves the awaiting requests", func(t *testing.T) {
+		d := NewCallbackDispatcher()
+
+		results := make(chan *STKPushCallback, 2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				callback, err := d.AwaitSTKPush(ctx, "ws_CO_1")
+				require.NoError(t, err)
+				results <- callback
+			}()
+		}
+
+		require.Eventually(t, func() bool {
+			d.stk.mu.Lock()
+			defer d.stk.mu.Unlock()
+			return len(d.stk.waiters["ws_CO_1"]) == 2
+		}, time.Second, time.Millisecond)
+
+		require.True(t, d.DispatchSTKPush(stkCallback("ws_CO_1")))
+		require.Equal(t, "ws_CO_1", (<-results).Body.STKCallback.CheckoutRequestID)
+		require.Equal(t, "ws_CO_1", (<-results).Body.STKCallback.CheckoutRequestID)
+		require.Empty(t, d.stk.waiters)
+	})
+
+	t.Run("it keeps the callbacks received before they are awaited", func(t *testing.T) {
+		d := NewCallbackDispatcher()
+		now := time.Now()
+		d.stk.now = func() time.Time { return now }
+
+		require.False(t, d.DispatchSTKPush(stkCallback("ws_CO_1")))
+
+		callback, err := d.AwaitSTKPush(ctx, "ws_CO_1")
+		require.NoError(t, err)
+		require.Equal(t, 1032, callback.Body.STKCallback.ResultCode)
+
+		require.False(t, d.DispatchSTKPush(stkCallback("ws_CO_2")))
+		now = now.Add(unclaimedCallbackTTL + time.Second)
+		require.False(t, d.DispatchSTKPush(stkCallback("ws_CO_3")))
+		require.NotContains(t, d.stk.unclaimed, "ws_CO_2")
+		require.Contains(t, d.stk.unclaimed, "ws_CO_3")
+	})
+
+	t.Run("it stops waiting once the context is done", func(t *testing.T) {
+		d := NewCallbackDispatcher()
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := d.AwaitResult(ctx, "AG_20191219_00005797af5d7d75f652")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Empty(t, d.results.waiters)
+	})
+
+	t.Run("it aborts the awaited stk push", func(t *testing.T) {
+		d := NewCallbackDispatcher()
+		d.StateMachine = NewTransactionStateMachine(NewMemoryStore())
+
+		_, err := d.StateMachine.Initiate(ctx, Transaction{Kind: TransactionKindSTKPush}, &STKPushResponse{
+			CheckoutRequestID: "ws_CO_1",
+			ResponseCode:      "0",
+		}, nil)
+		require.NoError(t, err)
+
+		errs := make(chan error, 1)
+		go func() {
+			_, err := d.AwaitSTKPush(ctx, "ws_CO_1")
+			errs <- err
+		}()
+
+		require.Eventually(t, func() bool {
+			d.stk.mu.Lock()
+			defer d.stk.mu.Unlock()
+			return len(d.stk.waiters["ws_CO_1"]) == 1
+		}, time.Second, time.Millisecond)
+
+		require.NoError(t, d.CancelSTKPush(ctx, "ws_CO_1"))
+		require.ErrorIs(t, <-errs, ErrSTKPushAborted)
+		require.Empty(t, d.stk.waiters)
+
+		txn, err := d.StateMachine.Store.Get(ctx, "ws_CO_1")
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusAborted, txn.Status)
+
+		require.ErrorIs(t, d.CancelSTKPush(ctx, "ws_CO_unknown"), ErrTransactionNotFound)
+	})
+
+	t.Run("it sends the request and waits for the result", func(t *testing.T) {
+		var (
+			d   = NewCallbackDispatcher()
+			cl  = newMockHttpClient()
+			app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+		)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+			go func() {
+				rec := httptest.NewRecorder()
+				d.ResultHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/result", strings.NewReader(
+					`{"Result": {"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResultCode": 0}}`,
+				)))
+				require.Equal(t, http.StatusOK, rec.Code)
+			}()
+
+			return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+		})
+
+		res, callback, err := d.B2C(ctx, app, "random-string", B2CRequest{
+			InitiatorName:   "TestG2Init",
+			CommandID:       BusinessPaymentCommandID,
+			Amount:          10,
+			PartyA:          600123,
+			PartyB:          254728762287,
+			QueueTimeOutURL: "https://example.com/timeout",
+			ResultURL:       "https://example.com/result",
+		})
+		require.NoError(t, err)
+		require.Equal(t, res.ConversationID, callback.Result.ConversationID)
+	})
+}
@@ -0,0 +1,152 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// CallbackAcknowledgement is the response M-Pesa expects from the callback URLs.
+type CallbackAcknowledgement struct {
+	ResultCode int    `json:"ResultCode"`
+	ResultDesc string `json:"ResultDesc"`
+}
+
+// acceptedAcknowledgement is written once a callback has been handled.
+var acceptedAcknowledgement = CallbackAcknowledgement{ResultCode: 0, ResultDesc: "Accepted"}
+
+// STKAcknowledgement returns the response the CallBackURL of an STK push must return once the callback has been
+// handled. Written with a 200 status, it is encoded as:
+//
+//	{"ResultCode":0,"ResultDesc":"Accepted"}
+//
+// The ResultCode must be the number 0, not the string "0" used by the C2B validation responses: M-Pesa may send the
+// callback again when it gets any other response, including a non-200 status or a body it cannot decode.
+func STKAcknowledgement() CallbackAcknowledgement {
+	return acceptedAcknowledgement
+}
+
+// WriteAcknowledgement writes the acknowledgement of a handled callback, see STKAcknowledgement, with resultDesc as its
+// ResultDesc, e.g. a reference of the record the callback was stored as. An empty resultDesc is written as "Accepted".
+func WriteAcknowledgement(w http.ResponseWriter, resultDesc string) {
+	ack := STKAcknowledgement()
+	if resultDesc != "" {
+		ack.ResultDesc = resultDesc
+	}
+
+	writeJSON(w, http.StatusOK, ack)
+}
+
+// WriteSuccessAcknowledgement writes the response M-Pesa expects from the CallBackURL, ResultURL, QueueTimeOutURL and
+// ConfirmationURL once a callback has been handled.
+func WriteSuccessAcknowledgement(w http.ResponseWriter) {
+	WriteAcknowledgement(w, "")
+}
+
+// WriteC2BValidationResult writes the response to a C2B validation request. A resultCode of C2BAccepted, or an empty
+// one, accepts the payment with the thirdPartyTransID, which is echoed on the confirmation. Any other code rejects it
+// with the documented description of the C2BReject code.
+func WriteC2BValidationResult(w http.ResponseWriter, resultCode, thirdPartyTransID string) {
+	if resultCode == "" || resultCode == C2BAccepted {
+		writeJSON(w, http.StatusOK, AcceptC2BPayment(thirdPartyTransID))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RejectC2BPayment(resultCode, c2bRejectionDescs[resultCode]))
+}
+
+// STKPushCallbackHandler returns a http.Handler for the CallBackURL of STK push requests. It decodes the callback,
+// calls fn and acknowledges the callback. A failed fn responds with a 500 status so that the callback is not lost.
+func STKPushCallbackHandler(fn func(ctx context.Context, callback *STKPushCallback) error) http.Handler {
+	return callbackHandlerFunc(fn)
+}
+
+// ResultCallbackHandler returns a http.Handler for the ResultURL of B2C, reversal, transaction status, account balance
+// and business pay bill requests. It decodes the callback, calls fn and acknowledges the callback.
+func ResultCallbackHandler(fn func(ctx context.Context, callback *Callback) error) http.Handler {
+	return callbackHandlerFunc(fn)
+}
+
+// QueueTimeoutCallbackHandler returns a http.Handler for the QueueTimeOutURL of B2C, reversal, transaction status,
+// account balance and business pay bill requests. It decodes the timeout notification, calls fn and acknowledges it.
+func QueueTimeoutCallbackHandler(fn func(ctx context.Context, callback *QueueTimeoutCallback) error) http.Handler {
+	return callbackHandlerFunc(fn)
+}
+
+// B2BExpressCheckoutCallbackHandler returns a http.Handler for the callback URL of B2B Express Checkout requests.
+func B2BExpressCheckoutCallbackHandler(
+	fn func(ctx context.Context, callback *B2BExpressCheckoutCallback) error,
+) http.Handler {
+	return callbackHandlerFunc(fn)
+}
+
+// StandingOrderCallbackHandler returns a http.Handler for the CallBackURL of Ratiba standing order status queries
+// and cancellations.
+func StandingOrderCallbackHandler(fn func(ctx context.Context, callback *StandingOrderCallback) error) http.Handler {
+	return callbackHandlerFunc(fn)
+}
+
+// C2BConfirmationHandler returns a http.Handler for the ConfirmationURL registered with RegisterC2BURL.
+func C2BConfirmationHandler(fn func(ctx context.Context, callback *C2BCallback) error) http.Handler {
+	return callbackHandlerFunc(fn)
+}
+
+// C2BValidationHandler returns a http.Handler for the ValidationURL registered with RegisterC2BURL. The payment is
+// accepted when fn returns nil and rejected otherwise, with the code of a *C2BRejection or C2BRejectOtherError.
+func C2BValidationHandler(fn func(ctx context.Context, callback *C2BCallback) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callback, ok := decodeCallback[C2BCallback](w, r)
+		if !ok {
+			return
+		}
+
+		err := fn(r.Context(), callback)
+		if err == nil {
+			writeJSON(w, http.StatusOK, AcceptC2BPayment(""))
+			return
+		}
+
+		var rejection *C2BRejection
+		if !errors.As(err, &rejection) {
+			rejection = &C2BRejection{}
+		}
+
+		writeJSON(w, http.StatusOK, RejectC2BPayment(rejection.Code, rejection.Desc))
+	})
+}
+
+// callbackHandlerFunc decodes the JSON body into T, calls fn and writes the acknowledgement.
+func callbackHandlerFunc[T any](fn func(ctx context.Context, callback *T) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		callback, ok := decodeCallback[T](w, r)
+		if !ok {
+			return
+		}
+
+		if err := fn(r.Context(), callback); err != nil {
+			writeJSON(w, http.StatusInternalServerError, CallbackAcknowledgement{ResultCode: 1, ResultDesc: "Rejected"})
+			return
+		}
+
+		WriteSuccessAcknowledgement(w)
+	})
+}
+
+// decodeCallback decodes the JSON body of the callback request. It writes the error response and returns false if
+// the request is not a valid callback.
+func decodeCallback[T any](w http.ResponseWriter, r *http.Request) (*T, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, CallbackAcknowledgement{ResultCode: 1, ResultDesc: "Method not allowed"})
+		return nil, false
+	}
+
+	callback, err := decode[T](io.LimitReader(r.Body, maxAPIRequestBodySize), false)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, CallbackAcknowledgement{ResultCode: 1, ResultDesc: "Invalid callback"})
+		return nil, false
+	}
+
+	return callback, true
+}
@@ -0,0 +1,217 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func serveCallback(h http.Handler, method, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, "/callback", strings.NewReader(body)))
+	return rec
+}
+
+func TestSTKPushCallbackHandler(t *testing.T) {
+	var received *STKPushCallback
+
+	h := STKPushCallbackHandler(func(_ context.Context, callback *STKPushCallback) error {
+		received = callback
+		if callback.Body.STKCallback.CheckoutRequestID == "ws_CO_fail" {
+			return errors.New("database unavailable")
+		}
+		return nil
+	})
+
+	rec := serveCallback(h, http.MethodPost, `{
+		"Body": {
+			"stkCallback": {
+				"MerchantRequestID": "29115-34620561-1",
+				"CheckoutRequestID": "ws_CO_191220191020363925",
+				"ResultCode": 1032,
+				"ResultDesc": "Request cancelled by user."
+			}
+		}
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"ResultCode":0,"ResultDesc":"Accepted"}`, rec.Body.String())
+	require.Equal(t, 1032, received.Body.STKCallback.ResultCode)
+
+	rec = serveCallback(h, http.MethodPost, `{"Body":{"stkCallback":{"CheckoutRequestID":"ws_CO_fail"}}}`)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.JSONEq(t, `{"ResultCode":1,"ResultDesc":"Rejected"}`, rec.Body.String())
+
+	rec = serveCallback(h, http.MethodPost, `{"Body":`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveCallback(h, http.MethodGet, ``)
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	require.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+}
+
+func TestResultCallbackHandler(t *testing.T) {
+	var conversationID string
+
+	h := ResultCallbackHandler(func(_ context.Context, callback *Callback) error {
+		conversationID = callback.Result.ConversationID
+		return nil
+	})
+
+	rec := serveCallback(h, http.MethodPost, `{"Result":{"ConversationID":"AG_20191219_00004e48cf7e3533f581"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "AG_20191219_00004e48cf7e3533f581", conversationID)
+
+	h = B2BExpressCheckoutCallbackHandler(func(_ context.Context, callback *B2BExpressCheckoutCallback) error {
+		conversationID = callback.RequestID
+		return nil
+	})
+
+	rec = serveCallback(h, http.MethodPost, `{"resultCode":"0","requestId":"404e1aec-19e0-4ce3-973d-bd92e94c8021"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "404e1aec-19e0-4ce3-973d-bd92e94c8021", conversationID)
+}
+
+func TestQueueTimeoutCallbackHandler(t *testing.T) {
+	var result QueueTimeoutResult
+
+	h := QueueTimeoutCallbackHandler(func(_ context.Context, callback *QueueTimeoutCallback) error {
+		result = callback.Result
+		return nil
+	})
+
+	rec := serveCallback(h, http.MethodPost, `{"Result":{"ResultType":1,"ResultCode":1,`+
+		`"ResultDesc":"The service request timed out.","ConversationID":"AG_20191219_00004e48cf7e3533f581"}}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "AG_20191219_00004e48cf7e3533f581", result.ConversationID)
+	require.Equal(t, 1, result.ResultCode)
+
+	rec = serveCallback(h, http.MethodPost, `{"Result":`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestC2BHandlers(t *testing.T) {
+	const body = `{
+		"TransactionType": "Pay Bill",
+		"TransID": "RKTQDM7W6S",
+		"TransTime": "20191122063845",
+		"TransAmount": "10",
+		"BusinessShortCode": "600638",
+		"BillRefNumber": "invoice008",
+		"InvoiceNumber": "",
+		"OrgAccountBalance": "",
+		"ThirdPartyTransID": "",
+		"MSISDN": "25470****149",
+		"FirstName": "John",
+		"MiddleName": "",
+		"LastName": "Doe"
+	}`
+
+	callback, err := UnmarshalC2BCallback(strings.NewReader(body))
+	require.NoError(t, err)
+	require.Equal(t, "RKTQDM7W6S", callback.TransID)
+
+	var confirmed string
+	rec := serveCallback(C2BConfirmationHandler(func(_ context.Context, callback *C2BCallback) error {
+		confirmed = callback.TransID
+		return nil
+	}), http.MethodPost, body)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "RKTQDM7W6S", confirmed)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "it accepts the payment",
+			want: `{"ResultCode":"0","ResultDesc":"Accepted"}`,
+		},
+		{
+			name: "it rejects the payment with the rejection code",
+			err:  &C2BRejection{Code: C2BRejectInvalidAccountNumber, Desc: "Unknown account"},
+			want: `{"ResultCode":"C2B00012","ResultDesc":"Unknown account"}`,
+		},
+		{
+			name: "it rejects the payment with a wrapped rejection",
+			err:  errors.Join(errors.New("lookup"), &C2BRejection{Code: C2BRejectInvalidAmount}),
+			want: `{"ResultCode":"C2B00013","ResultDesc":"Rejected"}`,
+		},
+		{
+			name: "it rejects the payment on other errors",
+			err:  errors.New("database unavailable"),
+			want: `{"ResultCode":"C2B00016","ResultDesc":"Rejected"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveCallback(C2BValidationHandler(func(_ context.Context, callback *C2BCallback) error {
+				require.Equal(t, "invoice008", callback.BillRefNumber)
+				return tc.err
+			}), http.MethodPost, body)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.JSONEq(t, tc.want, rec.Body.String())
+		})
+	}
+}
+
+func TestWriteSuccessAcknowledgement(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteSuccessAcknowledgement(rec)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"ResultCode":0,"ResultDesc":"Accepted"}`, rec.Body.String())
+}
+
+func TestWriteAcknowledgement(t *testing.T) {
+	body, err := json.Marshal(STKAcknowledgement())
+	require.NoError(t, err)
+	require.Equal(t, `{"ResultCode":0,"ResultDesc":"Accepted"}`, string(body))
+
+	rec := httptest.NewRecorder()
+	WriteAcknowledgement(rec, "Stored as payment 42")
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.JSONEq(t, `{"ResultCode":0,"ResultDesc":"Stored as payment 42"}`, rec.Body.String())
+}
+
+func TestWriteC2BValidationResult(t *testing.T) {
+	tests := []struct {
+		code              string
+		thirdPartyTransID string
+		want              string
+	}{
+		{
+			code:              C2BAccepted,
+			thirdPartyTransID: "1234567890",
+			want:              `{"ResultCode":"0","ResultDesc":"Accepted","ThirdPartyTransID":"1234567890"}`,
+		},
+		{code: "", want: `{"ResultCode":"0","ResultDesc":"Accepted"}`},
+		{code: C2BRejectInvalidMSISDN, want: `{"ResultCode":"C2B00011","ResultDesc":"Invalid MSISDN"}`},
+		{code: C2BRejectInvalidAccountNumber, want: `{"ResultCode":"C2B00012","ResultDesc":"Invalid Account Number"}`},
+		{code: C2BRejectInvalidAmount, want: `{"ResultCode":"C2B00013","ResultDesc":"Invalid Amount"}`},
+		{code: C2BRejectInvalidKYCDetails, want: `{"ResultCode":"C2B00014","ResultDesc":"Invalid KYC Details"}`},
+		{code: C2BRejectInvalidShortcode, want: `{"ResultCode":"C2B00015","ResultDesc":"Invalid Shortcode"}`},
+		{code: C2BRejectOtherError, want: `{"ResultCode":"C2B00016","ResultDesc":"Other Error"}`},
+		{code: "C2B00099", want: `{"ResultCode":"C2B00099","ResultDesc":"Rejected"}`},
+	}
+
+	for _, tc := range tests {
+		rec := httptest.NewRecorder()
+		WriteC2BValidationResult(rec, tc.code, tc.thirdPartyTransID)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.JSONEq(t, tc.want, rec.Body.String(), tc.code)
+	}
+}
@@ -0,0 +1,36 @@
+package mpesa
+
+// CallbackURLs are the URLs set on the requests that do not have them, so that they are configured once per app.
+type CallbackURLs struct {
+	// CallBackURL is set on the STKPush requests.
+	CallBackURL string `json:"callback_url" yaml:"callback_url"`
+
+	// ResultURL and QueueTimeOutURL are set on the B2C, B2B, transaction status, reversal and account balance
+	// requests.
+	ResultURL       string `json:"result_url" yaml:"result_url"`
+	QueueTimeOutURL string `json:"queue_timeout_url" yaml:"queue_timeout_url"`
+
+	// ConfirmationURL and ValidationURL are set on the RegisterC2BURL requests.
+	ConfirmationURL string `json:"confirmation_url" yaml:"confirmation_url"`
+	ValidationURL   string `json:"validation_url" yaml:"validation_url"`
+}
+
+// WithCallbackURLs sets the URLs used by the requests that do not have them.
+func WithCallbackURLs(urls CallbackURLs) Option {
+	return func(m *Mpesa) {
+		m.callbackURLs = urls
+	}
+}
+
+// defaultResultURLs sets the ResultURL and QueueTimeOutURL of the app on the fields that are not set.
+func (m *Mpesa) defaultResultURLs(resultURL, queueTimeOutURL *string) {
+	setDefault(resultURL, m.callbackURLs.ResultURL)
+	setDefault(queueTimeOutURL, m.callbackURLs.QueueTimeOutURL)
+}
+
+// setDefault sets value on the field if it is empty.
+func setDefault(field *string, value string) {
+	if *field == "" {
+		*field = value
+	}
+}
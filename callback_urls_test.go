@@ -0,0 +1,63 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCallbackURLs(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = newMockHttpClient()
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCallbackURLs(CallbackURLs{
+			CallBackURL:     "https://example.com/stk",
+			ResultURL:       "https://example.com/result",
+			QueueTimeOutURL: "https://example.com/timeout",
+		}))
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+		var params STKPushRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&params))
+		require.Equal(t, "https://example.com/stk", params.CallBackURL)
+
+		return http.StatusOK, `{"CheckoutRequestID": "ws_CO_191220191020363925", "ResponseCode": "0"}`
+	})
+
+	cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+		var params B2CRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&params))
+		require.Equal(t, "https://example.com/result", params.ResultURL)
+		require.Equal(t, "https://example.com/custom-timeout", params.QueueTimeOutURL)
+
+		return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+	})
+
+	_, err := app.STKPush(ctx, "passkey", STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyA:            254708374149,
+		PartyB:            174379,
+		PhoneNumber:       254708374149,
+		AccountReference:  "INV-001",
+		TransactionDesc:   "Test payment",
+	})
+	require.NoError(t, err)
+
+	_, err = app.B2C(ctx, "Safaricom999!*!", B2CRequest{
+		InitiatorName:   "testapi",
+		CommandID:       BusinessPaymentCommandID,
+		Amount:          10,
+		PartyA:          600980,
+		PartyB:          254708374149,
+		QueueTimeOutURL: "https://example.com/custom-timeout",
+	})
+	require.NoError(t, err)
+}
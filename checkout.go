@@ -0,0 +1,341 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// CheckoutStatus is the lifecycle state of a Checkout.
+type CheckoutStatus string
+
+const (
+	// CheckoutStatusCreated is the status of a checkout that has not been started.
+	CheckoutStatusCreated CheckoutStatus = "Created"
+
+	// CheckoutStatusPromptSent means M-Pesa accepted the STK push and the prompt is on its way to the customer.
+	CheckoutStatusPromptSent CheckoutStatus = "PromptSent"
+
+	// CheckoutStatusAwaitingPIN means the prompt is being shown to the customer and M-Pesa is waiting for the PIN.
+	CheckoutStatusAwaitingPIN CheckoutStatus = "AwaitingPIN"
+
+	// CheckoutStatusPaid means the customer completed the payment.
+	CheckoutStatusPaid CheckoutStatus = "Paid"
+
+	// CheckoutStatusCancelled means the customer dismissed the prompt.
+	CheckoutStatusCancelled CheckoutStatus = "Cancelled"
+
+	// CheckoutStatusFailed means the payment failed, e.g. because of insufficient funds or a wrong PIN.
+	CheckoutStatusFailed CheckoutStatus = "Failed"
+
+	// CheckoutStatusExpired means the customer did not complete the payment within the expiry window. An expired
+	// checkout still moves to CheckoutStatusPaid if the success callback of the payment arrives late.
+	CheckoutStatusExpired CheckoutStatus = "Expired"
+
+	// CheckoutStatusAborted means the merchant cancelled the checkout before it reached another final status.
+	CheckoutStatusAborted CheckoutStatus = "Aborted"
+)
+
+// stkQueryProcessingErrorCode is returned by STKQuery while the customer has not responded to the prompt.
+const stkQueryProcessingErrorCode = "500.001.1001"
+
+const (
+	defaultCheckoutExpiry       = 2 * time.Minute
+	defaultCheckoutPollInterval = 5 * time.Second
+
+	// checkoutFinalQueryTimeout bounds the STK query made once the expiry window elapses.
+	checkoutFinalQueryTimeout = 10 * time.Second
+)
+
+// ErrCheckoutStarted is returned when starting a Checkout more than once.
+var ErrCheckoutStarted = errors.New("mpesa: checkout already started")
+
+// IsFinal returns true if the checkout will not change status anymore, apart from an expired checkout that is paid
+// late.
+func (s CheckoutStatus) IsFinal() bool {
+	switch s {
+	case CheckoutStatusPaid, CheckoutStatusCancelled, CheckoutStatusFailed, CheckoutStatusExpired,
+		CheckoutStatusAborted:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkoutStatusFromResultCode maps an STK push result code to the final CheckoutStatus.
+func checkoutStatusFromResultCode(code int) CheckoutStatus {
+	switch STKResultCode(code) {
+	case STKResultSuccess:
+		return CheckoutStatusPaid
+	case STKResultCancelledByUser:
+		return CheckoutStatusCancelled
+	case STKResultTransactionExpired, STKResultTimeout:
+		return CheckoutStatusExpired
+	default:
+		return CheckoutStatusFailed
+	}
+}
+
+// CheckoutConfig configures a Checkout.
+type CheckoutConfig struct {
+	// Passkey is used to generate the password for the STK push and query requests.
+	Passkey string
+
+	// Expiry is how long the customer has to complete the payment. Defaults to 2 minutes.
+	Expiry time.Duration
+
+	// PollInterval is the time between STK queries made while waiting for the result. Set it to a negative value to
+	// rely on HandleCallback and the final query made when the checkout expires. Defaults to 5 seconds.
+	PollInterval time.Duration
+
+	// OnStatusChange, if set, is called every time the status of the checkout changes.
+	OnStatusChange func(status CheckoutStatus)
+}
+
+// Checkout is a single STK push payment tracked from the time the prompt is sent until the customer pays, cancels or
+// the expiry window elapses. The result is taken from the STK push callback passed to HandleCallback or from polling
+// STKQuery, whichever comes first.
+type Checkout struct {
+	app *Mpesa
+	cfg CheckoutConfig
+	req STKPushRequest
+
+	mu                sync.Mutex
+	started           bool
+	status            CheckoutStatus
+	checkoutRequestID string
+	resultCode        int
+	resultDesc        string
+	expiresAt         time.Time
+	done              chan struct{}
+}
+
+// NewCheckout creates a Checkout for the STK push request. Call Start to send the prompt.
+func NewCheckout(app *Mpesa, cfg CheckoutConfig, req STKPushRequest) *Checkout {
+	if cfg.Expiry <= 0 {
+		cfg.Expiry = defaultCheckoutExpiry
+	}
+
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultCheckoutPollInterval
+	}
+
+	return &Checkout{
+		app:    app,
+		cfg:    cfg,
+		req:    req,
+		status: CheckoutStatusCreated,
+		done:   make(chan struct{}),
+	}
+}
+
+// Start sends the STK push and starts tracking the checkout in the background until it reaches a final status. The
+// context is only used for the STK push request.
+func (c *Checkout) Start(ctx context.Context) error {
+	c.mu.Lock()
+	if c.started {
+		c.mu.Unlock()
+		return ErrCheckoutStarted
+	}
+	c.started = true
+	c.mu.Unlock()
+
+	res, err := c.app.STKPush(ctx, c.cfg.Passkey, c.req)
+	if err != nil {
+		c.finish(CheckoutStatusFailed, -1, err.Error())
+		return err
+	}
+
+	c.mu.Lock()
+	c.checkoutRequestID = res.CheckoutRequestID
+	c.expiresAt = time.Now().Add(c.cfg.Expiry)
+	c.mu.Unlock()
+
+	c.setStatus(CheckoutStatusPromptSent)
+
+	go c.track()
+	return nil
+}
+
+// track polls the status of the checkout and expires it once the expiry window elapses and a final query does not
+// establish the result.
+func (c *Checkout) track() {
+	ctx, cancel := context.WithDeadline(context.Background(), c.ExpiresAt())
+	defer cancel()
+
+	var poll <-chan time.Time
+	if c.cfg.PollInterval > 0 {
+		ticker := time.NewTicker(c.cfg.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ctx.Done():
+			c.expire()
+			return
+		case <-poll:
+			c.query(ctx)
+		}
+	}
+}
+
+// expire queries the status of the checkout one last time, so that a payment completed just before the deadline is
+// not reported as expired, and expires the checkout if the result is still unknown.
+func (c *Checkout) expire() {
+	ctx, cancel := context.WithTimeout(context.Background(), checkoutFinalQueryTimeout)
+	defer cancel()
+
+	select {
+	case <-c.done:
+		return
+	default:
+	}
+
+	c.query(ctx)
+	c.finish(CheckoutStatusExpired, int(STKResultTransactionExpired), "The checkout expired")
+}
+
+func (c *Checkout) query(ctx context.Context) {
+	res, err := c.app.STKQuery(ctx, c.cfg.Passkey, STKQueryRequest{
+		BusinessShortCode: c.req.BusinessShortCode,
+		CheckoutRequestID: c.CheckoutRequestID(),
+	})
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == stkQueryProcessingErrorCode {
+			c.setStatus(CheckoutStatusAwaitingPIN)
+		}
+		return
+	}
+
+	code, err := strconv.Atoi(res.ResultCode)
+	if err != nil {
+		return
+	}
+
+	c.finish(checkoutStatusFromResultCode(code), code, res.ResultDesc)
+}
+
+// HandleCallback completes the checkout with the result of the STK push callback. A successful payment reported
+// after the checkout expired moves it to CheckoutStatusPaid. Callbacks for other checkouts are ignored and false is
+// returned.
+func (c *Checkout) HandleCallback(callback *STKPushCallback) bool {
+	result := callback.Body.STKCallback
+	if result.CheckoutRequestID == "" || result.CheckoutRequestID != c.CheckoutRequestID() {
+		return false
+	}
+
+	status := checkoutStatusFromResultCode(result.ResultCode)
+	if !c.finish(status, result.ResultCode, result.ResultDesc) && status == CheckoutStatusPaid {
+		c.payExpired(result.ResultCode, result.ResultDesc)
+	}
+
+	return true
+}
+
+// payExpired moves an expired checkout to CheckoutStatusPaid once the late callback of its payment arrives.
+func (c *Checkout) payExpired(resultCode int, resultDesc string) {
+	c.mu.Lock()
+	if c.status != CheckoutStatusExpired {
+		c.mu.Unlock()
+		return
+	}
+
+	c.status = CheckoutStatusPaid
+	c.resultCode = resultCode
+	c.resultDesc = resultDesc
+	c.mu.Unlock()
+
+	if c.cfg.OnStatusChange != nil {
+		c.cfg.OnStatusChange(CheckoutStatusPaid)
+	}
+}
+
+// Cancel aborts the checkout and stops polling its status. Daraja cannot withdraw the prompt, so the customer may
+// still complete the payment; use STKQuery with the CheckoutRequestID to reconcile it. It returns false if the
+// checkout had already reached a final status.
+func (c *Checkout) Cancel() bool {
+	return c.finish(CheckoutStatusAborted, -1, "The checkout was aborted")
+}
+
+func (c *Checkout) setStatus(status CheckoutStatus) {
+	c.mu.Lock()
+	if c.status == status || c.status.IsFinal() {
+		c.mu.Unlock()
+		return
+	}
+	c.status = status
+	c.mu.Unlock()
+
+	if c.cfg.OnStatusChange != nil {
+		c.cfg.OnStatusChange(status)
+	}
+}
+
+func (c *Checkout) finish(status CheckoutStatus, resultCode int, resultDesc string) bool {
+	c.mu.Lock()
+	if c.status.IsFinal() {
+		c.mu.Unlock()
+		return false
+	}
+
+	c.status = status
+	c.resultCode = resultCode
+	c.resultDesc = resultDesc
+	close(c.done)
+	c.mu.Unlock()
+
+	if c.cfg.OnStatusChange != nil {
+		c.cfg.OnStatusChange(status)
+	}
+
+	return true
+}
+
+// Status returns the current status of the checkout.
+func (c *Checkout) Status() CheckoutStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.status
+}
+
+// Wait blocks until the checkout reaches a final status or the context is done.
+func (c *Checkout) Wait(ctx context.Context) (CheckoutStatus, error) {
+	select {
+	case <-c.done:
+		return c.Status(), nil
+	case <-ctx.Done():
+		return c.Status(), ctx.Err()
+	}
+}
+
+// CheckoutRequestID returns the CheckoutRequestID assigned by M-Pesa once the checkout is started.
+func (c *Checkout) CheckoutRequestID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.checkoutRequestID
+}
+
+// ExpiresAt returns the time the checkout expires if the customer has not completed the payment.
+func (c *Checkout) ExpiresAt() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.expiresAt
+}
+
+// Result returns the result code and description of a checkout that reached a final status.
+func (c *Checkout) Result() (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.resultCode, c.resultDesc
+}
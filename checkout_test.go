@@ -0,0 +1,252 @@
+package mpesa
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckout(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		stkReq = STKPushRequest{
+			BusinessShortCode: 174379,
+			TransactionType:   "CustomerPayBillOnline",
+			Amount:            10,
+			PartyA:            254708374149,
+			PartyB:            174379,
+			PhoneNumber:       254708374149,
+			CallBackURL:       "https://example.com",
+			AccountReference:  "Test",
+			TransactionDesc:   "Test",
+		}
+	)
+
+	newCheckout := func(t *testing.T, cfg CheckoutConfig) (*Checkout, *Mpesa, *mockHttpClient, func() []CheckoutStatus) {
+		var (
+			cl       = newMockHttpClient()
+			app      = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			mu       sync.Mutex
+			statuses []CheckoutStatus
+		)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+			return http.StatusOK, `
+				{
+				  "MerchantRequestID": "29115-34620561-1",
+				  "CheckoutRequestID": "ws_CO_191220191020363925",
+				  "ResponseCode": "0",
+				  "ResponseDescription": "Success. Request accepted for processing",
+				  "CustomerMessage": "Success. Request accepted for processing"
+				}`
+		})
+
+		cfg.Passkey = "passkey"
+		cfg.OnStatusChange = func(status CheckoutStatus) {
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, status)
+		}
+
+		return NewCheckout(app, cfg, stkReq), app, cl, func() []CheckoutStatus {
+			mu.Lock()
+			defer mu.Unlock()
+			return append([]CheckoutStatus(nil), statuses...)
+		}
+	}
+
+	t.Run("it completes from the callback", func(t *testing.T) {
+		checkout, _, _, statuses := newCheckout(t, CheckoutConfig{PollInterval: -1})
+		require.Equal(t, CheckoutStatusCreated, checkout.Status())
+
+		require.NoError(t, checkout.Start(ctx))
+		require.ErrorIs(t, checkout.Start(ctx), ErrCheckoutStarted)
+		require.Equal(t, CheckoutStatusPromptSent, checkout.Status())
+		require.Equal(t, "ws_CO_191220191020363925", checkout.CheckoutRequestID())
+
+		require.False(t, checkout.HandleCallback(&STKPushCallback{Body: STKPushCallbackBody{
+			STKCallback: STKCallback{CheckoutRequestID: "ws_CO_other"},
+		}}))
+
+		require.True(t, checkout.HandleCallback(&STKPushCallback{Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultCode:        1032,
+				ResultDesc:        "Request cancelled by user",
+			},
+		}}))
+
+		status, err := checkout.Wait(ctx)
+		require.NoError(t, err)
+		require.Equal(t, CheckoutStatusCancelled, status)
+
+		code, desc := checkout.Result()
+		require.Equal(t, 1032, code)
+		require.Equal(t, "Request cancelled by user", desc)
+		require.Equal(t, []CheckoutStatus{CheckoutStatusPromptSent, CheckoutStatusCancelled}, statuses())
+	})
+
+	t.Run("it completes by polling the status", func(t *testing.T) {
+		checkout, app, cl, statuses := newCheckout(t, CheckoutConfig{PollInterval: time.Millisecond})
+
+		var queries int32
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			if atomic.AddInt32(&queries, 1) < 3 {
+				return http.StatusInternalServerError, `
+					{
+					  "requestId": "29115-34620561-1",
+					  "errorCode": "500.001.1001",
+					  "errorMessage": "The transaction is being processed"
+					}`
+			}
+
+			return http.StatusOK, `
+				{
+				  "ResponseCode": "0",
+				  "ResponseDescription": "The service request has been accepted successsfully",
+				  "MerchantRequestID": "29115-34620561-1",
+				  "CheckoutRequestID": "ws_CO_191220191020363925",
+				  "ResultCode": "0",
+				  "ResultDesc": "The service request is processed successfully."
+				}`
+		})
+
+		require.NoError(t, checkout.Start(ctx))
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		status, err := checkout.Wait(waitCtx)
+		require.NoError(t, err)
+		require.Equal(t, CheckoutStatusPaid, status)
+		require.Equal(t, []CheckoutStatus{
+			CheckoutStatusPromptSent, CheckoutStatusAwaitingPIN, CheckoutStatusPaid,
+		}, statuses())
+	})
+
+	t.Run("it expires when the customer does not respond", func(t *testing.T) {
+		checkout, _, _, _ := newCheckout(t, CheckoutConfig{Expiry: 10 * time.Millisecond, PollInterval: -1})
+		require.NoError(t, checkout.Start(ctx))
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		status, err := checkout.Wait(waitCtx)
+		require.NoError(t, err)
+		require.Equal(t, CheckoutStatusExpired, status)
+	})
+
+	t.Run("it queries the status before expiring", func(t *testing.T) {
+		checkout, app, cl, statuses := newCheckout(t, CheckoutConfig{Expiry: 10 * time.Millisecond, PollInterval: -1})
+
+		var queries int32
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			atomic.AddInt32(&queries, 1)
+			return http.StatusOK, `
+				{
+				  "ResponseCode": "0",
+				  "ResponseDescription": "The service request has been accepted successsfully",
+				  "MerchantRequestID": "29115-34620561-1",
+				  "CheckoutRequestID": "ws_CO_191220191020363925",
+				  "ResultCode": "0",
+				  "ResultDesc": "The service request is processed successfully."
+				}`
+		})
+
+		require.NoError(t, checkout.Start(ctx))
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		status, err := checkout.Wait(waitCtx)
+		require.NoError(t, err)
+		require.Equal(t, CheckoutStatusPaid, status)
+		require.EqualValues(t, 1, atomic.LoadInt32(&queries))
+		require.Equal(t, []CheckoutStatus{CheckoutStatusPromptSent, CheckoutStatusPaid}, statuses())
+	})
+
+	t.Run("it pays an expired checkout on a late success callback", func(t *testing.T) {
+		checkout, _, _, statuses := newCheckout(t, CheckoutConfig{Expiry: 10 * time.Millisecond, PollInterval: -1})
+		require.NoError(t, checkout.Start(ctx))
+
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		status, err := checkout.Wait(waitCtx)
+		require.NoError(t, err)
+		require.Equal(t, CheckoutStatusExpired, status)
+
+		require.True(t, checkout.HandleCallback(&STKPushCallback{Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultCode:        1037,
+				ResultDesc:        "DS timeout user cannot be reached",
+			},
+		}}))
+		require.Equal(t, CheckoutStatusExpired, checkout.Status())
+
+		require.True(t, checkout.HandleCallback(&STKPushCallback{Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultDesc:        "The service request is processed successfully.",
+			},
+		}}))
+		require.Equal(t, CheckoutStatusPaid, checkout.Status())
+
+		code, desc := checkout.Result()
+		require.Equal(t, 0, code)
+		require.Equal(t, "The service request is processed successfully.", desc)
+		require.Equal(t, []CheckoutStatus{
+			CheckoutStatusPromptSent, CheckoutStatusExpired, CheckoutStatusPaid,
+		}, statuses())
+	})
+
+	t.Run("it stops polling once cancelled", func(t *testing.T) {
+		checkout, app, cl, statuses := newCheckout(t, CheckoutConfig{PollInterval: time.Millisecond})
+
+		var queries int32
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			atomic.AddInt32(&queries, 1)
+			return http.StatusInternalServerError, `
+				{"errorCode": "500.001.1001", "errorMessage": "The transaction is being processed"}`
+		})
+
+		require.NoError(t, checkout.Start(ctx))
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&queries) > 0 }, time.Second, time.Millisecond)
+
+		require.True(t, checkout.Cancel())
+		require.False(t, checkout.Cancel())
+
+		status, err := checkout.Wait(ctx)
+		require.NoError(t, err)
+		require.Equal(t, CheckoutStatusAborted, status)
+
+		// A late callback does not change the status of an aborted checkout.
+		checkout.HandleCallback(&STKPushCallback{Body: STKPushCallbackBody{
+			STKCallback: STKCallback{CheckoutRequestID: "ws_CO_191220191020363925"},
+		}})
+		require.Equal(t, CheckoutStatusAborted, checkout.Status())
+		require.Equal(t, CheckoutStatusAborted, statuses()[len(statuses())-1])
+
+		time.Sleep(10 * time.Millisecond)
+		polled := atomic.LoadInt32(&queries)
+		time.Sleep(10 * time.Millisecond)
+		require.Equal(t, polled, atomic.LoadInt32(&queries))
+	})
+
+	t.Run("it fails when the stk push is rejected", func(t *testing.T) {
+		checkout, app, cl, _ := newCheckout(t, CheckoutConfig{})
+		cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+			return http.StatusBadRequest, `{"errorCode": "400.002.02", "errorMessage": "Bad Request - Invalid PhoneNumber"}`
+		})
+
+		require.Error(t, checkout.Start(ctx))
+		require.Equal(t, CheckoutStatusFailed, checkout.Status())
+	})
+}
@@ -0,0 +1,182 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of the CircuitBreakerConfig fields left empty.
+const (
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenDuration     = 30 * time.Second
+	defaultCircuitHalfOpenProbes   = 1
+)
+
+// ErrCircuitOpen is returned, without calling Daraja, by the requests made while the circuit breaker of the app is
+// open.
+var ErrCircuitOpen = errors.New("mpesa: circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState uint8
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+
+	// CircuitOpen fails every request with ErrCircuitOpen.
+	CircuitOpen
+
+	// CircuitHalfOpen lets a few probe requests through to find out whether Daraja recovered.
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type (
+	// CircuitBreakerConfig configures a CircuitBreaker.
+	CircuitBreakerConfig struct {
+		// FailureThreshold is the number of consecutive failed requests that open the circuit. Defaults to 5.
+		FailureThreshold int
+
+		// OpenDuration is how long the circuit stays open before probe requests are let through. Defaults to 30s.
+		OpenDuration time.Duration
+
+		// HalfOpenProbes is the number of probe requests let through once OpenDuration elapsed. The circuit closes
+		// when they all succeed and opens again as soon as one fails. Defaults to 1.
+		HalfOpenProbes int
+	}
+
+	// CircuitBreaker stops the requests to Daraja during an outage so that they fail fast with ErrCircuitOpen
+	// instead of each waiting for its timeout. Requests that fail with a network error or a 5xx status are failures,
+	// the other responses, including the rejected requests, are successes. Requests cancelled by their context are
+	// not counted.
+	//
+	// Share the breaker between the apps calling the same Daraja environment, e.g. the apps of a TenantManager.
+	CircuitBreaker struct {
+		mu       sync.Mutex
+		cfg      CircuitBreakerConfig
+		state    CircuitState
+		failures int
+		openedAt time.Time
+		probes   int
+		passed   int
+		now      func() time.Time
+	}
+)
+
+// NewCircuitBreaker creates a closed CircuitBreaker.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = defaultCircuitFailureThreshold
+	}
+
+	if cfg.OpenDuration <= 0 {
+		cfg.OpenDuration = defaultCircuitOpenDuration
+	}
+
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = defaultCircuitHalfOpenProbes
+	}
+
+	return &CircuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// WithCircuitBreaker makes the app check the breaker before every request to Daraja, including the access token
+// requests, and report their outcome to it.
+//
+//	breaker := mpesa.NewCircuitBreaker(mpesa.CircuitBreakerConfig{FailureThreshold: 5, OpenDuration: time.Minute})
+//	app := mpesa.NewApp(client, key, secret, env, mpesa.WithCircuitBreaker(breaker))
+func WithCircuitBreaker(b *CircuitBreaker) Option {
+	return func(m *Mpesa) {
+		m.breaker = b
+	}
+}
+
+// State returns the current state of the breaker. An open breaker whose OpenDuration elapsed is reported half-open.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && !b.now().Before(b.openedAt.Add(b.cfg.OpenDuration)) {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if the request cannot be made. A request allowed while the circuit is half-open is one
+// of its probes.
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if b.now().Before(b.openedAt.Add(b.cfg.OpenDuration)) {
+			return ErrCircuitOpen
+		}
+
+		b.state, b.probes, b.passed = CircuitHalfOpen, 0, 0
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+// done records the outcome of a request let through by allow.
+func (b *CircuitBreaker) done(ctx context.Context, res *http.Response, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := err != nil || res.StatusCode >= http.StatusInternalServerError
+
+	if err != nil && ctx.Err() != nil {
+		// The request was abandoned by the caller, free its probe without counting it.
+		if b.state == CircuitHalfOpen && b.probes > 0 {
+			b.probes--
+		}
+		return
+	}
+
+	switch {
+	case b.state == CircuitHalfOpen && failed:
+		b.open()
+	case b.state == CircuitHalfOpen:
+		b.passed++
+		if b.passed >= b.cfg.HalfOpenProbes {
+			b.state, b.failures = CircuitClosed, 0
+		}
+	case b.state == CircuitClosed && failed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.open()
+		}
+	case b.state == CircuitClosed:
+		b.failures = 0
+	}
+}
+
+// open opens the circuit. It must be called with b.mu held.
+func (b *CircuitBreaker) open() {
+	b.state, b.openedAt, b.failures = CircuitOpen, b.now(), 0
+}
@@ -0,0 +1,80 @@
+package mpesa
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithCircuitBreaker(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		cl      = newMockHttpClient()
+		now     = time.Now()
+		status  = http.StatusServiceUnavailable
+		breaker = NewCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenDuration:     time.Minute,
+			HalfOpenProbes:   2,
+		})
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCircuitBreaker(breaker))
+	)
+
+	breaker.now = func() time.Time { return now }
+
+	mockAuth(app, cl)
+	cl.MockRequest(app.endpointSTKQuery(), func() (int, string) {
+		if status != http.StatusOK {
+			return status, `{"requestId": "11728-2929992-1", "errorCode": "500.003.02", ` +
+				`"errorMessage": "System is busy"}`
+		}
+
+		return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0", ` +
+			`"ResultDesc": "The service request is processed successfully."}`
+	})
+
+	query := func() error {
+		_, err := app.STKQuery(ctx, "passkey", STKQueryRequest{
+			BusinessShortCode: 174379,
+			CheckoutRequestID: "ws_CO_191220191020363925",
+		})
+		return err
+	}
+
+	requests := func() int { return len(cl.requests) }
+
+	for i := 0; i < 2; i++ {
+		err := query()
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+	require.Equal(t, CircuitOpen, breaker.State())
+
+	sent := requests()
+	require.ErrorIs(t, query(), ErrCircuitOpen)
+	require.Equal(t, sent, requests())
+
+	now = now.Add(time.Minute)
+	require.Equal(t, CircuitHalfOpen, breaker.State())
+
+	require.Error(t, query())
+	require.Equal(t, CircuitOpen, breaker.State())
+	require.ErrorIs(t, query(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	status = http.StatusOK
+
+	require.NoError(t, query())
+	require.Equal(t, CircuitHalfOpen, breaker.State())
+	require.NoError(t, query())
+	require.Equal(t, CircuitClosed, breaker.State())
+
+	status = http.StatusBadRequest
+	for i := 0; i < 3; i++ {
+		require.Error(t, query())
+	}
+	require.Equal(t, CircuitClosed, breaker.State())
+}
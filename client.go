@@ -0,0 +1,53 @@
+package mpesa
+
+import "context"
+
+// Client is the set of API methods of an app. Depend on it instead of *Mpesa to replace the app with a mock in unit
+// tests. *Mpesa implements it.
+type Client interface {
+	// Environment returns the environment the app is running on.
+	Environment() Environment
+
+	// Currency returns the currency of the amounts of the app.
+	Currency() Currency
+
+	// GenerateAccessToken returns the access token of the app.
+	GenerateAccessToken(ctx context.Context) (string, error)
+
+	// GenerateSecurityCredential encrypts the initiator password with the certificate of the app's environment.
+	GenerateSecurityCredential(initiatorPwd string) (string, error)
+
+	// STKPush prompts a customer to pay with the Lipa Na M-Pesa Online API.
+	STKPush(ctx context.Context, passkey string, req STKPushRequest) (*STKPushResponse, error)
+
+	// STKQuery checks the status of an STKPush request.
+	STKQuery(ctx context.Context, passkey string, req STKQueryRequest) (*STKQueryResponse, error)
+
+	// B2C sends money from a shortcode to a customer.
+	B2C(ctx context.Context, initiatorPwd string, req B2CRequest) (*B2CResponse, error)
+
+	// BusinessPayBill sends money from a shortcode to a paybill.
+	BusinessPayBill(ctx context.Context, initiatorPwd string, req BusinessPayBillRequest) (*AsyncResponse, error)
+
+	// BusinessBuyGoods sends money from a shortcode to a till.
+	BusinessBuyGoods(ctx context.Context, initiatorPwd string, req BusinessBuyGoodsRequest) (*AsyncResponse, error)
+
+	// RegisterC2BURL registers the URLs that receive the C2B payments of a shortcode.
+	RegisterC2BURL(ctx context.Context, req RegisterC2BURLRequest) (*C2BRegisterResponse, error)
+
+	// DynamicQR generates a QR code customers scan to pay.
+	DynamicQR(
+		ctx context.Context, req DynamicQRRequest, transactionType DynamicQRTransactionType, decodeImage bool,
+	) (*DynamicQRResponse, error)
+
+	// GetTransactionStatus checks the status of a transaction.
+	GetTransactionStatus(ctx context.Context, initiatorPwd string, req TransactionStatusRequest) (*AsyncResponse, error)
+
+	// Reversal reverses a C2B transaction.
+	Reversal(ctx context.Context, initiatorPwd string, req ReversalRequest) (*AsyncResponse, error)
+
+	// GetAccountBalance fetches the account balance of a shortcode.
+	GetAccountBalance(ctx context.Context, initiatorPwd string, req AccountBalanceRequest) (*AsyncResponse, error)
+}
+
+var _ Client = (*Mpesa)(nil)
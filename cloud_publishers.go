@@ -0,0 +1,125 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// The cloud publishers below encode events in the shape expected by the respective services and hand them over to a
+// send function. This keeps the SDK free of the cloud provider dependencies: wire the send function to the client
+// from the provider's SDK, e.g. for Google Pub/Sub:
+//
+//	publisher := mpesa.NewPubSubPublisher(func(ctx context.Context, msg mpesa.PubSubMessage) error {
+//		_, err := topic.Publish(ctx, &pubsub.Message{
+//			Data:        msg.Data,
+//			Attributes:  msg.Attributes,
+//			OrderingKey: msg.OrderingKey,
+//		}).Get(ctx)
+//		return err
+//	})
+
+type (
+	// PubSubMessage is an event encoded for Google Cloud Pub/Sub.
+	PubSubMessage struct {
+		// Data is the JSON encoded Event.
+		Data []byte
+
+		// Attributes hold the event kind, correlation ID and result code for subscription filters.
+		Attributes map[string]string
+
+		// OrderingKey is the correlation ID so that the events of a transaction are delivered in order when message
+		// ordering is enabled on the topic.
+		OrderingKey string
+	}
+
+	// SNSMessage is an event encoded for an AWS SNS topic.
+	SNSMessage struct {
+		// Message is the JSON encoded Event.
+		Message string
+
+		// MessageAttributes hold the event kind, correlation ID and result code for subscription filter policies.
+		MessageAttributes map[string]string
+
+		// MessageGroupID and MessageDeduplicationID are only used by FIFO topics. The group is the correlation ID
+		// and the de-duplication ID is the event ID.
+		MessageGroupID         string
+		MessageDeduplicationID string
+	}
+
+	// SQSMessage is an event encoded for an AWS SQS queue.
+	SQSMessage struct {
+		// Body is the JSON encoded Event.
+		Body string
+
+		// MessageAttributes hold the event kind, correlation ID and result code.
+		MessageAttributes map[string]string
+
+		// MessageGroupID and MessageDeduplicationID are only used by FIFO queues. The group is the correlation ID
+		// and the de-duplication ID is the event ID.
+		MessageGroupID         string
+		MessageDeduplicationID string
+	}
+)
+
+// NewPubSubPublisher returns an EventPublisher that encodes events as a PubSubMessage and publishes them using send.
+func NewPubSubPublisher(send func(ctx context.Context, msg PubSubMessage) error) EventPublisher {
+	return EventPublisherFunc(func(ctx context.Context, event Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("mpesa: marshal event: %v", err)
+		}
+
+		if err = send(ctx, PubSubMessage{
+			Data:        data,
+			Attributes:  event.attributes(),
+			OrderingKey: event.CorrelationID,
+		}); err != nil {
+			return fmt.Errorf("mpesa: publish to pubsub: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// NewSNSPublisher returns an EventPublisher that encodes events as an SNSMessage and publishes them using send.
+func NewSNSPublisher(send func(ctx context.Context, msg SNSMessage) error) EventPublisher {
+	return EventPublisherFunc(func(ctx context.Context, event Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("mpesa: marshal event: %v", err)
+		}
+
+		if err = send(ctx, SNSMessage{
+			Message:                string(data),
+			MessageAttributes:      event.attributes(),
+			MessageGroupID:         event.CorrelationID,
+			MessageDeduplicationID: event.ID,
+		}); err != nil {
+			return fmt.Errorf("mpesa: publish to sns: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// NewSQSPublisher returns an EventPublisher that encodes events as an SQSMessage and sends them using send.
+func NewSQSPublisher(send func(ctx context.Context, msg SQSMessage) error) EventPublisher {
+	return EventPublisherFunc(func(ctx context.Context, event Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("mpesa: marshal event: %v", err)
+		}
+
+		if err = send(ctx, SQSMessage{
+			Body:                   string(data),
+			MessageAttributes:      event.attributes(),
+			MessageGroupID:         event.CorrelationID,
+			MessageDeduplicationID: event.ID,
+		}); err != nil {
+			return fmt.Errorf("mpesa: send to sqs: %w", err)
+		}
+
+		return nil
+	})
+}
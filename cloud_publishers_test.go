@@ -0,0 +1,62 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudPublishers(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		event     = testEvent(t)
+		wantAttrs = map[string]string{
+			"kind":           "STKPush",
+			"correlation_id": "ws_CO_191220191020363925",
+			"result_code":    "1032",
+		}
+	)
+
+	decode := func(t *testing.T, data []byte) Event {
+		var got Event
+		require.NoError(t, json.Unmarshal(data, &got))
+		return got
+	}
+
+	t.Run("pubsub", func(t *testing.T) {
+		publisher := NewPubSubPublisher(func(_ context.Context, msg PubSubMessage) error {
+			require.Equal(t, event.ID, decode(t, msg.Data).ID)
+			require.Equal(t, wantAttrs, msg.Attributes)
+			require.Equal(t, event.CorrelationID, msg.OrderingKey)
+			return nil
+		})
+
+		require.NoError(t, publisher.Publish(ctx, event))
+	})
+
+	t.Run("sns", func(t *testing.T) {
+		publisher := NewSNSPublisher(func(_ context.Context, msg SNSMessage) error {
+			require.Equal(t, event.ID, decode(t, []byte(msg.Message)).ID)
+			require.Equal(t, wantAttrs, msg.MessageAttributes)
+			require.Equal(t, event.CorrelationID, msg.MessageGroupID)
+			require.Equal(t, event.ID, msg.MessageDeduplicationID)
+			return nil
+		})
+
+		require.NoError(t, publisher.Publish(ctx, event))
+	})
+
+	t.Run("sqs", func(t *testing.T) {
+		sendErr := errors.New("queue does not exist")
+		publisher := NewSQSPublisher(func(_ context.Context, msg SQSMessage) error {
+			require.Equal(t, event.ID, decode(t, []byte(msg.Body)).ID)
+			require.Equal(t, event.ID, msg.MessageDeduplicationID)
+			return sendErr
+		})
+
+		require.ErrorIs(t, publisher.Publish(ctx, event), sendErr)
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+)
+
+// stkPush prompts a customer to pay using an STK push.
+func stkPush(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		o               options
+		fs              = newFlagSet("stk push", stderr, &o)
+		phone           = fs.String("phone", "", "phone number of the customer")
+		amount          = fs.Uint("amount", 0, "amount to charge in whole units")
+		reference       = fs.String("reference", "CLI", "account reference")
+		description     = fs.String("description", "Payment", "transaction description")
+		callbackURL     = fs.String("callback-url", "", "URL of the callback, defaults to the configured one")
+		transactionType = fs.String("type", mpesa.CustomerPayBillOnlineTransactionType,
+			"transaction type, either CustomerPayBillOnline or CustomerBuyGoodsOnline")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return o.call(ctx, stdout, stderr, func(ctx context.Context, app *mpesa.Mpesa, _ mpesa.AppConfig) (any, error) {
+		return app.STKPush(ctx, "", mpesa.STKPushRequest{
+			TransactionType:  mpesa.TransactionType(*transactionType),
+			Amount:           *amount,
+			Phone:            *phone,
+			CallBackURL:      *callbackURL,
+			AccountReference: *reference,
+			TransactionDesc:  *description,
+		})
+	})
+}
+
+// stkQuery queries the status of an STK push.
+func stkQuery(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		o                 options
+		fs                = newFlagSet("stk query", stderr, &o)
+		checkoutRequestID = fs.String("checkout-request-id", "", "CheckoutRequestID of the STK push")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return o.call(ctx, stdout, stderr, func(ctx context.Context, app *mpesa.Mpesa, _ mpesa.AppConfig) (any, error) {
+		return app.STKQuery(ctx, "", mpesa.STKQueryRequest{CheckoutRequestID: *checkoutRequestID})
+	})
+}
+
+// b2c sends money to a customer.
+func b2c(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		o               options
+		fs              = newFlagSet("b2c", stderr, &o)
+		phone           = fs.String("phone", "", "phone number of the customer")
+		amount          = fs.Uint("amount", 0, "amount to send in whole units")
+		commandID       = fs.String("command", string(mpesa.BusinessPaymentCommandID), "CommandID of the payment")
+		remarks         = fs.String("remarks", "CLI payment", "remarks of the payment")
+		occasion        = fs.String("occasion", "", "occasion of the payment")
+		resultURL       = fs.String("result-url", "", "URL of the result, defaults to the configured one")
+		queueTimeOutURL = fs.String("timeout-url", "", "URL of the queue timeout, defaults to the configured one")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return o.call(ctx, stdout, stderr, func(ctx context.Context, app *mpesa.Mpesa, _ mpesa.AppConfig) (any, error) {
+		return app.B2C(ctx, "", mpesa.B2CRequest{
+			CommandID:       mpesa.CommandID(*commandID),
+			Amount:          *amount,
+			Phone:           *phone,
+			Remarks:         *remarks,
+			Occasion:        *occasion,
+			ResultURL:       *resultURL,
+			QueueTimeOutURL: *queueTimeOutURL,
+		})
+	})
+}
+
+// balance requests the balance of the shortcode, which is sent to the result URL.
+func balance(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		o               options
+		fs              = newFlagSet("balance", stderr, &o)
+		remarks         = fs.String("remarks", "CLI balance", "remarks of the request")
+		resultURL       = fs.String("result-url", "", "URL of the result, defaults to the configured one")
+		queueTimeOutURL = fs.String("timeout-url", "", "URL of the queue timeout, defaults to the configured one")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return o.call(ctx, stdout, stderr, func(ctx context.Context, app *mpesa.Mpesa, cfg mpesa.AppConfig) (any, error) {
+		return app.GetAccountBalance(ctx, "", mpesa.AccountBalanceRequest{
+			PartyA:          int(cfg.ShortCode),
+			Remarks:         *remarks,
+			ResultURL:       *resultURL,
+			QueueTimeOutURL: *queueTimeOutURL,
+		})
+	})
+}
+
+// qr generates a dynamic QR code and, with -out, saves its PNG image.
+func qr(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		o               options
+		fs              = newFlagSet("qr", stderr, &o)
+		merchant        = fs.String("merchant", "", "name of the merchant")
+		cpi             = fs.String("cpi", "", "credit party identifier, e.g. the till or paybill number")
+		amount          = fs.Uint("amount", 0, "amount in whole units")
+		reference       = fs.String("reference", "CLI", "reference of the transaction")
+		size            = fs.Int("size", 0, "size of the image in pixels")
+		transactionType = fs.String("type", string(mpesa.PayMerchantBuyGoods), "transaction type, e.g. BG or PB")
+		out             = fs.String("out", "", "file to save the PNG image to")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return o.call(ctx, stdout, stderr, func(ctx context.Context, app *mpesa.Mpesa, _ mpesa.AppConfig) (any, error) {
+		req := mpesa.DynamicQRRequest{
+			Amount:                *amount,
+			CreditPartyIdentifier: *cpi,
+			MerchantName:          *merchant,
+			ReferenceNo:           *reference,
+			Size:                  *size,
+		}
+
+		res, err := app.DynamicQR(ctx, req, mpesa.DynamicQRTransactionType(*transactionType), *out != "")
+		if err != nil || *out == "" {
+			return res, err
+		}
+
+		if err = os.WriteFile(*out, res.ImageData, 0o644); err != nil {
+			return nil, fmt.Errorf("mpesa: save qr: %v", err)
+		}
+
+		return res, nil
+	})
+}
+
+// c2bRegister registers the C2B confirmation and validation URLs of the shortcode.
+func c2bRegister(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		o               options
+		fs              = newFlagSet("c2b register", stderr, &o)
+		confirmationURL = fs.String("confirmation-url", "", "confirmation URL, defaults to the configured one")
+		validationURL   = fs.String("validation-url", "", "validation URL, defaults to the configured one")
+		responseType    = fs.String("response-type", string(mpesa.ResponseTypeComplete),
+			"action when the validation URL is unreachable, either Completed or Canceled")
+	)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return o.call(ctx, stdout, stderr, func(ctx context.Context, app *mpesa.Mpesa, _ mpesa.AppConfig) (any, error) {
+		return app.RegisterC2BURL(ctx, mpesa.RegisterC2BURLRequest{
+			ResponseType:    mpesa.ResponseType(*responseType),
+			ConfirmationURL: *confirmationURL,
+			ValidationURL:   *validationURL,
+		})
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+	"github.com/jwambugu/mpesa-golang-sdk/server"
+)
+
+// listener is a callback server that prints the callbacks it receives.
+type listener struct {
+	srv      *http.Server
+	ln       net.Listener
+	received chan struct{}
+	once     sync.Once
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// listen starts a listener on addr that prints the callbacks to out.
+func listen(addr string, out io.Writer) (*listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: listen for callbacks: %v", err)
+	}
+
+	l := &listener{ln: ln, received: make(chan struct{}), out: out}
+
+	handler := server.New(addr, server.Handlers{
+		STKPush:         printCallback[mpesa.STKPushCallback](l),
+		B2CResult:       printCallback[mpesa.Callback](l),
+		B2CTimeout:      printCallback[mpesa.QueueTimeoutCallback](l),
+		C2BValidation:   printCallback[mpesa.C2BCallback](l),
+		C2BConfirmation: printCallback[mpesa.C2BCallback](l),
+	}).Handler()
+
+	l.srv = &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		_ = l.srv.Serve(ln)
+	}()
+
+	return l, nil
+}
+
+// printCallback returns a callback handler function that prints the callback.
+func printCallback[T any](l *listener) func(ctx context.Context, callback *T) error {
+	return func(_ context.Context, callback *T) error {
+		l.mu.Lock()
+		err := printJSON(l.out, callback)
+		l.mu.Unlock()
+
+		l.once.Do(func() { close(l.received) })
+		return err
+	}
+}
+
+// Addr returns the address the listener listens on.
+func (l *listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+// Wait blocks until the first callback is received, the context is done or the timeout elapses.
+func (l *listener) Wait(ctx context.Context, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-l.received:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.New("mpesa: no callback received")
+	}
+}
+
+// Close stops the listener.
+func (l *listener) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return l.srv.Shutdown(ctx)
+}
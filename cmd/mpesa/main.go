@@ -0,0 +1,75 @@
+// Command mpesa calls the Daraja APIs from the terminal, e.g. to try the sandbox or in the runbooks of a deployment.
+//
+//	mpesa stk push -phone 0708374149 -amount 10 -reference INV-001
+//	mpesa stk query -checkout-request-id ws_CO_260520211133524545
+//	mpesa b2c -phone 0708374149 -amount 100 -remarks Refund
+//	mpesa balance
+//	mpesa qr -merchant "Test Supermarket" -cpi 373132 -amount 500 -reference INV-001 -out qr.png
+//	mpesa c2b register -confirmation-url https://example.com/confirm -validation-url https://example.com/validate
+//
+// The credentials are read from the config file set with -config and the MPESA_ environment variables, see
+// mpesa.LoadConfig, and can be overridden with flags. The responses are printed as JSON.
+//
+// With -listen, a callback listener is started before the request is made and the first callback it receives is
+// printed. It serves the paths of the server package, e.g. /stk and /b2c/result, so expose it with a tunnel and set the
+// callback URLs to the tunnel URL and these paths.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+)
+
+// command runs a subcommand with its arguments.
+type command func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+
+// commands are the subcommands keyed by their name.
+var commands = map[string]command{
+	"stk push":     stkPush,
+	"stk query":    stkQuery,
+	"b2c":          b2c,
+	"balance":      balance,
+	"qr":           qr,
+	"c2b register": c2bRegister,
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// run runs the subcommand named by the first arguments, e.g. "stk push".
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	for n := 2; n > 0; n-- {
+		if len(args) < n {
+			continue
+		}
+
+		if cmd, ok := commands[strings.Join(args[:n], " ")]; ok {
+			return cmd(ctx, args[n:], stdout, stderr)
+		}
+	}
+
+	return fmt.Errorf("usage: mpesa <command> [flags]\n\ncommands:\n  %s", strings.Join(commandNames(), "\n  "))
+}
+
+// commandNames returns the names of the subcommands sorted alphabetically.
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
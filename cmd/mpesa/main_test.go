@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+	"github.com/jwambugu/mpesa-golang-sdk/mpesatest"
+	"github.com/stretchr/testify/require"
+)
+
+// setEnv sets the environment variables of the default app and restores them once the test completes, including the
+// ones set from the flags.
+func setEnv(t *testing.T) {
+	t.Helper()
+
+	for key, value := range map[string]string{
+		"MPESA_CONSUMER_KEY":       "CONSUMER_KEY",
+		"MPESA_CONSUMER_SECRET":    "CONSUMER_SECRET",
+		"MPESA_SHORTCODE":          "174379",
+		"MPESA_PASSKEY":            "passkey",
+		"MPESA_INITIATOR_NAME":     "testapi",
+		"MPESA_INITIATOR_PASSWORD": "Safaricom999!*!",
+		"MPESA_ENVIRONMENT":        "sandbox",
+	} {
+		t.Setenv(key, value)
+	}
+}
+
+// freeAddr returns a local address that is free to listen on.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	return addr
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it lists the commands", func(t *testing.T) {
+		err := run(ctx, []string{"stk"}, &bytes.Buffer{}, &bytes.Buffer{})
+		require.ErrorContains(t, err, "stk push")
+		require.ErrorContains(t, err, "c2b register")
+	})
+
+	t.Run("it pushes an stk and prints the callback", func(t *testing.T) {
+		setEnv(t)
+		addr := freeAddr(t)
+
+		// The simulator sends the callbacks to the listener instead of the public callback URL.
+		srv := mpesatest.NewServer(mpesatest.WithCallbackHandler(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				res, err := http.Post("http://"+addr+r.URL.Path, "application/json", r.Body)
+				require.NoError(t, err)
+				defer res.Body.Close()
+				w.WriteHeader(res.StatusCode)
+			},
+		)))
+		defer srv.Close()
+
+		var stdout, stderr bytes.Buffer
+		err := run(ctx, []string{
+			"stk", "push",
+			"-base-url", srv.URL(),
+			"-listen", addr,
+			"-phone", "0708374149",
+			"-amount", "10",
+			"-callback-url", "https://example.com/stk",
+		}, &stdout, &stderr)
+		require.NoError(t, err)
+		require.Contains(t, stderr.String(), "listening for callbacks on "+addr)
+
+		dec := json.NewDecoder(&stdout)
+
+		var res mpesa.STKPushResponse
+		require.NoError(t, dec.Decode(&res))
+		require.Equal(t, "0", res.ResponseCode)
+
+		var callback mpesa.STKPushCallback
+		require.NoError(t, dec.Decode(&callback))
+		require.Equal(t, res.CheckoutRequestID, callback.Body.STKCallback.CheckoutRequestID)
+	})
+
+	t.Run("it uses the credentials set by the flags", func(t *testing.T) {
+		setEnv(t)
+
+		srv := mpesatest.NewServer(mpesatest.WithCredentials("FLAG_KEY", "FLAG_SECRET"))
+		defer srv.Close()
+
+		var stdout bytes.Buffer
+		err := run(ctx, []string{
+			"c2b", "register",
+			"-base-url", srv.URL(),
+			"-consumer-key", "FLAG_KEY",
+			"-consumer-secret", "FLAG_SECRET",
+			"-confirmation-url", "https://example.com/c2b/confirmation",
+			"-validation-url", "https://example.com/c2b/validation",
+		}, &stdout, &bytes.Buffer{})
+		require.NoError(t, err)
+
+		var res mpesa.C2BRegisterResponse
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &res))
+		require.Equal(t, "0", res.ResponseCode)
+	})
+
+	t.Run("it fails without credentials", func(t *testing.T) {
+		setEnv(t)
+		t.Setenv("MPESA_CONSUMER_KEY", "")
+
+		err := run(ctx, []string{"stk", "query", "-checkout-request-id", "ws_CO_1"}, &bytes.Buffer{}, &bytes.Buffer{})
+		require.ErrorIs(t, err, mpesa.ErrInvalidConfig)
+	})
+}
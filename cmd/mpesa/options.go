@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+)
+
+// options are the flags shared by the subcommands.
+type options struct {
+	config  string
+	app     string
+	baseURL string
+	timeout time.Duration
+
+	environment       string
+	consumerKey       string
+	consumerSecret    string
+	shortCode         uint
+	passkey           string
+	initiatorName     string
+	initiatorPassword string
+
+	listen string
+	wait   time.Duration
+}
+
+// newFlagSet returns the flag set of the subcommand with the shared flags registered on it.
+func newFlagSet(name string, stderr io.Writer, o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("mpesa "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	fs.StringVar(&o.config, "config", "", "JSON or YAML config file, see mpesa.LoadConfig")
+	fs.StringVar(&o.app, "app", mpesa.DefaultAppName, "name of the app of the config to use")
+	fs.StringVar(&o.baseURL, "base-url", "", "base URL of the Daraja API, e.g. of a simulator")
+	fs.DurationVar(&o.timeout, "timeout", 30*time.Second, "timeout of the request")
+
+	fs.StringVar(&o.environment, "env", "", "environment, either sandbox or production")
+	fs.StringVar(&o.consumerKey, "consumer-key", "", "consumer key of the app")
+	fs.StringVar(&o.consumerSecret, "consumer-secret", "", "consumer secret of the app")
+	fs.UintVar(&o.shortCode, "shortcode", 0, "shortcode of the app")
+	fs.StringVar(&o.passkey, "passkey", "", "passkey of the STK push requests")
+	fs.StringVar(&o.initiatorName, "initiator-name", "", "name of the API operator")
+	fs.StringVar(&o.initiatorPassword, "initiator-password", "", "password of the API operator")
+
+	fs.StringVar(&o.listen, "listen", "", "address of the callback listener to start, e.g. :8080")
+	fs.DurationVar(&o.wait, "wait", 2*time.Minute, "how long the callback listener waits for a callback")
+
+	return fs
+}
+
+// newApp creates the app from the config and the environment, overridden by the flags. The config of the app is
+// returned along with it.
+func (o *options) newApp() (*mpesa.Mpesa, mpesa.AppConfig, error) {
+	// The flags are set as environment variables as those take precedence over the config file.
+	overrides := map[string]string{
+		"ENVIRONMENT":        o.environment,
+		"CONSUMER_KEY":       o.consumerKey,
+		"CONSUMER_SECRET":    o.consumerSecret,
+		"PASSKEY":            o.passkey,
+		"INITIATOR_NAME":     o.initiatorName,
+		"INITIATOR_PASSWORD": o.initiatorPassword,
+	}
+	if o.shortCode != 0 {
+		overrides["SHORTCODE"] = strconv.FormatUint(uint64(o.shortCode), 10)
+	}
+
+	prefix := "MPESA_"
+	if o.app != mpesa.DefaultAppName {
+		prefix += strings.ToUpper(o.app) + "_"
+	}
+
+	for field, value := range overrides {
+		if value == "" {
+			continue
+		}
+
+		if err := os.Setenv(prefix+field, value); err != nil {
+			return nil, mpesa.AppConfig{}, err
+		}
+	}
+
+	cfg, err := mpesa.LoadConfig(o.config)
+	if err != nil {
+		return nil, mpesa.AppConfig{}, err
+	}
+
+	appCfg, ok := cfg.Apps[o.app]
+	if !ok {
+		return nil, mpesa.AppConfig{}, fmt.Errorf("mpesa: app %q is not configured", o.app)
+	}
+
+	opts := []mpesa.Option{mpesa.WithHTTPTimeout(o.timeout)}
+	if o.baseURL != "" {
+		opts = append(opts, mpesa.WithBaseURL(o.baseURL))
+	}
+
+	app, err := mpesa.NewAppFromConfig(nil, cfg, opts...)
+	if err != nil {
+		return nil, mpesa.AppConfig{}, err
+	}
+
+	return app.WithShortcode(o.app), appCfg, nil
+}
+
+// request makes an API request with the app and returns its response.
+type request func(ctx context.Context, app *mpesa.Mpesa, cfg mpesa.AppConfig) (any, error)
+
+// call makes the request, prints its response and, if a callback listener is set, waits for the first callback.
+func (o *options) call(ctx context.Context, stdout, stderr io.Writer, fn request) error {
+	app, cfg, err := o.newApp()
+	if err != nil {
+		return err
+	}
+
+	var l *listener
+	if o.listen != "" {
+		if l, err = listen(o.listen, stdout); err != nil {
+			return err
+		}
+		defer l.Close()
+
+		fmt.Fprintf(stderr, "listening for callbacks on %s\n", l.Addr())
+
+		// The callbacks received before the response is printed wait for it.
+		l.mu.Lock()
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, o.timeout)
+	defer cancel()
+
+	res, err := fn(reqCtx, app, cfg)
+	if err == nil {
+		err = printJSON(stdout, res)
+	}
+
+	if l == nil {
+		return err
+	}
+
+	l.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return l.Wait(ctx, o.wait)
+}
+
+// printJSON writes v to w as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
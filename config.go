@@ -0,0 +1,268 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultAppName is the name of the app configured by the environment variables without an app name, e.g.
+// MPESA_CONSUMER_KEY.
+const DefaultAppName = "default"
+
+// configEnvPrefix is the prefix of the environment variables read by LoadConfig.
+const configEnvPrefix = "MPESA_"
+
+// ErrInvalidConfig is returned when a config cannot be loaded or is incomplete.
+var ErrInvalidConfig = errors.New("mpesa: invalid config")
+
+type (
+	// Config holds the Daraja apps of a business keyed by name, e.g. "c2b" and "b2c".
+	Config struct {
+		Apps map[string]AppConfig `json:"apps" yaml:"apps"`
+	}
+
+	// AppConfig holds the settings of a Daraja app and the shortcode it is used with.
+	AppConfig struct {
+		// Environment is either "sandbox", which is the default, or "production".
+		Environment Environment `json:"environment" yaml:"environment"`
+
+		// ConsumerKey and ConsumerSecret are the credentials of the app on the Daraja portal.
+		ConsumerKey    string `json:"consumer_key" yaml:"consumer_key"`
+		ConsumerSecret string `json:"consumer_secret" yaml:"consumer_secret"`
+
+		// ShortCode is set on the requests that do not have a shortcode.
+		ShortCode uint `json:"shortcode" yaml:"shortcode"`
+
+		// Passkey is used for the STKPush and STKQuery requests made with an empty passkey.
+		Passkey string `json:"passkey" yaml:"passkey"`
+
+		// InitiatorName and InitiatorPassword are used for the requests made with an empty initiator password.
+		InitiatorName     string `json:"initiator_name" yaml:"initiator_name"`
+		InitiatorPassword string `json:"initiator_password" yaml:"initiator_password"`
+
+		// Callbacks are set on the requests that do not have them.
+		Callbacks CallbackURLs `json:"callbacks" yaml:"callbacks"`
+	}
+)
+
+// configEnvFields maps the environment variable suffixes to the AppConfig fields they set.
+var configEnvFields = map[string]func(app *AppConfig, value string) error{
+	"ENVIRONMENT": func(app *AppConfig, value string) error {
+		return app.Environment.UnmarshalText([]byte(value))
+	},
+	"CONSUMER_KEY":    func(app *AppConfig, value string) error { app.ConsumerKey = value; return nil },
+	"CONSUMER_SECRET": func(app *AppConfig, value string) error { app.ConsumerSecret = value; return nil },
+	"SHORTCODE": func(app *AppConfig, value string) error {
+		shortCode, err := strconv.ParseUint(value, 10, 0)
+		app.ShortCode = uint(shortCode)
+		return err
+	},
+	"PASSKEY":            func(app *AppConfig, value string) error { app.Passkey = value; return nil },
+	"INITIATOR_NAME":     func(app *AppConfig, value string) error { app.InitiatorName = value; return nil },
+	"INITIATOR_PASSWORD": func(app *AppConfig, value string) error { app.InitiatorPassword = value; return nil },
+	"CALLBACK_URL":       func(app *AppConfig, value string) error { app.Callbacks.CallBackURL = value; return nil },
+	"RESULT_URL":         func(app *AppConfig, value string) error { app.Callbacks.ResultURL = value; return nil },
+	"QUEUE_TIMEOUT_URL": func(app *AppConfig, value string) error {
+		app.Callbacks.QueueTimeOutURL = value
+		return nil
+	},
+	"CONFIRMATION_URL": func(app *AppConfig, value string) error {
+		app.Callbacks.ConfirmationURL = value
+		return nil
+	},
+	"VALIDATION_URL": func(app *AppConfig, value string) error { app.Callbacks.ValidationURL = value; return nil },
+}
+
+// String returns the name of the environment, either "sandbox" or "production".
+func (e Environment) String() string {
+	if e.IsProduction() {
+		return "production"
+	}
+
+	return "sandbox"
+}
+
+// MarshalText encodes the environment as its name.
+func (e Environment) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+// UnmarshalText decodes the name of an environment, either "sandbox" or "production".
+func (e *Environment) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "", "sandbox":
+		*e = EnvironmentSandbox
+	case "production":
+		*e = EnvironmentProduction
+	default:
+		return fmt.Errorf("%w: unknown environment %q", ErrInvalidConfig, text)
+	}
+
+	return nil
+}
+
+// LoadConfig loads the apps from the JSON or YAML file at path, chosen by its extension, and from the environment
+// variables, which take precedence. The variables are named MPESA_<APP>_<FIELD>, e.g. MPESA_B2C_CONSUMER_KEY, or
+// MPESA_<FIELD> for the DefaultAppName app. The fields are ENVIRONMENT, CONSUMER_KEY, CONSUMER_SECRET, SHORTCODE,
+// PASSKEY, INITIATOR_NAME, INITIATOR_PASSWORD, CALLBACK_URL, RESULT_URL, QUEUE_TIMEOUT_URL, CONFIRMATION_URL and
+// VALIDATION_URL. An empty path loads the environment variables only.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+
+		switch ext := strings.ToLower(filepath.Ext(path)); ext {
+		case ".json":
+			err = json.Unmarshal(b, cfg)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(b, cfg)
+		default:
+			err = fmt.Errorf("unsupported file extension %q", ext)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+		}
+	}
+
+	if err := cfg.loadEnv(os.Environ()); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadEnv sets the fields of the apps from the MPESA_ environment variables.
+func (c *Config) loadEnv(environ []string) error {
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(key, configEnvPrefix) {
+			continue
+		}
+
+		name, field := configEnvKey(strings.TrimPrefix(key, configEnvPrefix))
+		if field == "" {
+			continue
+		}
+
+		if c.Apps == nil {
+			c.Apps = make(map[string]AppConfig)
+		}
+
+		app := c.Apps[name]
+		if err := configEnvFields[field](&app, value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, key, err)
+		}
+		c.Apps[name] = app
+	}
+
+	return nil
+}
+
+// configEnvKey splits the environment variable key, without its prefix, into the app name and the field it sets. The
+// field is empty if the key does not set one.
+func configEnvKey(key string) (name, field string) {
+	for suffix := range configEnvFields {
+		if key == suffix {
+			return DefaultAppName, suffix
+		}
+
+		// The longest matching suffix wins so that the match does not depend on the order of the map.
+		if strings.HasSuffix(key, "_"+suffix) && len(suffix) > len(field) {
+			name, field = strings.ToLower(strings.TrimSuffix(key, "_"+suffix)), suffix
+		}
+	}
+
+	return name, field
+}
+
+// Validate checks that every app has its consumer key and secret.
+func (c *Config) Validate() error {
+	if len(c.Apps) == 0 {
+		return fmt.Errorf("%w: no apps configured", ErrInvalidConfig)
+	}
+
+	for _, name := range c.appNames() {
+		app := c.Apps[name]
+		if app.ConsumerKey == "" || app.ConsumerSecret == "" {
+			return fmt.Errorf("%w: app %q: consumer key and secret are required", ErrInvalidConfig, name)
+		}
+	}
+
+	return nil
+}
+
+// appNames returns the names of the apps sorted alphabetically.
+func (c *Config) appNames() []string {
+	names := make([]string, 0, len(c.Apps))
+	for name := range c.Apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewAppFromConfig creates the app of the DefaultAppName app or, if there is none, of the first app by name. Every app
+// of the config is registered as a shortcode profile under its name, e.g. app.WithShortcode("b2c").B2C(...). All the
+// apps must use the same environment. The opts take precedence over the config.
+func NewAppFromConfig(c HttpClient, cfg *Config, opts ...Option) (*Mpesa, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	names := cfg.appNames()
+
+	root, ok := cfg.Apps[DefaultAppName]
+	if !ok {
+		root = cfg.Apps[names[0]]
+	}
+
+	opts = append([]Option{func(m *Mpesa) {
+		m.shortCode = root.ShortCode
+		m.passkey = root.Passkey
+		m.initiator = root.initiator()
+		m.callbackURLs = root.Callbacks
+	}}, opts...)
+
+	app := NewApp(c, root.ConsumerKey, root.ConsumerSecret, root.Environment, opts...)
+
+	for _, name := range names {
+		profile := cfg.Apps[name]
+		if profile.Environment != root.Environment {
+			return nil, fmt.Errorf("%w: app %q: environment %s differs from %s", ErrInvalidConfig, name,
+				profile.Environment, root.Environment)
+		}
+
+		app.RegisterShortcode(name, ShortcodeProfile{
+			ShortCode:      profile.ShortCode,
+			ConsumerKey:    profile.ConsumerKey,
+			ConsumerSecret: profile.ConsumerSecret,
+			Passkey:        profile.Passkey,
+			Initiator:      profile.initiator(),
+			Callbacks:      profile.Callbacks,
+		})
+	}
+
+	return app, nil
+}
+
+func (a AppConfig) initiator() InitiatorCredentials {
+	return InitiatorCredentials{Name: a.InitiatorName, Password: a.InitiatorPassword}
+}
@@ -0,0 +1,142 @@
+package mpesa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "mpesa.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{
+		"apps": {
+			"c2b": {
+				"environment": "production",
+				"consumer_key": "c2b-key",
+				"consumer_secret": "c2b-secret",
+				"shortcode": 174379,
+				"passkey": "passkey",
+				"callbacks": {"callback_url": "https://example.com/stk"}
+			}
+		}
+	}`), 0600))
+
+	yamlPath := filepath.Join(dir, "mpesa.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte(`
+apps:
+  c2b:
+    environment: production
+    consumer_key: c2b-key
+    consumer_secret: c2b-secret
+    shortcode: 174379
+    passkey: passkey
+    callbacks:
+      callback_url: https://example.com/stk
+`), 0600))
+
+	want := AppConfig{
+		Environment:    EnvironmentProduction,
+		ConsumerKey:    "c2b-key",
+		ConsumerSecret: "c2b-secret",
+		ShortCode:      174379,
+		Passkey:        "passkey",
+		Callbacks:      CallbackURLs{CallBackURL: "https://example.com/stk"},
+	}
+
+	for _, path := range []string{jsonPath, yamlPath} {
+		cfg, err := LoadConfig(path)
+		require.NoError(t, err)
+		require.Equal(t, map[string]AppConfig{"c2b": want}, cfg.Apps)
+	}
+
+	t.Setenv("MPESA_C2B_PASSKEY", "env-passkey")
+	t.Setenv("MPESA_B2C_ENVIRONMENT", "production")
+	t.Setenv("MPESA_B2C_CONSUMER_KEY", "b2c-key")
+	t.Setenv("MPESA_B2C_CONSUMER_SECRET", "b2c-secret")
+	t.Setenv("MPESA_B2C_SHORTCODE", "600980")
+	t.Setenv("MPESA_B2C_INITIATOR_NAME", "testapi")
+	t.Setenv("MPESA_B2C_INITIATOR_PASSWORD", "Safaricom999!*!")
+	t.Setenv("MPESA_B2C_RESULT_URL", "https://example.com/result")
+	t.Setenv("MPESA_B2C_QUEUE_TIMEOUT_URL", "https://example.com/timeout")
+	t.Setenv("MPESA_UNRELATED", "value")
+
+	cfg, err := LoadConfig(yamlPath)
+	require.NoError(t, err)
+	require.Equal(t, "env-passkey", cfg.Apps["c2b"].Passkey)
+	require.Equal(t, AppConfig{
+		Environment:       EnvironmentProduction,
+		ConsumerKey:       "b2c-key",
+		ConsumerSecret:    "b2c-secret",
+		ShortCode:         600980,
+		InitiatorName:     "testapi",
+		InitiatorPassword: "Safaricom999!*!",
+		Callbacks: CallbackURLs{
+			ResultURL:       "https://example.com/result",
+			QueueTimeOutURL: "https://example.com/timeout",
+		},
+	}, cfg.Apps["b2c"])
+
+	t.Run("it fails on invalid configs", func(t *testing.T) {
+		_, err := LoadConfig(filepath.Join(dir, "mpesa.toml"))
+		require.ErrorIs(t, err, ErrInvalidConfig)
+
+		t.Setenv("MPESA_B2C_SHORTCODE", "invalid")
+		_, err = LoadConfig("")
+		require.ErrorIs(t, err, ErrInvalidConfig)
+
+		t.Setenv("MPESA_B2C_SHORTCODE", "600980")
+		t.Setenv("MPESA_B2C_ENVIRONMENT", "staging")
+		_, err = LoadConfig("")
+		require.ErrorIs(t, err, ErrInvalidConfig)
+
+		t.Setenv("MPESA_B2C_ENVIRONMENT", "production")
+		t.Setenv("MPESA_CONSUMER_KEY", "key")
+		_, err = LoadConfig("")
+		require.ErrorIs(t, err, ErrInvalidConfig)
+	})
+}
+
+func TestNewAppFromConfig(t *testing.T) {
+	cfg := &Config{Apps: map[string]AppConfig{
+		"c2b": {
+			ConsumerKey:    "c2b-key",
+			ConsumerSecret: "c2b-secret",
+			ShortCode:      174379,
+			Passkey:        "passkey",
+		},
+		"b2c": {
+			ConsumerKey:       "b2c-key",
+			ConsumerSecret:    "b2c-secret",
+			ShortCode:         600980,
+			InitiatorName:     "testapi",
+			InitiatorPassword: "Safaricom999!*!",
+			Callbacks:         CallbackURLs{ResultURL: "https://example.com/result"},
+		},
+	}}
+
+	app, err := NewAppFromConfig(nil, cfg)
+	require.NoError(t, err)
+	require.Equal(t, "b2c-key", app.consumerKey)
+	require.Equal(t, uint(600980), app.shortCode)
+
+	c2b := app.WithShortcode("c2b")
+	require.NoError(t, c2b.profileErr)
+	require.Equal(t, "c2b-key", c2b.consumerKey)
+	require.Equal(t, uint(174379), c2b.shortCode)
+	require.Equal(t, "passkey", c2b.passkey)
+
+	b2c := app.WithShortcode("b2c")
+	require.Equal(t, InitiatorCredentials{Name: "testapi", Password: "Safaricom999!*!"}, b2c.initiator)
+	require.Equal(t, "https://example.com/result", b2c.callbackURLs.ResultURL)
+
+	cfg.Apps["default"] = AppConfig{ConsumerKey: "key", ConsumerSecret: "secret", Environment: EnvironmentProduction}
+	_, err = NewAppFromConfig(nil, cfg)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewAppFromConfig(nil, &Config{})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+}
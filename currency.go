@@ -0,0 +1,89 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// Currencies of the M-Pesa markets.
+const (
+	CurrencyKES Currency = "KES"
+	CurrencyTZS Currency = "TZS"
+	CurrencyMZN Currency = "MZN"
+	CurrencyCDF Currency = "CDF"
+	CurrencyGHS Currency = "GHS"
+	CurrencyLSL Currency = "LSL"
+	CurrencyEGP Currency = "EGP"
+	CurrencyETB Currency = "ETB"
+)
+
+// DefaultCurrency is the currency assumed when none is set, the currency of the Daraja API.
+const DefaultCurrency = CurrencyKES
+
+// ErrInvalidCurrency indicates that a currency code is not made of three letters.
+var ErrInvalidCurrency = errors.New("mpesa: invalid currency")
+
+// ParseCurrency returns the currency with the provided code, e.g. "kes" or "TZS".
+func ParseCurrency(code string) (Currency, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 || strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) != -1 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidCurrency, code)
+	}
+
+	return Currency(code), nil
+}
+
+// OrDefault returns the currency or DefaultCurrency if it is empty.
+func (c Currency) OrDefault() Currency {
+	if c == "" {
+		return DefaultCurrency
+	}
+
+	return c
+}
+
+// Decimals returns the number of decimal places the amounts in the currency are shown with.
+func (c Currency) Decimals() int {
+	return 2
+}
+
+// Format formats the amount in the currency with thousands separators, e.g. KES 1,500.00.
+func (c Currency) Format(amount float64) string {
+	c = c.OrDefault()
+
+	s := strconv.FormatFloat(math.Abs(amount), 'f', c.Decimals(), 64)
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+
+	if fraction != "" {
+		whole += "." + fraction
+	}
+
+	if amount < 0 {
+		whole = "-" + whole
+	}
+
+	return string(c) + " " + whole
+}
+
+// Currency returns the currency of the amounts sent to and received from the API. It is DefaultCurrency unless set
+// using WithCurrency.
+func (m *Mpesa) Currency() Currency {
+	return m.currency.OrDefault()
+}
+
+// WithCurrency sets the currency of the amounts handled by the app, for shortcodes outside Kenya.
+func WithCurrency(c Currency) Option {
+	return func(m *Mpesa) {
+		m.currency = c
+	}
+}
@@ -0,0 +1,54 @@
+package mpesa
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := ParseCurrency(" tzs ")
+	require.NoError(t, err)
+	require.Equal(t, CurrencyTZS, currency)
+
+	for _, code := range []string{"", "KE", "KESH", "K3S"} {
+		_, err = ParseCurrency(code)
+		require.ErrorIs(t, err, ErrInvalidCurrency, code)
+	}
+}
+
+func TestCurrency_Format(t *testing.T) {
+	require.Equal(t, "KES 1,500.00", Currency("").Format(1500))
+	require.Equal(t, "TZS 1,234,567.50", CurrencyTZS.Format(1234567.5))
+	require.Equal(t, "MZN 999.99", CurrencyMZN.Format(999.99))
+	require.Equal(t, "KES -10,000.00", CurrencyKES.Format(-10000))
+}
+
+func TestMpesa_Currency(t *testing.T) {
+	app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+	require.Equal(t, DefaultCurrency, app.Currency())
+
+	app = NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCurrency(CurrencyMZN))
+	require.Equal(t, CurrencyMZN, app.Currency())
+}
+
+func TestTransactionAggregator_ByCurrency(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	for _, txn := range []Transaction{
+		{ID: "1", Amount: 100, Status: TransactionStatusCompleted},
+		{ID: "2", Amount: 5000, Currency: CurrencyTZS, Status: TransactionStatusCompleted},
+		{ID: "3", Amount: 2500, Currency: CurrencyTZS, Status: TransactionStatusFailed},
+	} {
+		require.NoError(t, store.Save(ctx, txn))
+	}
+
+	summaries, err := NewTransactionAggregator(store).ByCurrency(ctx, TransactionFilter{})
+	require.NoError(t, err)
+	require.Len(t, summaries, 2)
+	require.Equal(t, 100.0, summaries[CurrencyKES].Amount)
+	require.Equal(t, 7500.0, summaries[CurrencyTZS].Amount)
+	require.Equal(t, 5000.0, summaries[CurrencyTZS].CompletedAmount)
+}
@@ -0,0 +1,274 @@
+package mpesa
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Paths served by the handler returned by NewDashboardHandler.
+const (
+	DashboardPathIndex = "/"
+	DashboardPathData  = "/data.json"
+)
+
+const (
+	defaultCallbackFeedSize       = 100
+	defaultDashboardLimit         = 50
+	defaultDashboardSummaryWindow = 24 * time.Hour
+)
+
+// CallbackFeed keeps the most recent events in memory. It is an EventPublisher, add it to the publishers the
+// callbacks are sent to in order to show them on the dashboard.
+type CallbackFeed struct {
+	mu     sync.Mutex
+	size   int
+	events []Event
+}
+
+// NewCallbackFeed creates a CallbackFeed that keeps the last size events. A size of 0 keeps the last 100.
+func NewCallbackFeed(size int) *CallbackFeed {
+	if size <= 0 {
+		size = defaultCallbackFeedSize
+	}
+
+	return &CallbackFeed{size: size}
+}
+
+// Publish adds the event to the feed, dropping the oldest event once the feed is full.
+func (f *CallbackFeed) Publish(_ context.Context, event Event) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.events = append(f.events, event)
+	if len(f.events) > f.size {
+		f.events = f.events[len(f.events)-f.size:]
+	}
+
+	return nil
+}
+
+// Recent returns the events in the feed, most recent first.
+func (f *CallbackFeed) Recent() []Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	events := make([]Event, len(f.events))
+	for i, event := range f.events {
+		events[len(f.events)-1-i] = event
+	}
+
+	return events
+}
+
+type (
+	// DashboardConfig configures the handler returned by NewDashboardHandler. Only Store is required, the sections
+	// without a source are hidden.
+	DashboardConfig struct {
+		// Title is shown at the top of the page. Defaults to "M-Pesa".
+		Title string
+
+		// Store holds the transactions shown on the dashboard.
+		Store TransactionStore
+
+		// Feed, if set, provides the recent callbacks.
+		Feed *CallbackFeed
+
+		// Balance, if set, provides the latest balance snapshot.
+		Balance *BalanceWatcher
+
+		// Limit is the number of recent transactions shown. Defaults to 50.
+		Limit int
+
+		// SummaryWindow is the period covered by the summary. Defaults to 24 hours.
+		SummaryWindow time.Duration
+	}
+
+	// DashboardData is the data rendered on the dashboard and served as JSON on DashboardPathData.
+	DashboardData struct {
+		Title        string             `json:"title"`
+		GeneratedAt  time.Time          `json:"generatedAt"`
+		SummaryFrom  time.Time          `json:"summaryFrom"`
+		Summary      TransactionSummary `json:"summary"`
+		Transactions []Transaction      `json:"transactions"`
+		Callbacks    []Event            `json:"callbacks,omitempty"`
+		Balance      *BalanceSnapshot   `json:"balance,omitempty"`
+	}
+)
+
+// NewDashboardHandler returns a read-only http.Handler serving a minimal dashboard with the recent transactions, the
+// callback feed and the latest balance snapshot. The handler serves:
+//
+//	GET /           the dashboard as HTML.
+//	GET /data.json  the DashboardData as JSON.
+//
+// The dashboard has no authentication, wrap the handler with one before exposing it. Use http.StripPrefix to mount
+// the handler under a path.
+func NewDashboardHandler(cfg DashboardConfig) http.Handler {
+	if cfg.Title == "" {
+		cfg.Title = "M-Pesa"
+	}
+
+	if cfg.Limit <= 0 {
+		cfg.Limit = defaultDashboardLimit
+	}
+
+	if cfg.SummaryWindow <= 0 {
+		cfg.SummaryWindow = defaultDashboardSummaryWindow
+	}
+
+	d := &dashboard{cfg: cfg}
+
+	mux := http.NewServeMux()
+	mux.Handle(DashboardPathData, d.handler(func(w http.ResponseWriter, data DashboardData) {
+		writeJSON(w, http.StatusOK, data)
+	}))
+	mux.Handle(DashboardPathIndex, d.handler(func(w http.ResponseWriter, data DashboardData) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = dashboardTemplate.Execute(w, data)
+	}))
+
+	return mux
+}
+
+type dashboard struct {
+	cfg DashboardConfig
+}
+
+func (d *dashboard) handler(render func(w http.ResponseWriter, data DashboardData)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if r.URL.Path != DashboardPathIndex && r.URL.Path != DashboardPathData {
+			http.NotFound(w, r)
+			return
+		}
+
+		data, err := d.data(r.Context())
+		if err != nil {
+			writeAPIError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		render(w, data)
+	})
+}
+
+// data collects the dashboard data from the configured sources.
+func (d *dashboard) data(ctx context.Context) (DashboardData, error) {
+	now := time.Now()
+
+	data := DashboardData{
+		Title:       d.cfg.Title,
+		GeneratedAt: now,
+	}
+
+	txns, err := d.cfg.Store.List(ctx, TransactionFilter{})
+	if err != nil {
+		return data, err
+	}
+
+	data.SummaryFrom = now.Add(-d.cfg.SummaryWindow)
+	for _, txn := range txns {
+		if !txn.CreatedAt.Before(data.SummaryFrom) {
+			data.Summary.add(txn)
+		}
+	}
+
+	for i := len(txns) - 1; i >= 0 && len(data.Transactions) < d.cfg.Limit; i-- {
+		data.Transactions = append(data.Transactions, txns[i])
+	}
+
+	if d.cfg.Feed != nil {
+		data.Callbacks = d.cfg.Feed.Recent()
+	}
+
+	if d.cfg.Balance != nil {
+		if snapshot, ok := d.cfg.Balance.Latest(); ok {
+			data.Balance = &snapshot
+		}
+	}
+
+	return data, nil
+}
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"time": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(LedgerTimeFormat)
+	},
+	"percent": func(v float64) string {
+		return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta http-equiv="refresh" content="30">
+<title>{{.Title}}</title>
+<style>
+body{font-family:system-ui,sans-serif;margin:2rem;color:#222}
+h1{margin-bottom:0}small{color:#666}
+section{margin-top:2rem}
+table{border-collapse:collapse;width:100%;font-size:.9rem}
+th,td{text-align:left;padding:.35rem .6rem;border-bottom:1px solid #eee}
+th{background:#f6f6f6}
+.stats{display:flex;gap:2rem}.stats div{font-size:1.4rem}.stats span{display:block;font-size:.8rem;color:#666}
+.Completed{color:#08783e}.Failed{color:#b3261e}.Reversed{color:#8a5a00}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<small>Updated {{time .GeneratedAt}}</small>
+
+<section>
+<h2>Since {{time .SummaryFrom}}</h2>
+<div class="stats">
+<div>{{.Summary.Count}}<span>Transactions</span></div>
+<div>{{printf "%.2f" .Summary.CompletedAmount}}<span>Completed amount</span></div>
+<div>{{.Summary.Pending}}<span>Pending</span></div>
+<div>{{percent .Summary.FailureRate}}<span>Failure rate</span></div>
+</div>
+</section>
+
+{{with .Balance}}
+<section>
+<h2>Balance <small>shortcode {{.ShortCode}} at {{time .ReceivedAt}}</small></h2>
+<table>
+<tr><th>Account</th><th>Currency</th><th>Current</th><th>Available</th><th>Reserved</th><th>Uncleared</th></tr>
+{{range .Accounts}}<tr><td>{{.Name}}</td><td>{{.Currency}}</td><td>{{printf "%.2f" .Current}}</td><td>{{printf "%.2f" .Available}}</td><td>{{printf "%.2f" .Reserved}}</td><td>{{printf "%.2f" .Uncleared}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}
+
+<section>
+<h2>Recent transactions</h2>
+<table>
+<tr><th>Created</th><th>ID</th><th>Kind</th><th>Short code</th><th>Phone</th><th>Amount</th><th>Status</th><th>Receipt</th><th>Details</th></tr>
+{{range .Transactions}}<tr><td>{{time .CreatedAt}}</td><td>{{.ID}}</td><td>{{.Kind}}</td><td>{{.ShortCode}}</td><td>{{if .MSISDN}}{{.MSISDN}}{{end}}</td><td>{{printf "%.2f" .Amount}}</td><td class="{{.Status}}">{{.Status}}</td><td>{{.ReceiptNumber}}</td><td>{{.ResultDesc}}</td></tr>
+{{else}}<tr><td colspan="9">No transactions</td></tr>
+{{end}}</table>
+</section>
+
+{{if .Callbacks}}
+<section>
+<h2>Callbacks</h2>
+<table>
+<tr><th>Received</th><th>Kind</th><th>Correlation ID</th><th>Result code</th></tr>
+{{range .Callbacks}}<tr><td>{{time .OccurredAt}}</td><td>{{.Kind}}</td><td>{{.CorrelationID}}</td><td>{{.ResultCode}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,102 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackFeed(t *testing.T) {
+	feed := NewCallbackFeed(2)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, feed.Publish(context.Background(), Event{ID: strconv.Itoa(i)}))
+	}
+
+	events := feed.Recent()
+	require.Len(t, events, 2)
+	require.Equal(t, "3", events[0].ID)
+	require.Equal(t, "2", events[1].ID)
+}
+
+func TestNewDashboardHandler(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = NewMemoryStore()
+		feed  = NewCallbackFeed(0)
+		now   = time.Now()
+	)
+
+	for i, txn := range []Transaction{
+		{ID: "ws_CO_1", Status: TransactionStatusCompleted, Amount: 100, CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "ws_CO_2", Status: TransactionStatusFailed, Amount: 50, CreatedAt: now.Add(-time.Hour)},
+		{ID: "ws_CO_3", Status: TransactionStatusCompleted, Amount: 10, CreatedAt: now.Add(-time.Minute),
+			ReceiptNumber: "NLJ7RT61SV", ResultDesc: "<script>alert(1)</script>"},
+	} {
+		require.NoError(t, store.Save(ctx, txn), i)
+	}
+
+	require.NoError(t, feed.Publish(ctx, Event{ID: "1", Kind: CallbackKindSTKPush, CorrelationID: "ws_CO_3"}))
+
+	watcher := NewBalanceWatcher(nil, BalanceWatcherConfig{ShortCode: 600426})
+	_, err := watcher.HandleResult(testBalanceCallback("Utility Account|KES|20000.00|19000.00|0.00|0.00"))
+	require.NoError(t, err)
+
+	handler := NewDashboardHandler(DashboardConfig{
+		Title:   "Shop",
+		Store:   store,
+		Feed:    feed,
+		Balance: watcher,
+		Limit:   2,
+	})
+
+	t.Run("it serves the data as json", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, DashboardPathData, nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var data DashboardData
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&data))
+		require.Equal(t, "Shop", data.Title)
+		require.Len(t, data.Transactions, 2)
+		require.Equal(t, "ws_CO_3", data.Transactions[0].ID)
+		require.Equal(t, "ws_CO_2", data.Transactions[1].ID)
+		require.Equal(t, 2, data.Summary.Count)
+		require.Equal(t, 1, data.Summary.Failed)
+		require.Len(t, data.Callbacks, 1)
+		require.NotNil(t, data.Balance)
+		require.Equal(t, 19000.0, data.Balance.Accounts[0].Available)
+	})
+
+	t.Run("it renders the dashboard", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+
+		body := rr.Body.String()
+		require.Contains(t, body, "<title>Shop</title>")
+		require.Contains(t, body, "NLJ7RT61SV")
+		require.Contains(t, body, "19000.00")
+		require.Contains(t, body, "50.0%")
+		require.Contains(t, body, "&lt;script&gt;")
+		require.NotContains(t, body, "ws_CO_1")
+	})
+
+	t.Run("it is read-only", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+		require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,76 @@
+package mpesa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrUnknownField is wrapped by the errors returned when strict decoding is enabled and a response or callback has a
+// field the SDK does not know about.
+var ErrUnknownField = errors.New("mpesa: unknown field")
+
+// WithStrictDecoding makes the app reject the API responses, and the callbacks decoded with DecodeCallback, that have
+// fields the SDK does not know about, with a DecodeError wrapping ErrUnknownField. Enable it in the sandbox to detect
+// when Safaricom changes a payload. Decoding is lenient by default, which is what production deployments want.
+//
+// Fields decoded by the custom UnmarshalJSON methods of the SDK, such as the ones of CallbackResult, are not checked.
+func WithStrictDecoding(strict bool) Option {
+	return func(m *Mpesa) {
+		m.strictDecoding = strict
+	}
+}
+
+// DecodeCallback decodes a callback sent by M-Pesa into a T, e.g. an STKPushCallback, rejecting the unknown fields if
+// the app was created WithStrictDecoding(true).
+//
+//	callback, err := mpesa.DecodeCallback[mpesa.STKPushCallback](app, r.Body)
+func DecodeCallback[T any](app *Mpesa, r io.Reader) (*T, error) {
+	return decode[T](r, app.strictDecoding)
+}
+
+// decode decodes the JSON read from r into a T, see unmarshalJSON.
+func decode[T any](r io.Reader, strict bool) (*T, error) {
+	dec := json.NewDecoder(r)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+
+	var v T
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("mpesa: decode: %w", unknownFieldError(err))
+	}
+
+	return &v, nil
+}
+
+// unmarshalJSON decodes data into v. When strict is set, the unknown fields are rejected with an error wrapping
+// ErrUnknownField.
+func unmarshalJSON(data []byte, v any, strict bool) error {
+	if !strict {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return unknownFieldError(err)
+	}
+
+	return nil
+}
+
+// unknownFieldError wraps ErrUnknownField around the error returned by the JSON decoder for an unknown field, which
+// is not otherwise distinguishable.
+func unknownFieldError(err error) error {
+	field, ok := strings.CutPrefix(err.Error(), "json: unknown field ")
+	if !ok {
+		return err
+	}
+
+	return fmt.Errorf("%w %s", ErrUnknownField, field)
+}
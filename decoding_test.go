@@ -0,0 +1,74 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStrictDecoding(t *testing.T) {
+	ctx := context.Background()
+
+	const body = `{"MerchantRequestID": "29115-34620561-1", "CheckoutRequestID": "ws_CO_191220191020363925", ` +
+		`"ResponseCode": "0", "ResponseDescription": "Success. Request accepted for processing", ` +
+		`"CustomerMessage": "Success. Request accepted for processing", "NewField": "value"}`
+
+	req := STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyA:            254708374149,
+		PartyB:            174379,
+		PhoneNumber:       254708374149,
+		CallBackURL:       "https://example.com/stk",
+		AccountReference:  "Test",
+		TransactionDesc:   "Test",
+	}
+
+	newApp := func(opts ...Option) *Mpesa {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, opts...)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointSTK(), func() (int, string) {
+			return http.StatusOK, body
+		})
+
+		return app
+	}
+
+	t.Run("unknown fields are ignored by default", func(t *testing.T) {
+		res, err := newApp().STKPush(ctx, "passkey", req)
+		require.NoError(t, err)
+		require.Equal(t, "ws_CO_191220191020363925", res.CheckoutRequestID)
+	})
+
+	t.Run("unknown fields are rejected with strict decoding", func(t *testing.T) {
+		_, err := newApp(WithStrictDecoding(true)).STKPush(ctx, "passkey", req)
+		require.ErrorIs(t, err, ErrUnknownField)
+		require.ErrorContains(t, err, `"NewField"`)
+
+		var decodeErr *DecodeError
+		require.True(t, errors.As(err, &decodeErr))
+		require.JSONEq(t, body, string(decodeErr.RawBody))
+	})
+
+	t.Run("callbacks are decoded with the strictness of the app", func(t *testing.T) {
+		const callback = `{"Body": {"stkCallback": {"MerchantRequestID": "29115-34620561-1", ` +
+			`"CheckoutRequestID": "ws_CO_191220191020363925", "ResultCode": 1032, ` +
+			`"ResultDesc": "Request cancelled by user", "NewField": "value"}}}`
+
+		decoded, err := DecodeCallback[STKPushCallback](NewApp(nil, "", "", EnvironmentSandbox),
+			strings.NewReader(callback))
+		require.NoError(t, err)
+		require.Equal(t, 1032, decoded.Body.STKCallback.ResultCode)
+
+		_, err = DecodeCallback[STKPushCallback](NewApp(nil, "", "", EnvironmentSandbox, WithStrictDecoding(true)),
+			strings.NewReader(callback))
+		require.ErrorIs(t, err, ErrUnknownField)
+	})
+}
@@ -0,0 +1,193 @@
+package mpesa
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultDeduplicationTTL is how long the processed callbacks are remembered when no TTL is set.
+const defaultDeduplicationTTL = 24 * time.Hour
+
+type (
+	// DeduplicationStore records the keys of the processed callbacks. Implement it on top of a shared store such as
+	// Redis so that a callback redelivered to another app instance is detected too. Implementations must be safe for
+	// concurrent use.
+	DeduplicationStore interface {
+		// Mark records the key for ttl if it is not recorded yet and returns true. It returns false if the key is
+		// already recorded. The check and the write must be atomic.
+		Mark(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+		// Unmark removes the key so that the callback is processed again when it is redelivered.
+		Unmark(ctx context.Context, key string) error
+	}
+
+	// MemoryDeduplicationStore is an in-memory DeduplicationStore for single instance deployments.
+	MemoryDeduplicationStore struct {
+		mu      sync.Mutex
+		entries map[string]time.Time
+		now     func() time.Time
+	}
+
+	// Deduplicator detects the callbacks that M-Pesa redelivers once they were processed. The STK push callbacks are
+	// keyed by CheckoutRequestID, the results by TransactionID, or ConversationID for the failed transactions, and the
+	// C2B confirmations by TransID.
+	Deduplicator struct {
+		store DeduplicationStore
+		ttl   time.Duration
+	}
+)
+
+// NewMemoryDeduplicationStore creates an empty MemoryDeduplicationStore.
+func NewMemoryDeduplicationStore() *MemoryDeduplicationStore {
+	return &MemoryDeduplicationStore{
+		entries: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Mark records the key for ttl unless it is already recorded. Expired entries are removed on every call.
+func (s *MemoryDeduplicationStore) Mark(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, expiresAt := range s.entries {
+		if !now.Before(expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	if _, ok := s.entries[key]; ok {
+		return false, nil
+	}
+
+	s.entries[key] = now.Add(ttl)
+	return true, nil
+}
+
+// Unmark removes the key.
+func (s *MemoryDeduplicationStore) Unmark(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// NewDeduplicator creates a Deduplicator that remembers the processed callbacks in the store for ttl. A nil store
+// uses a MemoryDeduplicationStore and a ttl that is not positive defaults to 24 hours.
+func NewDeduplicator(store DeduplicationStore, ttl time.Duration) *Deduplicator {
+	if store == nil {
+		store = NewMemoryDeduplicationStore()
+	}
+
+	if ttl <= 0 {
+		ttl = defaultDeduplicationTTL
+	}
+
+	return &Deduplicator{store: store, ttl: ttl}
+}
+
+// IsNew records the key and returns true if it was not seen before. An empty key is always new.
+func (d *Deduplicator) IsNew(ctx context.Context, key string) (bool, error) {
+	if key == "" {
+		return true, nil
+	}
+
+	return d.store.Mark(ctx, key, d.ttl)
+}
+
+// Forget removes the key so that its callback is processed again, e.g. once processing it failed.
+func (d *Deduplicator) Forget(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+
+	return d.store.Unmark(ctx, key)
+}
+
+// STKPush returns true if the STK push callback was not seen before.
+func (d *Deduplicator) STKPush(ctx context.Context, callback *STKPushCallback) (bool, error) {
+	return d.IsNew(ctx, stkPushCallbackKey(callback))
+}
+
+// Result returns true if the result callback was not seen before.
+func (d *Deduplicator) Result(ctx context.Context, callback *Callback) (bool, error) {
+	return d.IsNew(ctx, resultCallbackKey(callback))
+}
+
+// C2BConfirmation returns true if the C2B confirmation was not seen before.
+func (d *Deduplicator) C2BConfirmation(ctx context.Context, callback *C2BCallback) (bool, error) {
+	return d.IsNew(ctx, c2bCallbackKey(callback))
+}
+
+// STKPushHandler wraps fn, e.g. for STKPushCallbackHandler, so that it is not called for the redelivered callbacks,
+// which are acknowledged. The callback is forgotten if fn fails so that its redelivery is processed.
+func (d *Deduplicator) STKPushHandler(
+	fn func(ctx context.Context, callback *STKPushCallback) error,
+) func(ctx context.Context, callback *STKPushCallback) error {
+	return deduplicate(d, stkPushCallbackKey, fn)
+}
+
+// ResultHandler is like STKPushHandler for ResultCallbackHandler.
+func (d *Deduplicator) ResultHandler(
+	fn func(ctx context.Context, callback *Callback) error,
+) func(ctx context.Context, callback *Callback) error {
+	return deduplicate(d, resultCallbackKey, fn)
+}
+
+// C2BConfirmationHandler is like STKPushHandler for C2BConfirmationHandler.
+func (d *Deduplicator) C2BConfirmationHandler(
+	fn func(ctx context.Context, callback *C2BCallback) error,
+) func(ctx context.Context, callback *C2BCallback) error {
+	return deduplicate(d, c2bCallbackKey, fn)
+}
+
+// deduplicate wraps fn so that it is only called for the callbacks whose key was not seen before.
+func deduplicate[T any](
+	d *Deduplicator, key func(callback *T) string, fn func(ctx context.Context, callback *T) error,
+) func(ctx context.Context, callback *T) error {
+	return func(ctx context.Context, callback *T) error {
+		k := key(callback)
+
+		isNew, err := d.IsNew(ctx, k)
+		if err != nil || !isNew {
+			return err
+		}
+
+		if err = fn(ctx, callback); err != nil {
+			_ = d.Forget(ctx, k)
+			return err
+		}
+
+		return nil
+	}
+}
+
+func stkPushCallbackKey(callback *STKPushCallback) string {
+	if id := callback.Body.STKCallback.CheckoutRequestID; id != "" {
+		return "stk:" + id
+	}
+
+	return ""
+}
+
+func resultCallbackKey(callback *Callback) string {
+	switch {
+	case callback.Result.TransactionID != "":
+		return "result:" + callback.Result.TransactionID
+	case callback.Result.ConversationID != "":
+		return "result:" + callback.Result.ConversationID
+	}
+
+	return ""
+}
+
+func c2bCallbackKey(callback *C2BCallback) string {
+	if callback.TransID != "" {
+		return "c2b:" + callback.TransID
+	}
+
+	return ""
+}
@@ -0,0 +1,111 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDeduplicationStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		now   = time.Now()
+		store = NewMemoryDeduplicationStore()
+	)
+
+	store.now = func() time.Time { return now }
+
+	marked, err := store.Mark(ctx, "stk:ws_CO_1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, marked)
+
+	marked, err = store.Mark(ctx, "stk:ws_CO_1", time.Minute)
+	require.NoError(t, err)
+	require.False(t, marked)
+
+	require.NoError(t, store.Unmark(ctx, "stk:ws_CO_1"))
+
+	marked, err = store.Mark(ctx, "stk:ws_CO_1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, marked)
+
+	now = now.Add(time.Minute)
+
+	marked, err = store.Mark(ctx, "stk:ws_CO_1", time.Minute)
+	require.NoError(t, err)
+	require.True(t, marked)
+}
+
+func TestDeduplicator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it detects the redelivered callbacks", func(t *testing.T) {
+		d := NewDeduplicator(nil, 0)
+
+		stk := &STKPushCallback{Body: STKPushCallbackBody{STKCallback: STKCallback{CheckoutRequestID: "ws_CO_1"}}}
+		result := &Callback{Result: CallbackResult{ConversationID: "AG_1", TransactionID: "NLJ41HAY6Q"}}
+		failed := &Callback{Result: CallbackResult{ConversationID: "AG_2", ResultCode: 2001}}
+		c2b := &C2BCallback{TransID: "RKTQDM7W6S"}
+
+		for i, want := range []bool{true, false} {
+			isNew, err := d.STKPush(ctx, stk)
+			require.NoError(t, err)
+			require.Equal(t, want, isNew, i)
+
+			isNew, err = d.Result(ctx, result)
+			require.NoError(t, err)
+			require.Equal(t, want, isNew, i)
+
+			isNew, err = d.Result(ctx, failed)
+			require.NoError(t, err)
+			require.Equal(t, want, isNew, i)
+
+			isNew, err = d.C2BConfirmation(ctx, c2b)
+			require.NoError(t, err)
+			require.Equal(t, want, isNew, i)
+		}
+
+		// The callbacks without an ID cannot be told apart.
+		isNew, err := d.C2BConfirmation(ctx, &C2BCallback{})
+		require.NoError(t, err)
+		require.True(t, isNew)
+	})
+
+	t.Run("it skips the redelivered callbacks in the handlers", func(t *testing.T) {
+		var (
+			d     = NewDeduplicator(nil, time.Hour)
+			calls int
+			fail  = true
+		)
+
+		handler := STKPushCallbackHandler(d.STKPushHandler(func(context.Context, *STKPushCallback) error {
+			calls++
+			if fail {
+				return errors.New("database unavailable")
+			}
+			return nil
+		}))
+
+		send := func() int {
+			body := `{"Body": {"stkCallback": {"CheckoutRequestID": "ws_CO_1", "ResultCode": 0}}}`
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stk", strings.NewReader(body)))
+			return rec.Code
+		}
+
+		// A failed callback is processed again when it is redelivered.
+		require.Equal(t, http.StatusInternalServerError, send())
+
+		fail = false
+		require.Equal(t, http.StatusOK, send())
+		require.Equal(t, http.StatusOK, send())
+		require.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,507 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PayoutStatus is the processing state of a Payout.
+type PayoutStatus string
+
+const (
+	// PayoutStatusQueued indicates the payout is waiting to be picked up in a batch.
+	PayoutStatusQueued PayoutStatus = "Queued"
+
+	// PayoutStatusRejected indicates the approval hook rejected the batch the payout was in.
+	PayoutStatusRejected PayoutStatus = "Rejected"
+
+	// PayoutStatusSubmitted indicates M-Pesa accepted the B2C request and the result callback is pending.
+	PayoutStatusSubmitted PayoutStatus = "Submitted"
+
+	// PayoutStatusUnconfirmed indicates the B2C request failed in a way that M-Pesa may still have processed it, e.g.
+	// a timeout or a 5xx response. The payout is never sent again: its status is queried using GetTransactionStatus
+	// and the result passed to HandleResult completes it.
+	PayoutStatusUnconfirmed PayoutStatus = "Unconfirmed"
+
+	// PayoutStatusCompleted indicates M-Pesa processed the payout successfully.
+	PayoutStatusCompleted PayoutStatus = "Completed"
+
+	// PayoutStatusFailed indicates the B2C request could not be submitted or M-Pesa failed to process it.
+	PayoutStatusFailed PayoutStatus = "Failed"
+)
+
+// IsTerminal returns true if the payout will not change state anymore.
+func (s PayoutStatus) IsTerminal() bool {
+	return s == PayoutStatusRejected || s == PayoutStatusCompleted || s == PayoutStatusFailed
+}
+
+var (
+	// ErrDuplicatePayout indicates that a payout with the same ID has already been enqueued.
+	ErrDuplicatePayout = errors.New("mpesa: duplicate payout")
+
+	// ErrUnknownPayout indicates that a callback could not be matched to a submitted payout.
+	ErrUnknownPayout = errors.New("mpesa: unknown payout")
+)
+
+type (
+	// Payout is an instruction to send money to a customer via B2C.
+	Payout struct {
+		// ID is the caller's unique reference for the payout.
+		ID string
+
+		// PhoneNumber is the customer mobile number in the format 2547XXXXXXXX.
+		PhoneNumber uint64
+
+		// Amount to be sent to the customer.
+		Amount uint
+
+		// CommandID is the B2C transaction type. Defaults to BusinessPaymentCommandID.
+		CommandID CommandID
+
+		// Remarks and Occasion are sent along with the B2C request.
+		Remarks  string
+		Occasion string
+
+		// Status is the current processing state of the payout.
+		Status PayoutStatus
+
+		// Attempts is the number of B2C requests made for the payout.
+		Attempts int
+
+		// ConversationID and OriginatorConversationID are returned by M-Pesa once the request is accepted and are
+		// used to match the result callback. The OriginatorConversationID of the request is the ID of the payout.
+		ConversationID           string
+		OriginatorConversationID string
+
+		// ReceiptNumber is the M-Pesa transaction ID set once the payout completes.
+		ReceiptNumber string
+
+		// ResultCode and ResultDesc hold the result sent on the callback.
+		ResultCode int
+		ResultDesc string
+
+		// Err is the last error encountered when submitting the payout.
+		Err error
+	}
+
+	// DisbursementConfig configures a DisbursementPipeline.
+	DisbursementConfig struct {
+		// InitiatorName and InitiatorPassword are the credentials of the B2C API operator.
+		InitiatorName     string
+		InitiatorPassword string
+
+		// ShortCode is the B2C organization shortcode the money is sent from.
+		ShortCode uint
+
+		// QueueTimeOutURL and ResultURL are the callback URLs set on every B2C request.
+		QueueTimeOutURL string
+		ResultURL       string
+
+		// MaxBatchSize is the maximum number of payouts processed in a batch. Defaults to 100.
+		MaxBatchSize int
+
+		// MaxBatchAmount is the maximum total amount of a batch. A payout that would exceed the limit is deferred to
+		// the next batch. Zero means no limit.
+		MaxBatchAmount uint
+
+		// MaxAttempts is the maximum number of times a B2C request is made for a payout. Defaults to 3. Only the
+		// requests that were never sent or were rate limited with a 429 response are made again.
+		MaxAttempts int
+
+		// Backoff is the time to wait before retrying the first failed attempt. It doubles on every subsequent
+		// attempt. Defaults to 1 second.
+		Backoff time.Duration
+
+		// Approve, if set, is called with every batch before it is executed. Returning an error rejects the batch.
+		Approve func(ctx context.Context, batch []Payout) error
+
+		// OnStatusChange, if set, is called whenever a payout changes state.
+		OnStatusChange func(payout Payout)
+	}
+
+	// DisbursementPipeline pays out batches of Payout instructions via B2C and tracks each payout to a terminal state
+	// using the result callbacks.
+	DisbursementPipeline struct {
+		app *Mpesa
+		cfg DisbursementConfig
+
+		mu             sync.Mutex
+		queue          []*Payout
+		payouts        map[string]*Payout
+		byConversation map[string]*Payout
+		queries        map[string]*Payout
+	}
+)
+
+// NewDisbursementPipeline creates a DisbursementPipeline that makes the B2C requests using the provided app.
+func NewDisbursementPipeline(app *Mpesa, cfg DisbursementConfig) *DisbursementPipeline {
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = 100
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+
+	return &DisbursementPipeline{
+		app:            app,
+		cfg:            cfg,
+		payouts:        make(map[string]*Payout),
+		byConversation: make(map[string]*Payout),
+		queries:        make(map[string]*Payout),
+	}
+}
+
+// setStatus updates the payout status and notifies OnStatusChange. It must be called with p.mu held.
+func (p *DisbursementPipeline) setStatus(payout *Payout, status PayoutStatus) {
+	payout.Status = status
+	if p.cfg.OnStatusChange != nil {
+		p.cfg.OnStatusChange(*payout)
+	}
+}
+
+// Enqueue adds the payouts to the queue. No payout is enqueued if any of them is invalid or already exists.
+func (p *DisbursementPipeline) Enqueue(payouts ...Payout) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(payouts))
+	for _, payout := range payouts {
+		if payout.ID == "" {
+			return errors.New("mpesa: payout ID cannot be empty")
+		}
+
+		if payout.Amount == 0 {
+			return fmt.Errorf("mpesa: payout %s amount must be greater than 0", payout.ID)
+		}
+
+		if _, ok := p.payouts[payout.ID]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicatePayout, payout.ID)
+		}
+
+		if _, ok := seen[payout.ID]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicatePayout, payout.ID)
+		}
+
+		seen[payout.ID] = struct{}{}
+	}
+
+	for _, payout := range payouts {
+		payout := payout
+		if payout.CommandID == "" {
+			payout.CommandID = BusinessPaymentCommandID
+		}
+
+		payout.Attempts = 0
+		payout.Err = nil
+
+		p.queue = append(p.queue, &payout)
+		p.payouts[payout.ID] = &payout
+		p.setStatus(&payout, PayoutStatusQueued)
+	}
+
+	return nil
+}
+
+// Pending returns the number of payouts waiting to be processed.
+func (p *DisbursementPipeline) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.queue)
+}
+
+// Payout returns the payout with the provided ID.
+func (p *DisbursementPipeline) Payout(id string) (Payout, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payout, ok := p.payouts[id]
+	if !ok {
+		return Payout{}, false
+	}
+
+	return *payout, true
+}
+
+// nextBatch removes the next batch of payouts from the queue respecting the batch limits.
+func (p *DisbursementPipeline) nextBatch() []*Payout {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		batch     []*Payout
+		remaining []*Payout
+		total     uint
+	)
+
+	for _, payout := range p.queue {
+		full := len(batch) == p.cfg.MaxBatchSize
+		overLimit := p.cfg.MaxBatchAmount > 0 && total+payout.Amount > p.cfg.MaxBatchAmount && len(batch) > 0
+
+		if full || overLimit {
+			remaining = append(remaining, payout)
+			continue
+		}
+
+		total += payout.Amount
+		batch = append(batch, payout)
+	}
+
+	p.queue = remaining
+	return batch
+}
+
+// Process executes the next batch of queued payouts and returns the state of the payouts in the batch. It returns
+// an error only if the batch was rejected by the approval hook or the context is done, in which case the payouts
+// that were not submitted are returned to the queue.
+func (p *DisbursementPipeline) Process(ctx context.Context) ([]Payout, error) {
+	batch := p.nextBatch()
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	snapshot := func() []Payout {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		payouts := make([]Payout, len(batch))
+		for i, payout := range batch {
+			payouts[i] = *payout
+		}
+		return payouts
+	}
+
+	if p.cfg.Approve != nil {
+		if err := p.cfg.Approve(ctx, snapshot()); err != nil {
+			p.mu.Lock()
+			for _, payout := range batch {
+				payout.Err = err
+				p.setStatus(payout, PayoutStatusRejected)
+			}
+			p.mu.Unlock()
+
+			return snapshot(), fmt.Errorf("mpesa: batch rejected: %w", err)
+		}
+	}
+
+	for i, payout := range batch {
+		if err := p.submit(ctx, payout); err != nil {
+			p.mu.Lock()
+			p.queue = append(append([]*Payout{}, batch[i:]...), p.queue...)
+			p.mu.Unlock()
+
+			return snapshot(), err
+		}
+	}
+
+	return snapshot(), nil
+}
+
+// submit makes the B2C request for the payout, making it again while it was provably not processed. A failure whose
+// outcome is unknown leaves the payout unconfirmed and queries its status instead. It only returns an error if the
+// context is done before the request is made.
+func (p *DisbursementPipeline) submit(ctx context.Context, payout *Payout) error {
+	p.mu.Lock()
+	req := B2CRequest{
+		OriginatorConversationID: payout.ID,
+		InitiatorName:            p.cfg.InitiatorName,
+		CommandID:                payout.CommandID,
+		Amount:                   payout.Amount,
+		PartyA:                   p.cfg.ShortCode,
+		PartyB:                   payout.PhoneNumber,
+		Remarks:                  payout.Remarks,
+		QueueTimeOutURL:          p.cfg.QueueTimeOutURL,
+		ResultURL:                p.cfg.ResultURL,
+		Occasion:                 payout.Occasion,
+	}
+	p.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		res, err := p.app.B2C(ctx, p.cfg.InitiatorPassword, req)
+
+		p.mu.Lock()
+		payout.Attempts++
+		payout.Err = err
+
+		switch {
+		case err == nil:
+			payout.ConversationID = res.ConversationID
+			payout.OriginatorConversationID = res.OriginatorConversationID
+			p.byConversation[res.ConversationID] = payout
+			p.byConversation[res.OriginatorConversationID] = payout
+			p.setStatus(payout, PayoutStatusSubmitted)
+			p.mu.Unlock()
+			return nil
+		case isUnknownB2COutcome(err):
+			payout.OriginatorConversationID = req.OriginatorConversationID
+			p.byConversation[req.OriginatorConversationID] = payout
+			p.setStatus(payout, PayoutStatusUnconfirmed)
+			p.mu.Unlock()
+
+			p.query(ctx, payout)
+			return nil
+		case !isResendableB2CError(err) || attempt >= p.cfg.MaxAttempts:
+			p.setStatus(payout, PayoutStatusFailed)
+			p.mu.Unlock()
+			return nil
+		}
+		p.mu.Unlock()
+
+		timer := time.NewTimer(p.cfg.Backoff << (attempt - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isResendableB2CError returns true if the B2C request failed without being processed by M-Pesa, so that it can be
+// made again without paying out twice: it was never sent or it was rate limited.
+func isResendableB2CError(err error) bool {
+	var (
+		notSentErr *requestNotSentError
+		apiErr     *Error
+	)
+
+	if errors.As(err, &notSentErr) {
+		return true
+	}
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// isUnknownB2COutcome returns true if the B2C request may have been processed by M-Pesa despite the error, i.e. it was
+// not refused before being sent and did not fail with a 4xx response.
+func isUnknownB2COutcome(err error) bool {
+	var (
+		notSentErr *requestNotSentError
+		apiErr     *Error
+	)
+
+	switch {
+	case errors.As(err, &notSentErr):
+		return false
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	case errors.Is(err, ErrInvalidRequest), errors.Is(err, ErrInvalidInitiatorPassword),
+		errors.Is(err, ErrInvalidCertificate), errors.Is(err, ErrIdempotencyKeyReused):
+		return false
+	default:
+		return true
+	}
+}
+
+// Resolve queries the status of the unconfirmed payouts, e.g. when the queries made after their failure were made
+// before M-Pesa recorded them. The results are passed to HandleResult.
+func (p *DisbursementPipeline) Resolve(ctx context.Context) {
+	p.mu.Lock()
+	var unconfirmed []*Payout
+	for _, payout := range p.payouts {
+		if payout.Status == PayoutStatusUnconfirmed {
+			unconfirmed = append(unconfirmed, payout)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, payout := range unconfirmed {
+		p.query(ctx, payout)
+	}
+}
+
+// query requests the status of the unconfirmed payout. A failed query is recorded on the payout.
+func (p *DisbursementPipeline) query(ctx context.Context, payout *Payout) {
+	p.mu.Lock()
+	id := payout.OriginatorConversationID
+	p.mu.Unlock()
+
+	res, err := p.app.GetTransactionStatus(ctx, p.cfg.InitiatorPassword, TransactionStatusRequest{
+		Initiator:                p.cfg.InitiatorName,
+		OriginatorConversationID: id,
+		PartyA:                   p.cfg.ShortCode,
+		QueueTimeOutURL:          p.cfg.QueueTimeOutURL,
+		Remarks:                  "Payout status",
+		ResultURL:                p.cfg.ResultURL,
+	})
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err != nil {
+		payout.Err = fmt.Errorf("mpesa: query payout %s status: %w", payout.ID, err)
+		return
+	}
+
+	p.queries[res.ConversationID] = payout
+}
+
+// HandleResult updates the payout matching the B2C result callback, or the result of the transaction status query
+// made for an unconfirmed payout, and returns its new state. A status query that does not establish the result leaves
+// the payout unconfirmed.
+func (p *DisbursementPipeline) HandleResult(callback *Callback) (Payout, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := callback.Result
+
+	if payout, ok := p.queries[result.ConversationID]; ok {
+		delete(p.queries, result.ConversationID)
+
+		if payout.Status.IsTerminal() || result.ResultCode != 0 {
+			return *payout, nil
+		}
+
+		status, _ := result.ResultParameters.String(ResultParameterTransactionStatus)
+		switch strings.ToLower(status) {
+		case "completed":
+			payout.ReceiptNumber, _ = result.ResultParameters.String(ResultParameterReceiptNo)
+			payout.ResultDesc = status
+			p.setStatus(payout, PayoutStatusCompleted)
+		case "failed", "cancelled", "declined", "expired":
+			payout.ResultDesc = status
+			p.setStatus(payout, PayoutStatusFailed)
+		}
+
+		return *payout, nil
+	}
+
+	payout, ok := p.byConversation[result.ConversationID]
+	if !ok {
+		payout, ok = p.byConversation[result.OriginatorConversationID]
+	}
+
+	if !ok {
+		return Payout{}, fmt.Errorf("%w: %s", ErrUnknownPayout, result.ConversationID)
+	}
+
+	if payout.Status.IsTerminal() {
+		return *payout, nil
+	}
+
+	payout.ResultCode = result.ResultCode
+	payout.ResultDesc = result.ResultDesc
+	payout.ReceiptNumber = result.TransactionID
+
+	status := PayoutStatusCompleted
+	if result.ResultCode != 0 {
+		status = PayoutStatusFailed
+	}
+
+	p.setStatus(payout, status)
+	return *payout, nil
+}
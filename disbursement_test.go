@@ -0,0 +1,284 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mockAuth(app *Mpesa, c *mockHttpClient) {
+	c.MockRequest(app.endpointAuth(), func() (status int, body string) {
+		return http.StatusOK, `
+		{
+			"access_token": "0A0v8OgxqqoocblflR58m9chMdnU",
+			"expires_in": "3599"
+		}`
+	})
+}
+
+func TestDisbursementPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	newPipeline := func(cfg DisbursementConfig) (*DisbursementPipeline, *Mpesa, *mockHttpClient) {
+		var (
+			cl  = newMockHttpClient()
+			app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+		)
+
+		mockAuth(app, cl)
+
+		cfg.InitiatorName = "testapi"
+		cfg.InitiatorPassword = "Safaricom999!*!"
+		cfg.ShortCode = 600426
+		cfg.QueueTimeOutURL = "https://example.com/timeout"
+		cfg.ResultURL = "https://example.com/result"
+		cfg.Backoff = time.Millisecond
+
+		return NewDisbursementPipeline(app, cfg), app, cl
+	}
+
+	tests := []struct {
+		name string
+		cfg  DisbursementConfig
+		mock func(t *testing.T, p *DisbursementPipeline, app *Mpesa, c *mockHttpClient)
+	}{
+		{
+			name: "it processes batches within the limits and tracks payouts to completion",
+			cfg:  DisbursementConfig{MaxBatchSize: 2, MaxBatchAmount: 1000},
+			mock: func(t *testing.T, p *DisbursementPipeline, app *Mpesa, c *mockHttpClient) {
+				var n int32
+				c.MockRequest(app.endpointB2C(), func() (status int, body string) {
+					i := atomic.AddInt32(&n, 1)
+					return http.StatusOK, fmt.Sprintf(`
+						{
+							"ConversationID": "AG_%d",
+							"OriginatorConversationID": "10571-7910404-%d",
+							"ResponseCode": "0",
+							"ResponseDescription": "Accept the service request successfully."
+						}`, i, i)
+				})
+
+				require.NoError(t, p.Enqueue(
+					Payout{ID: "1", PhoneNumber: 254708374149, Amount: 600},
+					Payout{ID: "2", PhoneNumber: 254708374149, Amount: 600},
+					Payout{ID: "3", PhoneNumber: 254708374149, Amount: 300},
+				))
+
+				require.ErrorIs(t, p.Enqueue(Payout{ID: "1", PhoneNumber: 254708374149, Amount: 10}), ErrDuplicatePayout)
+
+				batch, err := p.Process(ctx)
+				require.NoError(t, err)
+				require.Len(t, batch, 2)
+				require.Equal(t, "1", batch[0].ID)
+				require.Equal(t, "3", batch[1].ID)
+				require.Equal(t, PayoutStatusSubmitted, batch[0].Status)
+				require.Equal(t, BusinessPaymentCommandID, batch[0].CommandID)
+				require.Equal(t, 1, p.Pending())
+
+				batch, err = p.Process(ctx)
+				require.NoError(t, err)
+				require.Len(t, batch, 1)
+				require.Equal(t, "2", batch[0].ID)
+				require.Equal(t, 0, p.Pending())
+
+				payout, err := p.HandleResult(&Callback{Result: CallbackResult{
+					ConversationID: "AG_1",
+					ResultCode:     0,
+					ResultDesc:     "The service request is processed successfully.",
+					TransactionID:  "NLJ41HAY6Q",
+				}})
+				require.NoError(t, err)
+				require.Equal(t, "1", payout.ID)
+				require.Equal(t, PayoutStatusCompleted, payout.Status)
+				require.Equal(t, "NLJ41HAY6Q", payout.ReceiptNumber)
+
+				payout, err = p.HandleResult(&Callback{Result: CallbackResult{
+					OriginatorConversationID: "10571-7910404-2",
+					ResultCode:               2001,
+					ResultDesc:               "The initiator information is invalid.",
+				}})
+				require.NoError(t, err)
+				require.Equal(t, "3", payout.ID)
+				require.Equal(t, PayoutStatusFailed, payout.Status)
+
+				_, err = p.HandleResult(&Callback{Result: CallbackResult{ConversationID: "AG_unknown"}})
+				require.ErrorIs(t, err, ErrUnknownPayout)
+			},
+		},
+		{
+			name: "it retries rate limited requests with the same ID and marks the payout as failed",
+			cfg:  DisbursementConfig{MaxAttempts: 2},
+			mock: func(t *testing.T, p *DisbursementPipeline, app *Mpesa, c *mockHttpClient) {
+				var ids []string
+				c.MockRequest(app.endpointB2C(), func() (status int, body string) {
+					var req B2CRequest
+					require.NoError(t, json.NewDecoder(c.requests[len(c.requests)-1].Body).Decode(&req))
+					ids = append(ids, req.OriginatorConversationID)
+
+					return http.StatusTooManyRequests, `
+						{
+							"requestId": "11728-2929992-1",
+							"errorCode": "429.001.01",
+							"errorMessage": "Too many requests"
+						}`
+				})
+
+				require.NoError(t, p.Enqueue(Payout{ID: "1", PhoneNumber: 254708374149, Amount: 10}))
+
+				batch, err := p.Process(ctx)
+				require.NoError(t, err)
+				require.Equal(t, PayoutStatusFailed, batch[0].Status)
+				require.Equal(t, 2, batch[0].Attempts)
+				require.Error(t, batch[0].Err)
+				require.Equal(t, []string{"1", "1"}, ids)
+			},
+		},
+		{
+			name: "it does not resend a request with an unknown outcome and queries its status",
+			cfg:  DisbursementConfig{MaxAttempts: 3},
+			mock: func(t *testing.T, p *DisbursementPipeline, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointB2C(), func() (status int, body string) {
+					return http.StatusInternalServerError, `
+						{
+							"requestId": "11728-2929992-1",
+							"errorCode": "500.002.1001",
+							"errorMessage": "Service is currently unreachable"
+						}`
+				})
+
+				c.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+					var req TransactionStatusRequest
+					require.NoError(t, json.NewDecoder(c.requests[len(c.requests)-1].Body).Decode(&req))
+					require.Equal(t, "1", req.OriginatorConversationID)
+					require.Equal(t, uint(600426), req.PartyA)
+
+					return http.StatusOK, `
+						{
+							"ConversationID": "AG_query_1",
+							"OriginatorConversationID": "1",
+							"ResponseCode": "0",
+							"ResponseDescription": "Accept the service request successfully."
+						}`
+				})
+
+				require.NoError(t, p.Enqueue(Payout{ID: "1", PhoneNumber: 254708374149, Amount: 10}))
+
+				batch, err := p.Process(ctx)
+				require.NoError(t, err)
+				require.Equal(t, PayoutStatusUnconfirmed, batch[0].Status)
+				require.Equal(t, 1, batch[0].Attempts)
+				require.Equal(t, 0, p.Pending())
+
+				payout, err := p.HandleResult(&Callback{Result: CallbackResult{
+					ConversationID: "AG_query_1",
+					ResultParameters: ResultParameters{ResultParameter: []ResultParameter{
+						{Key: ResultParameterReceiptNo, Value: "NLJ41HAY6Q"},
+						{Key: ResultParameterTransactionStatus, Value: "Completed"},
+					}},
+				}})
+				require.NoError(t, err)
+				require.Equal(t, "1", payout.ID)
+				require.Equal(t, PayoutStatusCompleted, payout.Status)
+				require.Equal(t, "NLJ41HAY6Q", payout.ReceiptNumber)
+			},
+		},
+		{
+			name: "it marks a payout rejected with a 4xx response as failed without retrying",
+			cfg:  DisbursementConfig{MaxAttempts: 3},
+			mock: func(t *testing.T, p *DisbursementPipeline, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointB2C(), func() (status int, body string) {
+					return http.StatusBadRequest, `
+						{
+							"requestId": "11728-2929992-1",
+							"errorCode": "400.002.02",
+							"errorMessage": "Bad Request - Invalid Amount"
+						}`
+				})
+
+				require.NoError(t, p.Enqueue(Payout{ID: "1", PhoneNumber: 254708374149, Amount: 10}))
+
+				batch, err := p.Process(ctx)
+				require.NoError(t, err)
+				require.Equal(t, PayoutStatusFailed, batch[0].Status)
+				require.Equal(t, 1, batch[0].Attempts)
+			},
+		},
+		{
+			name: "it rejects a batch when the approval hook fails",
+			cfg: DisbursementConfig{
+				Approve: func(_ context.Context, batch []Payout) error {
+					return errors.New("amount exceeds daily limit")
+				},
+			},
+			mock: func(t *testing.T, p *DisbursementPipeline, app *Mpesa, c *mockHttpClient) {
+				require.NoError(t, p.Enqueue(Payout{ID: "1", PhoneNumber: 254708374149, Amount: 10}))
+
+				batch, err := p.Process(ctx)
+				require.Error(t, err)
+				require.Equal(t, PayoutStatusRejected, batch[0].Status)
+
+				for _, req := range c.requests {
+					require.NotEqual(t, app.endpointB2C(), req.URL.String())
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			p, app, cl := newPipeline(tc.cfg)
+			tc.mock(t, p, app, cl)
+		})
+	}
+}
+
+func TestDisbursementPipeline_StatusChanges(t *testing.T) {
+	var statuses []PayoutStatus
+
+	cl := newMockHttpClient()
+	app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+		req := cl.requests[len(cl.requests)-1]
+
+		var b2cReq B2CRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&b2cReq))
+		require.Equal(t, uint(600426), b2cReq.PartyA)
+		require.Equal(t, SalaryPaymentCommandID, b2cReq.CommandID)
+
+		return http.StatusOK, `{"ConversationID": "AG_1", "ResponseCode": "0"}`
+	})
+
+	p := NewDisbursementPipeline(app, DisbursementConfig{
+		InitiatorPassword: "Safaricom999!*!",
+		ShortCode:         600426,
+		QueueTimeOutURL:   "https://example.com/timeout",
+		ResultURL:         "https://example.com/result",
+		OnStatusChange: func(payout Payout) {
+			statuses = append(statuses, payout.Status)
+		},
+	})
+
+	require.NoError(t, p.Enqueue(Payout{
+		ID: "1", PhoneNumber: 254708374149, Amount: 10, CommandID: SalaryPaymentCommandID,
+	}))
+
+	_, err := p.Process(context.Background())
+	require.NoError(t, err)
+
+	_, err = p.HandleResult(&Callback{Result: CallbackResult{ConversationID: "AG_1"}})
+	require.NoError(t, err)
+
+	require.Equal(t, []PayoutStatus{PayoutStatusQueued, PayoutStatusSubmitted, PayoutStatusCompleted}, statuses)
+}
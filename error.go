@@ -0,0 +1,158 @@
+package mpesa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error codes returned by Daraja when the access token of a request is invalid or expired.
+const (
+	ErrorCodeInvalidAccessToken = "404.001.03"
+	ErrorCodeAccessTokenExpired = "401.002.01"
+)
+
+// maxErrorBodySize is the maximum size of the body of a failed response that is read.
+const maxErrorBodySize = 64 << 10
+
+// Error is returned when Daraja rejects an API request. Use errors.As to branch on the ErrorCode.
+type Error struct {
+	// RequestID is the unique ID of the request, as reported by Daraja.
+	RequestID string `json:"requestId"`
+
+	// ErrorCode is a predefined code that indicates the reason for request failure, e.g. 400.002.02.
+	ErrorCode string `json:"errorCode"`
+
+	// ErrorMessage is a short descriptive message of the failure reason.
+	ErrorMessage string `json:"errorMessage"`
+
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"-"`
+
+	// RawBody is the body of the response, up to 64KB, e.g. the HTML error page returned by a proxy.
+	RawBody []byte `json:"-"`
+
+	// ClientRequestID is the HeaderRequestID sent on the request.
+	ClientRequestID string `json:"-"`
+}
+
+// DecodeError is returned when the body of a successful response is not the expected JSON, e.g. an empty body or the
+// HTML page of a proxy. Use errors.As to inspect the RawBody.
+type DecodeError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// RawBody is the body of the response, up to 64KB.
+	RawBody []byte
+
+	// Err is the error returned by the JSON decoder.
+	Err error
+
+	// ClientRequestID is the HeaderRequestID sent on the request.
+	ClientRequestID string
+}
+
+// maxDecodeErrorBodyPreview is the maximum size of the body included in the message of a DecodeError.
+const maxDecodeErrorBodyPreview = 256
+
+func (e *DecodeError) Error() string {
+	body := e.RawBody
+	if len(body) > maxDecodeErrorBodyPreview {
+		body = body[:maxDecodeErrorBodyPreview]
+	}
+
+	return fmt.Sprintf("mpesa: decode response: %v (status %d, body %q)", e.Err, e.StatusCode, body)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Error() string {
+	if e.ErrorCode == "" {
+		return fmt.Sprintf("mpesa: request failed with status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("mpesa: request %v failed with code %v: %v", e.RequestID, e.ErrorCode, e.ErrorMessage)
+}
+
+// IsInvalidAccessToken returns true if the request was rejected because of an invalid or expired access token.
+func (e *Error) IsInvalidAccessToken() bool {
+	return e.ErrorCode == ErrorCodeInvalidAccessToken || e.ErrorCode == ErrorCodeAccessTokenExpired
+}
+
+// IsTemporary returns true if the request failed with a status that is worth retrying, i.e. 408, 429 or 5xx.
+func (e *Error) IsTemporary() bool {
+	return isRetryableStatus(e.StatusCode)
+}
+
+// hasInvalidAccessToken returns true if the request was rejected because of its access token. The body of the
+// response is read and replaced so that it can still be decoded.
+func hasInvalidAccessToken(res *http.Response) bool {
+	if res.StatusCode != http.StatusUnauthorized && res.StatusCode != http.StatusNotFound {
+		return false
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	//goland:noinspection GoUnhandledErrorResult
+	res.Body.Close()
+
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	return newError(res, body).IsInvalidAccessToken()
+}
+
+// newError returns the Error of a failed response with the body. The body is decoded on a best effort basis as
+// gateways may respond with a non JSON body.
+func newError(res *http.Response, body []byte) *Error {
+	e := &Error{}
+	_ = json.Unmarshal(body, e)
+	e.StatusCode, e.RawBody, e.ClientRequestID = res.StatusCode, limitBody(body), responseRequestID(res)
+	return e
+}
+
+// decodeResult decodes the body of the response to an API request into v. A response with a non 2xx status is returned
+// as an *Error and a successful response that is not valid JSON as a *DecodeError, so that every endpoint fails the
+// same way. Unknown fields are rejected when strict is set.
+func decodeResult(res *http.Response, v any, strict bool) ([]byte, error) {
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: read response: %v", err)
+		}
+
+		return body, newError(res, body)
+	}
+
+	return decodeBody(res, v, strict)
+}
+
+// decodeBody reads the body of the response and decodes it into v. It returns the body, or a DecodeError holding it
+// if it is not valid JSON or, when strict is set, has unknown fields.
+func decodeBody(res *http.Response, v any, strict bool) ([]byte, error) {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: read response: %v", err)
+	}
+
+	if err = unmarshalJSON(body, v, strict); err != nil {
+		return body, &DecodeError{
+			StatusCode:      res.StatusCode,
+			RawBody:         limitBody(body),
+			Err:             err,
+			ClientRequestID: responseRequestID(res),
+		}
+	}
+
+	return body, nil
+}
+
+// limitBody returns at most the first maxErrorBodySize bytes of the body.
+func limitBody(body []byte) []byte {
+	if len(body) > maxErrorBodySize {
+		return body[:maxErrorBodySize]
+	}
+
+	return body
+}
@@ -0,0 +1,182 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		mock func(t *testing.T, app *Mpesa, c *mockHttpClient)
+	}{
+		{
+			name: "it returns the error of a rejected request",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				mockAuth(app, c)
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					return http.StatusUnauthorized, `
+					{
+					   "requestId": "11728-2929992-1",
+					   "errorCode": "401.002.01",
+					   "errorMessage": "Error Occurred - Invalid Access Token - BJGFGOXv5aZnw90KkA4TDtu4Xdyf"
+					}`
+				})
+
+				_, err := app.STKQuery(ctx, "passkey", STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"})
+
+				var apiErr *Error
+				require.True(t, errors.As(err, &apiErr))
+				require.Equal(t, "11728-2929992-1", apiErr.RequestID)
+				require.Equal(t, ErrorCodeAccessTokenExpired, apiErr.ErrorCode)
+				require.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+				require.True(t, apiErr.IsInvalidAccessToken())
+				require.False(t, apiErr.IsTemporary())
+				require.EqualError(t, err, "mpesa: request 11728-2929992-1 failed with code 401.002.01: "+
+					"Error Occurred - Invalid Access Token - BJGFGOXv5aZnw90KkA4TDtu4Xdyf")
+			},
+		},
+		{
+			name: "it returns the status of a failed request without an error body",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				mockAuth(app, c)
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					return http.StatusBadGateway, `<html>Bad Gateway</html>`
+				})
+
+				_, err := app.STKQuery(ctx, "passkey", STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"})
+
+				var apiErr *Error
+				require.True(t, errors.As(err, &apiErr))
+				require.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+				require.Empty(t, apiErr.ErrorCode)
+				require.True(t, apiErr.IsTemporary())
+				require.EqualError(t, err, "mpesa: request failed with status 502 Bad Gateway")
+				require.Equal(t, "<html>Bad Gateway</html>", string(apiErr.RawBody))
+			},
+		},
+		{
+			name: "it returns the body of a successful response that is not JSON",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				mockAuth(app, c)
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					return http.StatusOK, `<html>Request blocked by the proxy</html>`
+				})
+
+				_, err := app.STKQuery(ctx, "passkey", STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"})
+
+				var decodeErr *DecodeError
+				require.True(t, errors.As(err, &decodeErr))
+				require.Equal(t, http.StatusOK, decodeErr.StatusCode)
+				require.Equal(t, "<html>Request blocked by the proxy</html>", string(decodeErr.RawBody))
+				require.ErrorContains(t, err, `body "<html>Request blocked by the proxy</html>"`)
+			},
+		},
+		{
+			name: "it returns the raw body of a response",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				mockAuth(app, c)
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0"}`
+				})
+
+				res, err := app.STKQuery(ctx, "passkey", STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"})
+				require.NoError(t, err)
+				require.JSONEq(t, `{"ResponseCode": "0", "ResultCode": "0"}`, string(res.RawBody))
+			},
+		},
+		{
+			name: "it limits the body of a decode error message",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointAuth(), func() (status int, body string) {
+					return http.StatusOK, strings.Repeat("x", maxDecodeErrorBodyPreview+100)
+				})
+
+				_, err := app.GenerateAccessToken(ctx)
+
+				var decodeErr *DecodeError
+				require.True(t, errors.As(err, &decodeErr))
+				require.Len(t, decodeErr.RawBody, maxDecodeErrorBodyPreview+100)
+				require.NotContains(t, err.Error(), strings.Repeat("x", maxDecodeErrorBodyPreview+1))
+			},
+		},
+		{
+			name: "it returns the error of a rejected C2B URL registration",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				mockAuth(app, c)
+				c.MockRequest(app.endpointC2BRegister(), func() (status int, body string) {
+					return http.StatusBadRequest, `
+					{
+					   "requestId": "4788-81090592-3",
+					   "errorCode": "500.003.1001",
+					   "errorMessage": "URLs are already registered"
+					}`
+				})
+
+				_, err := app.RegisterC2BURL(ctx, RegisterC2BURLRequest{
+					ShortCode:       600638,
+					ResponseType:    ResponseTypeComplete,
+					ConfirmationURL: "https://example.com/confirm",
+					ValidationURL:   "https://example.com/validate",
+				})
+
+				var apiErr *Error
+				require.True(t, errors.As(err, &apiErr))
+				require.Equal(t, "500.003.1001", apiErr.ErrorCode)
+				require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+				require.NotEmpty(t, apiErr.ClientRequestID)
+				require.Contains(t, string(apiErr.RawBody), "URLs are already registered")
+			},
+		},
+		{
+			name: "it accepts the successful responses with a status other than 200",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				mockAuth(app, c)
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					return http.StatusAccepted, `{"ResponseCode": "0", "ResultCode": "0"}`
+				})
+
+				res, err := app.STKQuery(ctx, "passkey", STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"})
+				require.NoError(t, err)
+				require.Equal(t, "0", res.ResultCode)
+			},
+		},
+		{
+			name: "it returns the error of a failed authentication",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointAuth(), func() (status int, body string) {
+					return http.StatusBadRequest, `
+					{
+					   "requestId": "",
+					   "errorCode": "400.008.01",
+					   "errorMessage": "Invalid Authentication passed"
+					}`
+				})
+
+				_, err := app.GenerateAccessToken(ctx)
+
+				var apiErr *Error
+				require.True(t, errors.As(err, &apiErr))
+				require.Equal(t, "400.008.01", apiErr.ErrorCode)
+				require.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+				require.False(t, apiErr.IsInvalidAccessToken())
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cl := newMockHttpClient()
+			app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			tc.mock(t, app, cl)
+		})
+	}
+}
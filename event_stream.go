@@ -0,0 +1,194 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultEventStreamBufferSize = 16
+	defaultEventStreamKeepAlive  = 15 * time.Second
+)
+
+// EventStreamConfig configures an EventStream.
+type EventStreamConfig struct {
+	// BufferSize is the number of events buffered per client. Events are dropped for clients that fall further
+	// behind. Defaults to 16.
+	BufferSize int
+
+	// KeepAlive is the interval at which a comment is sent to keep idle connections open. Defaults to 15 seconds.
+	KeepAlive time.Duration
+
+	// Replay, if set, is used to send the events a reconnecting client missed. The events published after the one
+	// in the Last-Event-ID header are replayed.
+	Replay *CallbackFeed
+}
+
+// EventStream pushes events to the connected clients using server-sent events. It is an EventPublisher, add it to the
+// publishers the callbacks are sent to, and an http.Handler, mount it where the clients connect.
+//
+// A client can set the correlationId query parameter to only receive the events of a transaction, e.g. to update an
+// order page once the customer pays:
+//
+//	const source = new EventSource("/events?correlationId=ws_CO_191220191020363925")
+//	source.addEventListener("STKPush", (e) => console.log(JSON.parse(e.data)))
+type EventStream struct {
+	cfg EventStreamConfig
+
+	mu      sync.Mutex
+	clients map[*eventStreamClient]struct{}
+}
+
+type eventStreamClient struct {
+	correlationID string
+	events        chan Event
+}
+
+// NewEventStream creates an EventStream without any connected clients.
+func NewEventStream(cfg EventStreamConfig) *EventStream {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = defaultEventStreamBufferSize
+	}
+
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = defaultEventStreamKeepAlive
+	}
+
+	return &EventStream{
+		cfg:     cfg,
+		clients: make(map[*eventStreamClient]struct{}),
+	}
+}
+
+func (c *eventStreamClient) wants(event Event) bool {
+	return c.correlationID == "" || c.correlationID == event.CorrelationID
+}
+
+// Publish sends the event to every connected client interested in it. It never blocks, the event is dropped for
+// clients whose buffer is full.
+func (s *EventStream) Publish(_ context.Context, event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for client := range s.clients {
+		if !client.wants(event) {
+			continue
+		}
+
+		select {
+		case client.events <- event:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Clients returns the number of connected clients.
+func (s *EventStream) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.clients)
+}
+
+// ServeHTTP streams the events to the client until it disconnects.
+func (s *EventStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	client := &eventStreamClient{
+		correlationID: r.URL.Query().Get("correlationId"),
+		events:        make(chan Event, s.cfg.BufferSize),
+	}
+
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" && s.cfg.Replay != nil {
+		for _, event := range s.missed(lastEventID) {
+			if client.wants(event) {
+				if err := writeServerSentEvent(w, event); err != nil {
+					return
+				}
+			}
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(s.cfg.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case event := <-client.events:
+			if err := writeServerSentEvent(w, event); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
+
+// missed returns the events in the replay feed published after the event with the provided ID, oldest first. Nothing
+// is returned if the event is no longer in the feed.
+func (s *EventStream) missed(lastEventID string) []Event {
+	recent := s.cfg.Replay.Recent()
+
+	for i, event := range recent {
+		if event.ID != lastEventID {
+			continue
+		}
+
+		missed := make([]Event, i)
+		for j := range missed {
+			missed[j] = recent[i-1-j]
+		}
+		return missed
+	}
+
+	return nil
+}
+
+// writeServerSentEvent writes the event using the event kind as the event type and the JSON encoded event as data.
+func writeServerSentEvent(w http.ResponseWriter, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Kind, data)
+	return err
+}
@@ -0,0 +1,121 @@
+package mpesa
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readServerSentEvent reads the next event from the stream, skipping comments.
+func readServerSentEvent(t *testing.T, r *bufio.Reader) (eventType string, event Event) {
+	t.Helper()
+
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventType = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event))
+		case line == "" && event.ID != "":
+			return eventType, event
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	ctx := context.Background()
+
+	connect := func(t *testing.T, srv *httptest.Server, query string, lastEventID string) *bufio.Reader {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+query, nil)
+		require.NoError(t, err)
+
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = res.Body.Close() })
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		require.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+		return bufio.NewReader(res.Body)
+	}
+
+	waitForClients := func(t *testing.T, s *EventStream, n int) {
+		require.Eventually(t, func() bool { return s.Clients() == n }, time.Second, time.Millisecond)
+	}
+
+	t.Run("it pushes the events to the connected clients", func(t *testing.T) {
+		s := NewEventStream(EventStreamConfig{})
+		srv := httptest.NewServer(s)
+		t.Cleanup(srv.Close)
+
+		all := connect(t, srv, "", "")
+		order := connect(t, srv, "?correlationId=ws_CO_2", "")
+		waitForClients(t, s, 2)
+
+		require.NoError(t, s.Publish(ctx, Event{ID: "1", Kind: CallbackKindSTKPush, CorrelationID: "ws_CO_1"}))
+		require.NoError(t, s.Publish(ctx, Event{ID: "2", Kind: CallbackKindResult, CorrelationID: "ws_CO_2"}))
+
+		eventType, event := readServerSentEvent(t, all)
+		require.Equal(t, "STKPush", eventType)
+		require.Equal(t, "ws_CO_1", event.CorrelationID)
+
+		_, event = readServerSentEvent(t, all)
+		require.Equal(t, "2", event.ID)
+
+		eventType, event = readServerSentEvent(t, order)
+		require.Equal(t, "Result", eventType)
+		require.Equal(t, "2", event.ID)
+	})
+
+	t.Run("it replays the events a reconnecting client missed", func(t *testing.T) {
+		feed := NewCallbackFeed(10)
+		s := NewEventStream(EventStreamConfig{Replay: feed})
+		srv := httptest.NewServer(s)
+		t.Cleanup(srv.Close)
+
+		for _, id := range []string{"1", "2", "3"} {
+			require.NoError(t, feed.Publish(ctx, Event{ID: id, Kind: CallbackKindSTKPush}))
+		}
+
+		r := connect(t, srv, "", "1")
+
+		_, event := readServerSentEvent(t, r)
+		require.Equal(t, "2", event.ID)
+
+		_, event = readServerSentEvent(t, r)
+		require.Equal(t, "3", event.ID)
+	})
+
+	t.Run("clients are removed once they disconnect", func(t *testing.T) {
+		s := NewEventStream(EventStreamConfig{KeepAlive: 10 * time.Millisecond})
+		srv := httptest.NewServer(s)
+		t.Cleanup(srv.Close)
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+
+		res, err := srv.Client().Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		waitForClients(t, s, 1)
+		cancel()
+		waitForClients(t, s, 0)
+	})
+}
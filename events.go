@@ -0,0 +1,105 @@
+package mpesa
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Event is a normalized notification emitted for every callback received from M-Pesa.
+	Event struct {
+		// ID uniquely identifies the event and can be used by consumers to de-duplicate deliveries.
+		ID string `json:"id"`
+
+		// Kind is the type of callback that produced the event.
+		Kind CallbackKind `json:"kind"`
+
+		// CorrelationID links the event to a Transaction. It is the CheckoutRequestID for STK push callbacks, the
+		// ConversationID for result callbacks and the requestId for B2B Express Checkout callbacks.
+		CorrelationID string `json:"correlationId"`
+
+		// ResultCode is the result code sent on the callback. 0 means the transaction was successful.
+		ResultCode int `json:"resultCode"`
+
+		// Payload is the callback as received.
+		Payload json.RawMessage `json:"payload"`
+
+		// OccurredAt is the time the callback was received.
+		OccurredAt time.Time `json:"occurredAt"`
+
+		// Tags attribute the event to a business entity. See NewTaggedPublisher.
+		Tags Tags `json:"tags,omitempty"`
+	}
+
+	// EventPublisher delivers events to a message broker or any other consumer.
+	EventPublisher interface {
+		Publish(ctx context.Context, event Event) error
+	}
+
+	// EventPublisherFunc is an adapter to allow the use of ordinary functions as an EventPublisher.
+	EventPublisherFunc func(ctx context.Context, event Event) error
+
+	// MultiPublisher publishes every event to all of its publishers.
+	MultiPublisher []EventPublisher
+)
+
+// Publish calls fn(ctx, event).
+func (fn EventPublisherFunc) Publish(ctx context.Context, event Event) error {
+	return fn(ctx, event)
+}
+
+// Publish publishes the event to every publisher and returns the joined errors of the publishers that failed.
+func (p MultiPublisher) Publish(ctx context.Context, event Event) error {
+	var errs []error
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// NewEvent creates an Event for the callback record.
+func NewEvent(record CallbackRecord) Event {
+	receivedAt := record.ReceivedAt
+	if receivedAt.IsZero() {
+		receivedAt = time.Now()
+	}
+
+	return Event{
+		ID:            newEventID(),
+		Kind:          record.Kind,
+		CorrelationID: record.CorrelationID,
+		ResultCode:    record.ResultCode,
+		Payload:       record.Payload,
+		OccurredAt:    receivedAt,
+	}
+}
+
+// attributes returns the event metadata as string attributes for brokers that support filtering on them.
+// Tags are added with a "tag_" prefix.
+func (e Event) attributes() map[string]string {
+	attrs := map[string]string{
+		"kind":           string(e.Kind),
+		"correlation_id": e.CorrelationID,
+		"result_code":    fmt.Sprint(e.ResultCode),
+	}
+
+	for k, v := range e.Tags {
+		attrs["tag_"+k] = v
+	}
+
+	return attrs
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,62 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent(t *testing.T) Event {
+	record, err := CallbackRecordFromSTKPushCallback(&STKPushCallback{
+		Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				MerchantRequestID: "29115-34620561-1",
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultCode:        1032,
+				ResultDesc:        "Request cancelled by user.",
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	return NewEvent(record)
+}
+
+func TestNewEvent(t *testing.T) {
+	event := testEvent(t)
+
+	require.Len(t, event.ID, 32)
+	require.Equal(t, CallbackKindSTKPush, event.Kind)
+	require.Equal(t, "ws_CO_191220191020363925", event.CorrelationID)
+	require.Equal(t, 1032, event.ResultCode)
+	require.False(t, event.OccurredAt.IsZero())
+	require.NotEqual(t, event.ID, testEvent(t).ID)
+}
+
+func TestMultiPublisher_Publish(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		event     = testEvent(t)
+		published []string
+	)
+
+	publisher := MultiPublisher{
+		EventPublisherFunc(func(_ context.Context, e Event) error {
+			published = append(published, "first:"+e.ID)
+			return nil
+		}),
+		EventPublisherFunc(func(_ context.Context, e Event) error {
+			return errors.New("broker unavailable")
+		}),
+		EventPublisherFunc(func(_ context.Context, e Event) error {
+			published = append(published, "third:"+e.ID)
+			return nil
+		}),
+	}
+
+	err := publisher.Publish(ctx, event)
+	require.ErrorContains(t, err, "broker unavailable")
+	require.Equal(t, []string{"first:" + event.ID, "third:" + event.ID}, published)
+}
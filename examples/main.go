@@ -22,8 +22,8 @@ func main() {
 		PartyB:            174379,
 		PhoneNumber:       254708374149,
 		CallBackURL:       "https://webhook.site/62daf156-31dc-4b07-ac41-698dbfadaa4b",
-		AccountReference:  "Test reference",
-		TransactionDesc:   "Test description",
+		AccountReference:  "INV-001",
+		TransactionDesc:   "Test payment",
 	})
 
 	if err != nil {
@@ -0,0 +1,165 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// TariffKind identifies the transactions a Tariff applies to.
+type TariffKind string
+
+const (
+	// TariffB2C is the tariff charged to the business for the B2C payments it makes.
+	TariffB2C TariffKind = "b2c"
+
+	// TariffC2B is the tariff charged to the customer for the C2B payments they make.
+	TariffC2B TariffKind = "c2b"
+)
+
+var (
+	// ErrInvalidTariff is returned when the bands of a tariff are not in order or overlap.
+	ErrInvalidTariff = errors.New("mpesa: invalid tariff")
+
+	// ErrNoTariffBand is returned when no band of a tariff covers an amount, or there is no tariff of a kind.
+	ErrNoTariffBand = errors.New("mpesa: no tariff band for the amount")
+)
+
+type (
+	// TariffBand is the Charge of the amounts between Min and Max, inclusive.
+	TariffBand struct {
+		Min    uint `json:"min"`
+		Max    uint `json:"max"`
+		Charge uint `json:"charge"`
+	}
+
+	// Tariff is the bands of a transaction charge, in ascending order of amounts.
+	Tariff []TariffBand
+
+	// Tariffs holds the tariffs of the transaction kinds. Safaricom revises them from time to time, so they are not
+	// bundled with the SDK. Load the published ones with LoadTariffs and replace them at runtime with Load or Set.
+	// Tariffs is safe for concurrent use.
+	Tariffs struct {
+		mu      sync.RWMutex
+		tariffs map[TariffKind]Tariff
+	}
+)
+
+// Validate checks that the bands are in ascending order and do not overlap.
+func (t Tariff) Validate() error {
+	for i, band := range t {
+		if band.Min > band.Max {
+			return fmt.Errorf("%w: band %d-%d", ErrInvalidTariff, band.Min, band.Max)
+		}
+
+		if i > 0 && band.Min <= t[i-1].Max {
+			return fmt.Errorf("%w: band %d-%d overlaps %d-%d", ErrInvalidTariff, band.Min, band.Max, t[i-1].Min,
+				t[i-1].Max)
+		}
+	}
+
+	return nil
+}
+
+// Charge returns the charge of the amount.
+func (t Tariff) Charge(amount uint) (uint, error) {
+	i := sort.Search(len(t), func(i int) bool { return t[i].Max >= amount })
+	if i == len(t) || amount < t[i].Min {
+		return 0, fmt.Errorf("%w: %d", ErrNoTariffBand, amount)
+	}
+
+	return t[i].Charge, nil
+}
+
+// NewTariffs creates an empty Tariffs.
+func NewTariffs() *Tariffs {
+	return &Tariffs{tariffs: make(map[TariffKind]Tariff)}
+}
+
+// LoadTariffs creates the Tariffs from a JSON object of the bands keyed by kind, e.g.
+//
+//	{"b2c": [{"min": 1, "max": 100, "charge": 0}, {"min": 101, "max": 1500, "charge": 5}]}
+func LoadTariffs(r io.Reader) (*Tariffs, error) {
+	t := NewTariffs()
+	if err := t.Load(r); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Load replaces the tariffs of the kinds in the JSON object read from r. The tariffs of the other kinds are kept.
+func (t *Tariffs) Load(r io.Reader) error {
+	var tariffs map[TariffKind]Tariff
+	if err := json.NewDecoder(r).Decode(&tariffs); err != nil {
+		return fmt.Errorf("%w: decode: %v", ErrInvalidTariff, err)
+	}
+
+	for kind, tariff := range tariffs {
+		if err := tariff.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", kind, err)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for kind, tariff := range tariffs {
+		t.tariffs[kind] = tariff
+	}
+
+	return nil
+}
+
+// Set replaces the tariff of the kind.
+func (t *Tariffs) Set(kind TariffKind, tariff Tariff) error {
+	if err := tariff.Validate(); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tariffs[kind] = tariff
+	return nil
+}
+
+// Charge returns the charge of the amount under the tariff of the kind.
+func (t *Tariffs) Charge(kind TariffKind, amount uint) (uint, error) {
+	t.mu.RLock()
+	tariff, ok := t.tariffs[kind]
+	t.mu.RUnlock()
+
+	if !ok {
+		return 0, fmt.Errorf("%w: no %s tariff", ErrNoTariffBand, kind)
+	}
+
+	return tariff.Charge(amount)
+}
+
+// Gross returns the amount plus its charge, i.e. what the payer is debited.
+func (t *Tariffs) Gross(kind TariffKind, amount uint) (uint, error) {
+	charge, err := t.Charge(kind, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount + charge, nil
+}
+
+// Net returns the amount less its charge, i.e. what is left when the charge is deducted from the amount.
+func (t *Tariffs) Net(kind TariffKind, amount uint) (uint, error) {
+	charge, err := t.Charge(kind, amount)
+	if err != nil {
+		return 0, err
+	}
+
+	if charge > amount {
+		return 0, nil
+	}
+
+	return amount - charge, nil
+}
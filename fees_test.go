@@ -0,0 +1,90 @@
+package mpesa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTariffs(t *testing.T) {
+	tariffs, err := LoadTariffs(strings.NewReader(`{
+		"b2c": [
+			{"min": 1, "max": 100, "charge": 0},
+			{"min": 101, "max": 1500, "charge": 5},
+			{"min": 1501, "max": 250000, "charge": 9}
+		],
+		"c2b": [
+			{"min": 1, "max": 100, "charge": 0},
+			{"min": 101, "max": 500, "charge": 7}
+		]
+	}`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		kind   TariffKind
+		amount uint
+		charge uint
+	}{
+		{kind: TariffB2C, amount: 1, charge: 0},
+		{kind: TariffB2C, amount: 100, charge: 0},
+		{kind: TariffB2C, amount: 101, charge: 5},
+		{kind: TariffB2C, amount: 1500, charge: 5},
+		{kind: TariffB2C, amount: 250000, charge: 9},
+		{kind: TariffC2B, amount: 500, charge: 7},
+	}
+
+	for _, tc := range tests {
+		charge, err := tariffs.Charge(tc.kind, tc.amount)
+		require.NoError(t, err)
+		require.Equal(t, tc.charge, charge, "%s %d", tc.kind, tc.amount)
+	}
+
+	gross, err := tariffs.Gross(TariffC2B, 200)
+	require.NoError(t, err)
+	require.Equal(t, uint(207), gross)
+
+	net, err := tariffs.Net(TariffB2C, 1000)
+	require.NoError(t, err)
+	require.Equal(t, uint(995), net)
+
+	_, err = tariffs.Charge(TariffB2C, 0)
+	require.ErrorIs(t, err, ErrNoTariffBand)
+
+	_, err = tariffs.Charge(TariffC2B, 501)
+	require.ErrorIs(t, err, ErrNoTariffBand)
+
+	_, err = tariffs.Charge("unknown", 100)
+	require.ErrorIs(t, err, ErrNoTariffBand)
+
+	t.Run("it replaces the tariffs at runtime", func(t *testing.T) {
+		require.NoError(t, tariffs.Load(strings.NewReader(`{"c2b": [{"min": 1, "max": 1000, "charge": 1}]}`)))
+
+		charge, err := tariffs.Charge(TariffC2B, 501)
+		require.NoError(t, err)
+		require.Equal(t, uint(1), charge)
+
+		charge, err = tariffs.Charge(TariffB2C, 101)
+		require.NoError(t, err)
+		require.Equal(t, uint(5), charge)
+
+		require.NoError(t, tariffs.Set(TariffB2C, Tariff{{Min: 1, Max: 10, Charge: 20}}))
+		net, err := tariffs.Net(TariffB2C, 5)
+		require.NoError(t, err)
+		require.Zero(t, net)
+	})
+
+	t.Run("it rejects invalid tariffs", func(t *testing.T) {
+		err := tariffs.Set(TariffB2C, Tariff{{Min: 1, Max: 100}, {Min: 100, Max: 200}})
+		require.ErrorIs(t, err, ErrInvalidTariff)
+
+		err = tariffs.Set(TariffB2C, Tariff{{Min: 100, Max: 1}})
+		require.ErrorIs(t, err, ErrInvalidTariff)
+
+		_, err = LoadTariffs(strings.NewReader(`{"b2c": [{"min": 10, "max": 1}]}`))
+		require.ErrorIs(t, err, ErrInvalidTariff)
+
+		_, err = LoadTariffs(strings.NewReader(`invalid`))
+		require.ErrorIs(t, err, ErrInvalidTariff)
+	})
+}
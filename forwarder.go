@@ -0,0 +1,240 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultForwardMaxAttempts = 3
+	defaultForwardBackoff     = time.Second
+)
+
+type (
+	// ForwardDestination is a downstream service that should receive a copy of every callback relayed by the
+	// Forwarder.
+	ForwardDestination struct {
+		// Name is a human friendly identifier for the destination used when reporting the DeliveryStatus.
+		Name string
+
+		// URL is the endpoint the callback payload is POSTed to.
+		URL string
+
+		// Header holds any additional headers to be sent along with the payload, e.g. an API key for the service.
+		Header http.Header
+
+		// Secret, if set, is used to sign every request with an HMAC sent on the SignatureHeader. The destination
+		// can verify it using a SignatureVerifier configured with the same secret.
+		Secret []byte
+	}
+
+	// DeliveryStatus reports the outcome of relaying a callback to a single ForwardDestination.
+	DeliveryStatus struct {
+		// Destination is the ForwardDestination the payload was sent to.
+		Destination ForwardDestination
+
+		// Attempts is the number of requests made to the destination.
+		Attempts int
+
+		// StatusCode is the http status code returned on the last attempt. It is 0 if no response was received.
+		StatusCode int
+
+		// Delivered is true if the destination acknowledged the payload with a 2xx status code.
+		Delivered bool
+
+		// Err is the error encountered on the last attempt if the payload was not delivered.
+		Err error
+
+		// CompletedAt is the time the last attempt completed.
+		CompletedAt time.Time
+	}
+
+	// Forwarder relays callbacks received from M-Pesa to one or more downstream URLs. Each destination is retried
+	// independently so a slow or failing service does not affect delivery to the others.
+	Forwarder struct {
+		// Client is used to deliver the payloads. If nil, a http.Client with a 10 second timeout is used.
+		Client HttpClient
+
+		// Destinations is the list of services to relay the callbacks to.
+		Destinations []ForwardDestination
+
+		// MaxAttempts is the maximum number of times a payload is sent to a destination before giving up.
+		// Defaults to 3.
+		MaxAttempts int
+
+		// Backoff is the time to wait before retrying the first failed attempt. It doubles on every subsequent
+		// attempt. Defaults to 1 second.
+		Backoff time.Duration
+
+		// OnDelivery, if set, is called with the DeliveryStatus of every destination once delivery completes.
+		OnDelivery func(status DeliveryStatus)
+
+		once   sync.Once
+		client HttpClient
+	}
+)
+
+// NewForwarder returns a Forwarder that relays callbacks to the provided destinations using the default retry policy.
+func NewForwarder(c HttpClient, destinations ...ForwardDestination) *Forwarder {
+	return &Forwarder{
+		Client:       c,
+		Destinations: destinations,
+	}
+}
+
+func (f *Forwarder) init() {
+	f.once.Do(func() {
+		f.client = f.Client
+		if f.client == nil {
+			f.client = &http.Client{
+				Timeout: 10 * time.Second,
+			}
+		}
+	})
+}
+
+func (f *Forwarder) maxAttempts() int {
+	if f.MaxAttempts <= 0 {
+		return defaultForwardMaxAttempts
+	}
+
+	return f.MaxAttempts
+}
+
+func (f *Forwarder) backoff(attempt int) time.Duration {
+	backoff := f.Backoff
+	if backoff <= 0 {
+		backoff = defaultForwardBackoff
+	}
+
+	return backoff << (attempt - 1)
+}
+
+// isRetryableStatus returns true if a request that failed with the provided status code can be retried.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// Forward sends the payload to every destination concurrently and blocks until all deliveries complete. The returned
+// statuses are in the same order as the Destinations.
+func (f *Forwarder) Forward(ctx context.Context, payload []byte) []DeliveryStatus {
+	f.init()
+
+	var (
+		statuses = make([]DeliveryStatus, len(f.Destinations))
+		wg       sync.WaitGroup
+	)
+
+	for i, destination := range f.Destinations {
+		wg.Add(1)
+		go func(i int, destination ForwardDestination) {
+			defer wg.Done()
+
+			statuses[i] = f.deliver(ctx, destination, payload)
+			if f.OnDelivery != nil {
+				f.OnDelivery(statuses[i])
+			}
+		}(i, destination)
+	}
+
+	wg.Wait()
+	return statuses
+}
+
+// deliver sends the payload to the destination, retrying until it is acknowledged, a non retryable status is
+// returned, the attempts are exhausted or the context is done.
+func (f *Forwarder) deliver(ctx context.Context, destination ForwardDestination, payload []byte) DeliveryStatus {
+	status := DeliveryStatus{Destination: destination}
+
+	for status.Attempts < f.maxAttempts() {
+		if status.Attempts > 0 {
+			timer := time.NewTimer(f.backoff(status.Attempts))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				status.Err = ctx.Err()
+				status.CompletedAt = time.Now()
+				return status
+			case <-timer.C:
+			}
+		}
+
+		status.Attempts++
+		status.StatusCode, status.Err = f.send(ctx, destination, payload)
+		status.CompletedAt = time.Now()
+
+		if status.Err == nil {
+			status.Delivered = true
+			return status
+		}
+
+		if status.StatusCode != 0 && !isRetryableStatus(status.StatusCode) {
+			return status
+		}
+	}
+
+	return status
+}
+
+func (f *Forwarder) send(ctx context.Context, destination ForwardDestination, payload []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("mpesa: create forward request: %v", err)
+	}
+
+	for key, values := range destination.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if len(destination.Secret) > 0 {
+		req.Header.Set(SignatureHeader, SignPayload(destination.Secret, payload, time.Now()))
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("mpesa: forward to %s: %v", destination.URL, err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res.StatusCode, fmt.Errorf("mpesa: forward to %s failed with status: %v", destination.URL, res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// ServeHTTP reads the callback sent by M-Pesa, acknowledges it and relays it to the destinations in the background.
+// Use OnDelivery to observe the delivery results.
+func (f *Forwarder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxAPIRequestBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "mpesa: callback too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		http.Error(w, "mpesa: read callback", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	go f.Forward(ctx, payload)
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte(`{"ResultCode":0,"ResultDesc":"Accepted"}`))
+}
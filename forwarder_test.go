@@ -0,0 +1,219 @@
+package mpesa
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwarder_Forward(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		payload = []byte(`{"Body":{"stkCallback":{"CheckoutRequestID":"ws_CO_191220191020363925","ResultCode":0}}}`)
+	)
+
+	tests := []struct {
+		name string
+		mock func(t *testing.T, f *Forwarder, c *mockHttpClient)
+	}{
+		{
+			name: "it relays the payload to all destinations",
+			mock: func(t *testing.T, f *Forwarder, c *mockHttpClient) {
+				for _, destination := range f.Destinations {
+					c.MockRequest(destination.URL, func() (status int, body string) {
+						return http.StatusOK, `{}`
+					})
+				}
+
+				statuses := f.Forward(ctx, payload)
+				require.Len(t, statuses, 2)
+
+				for i, status := range statuses {
+					require.True(t, status.Delivered)
+					require.NoError(t, status.Err)
+					require.Equal(t, 1, status.Attempts)
+					require.Equal(t, f.Destinations[i], status.Destination)
+				}
+
+				for _, req := range c.requests {
+					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+					require.Equal(t, "secret", req.Header.Get("X-Api-Key"))
+				}
+			},
+		},
+		{
+			name: "it signs the payload for destinations with a secret",
+			mock: func(t *testing.T, f *Forwarder, c *mockHttpClient) {
+				f.Destinations[0].Secret = []byte("ledger-secret")
+
+				for _, destination := range f.Destinations {
+					c.MockRequest(destination.URL, func() (status int, body string) {
+						return http.StatusOK, `{}`
+					})
+				}
+
+				statuses := f.Forward(ctx, payload)
+				require.True(t, statuses[0].Delivered)
+				require.True(t, statuses[1].Delivered)
+
+				verifier := NewSignatureVerifier([]byte("ledger-secret"))
+				for _, req := range c.requests {
+					if req.URL.String() == f.Destinations[0].URL {
+						require.NoError(t, verifier.Verify(req.Header.Get(SignatureHeader), payload))
+						continue
+					}
+
+					require.Empty(t, req.Header.Get(SignatureHeader))
+				}
+			},
+		},
+		{
+			name: "it retries a destination until it succeeds",
+			mock: func(t *testing.T, f *Forwarder, c *mockHttpClient) {
+				var attempts int32
+
+				c.MockRequest(f.Destinations[0].URL, func() (status int, body string) {
+					if atomic.AddInt32(&attempts, 1) < 3 {
+						return http.StatusServiceUnavailable, ``
+					}
+					return http.StatusOK, `{}`
+				})
+
+				c.MockRequest(f.Destinations[1].URL, func() (status int, body string) {
+					return http.StatusOK, `{}`
+				})
+
+				statuses := f.Forward(ctx, payload)
+				require.True(t, statuses[0].Delivered)
+				require.Equal(t, 3, statuses[0].Attempts)
+				require.True(t, statuses[1].Delivered)
+				require.Equal(t, 1, statuses[1].Attempts)
+			},
+		},
+		{
+			name: "it does not retry a destination that rejects the payload",
+			mock: func(t *testing.T, f *Forwarder, c *mockHttpClient) {
+				c.MockRequest(f.Destinations[0].URL, func() (status int, body string) {
+					return http.StatusBadRequest, ``
+				})
+
+				c.MockRequest(f.Destinations[1].URL, func() (status int, body string) {
+					return http.StatusInternalServerError, ``
+				})
+
+				statuses := f.Forward(ctx, payload)
+				require.False(t, statuses[0].Delivered)
+				require.Error(t, statuses[0].Err)
+				require.Equal(t, http.StatusBadRequest, statuses[0].StatusCode)
+				require.Equal(t, 1, statuses[0].Attempts)
+
+				require.False(t, statuses[1].Delivered)
+				require.Equal(t, http.StatusInternalServerError, statuses[1].StatusCode)
+				require.Equal(t, f.MaxAttempts, statuses[1].Attempts)
+			},
+		},
+		{
+			name: "it stops retrying once the context is cancelled",
+			mock: func(t *testing.T, f *Forwarder, c *mockHttpClient) {
+				ctx, cancel := context.WithCancel(ctx)
+
+				f.Backoff = time.Hour
+				c.MockRequest(f.Destinations[0].URL, func() (status int, body string) {
+					cancel()
+					return http.StatusBadGateway, ``
+				})
+
+				statuses := f.Forward(ctx, payload)
+				require.False(t, statuses[0].Delivered)
+				require.ErrorIs(t, statuses[0].Err, context.Canceled)
+				require.Equal(t, 1, statuses[0].Attempts)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				cl = newMockHttpClient()
+				f  = NewForwarder(cl,
+					ForwardDestination{
+						Name:   "ledger",
+						URL:    "https://ledger.example.com/mpesa",
+						Header: http.Header{"X-Api-Key": []string{"secret"}},
+					},
+					ForwardDestination{
+						Name:   "notifications",
+						URL:    "https://notifications.example.com/mpesa",
+						Header: http.Header{"X-Api-Key": []string{"secret"}},
+					},
+				)
+			)
+
+			f.Backoff = time.Millisecond
+			f.MaxAttempts = 3
+
+			tc.mock(t, f, cl)
+		})
+	}
+}
+
+func TestForwarder_ServeHTTP(t *testing.T) {
+	var (
+		cl        = newMockHttpClient()
+		delivered = make(chan DeliveryStatus, 1)
+		payload   = `{"Result":{"ConversationID":"AG_20191219_00005797af5d7d75f652","ResultCode":0}}`
+	)
+
+	cl.MockRequest("https://ledger.example.com/mpesa", func() (status int, body string) {
+		return http.StatusOK, `{}`
+	})
+
+	f := NewForwarder(cl, ForwardDestination{Name: "ledger", URL: "https://ledger.example.com/mpesa"})
+	f.OnDelivery = func(status DeliveryStatus) {
+		delivered <- status
+	}
+
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(payload)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"ResultCode":0,"ResultDesc":"Accepted"}`, rec.Body.String())
+
+	select {
+	case status := <-delivered:
+		require.True(t, status.Delivered)
+	case <-time.After(time.Second):
+		t.Fatal("callback was not forwarded")
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	require.Len(t, cl.requests, 1)
+	body, err := io.ReadAll(cl.requests[0].Body)
+	require.NoError(t, err)
+	require.JSONEq(t, payload, string(body))
+}
+
+func TestForwarder_ServeHTTP_tooLarge(t *testing.T) {
+	f := NewForwarder(newMockHttpClient(), ForwardDestination{Name: "ledger", URL: "https://ledger.example.com/mpesa"})
+	f.OnDelivery = func(DeliveryStatus) {
+		t.Error("oversized callback was forwarded")
+	}
+
+	payload := strings.Repeat("a", maxAPIRequestBodySize+1)
+
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(payload)))
+	require.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
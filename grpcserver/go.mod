@@ -0,0 +1,22 @@
+module github.com/jwambugu/mpesa-golang-sdk/grpcserver
+
+go 1.21
+
+require (
+	github.com/jwambugu/mpesa-golang-sdk v0.0.0
+	github.com/stretchr/testify v1.8.4
+	google.golang.org/grpc v1.67.1
+	google.golang.org/protobuf v1.35.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.24.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/jwambugu/mpesa-golang-sdk => ../
@@ -0,0 +1,188 @@
+// Package grpcserver exposes the M-Pesa SDK as a gRPC service so that services written in other languages can use
+// the Go SDK as a payment sidecar. The service definition lives in proto/mpesa/v1/mpesa.proto.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+	"github.com/jwambugu/mpesa-golang-sdk/grpcserver/mpesav1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Config holds the credentials used by the Server. These are kept on the sidecar and never sent by the clients.
+type Config struct {
+	// Passkey is used to generate the password for STKPush and STKQuery requests.
+	Passkey string
+
+	// InitiatorName and InitiatorPassword are the credentials of the API operator used for B2C, transaction status
+	// and account balance requests.
+	InitiatorName     string
+	InitiatorPassword string
+}
+
+// Server implements mpesav1.MpesaServiceServer using a *mpesa.Mpesa app.
+type Server struct {
+	mpesav1.UnimplementedMpesaServiceServer
+
+	app mpesa.Client
+	cfg Config
+}
+
+var _ mpesav1.MpesaServiceServer = (*Server)(nil)
+
+// NewServer creates a Server that makes the requests using the provided app.
+func NewServer(app mpesa.Client, cfg Config) *Server {
+	return &Server{
+		app: app,
+		cfg: cfg,
+	}
+}
+
+// Register registers the Server on the gRPC server.
+func (s *Server) Register(srv *grpc.Server) {
+	mpesav1.RegisterMpesaServiceServer(srv, s)
+}
+
+// toStatus maps errors returned by the SDK to gRPC status errors.
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, mpesa.ErrInvalidPasskey), errors.Is(err, mpesa.ErrInvalidInitiatorPassword):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, mpesa.ErrInvalidRequest):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, mpesa.ErrCircuitOpen):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Unknown, err.Error())
+	}
+}
+
+func fromSTKPushResponse(res *mpesa.STKPushResponse) *mpesav1.Response {
+	return &mpesav1.Response{
+		CheckoutRequestId:   res.CheckoutRequestID,
+		CustomerMessage:     res.CustomerMessage,
+		MerchantRequestId:   res.MerchantRequestID,
+		ResponseCode:        res.ResponseCode,
+		ResponseDescription: res.ResponseDescription,
+	}
+}
+
+func fromSTKQueryResponse(res *mpesa.STKQueryResponse) *mpesav1.Response {
+	return &mpesav1.Response{
+		CheckoutRequestId:   res.CheckoutRequestID,
+		MerchantRequestId:   res.MerchantRequestID,
+		ResponseCode:        res.ResponseCode,
+		ResponseDescription: res.ResponseDescription,
+		ResultCode:          res.ResultCode,
+		ResultDesc:          res.ResultDesc,
+	}
+}
+
+func fromAsyncResponse(res *mpesa.AsyncResponse) *mpesav1.Response {
+	return &mpesav1.Response{
+		ConversationId:           res.ConversationID,
+		OriginatorConversationId: res.OriginatorConversationID,
+		ResponseCode:             res.ResponseCode,
+		ResponseDescription:      res.ResponseDescription,
+	}
+}
+
+// STKPush initiates online payment on behalf of a customer.
+func (s *Server) STKPush(ctx context.Context, req *mpesav1.STKPushRequest) (*mpesav1.Response, error) {
+	res, err := s.app.STKPush(ctx, s.cfg.Passkey, mpesa.STKPushRequest{
+		BusinessShortCode: uint(req.GetBusinessShortCode()),
+		TransactionType:   mpesa.TransactionType(req.GetTransactionType()),
+		Amount:            uint(req.GetAmount()),
+		PartyA:            uint(req.GetPartyA()),
+		PartyB:            uint(req.GetPartyB()),
+		PhoneNumber:       req.GetPhoneNumber(),
+		CallBackURL:       req.GetCallbackUrl(),
+		AccountReference:  req.GetAccountReference(),
+		TransactionDesc:   req.GetTransactionDesc(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return fromSTKPushResponse(res), nil
+}
+
+// STKQuery checks the status of an STKPush payment.
+func (s *Server) STKQuery(ctx context.Context, req *mpesav1.STKQueryRequest) (*mpesav1.Response, error) {
+	res, err := s.app.STKQuery(ctx, s.cfg.Passkey, mpesa.STKQueryRequest{
+		BusinessShortCode: uint(req.GetBusinessShortCode()),
+		CheckoutRequestID: req.GetCheckoutRequestId(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return fromSTKQueryResponse(res), nil
+}
+
+// B2C transacts between an M-Pesa short code to a phone number registered on M-Pesa.
+func (s *Server) B2C(ctx context.Context, req *mpesav1.B2CRequest) (*mpesav1.Response, error) {
+	res, err := s.app.B2C(ctx, s.cfg.InitiatorPassword, mpesa.B2CRequest{
+		InitiatorName:   s.cfg.InitiatorName,
+		CommandID:       mpesa.CommandID(req.GetCommandId()),
+		Amount:          uint(req.GetAmount()),
+		PartyA:          uint(req.GetPartyA()),
+		PartyB:          req.GetPartyB(),
+		Remarks:         req.GetRemarks(),
+		QueueTimeOutURL: req.GetQueueTimeoutUrl(),
+		ResultURL:       req.GetResultUrl(),
+		Occasion:        req.GetOccasion(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return fromAsyncResponse(res), nil
+}
+
+// TransactionStatus checks the status of a transaction.
+func (s *Server) TransactionStatus(
+	ctx context.Context, req *mpesav1.TransactionStatusRequest,
+) (*mpesav1.Response, error) {
+	res, err := s.app.GetTransactionStatus(ctx, s.cfg.InitiatorPassword, mpesa.TransactionStatusRequest{
+		Initiator:                s.cfg.InitiatorName,
+		Occasion:                 req.GetOccasion(),
+		OriginatorConversationID: req.GetOriginatorConversationId(),
+		PartyA:                   uint(req.GetPartyA()),
+		QueueTimeOutURL:          req.GetQueueTimeoutUrl(),
+		Remarks:                  req.GetRemarks(),
+		ResultURL:                req.GetResultUrl(),
+		TransactionID:            req.GetTransactionId(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return fromAsyncResponse(res), nil
+}
+
+// AccountBalance fetches the account balance of a short code.
+func (s *Server) AccountBalance(
+	ctx context.Context, req *mpesav1.AccountBalanceRequest,
+) (*mpesav1.Response, error) {
+	res, err := s.app.GetAccountBalance(ctx, s.cfg.InitiatorPassword, mpesa.AccountBalanceRequest{
+		Initiator:       s.cfg.InitiatorName,
+		PartyA:          int(req.GetPartyA()),
+		QueueTimeOutURL: req.GetQueueTimeoutUrl(),
+		Remarks:         req.GetRemarks(),
+		ResultURL:       req.GetResultUrl(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return fromAsyncResponse(res), nil
+}
@@ -0,0 +1,153 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+	"github.com/jwambugu/mpesa-golang-sdk/grpcserver/mpesav1"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeHttpClient map[string]struct {
+	status int
+	body   string
+}
+
+func (f fakeHttpClient) Do(req *http.Request) (*http.Response, error) {
+	res, ok := f[req.URL.Path]
+	if !ok {
+		res.status, res.body = http.StatusNotFound, `{}`
+	}
+
+	return &http.Response{
+		Status:     http.StatusText(res.status),
+		StatusCode: res.status,
+		Body:       io.NopCloser(bytes.NewBufferString(res.body)),
+	}, nil
+}
+
+func newTestClient(t *testing.T, cfg Config) mpesav1.MpesaServiceClient {
+	t.Helper()
+
+	cl := fakeHttpClient{
+		"/oauth/v1/generate": {http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`},
+		"/mpesa/stkpush/v1/processrequest": {http.StatusOK, `
+			{
+				"MerchantRequestID": "29115-34620561-1",
+				"CheckoutRequestID": "ws_CO_191220191020363925",
+				"ResponseCode": "0",
+				"ResponseDescription": "Success. Request accepted for processing",
+				"CustomerMessage": "Success. Request accepted for processing"
+			}`},
+		"/mpesa/b2c/v1/paymentrequest": {http.StatusBadRequest, `
+			{
+				"requestId": "11728-2929992-1",
+				"errorCode": "401.002.01",
+				"errorMessage": "Error Occurred - Invalid Access Token"
+			}`},
+	}
+
+	var (
+		lis = bufconn.Listen(1 << 20)
+		srv = grpc.NewServer()
+		app = mpesa.NewApp(cl, "consumer-key", "consumer-secret", mpesa.EnvironmentSandbox)
+	)
+
+	NewServer(app, cfg).Register(srv)
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return mpesav1.NewMpesaServiceClient(conn)
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		cfg  Config
+		run  func(t *testing.T, c mpesav1.MpesaServiceClient)
+	}{
+		{
+			name: "it initiates an stk push",
+			cfg:  Config{Passkey: "passkey"},
+			run: func(t *testing.T, c mpesav1.MpesaServiceClient) {
+				res, err := c.STKPush(ctx, &mpesav1.STKPushRequest{
+					BusinessShortCode: 174379,
+					TransactionType:   mpesa.CustomerPayBillOnlineTransactionType,
+					Amount:            10,
+					PartyA:            254708374149,
+					PartyB:            174379,
+					PhoneNumber:       254708374149,
+					CallbackUrl:       "https://example.com",
+					AccountReference:  "Test",
+					TransactionDesc:   "Test",
+				})
+				require.NoError(t, err)
+				require.Equal(t, "ws_CO_191220191020363925", res.GetCheckoutRequestId())
+				require.Equal(t, "0", res.GetResponseCode())
+			},
+		},
+		{
+			name: "it fails with failed precondition if the passkey is not configured",
+			run: func(t *testing.T, c mpesav1.MpesaServiceClient) {
+				_, err := c.STKQuery(ctx, &mpesav1.STKQueryRequest{
+					BusinessShortCode: 174379,
+					CheckoutRequestId: "ws_CO_191220191020363925",
+				})
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name: "it returns the error from mpesa",
+			cfg:  Config{InitiatorName: "testapi", InitiatorPassword: "Safaricom999!*!"},
+			run: func(t *testing.T, c mpesav1.MpesaServiceClient) {
+				_, err := c.B2C(ctx, &mpesav1.B2CRequest{
+					CommandId:       string(mpesa.BusinessPaymentCommandID),
+					Amount:          10,
+					PartyA:          600426,
+					PartyB:          254708374149,
+					QueueTimeoutUrl: "https://example.com/timeout",
+					ResultUrl:       "https://example.com/result",
+				})
+				require.Equal(t, codes.Unknown, status.Code(err))
+				require.Contains(t, status.Convert(err).Message(), "Invalid Access Token")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tc.run(t, newTestClient(t, tc.cfg))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package mpesa
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// HeaderRequestID is the header carrying the ID of every request made by the app. The ID is generated unless it is
+// set using WithRequestID, and is reported on the errors, logs and request hooks for correlation.
+const HeaderRequestID = "X-Request-ID"
+
+// headersContextKey is the context key the headers are stored under.
+type headersContextKey struct{}
+
+// WithHeader returns a copy of ctx carrying the header, which is set on the API requests made with ctx, e.g. to
+// propagate a tracing header. A header already on ctx with the same key is replaced. The Authorization and
+// Content-Type headers cannot be overridden.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	headers := HeadersFromContext(ctx)
+	if headers == nil {
+		headers = make(http.Header)
+	}
+
+	headers.Set(key, value)
+	return context.WithValue(ctx, headersContextKey{}, headers)
+}
+
+// WithRequestID returns a copy of ctx that sets the ID of the API requests made with ctx instead of generating one.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithHeader(ctx, HeaderRequestID, id)
+}
+
+// HeadersFromContext returns a copy of the headers carried by ctx, or nil if there are none.
+func HeadersFromContext(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(headersContextKey{}).(http.Header)
+	return headers.Clone()
+}
+
+// WithDefaultHeader sets the header on every API request made by the app. Headers set on the request context using
+// WithHeader take precedence.
+func WithDefaultHeader(key, value string) Option {
+	return func(m *Mpesa) {
+		if m.headers == nil {
+			m.headers = make(http.Header)
+		}
+		m.headers.Set(key, value)
+	}
+}
+
+// setHeaders sets the default headers of the app and the headers carried by ctx on the request, and the request ID
+// unless one of them sets it.
+func (m *Mpesa) setHeaders(ctx context.Context, req *http.Request, requestID string) {
+	for key, values := range m.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+
+	for key, values := range HeadersFromContext(ctx) {
+		req.Header[key] = values
+	}
+
+	if req.Header.Get(HeaderRequestID) == "" {
+		req.Header.Set(HeaderRequestID, requestID)
+	}
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
+
+// responseRequestID returns the ID of the request the response was received for.
+func responseRequestID(res *http.Response) string {
+	if res.Request == nil {
+		return ""
+	}
+
+	return res.Request.Header.Get(HeaderRequestID)
+}
+
+// setResponseRequest sets the request of the response, which clients other than http.Client may leave unset.
+func setResponseRequest(res *http.Response, req *http.Request) {
+	if res != nil && res.Request == nil {
+		res.Request = req
+	}
+}
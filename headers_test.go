@@ -0,0 +1,86 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHeader(t *testing.T) {
+	ctx := context.Background()
+	queryReq := STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"}
+
+	t.Run("it sets the headers and a request ID on every request", func(t *testing.T) {
+		var (
+			cl    = newMockHttpClient()
+			infos []RequestInfo
+			app   = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+				WithDefaultHeader("X-Service", "checkout"),
+				WithDefaultHeader("Traceparent", "default"),
+				WithRequestHook(func(_ context.Context, info RequestInfo) { infos = append(infos, info) }),
+			)
+		)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0"}`
+		})
+
+		reqCtx := WithHeader(ctx, "Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		reqCtx = WithHeader(reqCtx, "Authorization", "Bearer forged")
+
+		_, err := app.STKQuery(reqCtx, "passkey", queryReq)
+		require.NoError(t, err)
+		require.Len(t, cl.requests, 2)
+
+		for _, req := range cl.requests {
+			require.Equal(t, "checkout", req.Header.Get("X-Service"))
+			require.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", req.Header.Get("Traceparent"))
+			require.Len(t, req.Header.Get(HeaderRequestID), 32)
+		}
+
+		require.NotEqual(t, cl.requests[0].Header.Get(HeaderRequestID), cl.requests[1].Header.Get(HeaderRequestID))
+		require.Equal(t, []string{"Bearer " + cachedAccessToken(t, app)}, cl.requests[1].Header.Values("Authorization"))
+
+		require.Len(t, infos, 1)
+		require.Equal(t, cl.requests[1].Header.Get(HeaderRequestID), infos[0].RequestID)
+	})
+
+	t.Run("it reports the request ID on the errors", func(t *testing.T) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			return http.StatusBadRequest, `{"requestId": "1", "errorCode": "400.002.02", "errorMessage": "Bad Request"}`
+		})
+
+		_, err := app.STKQuery(WithRequestID(ctx, "order-42"), "passkey", queryReq)
+
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, "order-42", apiErr.ClientRequestID)
+		require.Equal(t, "order-42", cl.requests[1].Header.Get(HeaderRequestID))
+
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			return http.StatusOK, `<html>Request blocked by the proxy</html>`
+		})
+
+		_, err = app.STKQuery(WithRequestID(ctx, "order-43"), "passkey", queryReq)
+
+		var decodeErr *DecodeError
+		require.True(t, errors.As(err, &decodeErr))
+		require.Equal(t, "order-43", decodeErr.ClientRequestID)
+	})
+
+	t.Run("it does not change the headers of the parent context", func(t *testing.T) {
+		parent := WithHeader(ctx, "X-Tenant", "a")
+		_ = WithHeader(parent, "X-Tenant", "b")
+
+		require.Equal(t, "a", HeadersFromContext(parent).Get("X-Tenant"))
+		require.Nil(t, HeadersFromContext(ctx))
+	})
+}
@@ -0,0 +1,93 @@
+package mpesa
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultHTTPTimeout is the timeout of the client NewApp creates when it is not given one.
+const defaultHTTPTimeout = 10 * time.Second
+
+// httpClientConfig holds the settings of the client NewApp creates when it is not given one.
+type httpClientConfig struct {
+	timeout             time.Duration
+	proxy               *url.URL
+	tlsConfig           *tls.Config
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+}
+
+// WithHTTPTimeout sets the timeout of the requests, 10 seconds by default. Like the other HTTP client options, it only
+// applies when NewApp is given a nil HttpClient.
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(m *Mpesa) {
+		m.httpConfig.timeout = timeout
+	}
+}
+
+// WithProxy sends the requests through the proxy, e.g. an egress proxy, instead of the one set by the HTTPS_PROXY
+// environment variable. It only applies when NewApp is given a nil HttpClient.
+func WithProxy(proxy *url.URL) Option {
+	return func(m *Mpesa) {
+		m.httpConfig.proxy = proxy
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the connections to Daraja, e.g. to trust the certificate authority of
+// a TLS-intercepting proxy. It only applies when NewApp is given a nil HttpClient.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(m *Mpesa) {
+		m.httpConfig.tlsConfig = cfg
+	}
+}
+
+// WithKeepAlive sets the number of idle connections kept open to Daraja and how long they are kept. A negative
+// maxIdleConns disables keep-alives and a zero value keeps the default. It only applies when NewApp is given a nil
+// HttpClient.
+func WithKeepAlive(maxIdleConns int, idleTimeout time.Duration) Option {
+	return func(m *Mpesa) {
+		m.httpConfig.maxIdleConnsPerHost = maxIdleConns
+		m.httpConfig.idleConnTimeout = idleTimeout
+	}
+}
+
+// newClient creates the client with the settings. The default transport is used when none of them change it.
+func (c httpClientConfig) newClient() *http.Client {
+	client := &http.Client{Timeout: c.timeout}
+	if client.Timeout <= 0 {
+		client.Timeout = defaultHTTPTimeout
+	}
+
+	if c.proxy == nil && c.tlsConfig == nil && c.maxIdleConnsPerHost == 0 && c.idleConnTimeout <= 0 {
+		return client
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.proxy != nil {
+		transport.Proxy = http.ProxyURL(c.proxy)
+	}
+
+	if c.tlsConfig != nil {
+		transport.TLSClientConfig = c.tlsConfig.Clone()
+	}
+
+	switch {
+	case c.maxIdleConnsPerHost < 0:
+		transport.DisableKeepAlives = true
+	case c.maxIdleConnsPerHost > 0:
+		transport.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+		if transport.MaxIdleConns < c.maxIdleConnsPerHost {
+			transport.MaxIdleConns = c.maxIdleConnsPerHost
+		}
+	}
+
+	if c.idleConnTimeout > 0 {
+		transport.IdleConnTimeout = c.idleConnTimeout
+	}
+
+	client.Transport = transport
+	return client
+}
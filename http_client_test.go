@@ -0,0 +1,87 @@
+package mpesa
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPClientOptions(t *testing.T) {
+	t.Run("it creates the default client", func(t *testing.T) {
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+
+		client, ok := app.client.(*http.Client)
+		require.True(t, ok)
+		require.Equal(t, defaultHTTPTimeout, client.Timeout)
+		require.Nil(t, client.Transport)
+	})
+
+	t.Run("it configures the client", func(t *testing.T) {
+		proxy, err := url.Parse("http://proxy.example.com:3128")
+		require.NoError(t, err)
+
+		tlsConfig := &tls.Config{ServerName: "sandbox.safaricom.co.ke", MinVersion: tls.VersionTLS12}
+
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+			WithHTTPTimeout(30*time.Second),
+			WithProxy(proxy),
+			WithTLSConfig(tlsConfig),
+			WithKeepAlive(200, time.Minute),
+		)
+
+		client := app.client.(*http.Client)
+		require.Equal(t, 30*time.Second, client.Timeout)
+
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+		require.NotSame(t, http.DefaultTransport, transport)
+		require.Equal(t, 200, transport.MaxIdleConnsPerHost)
+		require.GreaterOrEqual(t, transport.MaxIdleConns, 200)
+		require.Equal(t, time.Minute, transport.IdleConnTimeout)
+		require.Equal(t, "sandbox.safaricom.co.ke", transport.TLSClientConfig.ServerName)
+		require.NotSame(t, tlsConfig, transport.TLSClientConfig)
+
+		req := httptest.NewRequest(http.MethodGet, sandboxBaseURL, nil)
+		got, err := transport.Proxy(req)
+		require.NoError(t, err)
+		require.Equal(t, proxy, got)
+
+		app = NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithKeepAlive(-1, 0))
+		require.True(t, app.client.(*http.Client).Transport.(*http.Transport).DisableKeepAlives)
+	})
+
+	t.Run("it sends the requests through the proxy", func(t *testing.T) {
+		var requested string
+
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requested = r.URL.String()
+			_, _ = w.Write([]byte(`{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`))
+		}))
+		defer proxy.Close()
+
+		proxyURL, err := url.Parse(proxy.URL)
+		require.NoError(t, err)
+
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+			WithBaseURL("http://daraja.example.com"),
+			WithProxy(proxyURL),
+		)
+
+		token, err := app.GenerateAccessToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+		require.Equal(t, "http://daraja.example.com/oauth/v1/generate?grant_type=client_credentials", requested)
+	})
+
+	t.Run("it keeps the client it is given", func(t *testing.T) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithHTTPTimeout(time.Minute))
+		require.Same(t, cl, app.client)
+	})
+}
@@ -0,0 +1,238 @@
+package mpesa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultIdempotencyWindow is how long the requests are remembered when no window is set.
+const defaultIdempotencyWindow = 24 * time.Hour
+
+var (
+	// ErrDuplicateRequest is returned when a request with the same idempotency key is still being processed or its
+	// outcome is unknown.
+	ErrDuplicateRequest = errors.New("mpesa: duplicate request")
+
+	// ErrIdempotencyKeyReused is returned when an idempotency key is reused for a different request.
+	ErrIdempotencyKeyReused = errors.New("mpesa: idempotency key reused with a different request")
+)
+
+type (
+	// IdempotencyRecord is what an IdempotencyStore keeps for an idempotency key.
+	IdempotencyRecord struct {
+		// Fingerprint is the hash of the request made with the key.
+		Fingerprint string `json:"fingerprint"`
+
+		// Response is the response of the request. It is nil while the request is being processed.
+		Response *AsyncResponse `json:"response,omitempty"`
+
+		// CreatedAt is the time the request was made.
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// IdempotencyStore keeps the IdempotencyRecord of the B2C, BusinessPayBill and BusinessBuyGoods requests.
+	// Implement it on top of a shared store such as Redis to dedupe the requests made by different app instances.
+	// Implementations must be safe for concurrent use.
+	IdempotencyStore interface {
+		// Reserve records the key for ttl if it is not recorded yet and returns true. If it is, the existing record is
+		// returned with false. The check and the write must be atomic.
+		Reserve(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (IdempotencyRecord, bool, error)
+
+		// Complete replaces the record of the key for ttl once the request succeeded.
+		Complete(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+
+		// Release removes the key once the request was rejected so that it can be made again.
+		Release(ctx context.Context, key string) error
+	}
+
+	// MemoryIdempotencyStore is an in-memory IdempotencyStore for single instance deployments.
+	MemoryIdempotencyStore struct {
+		mu      sync.Mutex
+		entries map[string]idempotencyEntry
+		now     func() time.Time
+	}
+
+	idempotencyEntry struct {
+		record    IdempotencyRecord
+		expiresAt time.Time
+	}
+
+	// idempotencyKeyContextKey is the context key the idempotency key is stored under.
+	idempotencyKeyContextKey struct{}
+
+	// requestNotSentError wraps the errors raised before a request was sent to Daraja, e.g. by an open circuit
+	// breaker, a rate limiter whose context is done or a failure to generate the access token.
+	requestNotSentError struct {
+		err error
+	}
+)
+
+func (e *requestNotSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestNotSentError) Unwrap() error {
+	return e.err
+}
+
+// NewMemoryIdempotencyStore creates an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// Reserve records the key unless it is recorded and not expired. Expired entries are removed on every call.
+func (s *MemoryIdempotencyStore) Reserve(
+	_ context.Context, key string, record IdempotencyRecord, ttl time.Duration,
+) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+
+	if entry, ok := s.entries[key]; ok {
+		return entry.record, false, nil
+	}
+
+	s.entries[key] = idempotencyEntry{record: record, expiresAt: now.Add(ttl)}
+	return record, true, nil
+}
+
+// Complete replaces the record of the key.
+func (s *MemoryIdempotencyStore) Complete(
+	_ context.Context, key string, record IdempotencyRecord, ttl time.Duration,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = idempotencyEntry{record: record, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+// Release removes the key.
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// WithIdempotency makes B2C, BusinessPayBill and BusinessBuyGoods refuse duplicate submissions made within window, or
+// 24 hours if window is not positive. Requests are identified by the idempotency key set using WithIdempotencyKey, the
+// OriginatorConversationID of B2C requests or, failing both, by their fingerprint. Distinct payments with the same
+// details must therefore be made with distinct keys.
+//
+// A duplicate of a request that succeeded returns the original response. A duplicate of a request that is still
+// being processed, or whose outcome is unknown because of a network error or a 5xx response, fails with
+// ErrDuplicateRequest; use GetTransactionStatus to find out whether it went through. Requests rejected by Daraja with a
+// 4xx response, and requests that were never sent, e.g. because the circuit breaker is open, the context was done while
+// waiting for the rate limiter or the access token could not be generated, can be made again.
+func WithIdempotency(store IdempotencyStore, window time.Duration) Option {
+	if window <= 0 {
+		window = defaultIdempotencyWindow
+	}
+
+	return func(m *Mpesa) {
+		m.idempotencyStore = store
+		m.idempotencyWindow = window
+	}
+}
+
+// WithIdempotencyKey returns a copy of ctx carrying the idempotency key of the B2C, BusinessPayBill or BusinessBuyGoods
+// request made with it.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key carried by ctx, or an empty string if there is none.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyContextKey{}).(string)
+	return key
+}
+
+// requestFingerprint returns the hash of the request made to the endpoint.
+func requestFingerprint(endpoint Endpoint, req interface{}) (string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("mpesa: marshal request: %v", err)
+	}
+
+	sum := sha256.Sum256(append([]byte(endpoint+"\n"), b...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// idempotent calls do unless the request identified by key, or by its fingerprint if key is empty, was already made.
+// The fingerprint must not include values that change between attempts such as the SecurityCredential.
+func (m *Mpesa) idempotent(
+	ctx context.Context, endpoint Endpoint, key string, req interface{}, do func() (*AsyncResponse, error),
+) (*AsyncResponse, error) {
+	if m.idempotencyStore == nil {
+		return do()
+	}
+
+	fingerprint, err := requestFingerprint(endpoint, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" {
+		key = fingerprint
+	}
+	key = string(endpoint) + ":" + key
+
+	record, reserved, err := m.idempotencyStore.Reserve(ctx, key, IdempotencyRecord{
+		Fingerprint: fingerprint,
+		CreatedAt:   time.Now(),
+	}, m.idempotencyWindow)
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: reserve idempotency key: %v", err)
+	}
+
+	if !reserved {
+		switch {
+		case record.Fingerprint != fingerprint:
+			return nil, fmt.Errorf("%w: %s", ErrIdempotencyKeyReused, key)
+		case record.Response == nil:
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateRequest, key)
+		}
+
+		res := *record.Response
+		return &res, nil
+	}
+
+	res, err := do()
+	if err != nil {
+		var (
+			apiErr     *Error
+			notSentErr *requestNotSentError
+		)
+
+		// The key is kept when the outcome is unknown, i.e. the request was sent and failed without a 4xx response.
+		if errors.As(err, &notSentErr) ||
+			errors.As(err, &apiErr) && apiErr.StatusCode < http.StatusInternalServerError {
+			_ = m.idempotencyStore.Release(context.WithoutCancel(ctx), key)
+		}
+		return nil, err
+	}
+
+	// A failure to record the response must not fail the request, duplicates are refused until the key expires.
+	record.Response = res
+	_ = m.idempotencyStore.Complete(ctx, key, record, m.idempotencyWindow)
+
+	return res, nil
+}
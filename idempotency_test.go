@@ -0,0 +1,233 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		now   = time.Now()
+		store = NewMemoryIdempotencyStore()
+	)
+
+	store.now = func() time.Time { return now }
+
+	record, reserved, err := store.Reserve(ctx, "key", IdempotencyRecord{Fingerprint: "a"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.Equal(t, "a", record.Fingerprint)
+
+	record, reserved, err = store.Reserve(ctx, "key", IdempotencyRecord{Fingerprint: "b"}, time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Equal(t, "a", record.Fingerprint)
+
+	require.NoError(t, store.Complete(ctx, "key", IdempotencyRecord{
+		Fingerprint: "a",
+		Response:    &AsyncResponse{ConversationID: "AG_1"},
+	}, time.Minute))
+
+	record, reserved, err = store.Reserve(ctx, "key", IdempotencyRecord{Fingerprint: "a"}, time.Minute)
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Equal(t, "AG_1", record.Response.ConversationID)
+
+	now = now.Add(time.Minute)
+
+	_, reserved, err = store.Reserve(ctx, "key", IdempotencyRecord{Fingerprint: "b"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	require.NoError(t, store.Release(ctx, "key"))
+
+	_, reserved, err = store.Reserve(ctx, "key", IdempotencyRecord{Fingerprint: "c"}, time.Minute)
+	require.NoError(t, err)
+	require.True(t, reserved)
+}
+
+func TestWithIdempotency(t *testing.T) {
+	ctx := context.Background()
+
+	b2cReq := B2CRequest{
+		InitiatorName:   "TestG2Init",
+		CommandID:       BusinessPaymentCommandID,
+		Amount:          10,
+		PartyA:          600123,
+		PartyB:          254728762287,
+		Remarks:         "This is a remark",
+		QueueTimeOutURL: "https://example.com",
+		ResultURL:       "https://example.com",
+		Occasion:        "Test Occasion",
+	}
+
+	newApp := func(status int, body string, opts ...Option) (*Mpesa, *int) {
+		opts = append([]Option{WithIdempotency(NewMemoryIdempotencyStore(), 0)}, opts...)
+
+		var (
+			cl    = newMockHttpClient()
+			app   = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, opts...)
+			calls int
+		)
+
+		mockAuth(app, cl)
+
+		mock := func() (int, string) {
+			calls++
+			return status, body
+		}
+		cl.MockRequest(app.endpointB2C(), mock)
+		cl.MockRequest(app.endpointBusinessPayBill(), mock)
+
+		return app, &calls
+	}
+
+	const accepted = `{
+		"ConversationID": "AG_20191219_00005797af5d7d75f652",
+		"OriginatorConversationID": "16740-34861180-1",
+		"ResponseCode": "0",
+		"ResponseDescription": "Accept the service request successfully."
+	}`
+
+	t.Run("it returns the original response of a duplicate request", func(t *testing.T) {
+		app, calls := newApp(http.StatusOK, accepted)
+
+		first, err := app.B2C(ctx, "random-string", b2cReq)
+		require.NoError(t, err)
+
+		second, err := app.B2C(ctx, "random-string", b2cReq)
+		require.NoError(t, err)
+
+		require.Equal(t, first, second)
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("it makes requests with distinct keys", func(t *testing.T) {
+		app, calls := newApp(http.StatusOK, accepted)
+
+		_, err := app.B2C(WithIdempotencyKey(ctx, "payout-1"), "random-string", b2cReq)
+		require.NoError(t, err)
+
+		req := b2cReq
+		req.OriginatorConversationID = "payout-2"
+
+		_, err = app.B2C(ctx, "random-string", req)
+		require.NoError(t, err)
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("it refuses a key reused for a different request", func(t *testing.T) {
+		app, calls := newApp(http.StatusOK, accepted)
+		keyCtx := WithIdempotencyKey(ctx, "payout-1")
+
+		_, err := app.B2C(keyCtx, "random-string", b2cReq)
+		require.NoError(t, err)
+
+		req := b2cReq
+		req.Amount = 20
+
+		_, err = app.B2C(keyCtx, "random-string", req)
+		require.True(t, errors.Is(err, ErrIdempotencyKeyReused))
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("it refuses a duplicate of a request with an unknown outcome", func(t *testing.T) {
+		app, calls := newApp(http.StatusServiceUnavailable, `{"errorCode": "503.001.01"}`)
+
+		payBillReq := BusinessPayBillRequest{
+			AccountReference: "600992",
+			Amount:           10,
+			Initiator:        "testapi",
+			PartyA:           600992,
+			PartyB:           600992,
+			QueueTimeOutURL:  "https://example.com",
+			ResultURL:        "https://example.com",
+		}
+
+		_, err := app.BusinessPayBill(ctx, "random-string", payBillReq)
+		require.Error(t, err)
+
+		_, err = app.BusinessPayBill(ctx, "random-string", payBillReq)
+		require.True(t, errors.Is(err, ErrDuplicateRequest))
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("it allows a rejected request to be made again", func(t *testing.T) {
+		app, calls := newApp(http.StatusBadRequest, `{"errorCode": "400.002.02", "errorMessage": "Bad Request"}`)
+
+		_, err := app.B2C(ctx, "random-string", b2cReq)
+		require.Error(t, err)
+
+		_, err = app.B2C(ctx, "random-string", b2cReq)
+		require.ErrorContains(t, err, "400.002.02")
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("it allows a request stopped by the open circuit breaker to be made again", func(t *testing.T) {
+		var (
+			now     = time.Now()
+			breaker = NewCircuitBreaker(CircuitBreakerConfig{OpenDuration: time.Minute})
+			keyCtx  = WithIdempotencyKey(ctx, "payout-1")
+		)
+
+		breaker.now = func() time.Time { return now }
+		app, calls := newApp(http.StatusOK, accepted, WithCircuitBreaker(breaker))
+
+		breaker.mu.Lock()
+		breaker.open()
+		breaker.mu.Unlock()
+
+		_, err := app.B2C(keyCtx, "random-string", b2cReq)
+		require.ErrorIs(t, err, ErrCircuitOpen)
+		require.Equal(t, 0, *calls)
+
+		now = now.Add(time.Minute)
+
+		_, err = app.B2C(keyCtx, "random-string", b2cReq)
+		require.NoError(t, err)
+		require.Equal(t, 1, *calls)
+	})
+
+	t.Run("it allows a request cancelled while waiting for the rate limiter to be made again", func(t *testing.T) {
+		app, calls := newApp(http.StatusOK, accepted, WithRateLimit(EndpointB2C, 10))
+
+		_, err := app.B2C(WithIdempotencyKey(ctx, "payout-1"), "random-string", b2cReq)
+		require.NoError(t, err)
+
+		cancelledCtx, cancel := context.WithCancel(WithIdempotencyKey(ctx, "payout-2"))
+		cancel()
+
+		_, err = app.B2C(cancelledCtx, "random-string", b2cReq)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 1, *calls)
+
+		_, err = app.B2C(WithIdempotencyKey(ctx, "payout-2"), "random-string", b2cReq)
+		require.NoError(t, err)
+		require.Equal(t, 2, *calls)
+	})
+
+	t.Run("it allows a request whose access token could not be generated to be made again", func(t *testing.T) {
+		app, calls := newApp(http.StatusOK, accepted)
+		cl := app.client.(*mockHttpClient)
+
+		cl.MockRequest(app.endpointAuth(), func() (int, string) {
+			return http.StatusServiceUnavailable, `{"errorCode": "503.001.01"}`
+		})
+
+		_, err := app.B2C(ctx, "random-string", b2cReq)
+		require.Error(t, err)
+		require.Equal(t, 0, *calls)
+
+		mockAuth(app, cl)
+
+		_, err = app.B2C(ctx, "random-string", b2cReq)
+		require.NoError(t, err)
+		require.Equal(t, 1, *calls)
+	})
+}
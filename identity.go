@@ -0,0 +1,177 @@
+package mpesa
+
+import (
+	"context"
+	"strings"
+)
+
+// EndpointIdentityCheck is the endpoint of the customer identity (KYC) query. It is only available to the partners
+// Safaricom enabled it for; use WithEndpointURL if the URL assigned to you differs.
+const EndpointIdentityCheck Endpoint = "IdentityCheck"
+
+// CheckIdentityCommandID is applied when querying the registered name of a customer.
+const CheckIdentityCommandID CommandID = "CheckIdentity"
+
+type (
+	// IdentityCheckRequest queries the name an M-Pesa customer is registered with.
+	IdentityCheckRequest struct {
+		// Initiator is the credential/username used to authenticate the request. It defaults to the initiator of the
+		// app.
+		Initiator string `json:"Initiator"`
+
+		// SecurityCredential is the encrypted password of the initiator. It is set by IdentityCheck.
+		SecurityCredential string `json:"SecurityCredential"`
+
+		// CommandID is set to CheckIdentityCommandID by IdentityCheck.
+		CommandID CommandID `json:"CommandID"`
+
+		// PartyA is the shortcode making the query. It defaults to the shortcode of the app.
+		PartyA uint `json:"PartyA"`
+
+		// PhoneNumber is the phone number of the customer, in the format 2547XXXXXXXX.
+		PhoneNumber uint64 `json:"PhoneNumber"`
+
+		// Phone is the phone number of the customer in any format accepted by ParsePhoneNumber. It takes precedence
+		// over PhoneNumber.
+		Phone string `json:"-"`
+
+		// Remarks are comments sent along with the query.
+		Remarks string `json:"Remarks"`
+	}
+
+	// IdentityCheckResponse is the registered identity of a customer. The names are masked by M-Pesa, e.g. "J*** D***",
+	// use MatchesName to compare them with a name typed by the customer.
+	IdentityCheckResponse struct {
+		ConversationID           string `json:"ConversationID"`
+		OriginatorConversationID string `json:"OriginatorConversationID"`
+
+		// ResponseCode is "0" when the customer was found.
+		ResponseCode        string `json:"ResponseCode"`
+		ResponseDescription string `json:"ResponseDescription"`
+
+		// CustomerName is the masked full name the customer is registered with.
+		CustomerName string `json:"CustomerName"`
+
+		// RawBody is the body of the response.
+		RawBody []byte `json:"-"`
+	}
+)
+
+// IdentityCheck returns the masked name the customer is registered with, e.g. to check that a B2C payment goes to the
+// person the customer claims to be. The initiator is set using WithInitiatorCredentials or WithInitiator.
+func (m *Mpesa) IdentityCheck(ctx context.Context, req IdentityCheckRequest) (*IdentityCheckResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Initiator == "" {
+		req.Initiator = initiator.Name
+	}
+	m.defaultShortCode(&req.PartyA)
+
+	if err := req.resolvePhone(); err != nil {
+		return nil, err
+	}
+
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SecurityCredential = securityCredential
+	req.CommandID = CheckIdentityCommandID
+
+	return do[IdentityCheckResponse](ctx, m, EndpointIdentityCheck, req, true)
+}
+
+func (r *IdentityCheckResponse) setRawBody(body []byte) { r.RawBody = body }
+
+// IsFound returns true if M-Pesa returned the identity of the customer.
+func (r *IdentityCheckResponse) IsFound() bool {
+	return r.ResponseCode == "0" && r.CustomerName != ""
+}
+
+// MatchesName reports whether the name, e.g. as typed by the customer, is consistent with the masked CustomerName:
+// every masked name must match a distinct word of the name on its unmasked characters, ignoring case. Names are
+// matched in any order as customers rarely type them in the registered order.
+func (r *IdentityCheckResponse) MatchesName(name string) bool {
+	masked := strings.Fields(r.CustomerName)
+	words := strings.Fields(name)
+
+	if len(masked) == 0 || len(words) < len(masked) {
+		return false
+	}
+
+	used := make([]bool, len(words))
+
+	for _, m := range masked {
+		matched := false
+		for i, word := range words {
+			if !used[i] && matchesMaskedWord(m, word) {
+				used[i], matched = true, true
+				break
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesMaskedWord reports whether the word has the unmasked characters of the masked word at the same positions.
+// The mask may be shorter or longer than the hidden characters, so the lengths are only compared when nothing is
+// masked.
+func matchesMaskedWord(masked, word string) bool {
+	maskedRunes := []rune(strings.ToLower(masked))
+	wordRunes := []rune(strings.ToLower(word))
+
+	if !strings.ContainsRune(masked, '*') && len(maskedRunes) != len(wordRunes) {
+		return false
+	}
+
+	for i, c := range maskedRunes {
+		if c == '*' {
+			continue
+		}
+
+		if i >= len(wordRunes) || wordRunes[i] != c {
+			return false
+		}
+	}
+
+	return true
+}
+
+// resolvePhone sets PhoneNumber from Phone.
+func (r *IdentityCheckRequest) resolvePhone() error {
+	if r.Phone == "" {
+		return nil
+	}
+
+	msisdn, err := ParsePhoneNumber(r.Phone)
+	if err != nil {
+		return invalidPhone()
+	}
+
+	r.PhoneNumber = uint64(msisdn)
+	return nil
+}
+
+// Validate checks the Initiator, the PartyA shortcode and the PhoneNumber.
+func (r IdentityCheckRequest) Validate() []error {
+	var v validator
+
+	v.required("Initiator", r.Initiator)
+	v.shortCode("PartyA", r.PartyA)
+	v.phoneNumber("PhoneNumber", r.PhoneNumber)
+
+	return v.errs()
+}
@@ -0,0 +1,100 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMpesa_IdentityCheck(t *testing.T) {
+	ctx := context.Background()
+
+	newApp := func() (*Mpesa, *mockHttpClient) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+			WithInitiatorCredentials(InitiatorCredentials{Name: "testapi", Password: "random-string"}))
+		mockAuth(app, cl)
+		return app, cl
+	}
+
+	t.Run("it returns the masked name of the customer", func(t *testing.T) {
+		app, cl := newApp()
+
+		cl.MockRequest(app.endpointURL(EndpointIdentityCheck), func() (status int, body string) {
+			var req IdentityCheckRequest
+			require.NoError(t, json.NewDecoder(cl.requests[1].Body).Decode(&req))
+			require.Equal(t, "testapi", req.Initiator)
+			require.NotEmpty(t, req.SecurityCredential)
+			require.Equal(t, CheckIdentityCommandID, req.CommandID)
+			require.Equal(t, uint(600981), req.PartyA)
+			require.Equal(t, uint64(254712345678), req.PhoneNumber)
+
+			return http.StatusOK, `{
+				"OriginatorConversationID": "f1e2-4b95-a71d-b30d3cdbb7a7735297",
+				"ConversationID": "AG_20210706_20106e9209f64bebd05b",
+				"ResponseCode": "0",
+				"ResponseDescription": "Success",
+				"CustomerName": "J*** W***"
+			}`
+		})
+
+		res, err := app.IdentityCheck(ctx, IdentityCheckRequest{PartyA: 600981, Phone: "0712345678"})
+		require.NoError(t, err)
+		require.True(t, res.IsFound())
+		require.Equal(t, "J*** W***", res.CustomerName)
+		require.True(t, res.MatchesName("Wanjiku john"))
+		require.False(t, res.MatchesName("Jane Otieno"))
+	})
+
+	t.Run("it returns the error of a rejected query", func(t *testing.T) {
+		app, cl := newApp()
+
+		cl.MockRequest(app.endpointURL(EndpointIdentityCheck), func() (status int, body string) {
+			return http.StatusForbidden, `{"requestId": "1", "errorCode": "403.001.01", "errorMessage": "Not enabled"}`
+		})
+
+		_, err := app.IdentityCheck(ctx, IdentityCheckRequest{PartyA: 600981, Phone: "0712345678"})
+
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		require.Equal(t, "403.001.01", apiErr.ErrorCode)
+	})
+
+	t.Run("it validates the request", func(t *testing.T) {
+		app, _ := newApp()
+
+		_, err := app.IdentityCheck(ctx, IdentityCheckRequest{PartyA: 600981, Phone: "12345"})
+		require.ErrorIs(t, err, ErrInvalidRequest)
+		require.ErrorContains(t, err, "Phone")
+
+		_, err = app.IdentityCheck(ctx, IdentityCheckRequest{Phone: "0712345678"})
+		require.ErrorContains(t, err, "PartyA")
+	})
+}
+
+func TestIdentityCheckResponse_MatchesName(t *testing.T) {
+	tests := []struct {
+		masked string
+		name   string
+		want   bool
+	}{
+		{masked: "J*** D***", name: "John Doe", want: true},
+		{masked: "J*** D***", name: "doe JOHN", want: true},
+		{masked: "J*** D***", name: "John Kamau Doe", want: true},
+		{masked: "JO** DO*", name: "John Doe", want: true},
+		{masked: "J*** D***", name: "John", want: false},
+		{masked: "J*** D***", name: "John Mwangi", want: false},
+		{masked: "J*** J***", name: "John", want: false},
+		{masked: "JOHN DOE", name: "John Does", want: false},
+		{masked: "", name: "John Doe", want: false},
+	}
+
+	for _, tc := range tests {
+		res := IdentityCheckResponse{CustomerName: tc.masked}
+		require.Equal(t, tc.want, res.MatchesName(tc.name), "%q %q", tc.masked, tc.name)
+	}
+}
@@ -0,0 +1,58 @@
+package mpesa
+
+import "context"
+
+// InitiatorCredentials are the credentials of the API operator that initiates the B2C, B2B, transaction status,
+// reversal and account balance requests.
+type InitiatorCredentials struct {
+	// Name is the username of the API operator, set on the Initiator or InitiatorName field of the requests.
+	Name string
+
+	// Password is the password of the API operator, encrypted into the SecurityCredential of the requests.
+	Password string
+}
+
+type initiatorContextKey struct{}
+
+// WithInitiatorCredentials sets the initiator credentials used when a method is called with an empty initiator
+// password. The Name is set on the requests that do not have an initiator.
+func WithInitiatorCredentials(credentials InitiatorCredentials) Option {
+	return func(m *Mpesa) {
+		m.initiator = credentials
+	}
+}
+
+// WithInitiator returns a copy of ctx carrying the initiator credentials of the request made with it. They take
+// precedence over the ones set using WithInitiatorCredentials.
+func WithInitiator(ctx context.Context, credentials InitiatorCredentials) context.Context {
+	return context.WithValue(ctx, initiatorContextKey{}, credentials)
+}
+
+// InitiatorFromContext returns the initiator credentials carried by ctx.
+func InitiatorFromContext(ctx context.Context) (InitiatorCredentials, bool) {
+	credentials, ok := ctx.Value(initiatorContextKey{}).(InitiatorCredentials)
+	return credentials, ok
+}
+
+// initiatorCredentials returns the credentials of the request made with ctx. The password passed to the method takes
+// precedence over the ones set on ctx and the app.
+func (m *Mpesa) initiatorCredentials(ctx context.Context, password string) (InitiatorCredentials, error) {
+	if m.profileErr != nil {
+		return InitiatorCredentials{}, m.profileErr
+	}
+
+	credentials := m.initiator
+	if c, ok := InitiatorFromContext(ctx); ok {
+		credentials = c
+	}
+
+	if password != "" {
+		credentials.Password = password
+	}
+
+	if credentials.Password == "" {
+		return InitiatorCredentials{}, ErrInvalidInitiatorPassword
+	}
+
+	return credentials, nil
+}
@@ -0,0 +1,78 @@
+package mpesa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMpesa_InitiatorCredentials(t *testing.T) {
+	var (
+		ctx              = context.Background()
+		certificate, key = testCertificate(t)
+		cl               = newMockHttpClient()
+		app              = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+			WithCertificate(certificate),
+			WithInitiatorCredentials(InitiatorCredentials{Name: "testapi", Password: "Safaricom999!*!"}),
+		)
+		sent TransactionStatusRequest
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+		sent = TransactionStatusRequest{}
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&sent))
+		return http.StatusOK, `{"ConversationID": "AG_20240122_2010332bae9191b3d522", "ResponseCode": "0"}`
+	})
+
+	password := func(t *testing.T) string {
+		b, err := base64.StdEncoding.DecodeString(sent.SecurityCredential)
+		require.NoError(t, err)
+
+		password, err := rsa.DecryptPKCS1v15(rand.Reader, key, b)
+		require.NoError(t, err)
+		return string(password)
+	}
+
+	req := TransactionStatusRequest{
+		PartyA:          600426,
+		TransactionID:   "SB162HIYLY",
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	}
+
+	_, err := app.GetTransactionStatus(ctx, "", req)
+	require.NoError(t, err)
+	require.Equal(t, "testapi", sent.Initiator)
+	require.Equal(t, "Safaricom999!*!", password(t))
+
+	_, err = app.GetTransactionStatus(ctx, "Safaricom111!*!", req)
+	require.NoError(t, err)
+	require.Equal(t, "testapi", sent.Initiator)
+	require.Equal(t, "Safaricom111!*!", password(t))
+
+	override := WithInitiator(ctx, InitiatorCredentials{Name: "otherapi", Password: "Safaricom222!*!"})
+	_, err = app.GetTransactionStatus(override, "", req)
+	require.NoError(t, err)
+	require.Equal(t, "otherapi", sent.Initiator)
+	require.Equal(t, "Safaricom222!*!", password(t))
+
+	req.Initiator = "requestapi"
+	_, err = app.GetTransactionStatus(override, "", req)
+	require.NoError(t, err)
+	require.Equal(t, "requestapi", sent.Initiator)
+
+	credentials, ok := InitiatorFromContext(override)
+	require.True(t, ok)
+	require.Equal(t, "otherapi", credentials.Name)
+
+	_, err = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox).GetTransactionStatus(ctx, "", req)
+	require.ErrorIs(t, err, ErrInvalidInitiatorPassword)
+}
@@ -0,0 +1,174 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// LedgerColumn is a column written by the LedgerExporter. The value is used as the column header and mirrors the
+// headers used on the M-Pesa org portal statements where one exists.
+type LedgerColumn string
+
+const (
+	LedgerColumnID               LedgerColumn = "Transaction ID"
+	LedgerColumnReceiptNumber    LedgerColumn = "Receipt No."
+	LedgerColumnCompletedAt      LedgerColumn = "Completion Time"
+	LedgerColumnCreatedAt        LedgerColumn = "Initiation Time"
+	LedgerColumnKind             LedgerColumn = "Type"
+	LedgerColumnShortCode        LedgerColumn = "Short Code"
+	LedgerColumnMSISDN           LedgerColumn = "Other Party Info"
+	LedgerColumnAmount           LedgerColumn = "Amount"
+	LedgerColumnCurrency         LedgerColumn = "Currency"
+	LedgerColumnAccountReference LedgerColumn = "A/C No."
+	LedgerColumnResultDesc       LedgerColumn = "Details"
+)
+
+// LedgerTimeFormat is the default layout used to format times on the exported ledger.
+const LedgerTimeFormat = "2006-01-02 15:04:05"
+
+// DefaultLedgerColumns are the columns exported when none are specified.
+var DefaultLedgerColumns = []LedgerColumn{
+	LedgerColumnReceiptNumber,
+	LedgerColumnCompletedAt,
+	LedgerColumnKind,
+	LedgerColumnShortCode,
+	LedgerColumnMSISDN,
+	LedgerColumnAccountReference,
+	LedgerColumnAmount,
+}
+
+// LedgerExporter writes completed transactions from a TransactionStore as CSV for reconciliation against the M-Pesa
+// org portal statements.
+type LedgerExporter struct {
+	// Store is the TransactionStore to read the transactions from.
+	Store TransactionStore
+
+	// Columns are the columns to write in order. Defaults to DefaultLedgerColumns.
+	Columns []LedgerColumn
+
+	// TimeFormat is the layout used to format times. Defaults to LedgerTimeFormat.
+	TimeFormat string
+}
+
+// NewLedgerExporter creates a LedgerExporter that reads from the store and writes the provided columns.
+func NewLedgerExporter(store TransactionStore, columns ...LedgerColumn) *LedgerExporter {
+	return &LedgerExporter{
+		Store:   store,
+		Columns: columns,
+	}
+}
+
+func (e *LedgerExporter) value(column LedgerColumn, txn Transaction) (string, error) {
+	timeFormat := e.TimeFormat
+	if timeFormat == "" {
+		timeFormat = LedgerTimeFormat
+	}
+
+	switch column {
+	case LedgerColumnID:
+		return txn.ID, nil
+	case LedgerColumnReceiptNumber:
+		return txn.ReceiptNumber, nil
+	case LedgerColumnCompletedAt:
+		return txn.CompletedAt.Format(timeFormat), nil
+	case LedgerColumnCreatedAt:
+		return txn.CreatedAt.Format(timeFormat), nil
+	case LedgerColumnKind:
+		return string(txn.Kind), nil
+	case LedgerColumnShortCode:
+		return strconv.FormatUint(uint64(txn.ShortCode), 10), nil
+	case LedgerColumnMSISDN:
+		return strconv.FormatUint(txn.MSISDN, 10), nil
+	case LedgerColumnAmount:
+		return strconv.FormatFloat(txn.Amount, 'f', txn.Currency.OrDefault().Decimals(), 64), nil
+	case LedgerColumnCurrency:
+		return string(txn.Currency.OrDefault()), nil
+	case LedgerColumnAccountReference:
+		return txn.AccountReference, nil
+	case LedgerColumnResultDesc:
+		return txn.ResultDesc, nil
+	default:
+		return "", fmt.Errorf("mpesa: unknown ledger column %q", column)
+	}
+}
+
+// Export writes the completed transactions matching the filter to w and returns the number of transactions written.
+// The Status on the filter is ignored as only completed transactions are exported.
+//
+// The From and To of the filter select the transactions by the time they completed rather than the time they were
+// created, like the org portal statements, so that a transaction started before the period but completed within it is
+// exported with the period it settled in. Transactions without a completion time are selected by their creation time.
+func (e *LedgerExporter) Export(ctx context.Context, w io.Writer, filter TransactionFilter) (int, error) {
+	columns := e.Columns
+	if len(columns) == 0 {
+		columns = DefaultLedgerColumns
+	}
+
+	for _, column := range columns {
+		if _, err := e.value(column, Transaction{}); err != nil {
+			return 0, err
+		}
+	}
+
+	// A transaction completed within the period was created before its end, but possibly before its start.
+	period := filter
+	filter.Status = TransactionStatusCompleted
+	filter.From = time.Time{}
+
+	listed, err := e.Store.List(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("mpesa: list transactions: %v", err)
+	}
+
+	transactions := listed[:0]
+	for _, txn := range listed {
+		if at := ledgerTime(txn); (period.From.IsZero() || !at.Before(period.From)) &&
+			(period.To.IsZero() || at.Before(period.To)) {
+			transactions = append(transactions, txn)
+		}
+	}
+
+	var (
+		writer = csv.NewWriter(w)
+		record = make([]string, len(columns))
+	)
+
+	for i, column := range columns {
+		record[i] = string(column)
+	}
+
+	if err = writer.Write(record); err != nil {
+		return 0, fmt.Errorf("mpesa: write ledger: %v", err)
+	}
+
+	for _, txn := range transactions {
+		for i, column := range columns {
+			record[i], _ = e.value(column, txn)
+		}
+
+		if err = writer.Write(record); err != nil {
+			return 0, fmt.Errorf("mpesa: write ledger: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return 0, fmt.Errorf("mpesa: write ledger: %v", err)
+	}
+
+	return len(transactions), nil
+}
+
+// ledgerTime returns the time the transaction is accounted for: the time it completed once it reached a terminal
+// status, or the time it was created while it is pending.
+func ledgerTime(txn Transaction) time.Time {
+	if txn.Status.IsTerminal() && !txn.CompletedAt.IsZero() {
+		return txn.CompletedAt
+	}
+
+	return txn.CreatedAt
+}
@@ -0,0 +1,157 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerExporter_Export(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		store       = NewMemoryStore()
+		createdAt   = time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC)
+		completedAt = time.Date(2023, 10, 1, 9, 0, 30, 0, time.UTC)
+	)
+
+	transactions := []Transaction{
+		{
+			ID:               "ws_CO_1",
+			Kind:             TransactionKindSTKPush,
+			ReceiptNumber:    "NLJ7RT61SV",
+			ShortCode:        174379,
+			MSISDN:           254708374149,
+			Amount:           10,
+			AccountReference: "INV-001",
+			Status:           TransactionStatusCompleted,
+			CreatedAt:        createdAt,
+			CompletedAt:      completedAt,
+		},
+		{
+			ID:        "ws_CO_2",
+			Kind:      TransactionKindSTKPush,
+			ShortCode: 174379,
+			Status:    TransactionStatusFailed,
+			CreatedAt: createdAt.Add(time.Minute),
+		},
+		{
+			ID:            "AG_3",
+			Kind:          TransactionKindB2C,
+			ReceiptNumber: "NLJ41HAY6Q",
+			ShortCode:     600426,
+			MSISDN:        254708374149,
+			Amount:        1500.5,
+			Status:        TransactionStatusCompleted,
+			CreatedAt:     createdAt.Add(2 * time.Minute),
+			CompletedAt:   completedAt.Add(2 * time.Minute),
+		},
+	}
+
+	for _, txn := range transactions {
+		require.NoError(t, store.Save(ctx, txn))
+	}
+
+	tests := []struct {
+		name    string
+		columns []LedgerColumn
+		filter  TransactionFilter
+		wantN   int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "it exports completed transactions with the default columns",
+			wantN: 2,
+			want: "Receipt No.,Completion Time,Type,Short Code,Other Party Info,A/C No.,Amount\n" +
+				"NLJ7RT61SV,2023-10-01 09:00:30,STKPush,174379,254708374149,INV-001,10.00\n" +
+				"NLJ41HAY6Q,2023-10-01 09:02:30,B2C,600426,254708374149,,1500.50\n",
+		},
+		{
+			name:    "it exports the configured columns for a shortcode",
+			columns: []LedgerColumn{LedgerColumnID, LedgerColumnAmount},
+			filter:  TransactionFilter{ShortCode: 600426},
+			wantN:   1,
+			want:    "Transaction ID,Amount\nAG_3,1500.50\n",
+		},
+		{
+			name:    "it exports the currency",
+			columns: []LedgerColumn{LedgerColumnID, LedgerColumnCurrency},
+			filter:  TransactionFilter{ShortCode: 600426},
+			wantN:   1,
+			want:    "Transaction ID,Currency\nAG_3,KES\n",
+		},
+		{
+			name:    "it exports transactions within the date range",
+			columns: []LedgerColumn{LedgerColumnID},
+			filter:  TransactionFilter{From: createdAt, To: createdAt.Add(time.Minute)},
+			wantN:   1,
+			want:    "Transaction ID\nws_CO_1\n",
+		},
+		{
+			name:    "it fails with an unknown column",
+			columns: []LedgerColumn{"Balance"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			n, err := NewLedgerExporter(store, tc.columns...).Export(ctx, &buf, tc.filter)
+			if tc.wantErr {
+				require.Error(t, err)
+				require.Empty(t, buf.String())
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.wantN, n)
+			require.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func TestLedgerExporter_Export_completionPeriod(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = NewMemoryStore()
+		start = time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)
+		end   = start.Add(24 * time.Hour)
+	)
+
+	for _, txn := range []Transaction{
+		{
+			ID:          "AG_1",
+			Status:      TransactionStatusCompleted,
+			CreatedAt:   start.Add(-time.Minute),
+			CompletedAt: start.Add(time.Minute),
+		},
+		{
+			ID:          "AG_2",
+			Status:      TransactionStatusCompleted,
+			CreatedAt:   end.Add(-time.Minute),
+			CompletedAt: end.Add(time.Minute),
+		},
+		{
+			ID:        "AG_3",
+			Status:    TransactionStatusCompleted,
+			CreatedAt: start.Add(time.Hour),
+		},
+	} {
+		require.NoError(t, store.Save(ctx, txn))
+	}
+
+	var buf bytes.Buffer
+
+	n, err := NewLedgerExporter(store, LedgerColumnID).Export(ctx, &buf, TransactionFilter{From: start, To: end})
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, "Transaction ID\nAG_1\nAG_3\n", buf.String())
+}
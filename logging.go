@@ -0,0 +1,128 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces the values of the sensitive fields of logged payloads.
+const redactedValue = "[REDACTED]"
+
+// maxLoggedBodySize is the maximum size of a response body that is logged.
+const maxLoggedBodySize = 64 << 10
+
+// sensitivePayloadKeys are the lower cased payload fields that are masked before payloads are logged.
+var sensitivePayloadKeys = map[string]bool{
+	"password":           true,
+	"passkey":            true,
+	"securitycredential": true,
+	"initiatorpassword":  true,
+	"access_token":       true,
+	"consumersecret":     true,
+}
+
+// WithLogger logs every API request made by the app with its method, URL, status code, duration and tags. Failed
+// requests are logged at the error level and the others at the info level. When the logger is enabled for the debug
+// level, the request and response payloads are logged too with the passwords, passkeys, security credentials and
+// access tokens masked.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *Mpesa) {
+		m.logger = logger
+	}
+}
+
+// logRequest logs the request made with the body and its outcome. The response body is read and replaced so that it
+// can still be decoded.
+func (m *Mpesa) logRequest(
+	ctx context.Context, req *http.Request, body []byte, res *http.Response, start time.Time, err error,
+) {
+	if m.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.String("request_id", req.Header.Get(HeaderRequestID)),
+		slog.Duration("duration", time.Since(start)),
+	}
+
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	if res != nil {
+		attrs = append(attrs, slog.Int("status", res.StatusCode))
+		if res.StatusCode >= http.StatusBadRequest {
+			level = slog.LevelError
+		}
+	}
+
+	if tags := TagsFromContext(ctx); len(tags) > 0 {
+		attrs = append(attrs, slog.Any("tags", tags))
+	}
+
+	if m.logger.Enabled(ctx, slog.LevelDebug) {
+		if len(body) > 0 {
+			attrs = append(attrs, slog.String("request", redactPayload(body)))
+		}
+
+		if res != nil {
+			resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxLoggedBodySize))
+			res.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(resBody), res.Body), Closer: res.Body}
+			attrs = append(attrs, slog.String("response", redactPayload(resBody)))
+		}
+	}
+
+	m.logger.LogAttrs(ctx, level, "mpesa: request", attrs...)
+}
+
+// readCloser combines a Reader with the Closer of the body it reads from.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// redactPayload returns the JSON payload with the values of the sensitive fields masked. Payloads that are not JSON
+// are not logged as they cannot be redacted.
+func redactPayload(b []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "[" + strconv.Itoa(len(b)) + " bytes]"
+	}
+
+	redacted, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return "[" + strconv.Itoa(len(b)) + " bytes]"
+	}
+
+	return string(redacted)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, field := range val {
+			if sensitivePayloadKeys[strings.ToLower(k)] {
+				val[k] = redactedValue
+				continue
+			}
+			val[k] = redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactValue(item)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,136 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// logEntries decodes the JSON log lines written to buf.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestWithLogger(t *testing.T) {
+	ctx := WithTags(context.Background(), Tags{TagOrderID: "o_456"})
+
+	stkReq := STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyA:            254708374149,
+		PartyB:            174379,
+		PhoneNumber:       254708374149,
+		CallBackURL:       "https://example.com/callback",
+		AccountReference:  "Test",
+		TransactionDesc:   "Test",
+	}
+
+	newApp := func(level slog.Level) (*Mpesa, *mockHttpClient, *bytes.Buffer) {
+		var (
+			buf    bytes.Buffer
+			cl     = newMockHttpClient()
+			logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+			app    = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithLogger(logger))
+		)
+
+		mockAuth(app, cl)
+		return app, cl, &buf
+	}
+
+	t.Run("it logs the requests with the payloads redacted", func(t *testing.T) {
+		app, cl, buf := newApp(slog.LevelDebug)
+
+		cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+			return http.StatusOK, `{"CheckoutRequestID": "ws_CO_1", "ResponseCode": "0"}`
+		})
+
+		res, err := app.STKPush(ctx, "bfb279f9aa9bdbcf158e97dd71a467cd2e0c893059b10f78e6b72ada1ed2c919", stkReq)
+		require.NoError(t, err)
+		require.Equal(t, "ws_CO_1", res.CheckoutRequestID)
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 2)
+
+		auth := entries[0]
+		require.Equal(t, "INFO", auth["level"])
+		require.Equal(t, app.endpointAuth(), auth["url"])
+		require.Contains(t, auth["response"], redactedValue)
+		require.NotContains(t, auth["response"], "0A0v8OgxqqoocblflR58m9chMdnU")
+
+		stk := entries[1]
+		require.Equal(t, "INFO", stk["level"])
+		require.Equal(t, http.MethodPost, stk["method"])
+		require.Equal(t, app.endpointSTK(), stk["url"])
+		require.Equal(t, float64(http.StatusOK), stk["status"])
+		require.Contains(t, stk, "duration")
+		require.Equal(t, map[string]interface{}{TagOrderID: "o_456"}, stk["tags"])
+		require.Contains(t, stk["request"], `"Password":"[REDACTED]"`)
+		require.Contains(t, stk["request"], `"AccountReference":"Test"`)
+		require.Contains(t, stk["response"], "ws_CO_1")
+	})
+
+	t.Run("it logs failed requests as errors without payloads above the debug level", func(t *testing.T) {
+		app, cl, buf := newApp(slog.LevelInfo)
+
+		cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+			return http.StatusBadRequest, `{"requestId": "1", "errorCode": "400.002.02", "errorMessage": "Bad Request"}`
+		})
+
+		_, err := app.STKPush(ctx, "passkey", stkReq)
+		require.ErrorContains(t, err, "400.002.02")
+
+		entries := logEntries(t, buf)
+		require.Len(t, entries, 2)
+		require.Equal(t, "ERROR", entries[1]["level"])
+		require.Equal(t, float64(http.StatusBadRequest), entries[1]["status"])
+		require.NotContains(t, entries[1], "request")
+		require.NotContains(t, entries[1], "response")
+	})
+}
+
+func TestRedactPayload(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "it masks the sensitive fields",
+			input: `{"Initiator":"testapi","SecurityCredential":"Safaricom999!*!","Amount":10}`,
+			want:  `{"Amount":10,"Initiator":"testapi","SecurityCredential":"[REDACTED]"}`,
+		},
+		{
+			name:  "it masks nested fields regardless of case",
+			input: `{"tenants":[{"id":"a","passkey":"secret"}],"PASSWORD":"secret"}`,
+			want:  `{"PASSWORD":"[REDACTED]","tenants":[{"id":"a","passkey":"[REDACTED]"}]}`,
+		},
+		{
+			name:  "it does not log payloads that are not JSON",
+			input: `Password=secret`,
+			want:  `[15 bytes]`,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, redactPayload([]byte(tc.input)))
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package mpesa
+
+import (
+	"strings"
+	"sync"
+)
+
+// Language is an ISO 639-1 language code used to pick customer-facing messages.
+type Language string
+
+const (
+	LanguageEnglish Language = "en"
+	LanguageSwahili Language = "sw"
+)
+
+// DefaultLanguage is used when no message exists in the requested language.
+const DefaultLanguage = LanguageEnglish
+
+// messageCodeUnknown is the catalog entry used for codes without a message.
+const messageCodeUnknown = "unknown"
+
+// MessageCatalog translates result and error codes into messages that can be shown to customers. It is safe for
+// concurrent use.
+type MessageCatalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[Language]string
+}
+
+// NewMessageCatalog creates a MessageCatalog with the English and Swahili messages for the common STK push, B2C and
+// API error codes.
+func NewMessageCatalog() *MessageCatalog {
+	c := &MessageCatalog{messages: make(map[string]map[Language]string)}
+
+	for code, messages := range defaultCustomerMessages {
+		for lang, message := range messages {
+			c.Set(code, lang, message)
+		}
+	}
+
+	return c
+}
+
+// Set adds or replaces the message of the code in the language, e.g. to word a message differently or add a language.
+func (c *MessageCatalog) Set(code string, lang Language, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.messages[code] == nil {
+		c.messages[code] = make(map[Language]string)
+	}
+
+	c.messages[code][normalizeLanguage(lang)] = message
+}
+
+// Describe returns the message of the code in the language. The DefaultLanguage message is returned when there is
+// none in the language and a generic message when the code is unknown.
+func (c *MessageCatalog) Describe(code string, lang Language) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	lang = normalizeLanguage(lang)
+
+	messages, ok := c.messages[strings.TrimSpace(code)]
+	if !ok {
+		messages = c.messages[messageCodeUnknown]
+	}
+
+	if message, ok := messages[lang]; ok {
+		return message
+	}
+
+	return messages[DefaultLanguage]
+}
+
+// normalizeLanguage reduces a language tag such as sw-KE or EN_us to its language code.
+func normalizeLanguage(lang Language) Language {
+	s := strings.ToLower(strings.TrimSpace(string(lang)))
+	if i := strings.IndexAny(s, "-_"); i != -1 {
+		s = s[:i]
+	}
+
+	return Language(s)
+}
+
+var defaultMessageCatalog = NewMessageCatalog()
+
+// DescribeForCustomer returns a customer-facing message for the result code of a callback or the errorCode of a
+// failed request, e.g. "1032" or "500.001.1001", in the language. English is used for unsupported languages.
+//
+//	mpesa.DescribeForCustomer(strconv.Itoa(callback.Body.STKCallback.ResultCode), mpesa.LanguageSwahili)
+func DescribeForCustomer(code string, lang Language) string {
+	return defaultMessageCatalog.Describe(code, lang)
+}
+
+// defaultCustomerMessages holds the built-in messages keyed by code and language.
+var defaultCustomerMessages = map[string]map[Language]string{
+	messageCodeUnknown: {
+		LanguageEnglish: "We could not complete your M-Pesa payment. Please try again.",
+		LanguageSwahili: "Hatukuweza kukamilisha malipo yako ya M-Pesa. Tafadhali jaribu tena.",
+	},
+	"0": {
+		LanguageEnglish: "Your payment was received successfully.",
+		LanguageSwahili: "Malipo yako yamepokelewa.",
+	},
+	"1": {
+		LanguageEnglish: "Your M-Pesa balance is not enough to complete this payment.",
+		LanguageSwahili: "Salio lako la M-Pesa halitoshi kukamilisha malipo haya.",
+	},
+	"1001": {
+		LanguageEnglish: "Another M-Pesa transaction is in progress on your line. Please wait a moment and try again.",
+		LanguageSwahili: "Kuna muamala mwingine wa M-Pesa unaoendelea kwenye laini yako. Tafadhali subiri kidogo " +
+			"kisha ujaribu tena.",
+	},
+	"1019": {
+		LanguageEnglish: "The payment request expired before it was completed. Please try again.",
+		LanguageSwahili: "Ombi la malipo limeisha muda kabla ya kukamilika. Tafadhali jaribu tena.",
+	},
+	"1025": {
+		LanguageEnglish: "We could not send the payment prompt to your phone. Please try again.",
+		LanguageSwahili: "Hatukuweza kutuma ombi la malipo kwenye simu yako. Tafadhali jaribu tena.",
+	},
+	"1032": {
+		LanguageEnglish: "You cancelled the payment request.",
+		LanguageSwahili: "Umesitisha ombi la malipo.",
+	},
+	"1037": {
+		LanguageEnglish: "We could not reach your phone. Make sure it is on and has network, then try again.",
+		LanguageSwahili: "Hatukuweza kufikia simu yako. Hakikisha imewashwa na ina mtandao, kisha ujaribu tena.",
+	},
+	"2001": {
+		LanguageEnglish: "The M-Pesa PIN you entered is incorrect.",
+		LanguageSwahili: "Nambari ya siri ya M-Pesa uliyoweka si sahihi.",
+	},
+	"9999": {
+		LanguageEnglish: "The payment request could not be sent. Please try again.",
+		LanguageSwahili: "Ombi la malipo halikuweza kutumwa. Tafadhali jaribu tena.",
+	},
+	stkQueryProcessingErrorCode: {
+		LanguageEnglish: "Your payment is still being processed. Please complete the prompt on your phone.",
+		LanguageSwahili: "Malipo yako bado yanashughulikiwa. Tafadhali kamilisha ombi kwenye simu yako.",
+	},
+	"2040": {
+		LanguageEnglish: "The receiving phone number is not registered for M-Pesa.",
+		LanguageSwahili: "Nambari ya simu inayopokea haijasajiliwa kwenye M-Pesa.",
+	},
+	"400.002.02": {
+		LanguageEnglish: "Some of the payment details are invalid. Please check them and try again.",
+		LanguageSwahili: "Baadhi ya maelezo ya malipo si sahihi. Tafadhali yahakiki kisha ujaribu tena.",
+	},
+	"500.003.02": {
+		LanguageEnglish: "M-Pesa is busy at the moment. Please try again shortly.",
+		LanguageSwahili: "M-Pesa ina shughuli nyingi kwa sasa. Tafadhali jaribu tena baada ya muda mfupi.",
+	},
+}
@@ -0,0 +1,70 @@
+package mpesa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribeForCustomer(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		lang Language
+		want string
+	}{
+		{
+			name: "it describes a result code in english",
+			code: "1032",
+			lang: LanguageEnglish,
+			want: "You cancelled the payment request.",
+		},
+		{
+			name: "it describes a result code in swahili",
+			code: "1",
+			lang: LanguageSwahili,
+			want: "Salio lako la M-Pesa halitoshi kukamilisha malipo haya.",
+		},
+		{
+			name: "it accepts language tags with a region",
+			code: "1032",
+			lang: "sw-KE",
+			want: "Umesitisha ombi la malipo.",
+		},
+		{
+			name: "it describes an api error code",
+			code: "500.001.1001",
+			lang: LanguageEnglish,
+			want: "Your payment is still being processed. Please complete the prompt on your phone.",
+		},
+		{
+			name: "it falls back to english for unsupported languages",
+			code: "2001",
+			lang: "fr",
+			want: "The M-Pesa PIN you entered is incorrect.",
+		},
+		{
+			name: "it returns a generic message for unknown codes",
+			code: "4242",
+			lang: LanguageSwahili,
+			want: "Hatukuweza kukamilisha malipo yako ya M-Pesa. Tafadhali jaribu tena.",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, DescribeForCustomer(tc.code, tc.lang))
+		})
+	}
+}
+
+func TestMessageCatalog_Set(t *testing.T) {
+	catalog := NewMessageCatalog()
+	catalog.Set("1032", LanguageEnglish, "Payment cancelled.")
+	catalog.Set("1032", "fr", "Paiement annulé.")
+
+	require.Equal(t, "Payment cancelled.", catalog.Describe("1032", LanguageEnglish))
+	require.Equal(t, "Paiement annulé.", catalog.Describe("1032", "FR"))
+	require.Equal(t, "You cancelled the payment request.", DescribeForCustomer("1032", LanguageEnglish))
+}
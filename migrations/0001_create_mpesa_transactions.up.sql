@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS mpesa_transactions
+(
+    id                VARCHAR(64)    NOT NULL PRIMARY KEY,
+    kind              VARCHAR(32)    NOT NULL,
+    receipt_number    VARCHAR(32)    NOT NULL DEFAULT '',
+    short_code        BIGINT         NOT NULL,
+    msisdn            BIGINT         NOT NULL DEFAULT 0,
+    amount            NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    account_reference VARCHAR(64)    NOT NULL DEFAULT '',
+    status            VARCHAR(16)    NOT NULL,
+    result_code       INTEGER        NOT NULL DEFAULT 0,
+    result_desc       TEXT           NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ    NOT NULL,
+    updated_at        TIMESTAMPTZ    NOT NULL,
+    completed_at      TIMESTAMPTZ    NOT NULL DEFAULT '0001-01-01 00:00:00+00'
+);
+
+CREATE INDEX IF NOT EXISTS idx_mpesa_transactions_receipt_number ON mpesa_transactions (receipt_number);
+CREATE INDEX IF NOT EXISTS idx_mpesa_transactions_status ON mpesa_transactions (status);
+CREATE INDEX IF NOT EXISTS idx_mpesa_transactions_short_code_created_at ON mpesa_transactions (short_code, created_at);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS mpesa_callbacks;
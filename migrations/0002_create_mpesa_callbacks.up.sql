@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS mpesa_callbacks
+(
+    id             BIGSERIAL   NOT NULL PRIMARY KEY,
+    kind           VARCHAR(32) NOT NULL,
+    correlation_id VARCHAR(64) NOT NULL,
+    result_code    INTEGER     NOT NULL DEFAULT 0,
+    payload        JSONB       NOT NULL,
+    received_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_mpesa_callbacks_correlation_id ON mpesa_callbacks (correlation_id);
+CREATE INDEX IF NOT EXISTS idx_mpesa_callbacks_received_at ON mpesa_callbacks (received_at);
@@ -0,0 +1,2 @@
+ALTER TABLE mpesa_transactions
+    ADD COLUMN IF NOT EXISTS currency VARCHAR(3) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE mpesa_transactions
+    DROP COLUMN IF EXISTS balance;
@@ -0,0 +1,2 @@
+ALTER TABLE mpesa_transactions
+    ADD COLUMN IF NOT EXISTS balance NUMERIC(14, 2) NOT NULL DEFAULT 0;
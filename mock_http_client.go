@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"net/http"
+	"sync"
 )
 
 type mockResponseFunc func() (status int, body string)
@@ -14,6 +15,7 @@ type (
 	}
 
 	mockHttpClient struct {
+		mu        sync.Mutex
 		responses map[string]mockResponse
 		requests  []*http.Request
 	}
@@ -40,15 +42,21 @@ func newMockHttpClient() *mockHttpClient {
 
 // MockRequest appends the given response for the provided url.
 func (m *mockHttpClient) MockRequest(url string, fn mockResponseFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.responses[url] = mockResponse{fn: fn}
 }
 
 // Do checks if the given req.URL exists in the available responses lists and returns the stored response.
 // If none exists, it returns status http.StatusNotFound
 func (m *mockHttpClient) Do(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
 	m.requests = append(m.requests, req.Clone(req.Context()))
+	mock, ok := m.responses[req.URL.String()]
+	m.mu.Unlock()
 
-	if mock, ok := m.responses[req.URL.String()]; ok {
+	if ok {
 		if mock.fn != nil {
 			status, body := mock.fn()
 			return mockHttpResponse(status, body), nil
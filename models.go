@@ -0,0 +1,184 @@
+package mpesa
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations holds the SQL migrations, written for PostgreSQL, that create the tables backing Transaction and
+// CallbackRecord. The files follow the <version>_<name>.<up|down>.sql naming used by golang-migrate and can be used
+// as a schema for sqlc.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// CallbackKind identifies the type of callback stored on a CallbackRecord.
+type CallbackKind string
+
+const (
+	// CallbackKindSTKPush is the callback sent to the CallBackURL of an STKPushRequest.
+	CallbackKindSTKPush CallbackKind = "STKPush"
+
+	// CallbackKindResult is the callback sent to the ResultURL of B2C, transaction status, account balance and
+	// business pay bill requests.
+	CallbackKindResult CallbackKind = "Result"
+
+	// CallbackKindB2BExpressCheckout is the callback sent to the callback URL of a B2B Express Checkout request.
+	CallbackKindB2BExpressCheckout CallbackKind = "B2BExpressCheckout"
+)
+
+// CallbackRecord is a callback received from M-Pesa as persisted to the mpesa_callbacks table. The raw payload is kept
+// so that it can be re-processed if the parsing rules change.
+type CallbackRecord struct {
+	// ID is the database identifier of the record.
+	ID int64 `db:"id" gorm:"column:id;primaryKey;autoIncrement"`
+
+	// Kind is the type of the callback.
+	Kind CallbackKind `db:"kind" gorm:"column:kind"`
+
+	// CorrelationID links the callback to a Transaction. It is the CheckoutRequestID for STK push callbacks, the
+	// ConversationID for result callbacks and the requestId for B2B Express Checkout callbacks.
+	CorrelationID string `db:"correlation_id" gorm:"column:correlation_id;index"`
+
+	// ResultCode is the result code sent on the callback.
+	ResultCode int `db:"result_code" gorm:"column:result_code"`
+
+	// Payload is the callback as received.
+	Payload json.RawMessage `db:"payload" gorm:"column:payload;type:jsonb"`
+
+	// ReceivedAt is the time the callback was received.
+	ReceivedAt time.Time `db:"received_at" gorm:"column:received_at;index"`
+}
+
+// TableName returns the table the transactions are stored in.
+func (Transaction) TableName() string {
+	return "mpesa_transactions"
+}
+
+// TableName returns the table the callbacks are stored in.
+func (CallbackRecord) TableName() string {
+	return "mpesa_callbacks"
+}
+
+// TransactionFromSTKCallback creates the Transaction the STK push callback reports, identified by its
+// CheckoutRequestID. The fields the callback does not carry, e.g. ShortCode and AccountReference, are left empty so
+// that the transaction can be merged into the one recorded when the request was made with TransactionStore.Upsert.
+func TransactionFromSTKCallback(callback *STKPushCallback) Transaction {
+	result := callback.Body.STKCallback
+
+	txn := Transaction{
+		ID:         result.CheckoutRequestID,
+		Kind:       TransactionKindSTKPush,
+		Status:     transactionStatusFromResultCode(result.ResultCode),
+		ResultCode: result.ResultCode,
+		ResultDesc: result.ResultDesc,
+	}
+
+	txn.ReceiptNumber, _ = result.MpesaReceiptNumber()
+	txn.Amount, _ = result.Amount()
+	txn.MSISDN, _ = result.PhoneNumber()
+	txn.CompletedAt, _ = result.TransactionDate()
+	txn.Balance, _ = result.Balance()
+
+	return txn
+}
+
+// TransactionFromB2CCallback creates the Transaction the B2C result callback reports, identified by its
+// ConversationID. The MSISDN is taken from the ReceiverPartyPublicName and the Balance is the utility account balance
+// after the payment.
+func TransactionFromB2CCallback(callback *Callback) Transaction {
+	result := callback.Result
+	params := result.ResultParameters
+
+	txn := Transaction{
+		ID:            result.ConversationID,
+		Kind:          TransactionKindB2C,
+		ReceiptNumber: result.TransactionID,
+		Status:        transactionStatusFromResultCode(result.ResultCode),
+		ResultCode:    result.ResultCode,
+		ResultDesc:    result.ResultDesc,
+	}
+
+	if receipt, ok := params.TransactionReceipt(); ok && receipt != "" {
+		txn.ReceiptNumber = receipt
+	}
+
+	if receiver, ok := params.ReceiverPartyPublicName(); ok {
+		phone, _, _ := strings.Cut(receiver, " - ")
+		if msisdn, err := ParsePhoneNumber(phone); err == nil {
+			txn.MSISDN = uint64(msisdn)
+		}
+	}
+
+	txn.Amount, _ = params.TransactionAmount()
+	txn.CompletedAt, _ = params.TransactionCompletedDateTime()
+	txn.Balance, _ = params.B2CUtilityAccountAvailableFunds()
+
+	return txn
+}
+
+// transactionStatusFromResultCode returns the status of a transaction M-Pesa sent the result code for.
+func transactionStatusFromResultCode(code int) TransactionStatus {
+	if code == 0 {
+		return TransactionStatusCompleted
+	}
+
+	return TransactionStatusFailed
+}
+
+// CallbackRecordFromSTKPushCallback creates a CallbackRecord for the STK push callback.
+func CallbackRecordFromSTKPushCallback(callback *STKPushCallback) (CallbackRecord, error) {
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return CallbackRecord{}, fmt.Errorf("mpesa: marshal callback: %v", err)
+	}
+
+	return CallbackRecord{
+		Kind:          CallbackKindSTKPush,
+		CorrelationID: callback.Body.STKCallback.CheckoutRequestID,
+		ResultCode:    callback.Body.STKCallback.ResultCode,
+		Payload:       payload,
+		ReceivedAt:    time.Now(),
+	}, nil
+}
+
+// CallbackRecordFromCallback creates a CallbackRecord for the result callback.
+func CallbackRecordFromCallback(callback *Callback) (CallbackRecord, error) {
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return CallbackRecord{}, fmt.Errorf("mpesa: marshal callback: %v", err)
+	}
+
+	return CallbackRecord{
+		Kind:          CallbackKindResult,
+		CorrelationID: callback.Result.ConversationID,
+		ResultCode:    callback.Result.ResultCode,
+		Payload:       payload,
+		ReceivedAt:    time.Now(),
+	}, nil
+}
+
+// CallbackRecordFromB2BExpressCheckoutCallback creates a CallbackRecord for the B2B Express Checkout callback.
+func CallbackRecordFromB2BExpressCheckoutCallback(callback *B2BExpressCheckoutCallback) (CallbackRecord, error) {
+	resultCode, err := strconv.Atoi(strings.TrimSpace(callback.ResultCode))
+	if err != nil {
+		return CallbackRecord{}, fmt.Errorf("mpesa: parse result code %q: %v", callback.ResultCode, err)
+	}
+
+	payload, err := json.Marshal(callback)
+	if err != nil {
+		return CallbackRecord{}, fmt.Errorf("mpesa: marshal callback: %v", err)
+	}
+
+	return CallbackRecord{
+		Kind:          CallbackKindB2BExpressCheckout,
+		CorrelationID: callback.RequestID,
+		ResultCode:    resultCode,
+		Payload:       payload,
+		ReceivedAt:    time.Now(),
+	}, nil
+}
@@ -0,0 +1,188 @@
+package mpesa
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations(t *testing.T) {
+	files, err := fs.Glob(Migrations, "migrations/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".up.sql") {
+			continue
+		}
+
+		down := strings.TrimSuffix(file, ".up.sql") + ".down.sql"
+		_, err = fs.Stat(Migrations, down)
+		require.NoError(t, err, "%s has no down migration", file)
+
+		b, err := fs.ReadFile(Migrations, file)
+		require.NoError(t, err)
+		if !strings.Contains(string(b), "ALTER TABLE") {
+			require.Contains(t, string(b), "CREATE TABLE")
+		}
+	}
+
+	b, err := fs.ReadFile(Migrations, "migrations/0001_create_mpesa_transactions.up.sql")
+	require.NoError(t, err)
+	require.Contains(t, string(b), Transaction{}.TableName())
+
+	// Pending transactions are inserted without a completion time, stored as the zero time.Time.
+	require.Contains(t, string(b), "completed_at      TIMESTAMPTZ    NOT NULL DEFAULT '"+
+		time.Time{}.Format("2006-01-02 15:04:05-07")+"'")
+
+	// An empty currency is the currency of the app, which the database cannot know.
+	b, err = fs.ReadFile(Migrations, "migrations/0003_add_mpesa_transactions_currency.up.sql")
+	require.NoError(t, err)
+	require.Contains(t, string(b), "currency VARCHAR(3) NOT NULL DEFAULT ''")
+
+	b, err = fs.ReadFile(Migrations, "migrations/0002_create_mpesa_callbacks.up.sql")
+	require.NoError(t, err)
+	require.Contains(t, string(b), CallbackRecord{}.TableName())
+}
+
+func TestCallbackRecord(t *testing.T) {
+	record, err := CallbackRecordFromSTKPushCallback(&STKPushCallback{
+		Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				MerchantRequestID: "29115-34620561-1",
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultCode:        1032,
+				ResultDesc:        "Request cancelled by user.",
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, CallbackKindSTKPush, record.Kind)
+	require.Equal(t, "ws_CO_191220191020363925", record.CorrelationID)
+	require.Equal(t, 1032, record.ResultCode)
+	require.Contains(t, string(record.Payload), `"CheckoutRequestID":"ws_CO_191220191020363925"`)
+	require.False(t, record.ReceivedAt.IsZero())
+
+	record, err = CallbackRecordFromCallback(&Callback{
+		Result: CallbackResult{
+			ConversationID: "AG_20191219_00005797af5d7d75f652",
+			ResultCode:     0,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, CallbackKindResult, record.Kind)
+	require.Equal(t, "AG_20191219_00005797af5d7d75f652", record.CorrelationID)
+}
+
+func TestCallbackRecordFromB2BExpressCheckoutCallback(t *testing.T) {
+	record, err := CallbackRecordFromB2BExpressCheckoutCallback(&B2BExpressCheckoutCallback{
+		ResultCode:       "4001",
+		ResultDesc:       "User cancelled transaction",
+		RequestID:        "c2a9ba32-9e11-4b90-892c-7bc54944609a",
+		Amount:           "71.0",
+		PaymentReference: "MAndbubry3hi",
+	})
+	require.NoError(t, err)
+	require.Equal(t, CallbackKindB2BExpressCheckout, record.Kind)
+	require.Equal(t, "c2a9ba32-9e11-4b90-892c-7bc54944609a", record.CorrelationID)
+	require.Equal(t, 4001, record.ResultCode)
+	require.Contains(t, string(record.Payload), `"paymentReference":"MAndbubry3hi"`)
+
+	_, err = CallbackRecordFromB2BExpressCheckoutCallback(&B2BExpressCheckoutCallback{ResultCode: "ok"})
+	require.ErrorContains(t, err, "mpesa: parse result code")
+}
+
+func TestTransactionFromSTKCallback(t *testing.T) {
+	txn := TransactionFromSTKCallback(&STKPushCallback{
+		Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultDesc:        "The service request is processed successfully.",
+				CallbackMetadata: STKCallbackMetadata{
+					Item: []STKCallbackItem{
+						{Name: STKCallbackItemAmount, Value: 1.0},
+						{Name: STKCallbackItemMpesaReceiptNumber, Value: "NLJ7RT61SV"},
+						{Name: STKCallbackItemBalance, Value: 250.5},
+						{Name: STKCallbackItemTransactionDate, Value: 20191219102115.0},
+						{Name: STKCallbackItemPhoneNumber, Value: 254708374149.0},
+					},
+				},
+			},
+		},
+	})
+
+	require.True(t, time.Date(2019, time.December, 19, 7, 21, 15, 0, time.UTC).Equal(txn.CompletedAt))
+	txn.CompletedAt = time.Time{}
+
+	require.Equal(t, Transaction{
+		ID:            "ws_CO_191220191020363925",
+		Kind:          TransactionKindSTKPush,
+		ReceiptNumber: "NLJ7RT61SV",
+		MSISDN:        254708374149,
+		Amount:        1,
+		Balance:       250.5,
+		Status:        TransactionStatusCompleted,
+		ResultDesc:    "The service request is processed successfully.",
+	}, txn)
+
+	txn = TransactionFromSTKCallback(&STKPushCallback{
+		Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				CheckoutRequestID: "ws_CO_191220191020363925",
+				ResultCode:        1032,
+				ResultDesc:        "Request cancelled by user.",
+			},
+		},
+	})
+	require.Equal(t, TransactionStatusFailed, txn.Status)
+	require.Equal(t, 1032, txn.ResultCode)
+	require.Empty(t, txn.ReceiptNumber)
+	require.True(t, txn.CompletedAt.IsZero())
+}
+
+func TestTransactionFromB2CCallback(t *testing.T) {
+	txn := TransactionFromB2CCallback(&Callback{
+		Result: CallbackResult{
+			ConversationID: "AG_20191219_00005797af5d7d75f652",
+			ResultDesc:     "The service request is processed successfully.",
+			TransactionID:  "NLJ41HAY6Q",
+			ResultParameters: ResultParameters{
+				ResultParameter: []ResultParameter{
+					{Key: ResultParameterTransactionAmount, Value: 10},
+					{Key: ResultParameterTransactionReceipt, Value: "NLJ41HAY6Q"},
+					{Key: ResultParameterReceiverPartyPublicName, Value: "254708374149 - John Doe"},
+					{Key: ResultParameterTransactionCompletedDateTime, Value: "19.12.2019 11:45:50"},
+					{Key: ResultParameterB2CUtilityAccountAvailableFunds, Value: 10116.0},
+				},
+			},
+		},
+	})
+
+	require.True(t, time.Date(2019, time.December, 19, 8, 45, 50, 0, time.UTC).Equal(txn.CompletedAt))
+	txn.CompletedAt = time.Time{}
+
+	require.Equal(t, Transaction{
+		ID:            "AG_20191219_00005797af5d7d75f652",
+		Kind:          TransactionKindB2C,
+		ReceiptNumber: "NLJ41HAY6Q",
+		MSISDN:        254708374149,
+		Amount:        10,
+		Balance:       10116,
+		Status:        TransactionStatusCompleted,
+		ResultDesc:    "The service request is processed successfully.",
+	}, txn)
+
+	txn = TransactionFromB2CCallback(&Callback{
+		Result: CallbackResult{
+			ConversationID: "AG_20191219_00005797af5d7d75f652",
+			ResultCode:     2001,
+			ResultDesc:     "The initiator information is invalid.",
+		},
+	})
+	require.Equal(t, TransactionStatusFailed, txn.Status)
+	require.Zero(t, txn.MSISDN)
+	require.Zero(t, txn.Amount)
+}
@@ -0,0 +1,87 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidMoney indicates that an amount cannot be parsed or sent to the API, e.g. a fraction of a shilling.
+var ErrInvalidMoney = errors.New("mpesa: invalid amount")
+
+// Money is an amount in the minor units of its currency, e.g. KES 1,500.50 is Money{Currency: CurrencyKES,
+// Minor: 150050}. It avoids the rounding errors of floats and makes the currency of an amount explicit.
+type Money struct {
+	Currency Currency
+	Minor    int64
+}
+
+// NewMoney returns the amount of minor units of the currency.
+func NewMoney(c Currency, minor int64) Money {
+	return Money{Currency: c.OrDefault(), Minor: minor}
+}
+
+// Shillings returns the amount of whole Kenyan shillings.
+func Shillings(whole int64) Money {
+	return NewMoney(CurrencyKES, whole*minorUnits(CurrencyKES))
+}
+
+// ParseMoney parses an amount of the currency written in major units, e.g. "1500", "1,500.50" or "0.5". It fails
+// with ErrInvalidMoney if the amount has more decimal places than the currency.
+func ParseMoney(amount string, c Currency) (Money, error) {
+	c = c.OrDefault()
+
+	s := strings.ReplaceAll(strings.TrimSpace(amount), ",", "")
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction, _ := strings.Cut(s, ".")
+	if whole == "" && fraction == "" || len(fraction) > c.Decimals() {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, amount)
+	}
+
+	fraction += strings.Repeat("0", c.Decimals()-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil || minor < 0 {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidMoney, amount)
+	}
+
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Currency: c, Minor: minor}, nil
+}
+
+// minorUnits returns the number of minor units in a major unit of the currency, e.g. 100 cents in a shilling.
+func minorUnits(c Currency) int64 {
+	return int64(math.Pow10(c.Decimals()))
+}
+
+// Major returns the amount in major units, e.g. 1500.5 for KES 1,500.50. Use it to display the amount only.
+func (m Money) Major() float64 {
+	return float64(m.Minor) / float64(minorUnits(m.Currency.OrDefault()))
+}
+
+// String formats the amount with its currency, e.g. KES 1,500.50.
+func (m Money) String() string {
+	return m.Currency.Format(m.Major())
+}
+
+// Whole returns the amount in whole major units, as expected on the Amount field of the requests. It fails with
+// ErrInvalidMoney if the amount is not positive or has a fractional part, as M-Pesa does not move cents.
+func (m Money) Whole() (uint, error) {
+	units := minorUnits(m.Currency.OrDefault())
+
+	switch {
+	case m.Minor <= 0:
+		return 0, fmt.Errorf("%w: %s must be greater than 0", ErrInvalidMoney, m)
+	case m.Minor%units != 0:
+		return 0, fmt.Errorf("%w: %s is not a whole amount", ErrInvalidMoney, m)
+	}
+
+	return uint(m.Minor / units), nil
+}
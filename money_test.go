@@ -0,0 +1,51 @@
+package mpesa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		amount  string
+		want    Money
+		wantErr bool
+	}{
+		{amount: "1500", want: Money{Currency: CurrencyKES, Minor: 150000}},
+		{amount: "1,500.50", want: Money{Currency: CurrencyKES, Minor: 150050}},
+		{amount: " 0.5 ", want: Money{Currency: CurrencyKES, Minor: 50}},
+		{amount: "-10.25", want: Money{Currency: CurrencyKES, Minor: -1025}},
+		{amount: "10.255", wantErr: true},
+		{amount: "", wantErr: true},
+		{amount: "ten", wantErr: true},
+		{amount: "1.-5", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseMoney(tc.amount, "")
+		if tc.wantErr {
+			require.ErrorIs(t, err, ErrInvalidMoney, tc.amount)
+			continue
+		}
+
+		require.NoError(t, err, tc.amount)
+		require.Equal(t, tc.want, got, tc.amount)
+	}
+}
+
+func TestMoney(t *testing.T) {
+	amount := NewMoney(CurrencyTZS, 150050)
+	require.Equal(t, 1500.5, amount.Major())
+	require.Equal(t, "TZS 1,500.50", amount.String())
+
+	_, err := amount.Whole()
+	require.ErrorIs(t, err, ErrInvalidMoney)
+
+	whole, err := Shillings(1500).Whole()
+	require.NoError(t, err)
+	require.Equal(t, uint(1500), whole)
+
+	_, err = Shillings(0).Whole()
+	require.ErrorIs(t, err, ErrInvalidMoney)
+}
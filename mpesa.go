@@ -4,16 +4,13 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-	"embed"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"image/png"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
@@ -27,9 +24,6 @@ import (
 // Environment indicates the current mode the application is running on. Either EnvironmentSandbox or EnvironmentProduction.
 type Environment uint8
 
-// cache stores the AuthorizationResponse for the specified accessTokenTTL
-type cache map[string]AuthorizationResponse
-
 const (
 	EnvironmentSandbox Environment = iota
 	EnvironmentProduction
@@ -42,7 +36,19 @@ const (
 	ResponseTypeComplete ResponseType = "Completed"
 )
 
-var accessTokenTTL = 55 * time.Minute
+// Versions of the C2B register URL API, set on RegisterC2BURLRequest.APIVersion.
+const (
+	C2BRegisterAPIVersion1 = "v1"
+	C2BRegisterAPIVersion2 = "v2"
+)
+
+var (
+	// accessTokenTTL is how long an access token is cached when Daraja does not return a valid expires_in.
+	accessTokenTTL = 55 * time.Minute
+
+	// accessTokenExpiryMargin is how long before the expires_in returned by Daraja an access token stops being used.
+	accessTokenExpiryMargin = 5 * time.Minute
+)
 
 // requiredURLScheme present the required scheme for the callbacks
 const requiredURLScheme = "https"
@@ -70,18 +76,73 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-//go:embed certs
-var certFS embed.FS
-
 // Mpesa is an app to make a transaction
 type Mpesa struct {
 	client      HttpClient
+	httpConfig  httpClientConfig
 	environment Environment
 	mu          sync.Mutex
-	cache       cache
+	tokens      TokenStore
+	limiter     *RateLimiter
+	breaker     *CircuitBreaker
+	currency    Currency
+
+	baseURL      string
+	endpointURLs map[Endpoint]string
+	apiVersions  map[Endpoint]string
+	callbackURLs CallbackURLs
+
+	endpointLimiter *RateLimiter
+
+	stkQueryCache    STKQueryCache
+	stkQueryCacheTTL time.Duration
+
+	idempotencyStore  IdempotencyStore
+	idempotencyWindow time.Duration
+
+	requestHooks []RequestHook
+	headers      http.Header
+	logger       *slog.Logger
+	recorder     Recorder
+
+	strictDecoding bool
+
+	retryMaxAttempts int
+	retryBackoff     time.Duration
+
+	qrImageDir string
+
+	certificate     []byte
+	certificateFile string
+	credentials     *securityCredentialCache
+	initiator       InitiatorCredentials
+
+	options    []Option
+	profilesMu sync.RWMutex
+	profiles   map[string]*Mpesa
+	shortCode  uint
+	passkey    string
+	profileErr error
+
+	tenantResolver TenantResolver
+	tenantsMu      sync.Mutex
+	tenantApps     map[string]tenantApp
 
 	consumerKey    string
 	consumerSecret string
+	tokenRequests  *tokenRequestGroup
+	tokenRefresher *tokenRefresher
+}
+
+// Option configures optional behaviour of an Mpesa app.
+type Option func(m *Mpesa)
+
+// WithRateLimiter makes the app wait for the limiter before every API request. Share the limiter between the apps
+// that use the same credentials or shortcodes.
+func WithRateLimiter(l *RateLimiter) Option {
+	return func(m *Mpesa) {
+		m.limiter = l
+	}
 }
 
 var (
@@ -106,67 +167,145 @@ func validateURL(rawURL string) error {
 	return nil
 }
 
-// NewApp initializes a new Mpesa app that will be used to perform C2B or B2C transactions.
-func NewApp(c HttpClient, consumerKey, consumerSecret string, env Environment) *Mpesa {
-	if c == nil {
-		c = &http.Client{
-			Timeout: 10 * time.Second,
-		}
-	}
-
-	return &Mpesa{
+// NewApp initializes a new Mpesa app that will be used to perform C2B or B2C transactions. When c is nil, a client is
+// created with the settings of WithHTTPTimeout, WithProxy, WithTLSConfig and WithKeepAlive.
+func NewApp(c HttpClient, consumerKey, consumerSecret string, env Environment, opts ...Option) *Mpesa {
+	m := &Mpesa{
 		client:      c,
 		environment: env,
-		cache:       make(cache),
+		tokens:      NewMemoryTokenStore(),
+		credentials: &securityCredentialCache{},
+
+		tokenRequests: newTokenRequestGroup(),
 
 		consumerKey:    consumerKey,
 		consumerSecret: consumerSecret,
+		options:        opts,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.client == nil {
+		m.client = m.httpConfig.newClient()
+	}
+
+	return m
+}
+
+// Endpoint identifies a Daraja API, e.g. EndpointSTKPush. The options configuring an endpoint only apply to it, even
+// when its path is shared with another one, e.g. EndpointBusinessBuyGoods and EndpointBusinessPayBill.
+type Endpoint string
+
+// Endpoints of the Daraja APIs called by the app.
+const (
+	EndpointAuth              Endpoint = "Auth"
+	EndpointAccountBalance    Endpoint = "AccountBalance"
+	EndpointB2C               Endpoint = "B2C"
+	EndpointBusinessBuyGoods  Endpoint = "BusinessBuyGoods"
+	EndpointBusinessPayBill   Endpoint = "BusinessPayBill"
+	EndpointC2BRegister       Endpoint = "C2BRegister"
+	EndpointC2BRegisterV2     Endpoint = "C2BRegisterV2"
+	EndpointDynamicQR         Endpoint = "DynamicQR"
+	EndpointReversal          Endpoint = "Reversal"
+	EndpointSTKPush           Endpoint = "STKPush"
+	EndpointSTKQuery          Endpoint = "STKQuery"
+	EndpointTransactionStatus Endpoint = "TransactionStatus"
+)
+
+// endpointPaths maps the endpoints to the path of their API.
+var endpointPaths = map[Endpoint]string{
+	EndpointAuth:                "/oauth/v1/generate",
+	EndpointAccountBalance:      "/mpesa/accountbalance/v1/query",
+	EndpointB2C:                 "/mpesa/b2c/v1/paymentrequest",
+	EndpointBusinessBuyGoods:    "/mpesa/b2b/v1/paymentrequest",
+	EndpointBusinessPayBill:     "/mpesa/b2b/v1/paymentrequest",
+	EndpointC2BRegister:         "/mpesa/c2b/v1/registerurl",
+	EndpointC2BRegisterV2:       "/mpesa/c2b/v2/registerurl",
+	EndpointDynamicQR:           "/mpesa/qrcode/v1/generate",
+	EndpointIdentityCheck:       "/mpesa/checkidentity/v1/query",
+	EndpointReversal:            "/mpesa/reversal/v1/request",
+	EndpointSTKPush:             "/mpesa/stkpush/v1/processrequest",
+	EndpointSTKQuery:            "/mpesa/stkpushquery/v1/query",
+	EndpointStandingOrderCancel: "/standingorder/v1/cancelStandingOrderExternal",
+	EndpointStandingOrderStatus: "/standingorder/v1/queryStandingOrderExternal",
+	EndpointTransactionStatus:   "/mpesa/transactionstatus/v1/query",
+}
+
+// Path returns the path of the endpoint's API, e.g. /mpesa/stkpush/v1/processrequest. An endpoint that is not defined
+// by this package is its own path.
+func (e Endpoint) Path() string {
+	if path, ok := endpointPaths[e]; ok {
+		return path
 	}
+
+	return string(e)
+}
+
+// URL returns the URL of the endpoint on the Daraja API of env. The access token requests made to EndpointAuth also
+// set the grant_type=client_credentials query parameter.
+func (e Endpoint) URL(env Environment) string {
+	return env.BaseURL() + e.Path()
 }
 
 // endpointAuth returns the auth endpoint prefixed with the current Environment base URL
 func (m *Mpesa) endpointAuth() string {
-	return m.Environment().BaseURL() + `/oauth/v1/generate?grant_type=client_credentials`
+	return m.endpointURL(EndpointAuth) + `?grant_type=client_credentials`
 }
 
 // endpointB2C returns the account balance endpoint prefixed with the current Environment base URL
 func (m *Mpesa) endpointAccountBalance() string {
-	return m.Environment().BaseURL() + `/mpesa/accountbalance/v1/query`
+	return m.endpointURL(EndpointAccountBalance)
 }
 
 // endpointB2C returns the B2C endpoint prefixed with the current Environment base URL
 func (m *Mpesa) endpointB2C() string {
-	return m.Environment().BaseURL() + `/mpesa/b2c/v1/paymentrequest`
+	return m.endpointURL(EndpointB2C)
+}
+
+// endpointBusinessBuyGoods returns the Business Buy Goods endpoint prefixed with the current Environment base URL
+func (m *Mpesa) endpointBusinessBuyGoods() string {
+	return m.endpointURL(EndpointBusinessBuyGoods)
 }
 
 // endpointBusinessPayBill returns the Business Pay Bill endpoint prefixed with the current Environment base URL
 func (m *Mpesa) endpointBusinessPayBill() string {
-	return m.Environment().BaseURL() + `/mpesa/b2b/v1/paymentrequest`
+	return m.endpointURL(EndpointBusinessPayBill)
 }
 
 // endpointB2C returns the endpoint to register C2B callbacks prefixed with the current Environment base URL
 func (m *Mpesa) endpointC2BRegister() string {
-	return m.Environment().BaseURL() + `/mpesa/c2b/v1/registerurl`
+	return m.endpointURL(EndpointC2BRegister)
+}
+
+func (m *Mpesa) endpointC2BRegisterV2() string {
+	return m.endpointURL(EndpointC2BRegisterV2)
 }
 
 // endpointB2C returns the endpoint to generate dunamic QR code prefixed with the current Environment base URL
 func (m *Mpesa) endpointDynamicQR() string {
-	return m.Environment().BaseURL() + `/mpesa/qrcode/v1/generate`
+	return m.endpointURL(EndpointDynamicQR)
+}
+
+// endpointReversal returns the endpoint to reverse a transaction prefixed with the current Environment base URL
+func (m *Mpesa) endpointReversal() string {
+	return m.endpointURL(EndpointReversal)
 }
 
 // endpointSTK returns the endpoint to generate an STK push prefixed with the current Environment base URL
 func (m *Mpesa) endpointSTK() string {
-	return m.Environment().BaseURL() + `/mpesa/stkpush/v1/processrequest`
+	return m.endpointURL(EndpointSTKPush)
 }
 
 // endpointSTK returns the endpoint to query the status of an STK request prefixed with the current Environment base URL
 func (m *Mpesa) endpointSTKQuery() string {
-	return m.Environment().BaseURL() + `/mpesa/stkpushquery/v1/query`
+	return m.endpointURL(EndpointSTKQuery)
 }
 
 // endpointSTK returns the endpoint to query the status of a transaction prefixed with the current Environment base URL
 func (m *Mpesa) endpointTransactionStatus() string {
-	return m.Environment().BaseURL() + `/mpesa/transactionstatus/v1/query`
+	return m.endpointURL(EndpointTransactionStatus)
 }
 
 // generateTimestampAndPassword returns the current timestamp in the format YYYYMMDDHHmmss and a base64 encoded
@@ -177,36 +316,185 @@ func generateTimestampAndPassword(shortcode uint, passkey string) (string, strin
 	return timestamp, base64.StdEncoding.EncodeToString([]byte(password))
 }
 
-// makeHttpRequestWithToken makes an API call to the provided url using the provided http method.
-func (m *Mpesa) makeHttpRequestWithToken(
-	ctx context.Context, method, url string, body interface{},
+// rawBodySetter is implemented by the responses that keep the body they were decoded from.
+type rawBodySetter interface {
+	setRawBody(body []byte)
+}
+
+func (r *Response) setRawBody(body []byte)            { r.RawBody = body }
+func (r *STKPushResponse) setRawBody(body []byte)     { r.RawBody = body }
+func (r *STKQueryResponse) setRawBody(body []byte)    { r.RawBody = body }
+func (r *AsyncResponse) setRawBody(body []byte)       { r.RawBody = body }
+func (r *C2BRegisterResponse) setRawBody(body []byte) { r.RawBody = body }
+
+func (r *STKPushResponse) acknowledgement() (requestID, responseCode, responseDescription string) {
+	if r == nil {
+		return "", "", ""
+	}
+
+	return r.CheckoutRequestID, r.ResponseCode, r.ResponseDescription
+}
+
+func (r *AsyncResponse) acknowledgement() (requestID, responseCode, responseDescription string) {
+	if r == nil {
+		return "", "", ""
+	}
+
+	return r.ConversationID, r.ResponseCode, r.ResponseDescription
+}
+
+// do posts the body to the endpoint and decodes the response into a T. Every API call goes through it so that the
+// access token, headers, rate limiting, logging, request hooks and error normalization apply to all of them. Set retry
+// for idempotent calls only: they are retried on transient errors as configured using WithRetry.
+func do[T any](ctx context.Context, m *Mpesa, endpoint Endpoint, body any, retry bool) (*T, error) {
+	res, err := m.sendHttpRequestWithToken(ctx, http.MethodPost, endpoint, body, retry)
+	if err != nil {
+		return nil, err
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	var v T
+	raw, err := decodeResult(res, &v, m.strictDecoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := any(&v).(rawBodySetter); ok {
+		s.setRawBody(raw)
+	}
+
+	return &v, nil
+}
+
+func (m *Mpesa) sendHttpRequestWithToken(
+	ctx context.Context, method string, endpoint Endpoint, body interface{}, retry bool,
 ) (*http.Response, error) {
 	reqBody, err := json.Marshal(body)
 	if err != nil {
 		return nil, fmt.Errorf("mpesa: marshal request: %v", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("mpesa: create request: %v", err)
+	// sent is set once the request went out, so that the errors raised before can be told apart by the callers.
+	var sent bool
+	notSent := func(err error) error {
+		if sent {
+			return err
+		}
+
+		return &requestNotSentError{err: err}
 	}
 
 	accessToken, err := m.GenerateAccessToken(ctx)
 	if err != nil {
-		return nil, err
+		return nil, notSent(err)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", `Bearer `+accessToken)
+	requestID := newRequestID()
 
-	res, err := m.client.Do(req)
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, m.endpointURL(endpoint), bytes.NewReader(reqBody))
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: create request: %v", err)
+		}
+
+		if m.limiter != nil {
+			if err = m.limiter.Wait(ctx, m.rateLimitKey(body)); err != nil {
+				return nil, err
+			}
+		}
+
+		if m.endpointLimiter != nil {
+			if err = m.endpointLimiter.Wait(ctx, EndpointRateLimitKey(endpoint)); err != nil {
+				return nil, err
+			}
+		}
+
+		m.setHeaders(ctx, req, requestID)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", `Bearer `+accessToken)
+
+		if m.breaker != nil {
+			if err = m.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		m.recordRequest(ctx, endpoint, reqBody)
+
+		sent = true
+		start := time.Now()
+		res, err := m.client.Do(req)
+		if m.breaker != nil {
+			m.breaker.done(ctx, res, err)
+		}
+		setResponseRequest(res, req)
+		m.runRequestHooks(ctx, req, res, start, err)
+		m.logRequest(ctx, req, reqBody, res, start, err)
+		m.recordResponse(ctx, endpoint, res, err)
+
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: make request: %v", err)
+		}
+
+		return res, nil
+	}
+
+	do := send
+	if retry {
+		do = func() (*http.Response, error) {
+			return m.retry(ctx, send)
+		}
+	}
+
+	res, err := do()
 	if err != nil {
-		return nil, fmt.Errorf("mpesa: make request: %v", err)
+		return nil, notSent(err)
+	}
+
+	if !hasInvalidAccessToken(res) {
+		return res, nil
+	}
+
+	// The token was revoked before it expired, so the request was not processed. Generate a new one and try again once.
+	//goland:noinspection GoUnhandledErrorResult
+	res.Body.Close()
+	sent = false
+
+	if err = m.invalidateAccessToken(ctx, accessToken); err != nil {
+		return nil, notSent(err)
+	}
+
+	if accessToken, err = m.GenerateAccessToken(ctx); err != nil {
+		return nil, notSent(err)
+	}
+
+	if res, err = do(); err != nil {
+		return nil, notSent(err)
 	}
 
 	return res, nil
 }
 
+// invalidateAccessToken removes the access token from the TokenStore unless it was already replaced, e.g. by another
+// app instance sharing the store.
+func (m *Mpesa) invalidateAccessToken(ctx context.Context, accessToken string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok, err := m.tokens.Get(ctx, m.consumerKey)
+	if err != nil || !ok || token != accessToken {
+		return nil
+	}
+
+	if err = m.tokens.Delete(ctx, m.consumerKey); err != nil {
+		return fmt.Errorf("mpesa: invalidate access token: %v", err)
+	}
+
+	return nil
+}
+
 // Environment returns the current environment the app is running on.
 func (m *Mpesa) Environment() Environment {
 	return m.environment
@@ -214,155 +502,368 @@ func (m *Mpesa) Environment() Environment {
 
 // GenerateAccessToken returns a time bound access token to call allowed APIs.
 // This token should be used in all other subsequent responses to the APIs
-// GenerateAccessToken will also cache the access token for the specified refresh after period
+// GenerateAccessToken will also cache the access token in the TokenStore until shortly before the expires_in returned
+// by Daraja.
+// The concurrent callers of an app and of its shortcode profiles share a single request per consumer key.
 func (m *Mpesa) GenerateAccessToken(ctx context.Context) (string, error) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	token, err := m.Token(ctx)
+	if err != nil {
+		return "", err
+	}
 
-	if cachedData, ok := m.cache[m.consumerKey]; ok {
-		if cachedData.setAt.Add(accessTokenTTL).After(time.Now()) {
-			return cachedData.AccessToken, nil
-		}
+	return token.Token, nil
+}
+
+// Token returns the access token like GenerateAccessToken along with its expiry, e.g. to forward it to another
+// service. The expiry is zero when the token was cached by a TokenStore that does not implement TokenExpiryStore.
+func (m *Mpesa) Token(ctx context.Context) (AccessToken, error) {
+	if m.profileErr != nil {
+		return AccessToken{}, m.profileErr
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.endpointAuth(), nil)
-	if err != nil {
-		return "", fmt.Errorf("mpesa: create auth request: %v", err)
+	// A failing store must not fail the request, a new token is generated instead.
+	if token, ok := m.cachedAccessToken(ctx); ok {
+		return token, nil
 	}
 
-	req.SetBasicAuth(m.consumerKey, m.consumerSecret)
+	token, expiresAt, err := m.tokenRequests.do(ctx, m.consumerKey,
+		func(ctx context.Context) (string, time.Time, error) {
+			// The token may have been cached by a request that completed since the lookup above.
+			if token, ok := m.cachedAccessToken(ctx); ok {
+				return token.Token, token.ExpiresAt, nil
+			}
 
-	res, err := m.client.Do(req)
+			return m.fetchAccessToken(ctx)
+		},
+	)
 	if err != nil {
-		return "", fmt.Errorf("mpesa: make auth request: %v", err)
+		return AccessToken{}, err
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
+	// The request may have been made by a shortcode profile sharing the consumer key, which cached it in its own store.
+	m.cacheAccessToken(ctx, token, expiresAt)
+	return AccessToken{Token: token, ExpiresAt: expiresAt}, nil
+}
 
-	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("mpesa: auth failed with status: %v", res.Status)
+// cachedAccessToken returns the access token cached in the TokenStore, with its expiry when the store reports it.
+func (m *Mpesa) cachedAccessToken(ctx context.Context) (AccessToken, bool) {
+	if store, ok := m.tokens.(TokenExpiryStore); ok {
+		token, expiresAt, ok, err := store.GetWithExpiry(ctx, m.consumerKey)
+		if err != nil || !ok {
+			return AccessToken{}, false
+		}
+
+		return AccessToken{Token: token, ExpiresAt: expiresAt}, true
 	}
 
-	var response AuthorizationResponse
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("mpesa: decode auth response: %v", err)
+	token, ok, err := m.tokens.Get(ctx, m.consumerKey)
+	if err != nil || !ok {
+		return AccessToken{}, false
 	}
 
-	response.setAt = time.Now()
-	m.cache[m.consumerKey] = response
-	return m.cache[m.consumerKey].AccessToken, nil
+	return AccessToken{Token: token}, true
 }
 
-// STKPush initiates online payment on behalf of a customer using STKPush.
-func (m *Mpesa) STKPush(ctx context.Context, passkey string, req STKPushRequest) (*Response, error) {
-	if passkey == "" {
-		return nil, ErrInvalidPasskey
+// cacheAccessToken caches the token in the TokenStore until expiresAt and schedules its renewal. A zero expiresAt
+// means that the expiry of the token taken from the TokenStore is unknown.
+func (m *Mpesa) cacheAccessToken(ctx context.Context, token string, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		return
 	}
 
-	req.Timestamp, req.Password = generateTimestampAndPassword(req.BusinessShortCode, passkey)
+	_ = m.tokens.Set(ctx, m.consumerKey, token, time.Until(expiresAt))
+	m.scheduleTokenRefresh(expiresAt)
+}
 
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointSTK(), req)
+// fetchAccessToken requests a new access token and caches it, even when the callers waiting for it gave up.
+func (m *Mpesa) fetchAccessToken(ctx context.Context) (string, time.Time, error) {
+	token, expiresAt, err := m.requestAccessToken(ctx)
 	if err != nil {
-		return nil, err
+		return "", time.Time{}, err
+	}
+
+	m.cacheAccessToken(ctx, token, expiresAt)
+	return token, expiresAt, nil
+}
+
+// requestAccessToken requests a new access token from Daraja and returns it with the time it should be renewed by.
+func (m *Mpesa) requestAccessToken(ctx context.Context) (string, time.Time, error) {
+	requestedAt := time.Now()
+	requestID := newRequestID()
+
+	res, err := m.retry(ctx, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.endpointAuth(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: create auth request: %v", err)
+		}
+
+		m.setHeaders(ctx, req, requestID)
+		req.SetBasicAuth(m.consumerKey, m.consumerSecret)
+
+		if m.breaker != nil {
+			if err = m.breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		res, err := m.client.Do(req)
+		if m.breaker != nil {
+			m.breaker.done(ctx, res, err)
+		}
+		setResponseRequest(res, req)
+		m.logRequest(ctx, req, nil, res, start, err)
+
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: make auth request: %v", err)
+		}
+
+		return res, nil
+	})
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
 	//goland:noinspection GoUnhandledErrorResult
 	defer res.Body.Close()
 
-	return decodeResponse(res)
-}
-
-// UnmarshalSTKPushCallback decodes the provided value to STKPushCallback.
-func UnmarshalSTKPushCallback(r io.Reader) (*STKPushCallback, error) {
-	var callback STKPushCallback
-	if err := json.NewDecoder(r).Decode(&callback); err != nil {
-		return nil, fmt.Errorf("mpesa: decode: %v", err)
+	var response AuthorizationResponse
+	if _, err := decodeResult(res, &response, m.strictDecoding); err != nil {
+		return "", time.Time{}, err
 	}
 
-	return &callback, nil
+	return response.AccessToken, requestedAt.Add(response.ttl()), nil
 }
 
-func (m *Mpesa) generateSecurityCredentials(initiatorPwd string) (string, error) {
-	certPath := "certs/sandbox.cer"
-	if m.Environment().IsProduction() {
-		certPath = "certs/production.cer"
+// ttl returns how long the access token is used, accessTokenExpiryMargin less than its expires_in.
+func (r AuthorizationResponse) ttl() time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(r.ExpiresIn))
+	if err != nil || seconds <= 0 {
+		return accessTokenTTL
 	}
 
-	publicKey, err := certFS.ReadFile(certPath)
-	if err != nil {
-		return "", fmt.Errorf("mpesa: read cert: %v", err)
+	expiresIn := time.Duration(seconds) * time.Second
+	if expiresIn <= 2*accessTokenExpiryMargin {
+		return expiresIn / 2
 	}
 
-	block, _ := pem.Decode(publicKey)
+	return expiresIn - accessTokenExpiryMargin
+}
 
-	var cert *x509.Certificate
-	cert, err = x509.ParseCertificate(block.Bytes)
+// STKPush initiates online payment on behalf of a customer using STKPush.
+func (m *Mpesa) STKPush(ctx context.Context, passkey string, req STKPushRequest) (*STKPushResponse, error) {
+	passkey, err := m.resolvePasskey(passkey)
 	if err != nil {
-		return "", fmt.Errorf("mpesa:parse cert: %v", err)
+		return nil, err
 	}
 
-	rsaPublicKey := cert.PublicKey.(*rsa.PublicKey)
-	reader := rand.Reader
-	signature, err := rsa.EncryptPKCS1v15(reader, rsaPublicKey, []byte(initiatorPwd))
-	if err != nil {
-		return "", fmt.Errorf("mpesa: encrypt password: %v", err)
+	m.defaultShortCode(&req.BusinessShortCode)
+	m.defaultShortCode(&req.PartyB)
+	setDefault(&req.CallBackURL, m.callbackURLs.CallBackURL)
+
+	if err := req.resolvePhone(); err != nil {
+		return nil, err
+	}
+
+	if err := validate(req); err != nil {
+		return nil, err
 	}
 
-	return base64.StdEncoding.EncodeToString(signature), nil
+	req.Timestamp, req.Password = generateTimestampAndPassword(req.BusinessShortCode, passkey)
+
+	return do[STKPushResponse](ctx, m, EndpointSTKPush, req, false)
 }
 
-// B2C transacts between an M-Pesa short code to a phone number registered on M-Pesa
-func (m *Mpesa) B2C(ctx context.Context, initiatorPwd string, req B2CRequest) (*Response, error) {
-	if initiatorPwd == "" {
-		return nil, ErrInvalidInitiatorPassword
+// STKPushAuto is STKPush with the TransactionType selected from the shortcodes: CustomerBuyGoodsOnline when PartyB,
+// the till number, differs from the BusinessShortCode and CustomerPayBillOnline otherwise. Any TransactionType set on
+// the request is replaced.
+func (m *Mpesa) STKPushAuto(ctx context.Context, passkey string, req STKPushRequest) (*STKPushResponse, error) {
+	m.defaultShortCode(&req.BusinessShortCode)
+	m.defaultShortCode(&req.PartyB)
+
+	req.TransactionType = stkTransactionType(req.BusinessShortCode, req.PartyB)
+
+	return m.STKPush(ctx, passkey, req)
+}
+
+// stkTransactionType returns the TransactionType of an STK push paying partyB on behalf of the businessShortCode.
+func stkTransactionType(businessShortCode, partyB uint) TransactionType {
+	if partyB != businessShortCode {
+		return CustomerBuyGoodsOnlineTransactionType
 	}
 
-	securityCredential, err := m.generateSecurityCredentials(initiatorPwd)
+	return CustomerPayBillOnlineTransactionType
+}
+
+// UnmarshalSTKPushCallback decodes the provided value to STKPushCallback.
+func UnmarshalSTKPushCallback(r io.Reader) (*STKPushCallback, error) {
+	return decode[STKPushCallback](r, false)
+}
+
+// B2C transacts between an M-Pesa short code to a phone number registered on M-Pesa
+func (m *Mpesa) B2C(ctx context.Context, initiatorPwd string, req B2CRequest) (*B2CResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, initiatorPwd)
 	if err != nil {
 		return nil, err
 	}
 
-	req.SecurityCredential = securityCredential
+	if req.InitiatorName == "" {
+		req.InitiatorName = initiator.Name
+	}
+	m.defaultShortCode(&req.PartyA)
+	m.defaultResultURLs(&req.ResultURL, &req.QueueTimeOutURL)
+
+	if err := req.resolvePhone(); err != nil {
+		return nil, err
+	}
 
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointB2C(), req)
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
+	key := IdempotencyKeyFromContext(ctx)
+	if key == "" {
+		key = req.OriginatorConversationID
+	}
+
+	// The ID is generated once the request is fingerprinted so that retries of a request without an ID are still
+	// recognised as duplicates.
+	return m.idempotent(ctx, EndpointB2C, key, req, func() (*AsyncResponse, error) {
+		req.SecurityCredential = securityCredential
+		if req.OriginatorConversationID == "" {
+			req.OriginatorConversationID = newOriginatorConversationID()
+		}
+
+		response, err := do[AsyncResponse](ctx, m, EndpointB2C, req, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.OriginatorConversationID == "" {
+			response.OriginatorConversationID = req.OriginatorConversationID
+		}
 
-	return decodeResponse(res)
+		return response, nil
+	})
+}
+
+// newOriginatorConversationID returns a random version 4 UUID.
+func newOriginatorConversationID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
 }
 
 // UnmarshalCallback decodes the provided value to Callback
 func UnmarshalCallback(r io.Reader) (*Callback, error) {
-	var callback Callback
-	if err := json.NewDecoder(r).Decode(&callback); err != nil {
-		return nil, fmt.Errorf("mpesa: decode: %v", err)
+	return decode[Callback](r, false)
+}
+
+// UnmarshalJSON decodes the result, accepting ResultType and ResultCode sent as strings, as M-Pesa does on Business
+// Pay Bill and Business Buy Goods results.
+func (r *CallbackResult) UnmarshalJSON(data []byte) error {
+	type callbackResult CallbackResult
+
+	aux := struct {
+		*callbackResult
+		ResultCode json.Number `json:"ResultCode"`
+		ResultType json.Number `json:"ResultType"`
+	}{callbackResult: (*callbackResult)(r)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		name string
+		n    json.Number
+		dst  *int
+	}{{"ResultCode", aux.ResultCode, &r.ResultCode}, {"ResultType", aux.ResultType, &r.ResultType}} {
+		if field.n == "" {
+			continue
+		}
+
+		v, err := strconv.Atoi(field.n.String())
+		if err != nil {
+			return fmt.Errorf("mpesa: invalid %s %q", field.name, field.n)
+		}
+		*field.dst = v
+	}
+
+	return nil
+}
+
+// UnmarshalQueueTimeoutCallback decodes the provided value to QueueTimeoutCallback.
+func UnmarshalQueueTimeoutCallback(r io.Reader) (*QueueTimeoutCallback, error) {
+	return decode[QueueTimeoutCallback](r, false)
+}
+
+// UnmarshalJSON decodes the timeout notification, accepting ResultType and ResultCode sent as strings like
+// CallbackResult does.
+func (r *QueueTimeoutResult) UnmarshalJSON(data []byte) error {
+	var result CallbackResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+
+	*r = QueueTimeoutResult{
+		ConversationID:           result.ConversationID,
+		OriginatorConversationID: result.OriginatorConversationID,
+		ReferenceData:            result.ReferenceData,
+		ResultCode:               result.ResultCode,
+		ResultDesc:               result.ResultDesc,
+		ResultType:               result.ResultType,
 	}
 
-	return &callback, nil
+	return nil
+}
+
+// UnmarshalB2BExpressCheckoutCallback decodes the provided value to B2BExpressCheckoutCallback.
+func UnmarshalB2BExpressCheckoutCallback(r io.Reader) (*B2BExpressCheckoutCallback, error) {
+	return decode[B2BExpressCheckoutCallback](r, false)
 }
 
 // STKQuery checks the status of an STKPush payment.
-func (m *Mpesa) STKQuery(ctx context.Context, passkey string, req STKQueryRequest) (*Response, error) {
-	if passkey == "" {
-		return nil, ErrInvalidPasskey
+func (m *Mpesa) STKQuery(ctx context.Context, passkey string, req STKQueryRequest) (*STKQueryResponse, error) {
+	passkey, err := m.resolvePasskey(passkey)
+	if err != nil {
+		return nil, err
+	}
+
+	m.defaultShortCode(&req.BusinessShortCode)
+
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	if m.stkQueryCache != nil {
+		if cached, ok, err := m.stkQueryCache.Get(ctx, req.CheckoutRequestID); err == nil && ok {
+			return &cached, nil
+		}
 	}
 
 	req.Timestamp, req.Password = generateTimestampAndPassword(req.BusinessShortCode, passkey)
 
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointSTKQuery(), req)
+	resp, err := do[STKQueryResponse](ctx, m, EndpointSTKQuery, req, true)
 	if err != nil {
 		return nil, err
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
+	if m.stkQueryCache != nil && isFinalSTKQueryResult(resp) {
+		// A failure to cache the result must not fail the query.
+		_ = m.stkQueryCache.Set(ctx, req.CheckoutRequestID, *resp, m.stkQueryCacheTTL)
+	}
 
-	return decodeResponse(res)
+	return resp, nil
 }
 
 // RegisterC2BURL API works hand in hand with Customer to Business (C2B) APIs and allows receiving payment notifications to your paybill.
@@ -371,86 +872,83 @@ func (m *Mpesa) STKQuery(ctx context.Context, passkey string, req STKQueryReques
 // Validation URL: This is the URL that is only used when a Merchant (Partner) requires to validate the details of the payment before accepting.
 // For example, a bank would want to verify if an account number exists in their platform before accepting a payment from the customer.
 // Confirmation URL:  This is the URL that receives payment notification once payment has been completed successfully on M-PESA.
-func (m *Mpesa) RegisterC2BURL(ctx context.Context, req RegisterC2BURLRequest) (*Response, error) {
-	switch req.ResponseType {
-	case ResponseTypeComplete, ResponseTypeCanceled:
-		response, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointC2BRegister(), req)
-		if err != nil {
-			return nil, err
-		}
-		defer func(body io.ReadCloser) {
-			_ = body.Close()
-		}(response.Body)
+// Set ConfirmationOnly to register the Confirmation URL alone when the external validation of the shortcode is disabled.
+func (m *Mpesa) RegisterC2BURL(ctx context.Context, req RegisterC2BURLRequest) (*C2BRegisterResponse, error) {
+	m.defaultShortCode(&req.ShortCode)
+	setDefault(&req.ConfirmationURL, m.callbackURLs.ConfirmationURL)
+	if !req.ConfirmationOnly {
+		setDefault(&req.ValidationURL, m.callbackURLs.ValidationURL)
+	}
+
+	if err := validate(req); err != nil {
+		return nil, err
+	}
 
-		return decodeResponse(response)
-	default:
-		return nil, fmt.Errorf("mpesa: the provided ResponseType [%s] is not valid", req.ResponseType)
+	endpoint := EndpointC2BRegister
+	if req.APIVersion == C2BRegisterAPIVersion2 {
+		endpoint = EndpointC2BRegisterV2
 	}
+
+	return do[C2BRegisterResponse](ctx, m, endpoint, req, false)
 }
 
 // DynamicQR API is used to generate a Dynamic QR which enables Safaricom M-PESA customers who have My Safaricom App or
 // M-PESA app, to scan a QR (Quick Response) code, to capture till number and amount then authorize to pay for goods and
-// services at select LIPA NA M-PESA (LNM) merchant outlets. If the decodeImage parameter is set to true, the QR code
-// will be decoded and a base url is set on the ImagePath field
+// services at select LIPA NA M-PESA (LNM) merchant outlets. If the decodeImage parameter is set to true, the decoded
+// PNG image is set on the ImageData field and, if the app was created with WithQRImageDir, saved to the directory and
+// its path set on the ImagePath field.
 func (m *Mpesa) DynamicQR(
 	ctx context.Context, req DynamicQRRequest, transactionType DynamicQRTransactionType, decodeImage bool,
 ) (*DynamicQRResponse, error) {
 	req.TransactionType = transactionType
+	if req.Size == 0 {
+		req.Size = defaultQRSize
+	}
 
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointDynamicQR(), req)
-	if err != nil {
+	if err := validate(req); err != nil {
 		return nil, err
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
-
-	var resp *DynamicQRResponse
-	if err = json.NewDecoder(res.Body).Decode(&resp); err != nil {
-		return nil, fmt.Errorf("mpesa: decode response: %v", err)
+	// Daraja only accepts the phone numbers in the 2547XXXXXXXX format.
+	if req.TransactionType == SendMoneyViaMobileNumber || req.TransactionType == SentToBusiness {
+		msisdn, _ := ParsePhoneNumber(req.CreditPartyIdentifier)
+		req.CreditPartyIdentifier = msisdn.String()
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
-			"mpesa: request %v failed with code %v: %v", resp.RequestID, resp.ErrorCode, resp.ErrorMessage,
-		)
+	resp, err := do[DynamicQRResponse](ctx, m, EndpointDynamicQR, req, false)
+	if err != nil {
+		return nil, err
 	}
 
 	if !decodeImage {
 		return resp, nil
 	}
 
-	reader := base64.NewDecoder(base64.StdEncoding, strings.NewReader(resp.QRCode))
-
-	image, err := png.Decode(reader)
+	imageData, err := resp.PNG()
 	if err != nil {
+		return nil, err
+	}
+
+	if _, err = png.DecodeConfig(bytes.NewReader(imageData)); err != nil {
 		return nil, fmt.Errorf("mpesa: decode png: %v", err)
 	}
 
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("mpesa: wd: %v", err)
+	resp.ImageData = imageData
+
+	if m.qrImageDir == "" {
+		return resp, nil
 	}
 
-	imagesDir := filepath.Join(wd, "storage", "images")
-	if _, err := os.Stat(imagesDir); os.IsNotExist(err) {
-		if err = os.Mkdir(imagesDir, os.ModePerm); err != nil {
-			return nil, fmt.Errorf("mpesa: create images dir: %v", err)
-		}
+	if err = os.MkdirAll(m.qrImageDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("mpesa: create images dir: %v", err)
 	}
 
 	amountStr := strconv.Itoa(int(req.Amount))
 	filename := req.MerchantName + "_" + amountStr + "_" + req.CreditPartyIdentifier + ".png"
-	filename = imagesDir + "/" + strings.ReplaceAll(filename, " ", "_")
-
-	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE, 0755)
-	if err != nil {
-		return nil, fmt.Errorf("mpesa: open png: %v", err)
-
-	}
+	filename = filepath.Join(m.qrImageDir, strings.ReplaceAll(filename, " ", "_"))
 
-	if err = png.Encode(f, image); err != nil {
-		return nil, fmt.Errorf("mpesa: encode png: %v", err)
+	if err = os.WriteFile(filename, imageData, 0644); err != nil {
+		return nil, fmt.Errorf("mpesa: write png: %v", err)
 	}
 
 	resp.ImagePath = filename
@@ -460,125 +958,182 @@ func (m *Mpesa) DynamicQR(
 // GetTransactionStatus checks the status of a transaction
 func (m *Mpesa) GetTransactionStatus(
 	ctx context.Context, initiatorPwd string, req TransactionStatusRequest,
-) (*Response, error) {
-	if initiatorPwd == "" {
-		return nil, ErrInvalidInitiatorPassword
+) (*AsyncResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, initiatorPwd)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := validateURL(req.QueueTimeOutURL); err != nil {
-		return nil, err
+	if req.Initiator == "" {
+		req.Initiator = initiator.Name
 	}
+	m.defaultShortCode(&req.PartyA)
+	m.defaultResultURLs(&req.ResultURL, &req.QueueTimeOutURL)
 
-	if err := validateURL(req.ResultURL); err != nil {
+	if err := validate(req); err != nil {
 		return nil, err
 	}
 
-	securityCredential, err := m.generateSecurityCredentials(initiatorPwd)
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	req.SecurityCredential = securityCredential
-	req.CommandID = TransactionStatusQueryCommandID
-	req.IdentifierType = ShortcodeIdentifierType
+	if req.CommandID == "" {
+		req.CommandID = TransactionStatusQueryCommandID
+	}
+	if req.IdentifierType == 0 {
+		req.IdentifierType = ShortcodeIdentifierType
+	}
+
+	return do[AsyncResponse](ctx, m, EndpointTransactionStatus, req, true)
+}
 
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointTransactionStatus(), req)
+// Reversal reverses a completed M-Pesa transaction. The result is sent to the ResultURL.
+func (m *Mpesa) Reversal(ctx context.Context, initiatorPwd string, req ReversalRequest) (*AsyncResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, initiatorPwd)
 	if err != nil {
 		return nil, err
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
+	if req.Initiator == "" {
+		req.Initiator = initiator.Name
+	}
+	m.defaultShortCode(&req.ReceiverParty)
+	m.defaultResultURLs(&req.ResultURL, &req.QueueTimeOutURL)
+
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	req.SecurityCredential = securityCredential
+	req.CommandID = TransactionReversalCommandID
+	req.RecieverIdentifierType = ReversalIdentifierType
 
-	return decodeResponse(res)
+	return do[AsyncResponse](ctx, m, EndpointReversal, req, false)
 }
 
 // GetAccountBalance fetches the account balance of a short code. This can be used for both B2C, buy goods and pay bill
 // accounts.
 func (m *Mpesa) GetAccountBalance(
 	ctx context.Context, initiatorPwd string, req AccountBalanceRequest,
-) (*Response, error) {
-	if initiatorPwd == "" {
-		return nil, ErrInvalidInitiatorPassword
+) (*AsyncResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, initiatorPwd)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := validateURL(req.QueueTimeOutURL); err != nil {
-		return nil, err
+	if req.Initiator == "" {
+		req.Initiator = initiator.Name
 	}
+	if req.PartyA == 0 {
+		req.PartyA = int(m.shortCode)
+	}
+	m.defaultResultURLs(&req.ResultURL, &req.QueueTimeOutURL)
 
-	if err := validateURL(req.ResultURL); err != nil {
+	if err := validate(req); err != nil {
 		return nil, err
 	}
 
-	securityCredential, err := m.generateSecurityCredentials(initiatorPwd)
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
 	if err != nil {
 		return nil, err
 	}
 
 	req.SecurityCredential = securityCredential
-	req.CommandID = AccountBalanceCommandID
-	req.IdentifierType = ShortcodeIdentifierType
-
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointAccountBalance(), req)
-	if err != nil {
-		return nil, err
+	if req.CommandID == "" {
+		req.CommandID = AccountBalanceCommandID
+	}
+	if req.IdentifierType == 0 {
+		req.IdentifierType = ShortcodeIdentifierType
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
-
-	return decodeResponse(res)
+	return do[AsyncResponse](ctx, m, EndpointAccountBalance, req, true)
 }
 
 // BusinessPayBill API enables you to pay bills directly from your business account to a pay bill number, or a paybill
 // store. You can use this API to pay on behalf of a consumer/requester.
 //
 // The transaction moves money from your MMF/Working account to the recipient’s utility account.
-func (m *Mpesa) BusinessPayBill(ctx context.Context, initiatorPwd string, req BusinessPayBillRequest) (*Response, error) {
-	if initiatorPwd == "" {
-		return nil, ErrInvalidInitiatorPassword
+func (m *Mpesa) BusinessPayBill(
+	ctx context.Context, initiatorPwd string, req BusinessPayBillRequest,
+) (*AsyncResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, initiatorPwd)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := validateURL(req.QueueTimeOutURL); err != nil {
-		return nil, err
+	if req.Initiator == "" {
+		req.Initiator = initiator.Name
 	}
+	m.defaultShortCode(&req.PartyA)
+	m.defaultResultURLs(&req.ResultURL, &req.QueueTimeOutURL)
 
-	if err := validateURL(req.ResultURL); err != nil {
+	if err := validate(req); err != nil {
 		return nil, err
 	}
 
-	securityCredential, err := m.generateSecurityCredentials(initiatorPwd)
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	req.SecurityCredential = securityCredential
 	req.CommandID = BusinessPayBillCommandID
 	req.RecieverIdentifierType = ShortcodeIdentifierType
 	req.SenderIdentifierType = ShortcodeIdentifierType
 
-	res, err := m.makeHttpRequestWithToken(ctx, http.MethodPost, m.endpointBusinessPayBill(), req)
+	key := IdempotencyKeyFromContext(ctx)
+	return m.idempotent(ctx, EndpointBusinessPayBill, key, req, func() (*AsyncResponse, error) {
+		req.SecurityCredential = securityCredential
+
+		return do[AsyncResponse](ctx, m, EndpointBusinessPayBill, req, false)
+	})
+}
+
+// BusinessBuyGoods API enables you to pay for goods and services directly from your business account to a till number
+// or a merchant store number. You can use this API to pay on behalf of a consumer/requester.
+//
+// The transaction moves money from your MMF/Working account to the recipient’s merchant account. The result is sent to
+// the ResultURL and can be decoded with UnmarshalCallback.
+func (m *Mpesa) BusinessBuyGoods(
+	ctx context.Context, initiatorPwd string, req BusinessBuyGoodsRequest,
+) (*AsyncResponse, error) {
+	initiator, err := m.initiatorCredentials(ctx, initiatorPwd)
 	if err != nil {
 		return nil, err
 	}
 
-	//goland:noinspection GoUnhandledErrorResult
-	defer res.Body.Close()
+	if req.Initiator == "" {
+		req.Initiator = initiator.Name
+	}
+	m.defaultShortCode(&req.PartyA)
+	m.defaultResultURLs(&req.ResultURL, &req.QueueTimeOutURL)
 
-	return decodeResponse(res)
-}
+	if err := validate(req); err != nil {
+		return nil, err
+	}
 
-func decodeResponse(res *http.Response) (*Response, error) {
-	var resp Response
-	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
-		return nil, fmt.Errorf("mpesa: decode response: %v", err)
+	securityCredential, err := m.GenerateSecurityCredential(initiator.Password)
+	if err != nil {
+		return nil, err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf(
-			"mpesa: request %v failed with code %v: %v", resp.RequestID, resp.ErrorCode, resp.ErrorMessage,
-		)
+	req.CommandID = BusinessBuyGoodsCommandID
+	req.SenderIdentifierType = ShortcodeIdentifierType
+	if req.RecieverIdentifierType == 0 {
+		req.RecieverIdentifierType = ShortcodeIdentifierType
 	}
 
-	return &resp, nil
+	key := IdempotencyKeyFromContext(ctx)
+	return m.idempotent(ctx, EndpointBusinessBuyGoods, key, req, func() (*AsyncResponse, error) {
+		req.SecurityCredential = securityCredential
+
+		return do[AsyncResponse](ctx, m, EndpointBusinessBuyGoods, req, false)
+	})
 }
@@ -1,16 +1,17 @@
 package mpesa
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"image/png"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -23,6 +24,12 @@ const (
 	testConsumerSecret = "MmE8/5EW3XXBIKg4qpDJ8g"
 )
 
+func TestEndpoint_URL(t *testing.T) {
+	require.Equal(t, "https://sandbox.safaricom.co.ke/mpesa/stkpush/v1/processrequest",
+		EndpointSTKPush.URL(EnvironmentSandbox))
+	require.Equal(t, "https://api.safaricom.co.ke/oauth/v1/generate", EndpointAuth.URL(EnvironmentProduction))
+}
+
 func TestMpesa_GenerateAccessToken(t *testing.T) {
 	ctx := context.Background()
 
@@ -44,12 +51,12 @@ func TestMpesa_GenerateAccessToken(t *testing.T) {
 				token, err := app.GenerateAccessToken(ctx)
 				require.NoError(t, err)
 				require.NotEmpty(t, token)
-				require.Equal(t, token, app.cache[testConsumerKey].AccessToken)
+				require.Equal(t, token, cachedAccessToken(t, app))
 
 				// Make subsequent call to get the token from the cache
 				token, err = app.GenerateAccessToken(ctx)
 				require.NoError(t, err)
-				require.Equal(t, token, app.cache[testConsumerKey].AccessToken)
+				require.Equal(t, token, cachedAccessToken(t, app))
 			},
 		},
 		{
@@ -81,12 +88,11 @@ func TestMpesa_GenerateAccessToken(t *testing.T) {
 				require.NoError(t, err)
 				require.NotEmpty(t, token)
 
-				gotCachedData := app.cache[testConsumerKey]
-				require.Equal(t, token, gotCachedData.AccessToken)
+				require.Equal(t, token, cachedAccessToken(t, app))
 
-				// Alter the time the cache was set to simulate an expired cache
-				gotCachedData.setAt = time.Now().Add(-1 * time.Hour)
-				app.cache[testConsumerKey] = gotCachedData
+				// Move the clock of the store forward to simulate an expired cache
+				store := app.tokens.(*MemoryTokenStore)
+				store.now = func() time.Time { return time.Now().Add(time.Hour) }
 
 				c.MockRequest(app.endpointAuth(), func() (status int, body string) {
 					return http.StatusOK, `
@@ -99,8 +105,32 @@ func TestMpesa_GenerateAccessToken(t *testing.T) {
 				// Make subsequent call to get the token from the cache
 				token, err = app.GenerateAccessToken(ctx)
 				require.NoError(t, err)
-				require.Equal(t, token, app.cache[testConsumerKey].AccessToken)
-				require.NotEqual(t, oldToken, app.cache[testConsumerKey].AccessToken)
+				require.Equal(t, token, cachedAccessToken(t, app))
+				require.NotEqual(t, oldToken, cachedAccessToken(t, app))
+			},
+		},
+		{
+			name: "it caches the access token until shortly before it expires",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointAuth(), func() (status int, body string) {
+					return http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "1800"}`
+				})
+
+				before := time.Now()
+
+				token, err := app.Token(ctx)
+				require.NoError(t, err)
+				require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token.Token)
+				require.WithinRange(t, token.ExpiresAt,
+					before.Add(30*time.Minute-accessTokenExpiryMargin),
+					time.Now().Add(30*time.Minute-accessTokenExpiryMargin),
+				)
+
+				// The cached token is returned with its expiry.
+				cached, err := app.Token(ctx)
+				require.NoError(t, err)
+				require.Equal(t, token.Token, cached.Token)
+				require.WithinDuration(t, token.ExpiresAt, cached.ExpiresAt, time.Second)
 			},
 		},
 		{
@@ -132,6 +162,23 @@ func TestMpesa_GenerateAccessToken(t *testing.T) {
 	}
 }
 
+func TestAuthorizationResponse_ttl(t *testing.T) {
+	tests := []struct {
+		expiresIn string
+		want      time.Duration
+	}{
+		{expiresIn: "3599", want: 3599*time.Second - accessTokenExpiryMargin},
+		{expiresIn: "300", want: 150 * time.Second},
+		{expiresIn: "", want: accessTokenTTL},
+		{expiresIn: "soon", want: accessTokenTTL},
+		{expiresIn: "0", want: accessTokenTTL},
+	}
+
+	for _, tc := range tests {
+		require.Equal(t, tc.want, AuthorizationResponse{ExpiresIn: tc.expiresIn}.ttl(), tc.expiresIn)
+	}
+}
+
 func TestMpesa_STKPush(t *testing.T) {
 
 	ctx := context.Background()
@@ -161,7 +208,7 @@ func TestMpesa_STKPush(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams STKPushRequest
@@ -195,7 +242,7 @@ func TestMpesa_STKPush(t *testing.T) {
 		{
 			name: "request fails with an error code",
 			stkReq: STKPushRequest{
-				BusinessShortCode: 0,
+				BusinessShortCode: 1743790,
 				TransactionType:   "CustomerPayBillOnline",
 				Amount:            10,
 				PartyA:            254708374149,
@@ -251,6 +298,69 @@ func TestMpesa_STKPush(t *testing.T) {
 	}
 }
 
+func TestMpesa_STKPushAuto(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		req  STKPushRequest
+		want TransactionType
+	}{
+		{
+			name: "it selects pay bill for the business shortcode",
+			req:  STKPushRequest{BusinessShortCode: 174379, PartyB: 174379},
+			want: CustomerPayBillOnlineTransactionType,
+		},
+		{
+			name: "it selects pay bill for the default shortcode",
+			req:  STKPushRequest{TransactionType: CustomerBuyGoodsOnlineTransactionType},
+			want: CustomerPayBillOnlineTransactionType,
+		},
+		{
+			name: "it selects buy goods for a till number",
+			req:  STKPushRequest{BusinessShortCode: 174379, PartyB: 5678901},
+			want: CustomerBuyGoodsOnlineTransactionType,
+		},
+		{
+			name: "it selects buy goods for a till number of the default shortcode",
+			req:  STKPushRequest{PartyB: 5678901, TransactionType: CustomerPayBillOnlineTransactionType},
+			want: CustomerBuyGoodsOnlineTransactionType,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				cl  = newMockHttpClient()
+				app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			)
+
+			app.RegisterShortcode("express", ShortcodeProfile{ShortCode: 174379})
+			app = app.WithShortcode("express")
+
+			mockAuth(app, cl)
+			cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+				var req STKPushRequest
+				require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+				require.Equal(t, tc.want, req.TransactionType)
+				return http.StatusOK, `{"ResponseCode": "0", "CheckoutRequestID": "ws_CO_1"}`
+			})
+
+			tc.req.Amount = 10
+			tc.req.PhoneNumber = 254708374149
+			tc.req.PartyA = 254708374149
+			tc.req.CallBackURL = "https://example.com"
+			tc.req.AccountReference = "Test"
+			tc.req.TransactionDesc = "Test"
+
+			res, err := app.STKPushAuto(ctx, "passkey", tc.req)
+			require.NoError(t, err)
+			require.Equal(t, "0", res.ResponseCode)
+		})
+	}
+}
+
 func TestUnmarshalSTKPushCallback(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -326,6 +436,93 @@ func TestUnmarshalSTKPushCallback(t *testing.T) {
 	}
 }
 
+func TestUnmarshalB2BExpressCheckoutCallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		assert func(t *testing.T, callback *B2BExpressCheckoutCallback)
+	}{
+		{
+			name: "it can unmarshal a successful payment callback",
+			input: `{
+				"resultCode": "0",
+				"resultDesc": "The service request is processed successfully.",
+				"amount": "71.0",
+				"requestId": "404e1aec-19e0-4ce3-973d-bd92e94c8021",
+				"resultType": "0",
+				"conversationID": "AG_20230426_2010434680d9f5a73766",
+				"transactionId": "RDQ01NFT1Q",
+				"status": "SUCCESS"
+			}`,
+			assert: func(t *testing.T, callback *B2BExpressCheckoutCallback) {
+				require.Equal(t, "0", callback.ResultCode)
+				require.Equal(t, "404e1aec-19e0-4ce3-973d-bd92e94c8021", callback.RequestID)
+				require.Equal(t, "RDQ01NFT1Q", callback.TransactionID)
+				require.Equal(t, "SUCCESS", callback.Status)
+			},
+		},
+		{
+			name: "it can unmarshal a cancelled payment callback",
+			input: `{
+				"resultCode": "4001",
+				"resultDesc": "User cancelled transaction",
+				"requestId": "c2a9ba32-9e11-4b90-892c-7bc54944609a",
+				"amount": "71.0",
+				"paymentReference": "MAndbubry3hi"
+			}`,
+			assert: func(t *testing.T, callback *B2BExpressCheckoutCallback) {
+				require.Equal(t, "4001", callback.ResultCode)
+				require.Equal(t, "MAndbubry3hi", callback.PaymentReference)
+				require.Empty(t, callback.TransactionID)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			callback, err := UnmarshalB2BExpressCheckoutCallback(strings.NewReader(tc.input))
+			require.NoError(t, err)
+			tc.assert(t, callback)
+		})
+	}
+
+	_, err := UnmarshalB2BExpressCheckoutCallback(strings.NewReader(`{"resultCode":`))
+	require.ErrorContains(t, err, "mpesa: decode")
+}
+
+func TestUnmarshalQueueTimeoutCallback(t *testing.T) {
+	callback, err := UnmarshalQueueTimeoutCallback(strings.NewReader(`{
+		"Result": {
+			"ResultType": "1",
+			"ResultCode": "1",
+			"ResultDesc": "The service request timed out.",
+			"OriginatorConversationID": "10571-7910404-1",
+			"ConversationID": "AG_20191219_00004e48cf7e3533f581",
+			"ReferenceData": {
+				"ReferenceItem": {"Key": "QueueTimeoutURL", "Value": "https://internalsandbox.safaricom.co.ke/mpesa/"}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, QueueTimeoutResult{
+		ConversationID:           "AG_20191219_00004e48cf7e3533f581",
+		OriginatorConversationID: "10571-7910404-1",
+		ReferenceData: ReferenceData{ReferenceItem: ReferenceItem{
+			Key:   "QueueTimeoutURL",
+			Value: "https://internalsandbox.safaricom.co.ke/mpesa/",
+		}},
+		ResultCode: 1,
+		ResultDesc: "The service request timed out.",
+		ResultType: 1,
+	}, callback.Result)
+
+	_, err = UnmarshalQueueTimeoutCallback(strings.NewReader(`{"Result": {"ResultCode": "timeout"}}`))
+	require.ErrorContains(t, err, "mpesa: decode")
+}
+
 func TestMpesa_B2C(t *testing.T) {
 	var (
 		asserts = assert.New(t)
@@ -357,7 +554,7 @@ func TestMpesa_B2C(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams B2CRequest
@@ -419,6 +616,38 @@ func TestMpesa_B2C(t *testing.T) {
 				require.Contains(t, res.ResponseDescription, "Accept the service request successfully")
 			},
 		},
+		{
+			name: "it generates the originator conversation id",
+			b2cReq: B2CRequest{
+				InitiatorName:   "TestG2Init",
+				CommandID:       "BusinessPayment",
+				Amount:          10,
+				PartyA:          600123,
+				PartyB:          254728762287,
+				QueueTimeOutURL: "https://example.com",
+				ResultURL:       "https://example.com",
+			},
+			env: EnvironmentSandbox,
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, b2cReq B2CRequest) {
+				var originatorConversationID string
+
+				c.MockRequest(app.endpointB2C(), func() (status int, body string) {
+					var reqParams B2CRequest
+					require.NoError(t, json.NewDecoder(c.requests[1].Body).Decode(&reqParams))
+					require.Regexp(t,
+						`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`,
+						reqParams.OriginatorConversationID,
+					)
+					originatorConversationID = reqParams.OriginatorConversationID
+
+					return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+				})
+
+				res, err := app.B2C(ctx, "random-string", b2cReq)
+				require.NoError(t, err)
+				require.Equal(t, originatorConversationID, res.OriginatorConversationID)
+			},
+		},
 		{
 			name: "request fails with an error code",
 			b2cReq: B2CRequest{
@@ -641,7 +870,7 @@ func TestMpesa_STKPushQuery(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams STKQueryRequest
@@ -670,7 +899,7 @@ func TestMpesa_STKPushQuery(t *testing.T) {
 				res, err := app.STKQuery(ctx, passkey, stkReq)
 				require.NoError(t, err)
 				require.NotNil(t, res)
-				require.Contains(t, res.CustomerMessage, "Request accepted")
+				require.Contains(t, res.ResultDesc, "Request accepted")
 			},
 		},
 		{
@@ -737,15 +966,15 @@ func Test_RegisterC2BURL(t *testing.T) {
 			c2bRequest: RegisterC2BURLRequest{
 				ShortCode:       600638,
 				ResponseType:    "Completed",
-				ValidationURL:   "http://example.com/validate",
-				ConfirmationURL: "http://example.com/confirm",
+				ValidationURL:   "https://example.com/validate",
+				ConfirmationURL: "https://example.com/confirm",
 			},
 			mock: func(t *testing.T, ctx context.Context, app *Mpesa, c *mockHttpClient, c2bRequest RegisterC2BURLRequest) {
 				c.MockRequest(app.endpointC2BRegister(), func() (status int, body string) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams RegisterC2BURLRequest
@@ -772,15 +1001,15 @@ func Test_RegisterC2BURL(t *testing.T) {
 			c2bRequest: RegisterC2BURLRequest{
 				ShortCode:       200200,
 				ResponseType:    "Canceled",
-				ValidationURL:   "http://example.com/validate",
-				ConfirmationURL: "http://example.com/confirm",
+				ValidationURL:   "https://example.com/validate",
+				ConfirmationURL: "https://example.com/confirm",
 			},
 			mock: func(t *testing.T, ctx context.Context, app *Mpesa, c *mockHttpClient, c2bRequest RegisterC2BURLRequest) {
 				c.MockRequest(app.endpointC2BRegister(), func() (status int, body string) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams RegisterC2BURLRequest
@@ -801,6 +1030,47 @@ func Test_RegisterC2BURL(t *testing.T) {
 				require.Equal(t, res.ResponseDescription, "success")
 			},
 		},
+		{
+			name: "it should register the confirmation URL alone on v2",
+			env:  EnvironmentProduction,
+			c2bRequest: RegisterC2BURLRequest{
+				ShortCode:        200200,
+				ResponseType:     ResponseTypeComplete,
+				ConfirmationURL:  "https://example.com/confirm",
+				ConfirmationOnly: true,
+				APIVersion:       C2BRegisterAPIVersion2,
+			},
+			mock: func(t *testing.T, ctx context.Context, app *Mpesa, c *mockHttpClient, c2bRequest RegisterC2BURLRequest) {
+				c.MockRequest(app.endpointC2BRegisterV2(), func() (status int, body string) {
+					var reqParams RegisterC2BURLRequest
+					err := json.NewDecoder(c.requests[1].Body).Decode(&reqParams)
+					require.NoError(t, err)
+					require.Equal(t, "https://example.com/confirm", reqParams.ConfirmationURL)
+					require.Empty(t, reqParams.ValidationURL)
+
+					return http.StatusOK, `{"OriginatorCoversationID": "7619-37765134-1", "ResponseCode": "0", ` +
+						`"ResponseDescription": "success"}`
+				})
+
+				res, err := app.RegisterC2BURL(ctx, c2bRequest)
+				require.NoError(t, err)
+				require.Equal(t, "success", res.ResponseDescription)
+			},
+		},
+		{
+			name: "fail with insecure URLs",
+			c2bRequest: RegisterC2BURLRequest{
+				ShortCode:       600638,
+				ResponseType:    ResponseTypeComplete,
+				ConfirmationURL: "http://example.com/confirm",
+				ValidationURL:   "http://example.com/validate",
+			},
+			mock: func(t *testing.T, ctx context.Context, app *Mpesa, c *mockHttpClient, c2bRequest RegisterC2BURLRequest) {
+				_, err := app.RegisterC2BURL(ctx, c2bRequest)
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Len(t, c.requests, 0)
+			},
+		},
 		{
 			name: "fail with invalid response type",
 			c2bRequest: RegisterC2BURLRequest{
@@ -808,9 +1078,13 @@ func Test_RegisterC2BURL(t *testing.T) {
 			},
 			mock: func(t *testing.T, ctx context.Context, app *Mpesa, c *mockHttpClient, c2bRequest RegisterC2BURLRequest) {
 				res, err := app.RegisterC2BURL(ctx, c2bRequest)
-				require.Error(t, err)
-				require.Equal(t, err.Error(), "mpesa: the provided ResponseType [Foo] is not valid")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, ValidationErrors(err), FieldError{
+					Field:   "ResponseType",
+					Message: "must be Completed or Canceled",
+				})
 				require.Nil(t, res)
+				require.Len(t, c.requests, 0)
 			},
 		},
 	}
@@ -845,6 +1119,7 @@ func TestMpesa_DynamicQR(t *testing.T) {
 
 	tests := []struct {
 		name string
+		opts []Option
 		mock func(app *Mpesa, c *mockHttpClient, qrReq DynamicQRRequest)
 	}{
 		{
@@ -854,7 +1129,7 @@ func TestMpesa_DynamicQR(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					return http.StatusOK, `
@@ -878,7 +1153,7 @@ func TestMpesa_DynamicQR(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					return http.StatusOK, `
@@ -893,27 +1168,50 @@ func TestMpesa_DynamicQR(t *testing.T) {
 				require.NoError(t, err)
 				require.NotNil(t, resp)
 
-				defer func() {
-					err = os.Remove(resp.ImagePath)
-					require.NoError(t, err)
-				}()
-
 				require.Equal(t, "00", resp.ResponseCode)
-				asserts.NotEmpty(resp.ImagePath)
+				asserts.Empty(resp.ImagePath)
 
-				wd, err := os.Getwd()
+				img, err := png.Decode(bytes.NewReader(resp.ImageData))
 				require.NoError(t, err)
+				require.Equal(t, 300, img.Bounds().Dx())
+			},
+		},
+		{
+			name: "it saves the decoded image to the qr image dir",
+			opts: []Option{WithQRImageDir(filepath.Join(t.TempDir(), "images"))},
+			mock: func(app *Mpesa, c *mockHttpClient, qrReq DynamicQRRequest) {
+				c.MockRequest(app.endpointDynamicQR(), func() (status int, body string) {
+					return http.StatusOK, `{"ResponseCode": "00", "QRCode": "iVBORw0KGgoAAAANSUhEUgAAASwAAAEsCAYAAAB5fY51AAAJXElEQVR42u3dyZLbMAwFwPn/n55cU1nsIQXQANivSqfYMhewLSmy5utbRKRJvgyBiABLRARYIgIsERFgiYgAS0SAJSICLBERYIkIsOTPwfr6+msT4ybAsvCMmwiwLDzjJsCy8MS4CbAEWCLAEmAJsG5eeD9ZmKv//r/F/a/XrYDw6j07nx/1WU/2lbHPJ/MBbmC1A+vda56+fxetlf38vr+dz9z9rBMwRLUNWMBqeWrz5Nt6d/9RCz9ii/ysbBiqtkuAVRas1dOPHYR2X7vT3hVko5CJBCtyPIEFrOvBimhj5AI7CUjVNgELWKPAenf9Z/XbPOJaFrCABSxgvXzdKlgdLhIDC1jAGgjWyili1AV3YAELWMAqBZZrWMACljwq3ncYAQtYwALWKLB2Pr/TKWHkbQ07t1qcGM/ddgmwyoC1+7pp17BO3/SaPZ6R7RJgjQAre7FWAmt1HiogAyxgtQUr87pGpR/rZv9cKXMMqu9LgHUULDHnAqwyhat4Z8wrrIA1HirFO39+zTmwgCVtwRJguYYh5dESYImIAEtEgFX2usK0LWp8Ou7nZG2ow56nwcACFrDUIbCABSxgAQtYCgVYwAIWsIAFLHUILGABC1jAAhawgAUsYAELWMBSh8ACFrCABaxLweqYjgu7I8TqcG6/gAUsYKlDYCkUYAFLHQJLoQBLHQLLgAILWOoQWAoFWMCyvoClUIClDoFlQIEFLHUILIUCLGBZX8AqsWhv7tfJNqvDeV8MwAIWsNQhsBQKsIClDoGlUIAFLGABC1jAUofAUijAAhawgKVQgAUsYAELWMBSh8BSKMACFrCApVD0C1jAAhawgKUOgaVQ9AtYwAKWQtEvYAELWMAClvkClkLRL2ABC1gKRb+ABSxgAQtY5gtYCkW/gAUsYCmUqXeo37wfYAELWMACFrCABSxgqUNgKRRgAQtYwAIWsIAFLGABC1jAApZCARawgAUsYAELWMACFrCABSxgNRzQTv3q+NjiqV8M1hew9AtYwAKWAQUWsNQhsBQKsIBlfQFLv4AFLGAZUGABSx0CS6EAC1jWF7D0C1jAApYBBRaw1CGwLtw6Lkh3qN9Zh8BSKIAAFrCABSz7ARawgAUsYAELWAoFEMACFrCAZT/AAhawgAUsYAFLoQACWMACFrDsRx0CSxoFWNKuZg0BsIAlwBJgAUuAJcACFrAEWMASYAmwgCXAEmABC1gCLGAJsARYwJJ5YE2969ed3Hfe7T31lxLAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxglZrgap9VrZg6juHNi3/644+BBSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAsmg/vGg7LpKpOAqwgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAetasBRlnTGs9iWkVucCCixgAUutAksRAAtYahVYigBYahVYigBYwFKrwFIEwAKWWgWWIgCWWgWWIgAWsNQqsBQBsIClVoFV4hG3HYGYOobaAyxgAQtY2gMsiw1YgAAWsIAFLO0BFrCABSztAZbFBixAAAtYwAKW9gALWMACFrCABSxjCAhgNQKrGo4n99OxXx3hm1ob0x/HDCxgAQtYwAIWsEADLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAuhQsxXSmPTfDV22cp9/FDixgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAuhSsmzFyl3avRwlPRRZYwAIWsIAFLGAZQ2ABC1jAAhawgGWxAQtYwAIWsIwhsIAFLGABC1jAstiABSxgAQtYxhBYwOrXuYZ3cnu0sc2d7sACFrBswAIWsIAFLGABC1g2YAELWMACFrCABSxgAQtYwAKWDVjAAhawgAUsYAELWMACloQDYXx6fXGad2BZkMYHWMASYBkfYAFLgAUsYIkFaXyABSwBFrCABSwLEljAApZYkMYHWMASYAELWCPBchfynYhMfWzx1F9TAAtYwAIWsIAFLHMKLGABC1jAAhawgAUsYAELWMAyp8ACFrCABSxgAQtYwAIWsIAFLGABC1jHinvqou0IxFSw1DOwgAUsYAELWMACFrCABSxgAUs9AwtYwAIWsIAFLGABC1gmGFjAUs/AAhawgEUiYAELWMACVqMJnnrn9Mm+d2zPzb+CmI4jsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWNeB5VHLd/5aoOMXFbCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAGtavqUBM/YVD6y8sYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsCwBYwAIWsIAFLGABC1jAMl/AAlabwb74UctTkZ0678ACFrCABSxgAQtYwAIWsBQusIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAKWeQdWqQmeunUEa+zi8EsAYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsEREgCUiAiwRAZaICLBERIAlIsASEQGWiAiwRARYIiLAEhEBlogAS0QEWCICLBERYImIAEtEgCUiAiwREWBNnJQHz9rOfoZ31rPoP9Gv255RDyw5BlVnsCLaEtmv7n+IAVgCrMQjpEpgTfnrMcCSMmC9WlxZKD6F8N17s0/LnoC18u8CLFlYhJ/YZ/aRSBZYu58DLGBJAbAijq5OtesTR1eOsoAlxcFafU8XsE68ToAlSQsn4mL5qXZl9QtYwJLGYK2eCj5tV8a1sJV9AAtY0gCsyKOrTLCy+/WT17u9AVjSDKyoi9c7R1jVbmIFFrCkAFg7p4KnTlWfABgJJ7CAJYfBOnkUcuI6VNSp26v3AwtYUgisjCOQCm3MGncBlhwA6/TvEU+BlXna5ugKWFIIrNPtivifu8h+Rdz2IMCS79iLwVWOQqo9w8rFdmBJE7A+ceQXDUTWaTKogCUfAit7EZ5+PE10v0DVN78A4PhWMY/tjp0AAAAASUVORK5CYII="}`
+				})
 
-				imagesDir := filepath.Join(wd, "storage", "images")
-				amountStr := strconv.Itoa(int(qrReq.Amount))
-
-				wantFilename := qrReq.MerchantName + "_" + amountStr + "_" + qrReq.CreditPartyIdentifier + ".png"
-				wantFilename = imagesDir + "/" + strings.ReplaceAll(wantFilename, " ", "_")
+				resp, err := app.DynamicQR(ctx, qrReq, PayMerchantBuyGoods, true)
+				require.NoError(t, err)
+				require.NotNil(t, resp)
 
+				wantFilename := filepath.Join(app.qrImageDir, "jwambugu_10_111222.png")
 				require.Equal(t, wantFilename, resp.ImagePath)
 
-				_, err = os.Stat(resp.ImagePath)
+				b, err := os.ReadFile(resp.ImagePath)
+				require.NoError(t, err)
+				require.Equal(t, resp.ImageData, b)
+			},
+		},
+		{
+			name: "it normalizes the phone number of send money requests",
+			mock: func(app *Mpesa, c *mockHttpClient, qrReq DynamicQRRequest) {
+				c.MockRequest(app.endpointDynamicQR(), func() (status int, body string) {
+					var req DynamicQRRequest
+					require.NoError(t, json.NewDecoder(c.requests[1].Body).Decode(&req))
+					require.Equal(t, "254712345678", req.CreditPartyIdentifier)
+
+					return http.StatusOK, `{"ResponseCode": "00", "QRCode": ""}`
+				})
+
+				qrReq.CreditPartyIdentifier = "+254 712 345 678"
+
+				resp, err := app.DynamicQR(ctx, qrReq, SendMoneyViaMobileNumber, false)
 				require.NoError(t, err)
+				require.Equal(t, "00", resp.ResponseCode)
 			},
 		},
 		{
@@ -923,7 +1221,7 @@ func TestMpesa_DynamicQR(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					return http.StatusBadRequest, `
@@ -948,7 +1246,7 @@ func TestMpesa_DynamicQR(t *testing.T) {
 
 			var (
 				cl  = newMockHttpClient()
-				app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+				app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, tc.opts...)
 			)
 
 			cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
@@ -967,7 +1265,7 @@ func TestMpesa_DynamicQR(t *testing.T) {
 				CreditPartyIdentifier: "111222",
 				MerchantName:          "jwambugu",
 				ReferenceNo:           "NULLABLE",
-				Size:                  "500",
+				Size:                  500,
 			})
 		})
 	}
@@ -1003,7 +1301,7 @@ func TestMpesa_GetTransactionStatus(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams TransactionStatusRequest
@@ -1011,6 +1309,7 @@ func TestMpesa_GetTransactionStatus(t *testing.T) {
 					err := json.NewDecoder(req.Body).Decode(&reqParams)
 					require.NoError(t, err)
 					require.NotEmpty(t, reqParams.SecurityCredential) // TODO: verify the security credential
+					require.Equal(t, ShortcodeIdentifierType, reqParams.IdentifierType)
 
 					return http.StatusOK, `{
 						"OriginatorConversationID": "2ba8-4165-beca-292db11f9ef878061",
@@ -1027,6 +1326,35 @@ func TestMpesa_GetTransactionStatus(t *testing.T) {
 			},
 			requestsCount: 2,
 		},
+		{
+			name: "it sends the command and identifier type set on the request",
+			env:  EnvironmentSandbox,
+			txnStatusReq: TransactionStatusRequest{
+				CommandID:       "CustomTransactionStatusQuery",
+				IdentifierType:  MSISDNIdentifierType,
+				Initiator:       "testapi",
+				PartyA:          254708374149,
+				QueueTimeOutURL: "https://example.com/",
+				ResultURL:       "https://example.com/",
+				TransactionID:   "SAM62HFIRW",
+			},
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, txnStatusReq TransactionStatusRequest) {
+				c.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+					var reqParams TransactionStatusRequest
+
+					err := json.NewDecoder(c.requests[1].Body).Decode(&reqParams)
+					require.NoError(t, err)
+					require.Equal(t, CommandID("CustomTransactionStatusQuery"), reqParams.CommandID)
+					require.Equal(t, MSISDNIdentifierType, reqParams.IdentifierType)
+
+					return http.StatusOK, `{"ConversationID": "AG_20240122_2010332bae9191b3d522", "ResponseCode": "0"}`
+				})
+
+				_, err := app.GetTransactionStatus(ctx, initatorPassword, txnStatusReq)
+				require.NoError(t, err)
+			},
+			requestsCount: 2,
+		},
 		{
 			name: "it generates valid security credentials and makes the request successfully on production",
 			env:  EnvironmentProduction,
@@ -1044,7 +1372,7 @@ func TestMpesa_GetTransactionStatus(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams TransactionStatusRequest
@@ -1084,7 +1412,8 @@ func TestMpesa_GetTransactionStatus(t *testing.T) {
 			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, txnStatusReq TransactionStatusRequest) {
 				res, err := app.GetTransactionStatus(ctx, initatorPassword, txnStatusReq)
 				require.NotNil(t, err)
-				require.Contains(t, err.Error(), "must use \"https\"")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "must be a valid https URL")
 				require.Nil(t, res)
 			},
 			requestsCount: 1,
@@ -1098,7 +1427,8 @@ func TestMpesa_GetTransactionStatus(t *testing.T) {
 			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, txnStatusReq TransactionStatusRequest) {
 				res, err := app.GetTransactionStatus(ctx, initatorPassword, txnStatusReq)
 				require.NotNil(t, err)
-				require.Contains(t, err.Error(), "must use \"https\"")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "must be a valid https URL")
 				require.Nil(t, res)
 			},
 			requestsCount: 1,
@@ -1187,7 +1517,7 @@ func TestMpesa_GetAccountBalance(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams AccountBalanceRequest
@@ -1195,6 +1525,7 @@ func TestMpesa_GetAccountBalance(t *testing.T) {
 					err := json.NewDecoder(req.Body).Decode(&reqParams)
 					require.NoError(t, err)
 					require.NotEmpty(t, reqParams.SecurityCredential) // TODO: verify the security credential
+					require.Equal(t, ShortcodeIdentifierType, reqParams.IdentifierType)
 
 					return http.StatusOK, `{
 						"OriginatorConversationID": "2ba8-4165-beca-292db11f9ef878061",
@@ -1211,6 +1542,34 @@ func TestMpesa_GetAccountBalance(t *testing.T) {
 			},
 			requestsCount: 2,
 		},
+		{
+			name: "it sends the command and identifier type set on the request",
+			env:  EnvironmentSandbox,
+			accountBalanceReq: AccountBalanceRequest{
+				CommandID:       "AccountBalanceQuery",
+				IdentifierType:  TillNumberIdentifierType,
+				Initiator:       "testapi",
+				PartyA:          600981,
+				QueueTimeOutURL: "https://example.com",
+				ResultURL:       "https://example.com",
+			},
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, accountBalanceReq AccountBalanceRequest) {
+				c.MockRequest(app.endpointAccountBalance(), func() (status int, body string) {
+					var reqParams AccountBalanceRequest
+
+					err := json.NewDecoder(c.requests[1].Body).Decode(&reqParams)
+					require.NoError(t, err)
+					require.Equal(t, CommandID("AccountBalanceQuery"), reqParams.CommandID)
+					require.Equal(t, TillNumberIdentifierType, reqParams.IdentifierType)
+
+					return http.StatusOK, `{"ConversationID": "AG_20240122_2010332bae9191b3d522", "ResponseCode": "0"}`
+				})
+
+				_, err := app.GetAccountBalance(ctx, initatorPassword, accountBalanceReq)
+				require.NoError(t, err)
+			},
+			requestsCount: 2,
+		},
 		{
 			name: "generates valid security credentials and makes the request successfully on production",
 			env:  EnvironmentProduction,
@@ -1226,7 +1585,7 @@ func TestMpesa_GetAccountBalance(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams AccountBalanceRequest
@@ -1266,7 +1625,8 @@ func TestMpesa_GetAccountBalance(t *testing.T) {
 			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, accountBalanceReq AccountBalanceRequest) {
 				res, err := app.GetAccountBalance(ctx, initatorPassword, accountBalanceReq)
 				require.NotNil(t, err)
-				require.Contains(t, err.Error(), "must use \"https\"")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "must be a valid https URL")
 				require.Nil(t, res)
 			},
 			requestsCount: 1,
@@ -1280,7 +1640,8 @@ func TestMpesa_GetAccountBalance(t *testing.T) {
 			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, accountBalanceReq AccountBalanceRequest) {
 				res, err := app.GetAccountBalance(ctx, initatorPassword, accountBalanceReq)
 				require.NotNil(t, err)
-				require.Contains(t, err.Error(), "must use \"https\"")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "must be a valid https URL")
 				require.Nil(t, res)
 			},
 			requestsCount: 1,
@@ -1373,7 +1734,7 @@ func TestMpesa_BusinessPayBill(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams BusinessPayBillRequest
@@ -1409,7 +1770,7 @@ func TestMpesa_BusinessPayBill(t *testing.T) {
 					req := c.requests[1]
 
 					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
-					wantAuthorizationHeader := `Bearer ` + app.cache[testConsumerKey].AccessToken
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
 					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
 
 					var reqParams BusinessPayBillRequest
@@ -1448,8 +1809,8 @@ func TestMpesa_BusinessPayBill(t *testing.T) {
 			businesPaybillReq: BusinessPayBillRequest{QueueTimeOutURL: "http://example.com"},
 			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, businesPaybillReq BusinessPayBillRequest) {
 				res, err := app.BusinessPayBill(ctx, initatorPassword, businesPaybillReq)
-				require.NotNil(t, err)
-				require.Contains(t, err.Error(), "must use \"https\"")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "QueueTimeOutURL must be a valid https URL")
 				require.Nil(t, res)
 			},
 			requestsCount: 1,
@@ -1462,8 +1823,9 @@ func TestMpesa_BusinessPayBill(t *testing.T) {
 			},
 			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, businesPaybillReq BusinessPayBillRequest) {
 				res, err := app.BusinessPayBill(ctx, initatorPassword, businesPaybillReq)
-				require.NotNil(t, err)
-				require.Contains(t, err.Error(), "must use \"https\"")
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "ResultURL must be a valid https URL")
+				require.NotContains(t, err.Error(), "QueueTimeOutURL")
 				require.Nil(t, res)
 			},
 			requestsCount: 1,
@@ -1515,3 +1877,210 @@ func TestMpesa_BusinessPayBill(t *testing.T) {
 		})
 	}
 }
+
+func TestMpesa_BusinessBuyGoods(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		buyGoodsReq = BusinessBuyGoodsRequest{
+			AccountReference: "353353",
+			Amount:           10,
+			Initiator:        "testapi",
+			PartyA:           600992,
+			PartyB:           600000,
+			QueueTimeOutURL:  "https://example.com/timeout",
+			Remarks:          "Test remarks",
+			Requester:        254700000000,
+			ResultURL:        "https://example.com/result",
+		}
+	)
+
+	tests := []struct {
+		name          string
+		req           BusinessBuyGoodsRequest
+		initiatorPwd  string
+		wantReceiver  IdentifierType
+		wantErr       error
+		requestsCount int
+	}{
+		{
+			name:          "it pays a merchant store number",
+			req:           buyGoodsReq,
+			initiatorPwd:  "random-string",
+			wantReceiver:  ShortcodeIdentifierType,
+			requestsCount: 2,
+		},
+		{
+			name: "it pays a till number",
+			req: func() BusinessBuyGoodsRequest {
+				req := buyGoodsReq
+				req.RecieverIdentifierType = MerchantTillIdentifierType
+				return req
+			}(),
+			initiatorPwd:  "random-string",
+			wantReceiver:  MerchantTillIdentifierType,
+			requestsCount: 2,
+		},
+		{
+			name:    "request fails if no initiator password is provided",
+			req:     buyGoodsReq,
+			wantErr: ErrInvalidInitiatorPassword,
+		},
+		{
+			name:         "request fails if the request is invalid",
+			req:          BusinessBuyGoodsRequest{Amount: 10, PartyA: 600992, PartyB: 600000},
+			initiatorPwd: "random-string",
+			wantErr:      ErrInvalidRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				cl  = newMockHttpClient()
+				app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			)
+
+			mockAuth(app, cl)
+
+			cl.MockRequest(app.endpointBusinessBuyGoods(), func() (status int, body string) {
+				var reqParams BusinessBuyGoodsRequest
+				require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&reqParams))
+				require.NotEmpty(t, reqParams.SecurityCredential)
+				require.Equal(t, BusinessBuyGoodsCommandID, reqParams.CommandID)
+				require.Equal(t, ShortcodeIdentifierType, reqParams.SenderIdentifierType)
+				require.Equal(t, tc.wantReceiver, reqParams.RecieverIdentifierType)
+				require.Equal(t, int64(254700000000), reqParams.Requester)
+
+				return http.StatusOK, `{
+					"OriginatorConversationID": "5118-111210482-1",
+					"ConversationID": "AG_20230420_2010759fd5662ef6d054",
+					"ResponseCode": "0",
+					"ResponseDescription": "Accept the service request successfully."
+				}`
+			})
+
+			res, err := app.BusinessBuyGoods(ctx, tc.initiatorPwd, tc.req)
+			require.Len(t, cl.requests, tc.requestsCount)
+
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				require.Nil(t, res)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "AG_20230420_2010759fd5662ef6d054", res.ConversationID)
+		})
+	}
+}
+
+func TestMpesa_Reversal(t *testing.T) {
+	var (
+		ctx              = context.Background()
+		initatorPassword = "random-string"
+	)
+
+	tests := []struct {
+		name          string
+		reversalReq   ReversalRequest
+		mock          func(t *testing.T, app *Mpesa, c *mockHttpClient, reversalReq ReversalRequest)
+		requestsCount int
+	}{
+		{
+			name: "it generates valid security credentials and makes the request successfully",
+			reversalReq: ReversalRequest{
+				Amount:          10,
+				Initiator:       "testapi",
+				QueueTimeOutURL: "https://example.com/",
+				ReceiverParty:   600426,
+				Remarks:         "Test remarks",
+				ResultURL:       "https://example.com/",
+				TransactionID:   "SAM62HFIRW",
+			},
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, reversalReq ReversalRequest) {
+				c.MockRequest(app.endpointReversal(), func() (status int, body string) {
+					req := c.requests[1]
+
+					require.Equal(t, "application/json", req.Header.Get("Content-Type"))
+					wantAuthorizationHeader := `Bearer ` + cachedAccessToken(t, app)
+					require.Equal(t, wantAuthorizationHeader, req.Header.Get("Authorization"))
+
+					var reqParams ReversalRequest
+
+					err := json.NewDecoder(req.Body).Decode(&reqParams)
+					require.NoError(t, err)
+					require.NotEmpty(t, reqParams.SecurityCredential)
+					require.Equal(t, TransactionReversalCommandID, reqParams.CommandID)
+					require.Equal(t, ReversalIdentifierType, reqParams.RecieverIdentifierType)
+					require.Equal(t, "SAM62HFIRW", reqParams.TransactionID)
+
+					return http.StatusOK, `{
+						"OriginatorConversationID": "f1e2-4b95-a71d-b30d3cdbb7a7735297",
+						"ConversationID": "AG_20210706_20106e9209f64bebd05b",
+						"ResponseCode": "0",
+						"ResponseDescription": "Accept the service request successfully."
+					}`
+				})
+
+				res, err := app.Reversal(ctx, initatorPassword, reversalReq)
+				require.NoError(t, err)
+				require.NotNil(t, res)
+				require.Equal(t, "AG_20210706_20106e9209f64bebd05b", res.ConversationID)
+			},
+			requestsCount: 2,
+		},
+		{
+			name: "request fails if no initiator password is provided",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, reversalReq ReversalRequest) {
+				res, err := app.Reversal(ctx, "", reversalReq)
+				require.NotNil(t, err)
+				require.EqualError(t, err, ErrInvalidInitiatorPassword.Error())
+				require.Nil(t, res)
+			},
+			requestsCount: 1,
+		},
+		{
+			name: "request fails if invalid result URL is passed",
+			reversalReq: ReversalRequest{
+				QueueTimeOutURL: "https://example.com",
+				ResultURL:       "http://example.com",
+			},
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient, reversalReq ReversalRequest) {
+				res, err := app.Reversal(ctx, initatorPassword, reversalReq)
+				require.NotNil(t, err)
+				require.ErrorIs(t, err, ErrInvalidRequest)
+				require.Contains(t, err.Error(), "must be a valid https URL")
+				require.Nil(t, res)
+			},
+			requestsCount: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				cl  = newMockHttpClient()
+				app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			)
+
+			cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+				return http.StatusOK, `
+				{
+					"access_token": "0A0v8OgxqqoocblflR58m9chMdnU",
+					"expires_in": "3599"
+				}`
+			})
+
+			tc.mock(t, app, cl, tc.reversalReq)
+			_, err := app.GenerateAccessToken(ctx)
+			require.NoError(t, err)
+			require.Len(t, cl.requests, tc.requestsCount)
+		})
+	}
+}
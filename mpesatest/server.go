@@ -0,0 +1,449 @@
+// Package mpesatest provides an in-process fake of the Daraja API for the end-to-end tests of applications that use
+// the SDK.
+//
+//	srv := mpesatest.NewServer(mpesatest.WithCallbackHandler(mux))
+//	defer srv.Close()
+//
+//	app := mpesa.NewApp(srv.Client(), "CONSUMER_KEY", "CONSUMER_SECRET", mpesa.EnvironmentSandbox)
+package mpesatest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+)
+
+// Error codes returned by the Server.
+const (
+	errorCodeInvalidRequest     = "400.002.02"
+	errorCodeInvalidCredentials = "400.008.01"
+)
+
+// accessToken is the access token issued by the Server.
+const accessToken = "mpesatest-access-token"
+
+// stkResultDescs are the descriptions of the STK push result codes.
+var stkResultDescs = map[int]string{
+	0:    "The service request is processed successfully.",
+	1:    "The balance is insufficient for the transaction.",
+	1032: "Request cancelled by user",
+	1037: "DS timeout user cannot be reached",
+	2001: "The initiator information is invalid.",
+}
+
+var errNoCallbackHandler = errors.New("mpesatest: no callback handler set")
+
+// eastAfricaTime is the time zone M-Pesa reports transaction times in.
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// Server is a fake Daraja API. It implements the auth, STK push, STK query, B2C and C2B register endpoints and sends
+// the callbacks of the requests to the handler set with WithCallbackHandler.
+type Server struct {
+	srv       *httptest.Server
+	callbacks http.Handler
+
+	consumerKey    string
+	consumerSecret string
+	stkResultCode  int
+
+	mu      sync.Mutex
+	seq     int
+	stk     map[string]mpesa.STKCallback
+	c2bURLs map[string]mpesa.RegisterC2BURLRequest
+	pending sync.WaitGroup
+}
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithCallbackHandler sets the handler that receives the callbacks, e.g. the http.ServeMux of the application. The
+// requests are sent to the URL set on the API request, so the handler routes them as it would in production.
+func WithCallbackHandler(h http.Handler) Option {
+	return func(s *Server) {
+		s.callbacks = h
+	}
+}
+
+// WithCredentials makes the Server reject the access token requests that are not made with the consumer key and
+// secret. Any credentials are accepted by default.
+func WithCredentials(consumerKey, consumerSecret string) Option {
+	return func(s *Server) {
+		s.consumerKey, s.consumerSecret = consumerKey, consumerSecret
+	}
+}
+
+// WithSTKResultCode sets the ResultCode of the STK push callbacks and queries, e.g. 1032 for a request cancelled by
+// the customer. Defaults to 0.
+func WithSTKResultCode(code int) Option {
+	return func(s *Server) {
+		s.stkResultCode = code
+	}
+}
+
+// NewServer starts a Server. It must be closed with Close.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		stk:     make(map[string]mpesa.STKCallback),
+		c2bURLs: make(map[string]mpesa.RegisterC2BURLRequest),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(mpesa.EndpointAuth.Path(), s.handleAuth)
+	mux.HandleFunc(mpesa.EndpointSTKPush.Path(), s.authorized(s.handleSTKPush))
+	mux.HandleFunc(mpesa.EndpointSTKQuery.Path(), s.authorized(s.handleSTKQuery))
+	mux.HandleFunc(mpesa.EndpointB2C.Path(), s.authorized(s.handleB2C))
+	mux.HandleFunc(mpesa.EndpointC2BRegister.Path(), s.authorized(s.handleC2BRegister))
+	mux.HandleFunc(mpesa.EndpointC2BRegisterV2.Path(), s.authorized(s.handleC2BRegister))
+
+	s.srv = httptest.NewServer(mux)
+	return s
+}
+
+// URL returns the base URL of the Server.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns an HTTP client that sends the requests made to the Daraja API to the Server. Pass it to mpesa.NewApp.
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.srv.URL)
+
+	return &http.Client{
+		Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.URL.Scheme, req.URL.Host, req.Host = target.Scheme, target.Host, ""
+			return s.srv.Client().Transport.RoundTrip(req)
+		}),
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Wait blocks until the pending callbacks are handled.
+func (s *Server) Wait() {
+	s.pending.Wait()
+}
+
+// Close waits for the pending callbacks and shuts down the Server.
+func (s *Server) Close() {
+	s.Wait()
+	s.srv.Close()
+}
+
+// SimulateC2B makes the payment to the shortcode whose URLs were registered. The payment is sent to the ValidationURL
+// and, if it is accepted, to the ConfirmationURL. The ID of the transaction is returned, or a *mpesa.C2BRejection if the
+// payment was rejected.
+func (s *Server) SimulateC2B(payment mpesa.C2BCallback) (string, error) {
+	if s.callbacks == nil {
+		return "", errNoCallbackHandler
+	}
+
+	s.mu.Lock()
+	urls, ok := s.c2bURLs[payment.BusinessShortCode]
+	s.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("mpesatest: no URLs registered for shortcode %s", payment.BusinessShortCode)
+	}
+
+	if payment.TransID == "" {
+		payment.TransID = s.receipt()
+	}
+	if payment.TransTime == "" {
+		payment.TransTime = time.Now().In(eastAfricaTime).Format("20060102150405")
+	}
+	if payment.TransactionType == "" {
+		payment.TransactionType = "Pay Bill"
+	}
+
+	// As on Daraja, the ResponseType of the URLs decides the payment if the validation request fails.
+	if urls.ValidationURL != "" {
+		var resp mpesa.C2BValidationResponse
+
+		rec, err := s.send(urls.ValidationURL, payment)
+		if err == nil {
+			err = json.NewDecoder(rec.Body).Decode(&resp)
+		}
+
+		switch {
+		case err != nil && urls.ResponseType != mpesa.ResponseTypeComplete:
+			return "", &mpesa.C2BRejection{Code: mpesa.C2BRejectOtherError, Desc: err.Error()}
+		case err == nil && resp.ResultCode != mpesa.C2BAccepted:
+			return "", &mpesa.C2BRejection{Code: resp.ResultCode, Desc: resp.ResultDesc}
+		}
+
+		payment.ThirdPartyTransID = resp.ThirdPartyTransID
+	}
+
+	if _, err := s.send(urls.ConfirmationURL, payment); err != nil {
+		return "", err
+	}
+
+	return payment.TransID, nil
+}
+
+func (s *Server) handleAuth(w http.ResponseWriter, r *http.Request) {
+	key, secret, ok := r.BasicAuth()
+	if !ok || (s.consumerKey != "" && (key != s.consumerKey || secret != s.consumerSecret)) {
+		writeError(w, http.StatusBadRequest, errorCodeInvalidCredentials, "Invalid Authentication passed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": accessToken, "expires_in": "3599"})
+}
+
+// authorized rejects the requests that are not made with the access token issued by the Server.
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+accessToken {
+			writeError(w, http.StatusUnauthorized, mpesa.ErrorCodeInvalidAccessToken, "Invalid Access Token")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) handleSTKPush(w http.ResponseWriter, r *http.Request) {
+	var req mpesa.STKPushRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(req.Password); err != nil || req.Timestamp == "" {
+		writeError(w, http.StatusBadRequest, errorCodeInvalidRequest, "Bad Request - Invalid Password")
+		return
+	}
+
+	n := s.next()
+	callback := mpesa.STKCallback{
+		MerchantRequestID: fmt.Sprintf("%d-%d-1", 29115+n, 34620561+n),
+		CheckoutRequestID: fmt.Sprintf("ws_CO_%s%06d", time.Now().In(eastAfricaTime).Format("020120061504"), n),
+		ResultCode:        s.stkResultCode,
+		ResultDesc:        stkResultDescs[s.stkResultCode],
+	}
+
+	if callback.ResultCode == 0 {
+		callback.CallbackMetadata.Item = []mpesa.STKCallbackItem{
+			{Name: mpesa.STKCallbackItemAmount, Value: req.Amount},
+			{Name: mpesa.STKCallbackItemMpesaReceiptNumber, Value: s.receipt()},
+			{Name: mpesa.STKCallbackItemTransactionDate, Value: transactionDate()},
+			{Name: mpesa.STKCallbackItemPhoneNumber, Value: req.PhoneNumber},
+		}
+	}
+
+	s.mu.Lock()
+	s.stk[callback.CheckoutRequestID] = callback
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, mpesa.STKPushResponse{
+		MerchantRequestID:   callback.MerchantRequestID,
+		CheckoutRequestID:   callback.CheckoutRequestID,
+		ResponseCode:        "0",
+		ResponseDescription: "Success. Request accepted for processing",
+		CustomerMessage:     "Success. Request accepted for processing",
+	})
+
+	s.sendAsync(req.CallBackURL, mpesa.STKPushCallback{Body: mpesa.STKPushCallbackBody{STKCallback: callback}})
+}
+
+func (s *Server) handleSTKQuery(w http.ResponseWriter, r *http.Request) {
+	var req mpesa.STKQueryRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	callback, ok := s.stk[req.CheckoutRequestID]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, errorCodeInvalidRequest, "Bad Request - Invalid CheckoutRequestID")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mpesa.STKQueryResponse{
+		MerchantRequestID:   callback.MerchantRequestID,
+		CheckoutRequestID:   callback.CheckoutRequestID,
+		ResponseCode:        "0",
+		ResponseDescription: "The service request has been accepted successsfully",
+		ResultCode:          strconv.Itoa(callback.ResultCode),
+		ResultDesc:          callback.ResultDesc,
+	})
+}
+
+func (s *Server) handleB2C(w http.ResponseWriter, r *http.Request) {
+	var req mpesa.B2CRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.InitiatorName == "" || req.SecurityCredential == "" {
+		writeError(w, http.StatusBadRequest, errorCodeInvalidRequest, "Bad Request - Invalid Initiator")
+		return
+	}
+
+	n, receipt := s.next(), s.receipt()
+	result := mpesa.CallbackResult{
+		ConversationID:           fmt.Sprintf("AG_%s_%020d", time.Now().Format("20060102"), n),
+		OriginatorConversationID: req.OriginatorConversationID,
+		ResultDesc:               stkResultDescs[0],
+		TransactionID:            receipt,
+		ResultParameters: mpesa.ResultParameters{
+			ResultParameter: []mpesa.ResultParameter{
+				{Key: mpesa.ResultParameterTransactionAmount, Value: req.Amount},
+				{Key: mpesa.ResultParameterTransactionReceipt, Value: receipt},
+				{Key: mpesa.ResultParameterReceiverPartyPublicName, Value: fmt.Sprintf("%d - John Doe", req.PartyB)},
+				{
+					Key:   mpesa.ResultParameterTransactionCompletedDateTime,
+					Value: time.Now().In(eastAfricaTime).Format("02.01.2006 15:04:05"),
+				},
+				{Key: mpesa.ResultParameterB2CRecipientIsRegisteredCustomer, Value: "Y"},
+			},
+		},
+	}
+
+	if result.OriginatorConversationID == "" {
+		result.OriginatorConversationID = fmt.Sprintf("%d-%d-1", 10571+n, 7082437+n)
+	}
+
+	writeJSON(w, http.StatusOK, mpesa.B2CResponse{
+		ConversationID:           result.ConversationID,
+		OriginatorConversationID: result.OriginatorConversationID,
+		ResponseCode:             "0",
+		ResponseDescription:      "Accept the service request successfully.",
+	})
+
+	s.sendAsync(req.ResultURL, mpesa.Callback{Result: result})
+}
+
+func (s *Server) handleC2BRegister(w http.ResponseWriter, r *http.Request) {
+	var req mpesa.RegisterC2BURLRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	s.c2bURLs[strconv.FormatUint(uint64(req.ShortCode), 10)] = req
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, mpesa.C2BRegisterResponse{
+		OriginatorConversationID: fmt.Sprintf("%d-%d-1", 7619+s.next(), 37765134),
+		ResponseCode:             "0",
+		ResponseDescription:      "Success",
+	})
+}
+
+// next returns the next sequence number used to generate the IDs of the requests.
+func (s *Server) next() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	return s.seq
+}
+
+// receipt returns a unique M-Pesa receipt number.
+func (s *Server) receipt() string {
+	return fmt.Sprintf("SIM%07d", s.next())
+}
+
+// sendAsync sends the callback once the response of the request is written, as Daraja does.
+func (s *Server) sendAsync(rawURL string, v interface{}) {
+	s.pending.Add(1)
+
+	go func() {
+		defer s.pending.Done()
+		_, _ = s.send(rawURL, v)
+	}()
+}
+
+// send sends v to the callback handler as a POST request to rawURL.
+func (s *Server) send(rawURL string, v interface{}) (*httptest.ResponseRecorder, error) {
+	if s.callbacks == nil {
+		return nil, errNoCallbackHandler
+	}
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("mpesatest: encode callback: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, rawURL, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rec := httptest.NewRecorder()
+	s.callbacks.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return rec, fmt.Errorf("mpesatest: callback to %s failed with status %d", rawURL, rec.Code)
+	}
+
+	return rec, nil
+}
+
+// decodeRequest decodes the body of the request to v and checks it using its Validate method. It writes an error
+// response and returns false if the request is invalid.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{ Validate() []error }) bool {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, errorCodeInvalidRequest, "Bad Request - Invalid Method")
+		return false
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, errorCodeInvalidRequest, "Bad Request - Invalid JSON")
+		return false
+	}
+
+	if errs := v.Validate(); len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, err := range errs {
+			msgs[i] = err.Error()
+		}
+
+		writeError(w, http.StatusBadRequest, errorCodeInvalidRequest, "Bad Request - "+strings.Join(msgs, "; "))
+		return false
+	}
+
+	return true
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, mpesa.Error{
+		RequestID:    fmt.Sprintf("mpesatest-%d", time.Now().UnixNano()),
+		ErrorCode:    code,
+		ErrorMessage: message,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// transactionDate returns the current time as the TransactionDate item of an STK callback.
+func transactionDate() int64 {
+	date, _ := strconv.ParseInt(time.Now().In(eastAfricaTime).Format("20060102150405"), 10, 64)
+	return date
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
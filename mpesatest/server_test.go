@@ -0,0 +1,170 @@
+package mpesatest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		sandbox = mpesa.SandboxDefaults()
+		mux     = http.NewServeMux()
+
+		stkCallbacks = make(chan *mpesa.STKPushCallback, 1)
+		b2cCallbacks = make(chan *mpesa.Callback, 1)
+		confirmed    = make(chan *mpesa.C2BConfirmationRequest, 1)
+	)
+
+	mux.HandleFunc("/stk", func(w http.ResponseWriter, r *http.Request) {
+		callback, err := mpesa.UnmarshalSTKPushCallback(r.Body)
+		require.NoError(t, err)
+		stkCallbacks <- callback
+		mpesa.WriteSuccessAcknowledgement(w)
+	})
+
+	mux.HandleFunc("/b2c/result", func(w http.ResponseWriter, r *http.Request) {
+		callback, err := mpesa.UnmarshalCallback(r.Body)
+		require.NoError(t, err)
+		b2cCallbacks <- callback
+		mpesa.WriteSuccessAcknowledgement(w)
+	})
+
+	mux.HandleFunc("/c2b/validation", func(w http.ResponseWriter, r *http.Request) {
+		payment, err := mpesa.UnmarshalC2BValidation(r.Body)
+		require.NoError(t, err)
+
+		code := mpesa.C2BAccepted
+		if payment.BillRefNumber != "INV-001" {
+			code = mpesa.C2BRejectInvalidAccountNumber
+		}
+		mpesa.WriteC2BValidationResult(w, code, "")
+	})
+
+	mux.HandleFunc("/c2b/confirmation", func(w http.ResponseWriter, r *http.Request) {
+		payment, err := mpesa.UnmarshalC2BConfirmation(r.Body)
+		require.NoError(t, err)
+		confirmed <- payment
+		mpesa.WriteSuccessAcknowledgement(w)
+	})
+
+	srv := NewServer(WithCallbackHandler(mux), WithCredentials("key", "secret"))
+	defer srv.Close()
+
+	app := mpesa.NewApp(srv.Client(), "key", "secret", mpesa.EnvironmentSandbox)
+
+	t.Run("it sends the stk push callback", func(t *testing.T) {
+		res, err := app.STKPush(ctx, sandbox.STKPasskey, sandbox.STKPushRequest(10, "https://example.com/stk"))
+		require.NoError(t, err)
+		require.Equal(t, "0", res.ResponseCode)
+
+		callback := (<-stkCallbacks).Body.STKCallback
+		require.Equal(t, res.CheckoutRequestID, callback.CheckoutRequestID)
+		require.Zero(t, callback.ResultCode)
+
+		amount, ok := callback.Amount()
+		require.True(t, ok)
+		require.Equal(t, float64(10), amount)
+
+		phone, ok := callback.PhoneNumber()
+		require.True(t, ok)
+		require.Equal(t, uint64(254708374149), phone)
+
+		query, err := app.STKQuery(ctx, sandbox.STKPasskey, mpesa.STKQueryRequest{
+			BusinessShortCode: sandbox.STKShortCode,
+			CheckoutRequestID: res.CheckoutRequestID,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "0", query.ResultCode)
+
+		_, err = app.STKQuery(ctx, sandbox.STKPasskey, mpesa.STKQueryRequest{
+			BusinessShortCode: sandbox.STKShortCode,
+			CheckoutRequestID: "ws_CO_unknown",
+		})
+
+		var mpesaErr *mpesa.Error
+		require.True(t, errors.As(err, &mpesaErr))
+		require.Equal(t, http.StatusBadRequest, mpesaErr.StatusCode)
+	})
+
+	t.Run("it sends the b2c result", func(t *testing.T) {
+		req := sandbox.B2CRequest(100, "https://example.com/b2c/result", "https://example.com/b2c/timeout")
+
+		res, err := app.B2C(ctx, sandbox.Initiator.Password, req)
+		require.NoError(t, err)
+
+		callback := <-b2cCallbacks
+		require.Equal(t, res.ConversationID, callback.Result.ConversationID)
+		require.Zero(t, callback.Result.ResultCode)
+
+		receipt, ok := callback.Result.ResultParameters.String(mpesa.ResultParameterTransactionReceipt)
+		require.True(t, ok)
+		require.Equal(t, callback.Result.TransactionID, receipt)
+	})
+
+	t.Run("it simulates c2b payments", func(t *testing.T) {
+		_, err := srv.SimulateC2B(mpesa.C2BCallback{BusinessShortCode: "600000"})
+		require.Error(t, err)
+
+		_, err = app.RegisterC2BURL(ctx, mpesa.RegisterC2BURLRequest{
+			ShortCode:       sandbox.ShortCode,
+			ResponseType:    mpesa.ResponseTypeCanceled,
+			ConfirmationURL: "https://example.com/c2b/confirmation",
+			ValidationURL:   "https://example.com/c2b/validation",
+		})
+		require.NoError(t, err)
+
+		transID, err := srv.SimulateC2B(mpesa.C2BCallback{
+			BusinessShortCode: "600000",
+			TransAmount:       "10.00",
+			BillRefNumber:     "INV-001",
+			MSISDN:            "254708374149",
+		})
+		require.NoError(t, err)
+		require.Equal(t, transID, (<-confirmed).TransID)
+
+		_, err = srv.SimulateC2B(mpesa.C2BCallback{BusinessShortCode: "600000", BillRefNumber: "INV-002"})
+
+		var rejection *mpesa.C2BRejection
+		require.True(t, errors.As(err, &rejection))
+		require.Equal(t, mpesa.C2BRejectInvalidAccountNumber, rejection.Code)
+		require.Empty(t, confirmed)
+	})
+
+	t.Run("it rejects invalid requests", func(t *testing.T) {
+		_, err := mpesa.NewApp(srv.Client(), "key", "invalid", mpesa.EnvironmentSandbox).
+			STKPush(ctx, sandbox.STKPasskey, sandbox.STKPushRequest(10, "https://example.com/stk"))
+		require.Error(t, err)
+	})
+}
+
+func TestServer_STKResultCode(t *testing.T) {
+	var (
+		ctx       = context.Background()
+		sandbox   = mpesa.SandboxDefaults()
+		callbacks = make(chan *mpesa.STKPushCallback, 1)
+	)
+
+	srv := NewServer(WithSTKResultCode(1032), WithCallbackHandler(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			callback, err := mpesa.UnmarshalSTKPushCallback(r.Body)
+			require.NoError(t, err)
+			callbacks <- callback
+		},
+	)))
+	defer srv.Close()
+
+	app := mpesa.NewApp(srv.Client(), "key", "secret", mpesa.EnvironmentSandbox)
+
+	_, err := app.STKPush(ctx, sandbox.STKPasskey, sandbox.STKPushRequest(10, "https://example.com/stk"))
+	require.NoError(t, err)
+
+	callback := (<-callbacks).Body.STKCallback
+	require.Equal(t, 1032, callback.ResultCode)
+	require.Empty(t, callback.CallbackMetadata.Item)
+}
@@ -0,0 +1,291 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the document returned by OpenAPISpec conforms to.
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIConfig describes the service the OpenAPI document is generated for.
+type OpenAPIConfig struct {
+	// Title of the API. Defaults to "M-Pesa".
+	Title string
+
+	// Version of the API. Defaults to "1.0.0".
+	Version string
+
+	// ServerURL is the public URL of the service, e.g. https://payments.example.com.
+	ServerURL string
+
+	// APIPrefix is the path the handler returned by NewAPIHandler is mounted on, e.g. /api.
+	APIPrefix string
+}
+
+// openAPIWebhooks are the callbacks M-Pesa sends to the URLs set on the requests.
+var openAPIWebhooks = []struct {
+	name        string
+	summary     string
+	description string
+	payload     interface{}
+}{
+	{
+		name:        "stkPushCallback",
+		summary:     "STK push result",
+		description: "Sent to the CallBackURL of an STK push once the customer completes or cancels the payment.",
+		payload:     STKPushCallback{},
+	},
+	{
+		name:    "result",
+		summary: "Asynchronous request result",
+		description: "Sent to the ResultURL of B2C, transaction status, account balance and business pay bill " +
+			"requests once the request is processed.",
+		payload: Callback{},
+	},
+	{
+		name:        "queueTimeout",
+		summary:     "Queue timeout",
+		description: "Sent to the QueueTimeOutURL when a request times out while awaiting processing in the queue.",
+		payload:     QueueTimeoutCallback{},
+	},
+	{
+		name:    "b2bExpressCheckoutCallback",
+		summary: "B2B Express Checkout result",
+		description: "Sent to the callback URL of a B2B Express Checkout once the merchant completes or cancels " +
+			"the payment prompt.",
+		payload: B2BExpressCheckoutCallback{},
+	},
+	{
+		name:    "standingOrderCallback",
+		summary: "Ratiba standing order result",
+		description: "Sent to the CallBackURL of a Ratiba standing order status query or cancellation once it is " +
+			"processed.",
+		payload: StandingOrderCallback{},
+	},
+}
+
+// openAPIOperations are the operations served by the handler returned by NewAPIHandler.
+var openAPIOperations = []struct {
+	path        string
+	id          string
+	summary     string
+	description string
+	request     interface{}
+	response    interface{}
+}{
+	{
+		path:        APIPathSTKPush,
+		id:          "stkPush",
+		summary:     "Initiate an STK push",
+		description: "The Password and Timestamp are generated by the service.",
+		request:     STKPushRequest{},
+		response:    STKPushResponse{},
+	},
+	{
+		path:        APIPathSTKQuery,
+		id:          "stkQuery",
+		summary:     "Query the status of an STK push",
+		description: "The Password and Timestamp are generated by the service.",
+		request:     STKQueryRequest{},
+		response:    STKQueryResponse{},
+	},
+	{
+		path:        APIPathB2C,
+		id:          "b2c",
+		summary:     "Initiate a B2C payment",
+		description: "The InitiatorName and SecurityCredential are set by the service.",
+		request:     B2CRequest{},
+		response:    B2CResponse{},
+	},
+}
+
+// OpenAPISpec returns an OpenAPI document describing the JSON API served by NewAPIHandler and the webhooks M-Pesa
+// sends to the callback URLs. The schemas are generated from the SDK types so the document always matches the
+// payloads the SDK sends and expects.
+func OpenAPISpec(cfg OpenAPIConfig) ([]byte, error) {
+	if cfg.Title == "" {
+		cfg.Title = "M-Pesa"
+	}
+
+	if cfg.Version == "" {
+		cfg.Version = "1.0.0"
+	}
+
+	g := &openAPISchemaGenerator{schemas: make(map[string]interface{})}
+
+	paths := make(map[string]interface{})
+	for _, op := range openAPIOperations {
+		paths[strings.TrimRight(cfg.APIPrefix, "/")+op.path] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": op.id,
+				"summary":     op.summary,
+				"description": op.description,
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content":  openAPIJSONContent(g.schema(reflect.TypeOf(op.request))),
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "The request was accepted by M-Pesa.",
+						"content":     openAPIJSONContent(g.schema(reflect.TypeOf(op.response))),
+					},
+					"default": map[string]interface{}{
+						"description": "The request failed.",
+						"content":     openAPIJSONContent(g.schema(reflect.TypeOf(APIError{}))),
+					},
+				},
+			},
+		}
+	}
+
+	webhooks := make(map[string]interface{})
+	for _, webhook := range openAPIWebhooks {
+		webhooks[webhook.name] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": webhook.name,
+				"summary":     webhook.summary,
+				"description": webhook.description,
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content":  openAPIJSONContent(g.schema(reflect.TypeOf(webhook.payload))),
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "The callback was received.",
+					},
+				},
+			},
+		}
+	}
+
+	doc := map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":   cfg.Title,
+			"version": cfg.Version,
+		},
+		"paths":    paths,
+		"webhooks": webhooks,
+		"components": map[string]interface{}{
+			"schemas": g.schemas,
+		},
+	}
+
+	if cfg.ServerURL != "" {
+		doc["servers"] = []interface{}{
+			map[string]interface{}{"url": cfg.ServerURL},
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// NewOpenAPIHandler returns a http.Handler that serves the document returned by OpenAPISpec.
+func NewOpenAPIHandler(cfg OpenAPIConfig) (http.Handler, error) {
+	spec, err := OpenAPISpec(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(spec)
+	}), nil
+}
+
+func openAPIJSONContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{
+			"schema": schema,
+		},
+	}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// openAPISchemaGenerator converts Go types to JSON schemas. Named structs are added to the schemas and referenced.
+type openAPISchemaGenerator struct {
+	schemas map[string]interface{}
+}
+
+func (g *openAPISchemaGenerator) schema(t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := g.schemas[t.Name()]; !ok {
+			// Reserve the name before generating the schema to terminate on recursive types.
+			g.schemas[t.Name()] = nil
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+
+		return ref
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func (g *openAPISchemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		properties[name] = g.schema(field.Type)
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
@@ -0,0 +1,77 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	spec, err := OpenAPISpec(OpenAPIConfig{
+		Title:     "Payments",
+		ServerURL: "https://payments.example.com",
+		APIPrefix: "/api/",
+	})
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Servers    []map[string]string                   `json:"servers"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Webhooks   map[string]map[string]json.RawMessage `json:"webhooks"`
+		Components struct {
+			Schemas map[string]struct {
+				Type       string                     `json:"type"`
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	require.Equal(t, OpenAPIVersion, doc.OpenAPI)
+	require.Equal(t, "Payments", doc.Info.Title)
+	require.Equal(t, "1.0.0", doc.Info.Version)
+	require.Equal(t, "https://payments.example.com", doc.Servers[0]["url"])
+
+	for _, path := range []string{"/api/stkpush", "/api/stkpush/query", "/api/b2c"} {
+		require.Contains(t, doc.Paths, path)
+		require.Contains(t, doc.Paths[path], "post")
+	}
+
+	for _, webhook := range []string{"stkPushCallback", "result", "queueTimeout", "b2bExpressCheckoutCallback"} {
+		require.Contains(t, doc.Webhooks, webhook)
+	}
+
+	stkPush := doc.Components.Schemas["STKPushRequest"]
+	require.Equal(t, "object", stkPush.Type)
+	require.JSONEq(t, `{"type":"integer","minimum":0}`, string(stkPush.Properties["BusinessShortCode"]))
+	require.JSONEq(t, `{"type":"string"}`, string(stkPush.Properties["CallBackURL"]))
+
+	callback := doc.Components.Schemas["STKCallback"]
+	require.Contains(t, callback.Properties, "CheckoutRequestID")
+
+	require.JSONEq(t, `{"$ref":"#/components/schemas/CallbackResult"}`,
+		string(doc.Components.Schemas["Callback"].Properties["Result"]),
+	)
+	require.Contains(t, doc.Components.Schemas["APIError"].Properties, "error")
+	require.NotContains(t, doc.Components.Schemas, "AuthorizationResponse")
+}
+
+func TestNewOpenAPIHandler(t *testing.T) {
+	handler, err := NewOpenAPIHandler(OpenAPIConfig{})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.True(t, json.Valid(rec.Body.Bytes()))
+}
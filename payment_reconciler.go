@@ -0,0 +1,326 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PaymentStatus is the reconciled status of a B2C or B2B payment tracked by a PaymentReconciler.
+type PaymentStatus string
+
+const (
+	// PaymentStatusPending means the result of the payment is not known yet.
+	PaymentStatusPending PaymentStatus = "Pending"
+
+	// PaymentStatusConfirmed means M-Pesa completed the payment.
+	PaymentStatusConfirmed PaymentStatus = "Confirmed"
+
+	// PaymentStatusFailed means M-Pesa did not complete the payment.
+	PaymentStatusFailed PaymentStatus = "Failed"
+
+	// PaymentStatusUnknown means the result could not be established before the deadline. Check the payment on the
+	// M-Pesa portal before retrying it.
+	PaymentStatusUnknown PaymentStatus = "Unknown"
+)
+
+const (
+	defaultPaymentReconcilerDelay    = 5 * time.Minute
+	defaultPaymentReconcilerDeadline = time.Hour
+)
+
+// paymentReconcilerRateLimitKey is the RateLimiter key of the transaction status queries made by a
+// PaymentReconciler.
+const paymentReconcilerRateLimitKey = "payment-reconciler"
+
+type (
+	// PaymentReconcilerConfig configures a PaymentReconciler.
+	PaymentReconcilerConfig struct {
+		// InitiatorPassword is the password of the initiator of the transaction status queries. It defaults to the
+		// initiator credentials of the app.
+		InitiatorPassword string
+
+		// ResultURL and QueueTimeOutURL receive the transaction status results, which must be passed to
+		// HandleResult. They default to the callback URLs of the app.
+		ResultURL       string
+		QueueTimeOutURL string
+
+		// Delay is how long to wait for the result callback of a payment before querying its status, and between the
+		// queries of a payment that is still pending. Defaults to 5 minutes.
+		Delay time.Duration
+
+		// Deadline is how long after it was added a payment is given up on and reported as PaymentStatusUnknown.
+		// Defaults to 1 hour.
+		Deadline time.Duration
+
+		// QueriesPerSecond limits the transaction status queries made by the PaymentReconciler. Defaults to 1.
+		QueriesPerSecond float64
+
+		// OnTransition, if set, is called every time the status of a payment changes.
+		OnTransition func(transition PaymentTransition)
+	}
+
+	// PaymentTransition is a change of the status of a payment tracked by a PaymentReconciler.
+	PaymentTransition struct {
+		ShortCode                uint
+		ConversationID           string
+		OriginatorConversationID string
+
+		// From is the previous status of the payment and Status the new one.
+		From   PaymentStatus
+		Status PaymentStatus
+
+		// TransactionID is the M-Pesa receipt number of a confirmed payment.
+		TransactionID string
+
+		// ResultCode and ResultDesc describe the result. ResultCode is -1 when the PaymentReconciler gave up.
+		ResultCode int
+		ResultDesc string
+	}
+
+	// PaymentReconciler tracks submitted B2C and B2B payments and queries their status using GetTransactionStatus
+	// when their result callback did not arrive. Add the Response of every payment, pass the result callbacks to
+	// HandleResult, and call Run to query the pending ones.
+	PaymentReconciler struct {
+		app     *Mpesa
+		cfg     PaymentReconcilerConfig
+		limiter *RateLimiter
+
+		mu      sync.Mutex
+		pending map[string]*paymentEntry
+		queries map[string]string
+		now     func() time.Time
+	}
+
+	paymentEntry struct {
+		shortCode      uint
+		conversationID string
+		nextQueryAt    time.Time
+		deadline       time.Time
+	}
+)
+
+// NewPaymentReconciler creates a PaymentReconciler that queries the payments using the app.
+func NewPaymentReconciler(app *Mpesa, cfg PaymentReconcilerConfig) *PaymentReconciler {
+	if cfg.Delay <= 0 {
+		cfg.Delay = defaultPaymentReconcilerDelay
+	}
+
+	if cfg.Deadline <= 0 {
+		cfg.Deadline = defaultPaymentReconcilerDeadline
+	}
+
+	if cfg.QueriesPerSecond <= 0 {
+		cfg.QueriesPerSecond = defaultReconcilerQueriesPerSecond
+	}
+
+	return &PaymentReconciler{
+		app:     app,
+		cfg:     cfg,
+		limiter: NewRateLimiter(RateLimit{Rate: cfg.QueriesPerSecond, Burst: 1}),
+		pending: make(map[string]*paymentEntry),
+		queries: make(map[string]string),
+		now:     time.Now,
+	}
+}
+
+// Add starts tracking the payment made from the shortcode, using the AsyncResponse of the B2C, BusinessPayBill or
+// BusinessBuyGoods request. Adding a payment that is already tracked has no effect.
+func (r *PaymentReconciler) Add(shortCode uint, res *AsyncResponse) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[res.OriginatorConversationID]; ok {
+		return
+	}
+
+	now := r.now()
+	r.pending[res.OriginatorConversationID] = &paymentEntry{
+		shortCode:      shortCode,
+		conversationID: res.ConversationID,
+		nextQueryAt:    now.Add(r.cfg.Delay),
+		deadline:       now.Add(r.cfg.Deadline),
+	}
+}
+
+// Pending returns the number of payments whose result is not known yet.
+func (r *PaymentReconciler) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
+
+// HandleResult completes the payment the result callback belongs to, which is either the result of the payment or
+// of a transaction status query made by the PaymentReconciler. It returns false if the callback does not belong to a
+// tracked payment. A status query that does not establish the result leaves the payment pending.
+func (r *PaymentReconciler) HandleResult(callback *Callback) bool {
+	result := callback.Result
+
+	r.mu.Lock()
+	_, isPayment := r.pending[result.OriginatorConversationID]
+	id, isQuery := r.queries[result.ConversationID]
+	if isQuery {
+		delete(r.queries, result.ConversationID)
+	}
+	r.mu.Unlock()
+
+	switch {
+	case isPayment:
+		status := PaymentStatusConfirmed
+		if result.ResultCode != 0 {
+			status = PaymentStatusFailed
+		}
+
+		return r.finish(result.OriginatorConversationID, status, result.TransactionID, result.ResultCode,
+			result.ResultDesc)
+	case isQuery:
+		if result.ResultCode != 0 {
+			return true
+		}
+
+		transactionID, _ := result.ResultParameters.String(ResultParameterReceiptNo)
+		status, _ := result.ResultParameters.String(ResultParameterTransactionStatus)
+
+		switch strings.ToLower(status) {
+		case "completed":
+			r.finish(id, PaymentStatusConfirmed, transactionID, 0, status)
+		case "failed", "cancelled", "declined", "expired":
+			r.finish(id, PaymentStatusFailed, transactionID, result.ResultCode, status)
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// ReconcileDue queries the status of the payments whose result did not arrive within the Delay, waiting for the rate
+// limit between the queries, and gives up on the ones past their deadline. It returns the number of payments queried
+// and the errors of the failed queries, which are retried after the Delay.
+func (r *PaymentReconciler) ReconcileDue(ctx context.Context) (int, error) {
+	now := r.now()
+
+	var due, expired []string
+
+	r.mu.Lock()
+	for id, entry := range r.pending {
+		switch {
+		case !now.Before(entry.deadline):
+			expired = append(expired, id)
+		case !now.Before(entry.nextQueryAt):
+			entry.nextQueryAt = now.Add(r.cfg.Delay)
+			due = append(due, id)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, id := range expired {
+		r.finish(id, PaymentStatusUnknown, "", -1, "The reconciliation deadline elapsed")
+	}
+
+	var (
+		queried int
+		errs    []error
+	)
+
+	for _, id := range due {
+		if err := r.limiter.Wait(ctx, paymentReconcilerRateLimitKey); err != nil {
+			return queried, err
+		}
+
+		if err := r.query(ctx, id); err != nil {
+			errs = append(errs, err)
+		}
+		queried++
+	}
+
+	return queried, errors.Join(errs...)
+}
+
+// Run calls ReconcileDue every minute, or every Delay if it is shorter, until the context is done.
+func (r *PaymentReconciler) Run(ctx context.Context) error {
+	interval := time.Minute
+	if r.cfg.Delay < interval {
+		interval = r.cfg.Delay
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := r.ReconcileDue(ctx); err != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (r *PaymentReconciler) query(ctx context.Context, id string) error {
+	r.mu.Lock()
+	entry, ok := r.pending[id]
+	if !ok {
+		r.mu.Unlock()
+		return nil
+	}
+	shortCode := entry.shortCode
+	r.mu.Unlock()
+
+	res, err := r.app.GetTransactionStatus(ctx, r.cfg.InitiatorPassword, TransactionStatusRequest{
+		OriginatorConversationID: id,
+		PartyA:                   shortCode,
+		QueueTimeOutURL:          r.cfg.QueueTimeOutURL,
+		Remarks:                  "Reconciliation",
+		ResultURL:                r.cfg.ResultURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.queries[res.ConversationID] = id
+	r.mu.Unlock()
+
+	return nil
+}
+
+// finish stops tracking the payment once its result is established.
+func (r *PaymentReconciler) finish(
+	id string, status PaymentStatus, transactionID string, resultCode int, resultDesc string,
+) bool {
+	r.mu.Lock()
+	entry, ok := r.pending[id]
+	if !ok {
+		r.mu.Unlock()
+		return false
+	}
+
+	delete(r.pending, id)
+	for queryID, paymentID := range r.queries {
+		if paymentID == id {
+			delete(r.queries, queryID)
+		}
+	}
+	r.mu.Unlock()
+
+	if r.cfg.OnTransition != nil {
+		r.cfg.OnTransition(PaymentTransition{
+			ShortCode:                entry.shortCode,
+			ConversationID:           entry.conversationID,
+			OriginatorConversationID: id,
+			From:                     PaymentStatusPending,
+			Status:                   status,
+			TransactionID:            transactionID,
+			ResultCode:               resultCode,
+			ResultDesc:               resultDesc,
+		})
+	}
+
+	return true
+}
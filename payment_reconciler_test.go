@@ -0,0 +1,147 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentReconciler(t *testing.T) {
+	ctx := context.Background()
+
+	newReconciler := func(t *testing.T) (*PaymentReconciler, *time.Time, *[]PaymentTransition, *[]TransactionStatusRequest) {
+		var (
+			cl  = newMockHttpClient()
+			app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+				WithInitiatorCredentials(InitiatorCredentials{Name: "testapi", Password: "random-string"}),
+			)
+			now         = time.Now()
+			transitions []PaymentTransition
+			queries     []TransactionStatusRequest
+		)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+			var req TransactionStatusRequest
+			require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+			queries = append(queries, req)
+
+			return http.StatusOK, `{"ConversationID": "AG_query_` + req.OriginatorConversationID + `", ` +
+				`"OriginatorConversationID": "query", "ResponseCode": "0"}`
+		})
+
+		r := NewPaymentReconciler(app, PaymentReconcilerConfig{
+			ResultURL:        "https://example.com/result",
+			QueueTimeOutURL:  "https://example.com/timeout",
+			Delay:            time.Minute,
+			Deadline:         5 * time.Minute,
+			QueriesPerSecond: 1000,
+			OnTransition: func(transition PaymentTransition) {
+				transitions = append(transitions, transition)
+			},
+		})
+		r.now = func() time.Time { return now }
+
+		return r, &now, &transitions, &queries
+	}
+
+	statusResult := func(conversationID, status string) *Callback {
+		return &Callback{Result: CallbackResult{
+			ConversationID: conversationID,
+			ResultDesc:     "The service request is processed successfully.",
+			ResultParameters: ResultParameters{ResultParameter: []ResultParameter{
+				{Key: "ReceiptNo", Value: "OEI2AK4Q16"},
+				{Key: "TransactionStatus", Value: status},
+			}},
+		}}
+	}
+
+	t.Run("it queries the status of the payments without a result", func(t *testing.T) {
+		r, now, transitions, queries := newReconciler(t)
+
+		r.Add(600986, &AsyncResponse{ConversationID: "AG_1", OriginatorConversationID: "oc_1"})
+		r.Add(600986, &AsyncResponse{ConversationID: "AG_2", OriginatorConversationID: "oc_2"})
+
+		queried, err := r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+
+		*now = now.Add(time.Minute)
+
+		queried, err = r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, queried)
+		require.Len(t, *queries, 2)
+		require.Equal(t, uint(600986), (*queries)[0].PartyA)
+		require.Equal(t, "https://example.com/result", (*queries)[0].ResultURL)
+		require.ElementsMatch(t, []string{"oc_1", "oc_2"},
+			[]string{(*queries)[0].OriginatorConversationID, (*queries)[1].OriginatorConversationID})
+
+		require.True(t, r.HandleResult(statusResult("AG_query_oc_1", "Completed")))
+		require.True(t, r.HandleResult(statusResult("AG_query_oc_2", "Pending")))
+		require.Equal(t, 1, r.Pending())
+
+		require.Equal(t, []PaymentTransition{{
+			ShortCode:                600986,
+			ConversationID:           "AG_1",
+			OriginatorConversationID: "oc_1",
+			From:                     PaymentStatusPending,
+			Status:                   PaymentStatusConfirmed,
+			TransactionID:            "OEI2AK4Q16",
+			ResultDesc:               "Completed",
+		}}, *transitions)
+
+		*now = now.Add(time.Minute)
+
+		queried, err = r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, queried)
+
+		require.True(t, r.HandleResult(statusResult("AG_query_oc_2", "Failed")))
+		require.Zero(t, r.Pending())
+		require.Equal(t, PaymentStatusFailed, (*transitions)[1].Status)
+	})
+
+	t.Run("it stops tracking the payments completed by their result", func(t *testing.T) {
+		r, now, transitions, queries := newReconciler(t)
+
+		r.Add(600986, &AsyncResponse{ConversationID: "AG_1", OriginatorConversationID: "oc_1"})
+
+		callback := &Callback{Result: CallbackResult{
+			ConversationID:           "AG_1",
+			OriginatorConversationID: "oc_1",
+			ResultCode:               2001,
+			ResultDesc:               "The initiator information is invalid.",
+		}}
+		require.True(t, r.HandleResult(callback))
+		require.False(t, r.HandleResult(callback))
+
+		*now = now.Add(time.Minute)
+
+		queried, err := r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+		require.Empty(t, *queries)
+		require.Len(t, *transitions, 1)
+		require.Equal(t, PaymentStatusFailed, (*transitions)[0].Status)
+		require.Equal(t, 2001, (*transitions)[0].ResultCode)
+	})
+
+	t.Run("it gives up after the deadline", func(t *testing.T) {
+		r, now, transitions, _ := newReconciler(t)
+
+		r.Add(600986, &AsyncResponse{ConversationID: "AG_lost", OriginatorConversationID: "oc_lost"})
+		*now = now.Add(5 * time.Minute)
+
+		queried, err := r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+		require.Zero(t, r.Pending())
+		require.Equal(t, PaymentStatusUnknown, (*transitions)[0].Status)
+		require.Equal(t, -1, (*transitions)[0].ResultCode)
+	})
+}
@@ -0,0 +1,117 @@
+package mpesa
+
+import (
+	"context"
+	"fmt"
+)
+
+// Transaction limits of the Daraja APIs, in whole shillings.
+const (
+	minChargeAmount   = 1
+	maxChargeAmount   = 250000
+	minDisburseAmount = 10
+	maxDisburseAmount = 250000
+)
+
+type (
+	// Payments is a high level API over STKPush and B2C that takes the amounts as Money. The shortcode, passkey,
+	// initiator and callback URLs are the defaults of the app, see RegisterShortcode and WithCallbackURLs.
+	Payments struct {
+		app *Mpesa
+	}
+
+	// Customer is the customer charged by Payments.Charge.
+	Customer struct {
+		// Phone is the phone number of the customer in any format accepted by ParsePhoneNumber.
+		Phone string
+
+		// Reference identifies the transaction to the customer, e.g. an account or order number. It is sent as the
+		// AccountReference.
+		Reference string
+
+		// Description is sent as the TransactionDesc. It defaults to "Payment".
+		Description string
+
+		// TransactionType defaults to CustomerPayBillOnlineTransactionType.
+		TransactionType TransactionType
+	}
+
+	// Beneficiary is the customer paid by Payments.Disburse.
+	Beneficiary struct {
+		// Phone is the phone number of the beneficiary in any format accepted by ParsePhoneNumber.
+		Phone string
+
+		// Remarks and Occasion are sent along with the payment.
+		Remarks  string
+		Occasion string
+
+		// CommandID defaults to BusinessPaymentCommandID.
+		CommandID CommandID
+	}
+)
+
+// NewPayments returns the Payments of the app.
+func NewPayments(app *Mpesa) *Payments {
+	return &Payments{app: app}
+}
+
+// Charge sends an STK push prompting the customer to pay the amount. The amount must be a whole amount between 1 and
+// 250,000 in the currency of the app.
+func (p *Payments) Charge(ctx context.Context, customer Customer, amount Money) (*STKPushResponse, error) {
+	whole, err := p.amount(amount, minChargeAmount, maxChargeAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if customer.TransactionType == "" {
+		customer.TransactionType = CustomerPayBillOnlineTransactionType
+	}
+	setDefault(&customer.Description, "Payment")
+
+	return p.app.STKPush(ctx, "", STKPushRequest{
+		TransactionType:  customer.TransactionType,
+		Amount:           whole,
+		Phone:            customer.Phone,
+		AccountReference: customer.Reference,
+		TransactionDesc:  customer.Description,
+	})
+}
+
+// Disburse sends the amount to the beneficiary using B2C. The amount must be a whole amount between 10 and 250,000 in
+// the currency of the app.
+func (p *Payments) Disburse(ctx context.Context, beneficiary Beneficiary, amount Money) (*B2CResponse, error) {
+	whole, err := p.amount(amount, minDisburseAmount, maxDisburseAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	if beneficiary.CommandID == "" {
+		beneficiary.CommandID = BusinessPaymentCommandID
+	}
+
+	return p.app.B2C(ctx, "", B2CRequest{
+		CommandID: beneficiary.CommandID,
+		Amount:    whole,
+		Phone:     beneficiary.Phone,
+		Remarks:   beneficiary.Remarks,
+		Occasion:  beneficiary.Occasion,
+	})
+}
+
+// amount returns the amount in whole units after checking its currency and that it is within the limits.
+func (p *Payments) amount(amount Money, min, max uint) (uint, error) {
+	if currency := amount.Currency.OrDefault(); currency != p.app.Currency() {
+		return 0, fmt.Errorf("%w: %s amount for a %s app", ErrInvalidMoney, currency, p.app.Currency())
+	}
+
+	whole, err := amount.Whole()
+	if err != nil {
+		return 0, err
+	}
+
+	if whole < min || whole > max {
+		return 0, fmt.Errorf("%w: %s is not between %d and %d", ErrInvalidMoney, amount, min, max)
+	}
+
+	return whole, nil
+}
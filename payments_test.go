@@ -0,0 +1,92 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayments(t *testing.T) {
+	ctx := context.Background()
+
+	newPayments := func(t *testing.T) (*Payments, *mockHttpClient) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+		app.RegisterShortcode("default", ShortcodeProfile{
+			ShortCode: 174379,
+			Passkey:   "passkey",
+			Initiator: InitiatorCredentials{Name: "testapi", Password: "Safaricom999!*!"},
+			Callbacks: CallbackURLs{
+				CallBackURL:     "https://example.com/stk",
+				ResultURL:       "https://example.com/result",
+				QueueTimeOutURL: "https://example.com/timeout",
+			},
+		})
+
+		app = app.WithShortcode("default")
+		mockAuth(app, cl)
+		return NewPayments(app), cl
+	}
+
+	t.Run("it charges the customer", func(t *testing.T) {
+		payments, cl := newPayments(t)
+
+		cl.MockRequest(payments.app.endpointSTK(), func() (status int, body string) {
+			var req STKPushRequest
+			require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+			require.Equal(t, uint(1500), req.Amount)
+			require.Equal(t, uint64(254708374149), req.PhoneNumber)
+			require.Equal(t, TransactionType(CustomerPayBillOnlineTransactionType), req.TransactionType)
+			require.Equal(t, "INV-001", req.AccountReference)
+			require.Equal(t, "https://example.com/stk", req.CallBackURL)
+
+			return http.StatusOK, `{"CheckoutRequestID": "ws_CO_191220191020363925", "ResponseCode": "0"}`
+		})
+
+		res, err := payments.Charge(ctx, Customer{Phone: "0708374149", Reference: "INV-001"}, Shillings(1500))
+		require.NoError(t, err)
+		require.Equal(t, "ws_CO_191220191020363925", res.CheckoutRequestID)
+	})
+
+	t.Run("it disburses to the beneficiary", func(t *testing.T) {
+		payments, cl := newPayments(t)
+
+		cl.MockRequest(payments.app.endpointB2C(), func() (status int, body string) {
+			var req B2CRequest
+			require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+			require.Equal(t, uint(250000), req.Amount)
+			require.Equal(t, uint64(254708374149), req.PartyB)
+			require.Equal(t, BusinessPaymentCommandID, req.CommandID)
+			require.Equal(t, "testapi", req.InitiatorName)
+
+			return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+		})
+
+		res, err := payments.Disburse(ctx, Beneficiary{Phone: "0708374149", Remarks: "Refund"}, Shillings(250000))
+		require.NoError(t, err)
+		require.Equal(t, "AG_20191219_00005797af5d7d75f652", res.ConversationID)
+	})
+
+	t.Run("it rejects the amounts Daraja does not accept", func(t *testing.T) {
+		payments, cl := newPayments(t)
+
+		amounts := []Money{
+			NewMoney(CurrencyKES, 150050),
+			Shillings(0),
+			Shillings(250001),
+			NewMoney(CurrencyTZS, 150000),
+		}
+
+		for _, amount := range amounts {
+			_, err := payments.Charge(ctx, Customer{Phone: "0708374149"}, amount)
+			require.ErrorIs(t, err, ErrInvalidMoney, amount.String())
+		}
+
+		_, err := payments.Disburse(ctx, Beneficiary{Phone: "0708374149"}, Shillings(9))
+		require.ErrorIs(t, err, ErrInvalidMoney)
+		require.Empty(t, cl.requests)
+	})
+}
@@ -0,0 +1,333 @@
+package mpesa
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPayoutImportMinAmount = minDisburseAmount
+	defaultPayoutImportMaxAmount = maxDisburseAmount
+)
+
+var (
+	// ErrInvalidPayoutAmount indicates that a payout amount is not a whole number within the allowed range.
+	ErrInvalidPayoutAmount = errors.New("mpesa: invalid payout amount")
+
+	// ErrEmptyPayoutImport indicates that the CSV does not contain any payout rows.
+	ErrEmptyPayoutImport = errors.New("mpesa: no payouts to import")
+)
+
+type (
+	// PayoutImportConfig configures a PayoutImporter.
+	PayoutImportConfig struct {
+		// MinAmount and MaxAmount are the smallest and largest amounts accepted for a single payout. They default to
+		// 10 and 250,000, the B2C transaction limits.
+		MinAmount uint
+		MaxAmount uint
+
+		// CommandID, Remarks and Occasion are set on every imported payout.
+		CommandID CommandID
+		Remarks   string
+		Occasion  string
+	}
+
+	// PayoutImportRow is the result of importing a single CSV row.
+	PayoutImportRow struct {
+		// Line is the line number of the row in the CSV, starting from 1.
+		Line int
+
+		// Reference is used as the payout ID.
+		Reference string
+
+		// PhoneNumber is the normalized phone number in the format 2547XXXXXXXX.
+		PhoneNumber uint64
+
+		// Amount to be sent to the customer.
+		Amount uint
+
+		// Status is the state of the payout. It is empty for rows that failed validation.
+		Status PayoutStatus
+
+		// ReceiptNumber, ResultCode and ResultDesc are copied from the payout once it completes.
+		ReceiptNumber string
+		ResultCode    int
+		ResultDesc    string
+
+		// Err is the reason the row was rejected or the payout failed.
+		Err error
+	}
+
+	// PayoutImportReport is the per-row result of a PayoutImporter.Import.
+	PayoutImportReport struct {
+		// Rows holds the result of every row in the order they appear in the CSV.
+		Rows []PayoutImportRow
+
+		// Accepted and Rejected are the number of rows enqueued and rejected by validation.
+		Accepted int
+		Rejected int
+	}
+
+	// PayoutImporter reads payout batches from CSV files and feeds them to a DisbursementPipeline.
+	PayoutImporter struct {
+		pipeline *DisbursementPipeline
+		cfg      PayoutImportConfig
+	}
+)
+
+// NewPayoutImporter creates a PayoutImporter that enqueues the imported payouts on the pipeline.
+func NewPayoutImporter(pipeline *DisbursementPipeline, cfg PayoutImportConfig) *PayoutImporter {
+	if cfg.MinAmount == 0 {
+		cfg.MinAmount = defaultPayoutImportMinAmount
+	}
+
+	if cfg.MaxAmount == 0 {
+		cfg.MaxAmount = defaultPayoutImportMaxAmount
+	}
+
+	return &PayoutImporter{
+		pipeline: pipeline,
+		cfg:      cfg,
+	}
+}
+
+// parseAmount parses a whole amount, allowing thousands separators and a zero fractional part, e.g. 1,500.00.
+func (i *PayoutImporter) parseAmount(v string) (uint, error) {
+	v = strings.ReplaceAll(strings.TrimSpace(v), ",", "")
+	if whole, fraction, ok := strings.Cut(v, "."); ok && strings.Trim(fraction, "0") == "" {
+		v = whole
+	}
+
+	amount, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a whole number", ErrInvalidPayoutAmount, v)
+	}
+
+	if amount < uint64(i.cfg.MinAmount) || amount > uint64(i.cfg.MaxAmount) {
+		return 0, fmt.Errorf(
+			"%w: %d is not between %d and %d", ErrInvalidPayoutAmount, amount, i.cfg.MinAmount, i.cfg.MaxAmount,
+		)
+	}
+
+	return uint(amount), nil
+}
+
+// Import reads the payouts from the CSV, validates every row and enqueues the valid ones on the pipeline. The CSV
+// must have the phone, amount and reference columns, in that order unless a header row naming them is present.
+//
+// A row is rejected if the phone number or amount is invalid, the reference is empty, or the reference appears on an
+// earlier row or is already known to the pipeline. Rejected rows do not prevent the valid ones from being enqueued.
+func (i *PayoutImporter) Import(r io.Reader) (*PayoutImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var (
+		records [][]string
+		lines   []int
+	)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: read payouts: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+
+	columns := [3]int{0, 1, 2}
+	start := 0
+
+	if len(records) > 0 {
+		if header, ok := payoutImportHeader(records[0]); ok {
+			columns = header
+			start = 1
+		}
+	}
+
+	var (
+		report  = &PayoutImportReport{}
+		payouts []Payout
+		seen    = make(map[string]int)
+	)
+
+	for n, record := range records[start:] {
+		if isBlankRecord(record) {
+			continue
+		}
+
+		cell := func(col int) string {
+			if columns[col] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[columns[col]])
+		}
+
+		row := PayoutImportRow{Line: lines[start+n], Reference: cell(2)}
+
+		phone, phoneErr := NormalizePhoneNumber(cell(0))
+		amount, amountErr := i.parseAmount(cell(1))
+
+		row.PhoneNumber = phone
+		row.Amount = amount
+
+		switch {
+		case phoneErr != nil:
+			row.Err = phoneErr
+		case amountErr != nil:
+			row.Err = amountErr
+		case row.Reference == "":
+			row.Err = errors.New("mpesa: payout reference cannot be empty")
+		case seen[row.Reference] != 0:
+			row.Err = fmt.Errorf("%w: %s is also on line %d", ErrDuplicatePayout, row.Reference, seen[row.Reference])
+		default:
+			if _, ok := i.pipeline.Payout(row.Reference); ok {
+				row.Err = fmt.Errorf("%w: %s", ErrDuplicatePayout, row.Reference)
+			}
+		}
+
+		if row.Reference != "" && seen[row.Reference] == 0 {
+			seen[row.Reference] = row.Line
+		}
+
+		if row.Err != nil {
+			report.Rejected++
+		} else {
+			payouts = append(payouts, Payout{
+				ID:          row.Reference,
+				PhoneNumber: phone,
+				Amount:      amount,
+				CommandID:   i.cfg.CommandID,
+				Remarks:     i.cfg.Remarks,
+				Occasion:    i.cfg.Occasion,
+			})
+		}
+
+		report.Rows = append(report.Rows, row)
+	}
+
+	if len(report.Rows) == 0 {
+		return nil, ErrEmptyPayoutImport
+	}
+
+	if len(payouts) > 0 {
+		if err := i.pipeline.Enqueue(payouts...); err != nil {
+			return report, err
+		}
+		report.Accepted = len(payouts)
+	}
+
+	report.Refresh(i.pipeline)
+	return report, nil
+}
+
+// payoutImportHeader returns the positions of the phone, amount and reference columns if the record is a header row.
+func payoutImportHeader(record []string) ([3]int, bool) {
+	columns := [3]int{-1, -1, -1}
+
+	for i, cell := range record {
+		switch strings.ToLower(strings.TrimSpace(cell)) {
+		case "phone", "phone number", "phonenumber", "msisdn", "mobile":
+			columns[0] = i
+		case "amount":
+			columns[1] = i
+		case "reference", "ref", "id", "payout id":
+			columns[2] = i
+		}
+	}
+
+	for _, i := range columns {
+		if i == -1 {
+			return columns, false
+		}
+	}
+
+	return columns, true
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Refresh updates the status and result of the accepted rows from the payouts held by the pipeline. Call it after
+// the batches are processed and the result callbacks handled to get the final report.
+func (r *PayoutImportReport) Refresh(pipeline *DisbursementPipeline) {
+	for i := range r.Rows {
+		row := &r.Rows[i]
+		if row.Status == "" && row.Err != nil {
+			continue
+		}
+
+		payout, ok := pipeline.Payout(row.Reference)
+		if !ok {
+			continue
+		}
+
+		row.Status = payout.Status
+		row.ReceiptNumber = payout.ReceiptNumber
+		row.ResultCode = payout.ResultCode
+		row.ResultDesc = payout.ResultDesc
+		row.Err = payout.Err
+	}
+}
+
+// WriteCSV writes the report as CSV with a header row.
+func (r *PayoutImportReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{
+		"line", "reference", "phone", "amount", "status", "receipt", "result_code", "result_desc", "error",
+	}); err != nil {
+		return err
+	}
+
+	for _, row := range r.Rows {
+		var phone, errMsg string
+		if row.PhoneNumber != 0 {
+			phone = strconv.FormatUint(row.PhoneNumber, 10)
+		}
+
+		if row.Err != nil {
+			errMsg = row.Err.Error()
+		}
+
+		status := string(row.Status)
+		if status == "" {
+			status = "Invalid"
+		}
+
+		if err := cw.Write([]string{
+			strconv.Itoa(row.Line),
+			row.Reference,
+			phone,
+			strconv.FormatUint(uint64(row.Amount), 10),
+			status,
+			row.ReceiptNumber,
+			strconv.Itoa(row.ResultCode),
+			row.ResultDesc,
+			errMsg,
+		}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,125 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayoutImporter_Import(t *testing.T) {
+	newImporter := func() (*PayoutImporter, *DisbursementPipeline, *mockHttpClient) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+		mockAuth(app, cl)
+
+		p := NewDisbursementPipeline(app, DisbursementConfig{
+			InitiatorName:     "testapi",
+			InitiatorPassword: "Safaricom999!*!",
+			ShortCode:         600426,
+			QueueTimeOutURL:   "https://example.com/timeout",
+			ResultURL:         "https://example.com/result",
+		})
+
+		return NewPayoutImporter(p, PayoutImportConfig{Remarks: "Commission"}), p, cl
+	}
+
+	t.Run("it validates every row and enqueues the valid ones", func(t *testing.T) {
+		importer, p, _ := newImporter()
+
+		csv := strings.Join([]string{
+			"Reference,Phone,Amount",
+			"PAY-1,0708374149,\"1,500.00\"",
+			"PAY-2,0208374149,100",
+			"PAY-3,0708374150,5",
+			"PAY-4,0708374151,10.50",
+			"",
+			"PAY-1,0708374152,100",
+			",0708374153,100",
+			"PAY-5,+254708374154,250",
+		}, "\n")
+
+		report, err := importer.Import(strings.NewReader(csv))
+		require.NoError(t, err)
+		require.Equal(t, 2, report.Accepted)
+		require.Equal(t, 5, report.Rejected)
+		require.Len(t, report.Rows, 7)
+		require.Equal(t, 2, p.Pending())
+
+		first := report.Rows[0]
+		require.Equal(t, 2, first.Line)
+		require.Equal(t, "PAY-1", first.Reference)
+		require.Equal(t, uint64(254708374149), first.PhoneNumber)
+		require.Equal(t, uint(1500), first.Amount)
+		require.Equal(t, PayoutStatusQueued, first.Status)
+		require.NoError(t, first.Err)
+
+		require.True(t, errors.Is(report.Rows[1].Err, ErrInvalidPhoneNumber))
+		require.True(t, errors.Is(report.Rows[2].Err, ErrInvalidPayoutAmount))
+		require.True(t, errors.Is(report.Rows[3].Err, ErrInvalidPayoutAmount))
+
+		duplicate := report.Rows[4]
+		require.Equal(t, 7, duplicate.Line)
+		require.True(t, errors.Is(duplicate.Err, ErrDuplicatePayout))
+		require.Empty(t, duplicate.Status)
+
+		require.Error(t, report.Rows[5].Err)
+		require.Equal(t, PayoutStatusQueued, report.Rows[6].Status)
+
+		payout, ok := p.Payout("PAY-5")
+		require.True(t, ok)
+		require.Equal(t, "Commission", payout.Remarks)
+		require.Equal(t, BusinessPaymentCommandID, payout.CommandID)
+
+		report, err = importer.Import(strings.NewReader("0708374149,100,PAY-5\n"))
+		require.NoError(t, err)
+		require.Equal(t, 0, report.Accepted)
+		require.True(t, errors.Is(report.Rows[0].Err, ErrDuplicatePayout))
+	})
+
+	t.Run("the report is refreshed from the pipeline", func(t *testing.T) {
+		importer, p, cl := newImporter()
+
+		cl.MockRequest(p.app.endpointB2C(), func() (status int, body string) {
+			return http.StatusOK, `{"ConversationID": "AG_1", "OriginatorConversationID": "OG_1", "ResponseCode": "0"}`
+		})
+
+		report, err := importer.Import(strings.NewReader("0708374149,100,PAY-1\n0708374149,-1,PAY-2\n"))
+		require.NoError(t, err)
+
+		_, err = p.Process(context.Background())
+		require.NoError(t, err)
+
+		_, err = p.HandleResult(&Callback{Result: CallbackResult{
+			ConversationID: "AG_1",
+			ResultDesc:     "The service request is processed successfully.",
+			TransactionID:  "NLJ41HAY6Q",
+		}})
+		require.NoError(t, err)
+
+		report.Refresh(p)
+		require.Equal(t, PayoutStatusCompleted, report.Rows[0].Status)
+		require.Equal(t, "NLJ41HAY6Q", report.Rows[0].ReceiptNumber)
+		require.Empty(t, report.Rows[1].Status)
+
+		var buf bytes.Buffer
+		require.NoError(t, report.WriteCSV(&buf))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3)
+		require.Equal(t, "line,reference,phone,amount,status,receipt,result_code,result_desc,error", lines[0])
+		require.True(t, strings.HasPrefix(lines[1], "1,PAY-1,254708374149,100,Completed,NLJ41HAY6Q,0,"))
+		require.True(t, strings.HasPrefix(lines[2], "2,PAY-2,254708374149,0,Invalid,"))
+	})
+
+	t.Run("an empty file is rejected", func(t *testing.T) {
+		importer, _, _ := newImporter()
+
+		_, err := importer.Import(strings.NewReader("phone,amount,reference\n"))
+		require.ErrorIs(t, err, ErrEmptyPayoutImport)
+	})
+}
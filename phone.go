@@ -0,0 +1,90 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MSISDN is a Safaricom phone number in the 2547XXXXXXXX or 2541XXXXXXXX format expected by the API.
+type MSISDN uint64
+
+// ErrInvalidPhoneNumber indicates that a phone number is not a valid Safaricom number.
+var ErrInvalidPhoneNumber = errors.New("mpesa: invalid phone number")
+
+// ParsePhoneNumber normalizes a phone number given as 07XXXXXXXX, 01XXXXXXXX, 7XXXXXXXX, 2547XXXXXXXX or
+// +2547XXXXXXXX to the 2547XXXXXXXX or 2541XXXXXXXX format. Spaces, dashes and parentheses are ignored.
+func ParsePhoneNumber(phone string) (MSISDN, error) {
+	digits := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(strings.TrimSpace(phone))
+	digits = strings.TrimPrefix(digits, "+")
+
+	switch {
+	case len(digits) == 10 && digits[0] == '0':
+		digits = "254" + digits[1:]
+	case len(digits) == 9:
+		digits = "254" + digits
+	}
+
+	if len(digits) != 12 || !strings.HasPrefix(digits, "254") || (digits[3] != '7' && digits[3] != '1') {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPhoneNumber, phone)
+	}
+
+	msisdn, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidPhoneNumber, phone)
+	}
+
+	return MSISDN(msisdn), nil
+}
+
+// NormalizePhoneNumber is like ParsePhoneNumber but returns the number as an uint64.
+func NormalizePhoneNumber(phone string) (uint64, error) {
+	msisdn, err := ParsePhoneNumber(phone)
+	return uint64(msisdn), err
+}
+
+func (n MSISDN) String() string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// resolvePhone sets PhoneNumber, and PartyA if it is not set, from Phone.
+func (r *STKPushRequest) resolvePhone() error {
+	if r.Phone == "" {
+		return nil
+	}
+
+	msisdn, err := ParsePhoneNumber(r.Phone)
+	if err != nil {
+		return invalidPhone()
+	}
+
+	r.PhoneNumber = uint64(msisdn)
+	if r.PartyA == 0 {
+		r.PartyA = uint(msisdn)
+	}
+
+	return nil
+}
+
+// resolvePhone sets PartyB from Phone.
+func (r *B2CRequest) resolvePhone() error {
+	if r.Phone == "" {
+		return nil
+	}
+
+	msisdn, err := ParsePhoneNumber(r.Phone)
+	if err != nil {
+		return invalidPhone()
+	}
+
+	r.PartyB = uint64(msisdn)
+	return nil
+}
+
+// invalidPhone is the ValidationError returned when the Phone field of a request cannot be parsed.
+func invalidPhone() error {
+	var v validator
+	v.add("Phone", "must be a Safaricom phone number, e.g. 0712345678 or +254712345678")
+	return v.err()
+}
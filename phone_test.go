@@ -0,0 +1,103 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePhoneNumber(t *testing.T) {
+	tests := []struct {
+		phone string
+		want  MSISDN
+	}{
+		{phone: "0708374149", want: 254708374149},
+		{phone: "0110374149", want: 254110374149},
+		{phone: "+254 708 374 149", want: 254708374149},
+		{phone: "+254110374149", want: 254110374149},
+		{phone: "254708374149", want: 254708374149},
+		{phone: "708-374-149", want: 254708374149},
+	}
+
+	for _, tc := range tests {
+		got, err := ParsePhoneNumber(tc.phone)
+		require.NoError(t, err, tc.phone)
+		require.Equal(t, tc.want, got, tc.phone)
+		require.Len(t, got.String(), 12)
+
+		normalized, err := NormalizePhoneNumber(tc.phone)
+		require.NoError(t, err, tc.phone)
+		require.Equal(t, uint64(tc.want), normalized)
+	}
+
+	for _, phone := range []string{"", "0208374149", "25470837414", "+1 202 555 0143", "07O8374149"} {
+		_, err := ParsePhoneNumber(phone)
+		require.ErrorIs(t, err, ErrInvalidPhoneNumber, phone)
+	}
+}
+
+func TestMpesa_Phone(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = newMockHttpClient()
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+		var req STKPushRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+		require.Equal(t, uint(254708374149), req.PartyA)
+		require.Equal(t, uint64(254708374149), req.PhoneNumber)
+
+		return http.StatusOK, `{"CheckoutRequestID": "ws_CO_191220191020363925", "ResponseCode": "0"}`
+	})
+
+	cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+		var req B2CRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+		require.Equal(t, uint64(254110374149), req.PartyB)
+
+		return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+	})
+
+	stkReq := STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyB:            174379,
+		Phone:             "0708 374 149",
+		CallBackURL:       "https://example.com/callback",
+		AccountReference:  "INV-001",
+		TransactionDesc:   "Test payment",
+	}
+
+	_, err := app.STKPush(ctx, "passkey", stkReq)
+	require.NoError(t, err)
+
+	b2cReq := B2CRequest{
+		InitiatorName:   "testapi",
+		CommandID:       BusinessPaymentCommandID,
+		Amount:          10,
+		PartyA:          600980,
+		Phone:           "+254110374149",
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	}
+
+	_, err = app.B2C(ctx, "random-string", b2cReq)
+	require.NoError(t, err)
+
+	stkReq.Phone = "0208374149"
+	_, err = app.STKPush(ctx, "passkey", stkReq)
+	require.ErrorIs(t, err, ErrInvalidRequest)
+	require.EqualError(t, ValidationErrors(err)[0], "Phone must be a Safaricom phone number, e.g. 0712345678 or +254712345678")
+
+	b2cReq.Phone = "12345"
+	_, err = app.B2C(ctx, "random-string", b2cReq)
+	require.ErrorIs(t, err, ErrInvalidRequest)
+}
@@ -0,0 +1,407 @@
+package mpesa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// QRFormat is an output format for a Dynamic QR code.
+type QRFormat string
+
+const (
+	// QRFormatPNG is the PNG image returned by Safaricom, including the merchant name and amount captions.
+	QRFormatPNG QRFormat = "png"
+
+	// QRFormatSVG is a vector image of the QR code rendered locally.
+	QRFormatSVG QRFormat = "svg"
+
+	// QRFormatPDF is a single page PDF document with the QR code rendered locally, for printing.
+	QRFormatPDF QRFormat = "pdf"
+)
+
+// defaultQRSize is the width and height of the rendered QR codes when no size is set.
+const defaultQRSize = 300
+
+// qrQuietZone is the number of light modules drawn around the QR code.
+const qrQuietZone = 4
+
+var (
+	// ErrInvalidQRImage indicates that no QR code could be found on the image.
+	ErrInvalidQRImage = errors.New("mpesa: no qr code found on the image")
+
+	// ErrUnsupportedQRFormat indicates that the QRFormat is not supported.
+	ErrUnsupportedQRFormat = errors.New("mpesa: unsupported qr format")
+)
+
+// WithQRImageDir makes DynamicQR save the decoded images to dir, creating it if needed. Without it the images are only
+// returned on DynamicQRResponse.ImageData.
+func WithQRImageDir(dir string) Option {
+	return func(m *Mpesa) {
+		m.qrImageDir = dir
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func (f QRFormat) ContentType() string {
+	switch f {
+	case QRFormatPNG:
+		return "image/png"
+	case QRFormatSVG:
+		return "image/svg+xml"
+	case QRFormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// QRMatrix is the grid of modules of a QR code, without the quiet zone.
+type QRMatrix struct {
+	size    int
+	modules []bool
+}
+
+// Size returns the number of modules on each side of the QR code.
+func (q *QRMatrix) Size() int {
+	return q.size
+}
+
+// Dark returns true if the module at column x and row y is dark.
+func (q *QRMatrix) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.size || y >= q.size {
+		return false
+	}
+
+	return q.modules[y*q.size+x]
+}
+
+// qrImage is a binarized image.
+type qrImage struct {
+	bounds image.Rectangle
+	dark   []bool
+}
+
+func newQRImage(img image.Image) *qrImage {
+	b := img.Bounds()
+	q := &qrImage{bounds: b, dark: make([]bool, b.Dx()*b.Dy())}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			luminance := (299*r + 587*g + 114*bl) / 1000
+			q.dark[(y-b.Min.Y)*b.Dx()+(x-b.Min.X)] = a > 0x8000 && luminance < 0x8000
+		}
+	}
+
+	return q
+}
+
+func (q *qrImage) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= q.bounds.Dx() || y >= q.bounds.Dy() {
+		return false
+	}
+
+	return q.dark[y*q.bounds.Dx()+x]
+}
+
+// finderMatch is a horizontal run of pixels matching the 1:1:3:1:1 ratio of a finder pattern.
+type finderMatch struct {
+	start, width int
+}
+
+// findersOnRow returns the runs on the row that match the finder pattern ratio.
+func (q *qrImage) findersOnRow(y int) []finderMatch {
+	type run struct {
+		dark          bool
+		start, length int
+	}
+
+	var runs []run
+	for x := 0; x < q.bounds.Dx(); x++ {
+		dark := q.at(x, y)
+		if len(runs) > 0 && runs[len(runs)-1].dark == dark {
+			runs[len(runs)-1].length++
+			continue
+		}
+		runs = append(runs, run{dark: dark, start: x, length: 1})
+	}
+
+	var matches []finderMatch
+	for i := 0; i+4 < len(runs); i++ {
+		if !runs[i].dark {
+			continue
+		}
+
+		total := 0
+		for _, r := range runs[i : i+5] {
+			total += r.length
+		}
+
+		unit := float64(total) / 7
+		if unit < 1 {
+			continue
+		}
+
+		ok := true
+		for j, want := range []float64{1, 1, 3, 1, 1} {
+			if math.Abs(float64(runs[i+j].length)-want*unit) > want*unit/2 {
+				ok = false
+				break
+			}
+		}
+
+		if ok {
+			matches = append(matches, finderMatch{start: runs[i].start, width: total})
+		}
+	}
+
+	return matches
+}
+
+// QRMatrixFromImage extracts the modules of the QR code on the image, e.g. the PNG returned by the Dynamic QR API.
+// The image must be upright and not skewed, as generated images are.
+func QRMatrixFromImage(img image.Image) (*QRMatrix, error) {
+	q := newQRImage(img)
+
+	for y := 0; y < q.bounds.Dy(); y++ {
+		matches := q.findersOnRow(y)
+		if len(matches) < 2 {
+			continue
+		}
+
+		left, right := matches[0], matches[len(matches)-1]
+		module := float64(left.width) / 7
+		width := float64(right.start + right.width - left.start)
+
+		size := int(math.Round(width / module))
+		if size < 21 {
+			continue
+		}
+
+		// Versions have 21 + 4n modules, snap to the closest one.
+		size = 21 + int(math.Round(float64(size-21)/4))*4
+		module = width / float64(size)
+
+		top := y
+		column := left.start + int(module/2)
+		for top > 0 && q.at(column, top-1) {
+			top--
+		}
+
+		matrix := &QRMatrix{size: size, modules: make([]bool, size*size)}
+		for row := 0; row < size; row++ {
+			for col := 0; col < size; col++ {
+				matrix.modules[row*size+col] = q.at(
+					left.start+int((float64(col)+0.5)*module),
+					top+int((float64(row)+0.5)*module),
+				)
+			}
+		}
+
+		if matrix.hasFinderPatterns() {
+			return matrix, nil
+		}
+	}
+
+	return nil, ErrInvalidQRImage
+}
+
+// hasFinderPatterns returns true if the three finder patterns are where they are expected.
+func (q *QRMatrix) hasFinderPatterns() bool {
+	for _, origin := range [][2]int{{0, 0}, {q.size - 7, 0}, {0, q.size - 7}} {
+		mismatches := 0
+
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				ring := max(abs(dx-3), abs(dy-3))
+				if q.Dark(origin[0]+dx, origin[1]+dy) != (ring != 2) {
+					mismatches++
+				}
+			}
+		}
+
+		if mismatches > 2 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
+
+// darkRuns calls fn with every horizontal run of dark modules.
+func (q *QRMatrix) darkRuns(fn func(x, y, length int)) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.Dark(x, y) {
+				continue
+			}
+
+			start := x
+			for x < q.size && q.Dark(x, y) {
+				x++
+			}
+			fn(start, y, x-start)
+		}
+	}
+}
+
+// WriteSVG writes the QR code as an SVG image of size by size pixels including the quiet zone.
+func (q *QRMatrix) WriteSVG(w io.Writer, size int) error {
+	if size <= 0 {
+		size = defaultQRSize
+	}
+
+	var path strings.Builder
+	q.darkRuns(func(x, y, length int) {
+		fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", x+qrQuietZone, y+qrQuietZone, length, length)
+	})
+
+	view := q.size + 2*qrQuietZone
+
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">
+<rect width="100%%" height="100%%" fill="#fff"/>
+<path fill="#000" d="%s"/>
+</svg>
+`, size, size, view, view, path.String())
+
+	return err
+}
+
+// WritePDF writes the QR code as a single page PDF document. The page is size by size points including the quiet
+// zone, 72 points make an inch.
+func (q *QRMatrix) WritePDF(w io.Writer, size int) error {
+	if size <= 0 {
+		size = defaultQRSize
+	}
+
+	module := float64(size) / float64(q.size+2*qrQuietZone)
+	format := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+
+	var content strings.Builder
+	content.WriteString("0 0 0 rg\n")
+	q.darkRuns(func(x, y, length int) {
+		// PDF coordinates start at the bottom left corner of the page.
+		content.WriteString(format(float64(x+qrQuietZone)*module) + " ")
+		content.WriteString(format(float64(size)-float64(y+qrQuietZone+1)*module) + " ")
+		content.WriteString(format(float64(length)*module) + " " + format(module) + " re\n")
+	})
+	content.WriteString("f\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Contents 4 0 R /Resources << >> >>", size, size),
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// PNG returns the decoded PNG image returned by the Dynamic QR API.
+func (r *DynamicQRResponse) PNG() ([]byte, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(r.QRCode))
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: decode qr code: %v", err)
+	}
+
+	return b, nil
+}
+
+// Matrix returns the modules of the QR code on the image returned by the Dynamic QR API.
+func (r *DynamicQRResponse) Matrix() (*QRMatrix, error) {
+	b, err := r.PNG()
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: decode png: %v", err)
+	}
+
+	return QRMatrixFromImage(img)
+}
+
+// Render writes the QR code in the format. The SVG and PDF outputs are rendered locally from the modules of the QR
+// code and are size by size pixels or points, the PNG is written as returned by the API and size is ignored.
+func (r *DynamicQRResponse) Render(w io.Writer, format QRFormat, size int) error {
+	if format == QRFormatPNG {
+		b, err := r.PNG()
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+		return err
+	}
+
+	if format != QRFormatSVG && format != QRFormatPDF {
+		return fmt.Errorf("%w: %q", ErrUnsupportedQRFormat, format)
+	}
+
+	matrix, err := r.Matrix()
+	if err != nil {
+		return err
+	}
+
+	if format == QRFormatSVG {
+		return matrix.WriteSVG(w, size)
+	}
+
+	return matrix.WritePDF(w, size)
+}
+
+// DataURI returns the QR code in the format as a data URI that can be used as the src of an img tag or embedded in
+// a document.
+func (r *DynamicQRResponse) DataURI(format QRFormat, size int) (string, error) {
+	if format == QRFormatPNG {
+		if _, err := r.PNG(); err != nil {
+			return "", err
+		}
+
+		return "data:" + format.ContentType() + ";base64," + strings.TrimSpace(r.QRCode), nil
+	}
+
+	var buf bytes.Buffer
+	if err := r.Render(&buf, format, size); err != nil {
+		return "", err
+	}
+
+	return "data:" + format.ContentType() + ";base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,440 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ErrUnsupportedQRCode indicates that the QR code uses a version or encoding mode the decoder does not support.
+var ErrUnsupportedQRCode = errors.New("mpesa: unsupported qr code")
+
+// qrMaxVersion is the largest QR code version the decoder supports. M-Pesa payloads fit in much smaller codes.
+const qrMaxVersion = 10
+
+// qrErrorCorrectionLevels maps the 2 bit error correction level of the format information to its index in
+// qrBlocks.
+var qrErrorCorrectionLevels = [4]int{1, 0, 3, 2} // M, L, H, Q
+
+// qrBlockGroup is a group of error correction blocks with the same number of data codewords.
+type qrBlockGroup struct {
+	count, data int
+}
+
+// qrBlockLayout is the error correction block structure of a version and error correction level.
+type qrBlockLayout struct {
+	ec     int
+	groups []qrBlockGroup
+}
+
+// qrBlocks holds the block layouts of versions 1 to 10 for the L, M, Q and H error correction levels.
+var qrBlocks = [qrMaxVersion][4]qrBlockLayout{
+	{{7, []qrBlockGroup{{1, 19}}}, {10, []qrBlockGroup{{1, 16}}}, {13, []qrBlockGroup{{1, 13}}}, {17, []qrBlockGroup{{1, 9}}}},
+	{{10, []qrBlockGroup{{1, 34}}}, {16, []qrBlockGroup{{1, 28}}}, {22, []qrBlockGroup{{1, 22}}}, {28, []qrBlockGroup{{1, 16}}}},
+	{{15, []qrBlockGroup{{1, 55}}}, {26, []qrBlockGroup{{1, 44}}}, {18, []qrBlockGroup{{2, 17}}}, {22, []qrBlockGroup{{2, 13}}}},
+	{{20, []qrBlockGroup{{1, 80}}}, {18, []qrBlockGroup{{2, 32}}}, {26, []qrBlockGroup{{2, 24}}}, {16, []qrBlockGroup{{4, 9}}}},
+	{
+		{26, []qrBlockGroup{{1, 108}}}, {24, []qrBlockGroup{{2, 43}}},
+		{18, []qrBlockGroup{{2, 15}, {2, 16}}}, {22, []qrBlockGroup{{2, 11}, {2, 12}}},
+	},
+	{{18, []qrBlockGroup{{2, 68}}}, {16, []qrBlockGroup{{4, 27}}}, {24, []qrBlockGroup{{4, 19}}}, {28, []qrBlockGroup{{4, 15}}}},
+	{
+		{20, []qrBlockGroup{{2, 78}}}, {18, []qrBlockGroup{{4, 31}}},
+		{18, []qrBlockGroup{{2, 14}, {4, 15}}}, {26, []qrBlockGroup{{4, 13}, {1, 14}}},
+	},
+	{
+		{24, []qrBlockGroup{{2, 97}}}, {22, []qrBlockGroup{{2, 38}, {2, 39}}},
+		{22, []qrBlockGroup{{4, 18}, {2, 19}}}, {26, []qrBlockGroup{{4, 14}, {2, 15}}},
+	},
+	{
+		{30, []qrBlockGroup{{2, 116}}}, {22, []qrBlockGroup{{3, 36}, {2, 37}}},
+		{20, []qrBlockGroup{{4, 16}, {4, 17}}}, {24, []qrBlockGroup{{4, 12}, {4, 13}}},
+	},
+	{
+		{18, []qrBlockGroup{{2, 68}, {2, 69}}}, {26, []qrBlockGroup{{4, 43}, {1, 44}}},
+		{24, []qrBlockGroup{{6, 19}, {2, 20}}}, {28, []qrBlockGroup{{6, 15}, {2, 16}}},
+	},
+}
+
+// qrAlignmentPatterns holds the alignment pattern center coordinates of versions 1 to 10.
+var qrAlignmentPatterns = [qrMaxVersion][]int{
+	nil, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34}, {6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+// qrMasks are the data mask conditions. A module at row i and column j is inverted when the condition is true.
+var qrMasks = [8]func(i, j int) bool{
+	func(i, j int) bool { return (i+j)%2 == 0 },
+	func(i, j int) bool { return i%2 == 0 },
+	func(i, j int) bool { return j%3 == 0 },
+	func(i, j int) bool { return (i+j)%3 == 0 },
+	func(i, j int) bool { return (i/2+j/3)%2 == 0 },
+	func(i, j int) bool { return i*j%2+i*j%3 == 0 },
+	func(i, j int) bool { return (i*j%2+i*j%3)%2 == 0 },
+	func(i, j int) bool { return ((i+j)%2+i*j%3)%2 == 0 },
+}
+
+// DecodeQRMatrix returns the text encoded in the QR code. Only the numeric, alphanumeric and byte modes of versions 1
+// to 10 are supported. Codes with damaged modules are rejected rather than corrected, which is sufficient for
+// generated images such as the ones returned by the Dynamic QR API.
+func DecodeQRMatrix(q *QRMatrix) (string, error) {
+	version := (q.size - 17) / 4
+	if version < 1 || version > qrMaxVersion || q.size != 17+4*version {
+		return "", fmt.Errorf("%w: %d modules", ErrUnsupportedQRCode, q.size)
+	}
+
+	level, mask, err := q.formatInformation()
+	if err != nil {
+		return "", err
+	}
+
+	layout := qrBlocks[version-1][level]
+	codewords := q.codewords(version, mask)
+
+	data, err := deinterleaveQRBlocks(codewords, layout)
+	if err != nil {
+		return "", err
+	}
+
+	return decodeQRSegments(data, version)
+}
+
+// formatInformation returns the error correction level and data mask of the QR code.
+func (q *QRMatrix) formatInformation() (level, mask int, err error) {
+	var read uint32
+	for _, p := range [][2]int{
+		{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8},
+		{8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0},
+	} {
+		read <<= 1
+		if q.Dark(p[0], p[1]) {
+			read |= 1
+		}
+	}
+
+	best, distance := -1, 4
+	for data := uint32(0); data < 32; data++ {
+		if d := bits.OnesCount32(read ^ qrFormatCode(data)); d < distance {
+			best, distance = int(data), d
+		}
+	}
+
+	if best == -1 {
+		return 0, 0, fmt.Errorf("%w: unreadable format information", ErrInvalidQRImage)
+	}
+
+	return qrErrorCorrectionLevels[best>>3], best & 7, nil
+}
+
+// qrFormatCode returns the masked BCH(15,5) code of the 5 bit format information.
+func qrFormatCode(data uint32) uint32 {
+	code := data << 10
+	for i := 14; i >= 10; i-- {
+		if code&(1<<i) != 0 {
+			code ^= 0x537 << (i - 10)
+		}
+	}
+
+	return (data<<10 | code) ^ 0x5412
+}
+
+// isFunctionModule returns true if the module at column x and row y is part of a function pattern and carries no
+// data.
+func isFunctionModule(version, size, x, y int) bool {
+	switch {
+	case x < 9 && y < 9, x >= size-8 && y < 9, x < 9 && y >= size-8:
+		return true // Finder patterns, separators and format information.
+	case x == 6 || y == 6:
+		return true // Timing patterns.
+	case version >= 7 && ((x >= size-11 && y < 6) || (x < 6 && y >= size-11)):
+		return true // Version information.
+	}
+
+	centers := qrAlignmentPatterns[version-1]
+	for _, cy := range centers {
+		for _, cx := range centers {
+			if (cx == 6 && cy == 6) || (cx == 6 && cy == centers[len(centers)-1]) ||
+				(cy == 6 && cx == centers[len(centers)-1]) {
+				continue
+			}
+
+			if abs(x-cx) <= 2 && abs(y-cy) <= 2 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// codewords reads the data and error correction codewords of the QR code in placement order.
+func (q *QRMatrix) codewords(version, mask int) []byte {
+	var (
+		out     []byte
+		current byte
+		n       int
+		up      = true
+	)
+
+	for right := q.size - 1; right > 0; right -= 2 {
+		if right == 6 {
+			right--
+		}
+
+		for count := 0; count < q.size; count++ {
+			y := count
+			if up {
+				y = q.size - 1 - count
+			}
+
+			for col := 0; col < 2; col++ {
+				x := right - col
+				if isFunctionModule(version, q.size, x, y) {
+					continue
+				}
+
+				current <<= 1
+				if q.Dark(x, y) != qrMasks[mask](y, x) {
+					current |= 1
+				}
+
+				if n++; n == 8 {
+					out = append(out, current)
+					current, n = 0, 0
+				}
+			}
+		}
+
+		up = !up
+	}
+
+	return out
+}
+
+// deinterleaveQRBlocks returns the data codewords of the blocks in order after verifying the error correction
+// codewords of every block.
+func deinterleaveQRBlocks(codewords []byte, layout qrBlockLayout) ([]byte, error) {
+	var blocks [][]byte
+	for _, group := range layout.groups {
+		for i := 0; i < group.count; i++ {
+			blocks = append(blocks, make([]byte, 0, group.data+layout.ec))
+		}
+	}
+
+	offset := 0
+	next := func() byte {
+		if offset >= len(codewords) {
+			return 0
+		}
+		offset++
+		return codewords[offset-1]
+	}
+
+	// Data codewords are interleaved across the blocks, the longer blocks come last.
+	longest := layout.groups[len(layout.groups)-1].data
+	for i := 0; i < longest; i++ {
+		b := 0
+		for _, group := range layout.groups {
+			for j := 0; j < group.count; j, b = j+1, b+1 {
+				if i < group.data {
+					blocks[b] = append(blocks[b], next())
+				}
+			}
+		}
+	}
+
+	for i := 0; i < layout.ec; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], next())
+		}
+	}
+
+	var data []byte
+	for _, block := range blocks {
+		if !validQRBlock(block, layout.ec) {
+			return nil, fmt.Errorf("%w: damaged modules", ErrInvalidQRImage)
+		}
+		data = append(data, block[:len(block)-layout.ec]...)
+	}
+
+	return data, nil
+}
+
+// validQRBlock returns true if the Reed-Solomon syndromes of the block are all zero.
+func validQRBlock(block []byte, ec int) bool {
+	for i := 0; i < ec; i++ {
+		alpha := gf256Exp(i)
+
+		var syndrome byte
+		for _, c := range block {
+			syndrome = gf256Mul(syndrome, alpha) ^ c
+		}
+
+		if syndrome != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// gf256Mul multiplies a and b in GF(256) with the QR code polynomial x^8 + x^4 + x^3 + x^2 + 1.
+func gf256Mul(a, b byte) byte {
+	var p byte
+	for b > 0 {
+		if b&1 != 0 {
+			p ^= a
+		}
+
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1d
+		}
+		b >>= 1
+	}
+
+	return p
+}
+
+// gf256Exp returns alpha^n in GF(256).
+func gf256Exp(n int) byte {
+	v := byte(1)
+	for i := 0; i < n; i++ {
+		v = gf256Mul(v, 2)
+	}
+
+	return v
+}
+
+// qrBitReader reads big endian bit fields from the data codewords.
+type qrBitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *qrBitReader) available() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *qrBitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v <<= 1
+		if r.data[r.pos/8]&(0x80>>(r.pos%8)) != 0 {
+			v |= 1
+		}
+		r.pos++
+	}
+
+	return v
+}
+
+// qrAlphanumeric is the character set of the alphanumeric mode.
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// decodeQRSegments decodes the segments of the data codewords.
+func decodeQRSegments(data []byte, version int) (string, error) {
+	var (
+		r   = &qrBitReader{data: data}
+		out strings.Builder
+	)
+
+	// Character count indicators are longer from version 10.
+	countBits := map[int]int{1: 10, 2: 9, 4: 8}
+	if version >= 10 {
+		countBits = map[int]int{1: 12, 2: 11, 4: 16}
+	}
+
+	truncated := fmt.Errorf("%w: truncated data", ErrInvalidQRImage)
+
+	for r.available() >= 4 {
+		mode := r.read(4)
+		if mode == 0 {
+			break
+		}
+
+		if mode == 7 {
+			// ECI designators are skipped, the payloads are expected to be ASCII or UTF-8.
+			if r.available() < 8 {
+				return "", truncated
+			}
+
+			switch first := r.read(8); {
+			case first&0x80 == 0:
+			case first&0xc0 == 0x80:
+				r.read(8)
+			default:
+				r.read(16)
+			}
+			continue
+		}
+
+		bitsLen, ok := countBits[mode]
+		if !ok {
+			return "", fmt.Errorf("%w: mode %d", ErrUnsupportedQRCode, mode)
+		}
+
+		if r.available() < bitsLen {
+			return "", truncated
+		}
+		count := r.read(bitsLen)
+
+		switch mode {
+		case 1:
+			for ; count >= 3; count -= 3 {
+				if r.available() < 10 {
+					return "", truncated
+				}
+				fmt.Fprintf(&out, "%03d", r.read(10))
+			}
+
+			if count == 2 {
+				if r.available() < 7 {
+					return "", truncated
+				}
+				fmt.Fprintf(&out, "%02d", r.read(7))
+			} else if count == 1 {
+				if r.available() < 4 {
+					return "", truncated
+				}
+				fmt.Fprintf(&out, "%d", r.read(4))
+			}
+		case 2:
+			for ; count >= 2; count -= 2 {
+				if r.available() < 11 {
+					return "", truncated
+				}
+
+				v := r.read(11)
+				if v/45 >= len(qrAlphanumeric) {
+					return "", truncated
+				}
+				out.WriteByte(qrAlphanumeric[v/45])
+				out.WriteByte(qrAlphanumeric[v%45])
+			}
+
+			if count == 1 {
+				if r.available() < 6 {
+					return "", truncated
+				}
+
+				v := r.read(6)
+				if v >= len(qrAlphanumeric) {
+					return "", truncated
+				}
+				out.WriteByte(qrAlphanumeric[v])
+			}
+		case 4:
+			if r.available() < 8*count {
+				return "", truncated
+			}
+
+			for i := 0; i < count; i++ {
+				out.WriteByte(byte(r.read(8)))
+			}
+		}
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,86 @@
+package mpesa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeQRMatrix(t *testing.T) {
+	matrix, err := (&DynamicQRResponse{QRCode: testDynamicQRCode}).Matrix()
+	require.NoError(t, err)
+
+	text, err := DecodeQRMatrix(matrix)
+	require.NoError(t, err)
+	require.Equal(t, "BG|174379|10|NULLABLE", text)
+
+	// Flip a data module in the bottom right corner.
+	damaged := &QRMatrix{size: matrix.size, modules: append([]bool(nil), matrix.modules...)}
+	last := len(damaged.modules) - 1
+	damaged.modules[last] = !damaged.modules[last]
+
+	_, err = DecodeQRMatrix(damaged)
+	require.ErrorIs(t, err, ErrInvalidQRImage)
+
+	_, err = DecodeQRMatrix(&QRMatrix{size: 23, modules: make([]bool, 23*23)})
+	require.ErrorIs(t, err, ErrUnsupportedQRCode)
+
+	_, err = DecodeQRMatrix(&QRMatrix{size: 61, modules: make([]bool, 61*61)})
+	require.ErrorIs(t, err, ErrUnsupportedQRCode)
+}
+
+func TestQRFormatCode(t *testing.T) {
+	require.Equal(t, uint32(0x5412), qrFormatCode(0))    // M, mask 0
+	require.Equal(t, uint32(0x77c4), qrFormatCode(0x08)) // L, mask 0
+}
+
+func TestDecodeQRSegments(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		version int
+		want    string
+	}{
+		{
+			// Numeric mode, 8 digits: 012 345 67.
+			name:    "numeric",
+			data:    []byte{0x10, 0x20, 0x0c, 0x56, 0x61, 0x80, 0x00},
+			version: 1,
+			want:    "01234567",
+		},
+		{
+			// Alphanumeric mode, 5 characters: AC-42.
+			name:    "alphanumeric",
+			data:    []byte{0x20, 0x29, 0xce, 0xe7, 0x21, 0x00},
+			version: 1,
+			want:    "AC-42",
+		},
+		{
+			name:    "byte",
+			data:    []byte{0x40, 0x26, 0x86, 0x90, 0x00},
+			version: 1,
+			want:    "hi",
+		},
+		{
+			name:    "byte with 16 bit count",
+			data:    []byte{0x40, 0x00, 0x26, 0x86, 0x90, 0x00},
+			version: 10,
+			want:    "hi",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeQRSegments(tc.data, tc.version)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := decodeQRSegments([]byte{0x40, 0x56, 0x96}, 1)
+	require.ErrorIs(t, err, ErrInvalidQRImage)
+
+	_, err = decodeQRSegments([]byte{0x80, 0x10}, 1)
+	require.ErrorIs(t, err, ErrUnsupportedQRCode)
+}
@@ -0,0 +1,151 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+)
+
+// qrPayloadSeparator separates the fields of an M-Pesa QR payload.
+const qrPayloadSeparator = "|"
+
+// ErrInvalidQRPayload indicates that the text is not an M-Pesa QR payload.
+var ErrInvalidQRPayload = errors.New("mpesa: invalid qr payload")
+
+// Description returns the name of the transaction type as shown to customers, e.g. "Pay Merchant (Buy Goods)".
+func (t DynamicQRTransactionType) Description() string {
+	switch t {
+	case PayMerchantBuyGoods:
+		return "Pay Merchant (Buy Goods)"
+	case PaybillOrBusinessNumber:
+		return "Paybill or Business Number"
+	case SendMoneyViaMobileNumber:
+		return "Send Money (Mobile Number)"
+	case SentToBusiness:
+		return "Sent to Business"
+	case WithdrawCashAtAgentTill:
+		return "Withdraw Cash at Agent Till"
+	default:
+		return string(t)
+	}
+}
+
+// QRPayload is the content of an M-Pesa QR code. It is encoded as TrxCode|CPI|Amount|RefNo, followed by the merchant
+// name on codes that carry it. The codes generated by the Dynamic QR API show the merchant name as a caption instead.
+type QRPayload struct {
+	// TransactionType is the type of transaction the code is for.
+	TransactionType DynamicQRTransactionType
+
+	// CreditPartyIdentifier is the till, paybill, agent or phone number that receives the money.
+	CreditPartyIdentifier string
+
+	// Amount to be paid. It is zero when the customer enters the amount.
+	Amount uint
+
+	// ReferenceNo is the transaction reference.
+	ReferenceNo string
+
+	// MerchantName is the name of the merchant, if encoded.
+	MerchantName string
+}
+
+// ParseQRPayload decodes an M-Pesa QR payload, e.g. the text scanned from a customer or merchant code, into its
+// fields.
+func ParseQRPayload(s string) (*QRPayload, error) {
+	fields := strings.Split(strings.TrimSpace(s), qrPayloadSeparator)
+	if len(fields) < 4 || len(fields) > 5 {
+		return nil, fmt.Errorf("%w: expected 4 or 5 fields, got %d", ErrInvalidQRPayload, len(fields))
+	}
+
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+
+	p := &QRPayload{
+		TransactionType:       DynamicQRTransactionType(strings.ToUpper(fields[0])),
+		CreditPartyIdentifier: fields[1],
+		ReferenceNo:           fields[3],
+	}
+
+	switch p.TransactionType {
+	case PayMerchantBuyGoods, PaybillOrBusinessNumber, SendMoneyViaMobileNumber, SentToBusiness,
+		WithdrawCashAtAgentTill:
+	default:
+		return nil, fmt.Errorf("%w: unknown transaction type %q", ErrInvalidQRPayload, fields[0])
+	}
+
+	if _, err := strconv.ParseUint(p.CreditPartyIdentifier, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid CPI %q", ErrInvalidQRPayload, p.CreditPartyIdentifier)
+	}
+
+	if fields[2] != "" {
+		amount, err := strconv.ParseUint(fields[2], 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid amount %q", ErrInvalidQRPayload, fields[2])
+		}
+		p.Amount = uint(amount)
+	}
+
+	if len(fields) == 5 {
+		p.MerchantName = fields[4]
+	}
+
+	return p, nil
+}
+
+// String encodes the payload in the format read by ParseQRPayload.
+func (p *QRPayload) String() string {
+	fields := []string{
+		string(p.TransactionType),
+		p.CreditPartyIdentifier,
+		strconv.FormatUint(uint64(p.Amount), 10),
+		p.ReferenceNo,
+	}
+
+	if p.MerchantName != "" {
+		fields = append(fields, p.MerchantName)
+	}
+
+	return strings.Join(fields, qrPayloadSeparator)
+}
+
+// Matches returns true if the payload encodes the transaction type, CPI, amount and reference of the request. Use
+// it to verify a code before it is printed or displayed.
+func (p *QRPayload) Matches(req DynamicQRRequest) bool {
+	return p.TransactionType == req.TransactionType &&
+		p.CreditPartyIdentifier == req.CreditPartyIdentifier &&
+		p.Amount == req.Amount &&
+		p.ReferenceNo == req.ReferenceNo
+}
+
+// DecodeQRImage decodes the M-Pesa QR payload of the QR code on the image.
+func DecodeQRImage(img image.Image) (*QRPayload, error) {
+	matrix, err := QRMatrixFromImage(img)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := DecodeQRMatrix(matrix)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseQRPayload(text)
+}
+
+// Payload decodes the M-Pesa QR payload of the image returned by the Dynamic QR API.
+func (r *DynamicQRResponse) Payload() (*QRPayload, error) {
+	matrix, err := r.Matrix()
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := DecodeQRMatrix(matrix)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseQRPayload(text)
+}
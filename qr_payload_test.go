@@ -0,0 +1,72 @@
+package mpesa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQRPayload(t *testing.T) {
+	p, err := ParseQRPayload(" BG|174379|10|NULLABLE\n")
+	require.NoError(t, err)
+	require.Equal(t, &QRPayload{
+		TransactionType:       PayMerchantBuyGoods,
+		CreditPartyIdentifier: "174379",
+		Amount:                10,
+		ReferenceNo:           "NULLABLE",
+	}, p)
+	require.Equal(t, "BG|174379|10|NULLABLE", p.String())
+	require.Equal(t, "Pay Merchant (Buy Goods)", p.TransactionType.Description())
+
+	p, err = ParseQRPayload("pb|888880||INV-1|Acme Ltd")
+	require.NoError(t, err)
+	require.Equal(t, PaybillOrBusinessNumber, p.TransactionType)
+	require.Zero(t, p.Amount)
+	require.Equal(t, "Acme Ltd", p.MerchantName)
+	require.Equal(t, "PB|888880|0|INV-1|Acme Ltd", p.String())
+
+	for _, s := range []string{
+		"",
+		"BG|174379|10",
+		"BG|174379|10|REF|Name|Extra",
+		"XX|174379|10|REF",
+		"BG|till|10|REF",
+		"BG|174379|ten|REF",
+		"BG|174379|-10|REF",
+	} {
+		_, err = ParseQRPayload(s)
+		require.ErrorIs(t, err, ErrInvalidQRPayload, s)
+	}
+}
+
+func TestQRPayload_Matches(t *testing.T) {
+	req := DynamicQRRequest{
+		Amount:                10,
+		CreditPartyIdentifier: "174379",
+		MerchantName:          "jwambugu",
+		ReferenceNo:           "NULLABLE",
+		TransactionType:       PayMerchantBuyGoods,
+	}
+
+	p, err := (&DynamicQRResponse{QRCode: testDynamicQRCode}).Payload()
+	require.NoError(t, err)
+	require.True(t, p.Matches(req))
+
+	req.Amount = 100
+	require.False(t, p.Matches(req))
+}
+
+func TestDecodeQRImage(t *testing.T) {
+	b, err := base64.StdEncoding.DecodeString(testDynamicQRCode)
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(b))
+	require.NoError(t, err)
+
+	p, err := DecodeQRImage(img)
+	require.NoError(t, err)
+	require.Equal(t, "174379", p.CreditPartyIdentifier)
+}
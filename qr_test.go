@@ -0,0 +1,136 @@
+package mpesa
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testDynamicQRCode = "iVBORw0KGgoAAAANSUhEUgAAASwAAAEsCAYAAAB5fY51AAAJXElEQVR42u3dyZLbMAwFwPn/n55cU1nsIQXQANivSqfYMhewLSmy5utbRKRJvgyBiABLRARYIgIsERFgiYgAS0SAJSICLBERYIkIsOTPwfr6+msT4ybAsvCMmwiwLDzjJsCy8MS4CbAEWCLAEmAJsG5eeD9ZmKv//r/F/a/XrYDw6j07nx/1WU/2lbHPJ/MBbmC1A+vda56+fxetlf38vr+dz9z9rBMwRLUNWMBqeWrz5Nt6d/9RCz9ii/ysbBiqtkuAVRas1dOPHYR2X7vT3hVko5CJBCtyPIEFrOvBimhj5AI7CUjVNgELWKPAenf9Z/XbPOJaFrCABSxgvXzdKlgdLhIDC1jAGgjWyili1AV3YAELWMAqBZZrWMACljwq3ncYAQtYwALWKLB2Pr/TKWHkbQ07t1qcGM/ddgmwyoC1+7pp17BO3/SaPZ6R7RJgjQAre7FWAmt1HiogAyxgtQUr87pGpR/rZv9cKXMMqu9LgHUULDHnAqwyhat4Z8wrrIA1HirFO39+zTmwgCVtwRJguYYh5dESYImIAEtEgFX2usK0LWp8Ou7nZG2ow56nwcACFrDUIbCABSxgAQtYCgVYwAIWsIAFLHUILGABC1jAAhawgAUsYAELWMBSh8ACFrCABaxLweqYjgu7I8TqcG6/gAUsYKlDYCkUYAFLHQJLoQBLHQLLgAILWOoQWAoFWMCyvoClUIClDoFlQIEFLHUILIUCLGBZX8AqsWhv7tfJNqvDeV8MwAIWsNQhsBQKsIClDoGlUIAFLGABC1jAUofAUijAAhawgKVQgAUsYAELWMBSh8BSKMACFrCApVD0C1jAAhawgKUOgaVQ9AtYwAKWQtEvYAELWMAClvkClkLRL2ABC1gKRb+ABSxgAQtY5gtYCkW/gAUsYCmUqXeo37wfYAELWMACFrCABSxgqUNgKRRgAQtYwAIWsIAFLGABC1jAApZCARawgAUsYAELWMACFrCABSxgNRzQTv3q+NjiqV8M1hew9AtYwAKWAQUWsNQhsBQKsIBlfQFLv4AFLGAZUGABSx0CS6EAC1jWF7D0C1jAApYBBRaw1CGwLtw6Lkh3qN9Zh8BSKIAAFrCABSz7ARawgAUsYAELWAoFEMACFrCAZT/AAhawgAUsYAFLoQACWMACFrDsRx0CSxoFWNKuZg0BsIAlwBJgAUuAJcACFrAEWMASYAmwgCXAEmABC1gCLGAJsARYwJJ5YE2969ed3Hfe7T31lxLAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxglZrgap9VrZg6juHNi3/644+BBSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAsmg/vGg7LpKpOAqwgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAetasBRlnTGs9iWkVucCCixgAUutAksRAAtYahVYigBYahVYigBYwFKrwFIEwAKWWgWWIgCWWgWWIgAWsNQqsBQBsIClVoFV4hG3HYGYOobaAyxgAQtY2gMsiw1YgAAWsIAFLO0BFrCABSztAZbFBixAAAtYwAKW9gALWMACFrCABSxjCAhgNQKrGo4n99OxXx3hm1ob0x/HDCxgAQtYwAIWsEADLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAuhQsxXSmPTfDV22cp9/FDixgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAuhSsmzFyl3avRwlPRRZYwAIWsIAFLGAZQ2ABC1jAAhawgGWxAQtYwAIWsIwhsIAFLGABC1jAstiABSxgAQtYxhBYwOrXuYZ3cnu0sc2d7sACFrBswAIWsIAFLGABC1g2YAELWMACFrCABSxgAQtYwAKWDVjAAhawgAUsYAELWMACloQDYXx6fXGad2BZkMYHWMASYBkfYAFLgAUsYIkFaXyABSwBFrCABSwLEljAApZYkMYHWMASYAELWCPBchfynYhMfWzx1F9TAAtYwAIWsIAFLHMKLGABC1jAAhawgAUsYAELWMAyp8ACFrCABSxgAQtYwAIWsIAFLGABC1jHinvqou0IxFSw1DOwgAUsYAELWMACFrCABSxgAUs9AwtYwAIWsIAFLGABC1gmGFjAUs/AAhawgEUiYAELWMACVqMJnnrn9Mm+d2zPzb+CmI4jsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWNeB5VHLd/5aoOMXFbCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAGtavqUBM/YVD6y8sYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsCwBYwAIWsIAFLGABC1jAMl/AAlabwb74UctTkZ0678ACFrCABSxgAQtYwAIWsBQusIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAKWeQdWqQmeunUEa+zi8EsAYAELWMACFrCABSxgAQtYwAIWsIAFLGABC1jAAhawgAUsYAELWMACFrCABSxgAQtYwAIWsEREgCUiAiwRAZaICLBERIAlIsASEQGWiAiwRARYIiLAEhEBlogAS0QEWCICLBERYImIAEtEgCUiAiwREWBNnJQHz9rOfoZ31rPoP9Gv255RDyw5BlVnsCLaEtmv7n+IAVgCrMQjpEpgTfnrMcCSMmC9WlxZKD6F8N17s0/LnoC18u8CLFlYhJ/YZ/aRSBZYu58DLGBJAbAijq5OtesTR1eOsoAlxcFafU8XsE68ToAlSQsn4mL5qXZl9QtYwJLGYK2eCj5tV8a1sJV9AAtY0gCsyKOrTLCy+/WT17u9AVjSDKyoi9c7R1jVbmIFFrCkAFg7p4KnTlWfABgJJ7CAJYfBOnkUcuI6VNSp26v3AwtYUgisjCOQCm3MGncBlhwA6/TvEU+BlXna5ugKWFIIrNPtivifu8h+Rdz2IMCS79iLwVWOQqo9w8rFdmBJE7A+ceQXDUTWaTKogCUfAit7EZ5+PE10v0DVN78A4PhWMY/tjp0AAAAASUVORK5CYII="
+
+func TestQRMatrixFromImage(t *testing.T) {
+	res := &DynamicQRResponse{QRCode: testDynamicQRCode}
+
+	matrix, err := res.Matrix()
+	require.NoError(t, err)
+	require.Equal(t, 0, (matrix.Size()-21)%4)
+	require.True(t, matrix.hasFinderPatterns())
+
+	// The timing patterns alternate between dark and light modules.
+	for i := 8; i < matrix.Size()-8; i++ {
+		require.Equal(t, i%2 == 0, matrix.Dark(i, 6), "column %d", i)
+		require.Equal(t, i%2 == 0, matrix.Dark(6, i), "row %d", i)
+	}
+
+	_, err = QRMatrixFromImage(image.NewGray(image.Rect(0, 0, 100, 100)))
+	require.ErrorIs(t, err, ErrInvalidQRImage)
+}
+
+func TestQRMatrix_Outputs(t *testing.T) {
+	// A scaled up matrix with only the finder patterns.
+	const size, module = 21, 5
+
+	img := image.NewGray(image.Rect(0, 0, (size+8)*module, (size+8)*module))
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			img.SetGray(x, y, color.Gray{Y: 0xff})
+		}
+	}
+
+	for _, origin := range [][2]int{{0, 0}, {size - 7, 0}, {0, size - 7}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				if max(abs(dx-3), abs(dy-3)) == 2 {
+					continue
+				}
+
+				for py := 0; py < module; py++ {
+					for px := 0; px < module; px++ {
+						img.SetGray((4+origin[0]+dx)*module+px, (4+origin[1]+dy)*module+py, color.Gray{})
+					}
+				}
+			}
+		}
+	}
+
+	matrix, err := QRMatrixFromImage(img)
+	require.NoError(t, err)
+	require.Equal(t, size, matrix.Size())
+	require.True(t, matrix.Dark(0, 0))
+	require.False(t, matrix.Dark(1, 1))
+	require.False(t, matrix.Dark(10, 10))
+
+	var svg bytes.Buffer
+	require.NoError(t, matrix.WriteSVG(&svg, 0))
+	require.Contains(t, svg.String(), `width="300" height="300" viewBox="0 0 29 29"`)
+	require.Contains(t, svg.String(), "M4 4h7v1h-7z")
+
+	var pdf bytes.Buffer
+	require.NoError(t, matrix.WritePDF(&pdf, 290))
+	require.True(t, strings.HasPrefix(pdf.String(), "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(pdf.String(), "%%EOF\n"))
+	require.Contains(t, pdf.String(), "/MediaBox [0 0 290 290]")
+	require.Contains(t, pdf.String(), "40.000 240.000 70.000 10.000 re")
+
+	// The xref offsets must point at the objects.
+	out := pdf.String()
+	xref := out[strings.LastIndex(out, "startxref\n")+len("startxref\n"):]
+	xref = strings.TrimSuffix(xref, "\n%%EOF\n")
+	offset, err := strconv.Atoi(xref)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out[offset:], "xref\n"))
+
+	offset, err = strconv.Atoi(strings.Fields(out[offset+len("xref\n0 5\n0000000000 65535 f \n"):])[0])
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(out[offset:], "1 0 obj\n"))
+}
+
+func TestDynamicQRResponse_Render(t *testing.T) {
+	res := &DynamicQRResponse{QRCode: testDynamicQRCode}
+
+	want, err := base64.StdEncoding.DecodeString(testDynamicQRCode)
+	require.NoError(t, err)
+
+	var png bytes.Buffer
+	require.NoError(t, res.Render(&png, QRFormatPNG, 0))
+	require.Equal(t, want, png.Bytes())
+
+	var svg bytes.Buffer
+	require.NoError(t, res.Render(&svg, QRFormatSVG, 512))
+	require.Contains(t, svg.String(), `<svg xmlns="http://www.w3.org/2000/svg" width="512" height="512"`)
+
+	var pdf bytes.Buffer
+	require.NoError(t, res.Render(&pdf, QRFormatPDF, 0))
+	require.True(t, strings.HasPrefix(pdf.String(), "%PDF-1.4\n"))
+
+	err = res.Render(&bytes.Buffer{}, QRFormat("gif"), 0)
+	require.ErrorIs(t, err, ErrUnsupportedQRFormat)
+
+	err = (&DynamicQRResponse{QRCode: "not base64"}).Render(&bytes.Buffer{}, QRFormatSVG, 0)
+	require.ErrorContains(t, err, "mpesa: decode qr code")
+}
+
+func TestDynamicQRResponse_DataURI(t *testing.T) {
+	res := &DynamicQRResponse{QRCode: testDynamicQRCode}
+
+	uri, err := res.DataURI(QRFormatPNG, 0)
+	require.NoError(t, err)
+	require.Equal(t, "data:image/png;base64,"+testDynamicQRCode, uri)
+
+	for _, format := range []QRFormat{QRFormatSVG, QRFormatPDF} {
+		uri, err = res.DataURI(format, 0)
+		require.NoError(t, err)
+
+		prefix := "data:" + format.ContentType() + ";base64,"
+		require.True(t, strings.HasPrefix(uri, prefix), uri)
+
+		_, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, prefix))
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,209 @@
+package mpesa
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// RateLimit is the number of requests allowed per second with bursts of up to Burst requests. A zero Rate means
+	// no limit.
+	RateLimit struct {
+		Rate  float64
+		Burst int
+	}
+
+	// RateLimiter enforces independent request rates per key using token buckets. The Mpesa app uses the
+	// ShortCodeRateLimitKey of the shortcode the request is made for, or the AppRateLimitKey of its consumer key for
+	// requests without one, so that a busy shortcode cannot use up the requests available to the others.
+	RateLimiter struct {
+		mu      sync.Mutex
+		limit   RateLimit
+		limits  map[string]RateLimit
+		buckets map[string]*tokenBucket
+		now     func() time.Time
+	}
+
+	tokenBucket struct {
+		limit  RateLimit
+		tokens float64
+		last   time.Time
+	}
+)
+
+// ShortCodeRateLimitKey returns the RateLimiter key of the requests made for the shortcode.
+func ShortCodeRateLimitKey(shortCode uint) string {
+	return "shortcode:" + strconv.FormatUint(uint64(shortCode), 10)
+}
+
+// AppRateLimitKey returns the RateLimiter key of the requests made with the consumer key that are not tied to a
+// shortcode.
+func AppRateLimitKey(consumerKey string) string {
+	return "app:" + consumerKey
+}
+
+// EndpointRateLimitKey returns the RateLimiter key of the requests made to the endpoint.
+func EndpointRateLimitKey(endpoint Endpoint) string {
+	return "endpoint:" + string(endpoint)
+}
+
+// WithRateLimit limits the requests the app makes to the endpoint to rps requests per second, spaced evenly. Requests
+// over the limit block until they are allowed or the context is done. Endpoints without a limit are not limited.
+//
+//	app := mpesa.NewApp(client, key, secret, env, mpesa.WithRateLimit(mpesa.EndpointSTKPush, 5))
+func WithRateLimit(endpoint Endpoint, rps float64) Option {
+	return func(m *Mpesa) {
+		if m.endpointLimiter == nil {
+			m.endpointLimiter = NewRateLimiter(RateLimit{})
+		}
+
+		m.endpointLimiter.SetLimit(EndpointRateLimitKey(endpoint), RateLimit{Rate: rps, Burst: 1})
+	}
+}
+
+// NewRateLimiter creates a RateLimiter that applies limit to every key without a limit set using SetLimit.
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		limits:  make(map[string]RateLimit),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimit overrides the limit of the key, e.g. for a merchant that negotiated a higher throughput.
+func (l *RateLimiter) SetLimit(key string, limit RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits[key] = limit
+	delete(l.buckets, key)
+}
+
+// bucket returns the bucket of the key, creating a full one if needed. It must be called with l.mu held.
+func (l *RateLimiter) bucket(key string, now time.Time) *tokenBucket {
+	b, ok := l.buckets[key]
+	if ok {
+		return b
+	}
+
+	limit, ok := l.limits[key]
+	if !ok {
+		limit = l.limit
+	}
+
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+
+	b = &tokenBucket{limit: limit, tokens: float64(limit.Burst), last: now}
+	l.buckets[key] = b
+	return b
+}
+
+// reserve takes a token from the bucket of the key and returns how long to wait before using it.
+func (l *RateLimiter) reserve(key string) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b := l.bucket(key, now)
+	if b.limit.Rate <= 0 {
+		return 0, false
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * b.limit.Rate
+	if b.tokens > float64(b.limit.Burst) {
+		b.tokens = float64(b.limit.Burst)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, true
+	}
+
+	return time.Duration(-b.tokens / b.limit.Rate * float64(time.Second)), true
+}
+
+// cancel returns a token reserved but not used to the bucket of the key.
+func (l *RateLimiter) cancel(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[key]; ok {
+		b.tokens++
+	}
+}
+
+// Allow takes a token for the key and returns true if one is available right away.
+func (l *RateLimiter) Allow(key string) bool {
+	wait, limited := l.reserve(key)
+	if limited && wait > 0 {
+		l.cancel(key)
+		return false
+	}
+
+	return true
+}
+
+// Wait blocks until a request for the key is allowed or the context is done.
+func (l *RateLimiter) Wait(ctx context.Context, key string) error {
+	wait, limited := l.reserve(key)
+	if !limited || wait == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		l.cancel(key)
+		return fmt.Errorf("mpesa: rate limit for %s exceeded: %w", key, context.DeadlineExceeded)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.cancel(key)
+		return fmt.Errorf("mpesa: rate limit for %s exceeded: %w", key, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimitKey returns the RateLimiter key of the request body.
+func (m *Mpesa) rateLimitKey(body interface{}) string {
+	var shortCode uint
+
+	switch req := body.(type) {
+	case STKPushRequest:
+		shortCode = req.BusinessShortCode
+	case STKQueryRequest:
+		shortCode = req.BusinessShortCode
+	case B2CRequest:
+		shortCode = req.PartyA
+	case BusinessPayBillRequest:
+		shortCode = req.PartyA
+	case BusinessBuyGoodsRequest:
+		shortCode = req.PartyA
+	case TransactionStatusRequest:
+		shortCode = req.PartyA
+	case AccountBalanceRequest:
+		if req.PartyA > 0 {
+			shortCode = uint(req.PartyA)
+		}
+	case ReversalRequest:
+		shortCode = req.ReceiverParty
+	case RegisterC2BURLRequest:
+		shortCode = req.ShortCode
+	}
+
+	if shortCode == 0 {
+		return AppRateLimitKey(m.consumerKey)
+	}
+
+	return ShortCodeRateLimitKey(shortCode)
+}
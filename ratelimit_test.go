@@ -0,0 +1,137 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Run("every key has its own bucket", func(t *testing.T) {
+		now := time.Now()
+
+		l := NewRateLimiter(RateLimit{Rate: 1, Burst: 2})
+		l.now = func() time.Time { return now }
+
+		busy, other := ShortCodeRateLimitKey(174379), ShortCodeRateLimitKey(600426)
+
+		require.True(t, l.Allow(busy))
+		require.True(t, l.Allow(busy))
+		require.False(t, l.Allow(busy))
+		require.True(t, l.Allow(other))
+
+		now = now.Add(time.Second)
+		require.True(t, l.Allow(busy))
+		require.False(t, l.Allow(busy))
+	})
+
+	t.Run("limits can be set per key", func(t *testing.T) {
+		l := NewRateLimiter(RateLimit{Rate: 1})
+		l.SetLimit(AppRateLimitKey(testConsumerKey), RateLimit{})
+		l.SetLimit(ShortCodeRateLimitKey(174379), RateLimit{Rate: 1, Burst: 3})
+
+		for i := 0; i < 10; i++ {
+			require.True(t, l.Allow(AppRateLimitKey(testConsumerKey)))
+		}
+
+		require.True(t, l.Allow(ShortCodeRateLimitKey(174379)))
+		require.True(t, l.Allow(ShortCodeRateLimitKey(174379)))
+		require.True(t, l.Allow(ShortCodeRateLimitKey(174379)))
+		require.False(t, l.Allow(ShortCodeRateLimitKey(174379)))
+
+		require.True(t, l.Allow(ShortCodeRateLimitKey(600426)))
+		require.False(t, l.Allow(ShortCodeRateLimitKey(600426)))
+	})
+
+	t.Run("wait blocks until a token is available", func(t *testing.T) {
+		ctx := context.Background()
+		l := NewRateLimiter(RateLimit{Rate: 50, Burst: 1})
+
+		start := time.Now()
+		require.NoError(t, l.Wait(ctx, "key"))
+		require.NoError(t, l.Wait(ctx, "key"))
+		require.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+
+		err := l.Wait(timeoutCtx, "key")
+		require.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+func TestMpesa_WithRateLimiter(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		cl      = newMockHttpClient()
+		limiter = NewRateLimiter(RateLimit{Rate: 0.001, Burst: 1})
+		app     = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithRateLimiter(limiter))
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+		return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0"}`
+	})
+
+	query := func(shortCode uint) error {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := app.STKQuery(timeoutCtx, "passkey", STKQueryRequest{
+			BusinessShortCode: shortCode,
+			CheckoutRequestID: "ws_CO_191220191020363925",
+		})
+		return err
+	}
+
+	require.NoError(t, query(174379))
+	require.True(t, errors.Is(query(174379), context.DeadlineExceeded))
+	require.NoError(t, query(600426))
+}
+
+func TestMpesa_WithRateLimit(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = newMockHttpClient()
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithRateLimit(EndpointSTKQuery, 0.001))
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+		return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0"}`
+	})
+
+	cl.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+		return http.StatusOK, `{"ResponseCode": "0"}`
+	})
+
+	query := func(shortCode uint) error {
+		timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+
+		_, err := app.STKQuery(timeoutCtx, "passkey", STKQueryRequest{
+			BusinessShortCode: shortCode,
+			CheckoutRequestID: "ws_CO_191220191020363925",
+		})
+		return err
+	}
+
+	require.NoError(t, query(174379))
+
+	// The limit applies to the endpoint regardless of the shortcode.
+	require.True(t, errors.Is(query(600426), context.DeadlineExceeded))
+
+	_, err := app.GetTransactionStatus(ctx, "initiator-password", TransactionStatusRequest{
+		PartyA:          600426,
+		TransactionID:   "SB162HIYLY",
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,266 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconcilerPollInterval     = 30 * time.Second
+	defaultReconcilerDeadline         = 10 * time.Minute
+	defaultReconcilerQueriesPerSecond = 1
+)
+
+// reconcilerRateLimitKey is the RateLimiter key of the STK queries made by a Reconciler.
+const reconcilerRateLimitKey = "reconciler"
+
+type (
+	// ReconcilerConfig configures a Reconciler.
+	ReconcilerConfig struct {
+		// Passkey is used to generate the password of the STK queries. It defaults to the passkey of the app.
+		Passkey string
+
+		// PollInterval is the time between the queries of a pending checkout. Defaults to 30 seconds.
+		PollInterval time.Duration
+
+		// Deadline is how long after it was added a checkout is given up on and reported as CheckoutStatusExpired.
+		// Defaults to 10 minutes.
+		Deadline time.Duration
+
+		// QueriesPerSecond limits the STK queries made by the Reconciler to stay under the throttling of the STK
+		// query API, which rejects bursts of queries. Defaults to 1.
+		QueriesPerSecond float64
+
+		// OnTransition, if set, is called every time the status of a checkout changes.
+		OnTransition func(transition ReconcileTransition)
+	}
+
+	// ReconcileTransition is a change of the status of a checkout tracked by a Reconciler.
+	ReconcileTransition struct {
+		BusinessShortCode uint
+		CheckoutRequestID string
+
+		// From is the previous status of the checkout and Status the new one.
+		From   CheckoutStatus
+		Status CheckoutStatus
+
+		// ResultCode and ResultDesc are set once the status is final. ResultCode is -1 when the Reconciler gave up.
+		ResultCode int
+		ResultDesc string
+	}
+
+	// Reconciler queries the status of STK pushes whose callback did not arrive, e.g. behind a flaky webhook. Add the
+	// CheckoutRequestID of every STK push, pass the callbacks that do arrive to HandleCallback, and call Run to query
+	// the pending ones until they reach a final status or their deadline.
+	Reconciler struct {
+		app     *Mpesa
+		cfg     ReconcilerConfig
+		limiter *RateLimiter
+
+		mu      sync.Mutex
+		pending map[string]*reconcileEntry
+		now     func() time.Time
+	}
+
+	reconcileEntry struct {
+		shortCode   uint
+		status      CheckoutStatus
+		nextQueryAt time.Time
+		deadline    time.Time
+	}
+)
+
+// NewReconciler creates a Reconciler that queries the checkouts using the app.
+func NewReconciler(app *Mpesa, cfg ReconcilerConfig) *Reconciler {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultReconcilerPollInterval
+	}
+
+	if cfg.Deadline <= 0 {
+		cfg.Deadline = defaultReconcilerDeadline
+	}
+
+	if cfg.QueriesPerSecond <= 0 {
+		cfg.QueriesPerSecond = defaultReconcilerQueriesPerSecond
+	}
+
+	return &Reconciler{
+		app:     app,
+		cfg:     cfg,
+		limiter: NewRateLimiter(RateLimit{Rate: cfg.QueriesPerSecond, Burst: 1}),
+		pending: make(map[string]*reconcileEntry),
+		now:     time.Now,
+	}
+}
+
+// Add starts tracking the checkout of the STK push sent from the shortcode. It is first queried after the
+// PollInterval. Adding a checkout that is already tracked has no effect.
+func (r *Reconciler) Add(shortCode uint, checkoutRequestID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.pending[checkoutRequestID]; ok {
+		return
+	}
+
+	now := r.now()
+	r.pending[checkoutRequestID] = &reconcileEntry{
+		shortCode:   shortCode,
+		status:      CheckoutStatusPromptSent,
+		nextQueryAt: now.Add(r.cfg.PollInterval),
+		deadline:    now.Add(r.cfg.Deadline),
+	}
+}
+
+// HandleCallback completes the checkout with the result of the STK push callback, which stops it from being queried.
+// It returns false if the checkout is not tracked.
+func (r *Reconciler) HandleCallback(callback *STKPushCallback) bool {
+	result := callback.Body.STKCallback
+	return r.finish(result.CheckoutRequestID, checkoutStatusFromResultCode(result.ResultCode), result.ResultCode,
+		result.ResultDesc)
+}
+
+// Pending returns the number of checkouts that have not reached a final status.
+func (r *Reconciler) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.pending)
+}
+
+// ReconcileDue queries the checkouts due for a query, waiting for the rate limit between the queries, and gives up on
+// the ones past their deadline. It returns the number of checkouts queried. Failed queries are retried after the
+// PollInterval.
+func (r *Reconciler) ReconcileDue(ctx context.Context) (int, error) {
+	now := r.now()
+
+	var due, expired []string
+
+	r.mu.Lock()
+	for id, entry := range r.pending {
+		switch {
+		case !now.Before(entry.deadline):
+			expired = append(expired, id)
+		case !now.Before(entry.nextQueryAt):
+			entry.nextQueryAt = now.Add(r.cfg.PollInterval)
+			due = append(due, id)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, id := range expired {
+		r.finish(id, CheckoutStatusExpired, -1, "The reconciliation deadline elapsed")
+	}
+
+	queried := 0
+	for _, id := range due {
+		if err := r.limiter.Wait(ctx, reconcilerRateLimitKey); err != nil {
+			return queried, err
+		}
+
+		r.query(ctx, id)
+		queried++
+	}
+
+	return queried, nil
+}
+
+// Run calls ReconcileDue every PollInterval until the context is done.
+func (r *Reconciler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := r.ReconcileDue(ctx); err != nil && ctx.Err() != nil {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (r *Reconciler) query(ctx context.Context, checkoutRequestID string) {
+	r.mu.Lock()
+	entry, ok := r.pending[checkoutRequestID]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	shortCode := entry.shortCode
+	r.mu.Unlock()
+
+	res, err := r.app.STKQuery(ctx, r.cfg.Passkey, STKQueryRequest{
+		BusinessShortCode: shortCode,
+		CheckoutRequestID: checkoutRequestID,
+	})
+	if err != nil {
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.ErrorCode == stkQueryProcessingErrorCode {
+			r.transition(checkoutRequestID, CheckoutStatusAwaitingPIN)
+		}
+		return
+	}
+
+	code, err := strconv.Atoi(res.ResultCode)
+	if err != nil {
+		return
+	}
+
+	r.finish(checkoutRequestID, checkoutStatusFromResultCode(code), code, res.ResultDesc)
+}
+
+// transition moves a pending checkout to a status that is not final.
+func (r *Reconciler) transition(checkoutRequestID string, status CheckoutStatus) {
+	r.mu.Lock()
+	entry, ok := r.pending[checkoutRequestID]
+	if !ok || entry.status == status {
+		r.mu.Unlock()
+		return
+	}
+
+	t := ReconcileTransition{
+		BusinessShortCode: entry.shortCode,
+		CheckoutRequestID: checkoutRequestID,
+		From:              entry.status,
+		Status:            status,
+	}
+	entry.status = status
+	r.mu.Unlock()
+
+	r.notify(t)
+}
+
+// finish stops tracking the checkout once it reached a final status.
+func (r *Reconciler) finish(checkoutRequestID string, status CheckoutStatus, resultCode int, resultDesc string) bool {
+	r.mu.Lock()
+	entry, ok := r.pending[checkoutRequestID]
+	if !ok {
+		r.mu.Unlock()
+		return false
+	}
+	delete(r.pending, checkoutRequestID)
+	r.mu.Unlock()
+
+	r.notify(ReconcileTransition{
+		BusinessShortCode: entry.shortCode,
+		CheckoutRequestID: checkoutRequestID,
+		From:              entry.status,
+		Status:            status,
+		ResultCode:        resultCode,
+		ResultDesc:        resultDesc,
+	})
+
+	return true
+}
+
+func (r *Reconciler) notify(t ReconcileTransition) {
+	if r.cfg.OnTransition != nil {
+		r.cfg.OnTransition(t)
+	}
+}
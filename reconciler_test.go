@@ -0,0 +1,134 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconciler(t *testing.T) {
+	ctx := context.Background()
+
+	newReconciler := func(t *testing.T, results map[string]string) (*Reconciler, *time.Time, *[]ReconcileTransition) {
+		var (
+			cl          = newMockHttpClient()
+			app         = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			now         = time.Now()
+			transitions []ReconcileTransition
+		)
+
+		mockAuth(app, cl)
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			var req STKQueryRequest
+			require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+
+			result, ok := results[req.CheckoutRequestID]
+			if !ok {
+				return http.StatusInternalServerError, `{"requestId": "1", "errorCode": "500.001.1001", ` +
+					`"errorMessage": "The transaction is being processed"}`
+			}
+
+			return http.StatusOK, `{"ResponseCode": "0", "CheckoutRequestID": "` + req.CheckoutRequestID + `", ` +
+				`"ResultCode": "` + result + `", "ResultDesc": "Result ` + result + `"}`
+		})
+
+		r := NewReconciler(app, ReconcilerConfig{
+			Passkey:          "passkey",
+			PollInterval:     time.Minute,
+			Deadline:         5 * time.Minute,
+			QueriesPerSecond: 1000,
+			OnTransition: func(transition ReconcileTransition) {
+				transitions = append(transitions, transition)
+			},
+		})
+		r.now = func() time.Time { return now }
+
+		return r, &now, &transitions
+	}
+
+	t.Run("it queries the pending checkouts until they are final", func(t *testing.T) {
+		results := map[string]string{"ws_CO_paid": "0"}
+		r, now, transitions := newReconciler(t, results)
+
+		r.Add(174379, "ws_CO_paid")
+		r.Add(174379, "ws_CO_cancelled")
+
+		queried, err := r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+
+		*now = now.Add(time.Minute)
+
+		queried, err = r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 2, queried)
+		require.Equal(t, 1, r.Pending())
+
+		require.ElementsMatch(t, []ReconcileTransition{
+			{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_paid", From: CheckoutStatusPromptSent,
+				Status: CheckoutStatusPaid, ResultDesc: "Result 0"},
+			{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_cancelled", From: CheckoutStatusPromptSent,
+				Status: CheckoutStatusAwaitingPIN},
+		}, *transitions)
+
+		results["ws_CO_cancelled"] = "1032"
+
+		// The checkout is not due until the PollInterval elapses again.
+		queried, err = r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+
+		*now = now.Add(time.Minute)
+
+		queried, err = r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, queried)
+		require.Zero(t, r.Pending())
+		require.Equal(t, ReconcileTransition{
+			BusinessShortCode: 174379,
+			CheckoutRequestID: "ws_CO_cancelled",
+			From:              CheckoutStatusAwaitingPIN,
+			Status:            CheckoutStatusCancelled,
+			ResultCode:        1032,
+			ResultDesc:        "Result 1032",
+		}, (*transitions)[2])
+	})
+
+	t.Run("it gives up after the deadline", func(t *testing.T) {
+		r, now, transitions := newReconciler(t, nil)
+
+		r.Add(174379, "ws_CO_lost")
+		*now = now.Add(5 * time.Minute)
+
+		queried, err := r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+		require.Zero(t, r.Pending())
+		require.Equal(t, CheckoutStatusExpired, (*transitions)[0].Status)
+		require.Equal(t, -1, (*transitions)[0].ResultCode)
+	})
+
+	t.Run("it stops querying the checkouts completed by a callback", func(t *testing.T) {
+		r, now, transitions := newReconciler(t, nil)
+
+		r.Add(174379, "ws_CO_1")
+
+		callback := &STKPushCallback{Body: STKPushCallbackBody{STKCallback: STKCallback{
+			CheckoutRequestID: "ws_CO_1", ResultCode: 0, ResultDesc: "Success",
+		}}}
+		require.True(t, r.HandleCallback(callback))
+		require.False(t, r.HandleCallback(callback))
+
+		*now = now.Add(time.Minute)
+
+		queried, err := r.ReconcileDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, queried)
+		require.Len(t, *transitions, 1)
+		require.Equal(t, CheckoutStatusPaid, (*transitions)[0].Status)
+	})
+}
@@ -0,0 +1,77 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+// Recorder is called around every API request made by the app and every callback received by the handlers wrapped
+// with RecordCallbacks, e.g. to keep an audit trail in a database. The payloads are JSON with the passwords, passkeys,
+// security credentials and access tokens masked. Implementations must be safe for concurrent use and should not block,
+// as they are called on the path of the requests.
+type Recorder interface {
+	// OnRequest is called before the request with the payload is sent to the endpoint. It is called once per attempt
+	// when the request is retried.
+	OnRequest(ctx context.Context, endpoint Endpoint, payload []byte)
+
+	// OnResponse is called once the response of the endpoint is received with its status code and payload, or with
+	// the error that prevented it from being received.
+	OnResponse(ctx context.Context, endpoint Endpoint, statusCode int, payload []byte, err error)
+
+	// OnCallback is called with the payload of a callback received on the URL before it is decoded.
+	OnCallback(ctx context.Context, url string, payload []byte)
+}
+
+// WithRecorder makes the app report its API requests and their responses to r. The access token requests are not
+// reported.
+func WithRecorder(r Recorder) Option {
+	return func(m *Mpesa) {
+		m.recorder = r
+	}
+}
+
+// RecordCallbacks returns a http.Handler that reports the callbacks to r before passing them to next, e.g. the
+// handler returned by STKPushCallbackHandler.
+func RecordCallbacks(r Recorder, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxAPIRequestBodySize))
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, CallbackAcknowledgement{ResultCode: 1, ResultDesc: "Invalid callback"})
+			return
+		}
+
+		r.OnCallback(req.Context(), req.URL.String(), []byte(redactPayload(body)))
+
+		req.Body = readCloser{Reader: bytes.NewReader(body), Closer: req.Body}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// recordRequest reports the request to the endpoint with the body to the recorder of the app.
+func (m *Mpesa) recordRequest(ctx context.Context, endpoint Endpoint, body []byte) {
+	if m.recorder == nil {
+		return
+	}
+
+	m.recorder.OnRequest(ctx, endpoint, []byte(redactPayload(body)))
+}
+
+// recordResponse reports the response of the endpoint to the recorder of the app. The response body is read and
+// replaced so that it can still be decoded.
+func (m *Mpesa) recordResponse(ctx context.Context, endpoint Endpoint, res *http.Response, err error) {
+	if m.recorder == nil {
+		return
+	}
+
+	if res == nil {
+		m.recorder.OnResponse(ctx, endpoint, 0, nil, err)
+		return
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, maxLoggedBodySize))
+	res.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}
+
+	m.recorder.OnResponse(ctx, endpoint, res.StatusCode, []byte(redactPayload(body)), nil)
+}
@@ -0,0 +1,108 @@
+package mpesa
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type recordedCall struct {
+	kind       string
+	target     string
+	statusCode int
+	payload    string
+}
+
+type memoryRecorder struct {
+	mu    sync.Mutex
+	calls []recordedCall
+}
+
+func (r *memoryRecorder) record(call recordedCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, call)
+}
+
+func (r *memoryRecorder) OnRequest(_ context.Context, endpoint Endpoint, payload []byte) {
+	r.record(recordedCall{kind: "request", target: string(endpoint), payload: string(payload)})
+}
+
+func (r *memoryRecorder) OnResponse(_ context.Context, endpoint Endpoint, statusCode int, payload []byte, _ error) {
+	r.record(recordedCall{kind: "response", target: string(endpoint), statusCode: statusCode, payload: string(payload)})
+}
+
+func (r *memoryRecorder) OnCallback(_ context.Context, url string, payload []byte) {
+	r.record(recordedCall{kind: "callback", target: url, payload: string(payload)})
+}
+
+func TestWithRecorder(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		recorder = &memoryRecorder{}
+		cl       = newMockHttpClient()
+		app      = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithRecorder(recorder))
+	)
+
+	mockAuth(app, cl)
+	cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+		return http.StatusOK, `{"CheckoutRequestID": "ws_CO_191220191020363925", "ResponseCode": "0"}`
+	})
+
+	res, err := app.STKPush(ctx, "passkey", STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyA:            254708374149,
+		PartyB:            174379,
+		PhoneNumber:       254708374149,
+		CallBackURL:       "https://example.com/callback",
+		AccountReference:  "INV-001",
+		TransactionDesc:   "Test payment",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ws_CO_191220191020363925", res.CheckoutRequestID)
+
+	require.Len(t, recorder.calls, 2)
+	require.Equal(t, "request", recorder.calls[0].kind)
+	require.Equal(t, string(EndpointSTKPush), recorder.calls[0].target)
+	require.Contains(t, recorder.calls[0].payload, `"Password":"`+redactedValue+`"`)
+	require.Equal(t, recordedCall{
+		kind:       "response",
+		target:     string(EndpointSTKPush),
+		statusCode: http.StatusOK,
+		payload:    `{"CheckoutRequestID":"ws_CO_191220191020363925","ResponseCode":"0"}`,
+	}, recorder.calls[1])
+}
+
+func TestRecordCallbacks(t *testing.T) {
+	var (
+		recorder = &memoryRecorder{}
+		received *STKPushCallback
+	)
+
+	handler := RecordCallbacks(recorder, STKPushCallbackHandler(
+		func(_ context.Context, callback *STKPushCallback) error {
+			received = callback
+			return nil
+		},
+	))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(
+		`{"Body": {"stkCallback": {"CheckoutRequestID": "ws_CO_191220191020363925", "ResultCode": 1032}}}`,
+	)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, 1032, received.Body.STKCallback.ResultCode)
+	require.Len(t, recorder.calls, 1)
+	require.Equal(t, "callback", recorder.calls[0].kind)
+	require.Equal(t, "/callback", recorder.calls[0].target)
+	require.Contains(t, recorder.calls[0].payload, "ws_CO_191220191020363925")
+}
@@ -0,0 +1,371 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RefundStatus is the processing state of a Refund.
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "Pending"
+	RefundStatusCompleted RefundStatus = "Completed"
+	RefundStatusFailed    RefundStatus = "Failed"
+)
+
+// IsTerminal returns true if the refund will not change state anymore.
+func (s RefundStatus) IsTerminal() bool {
+	return s == RefundStatusCompleted || s == RefundStatusFailed
+}
+
+var (
+	// ErrRefundNotAllowed is returned when the original transaction cannot be refunded, e.g. because it has not
+	// completed or has already been reversed.
+	ErrRefundNotAllowed = errors.New("mpesa: transaction cannot be refunded")
+
+	// ErrInvalidRefundAmount is returned when the refund amount exceeds the amount of the original transaction not
+	// refunded yet.
+	ErrInvalidRefundAmount = errors.New("mpesa: invalid refund amount")
+
+	// ErrRefundInProgress is returned when a refund of the transaction is already pending.
+	ErrRefundInProgress = errors.New("mpesa: refund already in progress")
+
+	// ErrUnknownRefund is returned when a refund or a callback cannot be matched to a refund.
+	ErrUnknownRefund = errors.New("mpesa: unknown refund")
+)
+
+type (
+	// Refund is the reversal of a completed transaction.
+	Refund struct {
+		// ID is the ConversationID of the reversal request.
+		ID string
+
+		// OriginatorConversationID is the OriginatorConversationID of the reversal request.
+		OriginatorConversationID string
+
+		// TransactionID is the ID of the transaction being refunded.
+		TransactionID string
+
+		// ReceiptNumber is the M-Pesa receipt number of the transaction being refunded.
+		ReceiptNumber string
+
+		// Amount refunded.
+		Amount uint
+
+		// Status of the refund.
+		Status RefundStatus
+
+		// ResultCode and ResultDesc are set from the reversal result.
+		ResultCode int
+		ResultDesc string
+
+		// CreatedAt is the time the reversal was requested.
+		CreatedAt time.Time
+
+		// CompletedAt is the time the refund reached a terminal status.
+		CompletedAt time.Time
+	}
+
+	// RefundConfig configures a RefundManager.
+	RefundConfig struct {
+		// InitiatorName and InitiatorPassword are the credentials of the API operator allowed to reverse transactions.
+		InitiatorName     string
+		InitiatorPassword string
+
+		// ShortCode is the organization shortcode that received the transactions.
+		ShortCode uint
+
+		// QueueTimeOutURL and ResultURL are sent with the reversal and status requests. The ResultURL handler must
+		// pass the callbacks to RefundManager.HandleResult.
+		QueueTimeOutURL string
+		ResultURL       string
+
+		// Remarks sent with the reversal requests. Defaults to "Refund".
+		Remarks string
+
+		// OnStatusChange, if set, is called every time a refund changes status.
+		OnStatusChange func(refund Refund)
+	}
+
+	// RefundManager refunds completed transactions using the reversal API. It validates the original transaction,
+	// initiates the reversal, correlates the result callbacks and marks the transaction as reversed once its refunds
+	// add up to its amount.
+	RefundManager struct {
+		app     *Mpesa
+		machine *TransactionStateMachine
+		cfg     RefundConfig
+
+		mu          sync.Mutex
+		refunds     map[string]*Refund
+		byTxn       map[string]string
+		inFlight    map[string]bool
+		refunded    map[string]uint
+		queries     map[string]string
+		subscribers map[string][]chan struct{}
+	}
+)
+
+// NewRefundManager creates a RefundManager that reverses the transactions tracked by the state machine.
+func NewRefundManager(app *Mpesa, machine *TransactionStateMachine, cfg RefundConfig) *RefundManager {
+	if cfg.Remarks == "" {
+		cfg.Remarks = "Refund"
+	}
+
+	return &RefundManager{
+		app:         app,
+		machine:     machine,
+		cfg:         cfg,
+		refunds:     make(map[string]*Refund),
+		byTxn:       make(map[string]string),
+		inFlight:    make(map[string]bool),
+		refunded:    make(map[string]uint),
+		queries:     make(map[string]string),
+		subscribers: make(map[string][]chan struct{}),
+	}
+}
+
+// Refund initiates the refund of amount from the transaction with the provided ID. An amount of 0 refunds the amount
+// of the transaction not refunded yet. The amounts of the pending and completed refunds of a transaction cannot exceed
+// its amount. The refund is pending until the reversal result is received.
+func (m *RefundManager) Refund(ctx context.Context, transactionID string, amount uint) (Refund, error) {
+	txn, err := m.machine.Store.Get(ctx, transactionID)
+	if err != nil {
+		return Refund{}, err
+	}
+
+	if txn.Status != TransactionStatusCompleted || txn.ReceiptNumber == "" {
+		return Refund{}, fmt.Errorf("%w: %s is %s", ErrRefundNotAllowed, txn.ID, txn.Status)
+	}
+
+	m.mu.Lock()
+	if id, ok := m.byTxn[txn.ID]; m.inFlight[txn.ID] || ok && !m.refunds[id].Status.IsTerminal() {
+		m.mu.Unlock()
+		return Refund{}, fmt.Errorf("%w: %s", ErrRefundInProgress, txn.ID)
+	}
+
+	refunded := m.refunded[txn.ID]
+	if amount == 0 && txn.Amount > float64(refunded) {
+		amount = uint(txn.Amount) - refunded
+	}
+
+	if amount == 0 || float64(refunded+amount) > txn.Amount {
+		m.mu.Unlock()
+		return Refund{}, fmt.Errorf("%w: %d of %.2f, %d already refunded", ErrInvalidRefundAmount, amount, txn.Amount,
+			refunded)
+	}
+
+	m.inFlight[txn.ID] = true
+	m.refunded[txn.ID] += amount
+	m.mu.Unlock()
+
+	res, err := m.app.Reversal(ctx, m.cfg.InitiatorPassword, ReversalRequest{
+		Amount:          amount,
+		Initiator:       m.cfg.InitiatorName,
+		QueueTimeOutURL: m.cfg.QueueTimeOutURL,
+		ReceiverParty:   m.cfg.ShortCode,
+		Remarks:         m.cfg.Remarks,
+		ResultURL:       m.cfg.ResultURL,
+		TransactionID:   txn.ReceiptNumber,
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.inFlight, txn.ID)
+
+	if err != nil {
+		m.refunded[txn.ID] -= amount
+		return Refund{}, err
+	}
+
+	refund := &Refund{
+		ID:                       res.ConversationID,
+		OriginatorConversationID: res.OriginatorConversationID,
+		TransactionID:            txn.ID,
+		ReceiptNumber:            txn.ReceiptNumber,
+		Amount:                   amount,
+		Status:                   RefundStatusPending,
+		CreatedAt:                time.Now(),
+	}
+
+	m.refunds[refund.ID] = refund
+	m.byTxn[txn.ID] = refund.ID
+
+	return *refund, nil
+}
+
+// Get returns the refund with the provided ID.
+func (m *RefundManager) Get(id string) (Refund, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	refund, ok := m.refunds[id]
+	if !ok {
+		return Refund{}, fmt.Errorf("%w: %s", ErrUnknownRefund, id)
+	}
+
+	return *refund, nil
+}
+
+// QueryStatus requests the status of a pending refund, e.g. after a queue timeout. The status is delivered to the
+// ResultURL and must be passed to HandleResult.
+func (m *RefundManager) QueryStatus(ctx context.Context, id string) error {
+	refund, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+
+	res, err := m.app.GetTransactionStatus(ctx, m.cfg.InitiatorPassword, TransactionStatusRequest{
+		Initiator:                m.cfg.InitiatorName,
+		OriginatorConversationID: refund.OriginatorConversationID,
+		PartyA:                   m.cfg.ShortCode,
+		QueueTimeOutURL:          m.cfg.QueueTimeOutURL,
+		Remarks:                  m.cfg.Remarks,
+		ResultURL:                m.cfg.ResultURL,
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.queries[res.ConversationID] = id
+	m.mu.Unlock()
+
+	return nil
+}
+
+// HandleResult updates the refund the reversal or status query result callback belongs to.
+func (m *RefundManager) HandleResult(ctx context.Context, callback *Callback) (Refund, error) {
+	result := callback.Result
+
+	m.mu.Lock()
+	refund, ok := m.refunds[result.ConversationID]
+	if !ok {
+		if id, isQuery := m.queries[result.ConversationID]; isQuery {
+			delete(m.queries, result.ConversationID)
+			refund, ok = m.refunds[id]
+			if ok {
+				m.mu.Unlock()
+				return m.handleStatusResult(ctx, refund.ID, result)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return Refund{}, fmt.Errorf("%w: %s", ErrUnknownRefund, result.ConversationID)
+	}
+
+	status := RefundStatusCompleted
+	if result.ResultCode != 0 {
+		status = RefundStatusFailed
+	}
+
+	return m.complete(ctx, result.ConversationID, status, result.ResultCode, result.ResultDesc)
+}
+
+// handleStatusResult completes the refund from the TransactionStatus parameter of a status query result.
+func (m *RefundManager) handleStatusResult(ctx context.Context, id string, result CallbackResult) (Refund, error) {
+	if result.ResultCode != 0 {
+		return m.Get(id)
+	}
+
+	status, _ := result.ResultParameters.String(ResultParameterTransactionStatus)
+
+	switch strings.ToLower(status) {
+	case "completed":
+		return m.complete(ctx, id, RefundStatusCompleted, 0, result.ResultDesc)
+	case "failed", "cancelled", "declined":
+		return m.complete(ctx, id, RefundStatusFailed, result.ResultCode, status)
+	}
+
+	return m.Get(id)
+}
+
+func (m *RefundManager) complete(
+	ctx context.Context, id string, status RefundStatus, resultCode int, resultDesc string,
+) (Refund, error) {
+	m.mu.Lock()
+	refund, ok := m.refunds[id]
+	if !ok {
+		m.mu.Unlock()
+		return Refund{}, fmt.Errorf("%w: %s", ErrUnknownRefund, id)
+	}
+
+	if refund.Status.IsTerminal() {
+		m.mu.Unlock()
+		return *refund, nil
+	}
+
+	refund.Status = status
+	refund.ResultCode = resultCode
+	refund.ResultDesc = resultDesc
+	refund.CompletedAt = time.Now()
+
+	if status != RefundStatusCompleted {
+		m.refunded[refund.TransactionID] -= refund.Amount
+	}
+	refunded := m.refunded[refund.TransactionID]
+
+	for _, ch := range m.subscribers[id] {
+		close(ch)
+	}
+	delete(m.subscribers, id)
+
+	result := *refund
+	m.mu.Unlock()
+
+	if m.cfg.OnStatusChange != nil {
+		m.cfg.OnStatusChange(result)
+	}
+
+	if status != RefundStatusCompleted {
+		return result, nil
+	}
+
+	txn, err := m.machine.Store.Get(ctx, result.TransactionID)
+	if err != nil {
+		return result, err
+	}
+
+	if float64(refunded) >= txn.Amount {
+		if _, err = m.machine.Reverse(ctx, txn.ID); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// Wait blocks until the refund with the provided ID reaches a terminal status or the context is done.
+func (m *RefundManager) Wait(ctx context.Context, id string) (Refund, error) {
+	m.mu.Lock()
+	refund, ok := m.refunds[id]
+	if !ok {
+		m.mu.Unlock()
+		return Refund{}, fmt.Errorf("%w: %s", ErrUnknownRefund, id)
+	}
+
+	if refund.Status.IsTerminal() {
+		result := *refund
+		m.mu.Unlock()
+		return result, nil
+	}
+
+	ch := make(chan struct{})
+	m.subscribers[id] = append(m.subscribers[id], ch)
+	m.mu.Unlock()
+
+	select {
+	case <-ch:
+		return m.Get(id)
+	case <-ctx.Done():
+		refund, _ := m.Get(id)
+		return refund, ctx.Err()
+	}
+}
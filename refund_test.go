@@ -0,0 +1,229 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefundManager(t *testing.T) {
+	ctx := context.Background()
+
+	newManager := func(t *testing.T) (*RefundManager, *TransactionStateMachine, *mockHttpClient) {
+		var (
+			cl      = newMockHttpClient()
+			app     = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			machine = NewTransactionStateMachine(NewMemoryStore())
+		)
+
+		mockAuth(app, cl)
+
+		cl.MockRequest(app.endpointReversal(), func() (status int, body string) {
+			return http.StatusOK, `{
+				"OriginatorConversationID": "f1e2-4b95-a71d-b30d3cdbb7a7735297",
+				"ConversationID": "AG_20210706_20106e9209f64bebd05b",
+				"ResponseCode": "0",
+				"ResponseDescription": "Accept the service request successfully."
+			}`
+		})
+
+		cl.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+			return http.StatusOK, `{
+				"OriginatorConversationID": "5118-111210482-1",
+				"ConversationID": "AG_20210707_2010615ce97ab0c8a2b7",
+				"ResponseCode": "0",
+				"ResponseDescription": "Accept the service request successfully."
+			}`
+		})
+
+		err := machine.Store.Save(ctx, Transaction{
+			ID:            "ws_CO_191220191020363925",
+			Kind:          TransactionKindSTKPush,
+			Status:        TransactionStatusCompleted,
+			ShortCode:     600426,
+			Amount:        100,
+			ReceiptNumber: "SAM62HFIRW",
+		})
+		require.NoError(t, err)
+
+		m := NewRefundManager(app, machine, RefundConfig{
+			InitiatorName:     "testapi",
+			InitiatorPassword: "Safaricom999!*!",
+			ShortCode:         600426,
+			QueueTimeOutURL:   "https://example.com/timeout",
+			ResultURL:         "https://example.com/result",
+		})
+
+		return m, machine, cl
+	}
+
+	t.Run("it reverses the transaction once the full refund completes", func(t *testing.T) {
+		m, machine, _ := newManager(t)
+
+		var changes []RefundStatus
+		m.cfg.OnStatusChange = func(refund Refund) {
+			changes = append(changes, refund.Status)
+		}
+
+		refund, err := m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.NoError(t, err)
+		require.Equal(t, "AG_20210706_20106e9209f64bebd05b", refund.ID)
+		require.Equal(t, uint(100), refund.Amount)
+		require.Equal(t, RefundStatusPending, refund.Status)
+
+		done := make(chan Refund)
+		go func(id string) {
+			refund, _ := m.Wait(ctx, id)
+			done <- refund
+		}(refund.ID)
+
+		refund, err = m.HandleResult(ctx, &Callback{Result: CallbackResult{
+			ConversationID: "AG_20210706_20106e9209f64bebd05b",
+			ResultDesc:     "The service request is processed successfully.",
+		}})
+		require.NoError(t, err)
+		require.Equal(t, RefundStatusCompleted, refund.Status)
+		require.Equal(t, []RefundStatus{RefundStatusCompleted}, changes)
+
+		select {
+		case refund = <-done:
+			require.Equal(t, RefundStatusCompleted, refund.Status)
+		case <-time.After(time.Second):
+			t.Fatal("Wait did not return")
+		}
+
+		txn, err := machine.Store.Get(ctx, "ws_CO_191220191020363925")
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusReversed, txn.Status)
+
+		_, err = m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.True(t, errors.Is(err, ErrRefundNotAllowed))
+	})
+
+	t.Run("a partial refund does not reverse the transaction", func(t *testing.T) {
+		m, machine, _ := newManager(t)
+
+		refund, err := m.Refund(ctx, "ws_CO_191220191020363925", 40)
+		require.NoError(t, err)
+
+		_, err = m.HandleResult(ctx, &Callback{Result: CallbackResult{ConversationID: refund.ID}})
+		require.NoError(t, err)
+
+		txn, err := machine.Store.Get(ctx, "ws_CO_191220191020363925")
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusCompleted, txn.Status)
+	})
+
+	t.Run("it reverses the transaction once the partial refunds add up to its amount", func(t *testing.T) {
+		m, machine, cl := newManager(t)
+
+		var n int
+		cl.MockRequest(m.app.endpointReversal(), func() (status int, body string) {
+			n++
+			return http.StatusOK, fmt.Sprintf(`{
+				"OriginatorConversationID": "f1e2-4b95-a71d-b30d3cdbb7a77352%d",
+				"ConversationID": "AG_20210706_20106e9209f64bebd05%d",
+				"ResponseCode": "0",
+				"ResponseDescription": "Accept the service request successfully."
+			}`, n, n)
+		})
+
+		refund, err := m.Refund(ctx, "ws_CO_191220191020363925", 60)
+		require.NoError(t, err)
+
+		_, err = m.HandleResult(ctx, &Callback{Result: CallbackResult{ConversationID: refund.ID}})
+		require.NoError(t, err)
+
+		_, err = m.Refund(ctx, "ws_CO_191220191020363925", 50)
+		require.True(t, errors.Is(err, ErrInvalidRefundAmount))
+
+		refund, err = m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.NoError(t, err)
+		require.Equal(t, uint(40), refund.Amount)
+
+		_, err = m.HandleResult(ctx, &Callback{Result: CallbackResult{ConversationID: refund.ID}})
+		require.NoError(t, err)
+
+		txn, err := machine.Store.Get(ctx, "ws_CO_191220191020363925")
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusReversed, txn.Status)
+	})
+
+	t.Run("it validates the original transaction", func(t *testing.T) {
+		m, machine, _ := newManager(t)
+
+		_, err := m.Refund(ctx, "ws_CO_191220191020363925", 101)
+		require.True(t, errors.Is(err, ErrInvalidRefundAmount))
+
+		_, err = m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.NoError(t, err)
+
+		_, err = m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.True(t, errors.Is(err, ErrRefundInProgress))
+
+		require.NoError(t, machine.Store.Save(ctx, Transaction{ID: "ws_CO_pending", Status: TransactionStatusPending}))
+
+		_, err = m.Refund(ctx, "ws_CO_pending", 0)
+		require.True(t, errors.Is(err, ErrRefundNotAllowed))
+
+		_, err = m.Refund(ctx, "ws_CO_unknown", 0)
+		require.True(t, errors.Is(err, ErrTransactionNotFound))
+	})
+
+	t.Run("a failed reversal can be retried", func(t *testing.T) {
+		m, machine, _ := newManager(t)
+
+		refund, err := m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.NoError(t, err)
+
+		refund, err = m.HandleResult(ctx, &Callback{Result: CallbackResult{
+			ConversationID: refund.ID,
+			ResultCode:     2001,
+			ResultDesc:     "The initiator information is invalid.",
+		}})
+		require.NoError(t, err)
+		require.Equal(t, RefundStatusFailed, refund.Status)
+		require.Equal(t, 2001, refund.ResultCode)
+
+		txn, err := machine.Store.Get(ctx, "ws_CO_191220191020363925")
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusCompleted, txn.Status)
+
+		_, err = m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.NoError(t, err)
+	})
+
+	t.Run("it completes the refund from a status query result", func(t *testing.T) {
+		m, _, _ := newManager(t)
+
+		refund, err := m.Refund(ctx, "ws_CO_191220191020363925", 0)
+		require.NoError(t, err)
+
+		require.NoError(t, m.QueryStatus(ctx, refund.ID))
+
+		refund, err = m.HandleResult(ctx, &Callback{Result: CallbackResult{
+			ConversationID: "AG_20210707_2010615ce97ab0c8a2b7",
+			ResultDesc:     "The service request is processed successfully.",
+			ResultParameters: ResultParameters{ResultParameter: []ResultParameter{
+				{Key: "TransactionStatus", Value: "Completed"},
+			}},
+		}})
+		require.NoError(t, err)
+		require.Equal(t, RefundStatusCompleted, refund.Status)
+	})
+
+	t.Run("unknown results are rejected", func(t *testing.T) {
+		m, _, _ := newManager(t)
+
+		_, err := m.HandleResult(ctx, &Callback{Result: CallbackResult{ConversationID: "AG_unknown"}})
+		require.True(t, errors.Is(err, ErrUnknownRefund))
+
+		_, err = m.Get("AG_unknown")
+		require.True(t, errors.Is(err, ErrUnknownRefund))
+	})
+}
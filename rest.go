@@ -0,0 +1,115 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Paths served by the handler returned by NewAPIHandler.
+const (
+	APIPathSTKPush  = "/stkpush"
+	APIPathSTKQuery = "/stkpush/query"
+	APIPathB2C      = "/b2c"
+)
+
+// maxAPIRequestBodySize is the maximum size of the request body accepted by the API handler.
+const maxAPIRequestBodySize = 64 << 10
+
+// APIHandlerConfig holds the credentials used by the handler returned by NewAPIHandler. These are kept on the server
+// so that clients never send them.
+type APIHandlerConfig struct {
+	// Passkey is used to generate the password for STKPush and STKQuery requests.
+	Passkey string
+
+	// InitiatorName and InitiatorPassword are the credentials of the B2C API operator.
+	InitiatorName     string
+	InitiatorPassword string
+}
+
+// APIError is the JSON body written by the API handler when a request fails.
+type APIError struct {
+	// Error describes why the request failed.
+	Error string `json:"error"`
+}
+
+// NewAPIHandler returns a http.Handler that exposes the SDK as a JSON API. The request bodies are the SDK request
+// types and the responses are encoded as the SDK response types. The handler serves:
+//
+//	POST /stkpush        STKPushRequest  - initiates an STK push.
+//	POST /stkpush/query  STKQueryRequest - queries the status of an STK push.
+//	POST /b2c            B2CRequest      - initiates a B2C payment. The InitiatorName is set from the config.
+//
+// Use http.StripPrefix to mount the handler under a path.
+func NewAPIHandler(app *Mpesa, cfg APIHandlerConfig) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle(APIPathSTKPush, apiHandlerFunc(func(ctx context.Context, req STKPushRequest) (*STKPushResponse, error) {
+		return app.STKPush(ctx, cfg.Passkey, req)
+	}))
+
+	mux.Handle(APIPathSTKQuery, apiHandlerFunc(func(ctx context.Context, req STKQueryRequest) (*STKQueryResponse, error) {
+		return app.STKQuery(ctx, cfg.Passkey, req)
+	}))
+
+	mux.Handle(APIPathB2C, apiHandlerFunc(func(ctx context.Context, req B2CRequest) (*B2CResponse, error) {
+		req.InitiatorName = cfg.InitiatorName
+		return app.B2C(ctx, cfg.InitiatorPassword, req)
+	}))
+
+	return mux
+}
+
+// apiHandlerFunc decodes the JSON body into T, calls fn and writes the response as JSON.
+func apiHandlerFunc[T, R any](fn func(ctx context.Context, req T) (*R, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeAPIError(w, http.StatusMethodNotAllowed, errors.New("mpesa: method not allowed"))
+			return
+		}
+
+		var req T
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxAPIRequestBodySize)).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("mpesa: decode request: %v", err))
+			return
+		}
+
+		res, err := fn(r.Context(), req)
+		if err != nil {
+			writeAPIError(w, apiErrorStatus(err), err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	})
+}
+
+// apiErrorStatus maps an error returned by the SDK to the http status code returned to the client.
+func apiErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidPasskey), errors.Is(err, ErrInvalidInitiatorPassword):
+		return http.StatusInternalServerError
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, APIError{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
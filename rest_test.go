@@ -0,0 +1,178 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        APIHandlerConfig
+		method     string
+		path       string
+		body       string
+		mock       func(t *testing.T, app *Mpesa, c *mockHttpClient)
+		wantStatus int
+		want       string
+	}{
+		{
+			name:   "it initiates an stk push",
+			cfg:    APIHandlerConfig{Passkey: "passkey"},
+			method: http.MethodPost,
+			path:   APIPathSTKPush,
+			body: `{
+				"BusinessShortCode": 174379,
+				"TransactionType": "CustomerPayBillOnline",
+				"Amount": 10,
+				"PartyA": 254708374149,
+				"PartyB": 174379,
+				"PhoneNumber": 254708374149,
+				"CallBackURL": "https://example.com",
+				"AccountReference": "Test",
+				"TransactionDesc": "Test"
+			}`,
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointSTK(), func() (status int, body string) {
+					return http.StatusOK, `
+						{
+						  "MerchantRequestID": "29115-34620561-1",
+						  "CheckoutRequestID": "ws_CO_191220191020363925",
+						  "ResponseCode": "0",
+						  "ResponseDescription": "Success. Request accepted for processing",
+						  "CustomerMessage": "Success. Request accepted for processing"
+						}`
+				})
+			},
+			wantStatus: http.StatusOK,
+			want: `{
+				"MerchantRequestID": "29115-34620561-1",
+				"CheckoutRequestID": "ws_CO_191220191020363925",
+				"ResponseCode": "0",
+				"ResponseDescription": "Success. Request accepted for processing",
+				"CustomerMessage": "Success. Request accepted for processing"
+			}`,
+		},
+		{
+			name:   "it sets the initiator name on b2c requests",
+			cfg:    APIHandlerConfig{InitiatorName: "testapi", InitiatorPassword: "Safaricom999!*!"},
+			method: http.MethodPost,
+			path:   APIPathB2C,
+			body: `{
+				"CommandID": "BusinessPayment",
+				"Amount": 10,
+				"PartyA": 600426,
+				"PartyB": 254708374149,
+				"QueueTimeOutURL": "https://example.com/timeout",
+				"ResultURL": "https://example.com/result",
+				"OriginatorConversationID": "10571-7082437-1"
+			}`,
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointB2C(), func() (status int, body string) {
+					var req B2CRequest
+					require.NoError(t, json.NewDecoder(c.requests[len(c.requests)-1].Body).Decode(&req))
+					require.Equal(t, "testapi", req.InitiatorName)
+					require.NotEmpty(t, req.SecurityCredential)
+					require.Equal(t, "10571-7082437-1", req.OriginatorConversationID)
+
+					return http.StatusOK, `
+						{
+						  "ConversationID": "AG_20191219_00005797af5d7d75f652",
+						  "OriginatorConversationID": "10571-7082437-1",
+						  "ResponseCode": "0"
+						}`
+				})
+			},
+			wantStatus: http.StatusOK,
+			want: `{
+				"ConversationID": "AG_20191219_00005797af5d7d75f652",
+				"OriginatorConversationID": "10571-7082437-1",
+				"ResponseCode": "0"
+			}`,
+		},
+		{
+			name:       "it rejects invalid json",
+			method:     http.MethodPost,
+			path:       APIPathSTKQuery,
+			body:       `{"BusinessShortCode": "174379"`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "it fails with bad request if the request is invalid",
+			cfg:        APIHandlerConfig{InitiatorName: "testapi", InitiatorPassword: "Safaricom999!*!"},
+			method:     http.MethodPost,
+			path:       APIPathB2C,
+			body:       `{"CommandID": "BusinessPayment", "Amount": 10, "PartyA": 600426, "PartyB": 254708374149}`,
+			wantStatus: http.StatusBadRequest,
+			want: `{"error": "mpesa: invalid request: QueueTimeOutURL must be a valid https URL; ` +
+				`ResultURL must be a valid https URL"}`,
+		},
+		{
+			name:       "it rejects methods other than post",
+			method:     http.MethodGet,
+			path:       APIPathSTKQuery,
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:       "it fails with internal server error if the passkey is not configured",
+			method:     http.MethodPost,
+			path:       APIPathSTKQuery,
+			body:       `{"BusinessShortCode": 174379, "CheckoutRequestID": "ws_CO_191220191020363925"}`,
+			wantStatus: http.StatusInternalServerError,
+			want:       `{"error": "mpesa: passkey cannot be empty"}`,
+		},
+		{
+			name:   "it fails with bad gateway if mpesa rejects the request",
+			cfg:    APIHandlerConfig{Passkey: "passkey"},
+			method: http.MethodPost,
+			path:   APIPathSTKQuery,
+			body:   `{"BusinessShortCode": 174379, "CheckoutRequestID": "ws_CO_191220191020363925"}`,
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					return http.StatusInternalServerError, `
+						{
+							"requestId": "11728-2929992-1",
+							"errorCode": "500.001.1001",
+							"errorMessage": "The transaction is being processed"
+						}`
+				})
+			},
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				cl  = newMockHttpClient()
+				app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+				rec = httptest.NewRecorder()
+			)
+
+			mockAuth(app, cl)
+			if tc.mock != nil {
+				tc.mock(t, app, cl)
+			}
+
+			handler := NewAPIHandler(app, tc.cfg)
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)).
+				WithContext(context.Background()))
+
+			require.Equal(t, tc.wantStatus, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			if tc.want != "" {
+				require.JSONEq(t, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
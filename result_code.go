@@ -0,0 +1,114 @@
+package mpesa
+
+import "strconv"
+
+// ResultCode is the ResultCode of an asynchronous result, e.g. of B2C, B2B, transaction status, account balance or
+// reversal requests.
+type ResultCode int
+
+// Well-known result codes of the asynchronous results.
+const (
+	// ResultSuccess means the transaction was processed.
+	ResultSuccess ResultCode = 0
+
+	// ResultInsufficientFunds means the debit account does not have enough funds, including the charges.
+	ResultInsufficientFunds ResultCode = 1
+
+	// ResultLessThanMinimum means the amount is less than the minimum amount allowed.
+	ResultLessThanMinimum ResultCode = 2
+
+	// ResultMoreThanMaximum means the amount is more than the maximum amount allowed.
+	ResultMoreThanMaximum ResultCode = 3
+
+	// ResultDailyLimitExceeded means the transaction would exceed the daily transfer limit.
+	ResultDailyLimitExceeded ResultCode = 4
+
+	// ResultMinimumBalanceExceeded means the transaction would take the debit account below its minimum balance.
+	ResultMinimumBalanceExceeded ResultCode = 5
+
+	// ResultUnresolvedPrimaryParty means the initiating party could not be found.
+	ResultUnresolvedPrimaryParty ResultCode = 6
+
+	// ResultUnresolvedReceiverParty means the receiving party, e.g. the phone number or shortcode, could not be found.
+	ResultUnresolvedReceiverParty ResultCode = 7
+
+	// ResultMaximumBalanceExceeded means the transaction would take the credit account above its maximum balance.
+	ResultMaximumBalanceExceeded ResultCode = 8
+
+	// ResultInvalidDebitAccount means the debit account is invalid.
+	ResultInvalidDebitAccount ResultCode = 11
+
+	// ResultInvalidCreditAccount means the credit account is invalid.
+	ResultInvalidCreditAccount ResultCode = 12
+
+	// ResultUnresolvedDebitAccount means the debit account could not be found.
+	ResultUnresolvedDebitAccount ResultCode = 13
+
+	// ResultUnresolvedCreditAccount means the credit account could not be found.
+	ResultUnresolvedCreditAccount ResultCode = 14
+
+	// ResultDuplicateDetected means M-Pesa rejected the request as a duplicate of a recent one.
+	ResultDuplicateDetected ResultCode = 15
+
+	// ResultInternalFailure means M-Pesa failed to process the request. It is usually safe to retry.
+	ResultInternalFailure ResultCode = 17
+
+	// ResultUnresolvedInitiator means the initiator could not be found.
+	ResultUnresolvedInitiator ResultCode = 20
+
+	// ResultTrafficBlocking means M-Pesa throttled the request. Retry it later.
+	ResultTrafficBlocking ResultCode = 26
+
+	// ResultInvalidInitiator means the initiator name or security credential is invalid.
+	ResultInvalidInitiator ResultCode = 2001
+
+	// ResultUnregisteredCustomer means the receiving phone number is not registered on M-Pesa.
+	ResultUnregisteredCustomer ResultCode = 2040
+)
+
+// resultCodeDescs are the descriptions of the well-known result codes.
+var resultCodeDescs = map[ResultCode]string{
+	ResultSuccess:                 "success",
+	ResultInsufficientFunds:       "insufficient funds",
+	ResultLessThanMinimum:         "less than the minimum amount",
+	ResultMoreThanMaximum:         "more than the maximum amount",
+	ResultDailyLimitExceeded:      "daily limit exceeded",
+	ResultMinimumBalanceExceeded:  "minimum balance exceeded",
+	ResultUnresolvedPrimaryParty:  "unresolved primary party",
+	ResultUnresolvedReceiverParty: "unresolved receiver party",
+	ResultMaximumBalanceExceeded:  "maximum balance exceeded",
+	ResultInvalidDebitAccount:     "invalid debit account",
+	ResultInvalidCreditAccount:    "invalid credit account",
+	ResultUnresolvedDebitAccount:  "unresolved debit account",
+	ResultUnresolvedCreditAccount: "unresolved credit account",
+	ResultDuplicateDetected:       "duplicate detected",
+	ResultInternalFailure:         "internal failure",
+	ResultUnresolvedInitiator:     "unresolved initiator",
+	ResultTrafficBlocking:         "traffic blocking",
+	ResultInvalidInitiator:        "invalid initiator information",
+	ResultUnregisteredCustomer:    "unregistered customer",
+}
+
+// String returns a short description of the code, e.g. "insufficient funds", or the number of an unknown code.
+func (c ResultCode) String() string {
+	if desc, ok := resultCodeDescs[c]; ok {
+		return desc
+	}
+
+	return "result code " + strconv.Itoa(int(c))
+}
+
+// IsSuccessful reports whether the transaction was processed.
+func (c ResultCode) IsSuccessful() bool {
+	return c == ResultSuccess
+}
+
+// IsTemporary reports whether the failure is on the M-Pesa side and the request may succeed if it is sent again.
+func (c ResultCode) IsTemporary() bool {
+	return c == ResultInternalFailure || c == ResultTrafficBlocking
+}
+
+// Code returns the typed ResultCode of the result.
+func (r CallbackResult) Code() ResultCode {
+	return ResultCode(r.ResultCode)
+}
@@ -0,0 +1,42 @@
+package mpesa
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCallbackResult_Code(t *testing.T) {
+	tests := []struct {
+		name       string
+		resultCode string
+		want       ResultCode
+		desc       string
+		successful bool
+		temporary  bool
+	}{
+		{name: "success", resultCode: `0`, want: ResultSuccess, desc: "success", successful: true},
+		{name: "invalid initiator", resultCode: `2001`, want: ResultInvalidInitiator, desc: "invalid initiator information"},
+		{name: "internal failure", resultCode: `17`, want: ResultInternalFailure, desc: "internal failure", temporary: true},
+		{name: "traffic blocking sent as a string", resultCode: `"26"`, want: ResultTrafficBlocking, desc: "traffic blocking",
+			temporary: true},
+		{name: "unknown", resultCode: `4242`, want: ResultCode(4242), desc: "result code 4242"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			callback, err := UnmarshalCallback(strings.NewReader(
+				`{"Result": {"ConversationID": "AG_1", "ResultType": 0, "ResultCode": ` + tc.resultCode + `}}`,
+			))
+			require.NoError(t, err)
+
+			code := callback.Result.Code()
+			require.Equal(t, tc.want, code)
+			require.Equal(t, tc.desc, code.String())
+			require.Equal(t, tc.successful, code.IsSuccessful())
+			require.Equal(t, tc.temporary, code.IsTemporary())
+		})
+	}
+}
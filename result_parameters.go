@@ -0,0 +1,184 @@
+package mpesa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the result parameters sent on B2C, transaction status and account balance result callbacks.
+const (
+	ResultParameterTransactionAmount                   = "TransactionAmount"
+	ResultParameterTransactionReceipt                  = "TransactionReceipt"
+	ResultParameterReceiverPartyPublicName             = "ReceiverPartyPublicName"
+	ResultParameterTransactionCompletedDateTime        = "TransactionCompletedDateTime"
+	ResultParameterB2CRecipientIsRegisteredCustomer    = "B2CRecipientIsRegisteredCustomer"
+	ResultParameterB2CUtilityAccountAvailableFunds     = "B2CUtilityAccountAvailableFunds"
+	ResultParameterB2CWorkingAccountAvailableFunds     = "B2CWorkingAccountAvailableFunds"
+	ResultParameterB2CChargesPaidAccountAvailableFunds = "B2CChargesPaidAccountAvailableFunds"
+	ResultParameterAccountBalance                      = "AccountBalance"
+	ResultParameterBOCompletedTime                     = "BOCompletedTime"
+	ResultParameterTransactionStatus                   = "TransactionStatus"
+	ResultParameterReceiptNo                           = "ReceiptNo"
+)
+
+// Keys of the result parameters sent on Business Pay Bill and Business Buy Goods result callbacks.
+const (
+	ResultParameterAmount                           = "Amount"
+	ResultParameterCurrency                         = "Currency"
+	ResultParameterDebitAccountBalance              = "DebitAccountBalance"
+	ResultParameterDebitPartyAffectedAccountBalance = "DebitPartyAffectedAccountBalance"
+	ResultParameterDebitPartyCharges                = "DebitPartyCharges"
+	ResultParameterInitiatorAccountCurrentBalance   = "InitiatorAccountCurrentBalance"
+	ResultParameterTransCompletedTime               = "TransCompletedTime"
+)
+
+// Layouts of the time result parameters, in East Africa Time.
+const (
+	resultParameterTransactionCompletedDateTimeLayout = "02.01.2006 15:04:05"
+	resultParameterBOCompletedTimeLayout              = "20060102150405"
+	resultParameterTransCompletedTimeLayout           = "20060102150405"
+)
+
+// Get returns the value of the parameter with the key. It returns false if there is no such parameter.
+func (p ResultParameters) Get(key string) (interface{}, bool) {
+	for _, param := range p.ResultParameter {
+		if param.Key == key {
+			return param.Value, param.Value != nil
+		}
+	}
+
+	return nil, false
+}
+
+// String returns the value of the parameter with the key as a string. Numbers are formatted without a trailing
+// fraction when they are whole.
+func (p ResultParameters) String(key string) (string, bool) {
+	v, ok := p.Get(key)
+	if !ok {
+		return "", false
+	}
+
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64), true
+	}
+
+	return fmt.Sprint(v), true
+}
+
+// Float returns the numeric value of the parameter with the key.
+func (p ResultParameters) Float(key string) (float64, bool) {
+	v, ok := p.Get(key)
+	if !ok {
+		return 0, false
+	}
+
+	return numberValue(v)
+}
+
+// time returns the value of the parameter with the key parsed with the layout in East Africa Time.
+func (p ResultParameters) time(key, layout string) (time.Time, bool) {
+	s, ok := p.String(key)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation(layout, strings.TrimSpace(s), eastAfricaTime)
+	return t, err == nil
+}
+
+// TransactionReceipt returns the M-Pesa receipt number of a B2C payment.
+func (p ResultParameters) TransactionReceipt() (string, bool) {
+	return p.String(ResultParameterTransactionReceipt)
+}
+
+// TransactionAmount returns the amount sent on a B2C payment.
+func (p ResultParameters) TransactionAmount() (float64, bool) {
+	return p.Float(ResultParameterTransactionAmount)
+}
+
+// ReceiverPartyPublicName returns the phone number and name of the B2C recipient, e.g. "254708374149 - John Doe".
+func (p ResultParameters) ReceiverPartyPublicName() (string, bool) {
+	return p.String(ResultParameterReceiverPartyPublicName)
+}
+
+// TransactionCompletedDateTime returns the time a B2C payment was completed.
+func (p ResultParameters) TransactionCompletedDateTime() (time.Time, bool) {
+	return p.time(ResultParameterTransactionCompletedDateTime, resultParameterTransactionCompletedDateTimeLayout)
+}
+
+// B2CRecipientIsRegisteredCustomer returns true if the B2C recipient is a registered M-Pesa customer.
+func (p ResultParameters) B2CRecipientIsRegisteredCustomer() (bool, bool) {
+	s, ok := p.String(ResultParameterB2CRecipientIsRegisteredCustomer)
+	if !ok {
+		return false, false
+	}
+
+	return strings.EqualFold(strings.TrimSpace(s), "Y"), true
+}
+
+// B2CUtilityAccountAvailableFunds returns the balance of the utility account after a B2C payment.
+func (p ResultParameters) B2CUtilityAccountAvailableFunds() (float64, bool) {
+	return p.Float(ResultParameterB2CUtilityAccountAvailableFunds)
+}
+
+// B2CWorkingAccountAvailableFunds returns the balance of the working account after a B2C payment.
+func (p ResultParameters) B2CWorkingAccountAvailableFunds() (float64, bool) {
+	return p.Float(ResultParameterB2CWorkingAccountAvailableFunds)
+}
+
+// B2CChargesPaidAccountAvailableFunds returns the balance of the charges paid account after a B2C payment.
+func (p ResultParameters) B2CChargesPaidAccountAvailableFunds() (float64, bool) {
+	return p.Float(ResultParameterB2CChargesPaidAccountAvailableFunds)
+}
+
+// ParsedAccountBalance returns the per-account balances of an account balance result. See ParseAccountBalances.
+func (p ResultParameters) ParsedAccountBalance() ([]AccountBalance, error) {
+	v, ok := p.Get(ResultParameterAccountBalance)
+	if !ok {
+		return nil, fmt.Errorf("%w: missing AccountBalance parameter", ErrInvalidBalanceResult)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: unexpected value %v", ErrInvalidBalanceResult, v)
+	}
+
+	return ParseAccountBalances(s)
+}
+
+// BOCompletedTime returns the time an account balance query was completed.
+func (p ResultParameters) BOCompletedTime() (time.Time, bool) {
+	return p.time(ResultParameterBOCompletedTime, resultParameterBOCompletedTimeLayout)
+}
+
+// Amount returns the amount paid on a Business Pay Bill or Business Buy Goods request.
+func (p ResultParameters) Amount() (float64, bool) {
+	return p.Float(ResultParameterAmount)
+}
+
+// Currency returns the currency of a Business Pay Bill or Business Buy Goods payment.
+func (p ResultParameters) Currency() (Currency, bool) {
+	s, ok := p.String(ResultParameterCurrency)
+	if !ok {
+		return "", false
+	}
+
+	currency, err := ParseCurrency(s)
+	return currency, err == nil
+}
+
+// DebitPartyCharges returns the charges of a Business Pay Bill or Business Buy Goods payment, which M-Pesa sends as
+// text, e.g. "Business Buy Goods Charge|KES|77.00". It is empty when the payment was free.
+func (p ResultParameters) DebitPartyCharges() (string, bool) {
+	return p.String(ResultParameterDebitPartyCharges)
+}
+
+// TransCompletedTime returns the time a Business Pay Bill or Business Buy Goods payment was completed.
+func (p ResultParameters) TransCompletedTime() (time.Time, bool) {
+	return p.time(ResultParameterTransCompletedTime, resultParameterTransCompletedTimeLayout)
+}
@@ -0,0 +1,164 @@
+package mpesa
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultParameters_B2C(t *testing.T) {
+	callback, err := UnmarshalCallback(strings.NewReader(`{
+		"Result": {
+			"ResultType": 0,
+			"ResultCode": 0,
+			"ResultDesc": "The service request is processed successfully.",
+			"OriginatorConversationID": "10571-7910404-1",
+			"ConversationID": "AG_20191219_00004e48cf7e3533f581",
+			"TransactionID": "NLJ41HAY6Q",
+			"ResultParameters": {
+				"ResultParameter": [
+					{"Key": "TransactionAmount", "Value": 10},
+					{"Key": "TransactionReceipt", "Value": "NLJ41HAY6Q"},
+					{"Key": "B2CRecipientIsRegisteredCustomer", "Value": "Y"},
+					{"Key": "B2CChargesPaidAccountAvailableFunds", "Value": -4510.00},
+					{"Key": "ReceiverPartyPublicName", "Value": "254708374149 - John Doe"},
+					{"Key": "TransactionCompletedDateTime", "Value": "19.12.2019 11:45:50"},
+					{"Key": "B2CUtilityAccountAvailableFunds", "Value": 10116.00},
+					{"Key": "B2CWorkingAccountAvailableFunds", "Value": 900000.00}
+				]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	params := callback.Result.ResultParameters
+
+	receipt, ok := params.TransactionReceipt()
+	require.True(t, ok)
+	require.Equal(t, "NLJ41HAY6Q", receipt)
+
+	amount, ok := params.TransactionAmount()
+	require.True(t, ok)
+	require.Equal(t, 10.0, amount)
+
+	name, ok := params.ReceiverPartyPublicName()
+	require.True(t, ok)
+	require.Equal(t, "254708374149 - John Doe", name)
+
+	completedAt, ok := params.TransactionCompletedDateTime()
+	require.True(t, ok)
+	require.True(t, time.Date(2019, time.December, 19, 8, 45, 50, 0, time.UTC).Equal(completedAt))
+
+	registered, ok := params.B2CRecipientIsRegisteredCustomer()
+	require.True(t, ok)
+	require.True(t, registered)
+
+	utility, ok := params.B2CUtilityAccountAvailableFunds()
+	require.True(t, ok)
+	require.Equal(t, 10116.0, utility)
+
+	working, ok := params.B2CWorkingAccountAvailableFunds()
+	require.True(t, ok)
+	require.Equal(t, 900000.0, working)
+
+	charges, ok := params.B2CChargesPaidAccountAvailableFunds()
+	require.True(t, ok)
+	require.Equal(t, -4510.0, charges)
+
+	s, ok := params.String(ResultParameterTransactionAmount)
+	require.True(t, ok)
+	require.Equal(t, "10", s)
+
+	_, ok = params.Get("Missing")
+	require.False(t, ok)
+
+	_, ok = params.BOCompletedTime()
+	require.False(t, ok)
+}
+
+func TestResultParameters_AccountBalance(t *testing.T) {
+	params := ResultParameters{
+		ResultParameter: []ResultParameter{
+			{
+				Key:   ResultParameterAccountBalance,
+				Value: "Working Account|KES|46713.00|46713.00|0.00|0.00&Utility Account|KES|49217.00|49217.00|0.00|0.00",
+			},
+			{Key: ResultParameterBOCompletedTime, Value: 20200109125710.0},
+		},
+	}
+
+	balances, err := params.ParsedAccountBalance()
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	require.Equal(t, UtilityAccount, balances[1].Name)
+
+	completedAt, ok := params.BOCompletedTime()
+	require.True(t, ok)
+	require.Equal(t, time.Date(2020, time.January, 9, 12, 57, 10, 0, eastAfricaTime), completedAt)
+
+	_, err = ResultParameters{}.ParsedAccountBalance()
+	require.ErrorIs(t, err, ErrInvalidBalanceResult)
+
+	_, err = ResultParameters{
+		ResultParameter: []ResultParameter{{Key: ResultParameterAccountBalance, Value: 10.0}},
+	}.ParsedAccountBalance()
+	require.ErrorIs(t, err, ErrInvalidBalanceResult)
+}
+
+func TestResultParameters_BusinessBuyGoods(t *testing.T) {
+	callback, err := UnmarshalCallback(strings.NewReader(`{
+		"Result": {
+			"ResultType": "0",
+			"ResultCode": 0,
+			"ResultDesc": "The service request is processed successfully",
+			"OriginatorConversationID": "626f6ddf-ab37-4650-b882-b1de92ec9aa4",
+			"ConversationID": "12345677dfdf89099B3",
+			"TransactionID": "QKA81LK5CY",
+			"ResultParameters": {
+				"ResultParameter": [
+					{"Key": "DebitAccountBalance", "Value": "{Amount={CurrencyCode=KES, BasicAmount=6186.83}}"},
+					{"Key": "Amount", "Value": "190.00"},
+					{"Key": "DebitPartyAffectedAccountBalance", "Value": "Working Account|KES|346768.00|346768.00|0.00|0.00"},
+					{"Key": "TransCompletedTime", "Value": "20221110110717"},
+					{"Key": "DebitPartyCharges", "Value": ""},
+					{"Key": "ReceiverPartyPublicName", "Value": "000000– Biller Companj"},
+					{"Key": "Currency", "Value": "KES"},
+					{"Key": "InitiatorAccountCurrentBalance", "Value": "{Amount={CurrencyCode=KES, BasicAmount=6186.83}}"}
+				]
+			}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, 0, callback.Result.ResultType)
+	require.Equal(t, 0, callback.Result.ResultCode)
+	require.Equal(t, "QKA81LK5CY", callback.Result.TransactionID)
+
+	params := callback.Result.ResultParameters
+
+	amount, ok := params.Amount()
+	require.True(t, ok)
+	require.Equal(t, 190.0, amount)
+
+	currency, ok := params.Currency()
+	require.True(t, ok)
+	require.Equal(t, CurrencyKES, currency)
+
+	charges, ok := params.DebitPartyCharges()
+	require.True(t, ok)
+	require.Empty(t, charges)
+
+	completedAt, ok := params.TransCompletedTime()
+	require.True(t, ok)
+	require.Equal(t, time.Date(2022, time.November, 10, 11, 7, 17, 0, eastAfricaTime), completedAt)
+}
+
+func TestCallbackResult_UnmarshalJSON(t *testing.T) {
+	callback, err := UnmarshalCallback(strings.NewReader(`{"Result": {"ResultType": 0, "ResultCode": "2001"}}`))
+	require.NoError(t, err)
+	require.Equal(t, 2001, callback.Result.ResultCode)
+
+	_, err = UnmarshalCallback(strings.NewReader(`{"Result": {"ResultCode": "failed"}}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,172 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// PrunableStore is a TransactionStore that supports deleting transactions. It is required by the
+	// RetentionManager.
+	PrunableStore interface {
+		TransactionStore
+
+		// Delete removes the transactions with the provided IDs. Missing transactions are ignored.
+		Delete(ctx context.Context, ids ...string) error
+	}
+
+	// RetentionPolicy determines which transactions are kept in the store. Only transactions in a terminal status
+	// are ever removed. A zero value keeps everything.
+	RetentionPolicy struct {
+		// MaxAge removes the transactions created more than MaxAge ago.
+		MaxAge time.Duration
+
+		// MaxCount keeps at most MaxCount of the most recent terminal transactions.
+		MaxCount int
+	}
+
+	// ArchiveFunc receives the transactions that are about to be removed from the store. The transactions are only
+	// deleted if it returns nil.
+	ArchiveFunc func(ctx context.Context, transactions []Transaction) error
+
+	// RetentionResult reports the transactions removed by RetentionManager.Enforce.
+	RetentionResult struct {
+		// Archived is the number of transactions handed over to the archive.
+		Archived int
+
+		// Deleted is the number of transactions removed from the store.
+		Deleted int
+	}
+
+	// RetentionManager keeps the transaction store small by removing the transactions that fall outside the
+	// RetentionPolicy, optionally archiving them first.
+	RetentionManager struct {
+		// Store is the store the transactions are removed from.
+		Store PrunableStore
+
+		// Policy determines which transactions are removed.
+		Policy RetentionPolicy
+
+		// Archive, if set, is called with every batch of expired transactions before they are deleted.
+		Archive ArchiveFunc
+	}
+)
+
+// NewRetentionManager creates a RetentionManager that enforces the policy on the store.
+func NewRetentionManager(store PrunableStore, policy RetentionPolicy, archive ArchiveFunc) *RetentionManager {
+	return &RetentionManager{
+		Store:   store,
+		Policy:  policy,
+		Archive: archive,
+	}
+}
+
+// expired returns the terminal transactions that fall outside the policy, oldest first.
+func (r *RetentionManager) expired(ctx context.Context, now time.Time) ([]Transaction, error) {
+	txns, err := r.Store.List(ctx, TransactionFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var terminal []Transaction
+	for _, txn := range txns {
+		if txn.Status.IsTerminal() {
+			terminal = append(terminal, txn)
+		}
+	}
+
+	sort.SliceStable(terminal, func(i, j int) bool {
+		return terminal[i].CreatedAt.Before(terminal[j].CreatedAt)
+	})
+
+	cutoff := 0
+	if r.Policy.MaxCount > 0 && len(terminal) > r.Policy.MaxCount {
+		cutoff = len(terminal) - r.Policy.MaxCount
+	}
+
+	if r.Policy.MaxAge > 0 {
+		deadline := now.Add(-r.Policy.MaxAge)
+		for cutoff < len(terminal) && terminal[cutoff].CreatedAt.Before(deadline) {
+			cutoff++
+		}
+	}
+
+	return terminal[:cutoff], nil
+}
+
+// Enforce archives and deletes the transactions that fall outside the policy.
+func (r *RetentionManager) Enforce(ctx context.Context) (RetentionResult, error) {
+	var result RetentionResult
+
+	expired, err := r.expired(ctx, time.Now())
+	if err != nil {
+		return result, fmt.Errorf("mpesa: list transactions: %v", err)
+	}
+
+	if len(expired) == 0 {
+		return result, nil
+	}
+
+	if r.Archive != nil {
+		if err = r.Archive(ctx, expired); err != nil {
+			return result, fmt.Errorf("mpesa: archive transactions: %w", err)
+		}
+		result.Archived = len(expired)
+	}
+
+	ids := make([]string, len(expired))
+	for i, txn := range expired {
+		ids[i] = txn.ID
+	}
+
+	if err = r.Store.Delete(ctx, ids...); err != nil {
+		return result, fmt.Errorf("mpesa: delete transactions: %v", err)
+	}
+
+	result.Deleted = len(ids)
+	return result, nil
+}
+
+// Run calls Enforce every interval until the context is done. onError, if set, receives the errors returned by
+// Enforce.
+func (r *RetentionManager) Run(ctx context.Context, interval time.Duration, onError func(err error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Enforce(ctx); err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// NewJSONLinesArchive returns an ArchiveFunc that writes every transaction to w as a JSON object on its own line.
+// Use it with a file or an object storage upload stream.
+func NewJSONLinesArchive(w io.Writer) ArchiveFunc {
+	var mu sync.Mutex
+
+	return func(_ context.Context, transactions []Transaction) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		enc := json.NewEncoder(w)
+		for _, txn := range transactions {
+			if err := enc.Encode(txn); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
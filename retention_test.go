@@ -0,0 +1,105 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionManager_Enforce(t *testing.T) {
+	var (
+		ctx = context.Background()
+		now = time.Now()
+	)
+
+	newStore := func(t *testing.T) *MemoryStore {
+		store := NewMemoryStore()
+
+		for _, txn := range []Transaction{
+			{ID: "1", Status: TransactionStatusCompleted, CreatedAt: now.Add(-72 * time.Hour)},
+			{ID: "2", Status: TransactionStatusPending, CreatedAt: now.Add(-60 * time.Hour)},
+			{ID: "3", Status: TransactionStatusFailed, CreatedAt: now.Add(-48 * time.Hour)},
+			{ID: "4", Status: TransactionStatusCompleted, CreatedAt: now.Add(-time.Hour)},
+			{ID: "5", Status: TransactionStatusReversed, CreatedAt: now},
+		} {
+			require.NoError(t, store.Save(ctx, txn))
+		}
+
+		return store
+	}
+
+	remaining := func(t *testing.T, store *MemoryStore) []string {
+		txns, err := store.List(ctx, TransactionFilter{})
+		require.NoError(t, err)
+
+		var ids []string
+		for _, txn := range txns {
+			ids = append(ids, txn.ID)
+		}
+
+		return ids
+	}
+
+	tests := []struct {
+		name          string
+		policy        RetentionPolicy
+		wantRemaining []string
+	}{
+		{
+			name:          "it removes terminal transactions older than the max age",
+			policy:        RetentionPolicy{MaxAge: 24 * time.Hour},
+			wantRemaining: []string{"2", "4", "5"},
+		},
+		{
+			name:          "it keeps the most recent terminal transactions",
+			policy:        RetentionPolicy{MaxCount: 3},
+			wantRemaining: []string{"2", "3", "4", "5"},
+		},
+		{
+			name:          "it applies both limits",
+			policy:        RetentionPolicy{MaxAge: 24 * time.Hour, MaxCount: 1},
+			wantRemaining: []string{"2", "5"},
+		},
+		{
+			name:          "it keeps everything with a zero policy",
+			wantRemaining: []string{"1", "2", "3", "4", "5"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				store   = newStore(t)
+				archive bytes.Buffer
+			)
+
+			result, err := NewRetentionManager(store, tc.policy, NewJSONLinesArchive(&archive)).Enforce(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantRemaining, remaining(t, store))
+
+			deleted := 5 - len(tc.wantRemaining)
+			require.Equal(t, RetentionResult{Archived: deleted, Deleted: deleted}, result)
+			require.Equal(t, deleted, strings.Count(archive.String(), "\n"))
+		})
+	}
+
+	t.Run("it does not delete transactions that failed to archive", func(t *testing.T) {
+		store := newStore(t)
+
+		manager := NewRetentionManager(store, RetentionPolicy{MaxAge: time.Hour}, func(context.Context, []Transaction) error {
+			return errors.New("bucket unavailable")
+		})
+
+		_, err := manager.Enforce(ctx)
+		require.ErrorContains(t, err, "bucket unavailable")
+		require.Len(t, remaining(t, store), 5)
+	})
+}
@@ -0,0 +1,63 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// defaultRetryBackoff is the time to wait before the first retry when WithRetry is given no backoff.
+const defaultRetryBackoff = 500 * time.Millisecond
+
+// WithRetry retries the idempotent requests, i.e. token generation, STKQuery, GetTransactionStatus and
+// GetAccountBalance, that fail with a network error, a 408, a 429 or a 5xx status. Every request is attempted up to
+// maxAttempts times, waiting backoff before the first retry and doubling the wait on every subsequent one. Retries
+// stop as soon as the context is done.
+//
+// Requests that move money are never retried as a failed response does not mean the transaction was not processed.
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	return func(m *Mpesa) {
+		m.retryMaxAttempts = maxAttempts
+		m.retryBackoff = backoff
+	}
+}
+
+// retry calls do until it returns a response that cannot be retried or the attempts configured with WithRetry are
+// used up. The bodies of the responses that are retried are closed.
+func (m *Mpesa) retry(ctx context.Context, do func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		res, err := do()
+		if attempt >= m.retryMaxAttempts || !isRetryableResponse(ctx, res, err) {
+			return res, err
+		}
+
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+		}
+
+		timer := time.NewTimer(m.retryBackoff << (attempt - 1))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableResponse returns true if the request failed with a transient error. Errors caused by the context being
+// done or by an open circuit breaker are not retried.
+func isRetryableResponse(ctx context.Context, res *http.Response, err error) bool {
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrCircuitOpen)
+	}
+
+	return isRetryableStatus(res.StatusCode)
+}
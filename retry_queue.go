@@ -0,0 +1,407 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// RetryEntry is a B2C payout waiting to be retried by the B2CRetryQueue.
+	RetryEntry struct {
+		// ID uniquely identifies the payout, e.g. the caller's payout reference.
+		ID string `json:"id"`
+
+		// Request is the B2C request to retry. The SecurityCredential is generated on every attempt and is never
+		// persisted. Its OriginatorConversationID is fixed when the entry is queued and sent on every attempt so
+		// that the payout can be deduplicated and reconciled.
+		Request B2CRequest `json:"request"`
+
+		// Attempts is the number of failed attempts made so far, including the one that queued the entry.
+		Attempts int `json:"attempts"`
+
+		// NextAttemptAt is the earliest time the next attempt can be made.
+		NextAttemptAt time.Time `json:"nextAttemptAt"`
+
+		// LastError is the error returned by the last failed attempt.
+		LastError string `json:"lastError"`
+
+		// CreatedAt is the time the entry was queued.
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	// RetryStore persists the entries of a B2CRetryQueue. Implementations must be safe for concurrent use.
+	RetryStore interface {
+		// Put creates the entry or replaces an existing entry with the same ID.
+		Put(ctx context.Context, entry RetryEntry) error
+
+		// Due returns up to limit entries whose NextAttemptAt is not after now ordered by NextAttemptAt.
+		Due(ctx context.Context, now time.Time, limit int) ([]RetryEntry, error)
+
+		// Delete removes the entry with the provided ID. Deleting a missing entry is not an error.
+		Delete(ctx context.Context, id string) error
+	}
+
+	// RetryQueueConfig configures a B2CRetryQueue.
+	RetryQueueConfig struct {
+		// InitiatorPassword is the password of the B2C API operator used to generate the security credential.
+		InitiatorPassword string
+
+		// MaxAttempts is the total number of attempts, including the initial failed one, after which the payout
+		// is considered failed. Defaults to 5.
+		MaxAttempts int
+
+		// BaseDelay is the delay before the first retry. It doubles on every subsequent retry. Defaults to 30 seconds.
+		BaseDelay time.Duration
+
+		// MaxDelay caps the delay between retries. Defaults to 1 hour.
+		MaxDelay time.Duration
+
+		// BatchSize is the maximum number of due entries processed on every run. Defaults to 50.
+		BatchSize int
+
+		// Retryable reports whether a failed attempt should be retried. Defaults to retrying only the requests that
+		// were never sent or were refused with a 429 or 503 response, since M-Pesa may have processed the others.
+		Retryable func(err error) bool
+
+		// OnSuccess, if set, is called when a retried payout is accepted by M-Pesa.
+		OnSuccess func(ctx context.Context, entry RetryEntry, res *B2CResponse)
+
+		// OnTerminalFailure, if set, is called when a payout has exhausted its attempts or failed with a non
+		// retryable error. Use it to flag the payout for manual intervention.
+		OnTerminalFailure func(ctx context.Context, entry RetryEntry)
+	}
+
+	// B2CRetryQueue retries B2C payouts that failed with retryable errors using exponential backoff. The entries are
+	// kept in a RetryStore so that they survive restarts when a durable store is used.
+	B2CRetryQueue struct {
+		app   *Mpesa
+		store RetryStore
+		cfg   RetryQueueConfig
+	}
+)
+
+// isRetryableB2CError is the default RetryQueueConfig.Retryable.
+func isRetryableB2CError(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+
+	return isResendableB2CError(err)
+}
+
+// NewB2CRetryQueue creates a B2CRetryQueue that retries the payouts using the provided app.
+func NewB2CRetryQueue(app *Mpesa, store RetryStore, cfg RetryQueueConfig) *B2CRetryQueue {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 30 * time.Second
+	}
+
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = time.Hour
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	if cfg.Retryable == nil {
+		cfg.Retryable = isRetryableB2CError
+	}
+
+	return &B2CRetryQueue{
+		app:   app,
+		store: store,
+		cfg:   cfg,
+	}
+}
+
+// delay returns the time to wait after the provided number of failed attempts.
+func (q *B2CRetryQueue) delay(attempts int) time.Duration {
+	delay := q.cfg.BaseDelay
+	for i := 1; i < attempts && delay < q.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > q.cfg.MaxDelay {
+		delay = q.cfg.MaxDelay
+	}
+
+	return delay
+}
+
+// Enqueue schedules a payout whose B2C request failed with cause for retrying. Payouts that failed with a non
+// retryable error are reported to OnTerminalFailure instead. The OriginatorConversationID of the request defaults to
+// the ID, set it on the initial request as well so that every attempt carries the same one.
+func (q *B2CRetryQueue) Enqueue(ctx context.Context, id string, req B2CRequest, cause error) error {
+	if id == "" {
+		return errors.New("mpesa: retry entry ID cannot be empty")
+	}
+
+	req.SecurityCredential = ""
+	if req.OriginatorConversationID == "" {
+		req.OriginatorConversationID = id
+	}
+
+	now := time.Now()
+	entry := RetryEntry{
+		ID:            id,
+		Request:       req,
+		Attempts:      1,
+		NextAttemptAt: now.Add(q.delay(1)),
+		CreatedAt:     now,
+	}
+
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+
+	if cause != nil && !q.cfg.Retryable(cause) {
+		q.fail(ctx, entry)
+		return nil
+	}
+
+	if err := q.store.Put(ctx, entry); err != nil {
+		return fmt.Errorf("mpesa: queue retry: %v", err)
+	}
+
+	return nil
+}
+
+func (q *B2CRetryQueue) fail(ctx context.Context, entry RetryEntry) {
+	if q.cfg.OnTerminalFailure != nil {
+		q.cfg.OnTerminalFailure(ctx, entry)
+	}
+}
+
+// ProcessDue retries the payouts that are due and returns the number of entries processed.
+func (q *B2CRetryQueue) ProcessDue(ctx context.Context) (int, error) {
+	entries, err := q.store.Due(ctx, time.Now(), q.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("mpesa: load due retries: %v", err)
+	}
+
+	for i, entry := range entries {
+		if err = ctx.Err(); err != nil {
+			return i, err
+		}
+
+		res, err := q.app.B2C(ctx, q.cfg.InitiatorPassword, entry.Request)
+		if err == nil {
+			if err = q.store.Delete(ctx, entry.ID); err != nil {
+				return i, fmt.Errorf("mpesa: delete retry: %v", err)
+			}
+
+			if q.cfg.OnSuccess != nil {
+				q.cfg.OnSuccess(ctx, entry, res)
+			}
+			continue
+		}
+
+		entry.Attempts++
+		entry.LastError = err.Error()
+
+		if entry.Attempts >= q.cfg.MaxAttempts || !q.cfg.Retryable(err) {
+			if err = q.store.Delete(ctx, entry.ID); err != nil {
+				return i, fmt.Errorf("mpesa: delete retry: %v", err)
+			}
+
+			q.fail(ctx, entry)
+			continue
+		}
+
+		entry.NextAttemptAt = time.Now().Add(q.delay(entry.Attempts))
+		if err = q.store.Put(ctx, entry); err != nil {
+			return i, fmt.Errorf("mpesa: reschedule retry: %v", err)
+		}
+	}
+
+	return len(entries), nil
+}
+
+// Run calls ProcessDue every interval until the context is done.
+func (q *B2CRetryQueue) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := q.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// MemoryRetryStore is an in-memory RetryStore. Entries are lost on restart, use FileRetryStore or a database backed
+// store when the queue needs to be durable.
+type MemoryRetryStore struct {
+	mu      sync.Mutex
+	entries map[string]RetryEntry
+}
+
+// NewMemoryRetryStore creates an empty MemoryRetryStore.
+func NewMemoryRetryStore() *MemoryRetryStore {
+	return &MemoryRetryStore{
+		entries: make(map[string]RetryEntry),
+	}
+}
+
+// Put creates or replaces the entry.
+func (s *MemoryRetryStore) Put(_ context.Context, entry RetryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[entry.ID] = entry
+	return nil
+}
+
+// Due returns up to limit entries that are due ordered by NextAttemptAt.
+func (s *MemoryRetryStore) Due(_ context.Context, now time.Time, limit int) ([]RetryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return dueEntries(s.entries, now, limit), nil
+}
+
+// Delete removes the entry.
+func (s *MemoryRetryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
+
+func dueEntries(entries map[string]RetryEntry, now time.Time, limit int) []RetryEntry {
+	var due []RetryEntry
+	for _, entry := range entries {
+		if !entry.NextAttemptAt.After(now) {
+			due = append(due, entry)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due
+}
+
+// FileRetryStore is a RetryStore that persists the entries to a JSON file. Every change rewrites the file atomically
+// so it is suitable for single instance deployments with a moderate number of pending retries.
+type FileRetryStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]RetryEntry
+}
+
+// NewFileRetryStore creates a FileRetryStore backed by the file at path, loading any entries already saved in it.
+func NewFileRetryStore(path string) (*FileRetryStore, error) {
+	s := &FileRetryStore{
+		path:    path,
+		entries: make(map[string]RetryEntry),
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("mpesa: read retry store: %v", err)
+	}
+
+	if len(b) == 0 {
+		return s, nil
+	}
+
+	if err = json.Unmarshal(b, &s.entries); err != nil {
+		return nil, fmt.Errorf("mpesa: decode retry store: %v", err)
+	}
+
+	return s, nil
+}
+
+// save writes the entries to the file. It must be called with s.mu held.
+func (s *FileRetryStore) save() error {
+	b, err := json.Marshal(s.entries)
+	if err != nil {
+		return fmt.Errorf("mpesa: encode retry store: %v", err)
+	}
+
+	if err = writeFileAtomic(s.path, b); err != nil {
+		return fmt.Errorf("mpesa: write retry store: %v", err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with b by writing to a temporary file in the same directory first.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Put creates or replaces the entry and persists the store.
+func (s *FileRetryStore) Put(_ context.Context, entry RetryEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[entry.ID] = entry
+	return s.save()
+}
+
+// Due returns up to limit entries that are due ordered by NextAttemptAt.
+func (s *FileRetryStore) Due(_ context.Context, now time.Time, limit int) ([]RetryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return dueEntries(s.entries, now, limit), nil
+}
+
+// Delete removes the entry and persists the store.
+func (s *FileRetryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[id]; !ok {
+		return nil
+	}
+
+	delete(s.entries, id)
+	return s.save()
+}
@@ -0,0 +1,239 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRetryB2CRequest() B2CRequest {
+	return B2CRequest{
+		InitiatorName:   "testapi",
+		CommandID:       BusinessPaymentCommandID,
+		Amount:          10,
+		PartyA:          600426,
+		PartyB:          254708374149,
+		Remarks:         "Here are my remarks",
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	}
+}
+
+func TestB2CRetryQueue(t *testing.T) {
+	var (
+		ctx         = context.Background()
+		rateLimited = &Error{ErrorCode: "429.001.01", ErrorMessage: "Too many requests", StatusCode: http.StatusTooManyRequests}
+	)
+
+	newQueue := func(cfg RetryQueueConfig) (*B2CRetryQueue, *MemoryRetryStore, *Mpesa, *mockHttpClient) {
+		var (
+			cl    = newMockHttpClient()
+			app   = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			store = NewMemoryRetryStore()
+		)
+
+		mockAuth(app, cl)
+
+		cfg.InitiatorPassword = "Safaricom999!*!"
+		cfg.BaseDelay = time.Nanosecond
+		cfg.MaxDelay = time.Nanosecond
+
+		return NewB2CRetryQueue(app, store, cfg), store, app, cl
+	}
+
+	t.Run("it retries the payout until it is accepted", func(t *testing.T) {
+		var (
+			successes int32
+			failures  int32
+		)
+
+		q, store, app, cl := newQueue(RetryQueueConfig{
+			OnSuccess: func(_ context.Context, entry RetryEntry, res *B2CResponse) {
+				atomic.AddInt32(&successes, 1)
+				require.Equal(t, "payout-1", entry.ID)
+				require.Equal(t, "AG_20191219_00005797af5d7d75f652", res.ConversationID)
+			},
+			OnTerminalFailure: func(context.Context, RetryEntry) {
+				atomic.AddInt32(&failures, 1)
+			},
+		})
+
+		var calls int32
+		cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+			var req B2CRequest
+			require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+			require.Equal(t, "payout-1", req.OriginatorConversationID)
+
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return http.StatusServiceUnavailable, `{"errorCode": "503.001.01", "errorMessage": "Service unavailable"}`
+			}
+
+			return http.StatusOK, `
+				{
+					"ConversationID": "AG_20191219_00005797af5d7d75f652",
+					"OriginatorConversationID": "16740-34861180-1",
+					"ResponseCode": "0",
+					"ResponseDescription": "Accept the service request successfully."
+				}`
+		})
+
+		require.NoError(t, q.Enqueue(ctx, "payout-1", testRetryB2CRequest(), rateLimited))
+
+		time.Sleep(time.Millisecond)
+		n, err := q.ProcessDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		entries, err := store.Due(ctx, time.Now().Add(time.Hour), 0)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Equal(t, 2, entries[0].Attempts)
+		require.Empty(t, entries[0].Request.SecurityCredential)
+		require.Equal(t, "payout-1", entries[0].Request.OriginatorConversationID)
+		require.Contains(t, entries[0].LastError, "Service unavailable")
+
+		time.Sleep(time.Millisecond)
+		n, err = q.ProcessDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		entries, err = store.Due(ctx, time.Now().Add(time.Hour), 0)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+		require.EqualValues(t, 1, atomic.LoadInt32(&successes))
+		require.EqualValues(t, 0, atomic.LoadInt32(&failures))
+	})
+
+	t.Run("it reports the payout once the attempts are exhausted", func(t *testing.T) {
+		var terminal []RetryEntry
+
+		q, store, app, cl := newQueue(RetryQueueConfig{
+			MaxAttempts: 3,
+			OnTerminalFailure: func(_ context.Context, entry RetryEntry) {
+				terminal = append(terminal, entry)
+			},
+		})
+
+		cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+			return http.StatusServiceUnavailable, `{"errorCode": "503.001.01", "errorMessage": "Service unavailable"}`
+		})
+
+		require.NoError(t, q.Enqueue(ctx, "payout-1", testRetryB2CRequest(), rateLimited))
+
+		for i := 0; i < 2; i++ {
+			time.Sleep(time.Millisecond)
+			_, err := q.ProcessDue(ctx)
+			require.NoError(t, err)
+		}
+
+		require.Len(t, terminal, 1)
+		require.Equal(t, 3, terminal[0].Attempts)
+
+		entries, err := store.Due(ctx, time.Now().Add(time.Hour), 0)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("it does not queue payouts that failed with non retryable errors", func(t *testing.T) {
+		var terminal []RetryEntry
+
+		q, store, _, _ := newQueue(RetryQueueConfig{
+			OnTerminalFailure: func(_ context.Context, entry RetryEntry) {
+				terminal = append(terminal, entry)
+			},
+		})
+
+		require.NoError(t, q.Enqueue(ctx, "payout-1", testRetryB2CRequest(), ErrInvalidInitiatorPassword))
+		require.Len(t, terminal, 1)
+
+		entries, err := store.Due(ctx, time.Now().Add(time.Hour), 0)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("it does not retry payouts whose outcome is unknown", func(t *testing.T) {
+		var terminal []RetryEntry
+
+		q, store, app, cl := newQueue(RetryQueueConfig{
+			OnTerminalFailure: func(_ context.Context, entry RetryEntry) {
+				terminal = append(terminal, entry)
+			},
+		})
+
+		cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+			return http.StatusInternalServerError, `{"errorCode": "500.002.1001", "errorMessage": "Server error"}`
+		})
+
+		require.NoError(t, q.Enqueue(ctx, "payout-1", testRetryB2CRequest(), errors.New("timeout")))
+		require.Len(t, terminal, 1)
+
+		require.NoError(t, q.Enqueue(ctx, "payout-2", testRetryB2CRequest(), rateLimited))
+
+		time.Sleep(time.Millisecond)
+		_, err := q.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		require.Len(t, terminal, 2)
+		require.Equal(t, "payout-2", terminal[1].ID)
+		require.Equal(t, 2, terminal[1].Attempts)
+
+		entries, err := store.Due(ctx, time.Now().Add(time.Hour), 0)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
+
+func TestB2CRetryQueue_delay(t *testing.T) {
+	q := NewB2CRetryQueue(nil, NewMemoryRetryStore(), RetryQueueConfig{
+		BaseDelay: time.Second,
+		MaxDelay:  10 * time.Second,
+	})
+
+	require.Equal(t, time.Second, q.delay(1))
+	require.Equal(t, 2*time.Second, q.delay(2))
+	require.Equal(t, 8*time.Second, q.delay(4))
+	require.Equal(t, 10*time.Second, q.delay(5))
+	require.Equal(t, 10*time.Second, q.delay(50))
+}
+
+func TestFileRetryStore(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "retries.json")
+		now  = time.Now()
+	)
+
+	store, err := NewFileRetryStore(path)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, RetryEntry{ID: "1", Attempts: 1, NextAttemptAt: now.Add(-time.Minute)}))
+	require.NoError(t, store.Put(ctx, RetryEntry{ID: "2", Attempts: 1, NextAttemptAt: now.Add(-2 * time.Minute)}))
+	require.NoError(t, store.Put(ctx, RetryEntry{ID: "3", Attempts: 1, NextAttemptAt: now.Add(time.Hour)}))
+	require.NoError(t, store.Delete(ctx, "missing"))
+
+	reopened, err := NewFileRetryStore(path)
+	require.NoError(t, err)
+
+	due, err := reopened.Due(ctx, now, 0)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	require.Equal(t, "2", due[0].ID)
+	require.Equal(t, "1", due[1].ID)
+
+	require.NoError(t, reopened.Delete(ctx, "2"))
+
+	reopened, err = NewFileRetryStore(path)
+	require.NoError(t, err)
+
+	due, err = reopened.Due(ctx, now, 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	require.Equal(t, "1", due[0].ID)
+}
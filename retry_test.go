@@ -0,0 +1,170 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// httpClientFunc is an HttpClient backed by a function.
+type httpClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f httpClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+
+	queryReq := STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"}
+
+	tests := []struct {
+		name string
+		mock func(t *testing.T, app *Mpesa, c *mockHttpClient)
+	}{
+		{
+			name: "it retries idempotent requests that fail with a transient status",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				calls := 0
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					calls++
+					if calls < 3 {
+						return http.StatusServiceUnavailable, `{"errorCode": "503.001.01"}`
+					}
+					return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0"}`
+				})
+
+				res, err := app.STKQuery(ctx, "passkey", queryReq)
+				require.NoError(t, err)
+				require.Equal(t, "0", res.ResultCode)
+				require.Equal(t, 3, calls)
+			},
+		},
+		{
+			name: "it stops retrying after the max attempts",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				calls := 0
+				c.MockRequest(app.endpointTransactionStatus(), func() (status int, body string) {
+					calls++
+					return http.StatusTooManyRequests, `{"errorCode": "429.001.01"}`
+				})
+
+				_, err := app.GetTransactionStatus(ctx, "initiator-password", TransactionStatusRequest{
+					PartyA:          600426,
+					TransactionID:   "SB162HIYLY",
+					QueueTimeOutURL: "https://example.com/timeout",
+					ResultURL:       "https://example.com/result",
+				})
+				require.Error(t, err)
+				require.Equal(t, 3, calls)
+			},
+		},
+		{
+			name: "it does not retry client errors",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				calls := 0
+				c.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+					calls++
+					return http.StatusBadRequest, `{"errorCode": "400.002.02"}`
+				})
+
+				_, err := app.STKQuery(ctx, "passkey", queryReq)
+				require.Error(t, err)
+				require.Equal(t, 1, calls)
+			},
+		},
+		{
+			name: "it does not retry requests that move money",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				calls := 0
+				c.MockRequest(app.endpointSTK(), func() (status int, body string) {
+					calls++
+					return http.StatusInternalServerError, `{"errorCode": "500.001.1001"}`
+				})
+
+				_, err := app.STKPush(ctx, "passkey", STKPushRequest{
+					BusinessShortCode: 174379,
+					TransactionType:   CustomerPayBillOnlineTransactionType,
+					Amount:            10,
+					PartyA:            254708374149,
+					PartyB:            174379,
+					PhoneNumber:       254708374149,
+					CallBackURL:       "https://example.com/callback",
+					AccountReference:  "Test",
+					TransactionDesc:   "Test",
+				})
+				require.Error(t, err)
+				require.Equal(t, 1, calls)
+			},
+		},
+		{
+			name: "it retries the token generation",
+			mock: func(t *testing.T, app *Mpesa, c *mockHttpClient) {
+				calls := 0
+				c.MockRequest(app.endpointAuth(), func() (status int, body string) {
+					calls++
+					if calls == 1 {
+						return http.StatusBadGateway, ``
+					}
+					return http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`
+				})
+
+				token, err := app.GenerateAccessToken(ctx)
+				require.NoError(t, err)
+				require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+				require.Equal(t, 2, calls)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cl := newMockHttpClient()
+			app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithRetry(3, time.Millisecond))
+			mockAuth(app, cl)
+			tc.mock(t, app, cl)
+		})
+	}
+}
+
+func TestWithRetry_NetworkErrors(t *testing.T) {
+	t.Run("it retries network errors", func(t *testing.T) {
+		calls := 0
+		cl := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls < 3 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return mockHttpResponse(http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU"}`), nil
+		})
+
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithRetry(3, time.Millisecond))
+
+		token, err := app.GenerateAccessToken(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+		require.Equal(t, 3, calls)
+	})
+
+	t.Run("it stops retrying once the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+		cl := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			cancel()
+			return nil, errors.New("connection reset by peer")
+		})
+
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithRetry(5, time.Hour))
+
+		_, err := app.GenerateAccessToken(ctx)
+		require.Error(t, err)
+		require.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,70 @@
+package mpesa
+
+// Sandbox holds the test credentials documented on the Daraja portal for the sandbox environment.
+type Sandbox struct {
+	// STKShortCode is the Lipa Na M-Pesa Online shortcode used for the STKPush and STKQuery requests.
+	STKShortCode uint
+
+	// STKPasskey is the passkey of STKShortCode.
+	STKPasskey string
+
+	// ShortCode is the organization shortcode used for the B2C, B2B, C2B, transaction status, reversal and account
+	// balance requests.
+	ShortCode uint
+
+	// Initiator is the API operator of ShortCode.
+	Initiator InitiatorCredentials
+
+	// MSISDNs are the test phone numbers that receive the STK Pin Prompt and the B2C payments.
+	MSISDNs []uint64
+}
+
+// SandboxDefaults returns the test credentials of the sandbox environment. The credentials generated for an app on the
+// Daraja portal, under "Test Credentials", take precedence over these if they differ.
+func SandboxDefaults() Sandbox {
+	return Sandbox{
+		STKShortCode: 174379,
+		STKPasskey:   "bfb279f9aa9bdbcf158e97dd71a467cd2e0c893059b10f78e6b72ada1ed2c919",
+		ShortCode:    600000,
+		Initiator:    InitiatorCredentials{Name: "testapi", Password: "Safaricom999!*!"},
+		MSISDNs:      []uint64{254708374149},
+	}
+}
+
+// STKPushRequest returns a CustomerPayBillOnline request of amount from the first test MSISDN to STKShortCode. It is
+// sent using STKPasskey, e.g. app.STKPush(ctx, s.STKPasskey, s.STKPushRequest(1, callbackURL)).
+func (s Sandbox) STKPushRequest(amount uint, callbackURL string) STKPushRequest {
+	return STKPushRequest{
+		BusinessShortCode: s.STKShortCode,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            amount,
+		PartyA:            uint(s.msisdn()),
+		PartyB:            s.STKShortCode,
+		PhoneNumber:       s.msisdn(),
+		CallBackURL:       callbackURL,
+		AccountReference:  "Sandbox",
+		TransactionDesc:   "Sandbox",
+	}
+}
+
+// B2CRequest returns a BusinessPayment request of amount from ShortCode to the first test MSISDN. It is sent using the
+// initiator password, e.g. app.B2C(ctx, s.Initiator.Password, s.B2CRequest(10, resultURL, timeoutURL)).
+func (s Sandbox) B2CRequest(amount uint, resultURL, queueTimeOutURL string) B2CRequest {
+	return B2CRequest{
+		InitiatorName:   s.Initiator.Name,
+		CommandID:       BusinessPaymentCommandID,
+		Amount:          amount,
+		PartyA:          s.ShortCode,
+		PartyB:          s.msisdn(),
+		Remarks:         "Sandbox payment",
+		QueueTimeOutURL: queueTimeOutURL,
+		ResultURL:       resultURL,
+	}
+}
+
+func (s Sandbox) msisdn() uint64 {
+	if len(s.MSISDNs) == 0 {
+		return 0
+	}
+	return s.MSISDNs[0]
+}
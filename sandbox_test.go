@@ -0,0 +1,24 @@
+package mpesa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxDefaults(t *testing.T) {
+	sandbox := SandboxDefaults()
+
+	stk := sandbox.STKPushRequest(1, "https://example.com/callback")
+	require.Empty(t, stk.Validate())
+	require.Equal(t, uint(174379), stk.BusinessShortCode)
+	require.Equal(t, uint64(254708374149), stk.PhoneNumber)
+
+	b2c := sandbox.B2CRequest(10, "https://example.com/result", "https://example.com/timeout")
+	require.Empty(t, b2c.Validate())
+	require.Equal(t, "testapi", b2c.InitiatorName)
+	require.Equal(t, uint64(254708374149), b2c.PartyB)
+
+	sandbox.MSISDNs = nil
+	require.Zero(t, sandbox.STKPushRequest(1, "https://example.com/callback").PhoneNumber)
+}
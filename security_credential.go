@@ -0,0 +1,221 @@
+package mpesa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"embed"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+//go:embed certs
+var certFS embed.FS
+
+// ErrInvalidCertificate indicates that a certificate is not a PEM encoded X.509 certificate with an RSA public key.
+var ErrInvalidCertificate = errors.New("mpesa: invalid certificate")
+
+// WithCertificate makes the app encrypt the initiator passwords with the PEM encoded certificate instead of the
+// sandbox or production certificate bundled with the SDK. Use it when Safaricom rotates its certificates.
+func WithCertificate(pem []byte) Option {
+	return func(m *Mpesa) {
+		m.certificate = pem
+	}
+}
+
+// WithCertificateFile is like WithCertificate but reads the certificate from the file at path. The file is read
+// again when its modification time or size changes, or when ReloadCertificate is called, so that it can be replaced
+// without restarting the app.
+func WithCertificateFile(path string) Option {
+	return func(m *Mpesa) {
+		m.certificateFile = path
+	}
+}
+
+// WithSecurityCredentialCache makes the app reuse the security credential generated for an initiator password instead
+// of encrypting the password on every request, until the certificate changes. The passwords are kept hashed.
+func WithSecurityCredentialCache() Option {
+	return func(m *Mpesa) {
+		m.credentials.cacheCredentials = true
+	}
+}
+
+// securityCredentialCache keeps the public key parsed from the certificate and, if enabled, the security credentials
+// generated with it.
+type securityCredentialCache struct {
+	mu               sync.Mutex
+	certificate      []byte
+	file             certificateFileInfo
+	publicKey        *rsa.PublicKey
+	cacheCredentials bool
+	credentials      map[[sha256.Size]byte]string
+}
+
+// certificateFileInfo identifies the version of the certificate file set using WithCertificateFile that was read.
+type certificateFileInfo struct {
+	modTime time.Time
+	size    int64
+}
+
+func (i certificateFileInfo) equal(other certificateFileInfo) bool {
+	return i.modTime.Equal(other.modTime) && i.size == other.size
+}
+
+// GenerateSecurityCredential encrypts the initiator password with the M-Pesa public key certificate of the app
+// Environment, or the one set using WithCertificate or WithCertificateFile, and returns it base64 encoded as expected on
+// the SecurityCredential field of the requests.
+func (m *Mpesa) GenerateSecurityCredential(initiatorPwd string) (string, error) {
+	certificate, file, err := m.readCertificate(false)
+	if err != nil {
+		return "", err
+	}
+
+	c := m.credentials
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = c.loadPublicKey(certificate, file); err != nil {
+		return "", err
+	}
+
+	key := sha256.Sum256([]byte(initiatorPwd))
+	if credential, ok := c.credentials[key]; ok {
+		return credential, nil
+	}
+
+	signature, err := rsa.EncryptPKCS1v15(rand.Reader, c.publicKey, []byte(initiatorPwd))
+	if err != nil {
+		return "", fmt.Errorf("mpesa: encrypt password: %v", err)
+	}
+
+	credential := base64.StdEncoding.EncodeToString(signature)
+	if c.cacheCredentials {
+		if c.credentials == nil {
+			c.credentials = make(map[[sha256.Size]byte]string)
+		}
+		c.credentials[key] = credential
+	}
+
+	return credential, nil
+}
+
+// VerifyCertificates checks that the certificate used to generate the security credentials, see
+// GenerateSecurityCredential, can be read and holds an RSA public key. Call it at startup to fail fast on a bad
+// certificate instead of on the first B2C, reversal or other request that needs a security credential. It returns an
+// error wrapping ErrInvalidCertificate if the certificate cannot be parsed.
+func (m *Mpesa) VerifyCertificates() error {
+	return m.verifyCertificates(false)
+}
+
+// ReloadCertificate reads the certificate file set using WithCertificateFile again, even if its modification time
+// and size did not change, and verifies it like VerifyCertificates.
+func (m *Mpesa) ReloadCertificate() error {
+	return m.verifyCertificates(true)
+}
+
+func (m *Mpesa) verifyCertificates(reload bool) error {
+	certificate, file, err := m.readCertificate(reload)
+	if err != nil {
+		return err
+	}
+
+	c := m.credentials
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.loadPublicKey(certificate, file)
+}
+
+// loadPublicKey parses the certificate into the cache unless it is already cached. It must be called with the cache
+// locked.
+func (c *securityCredentialCache) loadPublicKey(certificate []byte, file certificateFileInfo) error {
+	if c.publicKey != nil && bytes.Equal(c.certificate, certificate) {
+		c.file = file
+		return nil
+	}
+
+	publicKey, err := parseCertificate(certificate)
+	if err != nil {
+		return err
+	}
+
+	c.certificate, c.file, c.publicKey, c.credentials = certificate, file, publicKey, nil
+	return nil
+}
+
+// parseCertificate returns the RSA public key of the PEM encoded certificate.
+func parseCertificate(certificate []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(certificate)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM data found", ErrInvalidCertificate)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCertificate, err)
+	}
+
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported public key %T", ErrInvalidCertificate, cert.PublicKey)
+	}
+
+	return publicKey, nil
+}
+
+// readCertificate returns the PEM encoded certificate used to generate the security credentials and, for a
+// certificate file, the version of the file it was read from. It must be called without the cache locked.
+func (m *Mpesa) readCertificate(reload bool) ([]byte, certificateFileInfo, error) {
+	switch {
+	case m.certificate != nil:
+		return m.certificate, certificateFileInfo{}, nil
+	case m.certificateFile != "":
+		return m.readCertificateFile(reload)
+	}
+
+	certPath := "certs/sandbox.cer"
+	if m.Environment().IsProduction() {
+		certPath = "certs/production.cer"
+	}
+
+	certificate, err := certFS.ReadFile(certPath)
+	if err != nil {
+		return nil, certificateFileInfo{}, fmt.Errorf("mpesa: read cert: %v", err)
+	}
+
+	return certificate, certificateFileInfo{}, nil
+}
+
+// readCertificateFile returns the cached contents of the certificate file unless the file changed since it was read
+// or reload is set.
+func (m *Mpesa) readCertificateFile(reload bool) ([]byte, certificateFileInfo, error) {
+	stat, err := os.Stat(m.certificateFile)
+	if err != nil {
+		return nil, certificateFileInfo{}, fmt.Errorf("mpesa: read cert: %v", err)
+	}
+
+	file := certificateFileInfo{modTime: stat.ModTime(), size: stat.Size()}
+
+	c := m.credentials
+	c.mu.Lock()
+	certificate, cached := c.certificate, c.file
+	c.mu.Unlock()
+
+	if !reload && certificate != nil && cached.equal(file) {
+		return certificate, file, nil
+	}
+
+	certificate, err = os.ReadFile(m.certificateFile)
+	if err != nil {
+		return nil, certificateFileInfo{}, fmt.Errorf("mpesa: read cert: %v", err)
+	}
+
+	return certificate, file, nil
+}
@@ -0,0 +1,205 @@
+package mpesa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate returns a self-signed PEM encoded certificate and its private key.
+func testCertificate(t *testing.T) ([]byte, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "apicrypt.safaricom.co.ke"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), key
+}
+
+func TestMpesa_GenerateSecurityCredential(t *testing.T) {
+	certificate, key := testCertificate(t)
+
+	decrypt := func(t *testing.T, credential string) string {
+		b, err := base64.StdEncoding.DecodeString(credential)
+		require.NoError(t, err)
+
+		password, err := rsa.DecryptPKCS1v15(rand.Reader, key, b)
+		require.NoError(t, err)
+		return string(password)
+	}
+
+	t.Run("it uses the bundled certificates by default", func(t *testing.T) {
+		for _, env := range []Environment{EnvironmentSandbox, EnvironmentProduction} {
+			credential, err := NewApp(nil, testConsumerKey, testConsumerSecret, env).
+				GenerateSecurityCredential("Safaricom999!*!")
+			require.NoError(t, err)
+			require.NotEmpty(t, credential)
+		}
+	})
+
+	t.Run("it uses the certificate set on the app", func(t *testing.T) {
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentProduction, WithCertificate(certificate))
+
+		credential, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		require.Equal(t, "Safaricom999!*!", decrypt(t, credential))
+	})
+
+	t.Run("it reads the certificate file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "production.cer")
+		require.NoError(t, os.WriteFile(path, certificate, 0600))
+
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentProduction, WithCertificateFile(path))
+
+		credential, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		require.Equal(t, "Safaricom999!*!", decrypt(t, credential))
+
+		require.NoError(t, os.Remove(path))
+		_, err = app.GenerateSecurityCredential("Safaricom999!*!")
+		require.Error(t, err)
+	})
+
+	t.Run("it caches the parsed certificate", func(t *testing.T) {
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCertificate(certificate))
+
+		first, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		publicKey := app.credentials.publicKey
+		require.NotNil(t, publicKey)
+
+		second, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		require.Same(t, publicKey, app.credentials.publicKey)
+		require.NotEqual(t, first, second)
+	})
+
+	t.Run("it caches the credentials until the certificate changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "production.cer")
+		require.NoError(t, os.WriteFile(path, certificate, 0600))
+
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentProduction,
+			WithCertificateFile(path), WithSecurityCredentialCache())
+
+		first, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+
+		second, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		require.Equal(t, first, second)
+
+		other, err := app.GenerateSecurityCredential("Safaricom111!*!")
+		require.NoError(t, err)
+		require.NotEqual(t, first, other)
+		require.Equal(t, "Safaricom111!*!", decrypt(t, other))
+
+		rotated, _ := testCertificate(t)
+		require.NoError(t, os.WriteFile(path, rotated, 0600))
+		require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+		third, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		require.NotEqual(t, first, third)
+	})
+
+	t.Run("it reads the certificate file again only once it changes or is reloaded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "production.cer")
+		require.NoError(t, os.WriteFile(path, certificate, 0600))
+
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentProduction, WithCertificateFile(path))
+
+		_, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+
+		stat, err := os.Stat(path)
+		require.NoError(t, err)
+
+		require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), len(certificate)), 0600))
+		require.NoError(t, os.Chtimes(path, stat.ModTime(), stat.ModTime()))
+
+		credential, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.NoError(t, err)
+		require.Equal(t, "Safaricom999!*!", decrypt(t, credential))
+
+		require.ErrorIs(t, app.ReloadCertificate(), ErrInvalidCertificate)
+	})
+
+	t.Run("it fails if the certificate is invalid", func(t *testing.T) {
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCertificate([]byte("invalid")))
+
+		_, err := app.GenerateSecurityCredential("Safaricom999!*!")
+		require.ErrorIs(t, err, ErrInvalidCertificate)
+	})
+}
+
+func TestMpesa_VerifyCertificates(t *testing.T) {
+	t.Run("it accepts the bundled certificates", func(t *testing.T) {
+		for _, env := range []Environment{EnvironmentSandbox, EnvironmentProduction} {
+			app := NewApp(nil, testConsumerKey, testConsumerSecret, env)
+			require.NoError(t, app.VerifyCertificates())
+		}
+	})
+
+	t.Run("it caches the parsed certificate", func(t *testing.T) {
+		certificate, _ := testCertificate(t)
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCertificate(certificate))
+
+		require.NoError(t, app.VerifyCertificates())
+		require.NotNil(t, app.credentials.publicKey)
+	})
+
+	t.Run("it rejects a certificate without PEM data", func(t *testing.T) {
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCertificate([]byte("invalid")))
+		require.ErrorIs(t, app.VerifyCertificates(), ErrInvalidCertificate)
+	})
+
+	t.Run("it rejects a certificate without an RSA public key", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		require.NoError(t, err)
+
+		certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCertificate(certificate))
+
+		err = app.VerifyCertificates()
+		require.ErrorIs(t, err, ErrInvalidCertificate)
+		require.ErrorContains(t, err, "unsupported public key")
+	})
+
+	t.Run("it fails if the certificate file is missing", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing.cer")
+		app := NewApp(nil, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithCertificateFile(path))
+		require.ErrorContains(t, app.VerifyCertificates(), "mpesa: read cert")
+	})
+}
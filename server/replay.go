@@ -0,0 +1,63 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+)
+
+// maxReplayResponseSize is the maximum size of the acknowledgement read by Replay.
+const maxReplayResponseSize = 64 << 10
+
+// Replay sends the callback to the callback URL as M-Pesa would, e.g. to re-deliver a recorded callback that failed or
+// to check a deployed server, and verifies that it was acknowledged with a ResultCode of 0. A nil client uses
+// http.DefaultClient.
+func Replay(ctx context.Context, client mpesa.HttpClient, callbackURL string, callback any) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	body, err := json.Marshal(callback)
+	if err != nil {
+		return fmt.Errorf("mpesa: encode callback: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("mpesa: create callback request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("mpesa: send callback: %v", err)
+	}
+
+	//goland:noinspection GoUnhandledErrorResult
+	defer res.Body.Close()
+
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, maxReplayResponseSize))
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("mpesa: callback failed with status %d: %s", res.StatusCode, resBody)
+	}
+
+	// The C2B validation responses have a string ResultCode while the other acknowledgements have a number.
+	var ack struct {
+		ResultCode any    `json:"ResultCode"`
+		ResultDesc string `json:"ResultDesc"`
+	}
+	if err = json.Unmarshal(resBody, &ack); err != nil {
+		return fmt.Errorf("mpesa: decode acknowledgement: %v", err)
+	}
+
+	if code := fmt.Sprint(ack.ResultCode); code != "0" {
+		return fmt.Errorf("mpesa: callback rejected with code %s: %s", code, ack.ResultDesc)
+	}
+
+	return nil
+}
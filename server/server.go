@@ -0,0 +1,193 @@
+// Package server is a ready-made server for the M-Pesa callbacks. It routes the callbacks to typed handlers, logs them
+// and shuts down gracefully, so that an application only provides the functions handling the callbacks.
+//
+//	srv := server.New(":8080", server.Handlers{
+//		STKPush: func(ctx context.Context, callback *mpesa.STKPushCallback) error {
+//			return orders.MarkPaid(ctx, callback.Body.STKCallback)
+//		},
+//	}, server.WithLogger(slog.Default()))
+//
+//	err := srv.ListenAndServe(ctx)
+//
+// The callback URLs of the requests are the public URL of the server followed by PathSTK, PathB2CResult and so on.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+)
+
+// Paths the callbacks are served on.
+const (
+	PathSTK             = "/stk"
+	PathB2CResult       = "/b2c/result"
+	PathB2CTimeout      = "/b2c/timeout"
+	PathC2BValidation   = "/c2b/validate"
+	PathC2BConfirmation = "/c2b/confirm"
+)
+
+// defaultShutdownTimeout is how long ListenAndServe waits for the callbacks being handled when it stops.
+const defaultShutdownTimeout = 10 * time.Second
+
+// Handlers are the functions handling the callbacks. The paths of the nil handlers are not served. A handler that
+// returns an error responds with a 500 status so that M-Pesa retries the callback, except C2BValidation whose error
+// rejects the payment, see mpesa.C2BValidationHandler.
+type Handlers struct {
+	STKPush         func(ctx context.Context, callback *mpesa.STKPushCallback) error
+	B2CResult       func(ctx context.Context, callback *mpesa.Callback) error
+	B2CTimeout      func(ctx context.Context, callback *mpesa.QueueTimeoutCallback) error
+	C2BValidation   func(ctx context.Context, callback *mpesa.C2BCallback) error
+	C2BConfirmation func(ctx context.Context, callback *mpesa.C2BCallback) error
+}
+
+// Server serves the callbacks.
+type Server struct {
+	addr            string
+	handlers        Handlers
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+}
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// WithLogger logs every callback with its path, status and duration, and the errors returned by the handlers.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// WithShutdownTimeout sets how long ListenAndServe waits for the callbacks being handled when its context is done.
+// Defaults to 10 seconds.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// New creates a Server listening on addr, e.g. ":8080".
+func New(addr string, handlers Handlers, opts ...Option) *Server {
+	s := &Server{addr: addr, handlers: handlers, shutdownTimeout: defaultShutdownTimeout}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Handler returns the handler serving the callbacks, to mount it on the mux of an existing server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	if h := s.handlers.STKPush; h != nil {
+		mux.Handle(PathSTK, mpesa.STKPushCallbackHandler(logErrors(s, PathSTK, h)))
+	}
+	if h := s.handlers.B2CResult; h != nil {
+		mux.Handle(PathB2CResult, mpesa.ResultCallbackHandler(logErrors(s, PathB2CResult, h)))
+	}
+	if h := s.handlers.B2CTimeout; h != nil {
+		mux.Handle(PathB2CTimeout, mpesa.QueueTimeoutCallbackHandler(logErrors(s, PathB2CTimeout, h)))
+	}
+	if h := s.handlers.C2BValidation; h != nil {
+		mux.Handle(PathC2BValidation, mpesa.C2BValidationHandler(logErrors(s, PathC2BValidation, h)))
+	}
+	if h := s.handlers.C2BConfirmation; h != nil {
+		mux.Handle(PathC2BConfirmation, mpesa.C2BConfirmationHandler(logErrors(s, PathC2BConfirmation, h)))
+	}
+
+	return s.logRequests(mux)
+}
+
+// ListenAndServe serves the callbacks until the context is done, then waits for the callbacks being handled before
+// returning.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// handlerFunc handles a callback of type T.
+type handlerFunc[T any] func(ctx context.Context, callback *T) error
+
+// logErrors logs the errors returned by the handler of the path.
+func logErrors[T any](s *Server, path string, fn handlerFunc[T]) handlerFunc[T] {
+	return func(ctx context.Context, callback *T) error {
+		err := fn(ctx, callback)
+		if err != nil && s.logger != nil {
+			s.logger.LogAttrs(ctx, slog.LevelError, "mpesa: callback failed",
+				slog.String("path", path), slog.String("error", err.Error()))
+		}
+
+		return err
+	}
+}
+
+// logRequests logs every request with its path, status and duration.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	if s.logger == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sw, r)
+
+		level := slog.LevelInfo
+		if sw.status >= http.StatusBadRequest {
+			level = slog.LevelError
+		}
+
+		s.logger.LogAttrs(r.Context(), level, "mpesa: callback",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", sw.status),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// statusWriter records the status written to the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jwambugu/mpesa-golang-sdk"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	var (
+		logs bytes.Buffer
+		stk  *mpesa.STKPushCallback
+	)
+
+	srv := New(":0", Handlers{
+		STKPush: func(_ context.Context, callback *mpesa.STKPushCallback) error {
+			stk = callback
+			return nil
+		},
+		B2CResult: func(context.Context, *mpesa.Callback) error {
+			return errors.New("database unavailable")
+		},
+		C2BValidation: func(_ context.Context, callback *mpesa.C2BCallback) error {
+			if callback.BillRefNumber == "" {
+				return &mpesa.C2BRejection{Code: mpesa.C2BRejectInvalidAccountNumber}
+			}
+			return nil
+		},
+	}, WithLogger(slog.New(slog.NewJSONHandler(&logs, nil))))
+
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	t.Run("it handles the callbacks", func(t *testing.T) {
+		callback := mpesa.STKPushCallback{Body: mpesa.STKPushCallbackBody{
+			STKCallback: mpesa.STKCallback{CheckoutRequestID: "ws_CO_1", ResultDesc: "Accepted"},
+		}}
+
+		require.NoError(t, Replay(ctx, ts.Client(), ts.URL+PathSTK, callback))
+		require.Equal(t, "ws_CO_1", stk.Body.STKCallback.CheckoutRequestID)
+		require.Contains(t, logs.String(), `"path":"/stk","status":200`)
+	})
+
+	t.Run("it reports the failed callbacks", func(t *testing.T) {
+		err := Replay(ctx, ts.Client(), ts.URL+PathB2CResult, mpesa.Callback{})
+		require.ErrorContains(t, err, "status 500")
+		require.Contains(t, logs.String(), `"error":"database unavailable"`)
+	})
+
+	t.Run("it reports the rejected payments", func(t *testing.T) {
+		err := Replay(ctx, ts.Client(), ts.URL+PathC2BValidation, mpesa.C2BCallback{})
+		require.ErrorContains(t, err, "rejected with code "+mpesa.C2BRejectInvalidAccountNumber)
+
+		require.NoError(t, Replay(ctx, ts.Client(), ts.URL+PathC2BValidation, mpesa.C2BCallback{BillRefNumber: "INV-1"}))
+	})
+
+	t.Run("it does not serve the paths without a handler", func(t *testing.T) {
+		err := Replay(ctx, ts.Client(), ts.URL+PathC2BConfirmation, mpesa.C2BCallback{})
+		require.ErrorContains(t, err, "status 404")
+	})
+}
+
+func TestServer_ListenAndServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		handling    = make(chan struct{})
+		handled     = make(chan struct{})
+	)
+	defer cancel()
+
+	srv := New(addr, Handlers{
+		STKPush: func(context.Context, *mpesa.STKPushCallback) error {
+			close(handling)
+			time.Sleep(50 * time.Millisecond)
+			close(handled)
+			return nil
+		},
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	replayErr := make(chan error, 1)
+	go func() {
+		replayErr <- Replay(context.Background(), nil, "http://"+addr+PathSTK, mpesa.STKPushCallback{})
+	}()
+
+	// The callback being handled completes before the server stops.
+	<-handling
+	cancel()
+
+	require.NoError(t, <-errCh)
+	require.NoError(t, <-replayErr)
+
+	select {
+	case <-handled:
+	default:
+		t.Fatal("the server stopped before the callback was handled")
+	}
+}
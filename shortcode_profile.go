@@ -0,0 +1,102 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownShortcode is returned by the requests made with the app returned by WithShortcode for a name that was not
+// registered.
+var ErrUnknownShortcode = errors.New("mpesa: unknown shortcode profile")
+
+// ShortcodeProfile holds the credentials of one of the shortcodes of a business, e.g. its C2B paybill and its B2C
+// shortcode, which are usually on different Daraja apps.
+type ShortcodeProfile struct {
+	// ShortCode is set on the requests that do not have a shortcode.
+	ShortCode uint
+
+	// ConsumerKey and ConsumerSecret are the credentials of the shortcode's app on the Daraja portal. The app ones
+	// are used if they are empty.
+	ConsumerKey    string
+	ConsumerSecret string
+
+	// Passkey is used for the STKPush and STKQuery requests made with an empty passkey.
+	Passkey string
+
+	// Initiator is used for the requests made with an empty initiator password.
+	Initiator InitiatorCredentials
+
+	// Callbacks are set on the requests that do not have them. The app ones are used if they are empty.
+	Callbacks CallbackURLs
+}
+
+// RegisterShortcode registers the profile under name, replacing any profile registered with the same name. The app
+// returned by WithShortcode shares the options of m but authenticates with the profile's consumer key, so that its
+// access tokens are cached independently.
+func (m *Mpesa) RegisterShortcode(name string, profile ShortcodeProfile) {
+	if profile.ConsumerKey == "" {
+		profile.ConsumerKey, profile.ConsumerSecret = m.consumerKey, m.consumerSecret
+	}
+
+	opts := append(append([]Option{}, m.options...), func(app *Mpesa) {
+		app.tokenRequests = m.tokenRequests
+		app.shortCode = profile.ShortCode
+		app.passkey = profile.Passkey
+		if profile.Initiator != (InitiatorCredentials{}) {
+			app.initiator = profile.Initiator
+		}
+		if profile.Callbacks != (CallbackURLs{}) {
+			app.callbackURLs = profile.Callbacks
+		}
+	})
+
+	app := NewApp(m.client, profile.ConsumerKey, profile.ConsumerSecret, m.environment, opts...)
+
+	m.profilesMu.Lock()
+	defer m.profilesMu.Unlock()
+
+	if m.profiles == nil {
+		m.profiles = make(map[string]*Mpesa)
+	}
+	m.profiles[name] = app
+}
+
+// WithShortcode returns the app of the profile registered under name, e.g. app.WithShortcode("b2c").B2C(...). The
+// requests made with the returned app fail with ErrUnknownShortcode if no profile is registered under name.
+func (m *Mpesa) WithShortcode(name string) *Mpesa {
+	m.profilesMu.RLock()
+	app, ok := m.profiles[name]
+	m.profilesMu.RUnlock()
+
+	if ok {
+		return app
+	}
+
+	app = NewApp(m.client, m.consumerKey, m.consumerSecret, m.environment)
+	app.profileErr = fmt.Errorf("%w: %s", ErrUnknownShortcode, name)
+	return app
+}
+
+// resolvePasskey returns the passkey passed to the method or, if it is empty, the one of the shortcode profile.
+func (m *Mpesa) resolvePasskey(passkey string) (string, error) {
+	if m.profileErr != nil {
+		return "", m.profileErr
+	}
+
+	if passkey == "" {
+		passkey = m.passkey
+	}
+
+	if passkey == "" {
+		return "", ErrInvalidPasskey
+	}
+
+	return passkey, nil
+}
+
+// defaultShortCode sets the shortcode of the profile on the shortcode field if it is not set.
+func (m *Mpesa) defaultShortCode(shortCode *uint) {
+	if *shortCode == 0 {
+		*shortCode = m.shortCode
+	}
+}
@@ -0,0 +1,129 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMpesa_WithShortcode(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cl  = newMockHttpClient()
+		app = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+	)
+
+	app.RegisterShortcode("c2b", ShortcodeProfile{
+		ShortCode:      174379,
+		ConsumerKey:    "c2b-key",
+		ConsumerSecret: "c2b-secret",
+		Passkey:        "passkey",
+	})
+	app.RegisterShortcode("b2c", ShortcodeProfile{
+		ShortCode:      600980,
+		ConsumerKey:    "b2c-key",
+		ConsumerSecret: "b2c-secret",
+		Initiator:      InitiatorCredentials{Name: "testapi", Password: "Safaricom999!*!"},
+	})
+
+	var authorized []string
+	cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+		key, _, ok := cl.requests[len(cl.requests)-1].BasicAuth()
+		require.True(t, ok)
+		authorized = append(authorized, key)
+
+		return http.StatusOK, `{"access_token": "` + key + `-token", "expires_in": "3599"}`
+	})
+
+	cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+		req := cl.requests[len(cl.requests)-1]
+		require.Equal(t, "Bearer c2b-key-token", req.Header.Get("Authorization"))
+
+		var params STKPushRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&params))
+		require.Equal(t, uint(174379), params.BusinessShortCode)
+		require.Equal(t, uint(174379), params.PartyB)
+		require.NotEmpty(t, params.Password)
+
+		return http.StatusOK, `{"CheckoutRequestID": "ws_CO_191220191020363925", "ResponseCode": "0"}`
+	})
+
+	cl.MockRequest(app.endpointB2C(), func() (status int, body string) {
+		req := cl.requests[len(cl.requests)-1]
+		require.Equal(t, "Bearer b2c-key-token", req.Header.Get("Authorization"))
+
+		var params B2CRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&params))
+		require.Equal(t, uint(600980), params.PartyA)
+		require.Equal(t, "testapi", params.InitiatorName)
+		require.NotEmpty(t, params.SecurityCredential)
+
+		return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+	})
+
+	cl.MockRequest(app.endpointAccountBalance(), func() (status int, body string) {
+		var params AccountBalanceRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&params))
+		require.Equal(t, 600980, params.PartyA)
+
+		return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+	})
+
+	cl.MockRequest(app.endpointReversal(), func() (status int, body string) {
+		var params ReversalRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&params))
+		require.Equal(t, uint(600980), params.ReceiverParty)
+
+		return http.StatusOK, `{"ConversationID": "AG_20191219_00005797af5d7d75f652", "ResponseCode": "0"}`
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := app.WithShortcode("c2b").STKPush(ctx, "", STKPushRequest{
+			TransactionType:  CustomerPayBillOnlineTransactionType,
+			Amount:           10,
+			PartyA:           254708374149,
+			PhoneNumber:      254708374149,
+			CallBackURL:      "https://example.com/callback",
+			AccountReference: "INV-001",
+			TransactionDesc:  "Test payment",
+		})
+		require.NoError(t, err)
+
+		_, err = app.WithShortcode("b2c").B2C(ctx, "", B2CRequest{
+			CommandID:       BusinessPaymentCommandID,
+			Amount:          10,
+			PartyB:          254708374149,
+			QueueTimeOutURL: "https://example.com/timeout",
+			ResultURL:       "https://example.com/result",
+		})
+		require.NoError(t, err)
+	}
+
+	_, err := app.WithShortcode("b2c").GetAccountBalance(ctx, "", AccountBalanceRequest{
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	})
+	require.NoError(t, err)
+
+	_, err = app.WithShortcode("b2c").Reversal(ctx, "", ReversalRequest{
+		Amount:          10,
+		TransactionID:   "OEI2AK4Q16",
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"c2b-key", "b2c-key"}, authorized)
+
+	_, err = app.WithShortcode("b2c").STKPush(ctx, "", STKPushRequest{})
+	require.ErrorIs(t, err, ErrInvalidPasskey)
+
+	_, err = app.WithShortcode("b2b").B2C(ctx, "", B2CRequest{})
+	require.ErrorIs(t, err, ErrUnknownShortcode)
+
+	_, err = app.WithShortcode("b2b").GenerateAccessToken(ctx)
+	require.ErrorIs(t, err, ErrUnknownShortcode)
+}
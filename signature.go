@@ -0,0 +1,193 @@
+package mpesa
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SignatureHeader is the header that carries the signature of the payloads relayed by the Forwarder.
+//
+// The value has the form t=<unix timestamp>,n=<nonce>,v1=<signature> where the signature is the hex encoded
+// HMAC-SHA256 of "<timestamp>.<nonce>.<payload>".
+const SignatureHeader = "X-Mpesa-Signature"
+
+// defaultSignatureTolerance is the maximum age of a signature accepted by a SignatureVerifier.
+const defaultSignatureTolerance = 5 * time.Minute
+
+var (
+	// ErrInvalidSignature is returned when a signature is missing, malformed or does not match the payload.
+	ErrInvalidSignature = errors.New("mpesa: invalid signature")
+
+	// ErrSignatureExpired is returned when a signature is older than the tolerance of the SignatureVerifier.
+	ErrSignatureExpired = errors.New("mpesa: signature expired")
+
+	// ErrSignatureReplayed is returned when a signature has already been accepted by the SignatureVerifier.
+	ErrSignatureReplayed = errors.New("mpesa: signature replayed")
+)
+
+func computeSignature(secret []byte, timestamp int64, nonce string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = fmt.Fprintf(mac, "%d.%s.", timestamp, nonce)
+	_, _ = mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// SignPayload signs the payload with the secret and returns the value of the SignatureHeader. A random nonce is
+// included so that every signature is unique even for identical payloads.
+func SignPayload(secret, payload []byte, t time.Time) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	nonce := hex.EncodeToString(b)
+
+	timestamp := t.Unix()
+	return fmt.Sprintf("t=%d,n=%s,v1=%s",
+		timestamp, nonce, hex.EncodeToString(computeSignature(secret, timestamp, nonce, payload)),
+	)
+}
+
+type signature struct {
+	timestamp time.Time
+	nonce     string
+	mac       []byte
+}
+
+func parseSignature(header string) (signature, error) {
+	var (
+		sig          signature
+		hasTimestamp bool
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return signature{}, ErrInvalidSignature
+		}
+
+		switch key {
+		case "t":
+			timestamp, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return signature{}, ErrInvalidSignature
+			}
+			sig.timestamp = time.Unix(timestamp, 0)
+			hasTimestamp = true
+		case "n":
+			sig.nonce = value
+		case "v1":
+			mac, err := hex.DecodeString(value)
+			if err != nil {
+				return signature{}, ErrInvalidSignature
+			}
+			sig.mac = mac
+		}
+	}
+
+	if !hasTimestamp || sig.nonce == "" || len(sig.mac) == 0 {
+		return signature{}, ErrInvalidSignature
+	}
+
+	return sig, nil
+}
+
+// SignatureVerifier verifies the signatures of the payloads relayed by the Forwarder. Signatures older than the
+// tolerance are rejected and every accepted nonce is remembered for the tolerance window so that a captured request
+// cannot be replayed.
+type SignatureVerifier struct {
+	// Secret is the secret shared with the Forwarder destination.
+	Secret []byte
+
+	// Tolerance is the maximum difference between the signature timestamp and the current time. Defaults to 5
+	// minutes.
+	Tolerance time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewSignatureVerifier creates a SignatureVerifier for the secret using the default tolerance.
+func NewSignatureVerifier(secret []byte) *SignatureVerifier {
+	return &SignatureVerifier{
+		Secret: secret,
+	}
+}
+
+func (v *SignatureVerifier) tolerance() time.Duration {
+	if v.Tolerance <= 0 {
+		return defaultSignatureTolerance
+	}
+
+	return v.Tolerance
+}
+
+// Verify checks that the header is a valid signature of the payload that has not expired or been used before.
+func (v *SignatureVerifier) Verify(header string, payload []byte) error {
+	sig, err := parseSignature(header)
+	if err != nil {
+		return err
+	}
+
+	want := computeSignature(v.Secret, sig.timestamp.Unix(), sig.nonce, payload)
+	if !hmac.Equal(want, sig.mac) {
+		return ErrInvalidSignature
+	}
+
+	var (
+		now       = time.Now()
+		tolerance = v.tolerance()
+	)
+
+	if age := now.Sub(sig.timestamp); age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.seen == nil {
+		v.seen = make(map[string]time.Time)
+	}
+
+	for nonce, expiresAt := range v.seen {
+		if now.After(expiresAt) {
+			delete(v.seen, nonce)
+		}
+	}
+
+	if _, ok := v.seen[sig.nonce]; ok {
+		return ErrSignatureReplayed
+	}
+
+	v.seen[sig.nonce] = sig.timestamp.Add(tolerance)
+	return nil
+}
+
+// Middleware returns a http.Handler that verifies the SignatureHeader of every request before calling next. Requests
+// that fail verification are rejected with http.StatusUnauthorized.
+func (v *SignatureVerifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(io.LimitReader(r.Body, maxAPIRequestBodySize))
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("mpesa: read request: %v", err))
+			return
+		}
+
+		if err = v.Verify(r.Header.Get(SignatureHeader), payload); err != nil {
+			writeAPIError(w, http.StatusUnauthorized, err)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(payload))
+		next.ServeHTTP(w, r)
+	})
+}
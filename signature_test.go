@@ -0,0 +1,113 @@
+package mpesa
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignatureVerifier_Verify(t *testing.T) {
+	var (
+		secret  = []byte("secret")
+		payload = []byte(`{"Result":{"ConversationID":"AG_20191219_00005797af5d7d75f652","ResultCode":0}}`)
+	)
+
+	tests := []struct {
+		name    string
+		header  func() string
+		payload []byte
+		wantErr error
+	}{
+		{
+			name:    "it accepts a valid signature",
+			header:  func() string { return SignPayload(secret, payload, time.Now()) },
+			payload: payload,
+		},
+		{
+			name:    "it rejects a signature made with a different secret",
+			header:  func() string { return SignPayload([]byte("other"), payload, time.Now()) },
+			payload: payload,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "it rejects a tampered payload",
+			header:  func() string { return SignPayload(secret, payload, time.Now()) },
+			payload: []byte(`{"Result":{"ResultCode":1}}`),
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "it rejects an expired signature",
+			header:  func() string { return SignPayload(secret, payload, time.Now().Add(-time.Hour)) },
+			payload: payload,
+			wantErr: ErrSignatureExpired,
+		},
+		{
+			name:    "it rejects a malformed signature",
+			header:  func() string { return "t=abc,n=1,v1=zz" },
+			payload: payload,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "it rejects a missing signature",
+			header:  func() string { return "" },
+			payload: payload,
+			wantErr: ErrInvalidSignature,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewSignatureVerifier(secret).Verify(tc.header(), tc.payload)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestSignatureVerifier_Replay(t *testing.T) {
+	var (
+		secret   = []byte("secret")
+		payload  = []byte(`{}`)
+		verifier = NewSignatureVerifier(secret)
+		header   = SignPayload(secret, payload, time.Now())
+	)
+
+	require.NoError(t, verifier.Verify(header, payload))
+	require.ErrorIs(t, verifier.Verify(header, payload), ErrSignatureReplayed)
+	require.NoError(t, verifier.Verify(SignPayload(secret, payload, time.Now()), payload))
+}
+
+func TestSignatureVerifier_Middleware(t *testing.T) {
+	var (
+		secret  = []byte("secret")
+		payload = `{"Result":{"ResultCode":0}}`
+	)
+
+	handler := NewSignatureVerifier(secret).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, payload, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(payload))
+	req.Header.Set(SignatureHeader, SignPayload(secret, []byte(payload), time.Now()))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/callback", strings.NewReader(payload)))
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,189 @@
+package mpesa
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// EndpointStandingOrderStatus and EndpointStandingOrderCancel are the endpoints of the M-Pesa Ratiba standing order
+// status queries and cancellations. They are only available to the partners Safaricom enabled Ratiba for; use
+// WithEndpointURL if the URLs assigned to you differ.
+const (
+	EndpointStandingOrderStatus Endpoint = "StandingOrderStatus"
+	EndpointStandingOrderCancel Endpoint = "StandingOrderCancel"
+)
+
+// Names of the StandingOrderCallback data items.
+const (
+	StandingOrderDataID            = "StandingOrderID"
+	StandingOrderDataStatus        = "Status"
+	StandingOrderDataTransactionID = "TransactionID"
+)
+
+type (
+	// StandingOrderStatusRequest queries the status of a Ratiba standing order. The status is sent to the
+	// CallBackURL.
+	StandingOrderStatusRequest struct {
+		// BusinessShortCode is the shortcode the standing order pays. It defaults to the shortcode of the app.
+		BusinessShortCode uint `json:"BusinessShortCode"`
+
+		// StandingOrderID is the ID M-Pesa assigned to the standing order when it was created.
+		StandingOrderID string `json:"StandingOrderID"`
+
+		// CallBackURL receives the StandingOrderCallback. It defaults to the CallBackURL of the app.
+		CallBackURL string `json:"CallBackURL"`
+	}
+
+	// CancelStandingOrderRequest stops a Ratiba standing order. The result is sent to the CallBackURL.
+	CancelStandingOrderRequest struct {
+		// BusinessShortCode is the shortcode the standing order pays. It defaults to the shortcode of the app.
+		BusinessShortCode uint `json:"BusinessShortCode"`
+
+		// StandingOrderID is the ID M-Pesa assigned to the standing order when it was created.
+		StandingOrderID string `json:"StandingOrderID"`
+
+		// CallBackURL receives the StandingOrderCallback. It defaults to the CallBackURL of the app.
+		CallBackURL string `json:"CallBackURL"`
+
+		// Remarks are comments sent along with the cancellation.
+		Remarks string `json:"Remarks,omitempty"`
+	}
+
+	// StandingOrderResponseHeader is the header of the responses and callbacks of the Ratiba APIs.
+	StandingOrderResponseHeader struct {
+		// ResponseRefID uniquely identifies the request. It is sent back on the callback.
+		ResponseRefID string `json:"responseRefID"`
+
+		// RequestRefID is the ResponseRefID of the request a callback belongs to.
+		RequestRefID string `json:"requestRefID,omitempty"`
+
+		// ResponseCode is "200" when a request is accepted and "0" on the callback of a successful request.
+		ResponseCode string `json:"responseCode"`
+
+		ResponseDescription string `json:"responseDescription"`
+		ResultDesc          string `json:"ResultDesc,omitempty"`
+	}
+
+	// StandingOrderResponse is the acknowledgement of a Ratiba standing order request. The result is sent to the
+	// CallBackURL of the request.
+	StandingOrderResponse struct {
+		ResponseHeader StandingOrderResponseHeader `json:"ResponseHeader"`
+
+		ResponseBody struct {
+			ResponseCode        string `json:"responseCode"`
+			ResponseDescription string `json:"responseDescription"`
+		} `json:"ResponseBody"`
+
+		// RawBody is the body of the response.
+		RawBody []byte `json:"-"`
+	}
+
+	// StandingOrderData is a named value sent on a StandingOrderCallback.
+	StandingOrderData struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	// StandingOrderCallback is sent to the CallBackURL of a Ratiba standing order status query or cancellation.
+	StandingOrderCallback struct {
+		ResponseHeader StandingOrderResponseHeader `json:"ResponseHeader"`
+
+		ResponseBody struct {
+			// ResponseData holds the details of the standing order, see the StandingOrderData names.
+			ResponseData []StandingOrderData `json:"responseData"`
+		} `json:"ResponseBody"`
+	}
+)
+
+// StandingOrderStatus queries the status of the Ratiba standing order. The status is sent to the CallBackURL and can
+// be decoded using UnmarshalStandingOrderCallback.
+func (m *Mpesa) StandingOrderStatus(
+	ctx context.Context, req StandingOrderStatusRequest,
+) (*StandingOrderResponse, error) {
+	m.defaultShortCode(&req.BusinessShortCode)
+	setDefault(&req.CallBackURL, m.callbackURLs.CallBackURL)
+
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	return do[StandingOrderResponse](ctx, m, EndpointStandingOrderStatus, req, true)
+}
+
+// CancelStandingOrder stops the Ratiba standing order so that the customer is not charged anymore. The result is sent
+// to the CallBackURL and can be decoded using UnmarshalStandingOrderCallback.
+func (m *Mpesa) CancelStandingOrder(
+	ctx context.Context, req CancelStandingOrderRequest,
+) (*StandingOrderResponse, error) {
+	m.defaultShortCode(&req.BusinessShortCode)
+	setDefault(&req.CallBackURL, m.callbackURLs.CallBackURL)
+
+	if err := validate(req); err != nil {
+		return nil, err
+	}
+
+	return do[StandingOrderResponse](ctx, m, EndpointStandingOrderCancel, req, false)
+}
+
+func (r *StandingOrderResponse) setRawBody(body []byte) { r.RawBody = body }
+
+// IsAccepted returns true if M-Pesa accepted the request for processing.
+func (r *StandingOrderResponse) IsAccepted() bool {
+	return r.ResponseHeader.ResponseCode == "200" || r.ResponseHeader.ResponseCode == "0"
+}
+
+// UnmarshalStandingOrderCallback decodes the provided value to StandingOrderCallback.
+func UnmarshalStandingOrderCallback(r io.Reader) (*StandingOrderCallback, error) {
+	return decode[StandingOrderCallback](r, false)
+}
+
+// IsSuccessful returns true if the status query or cancellation was processed successfully.
+func (c *StandingOrderCallback) IsSuccessful() bool {
+	return c.ResponseHeader.ResponseCode == "0"
+}
+
+// Value returns the value of the data item with the provided name, which is matched ignoring case.
+func (c *StandingOrderCallback) Value(name string) (string, bool) {
+	for _, data := range c.ResponseBody.ResponseData {
+		if strings.EqualFold(data.Name, name) {
+			return data.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// StandingOrderID returns the ID of the standing order the callback belongs to.
+func (c *StandingOrderCallback) StandingOrderID() string {
+	id, _ := c.Value(StandingOrderDataID)
+	return id
+}
+
+// Status returns the status of the standing order, e.g. Active or Cancelled.
+func (c *StandingOrderCallback) Status() string {
+	status, _ := c.Value(StandingOrderDataStatus)
+	return status
+}
+
+// Validate checks the BusinessShortCode, that the StandingOrderID is set and the CallBackURL.
+func (r StandingOrderStatusRequest) Validate() []error {
+	var v validator
+
+	v.shortCode("BusinessShortCode", r.BusinessShortCode)
+	v.required("StandingOrderID", r.StandingOrderID)
+	v.url("CallBackURL", r.CallBackURL)
+
+	return v.errs()
+}
+
+// Validate checks the BusinessShortCode, that the StandingOrderID is set and the CallBackURL.
+func (r CancelStandingOrderRequest) Validate() []error {
+	var v validator
+
+	v.shortCode("BusinessShortCode", r.BusinessShortCode)
+	v.required("StandingOrderID", r.StandingOrderID)
+	v.url("CallBackURL", r.CallBackURL)
+
+	return v.errs()
+}
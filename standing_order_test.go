@@ -0,0 +1,112 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMpesa_StandingOrder(t *testing.T) {
+	ctx := context.Background()
+
+	newApp := func() (*Mpesa, *mockHttpClient) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+			WithCallbackURLs(CallbackURLs{CallBackURL: "https://example.com/ratiba"}))
+		mockAuth(app, cl)
+		return app, cl
+	}
+
+	accepted := `{
+		"ResponseHeader": {
+			"responseRefID": "4dd9b5d9-d738-42ba-9326-2cc99e966000",
+			"responseCode": "200",
+			"responseDescription": "Request accepted for processing"
+		},
+		"ResponseBody": {
+			"responseDescription": "Request accepted for processing",
+			"responseCode": "200"
+		}
+	}`
+
+	t.Run("it queries the status of a standing order", func(t *testing.T) {
+		app, cl := newApp()
+
+		cl.MockRequest(app.endpointURL(EndpointStandingOrderStatus), func() (status int, body string) {
+			var req StandingOrderStatusRequest
+			require.NoError(t, json.NewDecoder(cl.requests[1].Body).Decode(&req))
+			require.Equal(t, uint(174379), req.BusinessShortCode)
+			require.Equal(t, "SO-4dd9b5d9", req.StandingOrderID)
+			require.Equal(t, "https://example.com/ratiba", req.CallBackURL)
+
+			return http.StatusOK, accepted
+		})
+
+		res, err := app.StandingOrderStatus(ctx, StandingOrderStatusRequest{
+			BusinessShortCode: 174379,
+			StandingOrderID:   "SO-4dd9b5d9",
+		})
+		require.NoError(t, err)
+		require.True(t, res.IsAccepted())
+		require.Equal(t, "4dd9b5d9-d738-42ba-9326-2cc99e966000", res.ResponseHeader.ResponseRefID)
+		require.NotEmpty(t, res.RawBody)
+	})
+
+	t.Run("it cancels a standing order", func(t *testing.T) {
+		app, cl := newApp()
+
+		cl.MockRequest(app.endpointURL(EndpointStandingOrderCancel), func() (status int, body string) {
+			var req CancelStandingOrderRequest
+			require.NoError(t, json.NewDecoder(cl.requests[1].Body).Decode(&req))
+			require.Equal(t, "SO-4dd9b5d9", req.StandingOrderID)
+			require.Equal(t, "Subscription ended", req.Remarks)
+
+			return http.StatusOK, accepted
+		})
+
+		res, err := app.CancelStandingOrder(ctx, CancelStandingOrderRequest{
+			BusinessShortCode: 174379,
+			StandingOrderID:   "SO-4dd9b5d9",
+			Remarks:           "Subscription ended",
+		})
+		require.NoError(t, err)
+		require.True(t, res.IsAccepted())
+	})
+
+	t.Run("it validates the request", func(t *testing.T) {
+		app, _ := newApp()
+
+		_, err := app.CancelStandingOrder(ctx, CancelStandingOrderRequest{BusinessShortCode: 174379})
+		require.ErrorIs(t, err, ErrInvalidRequest)
+		require.Len(t, ValidationErrors(err), 1)
+	})
+}
+
+func TestUnmarshalStandingOrderCallback(t *testing.T) {
+	callback, err := UnmarshalStandingOrderCallback(strings.NewReader(`{
+		"ResponseHeader": {
+			"responseRefID": "0acb4f8e-7e63-4f3b-a7c3-0b2c5e1b9a4f",
+			"requestRefID": "4dd9b5d9-d738-42ba-9326-2cc99e966000",
+			"responseCode": "0",
+			"responseDescription": "The service request is processed successfully"
+		},
+		"ResponseBody": {
+			"responseData": [
+				{"name": "StandingOrderID", "value": "SO-4dd9b5d9"},
+				{"name": "status", "value": "Cancelled"}
+			]
+		}
+	}`))
+	require.NoError(t, err)
+	require.True(t, callback.IsSuccessful())
+	require.Equal(t, "4dd9b5d9-d738-42ba-9326-2cc99e966000", callback.ResponseHeader.RequestRefID)
+	require.Equal(t, "SO-4dd9b5d9", callback.StandingOrderID())
+	require.Equal(t, "Cancelled", callback.Status())
+
+	_, ok := callback.Value(StandingOrderDataTransactionID)
+	require.False(t, ok)
+}
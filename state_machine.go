@@ -0,0 +1,257 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrInvalidTransition is returned when a transaction cannot move from its current status to the requested one.
+var ErrInvalidTransition = errors.New("mpesa: invalid transaction state transition")
+
+// transactionTransitions lists the statuses a transaction can move to from each status.
+var transactionTransitions = map[TransactionStatus][]TransactionStatus{
+	TransactionStatusInitiated: {TransactionStatusPending, TransactionStatusFailed},
+	TransactionStatusPending: {
+		TransactionStatusCompleted, TransactionStatusFailed, TransactionStatusTimedOut, TransactionStatusAborted,
+	},
+	TransactionStatusTimedOut:  {TransactionStatusCompleted, TransactionStatusFailed},
+	TransactionStatusAborted:   {TransactionStatusCompleted, TransactionStatusFailed},
+	TransactionStatusCompleted: {TransactionStatusReversed},
+}
+
+// CanTransitionTo returns true if a transaction in status s can move to next:
+//
+//	Initiated -> Pending | Failed
+//	Pending   -> Completed | Failed | TimedOut | Aborted
+//	TimedOut  -> Completed | Failed
+//	Aborted   -> Completed | Failed
+//	Completed -> Reversed
+//
+// A transaction that timed out can still complete since M-Pesa may deliver the result after the queue timeout. An
+// aborted STK push can still complete since the customer may confirm the prompt after it was aborted.
+func (s TransactionStatus) CanTransitionTo(next TransactionStatus) bool {
+	for _, status := range transactionTransitions[s] {
+		if status == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StateChange describes a transaction moving from one status to another.
+type StateChange struct {
+	// Transaction is the transaction after the change.
+	Transaction Transaction
+
+	// From is the previous status. It is empty when the transaction is created.
+	From TransactionStatus
+
+	// To is the new status.
+	To TransactionStatus
+}
+
+// TransactionStateMachine moves the transactions held in a TransactionStore through their statuses as the responses
+// and callbacks are received, rejecting transitions that are not allowed by TransactionStatus.CanTransitionTo.
+type TransactionStateMachine struct {
+	// Store holds the transactions.
+	Store TransactionStore
+
+	// OnStateChange, if set, is called after every successful transition.
+	OnStateChange func(ctx context.Context, change StateChange)
+
+	mu sync.Mutex
+}
+
+// NewTransactionStateMachine creates a TransactionStateMachine for the transactions in the store.
+func NewTransactionStateMachine(store TransactionStore) *TransactionStateMachine {
+	return &TransactionStateMachine{
+		Store: store,
+	}
+}
+
+func (m *TransactionStateMachine) notify(ctx context.Context, txn Transaction, from TransactionStatus) {
+	if m.OnStateChange != nil {
+		m.OnStateChange(ctx, StateChange{Transaction: txn, From: from, To: txn.Status})
+	}
+}
+
+// Initiate records a transaction from the response to the request that initiated it. txn describes the request,
+// res and reqErr are the values returned by the SDK.
+//
+// The transaction moves to Pending when M-Pesa accepts the request and to Failed otherwise. When the ID is not set, it
+// is taken from the CheckoutRequestID or ConversationID of the response. A rejected request without an ID is returned
+// but not saved.
+func (m *TransactionStateMachine) Initiate(
+	ctx context.Context, txn Transaction, res Acknowledgement, reqErr error,
+) (Transaction, error) {
+	now := time.Now()
+
+	txn.Status = TransactionStatusInitiated
+	if txn.CreatedAt.IsZero() {
+		txn.CreatedAt = now
+	}
+	txn.UpdatedAt = now
+
+	var requestID, responseCode, responseDescription string
+	if res != nil {
+		requestID, responseCode, responseDescription = res.acknowledgement()
+	}
+
+	if txn.ID == "" {
+		txn.ID = requestID
+	}
+
+	m.notify(ctx, txn, "")
+
+	switch {
+	case reqErr != nil:
+		txn.Status = TransactionStatusFailed
+		txn.ResultDesc = reqErr.Error()
+	case responseCode != "0":
+		txn.Status = TransactionStatusFailed
+		txn.ResultDesc = responseDescription
+	default:
+		txn.Status = TransactionStatusPending
+	}
+
+	if txn.Status == TransactionStatusFailed {
+		txn.CompletedAt = now
+	}
+
+	if txn.ID != "" {
+		m.mu.Lock()
+		err := m.Store.Save(ctx, txn)
+		m.mu.Unlock()
+
+		if err != nil {
+			return txn, err
+		}
+	}
+
+	m.notify(ctx, txn, TransactionStatusInitiated)
+	return txn, nil
+}
+
+// Transition moves the transaction with the provided ID to the status and applies update, if set, before saving it.
+// Moving a transaction to the status it is already in is a no-op so duplicate callbacks are harmless.
+func (m *TransactionStateMachine) Transition(
+	ctx context.Context, id string, to TransactionStatus, update func(txn *Transaction),
+) (Transaction, error) {
+	m.mu.Lock()
+
+	stored, err := m.Store.Get(ctx, id)
+	if err != nil {
+		m.mu.Unlock()
+		return Transaction{}, err
+	}
+
+	txn := *stored
+	from := txn.Status
+
+	if from == to {
+		m.mu.Unlock()
+		return txn, nil
+	}
+
+	if !from.CanTransitionTo(to) {
+		m.mu.Unlock()
+		return txn, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	if update != nil {
+		update(&txn)
+	}
+
+	now := time.Now()
+	txn.Status = to
+	txn.UpdatedAt = now
+	if to.IsTerminal() && txn.CompletedAt.IsZero() {
+		txn.CompletedAt = now
+	}
+
+	if err = m.Store.Save(ctx, txn); err != nil {
+		m.mu.Unlock()
+		return Transaction{}, err
+	}
+	m.mu.Unlock()
+
+	m.notify(ctx, txn, from)
+	return txn, nil
+}
+
+// HandleSTKPushCallback completes or fails the STK push transaction the callback belongs to.
+func (m *TransactionStateMachine) HandleSTKPushCallback(
+	ctx context.Context, callback *STKPushCallback,
+) (Transaction, error) {
+	result := callback.Body.STKCallback
+
+	to := TransactionStatusCompleted
+	if result.ResultCode != 0 {
+		to = TransactionStatusFailed
+	}
+
+	return m.Transition(ctx, result.CheckoutRequestID, to, func(txn *Transaction) {
+		txn.ResultCode = result.ResultCode
+		txn.ResultDesc = result.ResultDesc
+
+		if receipt, ok := result.MpesaReceiptNumber(); ok {
+			txn.ReceiptNumber = receipt
+		}
+	})
+}
+
+// HandleResult completes or fails the transaction the result callback belongs to.
+func (m *TransactionStateMachine) HandleResult(ctx context.Context, callback *Callback) (Transaction, error) {
+	result := callback.Result
+
+	to := TransactionStatusCompleted
+	if result.ResultCode != 0 {
+		to = TransactionStatusFailed
+	}
+
+	return m.Transition(ctx, result.ConversationID, to, func(txn *Transaction) {
+		txn.ResultCode = result.ResultCode
+		txn.ResultDesc = result.ResultDesc
+
+		if result.TransactionID != "" {
+			txn.ReceiptNumber = result.TransactionID
+		}
+	})
+}
+
+// HandleTimeout marks the transaction the queue timeout callback belongs to as timed out. The transaction is matched
+// by the ConversationID of the callback, or by its OriginatorConversationID when it was recorded with the ID set on
+// the request.
+func (m *TransactionStateMachine) HandleTimeout(
+	ctx context.Context, callback *QueueTimeoutCallback,
+) (Transaction, error) {
+	result := callback.Result
+
+	update := func(txn *Transaction) {
+		txn.ResultCode = result.ResultCode
+		txn.ResultDesc = result.ResultDesc
+	}
+
+	txn, err := m.Transition(ctx, result.ConversationID, TransactionStatusTimedOut, update)
+	if errors.Is(err, ErrTransactionNotFound) && result.OriginatorConversationID != "" {
+		return m.Transition(ctx, result.OriginatorConversationID, TransactionStatusTimedOut, update)
+	}
+
+	return txn, err
+}
+
+// Abort marks the pending STK push with the provided CheckoutRequestID as aborted.
+func (m *TransactionStateMachine) Abort(ctx context.Context, id string) (Transaction, error) {
+	return m.Transition(ctx, id, TransactionStatusAborted, func(txn *Transaction) {
+		txn.ResultDesc = "The STK push was aborted"
+	})
+}
+
+// Reverse marks the completed transaction with the provided ID as reversed.
+func (m *TransactionStateMachine) Reverse(ctx context.Context, id string) (Transaction, error) {
+	return m.Transition(ctx, id, TransactionStatusReversed, nil)
+}
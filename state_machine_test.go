@@ -0,0 +1,179 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionStatus_CanTransitionTo(t *testing.T) {
+	require.True(t, TransactionStatusInitiated.CanTransitionTo(TransactionStatusPending))
+	require.True(t, TransactionStatusPending.CanTransitionTo(TransactionStatusTimedOut))
+	require.True(t, TransactionStatusTimedOut.CanTransitionTo(TransactionStatusCompleted))
+	require.True(t, TransactionStatusCompleted.CanTransitionTo(TransactionStatusReversed))
+	require.True(t, TransactionStatusPending.CanTransitionTo(TransactionStatusAborted))
+	require.True(t, TransactionStatusAborted.CanTransitionTo(TransactionStatusCompleted))
+
+	require.False(t, TransactionStatusInitiated.CanTransitionTo(TransactionStatusCompleted))
+	require.False(t, TransactionStatusFailed.CanTransitionTo(TransactionStatusCompleted))
+	require.False(t, TransactionStatusCompleted.CanTransitionTo(TransactionStatusFailed))
+	require.False(t, TransactionStatusReversed.CanTransitionTo(TransactionStatusCompleted))
+	require.False(t, TransactionStatusCompleted.CanTransitionTo(TransactionStatusAborted))
+}
+
+func TestTransactionStateMachine(t *testing.T) {
+	ctx := context.Background()
+
+	newMachine := func() (*TransactionStateMachine, *[]StateChange) {
+		var changes []StateChange
+
+		m := NewTransactionStateMachine(NewMemoryStore())
+		m.OnStateChange = func(_ context.Context, change StateChange) {
+			changes = append(changes, change)
+		}
+
+		return m, &changes
+	}
+
+	t.Run("it drives an stk push from initiation to completion", func(t *testing.T) {
+		m, changes := newMachine()
+
+		txn, err := m.Initiate(ctx, Transaction{Kind: TransactionKindSTKPush, ShortCode: 174379, Amount: 10},
+			&STKPushResponse{CheckoutRequestID: "ws_CO_191220191020363925", ResponseCode: "0"}, nil)
+		require.NoError(t, err)
+		require.Equal(t, "ws_CO_191220191020363925", txn.ID)
+		require.Equal(t, TransactionStatusPending, txn.Status)
+
+		callback := &STKPushCallback{Body: STKPushCallbackBody{STKCallback: STKCallback{
+			CheckoutRequestID: "ws_CO_191220191020363925",
+			ResultDesc:        "The service request is processed successfully.",
+			CallbackMetadata: STKCallbackMetadata{Item: []STKCallbackItem{
+				{Name: "Amount", Value: 10.0},
+				{Name: "MpesaReceiptNumber", Value: "NLJ7RT61SV"},
+			}},
+		}}}
+
+		txn, err = m.HandleSTKPushCallback(ctx, callback)
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusCompleted, txn.Status)
+		require.Equal(t, "NLJ7RT61SV", txn.ReceiptNumber)
+		require.False(t, txn.CompletedAt.IsZero())
+
+		// A duplicate callback does not produce a new state change.
+		_, err = m.HandleSTKPushCallback(ctx, callback)
+		require.NoError(t, err)
+
+		txn, err = m.Reverse(ctx, txn.ID)
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusReversed, txn.Status)
+
+		require.Equal(t, []TransactionStatus{
+			TransactionStatusInitiated,
+			TransactionStatusPending,
+			TransactionStatusCompleted,
+			TransactionStatusReversed,
+		}, collectStatuses(*changes))
+		require.Equal(t, TransactionStatus(""), (*changes)[0].From)
+		require.Equal(t, TransactionStatusCompleted, (*changes)[3].From)
+	})
+
+	t.Run("it accepts a result that arrives after the queue timeout", func(t *testing.T) {
+		m, changes := newMachine()
+
+		_, err := m.Initiate(ctx, Transaction{Kind: TransactionKindB2C}, &B2CResponse{
+			ConversationID: "AG_20191219_00005797af5d7d75f652",
+			ResponseCode:   "0",
+		}, nil)
+		require.NoError(t, err)
+
+		txn, err := m.HandleTimeout(ctx, &QueueTimeoutCallback{Result: QueueTimeoutResult{
+			ConversationID: "AG_20191219_00005797af5d7d75f652",
+			ResultCode:     1,
+			ResultDesc:     "The service request timed out.",
+		}})
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusTimedOut, txn.Status)
+		require.Equal(t, 1, txn.ResultCode)
+
+		callback := &Callback{Result: CallbackResult{ConversationID: "AG_20191219_00005797af5d7d75f652"}}
+
+		callback.Result.ResultCode = 2001
+		callback.Result.ResultDesc = "The initiator information is invalid."
+
+		txn, err = m.HandleResult(ctx, callback)
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusFailed, txn.Status)
+		require.Equal(t, 2001, txn.ResultCode)
+
+		_, err = m.Reverse(ctx, txn.ID)
+		require.ErrorIs(t, err, ErrInvalidTransition)
+
+		require.Equal(t, []TransactionStatus{
+			TransactionStatusInitiated,
+			TransactionStatusPending,
+			TransactionStatusTimedOut,
+			TransactionStatusFailed,
+		}, collectStatuses(*changes))
+	})
+
+	t.Run("it matches a queue timeout by the originator conversation ID", func(t *testing.T) {
+		m, _ := newMachine()
+
+		_, err := m.Initiate(ctx, Transaction{ID: "16740-34861180-1", Kind: TransactionKindB2C}, &B2CResponse{
+			ConversationID:           "AG_20191219_00005797af5d7d75f652",
+			OriginatorConversationID: "16740-34861180-1",
+			ResponseCode:             "0",
+		}, nil)
+		require.NoError(t, err)
+
+		txn, err := m.HandleTimeout(ctx, &QueueTimeoutCallback{Result: QueueTimeoutResult{
+			ConversationID:           "AG_20191219_00005797af5d7d75f652",
+			OriginatorConversationID: "16740-34861180-1",
+			ResultCode:               1,
+		}})
+		require.NoError(t, err)
+		require.Equal(t, "16740-34861180-1", txn.ID)
+		require.Equal(t, TransactionStatusTimedOut, txn.Status)
+
+		_, err = m.HandleTimeout(ctx, &QueueTimeoutCallback{Result: QueueTimeoutResult{
+			ConversationID:           "AG_unknown",
+			OriginatorConversationID: "unknown",
+		}})
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+
+	t.Run("it fails rejected requests", func(t *testing.T) {
+		m, _ := newMachine()
+
+		txn, err := m.Initiate(ctx, Transaction{Kind: TransactionKindB2C}, nil, errors.New("mpesa: request failed"))
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusFailed, txn.Status)
+		require.Empty(t, txn.ID)
+
+		txn, err = m.Initiate(ctx, Transaction{ID: "payout-1", Kind: TransactionKindB2C}, nil, errors.New("boom"))
+		require.NoError(t, err)
+
+		stored, err := m.Store.Get(ctx, "payout-1")
+		require.NoError(t, err)
+		require.Equal(t, TransactionStatusFailed, stored.Status)
+		require.Equal(t, "boom", stored.ResultDesc)
+	})
+
+	t.Run("it returns an error for unknown transactions", func(t *testing.T) {
+		m, _ := newMachine()
+
+		_, err := m.HandleResult(ctx, &Callback{Result: CallbackResult{ConversationID: "AG_unknown"}})
+		require.ErrorIs(t, err, ErrTransactionNotFound)
+	})
+}
+
+func collectStatuses(changes []StateChange) []TransactionStatus {
+	statuses := make([]TransactionStatus, len(changes))
+	for i, change := range changes {
+		statuses[i] = change.To
+	}
+
+	return statuses
+}
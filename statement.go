@@ -0,0 +1,374 @@
+package mpesa
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// statementTimeLayouts are the layouts used by the org portal for the completion and initiation times.
+var statementTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"02-01-2006 15:04:05",
+	"02/01/2006 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// ErrInvalidStatement indicates that the statement does not have the columns exported by the M-Pesa org portal.
+var ErrInvalidStatement = errors.New("mpesa: statement is missing the Receipt No. column")
+
+// StatementDiff is the result of comparing the transactions recorded by the SDK against an org portal statement.
+type StatementDiff struct {
+	// Unrecorded are completed transactions on the statement that were not recorded by the SDK.
+	Unrecorded []Transaction
+
+	// Missing are transactions recorded as completed by the SDK that do not appear on the statement.
+	Missing []Transaction
+
+	// Mismatched are transactions present on both but with a different amount or status. The statement entry is
+	// returned.
+	Mismatched []Transaction
+}
+
+// ParseStatementCSV parses a transaction statement exported from the M-Pesa org portal as CSV. Any rows preceding
+// the header row, such as the organization details, are skipped.
+func ParseStatementCSV(r io.Reader) ([]Transaction, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: read statement: %v", err)
+	}
+
+	return parseStatementRows(rows)
+}
+
+// ParseStatementXLSX parses a transaction statement exported from the M-Pesa org portal as an Excel workbook. Only the
+// first worksheet is read. The legacy binary .xls format is not supported, re-save the file as .xlsx or .csv first.
+func ParseStatementXLSX(r io.ReaderAt, size int64) ([]Transaction, error) {
+	rows, err := readXLSXRows(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseStatementRows(rows)
+}
+
+// ReconcileStatement compares the transactions recorded by the SDK against the transactions on a statement using the
+// receipt number.
+func ReconcileStatement(recorded, statement []Transaction) StatementDiff {
+	var (
+		diff        StatementDiff
+		byReceipt   = make(map[string]Transaction, len(recorded))
+		onStatement = make(map[string]struct{}, len(statement))
+	)
+
+	for _, txn := range recorded {
+		if txn.ReceiptNumber != "" {
+			byReceipt[txn.ReceiptNumber] = txn
+		}
+	}
+
+	for _, entry := range statement {
+		onStatement[entry.ReceiptNumber] = struct{}{}
+
+		txn, ok := byReceipt[entry.ReceiptNumber]
+		if !ok {
+			if entry.Status == TransactionStatusCompleted {
+				diff.Unrecorded = append(diff.Unrecorded, entry)
+			}
+			continue
+		}
+
+		if math.Abs(txn.Amount-entry.Amount) > 0.005 || txn.Status != entry.Status {
+			diff.Mismatched = append(diff.Mismatched, entry)
+		}
+	}
+
+	for _, txn := range recorded {
+		if txn.Status != TransactionStatusCompleted {
+			continue
+		}
+
+		if _, ok := onStatement[txn.ReceiptNumber]; !ok {
+			diff.Missing = append(diff.Missing, txn)
+		}
+	}
+
+	return diff
+}
+
+func parseStatementRows(rows [][]string) ([]Transaction, error) {
+	header := -1
+	columns := make(map[string]int)
+
+	for i, row := range rows {
+		for _, cell := range row {
+			if strings.EqualFold(strings.TrimSpace(cell), string(LedgerColumnReceiptNumber)) {
+				header = i
+				break
+			}
+		}
+
+		if header != -1 {
+			for j, cell := range row {
+				columns[strings.ToLower(strings.TrimSpace(cell))] = j
+			}
+			break
+		}
+	}
+
+	if header == -1 {
+		return nil, ErrInvalidStatement
+	}
+
+	cell := func(row []string, name string) string {
+		i, ok := columns[strings.ToLower(name)]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var transactions []Transaction
+	for i, row := range rows[header+1:] {
+		receipt := cell(row, string(LedgerColumnReceiptNumber))
+		if receipt == "" {
+			continue
+		}
+
+		paidIn, err := parseStatementAmount(cell(row, "Paid In"))
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: statement row %d: %v", header+i+2, err)
+		}
+
+		withdrawn, err := parseStatementAmount(cell(row, "Withdrawn"))
+		if err != nil {
+			return nil, fmt.Errorf("mpesa: statement row %d: %v", header+i+2, err)
+		}
+
+		txn := Transaction{
+			ID:               receipt,
+			ReceiptNumber:    receipt,
+			Kind:             statementTransactionKind(cell(row, "Reason Type")),
+			MSISDN:           statementMSISDN(cell(row, string(LedgerColumnMSISDN))),
+			Amount:           paidIn,
+			AccountReference: cell(row, string(LedgerColumnAccountReference)),
+			Status:           statementTransactionStatus(cell(row, "Transaction Status")),
+			ResultDesc:       cell(row, string(LedgerColumnResultDesc)),
+			CreatedAt:        parseStatementTime(cell(row, string(LedgerColumnCreatedAt))),
+			CompletedAt:      parseStatementTime(cell(row, string(LedgerColumnCompletedAt))),
+		}
+
+		if txn.Amount == 0 {
+			txn.Amount = math.Abs(withdrawn)
+		}
+
+		transactions = append(transactions, txn)
+	}
+
+	return transactions, nil
+}
+
+func parseStatementAmount(v string) (float64, error) {
+	v = strings.ReplaceAll(v, ",", "")
+	if v == "" {
+		return 0, nil
+	}
+
+	amount, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", v)
+	}
+
+	return amount, nil
+}
+
+func parseStatementTime(v string) time.Time {
+	for _, layout := range statementTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+
+	// Excel stores dates as the number of days since 1899-12-30 when the cell is not formatted as text.
+	if days, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).Add(time.Duration(days * 24 * float64(time.Hour))).
+			Round(time.Second)
+	}
+
+	return time.Time{}
+}
+
+func statementTransactionStatus(v string) TransactionStatus {
+	switch strings.ToLower(v) {
+	case "completed":
+		return TransactionStatusCompleted
+	case "failed", "declined", "cancelled":
+		return TransactionStatusFailed
+	default:
+		return TransactionStatusPending
+	}
+}
+
+func statementTransactionKind(reasonType string) TransactionKind {
+	reasonType = strings.ToLower(reasonType)
+
+	switch {
+	case strings.Contains(reasonType, "online"):
+		return TransactionKindSTKPush
+	case strings.Contains(reasonType, "to customer"), strings.Contains(reasonType, "payment via api"):
+		return TransactionKindB2C
+	case strings.Contains(reasonType, "business pay bill"), strings.Contains(reasonType, "business buy goods"):
+		return TransactionKindBusinessPayBill
+	case strings.Contains(reasonType, "pay bill"), strings.Contains(reasonType, "buy goods"):
+		return TransactionKindC2B
+	default:
+		return ""
+	}
+}
+
+// statementMSISDN extracts the phone number from the "Other Party Info" column, e.g. 254708374149 - JOHN DOE.
+func statementMSISDN(v string) uint64 {
+	end := strings.IndexFunc(v, func(r rune) bool {
+		return !unicode.IsDigit(r)
+	})
+
+	if end == -1 {
+		end = len(v)
+	}
+
+	msisdn, _ := strconv.ParseUint(v[:end], 10, 64)
+	return msisdn
+}
+
+type (
+	xlsxSharedStrings struct {
+		Items []struct {
+			Text string `xml:"t"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"si"`
+	}
+
+	xlsxWorksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref       string `xml:"r,attr"`
+				Type      string `xml:"t,attr"`
+				Value     string `xml:"v"`
+				InlineStr string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+)
+
+func readXLSXFile(zr *zip.Reader, name string, v interface{}) (bool, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return false, fmt.Errorf("mpesa: open %s: %v", name, err)
+		}
+
+		//goland:noinspection GoUnhandledErrorResult
+		defer rc.Close()
+
+		if err = xml.NewDecoder(rc).Decode(v); err != nil {
+			return false, fmt.Errorf("mpesa: decode %s: %v", name, err)
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// xlsxColumn returns the zero based column index of a cell reference such as C12.
+func xlsxColumn(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+
+	return column - 1
+}
+
+func readXLSXRows(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("mpesa: read workbook: %v", err)
+	}
+
+	var sharedStrings xlsxSharedStrings
+	if _, err = readXLSXFile(zr, "xl/sharedStrings.xml", &sharedStrings); err != nil {
+		return nil, err
+	}
+
+	strs := make([]string, len(sharedStrings.Items))
+	for i, item := range sharedStrings.Items {
+		strs[i] = item.Text
+		for _, run := range item.Runs {
+			strs[i] += run.Text
+		}
+	}
+
+	var sheet xlsxWorksheet
+	found, err := readXLSXFile(zr, "xl/worksheets/sheet1.xml", &sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, errors.New("mpesa: workbook has no worksheets")
+	}
+
+	rows := make([][]string, 0, len(sheet.Rows))
+	for _, sheetRow := range sheet.Rows {
+		var row []string
+		for i, c := range sheetRow.Cells {
+			column := i
+			if c.Ref != "" {
+				column = xlsxColumn(c.Ref)
+			}
+
+			for len(row) <= column {
+				row = append(row, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(strs) {
+					return nil, fmt.Errorf("mpesa: invalid shared string %q in cell %s", c.Value, c.Ref)
+				}
+				row[column] = strs[idx]
+			case "inlineStr":
+				row[column] = c.InlineStr
+			default:
+				row[column] = c.Value
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,129 @@
+package mpesa
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testStatementCSV = `Account Holder:,JWAMBUGU LTD
+Short Code:,600426
+Time Period:,01-10-2023 - 31-10-2023
+
+Receipt No.,Completion Time,Initiation Time,Details,Transaction Status,Paid In,Withdrawn,Balance,Balance Confirmed,Reason Type,Other Party Info,Linked Transaction ID,A/C No.
+NLJ7RT61SV,2023-10-01 09:00:30,2023-10-01 09:00:00,Pay Bill Online from 254708374149,Completed,"1,000.00",,"5,000.00",true,Pay Bill Online,254708374149 - JOHN DOE,,INV-001
+NLJ41HAY6Q,2023-10-02 10:15:00,2023-10-02 10:14:58,Business Payment to 254708374149,Completed,,-250.00,"4,750.00",true,Business Payment to Customer via API,254708374149 - JOHN DOE,,
+NLJ41HAY7R,2023-10-02 11:00:00,2023-10-02 11:00:00,Pay Bill from 254708374149,Failed,100.00,,"4,750.00",true,Pay Bill,254708374149 - JOHN DOE,,INV-002
+`
+
+func TestParseStatementCSV(t *testing.T) {
+	transactions, err := ParseStatementCSV(strings.NewReader(testStatementCSV))
+	require.NoError(t, err)
+	require.Len(t, transactions, 3)
+
+	require.Equal(t, Transaction{
+		ID:               "NLJ7RT61SV",
+		ReceiptNumber:    "NLJ7RT61SV",
+		Kind:             TransactionKindSTKPush,
+		MSISDN:           254708374149,
+		Amount:           1000,
+		AccountReference: "INV-001",
+		Status:           TransactionStatusCompleted,
+		ResultDesc:       "Pay Bill Online from 254708374149",
+		CreatedAt:        time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC),
+		CompletedAt:      time.Date(2023, 10, 1, 9, 0, 30, 0, time.UTC),
+	}, transactions[0])
+
+	require.Equal(t, TransactionKindB2C, transactions[1].Kind)
+	require.Equal(t, float64(250), transactions[1].Amount)
+
+	require.Equal(t, TransactionKindC2B, transactions[2].Kind)
+	require.Equal(t, TransactionStatusFailed, transactions[2].Status)
+
+	_, err = ParseStatementCSV(strings.NewReader("Name,Amount\nJohn,10\n"))
+	require.ErrorIs(t, err, ErrInvalidStatement)
+
+	_, err = ParseStatementCSV(strings.NewReader("Receipt No.,Paid In\nNLJ7RT61SV,ten\n"))
+	require.Error(t, err)
+}
+
+func TestParseStatementXLSX(t *testing.T) {
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+	files := map[string]string{
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+			<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+				<si><t>Receipt No.</t></si>
+				<si><t>Completion Time</t></si>
+				<si><t>Paid In</t></si>
+				<si><t>Transaction Status</t></si>
+				<si><r><t>NLJ7</t></r><r><t>RT61SV</t></r></si>
+				<si><t>Completed</t></si>
+			</sst>`,
+		"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+			<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+				<sheetData>
+					<row r="1"><c r="A1" t="inlineStr"><is><t>Statement</t></is></c></row>
+					<row r="2">
+						<c r="A2" t="s"><v>0</v></c><c r="B2" t="s"><v>1</v></c>
+						<c r="C2" t="s"><v>2</v></c><c r="E2" t="s"><v>3</v></c>
+					</row>
+					<row r="3">
+						<c r="A3" t="s"><v>4</v></c><c r="B3"><v>45200.375</v></c>
+						<c r="C3"><v>1000</v></c><c r="E3" t="s"><v>5</v></c>
+					</row>
+				</sheetData>
+			</worksheet>`,
+	}
+
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, zw.Close())
+
+	transactions, err := ParseStatementXLSX(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	require.Equal(t, "NLJ7RT61SV", transactions[0].ReceiptNumber)
+	require.Equal(t, float64(1000), transactions[0].Amount)
+	require.Equal(t, TransactionStatusCompleted, transactions[0].Status)
+	require.Equal(t, time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC), transactions[0].CompletedAt)
+
+	_, err = ParseStatementXLSX(strings.NewReader("not a workbook"), 14)
+	require.Error(t, err)
+}
+
+func TestReconcileStatement(t *testing.T) {
+	statement, err := ParseStatementCSV(strings.NewReader(testStatementCSV))
+	require.NoError(t, err)
+
+	recorded := []Transaction{
+		{ID: "ws_CO_1", ReceiptNumber: "NLJ7RT61SV", Amount: 1000, Status: TransactionStatusCompleted},
+		{ID: "AG_2", ReceiptNumber: "NLJ41HAY6Q", Amount: 200, Status: TransactionStatusCompleted},
+		{ID: "AG_3", ReceiptNumber: "NLJ41HAY8S", Amount: 50, Status: TransactionStatusCompleted},
+		{ID: "ws_CO_4", Status: TransactionStatusPending},
+	}
+
+	diff := ReconcileStatement(recorded, statement)
+
+	require.Len(t, diff.Mismatched, 1)
+	require.Equal(t, "NLJ41HAY6Q", diff.Mismatched[0].ReceiptNumber)
+
+	require.Len(t, diff.Missing, 1)
+	require.Equal(t, "AG_3", diff.Missing[0].ID)
+
+	require.Empty(t, diff.Unrecorded)
+
+	diff = ReconcileStatement(nil, statement)
+	require.Len(t, diff.Unrecorded, 2)
+}
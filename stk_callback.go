@@ -0,0 +1,120 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the STK callback metadata items.
+const (
+	STKCallbackItemAmount             = "Amount"
+	STKCallbackItemMpesaReceiptNumber = "MpesaReceiptNumber"
+	STKCallbackItemTransactionDate    = "TransactionDate"
+	STKCallbackItemPhoneNumber        = "PhoneNumber"
+	STKCallbackItemBalance            = "Balance"
+)
+
+// stkTransactionDateLayout is the layout of the TransactionDate item, in East Africa Time.
+const stkTransactionDateLayout = "20060102150405"
+
+// eastAfricaTime is the time zone M-Pesa reports transaction times in.
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// Value returns the value of the metadata item with the name. It returns false if there is no such item.
+func (m STKCallbackMetadata) Value(name string) (interface{}, bool) {
+	for _, item := range m.Item {
+		if item.Name == name {
+			return item.Value, item.Value != nil
+		}
+	}
+
+	return nil, false
+}
+
+// number returns the numeric value of the metadata item.
+func (m STKCallbackMetadata) number(name string) (float64, bool) {
+	v, ok := m.Value(name)
+	if !ok {
+		return 0, false
+	}
+
+	return numberValue(v)
+}
+
+// numberValue converts a decoded callback value to a float64. Decoded callbacks hold float64 values, callbacks built
+// in code may hold any numeric type or a string.
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+
+	return 0, false
+}
+
+// Amount returns the amount paid. It returns false if the callback has no amount, e.g. for failed transactions.
+func (c STKCallback) Amount() (float64, bool) {
+	return c.CallbackMetadata.number(STKCallbackItemAmount)
+}
+
+// MpesaReceiptNumber returns the M-Pesa receipt number sent to the customer.
+func (c STKCallback) MpesaReceiptNumber() (string, bool) {
+	v, ok := c.CallbackMetadata.Value(STKCallbackItemMpesaReceiptNumber)
+	if !ok {
+		return "", false
+	}
+
+	receipt, ok := v.(string)
+	return receipt, ok && receipt != ""
+}
+
+// TransactionDate returns the time the transaction was completed.
+func (c STKCallback) TransactionDate() (time.Time, bool) {
+	v, ok := c.CallbackMetadata.Value(STKCallbackItemTransactionDate)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	var s string
+	if str, isString := v.(string); isString {
+		s = str
+	} else if n, isNumber := c.CallbackMetadata.number(STKCallbackItemTransactionDate); isNumber {
+		s = strconv.FormatFloat(n, 'f', 0, 64)
+	}
+
+	t, err := time.ParseInLocation(stkTransactionDateLayout, s, eastAfricaTime)
+	return t, err == nil
+}
+
+// PhoneNumber returns the phone number of the customer who paid, in the format 2547XXXXXXXX.
+func (c STKCallback) PhoneNumber() (uint64, bool) {
+	n, ok := c.CallbackMetadata.number(STKCallbackItemPhoneNumber)
+	if !ok || n <= 0 {
+		return 0, false
+	}
+
+	return uint64(n), true
+}
+
+// Balance returns the balance of the account credited by the payment. M-Pesa rarely sends it.
+func (c STKCallback) Balance() (float64, bool) {
+	return c.CallbackMetadata.number(STKCallbackItemBalance)
+}
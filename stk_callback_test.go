@@ -0,0 +1,93 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSTKCallback_Metadata(t *testing.T) {
+	callback, err := UnmarshalSTKPushCallback(strings.NewReader(`{
+		"Body": {
+			"stkCallback": {
+				"MerchantRequestID": "29115-34620561-1",
+				"CheckoutRequestID": "ws_CO_191220191020363925",
+				"ResultCode": 0,
+				"ResultDesc": "The service request is processed successfully.",
+				"CallbackMetadata": {
+					"Item": [
+						{"Name": "Amount", "Value": 1.00},
+						{"Name": "MpesaReceiptNumber", "Value": "NLJ7RT61SV"},
+						{"Name": "Balance"},
+						{"Name": "TransactionDate", "Value": 20191219102115},
+						{"Name": "PhoneNumber", "Value": 254708374149}
+					]
+				}
+			}
+		}
+	}`))
+	require.NoError(t, err)
+
+	result := callback.Body.STKCallback
+
+	amount, ok := result.Amount()
+	require.True(t, ok)
+	require.Equal(t, 1.0, amount)
+
+	receipt, ok := result.MpesaReceiptNumber()
+	require.True(t, ok)
+	require.Equal(t, "NLJ7RT61SV", receipt)
+
+	date, ok := result.TransactionDate()
+	require.True(t, ok)
+	require.True(t, time.Date(2019, time.December, 19, 7, 21, 15, 0, time.UTC).Equal(date))
+
+	phone, ok := result.PhoneNumber()
+	require.True(t, ok)
+	require.Equal(t, uint64(254708374149), phone)
+
+	_, ok = result.CallbackMetadata.Value(STKCallbackItemBalance)
+	require.False(t, ok)
+}
+
+func TestSTKCallback_MetadataValueTypes(t *testing.T) {
+	result := STKCallback{
+		CallbackMetadata: STKCallbackMetadata{
+			Item: []STKCallbackItem{
+				{Name: STKCallbackItemAmount, Value: json.Number("150.50")},
+				{Name: STKCallbackItemTransactionDate, Value: "20191219102115"},
+				{Name: STKCallbackItemPhoneNumber, Value: uint64(254708374149)},
+				{Name: STKCallbackItemMpesaReceiptNumber, Value: 12345},
+			},
+		},
+	}
+
+	amount, ok := result.Amount()
+	require.True(t, ok)
+	require.Equal(t, 150.5, amount)
+
+	date, ok := result.TransactionDate()
+	require.True(t, ok)
+	require.Equal(t, 2019, date.Year())
+
+	phone, ok := result.PhoneNumber()
+	require.True(t, ok)
+	require.Equal(t, uint64(254708374149), phone)
+
+	_, ok = result.MpesaReceiptNumber()
+	require.False(t, ok)
+
+	failed := STKCallback{ResultCode: 1032, ResultDesc: "Request cancelled by user"}
+
+	_, ok = failed.Amount()
+	require.False(t, ok)
+
+	_, ok = failed.TransactionDate()
+	require.False(t, ok)
+
+	_, ok = failed.PhoneNumber()
+	require.False(t, ok)
+}
@@ -0,0 +1,98 @@
+package mpesa
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultSTKQueryCacheTTL is how long terminal STKQuery results are cached when no TTL is set.
+const defaultSTKQueryCacheTTL = 5 * time.Minute
+
+type (
+	// STKQueryCache stores the terminal STKQuery results keyed by CheckoutRequestID. Implement it on top of a shared
+	// store such as Redis to share the results between app instances. Implementations must be safe for concurrent
+	// use.
+	STKQueryCache interface {
+		// Get returns the cached result of the checkout request. It returns false if there is none or it expired.
+		Get(ctx context.Context, checkoutRequestID string) (STKQueryResponse, bool, error)
+
+		// Set caches the result of the checkout request for ttl.
+		Set(ctx context.Context, checkoutRequestID string, res STKQueryResponse, ttl time.Duration) error
+	}
+
+	// MemorySTKQueryCache is an in-memory STKQueryCache for single instance deployments.
+	MemorySTKQueryCache struct {
+		mu      sync.Mutex
+		entries map[string]stkQueryCacheEntry
+		now     func() time.Time
+	}
+
+	stkQueryCacheEntry struct {
+		res       STKQueryResponse
+		expiresAt time.Time
+	}
+)
+
+// NewMemorySTKQueryCache creates an empty MemorySTKQueryCache.
+func NewMemorySTKQueryCache() *MemorySTKQueryCache {
+	return &MemorySTKQueryCache{
+		entries: make(map[string]stkQueryCacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached result of the checkout request.
+func (c *MemorySTKQueryCache) Get(_ context.Context, checkoutRequestID string) (STKQueryResponse, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[checkoutRequestID]
+	if !ok {
+		return STKQueryResponse{}, false, nil
+	}
+
+	if !c.now().Before(entry.expiresAt) {
+		delete(c.entries, checkoutRequestID)
+		return STKQueryResponse{}, false, nil
+	}
+
+	return entry.res, true, nil
+}
+
+// Set caches the result of the checkout request for ttl. Expired entries are removed on every call.
+func (c *MemorySTKQueryCache) Set(
+	_ context.Context, checkoutRequestID string, res STKQueryResponse, ttl time.Duration,
+) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for id, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, id)
+		}
+	}
+
+	c.entries[checkoutRequestID] = stkQueryCacheEntry{res: res, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// WithSTKQueryCache makes STKQuery return the cached result of checkout requests that already reached a final
+// result, so that repeated status checks do not reach Daraja. Results are cached for ttl, or 5 minutes if ttl is
+// not positive. Results of checkout requests still being processed are never cached.
+func WithSTKQueryCache(cache STKQueryCache, ttl time.Duration) Option {
+	if ttl <= 0 {
+		ttl = defaultSTKQueryCacheTTL
+	}
+
+	return func(m *Mpesa) {
+		m.stkQueryCache = cache
+		m.stkQueryCacheTTL = ttl
+	}
+}
+
+// isFinalSTKQueryResult returns true if the STKQuery response carries the final result of the checkout request.
+func isFinalSTKQueryResult(res *STKQueryResponse) bool {
+	return res.ResponseCode == "0" && res.ResultCode != ""
+}
@@ -0,0 +1,91 @@
+package mpesa
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemorySTKQueryCache(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		now   = time.Now()
+		cache = NewMemorySTKQueryCache()
+	)
+
+	cache.now = func() time.Time { return now }
+
+	_, ok, err := cache.Get(ctx, "ws_CO_1")
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, cache.Set(ctx, "ws_CO_1", STKQueryResponse{ResultCode: "0"}, time.Minute))
+
+	res, ok, err := cache.Get(ctx, "ws_CO_1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "0", res.ResultCode)
+
+	now = now.Add(time.Minute)
+
+	_, ok, err = cache.Get(ctx, "ws_CO_1")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMpesa_STKQueryWithCache(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		cl      = newMockHttpClient()
+		cache   = NewMemorySTKQueryCache()
+		app     = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithSTKQueryCache(cache, 0))
+		queries int
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+		queries++
+
+		if queries == 1 {
+			return http.StatusInternalServerError, `{
+				"requestId": "ws_CO_191220191020363925",
+				"errorCode": "500.001.1001",
+				"errorMessage": "The transaction is being processed"
+			}`
+		}
+
+		return http.StatusOK, `{
+			"ResponseCode": "0",
+			"ResponseDescription": "The service request has been accepted successsfully",
+			"MerchantRequestID": "22205-34066-1",
+			"CheckoutRequestID": "ws_CO_191220191020363925",
+			"ResultCode": "1032",
+			"ResultDesc": "Request cancelled by user"
+		}`
+	})
+
+	req := STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_191220191020363925"}
+
+	_, err := app.STKQuery(ctx, "passkey", req)
+	require.ErrorContains(t, err, "500.001.1001")
+
+	for i := 0; i < 3; i++ {
+		res, err := app.STKQuery(ctx, "passkey", req)
+		require.NoError(t, err)
+		require.Equal(t, "1032", res.ResultCode)
+	}
+
+	require.Equal(t, 2, queries)
+
+	cached, ok, err := cache.Get(ctx, "ws_CO_191220191020363925")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "Request cancelled by user", cached.ResultDesc)
+
+	_, err = app.STKQuery(ctx, "", req)
+	require.ErrorIs(t, err, ErrInvalidPasskey)
+}
@@ -0,0 +1,73 @@
+package mpesa
+
+import "strconv"
+
+// STKResultCode is the ResultCode of an STK push callback or query.
+type STKResultCode int
+
+// Well-known STK push result codes.
+const (
+	STKResultSuccess              STKResultCode = 0
+	STKResultInsufficientBalance  STKResultCode = 1
+	STKResultTransactionLimit     STKResultCode = 17
+	STKResultTransactionInProcess STKResultCode = 1001
+	STKResultTransactionExpired   STKResultCode = 1019
+	STKResultPushFailed           STKResultCode = 1025
+	STKResultCancelledByUser      STKResultCode = 1032
+	STKResultTimeout              STKResultCode = 1037
+	STKResultWrongPIN             STKResultCode = 2001
+	STKResultSystemError          STKResultCode = 9999
+)
+
+// stkResultCodeDescs are the descriptions of the well-known STK push result codes.
+var stkResultCodeDescs = map[STKResultCode]string{
+	STKResultSuccess:              "success",
+	STKResultInsufficientBalance:  "insufficient balance",
+	STKResultTransactionLimit:     "transaction limit reached",
+	STKResultTransactionInProcess: "transaction in process",
+	STKResultTransactionExpired:   "transaction expired",
+	STKResultPushFailed:           "push request failed",
+	STKResultCancelledByUser:      "cancelled by user",
+	STKResultTimeout:              "user cannot be reached",
+	STKResultWrongPIN:             "wrong PIN",
+	STKResultSystemError:          "system error",
+}
+
+// String returns a short description of the code, e.g. "cancelled by user", or the number of an unknown code.
+func (c STKResultCode) String() string {
+	if desc, ok := stkResultCodeDescs[c]; ok {
+		return desc
+	}
+
+	return "result code " + strconv.Itoa(int(c))
+}
+
+// IsSuccessful reports whether the customer paid.
+func (c STKResultCode) IsSuccessful() bool {
+	return c == STKResultSuccess
+}
+
+// IsCancelled reports whether the customer cancelled the prompt.
+func (c STKResultCode) IsCancelled() bool {
+	return c == STKResultCancelledByUser
+}
+
+// IsTimeout reports whether the customer did not respond to the prompt or could not be reached.
+func (c STKResultCode) IsTimeout() bool {
+	return c == STKResultTimeout || c == STKResultTransactionExpired
+}
+
+// Result returns the typed ResultCode of the callback.
+func (c STKCallback) Result() STKResultCode {
+	return STKResultCode(c.ResultCode)
+}
+
+// IsSuccessful reports whether the customer paid.
+func (c STKCallback) IsSuccessful() bool {
+	return c.Result().IsSuccessful()
+}
+
+// IsCancelled reports whether the customer cancelled the prompt.
+func (c STKCallback) IsCancelled() bool {
+	return c.Result().IsCancelled()
+}
@@ -0,0 +1,45 @@
+package mpesa
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSTKCallback_Result(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      int
+		want      STKResultCode
+		desc      string
+		succeeded bool
+		cancelled bool
+		timedOut  bool
+	}{
+		{name: "success", code: 0, want: STKResultSuccess, desc: "success", succeeded: true},
+		{name: "cancelled", code: 1032, want: STKResultCancelledByUser, desc: "cancelled by user", cancelled: true},
+		{name: "timeout", code: 1037, want: STKResultTimeout, desc: "user cannot be reached", timedOut: true},
+		{name: "insufficient balance", code: 1, want: STKResultInsufficientBalance, desc: "insufficient balance"},
+		{name: "wrong PIN", code: 2001, want: STKResultWrongPIN, desc: "wrong PIN"},
+		{name: "unknown", code: 4242, want: STKResultCode(4242), desc: "result code 4242"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			callback, err := UnmarshalSTKPushCallback(strings.NewReader(
+				`{"Body": {"stkCallback": {"CheckoutRequestID": "ws_CO_1", "ResultCode": ` + strconv.Itoa(tc.code) + `}}}`,
+			))
+			require.NoError(t, err)
+
+			stk := callback.Body.STKCallback
+			require.Equal(t, tc.want, stk.Result())
+			require.Equal(t, tc.desc, stk.Result().String())
+			require.Equal(t, tc.succeeded, stk.IsSuccessful())
+			require.Equal(t, tc.cancelled, stk.IsCancelled())
+			require.Equal(t, tc.timedOut, stk.Result().IsTimeout())
+		})
+	}
+}
@@ -0,0 +1,658 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SchedulePeriod is how often a recurring STK push is made.
+type SchedulePeriod string
+
+const (
+	SchedulePeriodDaily   SchedulePeriod = "Daily"
+	SchedulePeriodWeekly  SchedulePeriod = "Weekly"
+	SchedulePeriodMonthly SchedulePeriod = "Monthly"
+)
+
+// ScheduleStatus is the state of an STKSchedule.
+type ScheduleStatus string
+
+const (
+	// ScheduleStatusActive indicates that the schedule charges the customer on every cycle.
+	ScheduleStatusActive ScheduleStatus = "Active"
+
+	// ScheduleStatusCancelled indicates that the schedule was cancelled and will not charge the customer anymore.
+	ScheduleStatusCancelled ScheduleStatus = "Cancelled"
+
+	// ScheduleStatusEnded indicates that the schedule reached its EndAt time.
+	ScheduleStatusEnded ScheduleStatus = "Ended"
+)
+
+var (
+	// ErrDuplicateSchedule indicates that a schedule with the same ID already exists.
+	ErrDuplicateSchedule = errors.New("mpesa: duplicate schedule")
+
+	// ErrUnknownSchedule indicates that no schedule matches the ID or STK push callback.
+	ErrUnknownSchedule = errors.New("mpesa: unknown schedule")
+
+	// ErrInvalidSchedule indicates that the schedule is missing required values.
+	ErrInvalidSchedule = errors.New("mpesa: invalid schedule")
+)
+
+type (
+	// STKSchedule charges a customer using an STK push every period, e.g. for a monthly subscription.
+	STKSchedule struct {
+		// ID is the caller's unique reference for the schedule, e.g. the subscription ID.
+		ID string `json:"id"`
+
+		// Request is the STK push sent on every cycle. The Password and Timestamp are generated on every attempt and
+		// the BusinessShortCode, TransactionType, PartyB and CallBackURL default to the STKSchedulerConfig values.
+		Request STKPushRequest `json:"request"`
+
+		// Period is how often the customer is charged.
+		Period SchedulePeriod `json:"period"`
+
+		// StartAt is the time of the first charge. Monthly charges are made on the same day of the month, or on the
+		// last day of shorter months. Defaults to the time the schedule is added.
+		StartAt time.Time `json:"startAt"`
+
+		// EndAt, if set, is the time after which no charges are made.
+		EndAt time.Time `json:"endAt,omitempty"`
+
+		// Status is the current state of the schedule.
+		Status ScheduleStatus `json:"status"`
+
+		// Cycle is the number of cycles that have been paid or skipped.
+		Cycle int `json:"cycle"`
+
+		// Attempts is the number of failed attempts made in the current cycle.
+		Attempts int `json:"attempts"`
+
+		// NextAttemptAt is the earliest time the next STK push can be made. While a CheckoutRequestID is pending it
+		// is the time after which the attempt is considered failed if no callback has been received.
+		NextAttemptAt time.Time `json:"nextAttemptAt"`
+
+		// CheckoutRequestID is the ID of the STK push awaiting its callback.
+		CheckoutRequestID string `json:"checkoutRequestId,omitempty"`
+
+		// LastError is the error or result description of the last failed attempt.
+		LastError string `json:"lastError,omitempty"`
+
+		// CreatedAt is the time the schedule was added.
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	// STKScheduleResult is the outcome of a cycle of an STKSchedule.
+	STKScheduleResult struct {
+		// Schedule is the schedule after the outcome was applied.
+		Schedule STKSchedule
+
+		// Cycle is the cycle the outcome is for.
+		Cycle int
+
+		// Paid is true if the customer paid for the cycle.
+		Paid bool
+
+		// Skipped is true if the cycle was skipped after exhausting its attempts or failing with a result that should
+		// not be retried.
+		Skipped bool
+
+		// Callback is the STK push callback that completed the attempt. It is nil when the STK push could not be
+		// made or no callback was received in time.
+		Callback *STKPushCallback
+	}
+
+	// STKScheduleStore persists the schedules of an STKScheduler. Implementations must be safe for concurrent use.
+	STKScheduleStore interface {
+		// Put creates the schedule or replaces an existing schedule with the same ID.
+		Put(ctx context.Context, schedule STKSchedule) error
+
+		// Get returns the schedule with the ID. It returns false if there is none.
+		Get(ctx context.Context, id string) (STKSchedule, bool, error)
+
+		// GetByCheckoutRequestID returns the schedule awaiting the callback of the STK push. It returns false if
+		// there is none.
+		GetByCheckoutRequestID(ctx context.Context, checkoutRequestID string) (STKSchedule, bool, error)
+
+		// Due returns up to limit active schedules whose NextAttemptAt is not after now ordered by NextAttemptAt.
+		Due(ctx context.Context, now time.Time, limit int) ([]STKSchedule, error)
+	}
+
+	// STKSchedulerConfig configures an STKScheduler.
+	STKSchedulerConfig struct {
+		// Passkey is used to generate the password of the STK push requests.
+		Passkey string
+
+		// ShortCode, TransactionType, PartyB and CallBackURL are set on the schedule requests that do not set them.
+		// TransactionType defaults to CustomerPayBillOnlineTransactionType and PartyB to ShortCode.
+		ShortCode       uint
+		TransactionType TransactionType
+		PartyB          uint
+		CallBackURL     string
+
+		// MaxAttempts is the number of attempts made in a cycle before it is skipped. Defaults to 3.
+		MaxAttempts int
+
+		// RetryDelay is the time to wait before retrying a failed attempt. Defaults to 1 hour.
+		RetryDelay time.Duration
+
+		// CallbackTimeout is how long to wait for the callback of an STK push before the attempt is considered
+		// failed. Defaults to 5 minutes.
+		CallbackTimeout time.Duration
+
+		// BatchSize is the maximum number of due schedules processed on every run. Defaults to 50.
+		BatchSize int
+
+		// RetryResultCode reports whether a cycle whose STK push failed with the callback result code should be
+		// retried. Defaults to retrying every result code except 1032, which means the customer cancelled the
+		// prompt.
+		RetryResultCode func(code int) bool
+
+		// OnResult, if set, is called when a cycle is paid or skipped.
+		OnResult func(ctx context.Context, result STKScheduleResult)
+	}
+
+	// STKScheduler issues STK pushes for recurring charges, retrying failed attempts and skipping the cycles that
+	// cannot be paid. The schedules are kept in an STKScheduleStore so that they survive restarts when a durable
+	// store is used. Pass the STK push callbacks to HandleCallback to tie them back to their schedules.
+	STKScheduler struct {
+		app   *Mpesa
+		store STKScheduleStore
+		cfg   STKSchedulerConfig
+
+		// mu serializes the updates of the schedules.
+		mu  sync.Mutex
+		now func() time.Time
+	}
+)
+
+// isRetryableSTKResultCode is the default STKSchedulerConfig.RetryResultCode.
+func isRetryableSTKResultCode(code int) bool {
+	return STKResultCode(code) != STKResultCancelledByUser
+}
+
+// NewSTKScheduler creates an STKScheduler that makes the STK pushes using the provided app.
+func NewSTKScheduler(app *Mpesa, store STKScheduleStore, cfg STKSchedulerConfig) *STKScheduler {
+	if cfg.TransactionType == "" {
+		cfg.TransactionType = CustomerPayBillOnlineTransactionType
+	}
+
+	if cfg.PartyB == 0 {
+		cfg.PartyB = cfg.ShortCode
+	}
+
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 3
+	}
+
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = time.Hour
+	}
+
+	if cfg.CallbackTimeout <= 0 {
+		cfg.CallbackTimeout = 5 * time.Minute
+	}
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+
+	if cfg.RetryResultCode == nil {
+		cfg.RetryResultCode = isRetryableSTKResultCode
+	}
+
+	return &STKScheduler{
+		app:   app,
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// cycleStart returns the time the cycle of the schedule is due.
+func (s STKSchedule) cycleStart(cycle int) time.Time {
+	switch s.Period {
+	case SchedulePeriodDaily:
+		return s.StartAt.AddDate(0, 0, cycle)
+	case SchedulePeriodWeekly:
+		return s.StartAt.AddDate(0, 0, 7*cycle)
+	}
+
+	// Clamp the day so that a schedule starting on the 31st is charged on the last day of shorter months instead of
+	// overflowing into the next month.
+	year, month, day := s.StartAt.Date()
+	hour, minute, sec := s.StartAt.Clock()
+
+	first := time.Date(year, month+time.Month(cycle), 1, hour, minute, sec, s.StartAt.Nanosecond(), s.StartAt.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+
+	return first.AddDate(0, 0, day-1)
+}
+
+// Add adds the schedule. The first STK push is made by ProcessDue once StartAt is reached.
+func (s *STKScheduler) Add(ctx context.Context, schedule STKSchedule) error {
+	if schedule.ID == "" {
+		return fmt.Errorf("%w: ID cannot be empty", ErrInvalidSchedule)
+	}
+
+	if schedule.Request.PhoneNumber == 0 || schedule.Request.Amount == 0 {
+		return fmt.Errorf("%w: phone number and amount are required", ErrInvalidSchedule)
+	}
+
+	switch schedule.Period {
+	case SchedulePeriodDaily, SchedulePeriodWeekly, SchedulePeriodMonthly:
+	default:
+		return fmt.Errorf("%w: unsupported period %q", ErrInvalidSchedule, schedule.Period)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok, err := s.store.Get(ctx, schedule.ID); err != nil {
+		return fmt.Errorf("mpesa: load schedule: %v", err)
+	} else if ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateSchedule, schedule.ID)
+	}
+
+	now := s.now()
+	if schedule.StartAt.IsZero() {
+		schedule.StartAt = now
+	}
+
+	schedule.Status = ScheduleStatusActive
+	schedule.Cycle = 0
+	schedule.Attempts = 0
+	schedule.NextAttemptAt = schedule.StartAt
+	schedule.CheckoutRequestID = ""
+	schedule.LastError = ""
+	schedule.CreatedAt = now
+
+	if err := s.store.Put(ctx, schedule); err != nil {
+		return fmt.Errorf("mpesa: save schedule: %v", err)
+	}
+
+	return nil
+}
+
+// Get returns the schedule with the ID.
+func (s *STKScheduler) Get(ctx context.Context, id string) (STKSchedule, error) {
+	schedule, ok, err := s.store.Get(ctx, id)
+	if err != nil {
+		return STKSchedule{}, fmt.Errorf("mpesa: load schedule: %v", err)
+	}
+
+	if !ok {
+		return STKSchedule{}, fmt.Errorf("%w: %s", ErrUnknownSchedule, id)
+	}
+
+	return schedule, nil
+}
+
+// Cancel stops the schedule from charging the customer. The callback of an STK push already sent is still applied.
+func (s *STKScheduler) Cancel(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	schedule.Status = ScheduleStatusCancelled
+	if err = s.store.Put(ctx, schedule); err != nil {
+		return fmt.Errorf("mpesa: save schedule: %v", err)
+	}
+
+	return nil
+}
+
+// request returns the STK push request of the schedule with the defaults applied.
+func (s *STKScheduler) request(schedule STKSchedule) STKPushRequest {
+	req := schedule.Request
+
+	if req.BusinessShortCode == 0 {
+		req.BusinessShortCode = s.cfg.ShortCode
+	}
+
+	if req.TransactionType == "" {
+		req.TransactionType = s.cfg.TransactionType
+	}
+
+	if req.PartyA == 0 {
+		req.PartyA = uint(req.PhoneNumber)
+	}
+
+	if req.PartyB == 0 {
+		req.PartyB = s.cfg.PartyB
+	}
+
+	if req.CallBackURL == "" {
+		req.CallBackURL = s.cfg.CallBackURL
+	}
+
+	return req
+}
+
+// ProcessDue sends the STK pushes of the schedules that are due and fails the attempts whose callback was not
+// received in time. It returns the number of schedules processed.
+func (s *STKScheduler) ProcessDue(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedules, err := s.store.Due(ctx, s.now(), s.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("mpesa: load due schedules: %v", err)
+	}
+
+	for i, schedule := range schedules {
+		if err = ctx.Err(); err != nil {
+			return i, err
+		}
+
+		if schedule.CheckoutRequestID != "" {
+			if err = s.fail(ctx, schedule, "no callback received for "+schedule.CheckoutRequestID, true, nil); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		if s.ended(schedule) {
+			schedule.Status = ScheduleStatusEnded
+			if err = s.store.Put(ctx, schedule); err != nil {
+				return i, fmt.Errorf("mpesa: save schedule: %v", err)
+			}
+			continue
+		}
+
+		res, err := s.app.STKPush(ctx, s.cfg.Passkey, s.request(schedule))
+		if err != nil {
+			if err = s.fail(ctx, schedule, err.Error(), !errors.Is(err, ErrInvalidPasskey), nil); err != nil {
+				return i, err
+			}
+			continue
+		}
+
+		schedule.CheckoutRequestID = res.CheckoutRequestID
+		schedule.NextAttemptAt = s.now().Add(s.cfg.CallbackTimeout)
+		if err = s.store.Put(ctx, schedule); err != nil {
+			return i, fmt.Errorf("mpesa: save schedule: %v", err)
+		}
+	}
+
+	return len(schedules), nil
+}
+
+// ended returns true if the next cycle of the schedule starts after its EndAt.
+func (s *STKScheduler) ended(schedule STKSchedule) bool {
+	return !schedule.EndAt.IsZero() && schedule.cycleStart(schedule.Cycle).After(schedule.EndAt)
+}
+
+// fail records a failed attempt, retrying it after RetryDelay or skipping the cycle once MaxAttempts is reached or
+// the failure should not be retried. It must be called with s.mu held.
+func (s *STKScheduler) fail(
+	ctx context.Context, schedule STKSchedule, reason string, retry bool, callback *STKPushCallback,
+) error {
+	schedule.Attempts++
+	schedule.CheckoutRequestID = ""
+	schedule.LastError = reason
+
+	if retry && schedule.Attempts < s.cfg.MaxAttempts {
+		schedule.NextAttemptAt = s.now().Add(s.cfg.RetryDelay)
+		if err := s.store.Put(ctx, schedule); err != nil {
+			return fmt.Errorf("mpesa: save schedule: %v", err)
+		}
+		return nil
+	}
+
+	return s.advance(ctx, schedule, STKScheduleResult{Skipped: true, Callback: callback})
+}
+
+// advance moves the schedule to its next cycle and reports the result of the current one. It must be called with
+// s.mu held.
+func (s *STKScheduler) advance(ctx context.Context, schedule STKSchedule, result STKScheduleResult) error {
+	result.Cycle = schedule.Cycle
+
+	schedule.Cycle++
+	schedule.Attempts = 0
+	schedule.CheckoutRequestID = ""
+	schedule.NextAttemptAt = schedule.cycleStart(schedule.Cycle)
+
+	if schedule.Status == ScheduleStatusActive && s.ended(schedule) {
+		schedule.Status = ScheduleStatusEnded
+	}
+
+	if err := s.store.Put(ctx, schedule); err != nil {
+		return fmt.Errorf("mpesa: save schedule: %v", err)
+	}
+
+	if s.cfg.OnResult != nil {
+		result.Schedule = schedule
+		s.cfg.OnResult(ctx, result)
+	}
+
+	return nil
+}
+
+// HandleCallback applies the STK push callback to the schedule that sent the prompt and returns the updated
+// schedule. A successful payment completes the current cycle, a failed one is retried or skips the cycle.
+func (s *STKScheduler) HandleCallback(ctx context.Context, callback *STKPushCallback) (STKSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkoutRequestID := callback.Body.STKCallback.CheckoutRequestID
+
+	schedule, ok, err := s.store.GetByCheckoutRequestID(ctx, checkoutRequestID)
+	if err != nil {
+		return STKSchedule{}, fmt.Errorf("mpesa: load schedule: %v", err)
+	}
+
+	if !ok {
+		return STKSchedule{}, fmt.Errorf("%w: %s", ErrUnknownSchedule, checkoutRequestID)
+	}
+
+	code := callback.Body.STKCallback.ResultCode
+	if code == 0 {
+		schedule.LastError = ""
+		err = s.advance(ctx, schedule, STKScheduleResult{Paid: true, Callback: callback})
+	} else {
+		err = s.fail(ctx, schedule, callback.Body.STKCallback.ResultDesc, s.cfg.RetryResultCode(code), callback)
+	}
+
+	if err != nil {
+		return STKSchedule{}, err
+	}
+
+	return s.Get(ctx, schedule.ID)
+}
+
+// Run calls ProcessDue every interval until the context is done.
+func (s *STKScheduler) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// MemorySTKScheduleStore is an in-memory STKScheduleStore. Schedules are lost on restart, use FileSTKScheduleStore
+// or a database backed store when they need to be durable.
+type MemorySTKScheduleStore struct {
+	mu        sync.Mutex
+	schedules map[string]STKSchedule
+}
+
+// NewMemorySTKScheduleStore creates an empty MemorySTKScheduleStore.
+func NewMemorySTKScheduleStore() *MemorySTKScheduleStore {
+	return &MemorySTKScheduleStore{
+		schedules: make(map[string]STKSchedule),
+	}
+}
+
+// Put creates or replaces the schedule.
+func (s *MemorySTKScheduleStore) Put(_ context.Context, schedule STKSchedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules[schedule.ID] = schedule
+	return nil
+}
+
+// Get returns the schedule with the ID.
+func (s *MemorySTKScheduleStore) Get(_ context.Context, id string) (STKSchedule, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	return schedule, ok, nil
+}
+
+// GetByCheckoutRequestID returns the schedule awaiting the callback of the STK push.
+func (s *MemorySTKScheduleStore) GetByCheckoutRequestID(
+	_ context.Context, checkoutRequestID string,
+) (STKSchedule, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := scheduleByCheckoutRequestID(s.schedules, checkoutRequestID)
+	return schedule, ok, nil
+}
+
+// Due returns up to limit active schedules that are due ordered by NextAttemptAt.
+func (s *MemorySTKScheduleStore) Due(_ context.Context, now time.Time, limit int) ([]STKSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return dueSchedules(s.schedules, now, limit), nil
+}
+
+func scheduleByCheckoutRequestID(schedules map[string]STKSchedule, checkoutRequestID string) (STKSchedule, bool) {
+	if checkoutRequestID == "" {
+		return STKSchedule{}, false
+	}
+
+	for _, schedule := range schedules {
+		if schedule.CheckoutRequestID == checkoutRequestID {
+			return schedule, true
+		}
+	}
+
+	return STKSchedule{}, false
+}
+
+func dueSchedules(schedules map[string]STKSchedule, now time.Time, limit int) []STKSchedule {
+	var due []STKSchedule
+	for _, schedule := range schedules {
+		if schedule.Status == ScheduleStatusActive && !schedule.NextAttemptAt.After(now) {
+			due = append(due, schedule)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+	})
+
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due
+}
+
+// FileSTKScheduleStore is an STKScheduleStore that persists the schedules to a JSON file. Every change rewrites the
+// file atomically so it is suitable for single instance deployments with a moderate number of schedules.
+type FileSTKScheduleStore struct {
+	mu        sync.Mutex
+	path      string
+	schedules map[string]STKSchedule
+}
+
+// NewFileSTKScheduleStore creates a FileSTKScheduleStore backed by the file at path, loading any schedules already
+// saved in it.
+func NewFileSTKScheduleStore(path string) (*FileSTKScheduleStore, error) {
+	s := &FileSTKScheduleStore{
+		path:      path,
+		schedules: make(map[string]STKSchedule),
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("mpesa: read schedule store: %v", err)
+	}
+
+	if len(b) == 0 {
+		return s, nil
+	}
+
+	if err = json.Unmarshal(b, &s.schedules); err != nil {
+		return nil, fmt.Errorf("mpesa: decode schedule store: %v", err)
+	}
+
+	return s, nil
+}
+
+// Put creates or replaces the schedule and persists the store.
+func (s *FileSTKScheduleStore) Put(_ context.Context, schedule STKSchedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schedules[schedule.ID] = schedule
+
+	b, err := json.Marshal(s.schedules)
+	if err != nil {
+		return fmt.Errorf("mpesa: encode schedule store: %v", err)
+	}
+
+	if err = writeFileAtomic(s.path, b); err != nil {
+		return fmt.Errorf("mpesa: write schedule store: %v", err)
+	}
+
+	return nil
+}
+
+// Get returns the schedule with the ID.
+func (s *FileSTKScheduleStore) Get(_ context.Context, id string) (STKSchedule, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[id]
+	return schedule, ok, nil
+}
+
+// GetByCheckoutRequestID returns the schedule awaiting the callback of the STK push.
+func (s *FileSTKScheduleStore) GetByCheckoutRequestID(
+	_ context.Context, checkoutRequestID string,
+) (STKSchedule, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := scheduleByCheckoutRequestID(s.schedules, checkoutRequestID)
+	return schedule, ok, nil
+}
+
+// Due returns up to limit active schedules that are due ordered by NextAttemptAt.
+func (s *FileSTKScheduleStore) Due(_ context.Context, now time.Time, limit int) ([]STKSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return dueSchedules(s.schedules, now, limit), nil
+}
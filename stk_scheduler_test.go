@@ -0,0 +1,281 @@
+package mpesa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSTKScheduleCallback(checkoutRequestID string, code int) *STKPushCallback {
+	return &STKPushCallback{
+		Body: STKPushCallbackBody{
+			STKCallback: STKCallback{
+				MerchantRequestID: "29115-34620561-1",
+				CheckoutRequestID: checkoutRequestID,
+				ResultCode:        code,
+				ResultDesc:        fmt.Sprintf("result %d", code),
+			},
+		},
+	}
+}
+
+func TestSTKScheduler(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		start = time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+	)
+
+	newScheduler := func(t *testing.T) (*STKScheduler, *time.Time, *[]STKScheduleResult) {
+		var (
+			cl      = newMockHttpClient()
+			app     = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			now     = start
+			results []STKScheduleResult
+			pushes  int
+		)
+
+		mockAuth(app, cl)
+
+		cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+			pushes++
+			return http.StatusOK, fmt.Sprintf(`{
+				"MerchantRequestID": "29115-34620561-1",
+				"CheckoutRequestID": "ws_CO_%d",
+				"ResponseCode": "0",
+				"ResponseDescription": "Success. Request accepted for processing",
+				"CustomerMessage": "Success. Request accepted for processing"
+			}`, pushes)
+		})
+
+		s := NewSTKScheduler(app, NewMemorySTKScheduleStore(), STKSchedulerConfig{
+			Passkey:     "passkey",
+			ShortCode:   174379,
+			CallBackURL: "https://example.com/stk",
+			RetryDelay:  time.Hour,
+			OnResult: func(_ context.Context, result STKScheduleResult) {
+				results = append(results, result)
+			},
+		})
+		s.now = func() time.Time { return now }
+
+		require.NoError(t, s.Add(ctx, STKSchedule{
+			ID: "sub-1",
+			Request: STKPushRequest{
+				PhoneNumber:      254708374149,
+				Amount:           500,
+				AccountReference: "sub-1",
+				TransactionDesc:  "Subscription",
+			},
+			Period:  SchedulePeriodMonthly,
+			StartAt: start,
+		}))
+
+		return s, &now, &results
+	}
+
+	t.Run("it charges the customer every cycle", func(t *testing.T) {
+		s, now, results := newScheduler(t)
+
+		n, err := s.ProcessDue(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, n)
+
+		schedule, err := s.Get(ctx, "sub-1")
+		require.NoError(t, err)
+		require.Equal(t, "ws_CO_1", schedule.CheckoutRequestID)
+		require.Equal(t, start.Add(5*time.Minute), schedule.NextAttemptAt)
+
+		n, err = s.ProcessDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		schedule, err = s.HandleCallback(ctx, testSTKScheduleCallback("ws_CO_1", 0))
+		require.NoError(t, err)
+		require.Equal(t, 1, schedule.Cycle)
+		require.Empty(t, schedule.CheckoutRequestID)
+		require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), schedule.NextAttemptAt)
+
+		require.Len(t, *results, 1)
+		require.True(t, (*results)[0].Paid)
+		require.Zero(t, (*results)[0].Cycle)
+
+		*now = schedule.NextAttemptAt
+		_, err = s.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		schedule, err = s.HandleCallback(ctx, testSTKScheduleCallback("ws_CO_2", 0))
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), schedule.NextAttemptAt)
+
+		_, err = s.HandleCallback(ctx, testSTKScheduleCallback("ws_CO_2", 0))
+		require.ErrorIs(t, err, ErrUnknownSchedule)
+	})
+
+	t.Run("it retries failed attempts and skips the cycle", func(t *testing.T) {
+		s, now, results := newScheduler(t)
+
+		_, err := s.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		schedule, err := s.HandleCallback(ctx, testSTKScheduleCallback("ws_CO_1", 1))
+		require.NoError(t, err)
+		require.Equal(t, 1, schedule.Attempts)
+		require.Equal(t, "result 1", schedule.LastError)
+		require.Equal(t, start.Add(time.Hour), schedule.NextAttemptAt)
+
+		*now = now.Add(time.Hour)
+		_, err = s.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		// No callback is received for the second attempt.
+		*now = now.Add(5 * time.Minute)
+		_, err = s.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		schedule, err = s.Get(ctx, "sub-1")
+		require.NoError(t, err)
+		require.Equal(t, 2, schedule.Attempts)
+		require.Equal(t, "no callback received for ws_CO_2", schedule.LastError)
+
+		*now = now.Add(time.Hour)
+		_, err = s.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		schedule, err = s.HandleCallback(ctx, testSTKScheduleCallback("ws_CO_3", 1037))
+		require.NoError(t, err)
+		require.Equal(t, 1, schedule.Cycle)
+		require.Zero(t, schedule.Attempts)
+		require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), schedule.NextAttemptAt)
+
+		require.Len(t, *results, 1)
+		require.True(t, (*results)[0].Skipped)
+		require.Equal(t, 1037, (*results)[0].Callback.Body.STKCallback.ResultCode)
+	})
+
+	t.Run("it skips the cycle when the customer cancels", func(t *testing.T) {
+		s, _, results := newScheduler(t)
+
+		_, err := s.ProcessDue(ctx)
+		require.NoError(t, err)
+
+		schedule, err := s.HandleCallback(ctx, testSTKScheduleCallback("ws_CO_1", int(STKResultCancelledByUser)))
+		require.NoError(t, err)
+		require.Equal(t, 1, schedule.Cycle)
+		require.Len(t, *results, 1)
+		require.True(t, (*results)[0].Skipped)
+	})
+
+	t.Run("it stops charging cancelled and ended schedules", func(t *testing.T) {
+		s, now, _ := newScheduler(t)
+
+		require.NoError(t, s.Cancel(ctx, "sub-1"))
+
+		n, err := s.ProcessDue(ctx)
+		require.NoError(t, err)
+		require.Zero(t, n)
+
+		require.NoError(t, s.Add(ctx, STKSchedule{
+			ID: "sub-2",
+			Request: STKPushRequest{
+				PhoneNumber:      254708374149,
+				Amount:           100,
+				AccountReference: "sub-2",
+				TransactionDesc:  "Subscription",
+			},
+			Period: SchedulePeriodWeekly,
+			EndAt:  start.AddDate(0, 0, 10),
+		}))
+
+		for i := 1; i <= 2; i++ {
+			_, err = s.ProcessDue(ctx)
+			require.NoError(t, err)
+
+			schedule, err := s.HandleCallback(ctx, testSTKScheduleCallback(fmt.Sprintf("ws_CO_%d", i), 0))
+			require.NoError(t, err)
+			*now = schedule.NextAttemptAt
+		}
+
+		schedule, err := s.Get(ctx, "sub-2")
+		require.NoError(t, err)
+		require.Equal(t, ScheduleStatusEnded, schedule.Status)
+		require.Equal(t, 2, schedule.Cycle)
+
+		require.ErrorIs(t, s.Cancel(ctx, "missing"), ErrUnknownSchedule)
+	})
+
+	t.Run("it validates the schedules", func(t *testing.T) {
+		s, _, _ := newScheduler(t)
+
+		err := s.Add(ctx, STKSchedule{
+			ID:      "sub-1",
+			Request: STKPushRequest{PhoneNumber: 254708374149, Amount: 500},
+			Period:  SchedulePeriodMonthly,
+		})
+		require.ErrorIs(t, err, ErrDuplicateSchedule)
+
+		for _, schedule := range []STKSchedule{
+			{Request: STKPushRequest{PhoneNumber: 254708374149, Amount: 500}, Period: SchedulePeriodDaily},
+			{ID: "sub-3", Request: STKPushRequest{Amount: 500}, Period: SchedulePeriodDaily},
+			{ID: "sub-3", Request: STKPushRequest{PhoneNumber: 254708374149, Amount: 500}, Period: "Yearly"},
+		} {
+			require.ErrorIs(t, s.Add(ctx, schedule), ErrInvalidSchedule)
+		}
+	})
+}
+
+func TestSTKScheduler_request(t *testing.T) {
+	s := NewSTKScheduler(nil, NewMemorySTKScheduleStore(), STKSchedulerConfig{
+		ShortCode:   174379,
+		CallBackURL: "https://example.com/stk",
+	})
+
+	req := s.request(STKSchedule{Request: STKPushRequest{PhoneNumber: 254708374149, Amount: 500}})
+	require.Equal(t, uint(174379), req.BusinessShortCode)
+	require.Equal(t, uint(174379), req.PartyB)
+	require.Equal(t, uint(254708374149), req.PartyA)
+	require.Equal(t, TransactionType(CustomerPayBillOnlineTransactionType), req.TransactionType)
+	require.Equal(t, "https://example.com/stk", req.CallBackURL)
+}
+
+func TestFileSTKScheduleStore(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "schedules.json")
+		now  = time.Now()
+	)
+
+	store, err := NewFileSTKScheduleStore(path)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, STKSchedule{ID: "1", Status: ScheduleStatusActive, NextAttemptAt: now}))
+	require.NoError(t, store.Put(ctx, STKSchedule{
+		ID:                "2",
+		Status:            ScheduleStatusActive,
+		NextAttemptAt:     now.Add(-time.Minute),
+		CheckoutRequestID: "ws_CO_2",
+	}))
+	require.NoError(t, store.Put(ctx, STKSchedule{ID: "3", Status: ScheduleStatusCancelled, NextAttemptAt: now}))
+
+	reopened, err := NewFileSTKScheduleStore(path)
+	require.NoError(t, err)
+
+	due, err := reopened.Due(ctx, now, 0)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	require.Equal(t, "2", due[0].ID)
+	require.Equal(t, "1", due[1].ID)
+
+	schedule, ok, err := reopened.GetByCheckoutRequestID(ctx, "ws_CO_2")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "2", schedule.ID)
+
+	_, ok, err = reopened.GetByCheckoutRequestID(ctx, "")
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -0,0 +1,351 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TransactionStatus is the processing state of a Transaction.
+type TransactionStatus string
+
+const (
+	TransactionStatusInitiated TransactionStatus = "Initiated"
+	TransactionStatusPending   TransactionStatus = "Pending"
+	TransactionStatusCompleted TransactionStatus = "Completed"
+	TransactionStatusFailed    TransactionStatus = "Failed"
+	TransactionStatusReversed  TransactionStatus = "Reversed"
+	TransactionStatusTimedOut  TransactionStatus = "TimedOut"
+
+	// TransactionStatusAborted is the status of an STK push the merchant stopped waiting for. Daraja cannot cancel
+	// the prompt, so the customer may still complete it.
+	TransactionStatusAborted TransactionStatus = "Aborted"
+)
+
+// IsTerminal returns true if M-Pesa has sent the final result of the transaction. The only transition allowed out
+// of a terminal status is the reversal of a completed transaction.
+func (s TransactionStatus) IsTerminal() bool {
+	return s == TransactionStatusCompleted || s == TransactionStatusFailed || s == TransactionStatusReversed
+}
+
+// TransactionKind identifies the API that produced a Transaction.
+type TransactionKind string
+
+const (
+	TransactionKindSTKPush         TransactionKind = "STKPush"
+	TransactionKindB2C             TransactionKind = "B2C"
+	TransactionKindC2B             TransactionKind = "C2B"
+	TransactionKindBusinessPayBill TransactionKind = "BusinessPayBill"
+)
+
+// ErrTransactionNotFound is returned by a TransactionStore when the requested transaction does not exist.
+var ErrTransactionNotFound = errors.New("mpesa: transaction not found")
+
+type (
+	// Transaction is a payment tracked by the SDK from the time the request is made until M-Pesa sends the result.
+	Transaction struct {
+		// ID uniquely identifies the transaction. This is the CheckoutRequestID for STK push requests and the
+		// ConversationID for B2C and B2B requests.
+		ID string `db:"id" gorm:"column:id;primaryKey"`
+
+		// Kind is the API used to initiate the transaction.
+		Kind TransactionKind `db:"kind" gorm:"column:kind"`
+
+		// ReceiptNumber is the M-Pesa transaction ID sent to the customer via SMS. It is only set once the
+		// transaction completes.
+		ReceiptNumber string `db:"receipt_number" gorm:"column:receipt_number;index"`
+
+		// ShortCode is the organization shortcode the transaction was made on.
+		ShortCode uint `db:"short_code" gorm:"column:short_code;index:idx_mpesa_transactions_short_code_created_at"`
+
+		// MSISDN is the customer phone number in the format 2547XXXXXXXX.
+		MSISDN uint64 `db:"msisdn" gorm:"column:msisdn"`
+
+		// Amount transacted.
+		Amount float64 `db:"amount" gorm:"column:amount;type:numeric(12,2)"`
+
+		// Balance is the balance of the account debited or credited once the transaction completed, when M-Pesa
+		// sends it.
+		Balance float64 `db:"balance" gorm:"column:balance;type:numeric(14,2)"`
+
+		// Currency of the amount. Empty means DefaultCurrency.
+		Currency Currency `db:"currency" gorm:"column:currency"`
+
+		// AccountReference is the account number or reference the transaction is associated with.
+		AccountReference string `db:"account_reference" gorm:"column:account_reference"`
+
+		// Status is the current processing state of the transaction.
+		Status TransactionStatus `db:"status" gorm:"column:status;index"`
+
+		// ResultCode and ResultDesc hold the result sent by M-Pesa on the callback.
+		ResultCode int    `db:"result_code" gorm:"column:result_code"`
+		ResultDesc string `db:"result_desc" gorm:"column:result_desc"`
+
+		// CreatedAt is the time the transaction was first recorded.
+		CreatedAt time.Time `db:"created_at" gorm:"column:created_at;index:idx_mpesa_transactions_short_code_created_at"`
+
+		// UpdatedAt is the time the transaction was last modified.
+		UpdatedAt time.Time `db:"updated_at" gorm:"column:updated_at"`
+
+		// CompletedAt is the time M-Pesa processed the transaction. It is zero for pending transactions.
+		CompletedAt time.Time `db:"completed_at" gorm:"column:completed_at"`
+	}
+
+	// TransactionFilter narrows down the transactions returned by TransactionStore.List. Zero values are ignored.
+	TransactionFilter struct {
+		// From and To limit the transactions to those created within the range. To is exclusive.
+		From time.Time
+		To   time.Time
+
+		// ShortCode limits the transactions to a single organization shortcode.
+		ShortCode uint
+
+		// Status limits the transactions to those in the provided state.
+		Status TransactionStatus
+	}
+
+	// UpsertResult describes how TransactionStore.Upsert persisted a transaction.
+	UpsertResult struct {
+		// Created is true if no transaction with the same ID existed.
+		Created bool
+
+		// Duplicate is true if the stored transaction had already reached a terminal state with the same outcome, e.g.
+		// when M-Pesa redelivers a callback. The stored transaction is left unchanged.
+		Duplicate bool
+
+		// Conflict is true if the stored transaction cannot move to the incoming status, e.g. because it had already
+		// reached a terminal state with a different outcome. The stored transaction is left unchanged and should be
+		// investigated.
+		Conflict bool
+
+		// Existing is the transaction as it was stored before the upsert. It is nil if Created is true.
+		Existing *Transaction
+	}
+
+	// TransactionStore persists transactions. Implementations must be safe for concurrent use.
+	TransactionStore interface {
+		// Save creates the transaction or replaces an existing one with the same ID.
+		Save(ctx context.Context, txn Transaction) error
+
+		// Upsert creates the transaction or merges the non-zero fields into an existing one with the same ID. The
+		// status of the existing transaction only changes as allowed by TransactionStatus.CanTransitionTo, and a
+		// transaction that has reached a terminal state is only modified by its reversal, so that duplicate callbacks
+		// and replays do not alter the ledger.
+		Upsert(ctx context.Context, txn Transaction) (UpsertResult, error)
+
+		// Get returns the transaction with the provided ID or ErrTransactionNotFound.
+		Get(ctx context.Context, id string) (*Transaction, error)
+
+		// List returns the transactions matching the filter ordered by CreatedAt.
+		List(ctx context.Context, filter TransactionFilter) ([]Transaction, error)
+	}
+)
+
+// Matches returns true if the transaction satisfies every condition set on the filter.
+func (f TransactionFilter) Matches(txn Transaction) bool {
+	if !f.From.IsZero() && txn.CreatedAt.Before(f.From) {
+		return false
+	}
+
+	if !f.To.IsZero() && !txn.CreatedAt.Before(f.To) {
+		return false
+	}
+
+	if f.ShortCode != 0 && txn.ShortCode != f.ShortCode {
+		return false
+	}
+
+	if f.Status != "" && txn.Status != f.Status {
+		return false
+	}
+
+	return true
+}
+
+// MemoryStore is an in-memory TransactionStore. It is useful for tests and single instance deployments where
+// transactions do not need to survive a restart.
+type MemoryStore struct {
+	mu           sync.RWMutex
+	transactions map[string]Transaction
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		transactions: make(map[string]Transaction),
+	}
+}
+
+// Save stores the transaction, replacing any existing transaction with the same ID.
+func (s *MemoryStore) Save(_ context.Context, txn Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.transactions[txn.ID]; ok && txn.CreatedAt.IsZero() {
+		txn.CreatedAt = existing.CreatedAt
+	}
+
+	if txn.CreatedAt.IsZero() {
+		txn.CreatedAt = now
+	}
+
+	txn.UpdatedAt = now
+	s.transactions[txn.ID] = txn
+	return nil
+}
+
+// Upsert creates the transaction or merges it into an existing one with the same ID. The incoming status must be
+// reachable from the stored one, and a completed transaction is only merged with its reversal.
+func (s *MemoryStore) Upsert(_ context.Context, txn Transaction) (UpsertResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	existing, ok := s.transactions[txn.ID]
+	if !ok {
+		if txn.CreatedAt.IsZero() {
+			txn.CreatedAt = now
+		}
+
+		txn.UpdatedAt = now
+		s.transactions[txn.ID] = txn
+		return UpsertResult{Created: true}, nil
+	}
+
+	result := UpsertResult{Existing: &existing}
+	changed := txn.Status != "" && txn.Status != existing.Status
+
+	switch {
+	case changed && !existing.Status.CanTransitionTo(txn.Status):
+		result.Conflict = true
+		return result, nil
+	case !changed && existing.Status.IsTerminal():
+		if isSameOutcome(existing, txn) {
+			result.Duplicate = true
+		} else {
+			result.Conflict = true
+		}
+
+		return result, nil
+	}
+
+	merged := mergeTransaction(existing, txn)
+	merged.UpdatedAt = now
+	s.transactions[txn.ID] = merged
+	return result, nil
+}
+
+// isSameOutcome returns true if the incoming transaction reports the same result as the stored one. Fields not set on
+// the incoming transaction are ignored.
+func isSameOutcome(stored, incoming Transaction) bool {
+	if incoming.Status != "" && incoming.Status != stored.Status {
+		return false
+	}
+
+	if incoming.ReceiptNumber != "" && incoming.ReceiptNumber != stored.ReceiptNumber {
+		return false
+	}
+
+	return incoming.ResultCode == stored.ResultCode
+}
+
+// mergeTransaction returns the stored transaction with the non-zero fields of the incoming transaction applied.
+func mergeTransaction(stored, incoming Transaction) Transaction {
+	if incoming.Kind != "" {
+		stored.Kind = incoming.Kind
+	}
+
+	if incoming.ReceiptNumber != "" {
+		stored.ReceiptNumber = incoming.ReceiptNumber
+	}
+
+	if incoming.ShortCode != 0 {
+		stored.ShortCode = incoming.ShortCode
+	}
+
+	if incoming.MSISDN != 0 {
+		stored.MSISDN = incoming.MSISDN
+	}
+
+	if incoming.Amount != 0 {
+		stored.Amount = incoming.Amount
+	}
+
+	if incoming.Balance != 0 {
+		stored.Balance = incoming.Balance
+	}
+
+	if incoming.Currency != "" {
+		stored.Currency = incoming.Currency
+	}
+
+	if incoming.AccountReference != "" {
+		stored.AccountReference = incoming.AccountReference
+	}
+
+	if incoming.Status != "" {
+		stored.Status = incoming.Status
+	}
+
+	if incoming.ResultCode != 0 || incoming.Status.IsTerminal() {
+		stored.ResultCode = incoming.ResultCode
+	}
+
+	if incoming.ResultDesc != "" {
+		stored.ResultDesc = incoming.ResultDesc
+	}
+
+	if !incoming.CompletedAt.IsZero() {
+		stored.CompletedAt = incoming.CompletedAt
+	}
+
+	return stored
+}
+
+// Get returns the transaction with the provided ID.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	txn, ok := s.transactions[id]
+	if !ok {
+		return nil, ErrTransactionNotFound
+	}
+
+	return &txn, nil
+}
+
+// List returns the transactions matching the filter ordered by CreatedAt.
+func (s *MemoryStore) List(_ context.Context, filter TransactionFilter) ([]Transaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var transactions []Transaction
+	for _, txn := range s.transactions {
+		if filter.Matches(txn) {
+			transactions = append(transactions, txn)
+		}
+	}
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+
+	return transactions, nil
+}
+
+// Delete removes the transactions with the provided IDs. Missing transactions are ignored.
+func (s *MemoryStore) Delete(_ context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.transactions, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package mpesa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = NewMemoryStore()
+		now   = time.Now()
+	)
+
+	transactions := []Transaction{
+		{ID: "ws_CO_1", ShortCode: 174379, Status: TransactionStatusCompleted, CreatedAt: now.Add(-2 * time.Hour)},
+		{ID: "ws_CO_2", ShortCode: 174379, Status: TransactionStatusFailed, CreatedAt: now.Add(-1 * time.Hour)},
+		{ID: "AG_3", ShortCode: 600426, Status: TransactionStatusCompleted, CreatedAt: now},
+	}
+
+	for _, txn := range transactions {
+		require.NoError(t, store.Save(ctx, txn))
+	}
+
+	txn, err := store.Get(ctx, "ws_CO_2")
+	require.NoError(t, err)
+	require.Equal(t, TransactionStatusFailed, txn.Status)
+	require.False(t, txn.UpdatedAt.IsZero())
+
+	_, err = store.Get(ctx, "missing")
+	require.ErrorIs(t, err, ErrTransactionNotFound)
+
+	tests := []struct {
+		name    string
+		filter  TransactionFilter
+		wantIDs []string
+	}{
+		{
+			name:    "it lists all transactions ordered by creation time",
+			wantIDs: []string{"ws_CO_1", "ws_CO_2", "AG_3"},
+		},
+		{
+			name:    "it filters by shortcode",
+			filter:  TransactionFilter{ShortCode: 174379},
+			wantIDs: []string{"ws_CO_1", "ws_CO_2"},
+		},
+		{
+			name:    "it filters by status",
+			filter:  TransactionFilter{Status: TransactionStatusCompleted},
+			wantIDs: []string{"ws_CO_1", "AG_3"},
+		},
+		{
+			name:    "it filters by date range",
+			filter:  TransactionFilter{From: now.Add(-90 * time.Minute), To: now},
+			wantIDs: []string{"ws_CO_2"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := store.List(ctx, tc.filter)
+			require.NoError(t, err)
+
+			var gotIDs []string
+			for _, txn := range got {
+				gotIDs = append(gotIDs, txn.ID)
+			}
+
+			require.Equal(t, tc.wantIDs, gotIDs)
+		})
+	}
+}
+
+func TestMemoryStore_Upsert(t *testing.T) {
+	ctx := context.Background()
+
+	pending := Transaction{
+		ID:               "ws_CO_191220191020363925",
+		Kind:             TransactionKindSTKPush,
+		ShortCode:        174379,
+		MSISDN:           254708374149,
+		Amount:           10,
+		AccountReference: "INV-001",
+		Status:           TransactionStatusPending,
+	}
+
+	completed := Transaction{
+		ID:            "ws_CO_191220191020363925",
+		ReceiptNumber: "NLJ7RT61SV",
+		Status:        TransactionStatusCompleted,
+		ResultDesc:    "The service request is processed successfully.",
+		CompletedAt:   time.Now(),
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, store *MemoryStore)
+	}{
+		{
+			name: "it creates a new transaction",
+			run: func(t *testing.T, store *MemoryStore) {
+				result, err := store.Upsert(ctx, pending)
+				require.NoError(t, err)
+				require.True(t, result.Created)
+				require.Nil(t, result.Existing)
+			},
+		},
+		{
+			name: "it merges the callback into the pending transaction",
+			run: func(t *testing.T, store *MemoryStore) {
+				_, err := store.Upsert(ctx, pending)
+				require.NoError(t, err)
+
+				result, err := store.Upsert(ctx, completed)
+				require.NoError(t, err)
+				require.False(t, result.Created)
+				require.False(t, result.Duplicate)
+				require.False(t, result.Conflict)
+				require.Equal(t, TransactionStatusPending, result.Existing.Status)
+
+				txn, err := store.Get(ctx, pending.ID)
+				require.NoError(t, err)
+				require.Equal(t, TransactionStatusCompleted, txn.Status)
+				require.Equal(t, "NLJ7RT61SV", txn.ReceiptNumber)
+				require.Equal(t, "INV-001", txn.AccountReference)
+				require.Equal(t, float64(10), txn.Amount)
+			},
+		},
+		{
+			name: "it ignores duplicate deliveries of a callback",
+			run: func(t *testing.T, store *MemoryStore) {
+				_, err := store.Upsert(ctx, pending)
+				require.NoError(t, err)
+
+				_, err = store.Upsert(ctx, completed)
+				require.NoError(t, err)
+
+				before, err := store.Get(ctx, pending.ID)
+				require.NoError(t, err)
+
+				result, err := store.Upsert(ctx, completed)
+				require.NoError(t, err)
+				require.True(t, result.Duplicate)
+				require.False(t, result.Conflict)
+
+				after, err := store.Get(ctx, pending.ID)
+				require.NoError(t, err)
+				require.Equal(t, before, after)
+			},
+		},
+		{
+			name: "it reports a conflict without modifying a completed transaction",
+			run: func(t *testing.T, store *MemoryStore) {
+				_, err := store.Upsert(ctx, completed)
+				require.NoError(t, err)
+
+				result, err := store.Upsert(ctx, Transaction{
+					ID:         completed.ID,
+					Status:     TransactionStatusFailed,
+					ResultCode: 1032,
+					ResultDesc: "Request cancelled by user.",
+				})
+				require.NoError(t, err)
+				require.True(t, result.Conflict)
+				require.Equal(t, TransactionStatusCompleted, result.Existing.Status)
+
+				txn, err := store.Get(ctx, completed.ID)
+				require.NoError(t, err)
+				require.Equal(t, TransactionStatusCompleted, txn.Status)
+			},
+		},
+		{
+			name: "it reports a conflict for a status the pending transaction cannot move to",
+			run: func(t *testing.T, store *MemoryStore) {
+				_, err := store.Upsert(ctx, pending)
+				require.NoError(t, err)
+
+				result, err := store.Upsert(ctx, Transaction{ID: pending.ID, Status: TransactionStatusReversed})
+				require.NoError(t, err)
+				require.True(t, result.Conflict)
+
+				_, err = store.Upsert(ctx, Transaction{ID: pending.ID, Status: TransactionStatusTimedOut})
+				require.NoError(t, err)
+
+				result, err = store.Upsert(ctx, Transaction{ID: pending.ID, Status: TransactionStatusPending})
+				require.NoError(t, err)
+				require.True(t, result.Conflict)
+
+				txn, err := store.Get(ctx, pending.ID)
+				require.NoError(t, err)
+				require.Equal(t, TransactionStatusTimedOut, txn.Status)
+			},
+		},
+		{
+			name: "it merges the reversal of a completed transaction",
+			run: func(t *testing.T, store *MemoryStore) {
+				_, err := store.Upsert(ctx, pending)
+				require.NoError(t, err)
+
+				_, err = store.Upsert(ctx, completed)
+				require.NoError(t, err)
+
+				result, err := store.Upsert(ctx, Transaction{
+					ID:         completed.ID,
+					Status:     TransactionStatusReversed,
+					ResultDesc: "The service request is processed successfully.",
+				})
+				require.NoError(t, err)
+				require.False(t, result.Duplicate)
+				require.False(t, result.Conflict)
+				require.Equal(t, TransactionStatusCompleted, result.Existing.Status)
+
+				txn, err := store.Get(ctx, completed.ID)
+				require.NoError(t, err)
+				require.Equal(t, TransactionStatusReversed, txn.Status)
+				require.Equal(t, "NLJ7RT61SV", txn.ReceiptNumber)
+
+				result, err = store.Upsert(ctx, Transaction{ID: completed.ID, Status: TransactionStatusReversed})
+				require.NoError(t, err)
+				require.True(t, result.Duplicate)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tc.run(t, NewMemoryStore())
+		})
+	}
+}
+
+func TestMemoryStore_Delete(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = NewMemoryStore()
+	)
+
+	require.NoError(t, store.Save(ctx, Transaction{ID: "ws_CO_1"}))
+	require.NoError(t, store.Save(ctx, Transaction{ID: "ws_CO_2"}))
+	require.NoError(t, store.Delete(ctx, "ws_CO_1", "missing"))
+
+	_, err := store.Get(ctx, "ws_CO_1")
+	require.ErrorIs(t, err, ErrTransactionNotFound)
+
+	_, err = store.Get(ctx, "ws_CO_2")
+	require.NoError(t, err)
+}
@@ -0,0 +1,275 @@
+package mpesa
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+type (
+	// TransactionSummary aggregates a group of transactions.
+	TransactionSummary struct {
+		// Count is the number of transactions in the group.
+		Count int
+
+		// Amount is the sum of the amounts of all the transactions in the group.
+		Amount float64
+
+		// Pending, Completed, Failed and Reversed are the number of transactions in each status. Pending includes
+		// the transactions that are still being initiated and those that timed out.
+		Pending   int
+		Completed int
+		Failed    int
+		Reversed  int
+
+		// CompletedAmount is the sum of the amounts of the completed transactions.
+		CompletedAmount float64
+	}
+
+	// DailySummary is the summary of the transactions created on a day.
+	DailySummary struct {
+		// Day is midnight of the day in the location used by the TransactionAggregator.
+		Day time.Time
+
+		TransactionSummary
+	}
+
+	// ResultCodeRate is the share of the finished transactions that ended with a result code.
+	ResultCodeRate struct {
+		// ResultCode is the result code sent by M-Pesa.
+		ResultCode int
+
+		// ResultDesc is the description of the most recent transaction with the result code.
+		ResultDesc string
+
+		// Count is the number of transactions that ended with the result code.
+		Count int
+
+		// Rate is Count divided by the number of completed and failed transactions.
+		Rate float64
+	}
+
+	// TransactionAggregator computes summaries over the transactions held in a TransactionStore for building
+	// merchant dashboards and reports.
+	TransactionAggregator struct {
+		// Store is the store the transactions are read from.
+		Store TransactionStore
+
+		// Location is used to determine the day a transaction belongs to. Defaults to time.Local.
+		Location *time.Location
+	}
+)
+
+// Average returns the average amount of the transactions in the group.
+func (s TransactionSummary) Average() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Amount / float64(s.Count)
+}
+
+// FailureRate returns the share of the finished transactions that failed.
+func (s TransactionSummary) FailureRate() float64 {
+	finished := s.Completed + s.Failed
+	if finished == 0 {
+		return 0
+	}
+
+	return float64(s.Failed) / float64(finished)
+}
+
+func (s *TransactionSummary) add(txn Transaction) {
+	s.Count++
+	s.Amount += txn.Amount
+
+	switch txn.Status {
+	case TransactionStatusCompleted:
+		s.Completed++
+		s.CompletedAmount += txn.Amount
+	case TransactionStatusFailed:
+		s.Failed++
+	case TransactionStatusReversed:
+		s.Reversed++
+	default:
+		s.Pending++
+	}
+}
+
+// NewTransactionAggregator creates a TransactionAggregator that reads the transactions from the store.
+func NewTransactionAggregator(store TransactionStore) *TransactionAggregator {
+	return &TransactionAggregator{
+		Store:    store,
+		Location: time.Local,
+	}
+}
+
+// Summary returns the summary of all the transactions matching the filter.
+func (a *TransactionAggregator) Summary(ctx context.Context, filter TransactionFilter) (TransactionSummary, error) {
+	txns, err := a.Store.List(ctx, filter)
+	if err != nil {
+		return TransactionSummary{}, err
+	}
+
+	var summary TransactionSummary
+	for _, txn := range txns {
+		summary.add(txn)
+	}
+
+	return summary, nil
+}
+
+// ByDay returns the summaries of the transactions matching the filter grouped by the day they were created, oldest
+// first. Days without transactions are omitted.
+func (a *TransactionAggregator) ByDay(ctx context.Context, filter TransactionFilter) ([]DailySummary, error) {
+	txns, err := a.Store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := a.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	days := make(map[time.Time]*DailySummary)
+	for _, txn := range txns {
+		createdAt := txn.CreatedAt.In(loc)
+		day := time.Date(createdAt.Year(), createdAt.Month(), createdAt.Day(), 0, 0, 0, 0, loc)
+
+		summary, ok := days[day]
+		if !ok {
+			summary = &DailySummary{Day: day}
+			days[day] = summary
+		}
+
+		summary.add(txn)
+	}
+
+	summaries := make([]DailySummary, 0, len(days))
+	for _, summary := range days {
+		summaries = append(summaries, *summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Day.Before(summaries[j].Day)
+	})
+
+	return summaries, nil
+}
+
+// ByShortCode returns the summaries of the transactions matching the filter grouped by shortcode.
+func (a *TransactionAggregator) ByShortCode(
+	ctx context.Context, filter TransactionFilter,
+) (map[uint]TransactionSummary, error) {
+	txns, err := a.Store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[uint]TransactionSummary)
+	for _, txn := range txns {
+		summary := summaries[txn.ShortCode]
+		summary.add(txn)
+		summaries[txn.ShortCode] = summary
+	}
+
+	return summaries, nil
+}
+
+// ByCurrency returns the summaries of the transactions matching the filter grouped by currency. Use it instead of
+// Summary when the store holds transactions from more than one market as the amounts of different currencies cannot be
+// added up.
+func (a *TransactionAggregator) ByCurrency(
+	ctx context.Context, filter TransactionFilter,
+) (map[Currency]TransactionSummary, error) {
+	txns, err := a.Store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[Currency]TransactionSummary)
+	for _, txn := range txns {
+		currency := txn.Currency.OrDefault()
+
+		summary := summaries[currency]
+		summary.add(txn)
+		summaries[currency] = summary
+	}
+
+	return summaries, nil
+}
+
+// ByStatus returns the summaries of the transactions matching the filter grouped by status.
+func (a *TransactionAggregator) ByStatus(
+	ctx context.Context, filter TransactionFilter,
+) (map[TransactionStatus]TransactionSummary, error) {
+	txns, err := a.Store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[TransactionStatus]TransactionSummary)
+	for _, txn := range txns {
+		summary := summaries[txn.Status]
+		summary.add(txn)
+		summaries[txn.Status] = summary
+	}
+
+	return summaries, nil
+}
+
+// FailureRateByResultCode returns the share of the finished transactions matching the filter that failed with each
+// result code, most frequent first.
+func (a *TransactionAggregator) FailureRateByResultCode(
+	ctx context.Context, filter TransactionFilter,
+) ([]ResultCodeRate, error) {
+	txns, err := a.Store.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		finished int
+		byCode   = make(map[int]*ResultCodeRate)
+		latest   = make(map[int]time.Time)
+	)
+
+	for _, txn := range txns {
+		if txn.Status != TransactionStatusCompleted && txn.Status != TransactionStatusFailed {
+			continue
+		}
+
+		finished++
+		if txn.Status != TransactionStatusFailed {
+			continue
+		}
+
+		rate, ok := byCode[txn.ResultCode]
+		if !ok {
+			rate = &ResultCodeRate{ResultCode: txn.ResultCode}
+			byCode[txn.ResultCode] = rate
+		}
+
+		rate.Count++
+		if !txn.UpdatedAt.Before(latest[txn.ResultCode]) {
+			latest[txn.ResultCode] = txn.UpdatedAt
+			rate.ResultDesc = txn.ResultDesc
+		}
+	}
+
+	rates := make([]ResultCodeRate, 0, len(byCode))
+	for _, rate := range byCode {
+		rate.Rate = float64(rate.Count) / float64(finished)
+		rates = append(rates, *rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Count != rates[j].Count {
+			return rates[i].Count > rates[j].Count
+		}
+		return rates[i].ResultCode < rates[j].ResultCode
+	})
+
+	return rates, nil
+}
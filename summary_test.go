@@ -0,0 +1,95 @@
+package mpesa
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionAggregator(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = NewMemoryStore()
+		day   = time.Date(2023, 10, 1, 9, 0, 0, 0, time.UTC)
+	)
+
+	transactions := []Transaction{
+		{ID: "1", ShortCode: 174379, Amount: 100, Status: TransactionStatusCompleted, CreatedAt: day},
+		{ID: "2", ShortCode: 174379, Amount: 300, Status: TransactionStatusCompleted, CreatedAt: day.Add(time.Hour)},
+		{
+			ID: "3", ShortCode: 174379, Amount: 50, Status: TransactionStatusFailed, ResultCode: 1032,
+			ResultDesc: "Request cancelled by user", CreatedAt: day.Add(2 * time.Hour),
+		},
+		{
+			ID: "4", ShortCode: 600426, Amount: 1000, Status: TransactionStatusFailed, ResultCode: 1,
+			ResultDesc: "The balance is insufficient for the transaction", CreatedAt: day.Add(24 * time.Hour),
+		},
+		{
+			ID: "5", ShortCode: 600426, Amount: 200, Status: TransactionStatusFailed, ResultCode: 1032,
+			ResultDesc: "Request cancelled by user", CreatedAt: day.Add(25 * time.Hour),
+		},
+		{ID: "6", ShortCode: 600426, Amount: 150, Status: TransactionStatusPending, CreatedAt: day.Add(26 * time.Hour)},
+	}
+
+	for _, txn := range transactions {
+		require.NoError(t, store.Save(ctx, txn))
+	}
+
+	aggregator := NewTransactionAggregator(store)
+	aggregator.Location = time.UTC
+
+	t.Run("summary", func(t *testing.T) {
+		summary, err := aggregator.Summary(ctx, TransactionFilter{})
+		require.NoError(t, err)
+		require.Equal(t, 6, summary.Count)
+		require.Equal(t, 1800.0, summary.Amount)
+		require.Equal(t, 300.0, summary.Average())
+		require.Equal(t, 400.0, summary.CompletedAmount)
+		require.Equal(t, 2, summary.Completed)
+		require.Equal(t, 3, summary.Failed)
+		require.Equal(t, 1, summary.Pending)
+		require.Equal(t, 0.6, summary.FailureRate())
+
+		empty, err := aggregator.Summary(ctx, TransactionFilter{ShortCode: 1})
+		require.NoError(t, err)
+		require.Zero(t, empty.Average())
+		require.Zero(t, empty.FailureRate())
+	})
+
+	t.Run("by day", func(t *testing.T) {
+		days, err := aggregator.ByDay(ctx, TransactionFilter{})
+		require.NoError(t, err)
+		require.Len(t, days, 2)
+		require.Equal(t, time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC), days[0].Day)
+		require.Equal(t, 3, days[0].Count)
+		require.Equal(t, time.Date(2023, 10, 2, 0, 0, 0, 0, time.UTC), days[1].Day)
+		require.Equal(t, 1350.0, days[1].Amount)
+	})
+
+	t.Run("by shortcode", func(t *testing.T) {
+		shortCodes, err := aggregator.ByShortCode(ctx, TransactionFilter{})
+		require.NoError(t, err)
+		require.Len(t, shortCodes, 2)
+		require.Equal(t, 450.0, shortCodes[174379].Amount)
+		require.Equal(t, 2, shortCodes[600426].Failed)
+	})
+
+	t.Run("by status", func(t *testing.T) {
+		statuses, err := aggregator.ByStatus(ctx, TransactionFilter{From: day.Add(24 * time.Hour)})
+		require.NoError(t, err)
+		require.Equal(t, 2, statuses[TransactionStatusFailed].Count)
+		require.Equal(t, 150.0, statuses[TransactionStatusPending].Amount)
+		require.NotContains(t, statuses, TransactionStatusCompleted)
+	})
+
+	t.Run("failure rate by result code", func(t *testing.T) {
+		rates, err := aggregator.FailureRateByResultCode(ctx, TransactionFilter{})
+		require.NoError(t, err)
+		require.Equal(t, []ResultCodeRate{
+			{ResultCode: 1032, ResultDesc: "Request cancelled by user", Count: 2, Rate: 0.4},
+			{ResultCode: 1, ResultDesc: "The balance is insufficient for the transaction", Count: 1, Rate: 0.2},
+		}, rates)
+	})
+}
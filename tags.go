@@ -0,0 +1,162 @@
+package mpesa
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Tags are key value pairs that attribute M-Pesa calls to a business entity such as a merchant, order or user.
+type Tags map[string]string
+
+// Well-known tag keys.
+const (
+	TagMerchantID = "merchant_id"
+	TagOrderID    = "order_id"
+	TagUserID     = "user_id"
+)
+
+// tagsContextKey is the context key the Tags are stored under.
+type tagsContextKey struct{}
+
+// WithTags returns a copy of ctx carrying the tags merged with the tags already on ctx. Tags on ctx with the same key
+// are replaced.
+//
+//	ctx = mpesa.WithTags(ctx, mpesa.Tags{mpesa.TagMerchantID: "m_123", mpesa.TagOrderID: "o_456"})
+//	res, err := app.STKPush(ctx, passkey, req)
+func WithTags(ctx context.Context, tags Tags) context.Context {
+	merged := TagsFromContext(ctx)
+	if merged == nil {
+		merged = make(Tags, len(tags))
+	}
+
+	for k, v := range tags {
+		merged[k] = v
+	}
+
+	return context.WithValue(ctx, tagsContextKey{}, merged)
+}
+
+// TagsFromContext returns a copy of the tags carried by ctx, or nil if there are none.
+func TagsFromContext(ctx context.Context) Tags {
+	tags, _ := ctx.Value(tagsContextKey{}).(Tags)
+	return tags.clone()
+}
+
+func (t Tags) clone() Tags {
+	if t == nil {
+		return nil
+	}
+
+	c := make(Tags, len(t))
+	for k, v := range t {
+		c[k] = v
+	}
+
+	return c
+}
+
+// Labels returns the values of the keys in order, with an empty string for the missing keys, for use as the label
+// values of a metric with a fixed set of labels.
+func (t Tags) Labels(keys ...string) []string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = t[key]
+	}
+
+	return values
+}
+
+// LogValue implements slog.LogValuer, logging the tags as a group sorted by key.
+func (t Tags) LogValue() slog.Value {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, len(keys))
+	for i, k := range keys {
+		attrs[i] = slog.String(k, t[k])
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+type (
+	// RequestInfo describes an API request made by the app.
+	RequestInfo struct {
+		// Method and URL of the request.
+		Method string
+		URL    string
+
+		// RequestID is the HeaderRequestID sent on the request.
+		RequestID string
+
+		// StatusCode of the response. It is zero if no response was received.
+		StatusCode int
+
+		// Duration is how long the request took, excluding the access token generation and rate limiting.
+		Duration time.Duration
+
+		// Err is the error that prevented a response from being received.
+		Err error
+
+		// Tags carried by the request context.
+		Tags Tags
+	}
+
+	// RequestHook is called after every API request made by the app with the context of the request. Use it to log,
+	// record metrics or audit the requests.
+	RequestHook func(ctx context.Context, info RequestInfo)
+)
+
+// WithRequestHook makes the app call hook after every API request. The access token requests are not reported.
+func WithRequestHook(hook RequestHook) Option {
+	return func(m *Mpesa) {
+		m.requestHooks = append(m.requestHooks, hook)
+	}
+}
+
+// runRequestHooks reports the request to the hooks of the app.
+func (m *Mpesa) runRequestHooks(ctx context.Context, req *http.Request, res *http.Response, start time.Time, err error) {
+	if len(m.requestHooks) == 0 {
+		return
+	}
+
+	info := RequestInfo{
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		RequestID: req.Header.Get(HeaderRequestID),
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+
+	if res != nil {
+		info.StatusCode = res.StatusCode
+	}
+
+	for _, hook := range m.requestHooks {
+		info.Tags = TagsFromContext(ctx)
+		hook(ctx, info)
+	}
+}
+
+// NewTaggedPublisher returns an EventPublisher that sets the Tags of the events to the tags carried by the publish
+// context before passing them to p. Tags already set on the events are kept.
+func NewTaggedPublisher(p EventPublisher) EventPublisher {
+	return EventPublisherFunc(func(ctx context.Context, event Event) error {
+		tags := TagsFromContext(ctx)
+		for k, v := range event.Tags {
+			if tags == nil {
+				tags = make(Tags, len(event.Tags))
+			}
+			tags[k] = v
+		}
+
+		event.Tags = tags
+		return p.Publish(ctx, event)
+	})
+}
@@ -0,0 +1,112 @@
+package mpesa
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithTags(t *testing.T) {
+	ctx := context.Background()
+	require.Nil(t, TagsFromContext(ctx))
+
+	ctx = WithTags(ctx, Tags{TagMerchantID: "m_1", TagOrderID: "o_1"})
+	child := WithTags(ctx, Tags{TagOrderID: "o_2", TagUserID: "u_1"})
+
+	require.Equal(t, Tags{TagMerchantID: "m_1", TagOrderID: "o_1"}, TagsFromContext(ctx))
+	require.Equal(t, Tags{TagMerchantID: "m_1", TagOrderID: "o_2", TagUserID: "u_1"}, TagsFromContext(child))
+
+	tags := TagsFromContext(child)
+	tags[TagUserID] = "u_2"
+	require.Equal(t, "u_1", TagsFromContext(child)[TagUserID])
+
+	require.Equal(t, []string{"m_1", "", "u_1"}, TagsFromContext(child).Labels(TagMerchantID, "region", TagUserID))
+}
+
+func TestTags_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	logger.Info("stk push", "tags", Tags{TagOrderID: "o_1", TagMerchantID: "m_1"})
+	require.Equal(t, "level=INFO msg=\"stk push\" tags.merchant_id=m_1 tags.order_id=o_1\n", buf.String())
+}
+
+func TestWithRequestHook(t *testing.T) {
+	var (
+		cl    = newMockHttpClient()
+		infos []RequestInfo
+		app   = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox,
+			WithRequestHook(func(_ context.Context, info RequestInfo) {
+				infos = append(infos, info)
+			}),
+		)
+	)
+
+	mockAuth(app, cl)
+
+	cl.MockRequest(app.endpointSTK(), func() (status int, body string) {
+		return http.StatusOK, `{
+			"MerchantRequestID": "29115-34620561-1",
+			"CheckoutRequestID": "ws_CO_191220191020363925",
+			"ResponseCode": "0",
+			"ResponseDescription": "Success. Request accepted for processing",
+			"CustomerMessage": "Success. Request accepted for processing"
+		}`
+	})
+
+	ctx := WithTags(context.Background(), Tags{TagOrderID: "o_1"})
+
+	_, err := app.STKPush(ctx, "passkey", STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyA:            254708374149,
+		PartyB:            174379,
+		PhoneNumber:       254708374149,
+		CallBackURL:       "https://example.com/callback",
+		AccountReference:  "Test",
+		TransactionDesc:   "Test",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, infos, 1)
+	require.Equal(t, http.MethodPost, infos[0].Method)
+	require.Equal(t, app.endpointSTK(), infos[0].URL)
+	require.Equal(t, http.StatusOK, infos[0].StatusCode)
+	require.NoError(t, infos[0].Err)
+	require.Equal(t, Tags{TagOrderID: "o_1"}, infos[0].Tags)
+}
+
+func TestNewTaggedPublisher(t *testing.T) {
+	var published []Event
+
+	publisher := NewTaggedPublisher(EventPublisherFunc(func(_ context.Context, e Event) error {
+		published = append(published, e)
+		return nil
+	}))
+
+	event := testEvent(t)
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	require.Nil(t, published[0].Tags)
+
+	event.Tags = Tags{TagOrderID: "o_2"}
+	ctx := WithTags(context.Background(), Tags{TagMerchantID: "m_1", TagOrderID: "o_1"})
+	require.NoError(t, publisher.Publish(ctx, event))
+	require.Equal(t, Tags{TagMerchantID: "m_1", TagOrderID: "o_2"}, published[1].Tags)
+
+	attrs := published[1].attributes()
+	require.Equal(t, "m_1", attrs["tag_merchant_id"])
+	require.Equal(t, "o_2", attrs["tag_order_id"])
+}
@@ -0,0 +1,385 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Callback paths appended to the tenant callback URL when the TenantManager fills in the callback URLs of a request.
+const (
+	TenantCallbackPathSTKPush = "/stkpush"
+	TenantCallbackPathResult  = "/result"
+	TenantCallbackPathTimeout = "/timeout"
+)
+
+var (
+	// ErrUnknownTenant is returned when a tenant cannot be resolved.
+	ErrUnknownTenant = errors.New("mpesa: unknown tenant")
+
+	// ErrDuplicateTenant is returned when registering a tenant whose ID or shortcode is already registered.
+	ErrDuplicateTenant = errors.New("mpesa: duplicate tenant")
+)
+
+// Tenant holds the credentials and configuration of a merchant served by a TenantManager.
+type Tenant struct {
+	// ID uniquely identifies the tenant. It is used as the first segment of the tenant's callback paths.
+	ID string
+
+	// ConsumerKey and ConsumerSecret are the credentials of the tenant's app on the Daraja portal.
+	ConsumerKey    string
+	ConsumerSecret string
+
+	// Environment is the environment the tenant's app runs on.
+	Environment Environment
+
+	// Currency of the tenant's market. Defaults to DefaultCurrency.
+	Currency Currency
+
+	// ShortCode is the tenant's paybill or till number.
+	ShortCode uint
+
+	// Passkey is used to generate the password for STKPush and STKQuery requests.
+	Passkey string
+
+	// InitiatorName and InitiatorPassword are the credentials of the tenant's API operator.
+	InitiatorName     string
+	InitiatorPassword string
+}
+
+type tenantKey struct{}
+
+// TenantFromContext returns the tenant stored on the context by TenantManager.CallbackHandler.
+func TenantFromContext(ctx context.Context) (Tenant, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(Tenant)
+	return tenant, ok
+}
+
+// TenantManager serves many merchants from one deployment. Every tenant gets its own Mpesa app so that access tokens
+// are never shared between tenants.
+//
+// Callbacks are routed by tenant ID: the callback URLs are CallbackBaseURL + "/" + tenant ID + path and
+// CallbackHandler resolves the tenant from the first path segment.
+type TenantManager struct {
+	client          HttpClient
+	callbackBaseURL string
+	options         []Option
+
+	mu          sync.RWMutex
+	tenants     map[string]Tenant
+	apps        map[string]*Mpesa
+	byShortCode map[uint]string
+}
+
+// NewTenantManager creates a TenantManager whose apps share the provided http client. callbackBaseURL is the public
+// URL the CallbackHandler is mounted on and can be left empty when the callback URLs are always set on the requests.
+// The options are applied to the app of every tenant, e.g. WithRateLimiter to throttle each shortcode independently.
+func NewTenantManager(c HttpClient, callbackBaseURL string, opts ...Option) *TenantManager {
+	return &TenantManager{
+		client:          c,
+		callbackBaseURL: strings.TrimRight(callbackBaseURL, "/"),
+		options:         opts,
+		tenants:         make(map[string]Tenant),
+		apps:            make(map[string]*Mpesa),
+		byShortCode:     make(map[uint]string),
+	}
+}
+
+// Register adds the tenant to the manager.
+func (m *TenantManager) Register(tenant Tenant) error {
+	if tenant.ID == "" {
+		return errors.New("mpesa: tenant ID cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.tenants[tenant.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateTenant, tenant.ID)
+	}
+
+	if tenant.ShortCode != 0 {
+		if _, ok := m.byShortCode[tenant.ShortCode]; ok {
+			return fmt.Errorf("%w: shortcode %d", ErrDuplicateTenant, tenant.ShortCode)
+		}
+		m.byShortCode[tenant.ShortCode] = tenant.ID
+	}
+
+	opts := m.options
+	if tenant.Currency != "" {
+		opts = append(append([]Option{}, m.options...), WithCurrency(tenant.Currency))
+	}
+
+	m.tenants[tenant.ID] = tenant
+	m.apps[tenant.ID] = NewApp(m.client, tenant.ConsumerKey, tenant.ConsumerSecret, tenant.Environment, opts...)
+	return nil
+}
+
+// Remove removes the tenant, stops the background renewal of its access token and discards it.
+func (m *TenantManager) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tenant, ok := m.tenants[id]
+	if !ok {
+		return
+	}
+
+	m.apps[id].Close()
+
+	delete(m.byShortCode, tenant.ShortCode)
+	delete(m.tenants, id)
+	delete(m.apps, id)
+}
+
+// Tenant returns the tenant with the provided ID.
+func (m *TenantManager) Tenant(id string) (Tenant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant, ok := m.tenants[id]
+	if !ok {
+		return Tenant{}, fmt.Errorf("%w: %s", ErrUnknownTenant, id)
+	}
+
+	return tenant, nil
+}
+
+// TenantByShortCode returns the tenant that owns the shortcode.
+func (m *TenantManager) TenantByShortCode(shortCode uint) (Tenant, error) {
+	m.mu.RLock()
+	id, ok := m.byShortCode[shortCode]
+	m.mu.RUnlock()
+
+	if !ok {
+		return Tenant{}, fmt.Errorf("%w: shortcode %d", ErrUnknownTenant, shortCode)
+	}
+
+	return m.Tenant(id)
+}
+
+// App returns the Mpesa app of the tenant with the provided ID.
+func (m *TenantManager) App(id string) (*Mpesa, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	app, ok := m.apps[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTenant, id)
+	}
+
+	return app, nil
+}
+
+func (m *TenantManager) resolve(id string) (Tenant, *Mpesa, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tenant, ok := m.tenants[id]
+	if !ok {
+		return Tenant{}, nil, fmt.Errorf("%w: %s", ErrUnknownTenant, id)
+	}
+
+	return tenant, m.apps[id], nil
+}
+
+// CallbackURL returns the URL that routes the callbacks sent to path to the tenant. It returns an empty string when
+// the manager has no callback base URL.
+func (m *TenantManager) CallbackURL(id, path string) string {
+	if m.callbackBaseURL == "" {
+		return ""
+	}
+
+	return m.callbackBaseURL + "/" + url.PathEscape(id) + path
+}
+
+// CallbackHandler returns a http.Handler that resolves the tenant from the first segment of the request path, strips
+// it and calls next with the tenant stored on the request context. Use TenantFromContext to retrieve it. Requests for
+// unknown tenants are rejected with http.StatusNotFound.
+func (m *TenantManager) CallbackHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+		id, err := url.PathUnescape(id)
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("%w: %v", ErrUnknownTenant, err))
+			return
+		}
+
+		tenant, err := m.Tenant(id)
+		if err != nil {
+			writeAPIError(w, http.StatusNotFound, err)
+			return
+		}
+
+		r2 := r.WithContext(context.WithValue(r.Context(), tenantKey{}, tenant))
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + rest
+		r2.URL.RawPath = ""
+
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// STKPush sends an STK push on behalf of the tenant. The BusinessShortCode, PartyB and CallBackURL are set from the
+// tenant when they are empty.
+func (m *TenantManager) STKPush(ctx context.Context, tenantID string, req STKPushRequest) (*STKPushResponse, error) {
+	tenant, app, err := m.resolve(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BusinessShortCode == 0 {
+		req.BusinessShortCode = tenant.ShortCode
+	}
+
+	if req.PartyB == 0 {
+		req.PartyB = req.BusinessShortCode
+	}
+
+	if req.CallBackURL == "" {
+		req.CallBackURL = m.CallbackURL(tenant.ID, TenantCallbackPathSTKPush)
+	}
+
+	return app.STKPush(ctx, tenant.Passkey, req)
+}
+
+// STKQuery queries the status of an STK push made on behalf of the tenant. The BusinessShortCode is set from the
+// tenant when it is empty.
+func (m *TenantManager) STKQuery(ctx context.Context, tenantID string, req STKQueryRequest) (*STKQueryResponse, error) {
+	tenant, app, err := m.resolve(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.BusinessShortCode == 0 {
+		req.BusinessShortCode = tenant.ShortCode
+	}
+
+	return app.STKQuery(ctx, tenant.Passkey, req)
+}
+
+// B2C sends a B2C payment on behalf of the tenant. The InitiatorName, PartyA, QueueTimeOutURL and ResultURL are set
+// from the tenant when they are empty.
+func (m *TenantManager) B2C(ctx context.Context, tenantID string, req B2CRequest) (*B2CResponse, error) {
+	tenant, app, err := m.resolve(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.InitiatorName == "" {
+		req.InitiatorName = tenant.InitiatorName
+	}
+
+	if req.PartyA == 0 {
+		req.PartyA = tenant.ShortCode
+	}
+
+	if req.QueueTimeOutURL == "" {
+		req.QueueTimeOutURL = m.CallbackURL(tenant.ID, TenantCallbackPathTimeout)
+	}
+
+	if req.ResultURL == "" {
+		req.ResultURL = m.CallbackURL(tenant.ID, TenantCallbackPathResult)
+	}
+
+	return app.B2C(ctx, tenant.InitiatorPassword, req)
+}
+
+// TenantResolver looks up the credentials of a tenant when a request is made on its behalf, e.g. from the database of
+// a platform processing payments for many merchants. Implementations must be safe for concurrent use and should
+// return an error wrapping ErrUnknownTenant for the tenants they do not know.
+type TenantResolver interface {
+	ResolveTenant(ctx context.Context, id string) (Tenant, error)
+}
+
+// TenantResolverFunc is an adapter to allow the use of ordinary functions as a TenantResolver.
+type TenantResolverFunc func(ctx context.Context, id string) (Tenant, error)
+
+// ResolveTenant calls f(ctx, id).
+func (f TenantResolverFunc) ResolveTenant(ctx context.Context, id string) (Tenant, error) {
+	return f(ctx, id)
+}
+
+// ResolveTenant returns the registered tenant with the provided ID, so that a TenantManager can be used as the
+// TenantResolver of an app.
+func (m *TenantManager) ResolveTenant(_ context.Context, id string) (Tenant, error) {
+	return m.Tenant(id)
+}
+
+// WithTenantResolver sets the resolver used by ForTenant to look up the credentials of the tenants.
+func WithTenantResolver(r TenantResolver) Option {
+	return func(m *Mpesa) {
+		m.tenantResolver = r
+	}
+}
+
+// tenantApp is the app created by ForTenant for a tenant, along with the tenant it was created for.
+type tenantApp struct {
+	tenant Tenant
+	app    *Mpesa
+}
+
+// ForTenant resolves the tenant with the TenantResolver of the app and returns an app that makes the requests on its
+// behalf, e.g. app.ForTenant(ctx, "merchant-a").STKPush(ctx, "", req). The returned app shares the options of m but
+// authenticates with the tenant's consumer key, so that its access tokens are cached independently, and uses its
+// shortcode, passkey, initiator and currency as defaults.
+//
+// The app of a tenant is reused until the resolver returns different credentials for the tenant. The requests made
+// with the returned app fail with the error of the resolver if the tenant cannot be resolved.
+func (m *Mpesa) ForTenant(ctx context.Context, tenantID string) *Mpesa {
+	if m.tenantResolver == nil {
+		return m.tenantErrApp(fmt.Errorf("%w: %s: no tenant resolver", ErrUnknownTenant, tenantID))
+	}
+
+	tenant, err := m.tenantResolver.ResolveTenant(ctx, tenantID)
+	if err != nil {
+		return m.tenantErrApp(err)
+	}
+
+	m.tenantsMu.Lock()
+	defer m.tenantsMu.Unlock()
+
+	cached, ok := m.tenantApps[tenantID]
+	if ok && cached.tenant == tenant {
+		return cached.app
+	}
+
+	// The app created for the previous credentials of the tenant is no longer used, so its token renewals must stop.
+	if ok {
+		cached.app.Close()
+	}
+
+	opts := append(append([]Option{}, m.options...), func(app *Mpesa) {
+		app.tokenRequests = m.tokenRequests
+		app.shortCode = tenant.ShortCode
+		app.passkey = tenant.Passkey
+		if tenant.InitiatorName != "" || tenant.InitiatorPassword != "" {
+			app.initiator = InitiatorCredentials{Name: tenant.InitiatorName, Password: tenant.InitiatorPassword}
+		}
+		if tenant.Currency != "" {
+			app.currency = tenant.Currency
+		}
+	})
+
+	app := NewApp(m.client, tenant.ConsumerKey, tenant.ConsumerSecret, tenant.Environment, opts...)
+
+	if m.tenantApps == nil {
+		m.tenantApps = make(map[string]tenantApp)
+	}
+	m.tenantApps[tenantID] = tenantApp{tenant: tenant, app: app}
+
+	return app
+}
+
+// tenantErrApp returns an app whose requests fail with err.
+func (m *Mpesa) tenantErrApp(err error) *Mpesa {
+	app := NewApp(m.client, m.consumerKey, m.consumerSecret, m.environment)
+	app.profileErr = err
+	return app
+}
@@ -0,0 +1,278 @@
+package mpesa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantManager(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		cl      = newMockHttpClient()
+		manager = NewTenantManager(cl, "https://callbacks.example.com/mpesa/")
+	)
+
+	tenants := []Tenant{
+		{
+			ID: "merchant-a", ConsumerKey: "key-a", ConsumerSecret: "secret-a", ShortCode: 174379, Passkey: "passkey-a",
+			InitiatorName: "api-a", InitiatorPassword: "Safaricom999!*!",
+		},
+		{
+			ID: "merchant-b", ConsumerKey: "key-b", ConsumerSecret: "secret-b", ShortCode: 600426, Passkey: "passkey-b",
+			InitiatorName: "api-b", InitiatorPassword: "Safaricom999!*!", Currency: CurrencyTZS,
+		},
+	}
+
+	for _, tenant := range tenants {
+		require.NoError(t, manager.Register(tenant))
+	}
+
+	require.ErrorIs(t, manager.Register(Tenant{ID: "merchant-a"}), ErrDuplicateTenant)
+	require.ErrorIs(t, manager.Register(Tenant{ID: "merchant-c", ShortCode: 600426}), ErrDuplicateTenant)
+
+	appA, err := manager.App("merchant-a")
+	require.NoError(t, err)
+
+	appB, err := manager.App("merchant-b")
+	require.NoError(t, err)
+	require.NotSame(t, appA, appB)
+	require.Equal(t, CurrencyKES, appA.Currency())
+	require.Equal(t, CurrencyTZS, appB.Currency())
+
+	mockAuth(appA, cl)
+
+	cl.MockRequest(appA.endpointSTK(), func() (status int, body string) {
+		var req STKPushRequest
+		require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+
+		switch req.BusinessShortCode {
+		case 174379:
+			require.Equal(t, "https://callbacks.example.com/mpesa/merchant-a/stkpush", req.CallBackURL)
+		case 600426:
+			require.Equal(t, "https://callbacks.example.com/mpesa/merchant-b/stkpush", req.CallBackURL)
+		default:
+			t.Fatalf("unexpected shortcode %d", req.BusinessShortCode)
+		}
+
+		require.Equal(t, req.BusinessShortCode, req.PartyB)
+
+		return http.StatusOK, `
+			{
+			  "MerchantRequestID": "29115-34620561-1",
+			  "CheckoutRequestID": "ws_CO_191220191020363925",
+			  "ResponseCode": "0",
+			  "ResponseDescription": "Success. Request accepted for processing",
+			  "CustomerMessage": "Success. Request accepted for processing"
+			}`
+	})
+
+	stkReq := STKPushRequest{
+		TransactionType:  "CustomerPayBillOnline",
+		Amount:           10,
+		PartyA:           254708374149,
+		PhoneNumber:      254708374149,
+		AccountReference: "Test",
+		TransactionDesc:  "Test",
+	}
+
+	_, err = manager.STKPush(ctx, "merchant-a", stkReq)
+	require.NoError(t, err)
+
+	_, err = manager.STKPush(ctx, "merchant-b", stkReq)
+	require.NoError(t, err)
+
+	for app, keys := range map[*Mpesa][2]string{appA: {"key-a", "key-b"}, appB: {"key-b", "key-a"}} {
+		_, ok, err := app.tokens.Get(ctx, keys[0])
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		_, ok, err = app.tokens.Get(ctx, keys[1])
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	_, err = manager.STKPush(ctx, "merchant-c", stkReq)
+	require.ErrorIs(t, err, ErrUnknownTenant)
+
+	tenant, err := manager.TenantByShortCode(600426)
+	require.NoError(t, err)
+	require.Equal(t, "merchant-b", tenant.ID)
+
+	manager.Remove("merchant-b")
+
+	_, err = manager.TenantByShortCode(600426)
+	require.ErrorIs(t, err, ErrUnknownTenant)
+
+	_, err = manager.App("merchant-b")
+	require.ErrorIs(t, err, ErrUnknownTenant)
+}
+
+func TestTenantManager_Remove(t *testing.T) {
+	manager := NewTenantManager(newMockHttpClient(), "", WithAutoRefreshToken(true))
+	require.NoError(t, manager.Register(Tenant{ID: "merchant-a", ShortCode: 174379}))
+
+	app, err := manager.App("merchant-a")
+	require.NoError(t, err)
+
+	manager.Remove("merchant-a")
+
+	app.tokenRefresher.mu.Lock()
+	defer app.tokenRefresher.mu.Unlock()
+	require.True(t, app.tokenRefresher.closed)
+}
+
+func TestTenantManager_CallbackHandler(t *testing.T) {
+	manager := NewTenantManager(newMockHttpClient(), "")
+	require.NoError(t, manager.Register(Tenant{ID: "merchant-a", ShortCode: 174379}))
+	require.Empty(t, manager.CallbackURL("merchant-a", TenantCallbackPathResult))
+
+	handler := manager.CallbackHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := TenantFromContext(r.Context())
+		require.True(t, ok)
+		require.Equal(t, "merchant-a", tenant.ID)
+		require.Equal(t, TenantCallbackPathSTKPush, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/merchant-a/stkpush", strings.NewReader("{}")))
+	require.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/merchant-b/stkpush", strings.NewReader("{}")))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestMpesa_ForTenant(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		cl      = newMockHttpClient()
+		tenants = map[string]Tenant{
+			"merchant-a": {ID: "merchant-a", ConsumerKey: "key-a", ConsumerSecret: "secret-a", ShortCode: 174379,
+				Passkey: "passkey-a"},
+			"merchant-b": {ID: "merchant-b", ConsumerKey: "key-b", ConsumerSecret: "secret-b", ShortCode: 600426,
+				Passkey: "passkey-b", Currency: CurrencyTZS},
+		}
+		resolved []string
+	)
+
+	resolver := TenantResolverFunc(func(_ context.Context, id string) (Tenant, error) {
+		resolved = append(resolved, id)
+
+		tenant, ok := tenants[id]
+		if !ok {
+			return Tenant{}, ErrUnknownTenant
+		}
+
+		return tenant, nil
+	})
+
+	app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithTenantResolver(resolver))
+
+	mockAuth(app, cl)
+	cl.MockRequest(app.endpointSTK(), func() (int, string) {
+		return http.StatusOK, `{"MerchantRequestID": "29115-34620561-1", ` +
+			`"CheckoutRequestID": "ws_CO_191220191020363925", ` +
+			`"ResponseCode": "0", "ResponseDescription": "Success. Request accepted for processing"}`
+	})
+
+	stkReq := STKPushRequest{
+		TransactionType:  CustomerPayBillOnlineTransactionType,
+		Amount:           10,
+		PartyA:           254708374149,
+		PhoneNumber:      254708374149,
+		CallBackURL:      "https://example.com/stk",
+		AccountReference: "Test",
+		TransactionDesc:  "Test",
+	}
+
+	appA := app.ForTenant(ctx, "merchant-a")
+	_, err := appA.STKPush(ctx, "", stkReq)
+	require.NoError(t, err)
+
+	var req STKPushRequest
+	require.NoError(t, json.NewDecoder(cl.requests[len(cl.requests)-1].Body).Decode(&req))
+	require.Equal(t, uint(174379), req.BusinessShortCode)
+
+	appB := app.ForTenant(ctx, "merchant-b")
+	require.NotSame(t, appA, appB)
+	require.Equal(t, CurrencyTZS, appB.Currency())
+
+	_, err = appB.STKPush(ctx, "", stkReq)
+	require.NoError(t, err)
+
+	for tenantApp, keys := range map[*Mpesa][2]string{appA: {"key-a", "key-b"}, appB: {"key-b", "key-a"}} {
+		_, ok, err := tenantApp.tokens.Get(ctx, keys[0])
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		_, ok, err = tenantApp.tokens.Get(ctx, keys[1])
+		require.NoError(t, err)
+		require.False(t, ok)
+	}
+
+	require.Same(t, appA, app.ForTenant(ctx, "merchant-a"))
+	require.Equal(t, []string{"merchant-a", "merchant-b", "merchant-a"}, resolved)
+
+	tenants["merchant-a"] = Tenant{ID: "merchant-a", ConsumerKey: "key-a2", ConsumerSecret: "secret-a2"}
+	require.NotSame(t, appA, app.ForTenant(ctx, "merchant-a"))
+
+	_, err = app.ForTenant(ctx, "merchant-c").STKPush(ctx, "", stkReq)
+	require.ErrorIs(t, err, ErrUnknownTenant)
+
+	_, err = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox).ForTenant(ctx, "merchant-a").
+		STKPush(ctx, "", stkReq)
+	require.ErrorIs(t, err, ErrUnknownTenant)
+}
+
+func TestMpesa_ForTenantStopsRefreshingRotatedCredentials(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		cl     = newMockHttpClient()
+		tenant = Tenant{ID: "merchant-a", ConsumerKey: "key-a", ConsumerSecret: "secret-a", ShortCode: 174379}
+	)
+
+	resolver := TenantResolverFunc(func(context.Context, string) (Tenant, error) {
+		return tenant, nil
+	})
+
+	app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithTenantResolver(resolver),
+		WithAutoRefreshToken(true))
+	t.Cleanup(app.Close)
+
+	mockAuth(app, cl)
+
+	oldApp := app.ForTenant(ctx, "merchant-a")
+	_, err := oldApp.GenerateAccessToken(ctx)
+	require.NoError(t, err)
+
+	oldApp.tokenRefresher.mu.Lock()
+	require.NotNil(t, oldApp.tokenRefresher.timer)
+	require.False(t, oldApp.tokenRefresher.closed)
+	oldApp.tokenRefresher.mu.Unlock()
+
+	tenant.ConsumerKey, tenant.ConsumerSecret = "key-a2", "secret-a2"
+
+	newApp := app.ForTenant(ctx, "merchant-a")
+	require.NotSame(t, oldApp, newApp)
+
+	_, err = newApp.GenerateAccessToken(ctx)
+	require.NoError(t, err)
+
+	oldApp.tokenRefresher.mu.Lock()
+	require.True(t, oldApp.tokenRefresher.closed)
+	require.False(t, oldApp.tokenRefresher.timer.Stop())
+	oldApp.tokenRefresher.mu.Unlock()
+
+	app.Close()
+
+	newApp.tokenRefresher.mu.Lock()
+	require.True(t, newApp.tokenRefresher.closed)
+	newApp.tokenRefresher.mu.Unlock()
+}
@@ -0,0 +1,114 @@
+package mpesa
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// tokenRefreshAhead is how long before its expiry an access token is refreshed by WithAutoRefreshToken.
+	tokenRefreshAhead = 2 * time.Minute
+
+	// tokenRefreshRetryInterval is the time between the attempts to refresh an access token after a failure.
+	tokenRefreshRetryInterval = 30 * time.Second
+)
+
+// tokenRefresher renews the access token of an app shortly before it expires.
+type tokenRefresher struct {
+	mu        sync.Mutex
+	ahead     time.Duration
+	timer     *time.Timer
+	expiresAt time.Time
+	closed    bool
+}
+
+// WithAutoRefreshToken makes the app renew its access token in the background 2 minutes before it expires, so that
+// the requests never wait for a new token once the first one was generated. A failed renewal is retried every 30
+// seconds until the token expires. Call Close to stop the renewals once the app is no longer used.
+func WithAutoRefreshToken(enabled bool) Option {
+	return func(m *Mpesa) {
+		if !enabled {
+			m.tokenRefresher = nil
+			return
+		}
+
+		m.tokenRefresher = &tokenRefresher{ahead: tokenRefreshAhead}
+	}
+}
+
+// Close stops the background renewal of the access tokens started by WithAutoRefreshToken for the app, its shortcode
+// profiles and the apps created by ForTenant. The app can still be used and requests new tokens when needed.
+func (m *Mpesa) Close() {
+	if r := m.tokenRefresher; r != nil {
+		r.mu.Lock()
+		r.closed = true
+		if r.timer != nil {
+			r.timer.Stop()
+		}
+		r.mu.Unlock()
+	}
+
+	m.profilesMu.RLock()
+	for _, profile := range m.profiles {
+		profile.Close()
+	}
+	m.profilesMu.RUnlock()
+
+	m.tenantsMu.Lock()
+	defer m.tenantsMu.Unlock()
+
+	for _, cached := range m.tenantApps {
+		cached.app.Close()
+	}
+}
+
+// scheduleTokenRefresh schedules the renewal of the access token cached until expiresAt.
+func (m *Mpesa) scheduleTokenRefresh(expiresAt time.Time) {
+	r := m.tokenRefresher
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expiresAt = expiresAt
+	r.schedule(time.Until(expiresAt)-r.ahead, m.refreshAccessToken)
+}
+
+// refreshAccessToken requests a new access token and schedules its renewal, or retries later on failure.
+func (m *Mpesa) refreshAccessToken() {
+	ctx := context.Background()
+
+	token, expiresAt, err := m.tokenRequests.do(ctx, m.consumerKey, m.fetchAccessToken)
+	if err == nil {
+		m.cacheAccessToken(ctx, token, expiresAt)
+		return
+	}
+
+	r := m.tokenRefresher
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Once the token expires, the next request generates a new one and schedules its renewal.
+	if time.Until(r.expiresAt) < tokenRefreshRetryInterval {
+		return
+	}
+
+	r.schedule(tokenRefreshRetryInterval, m.refreshAccessToken)
+}
+
+// schedule calls fn after d, replacing the call scheduled before. It must be called with mu held.
+func (r *tokenRefresher) schedule(d time.Duration, fn func()) {
+	if r.closed {
+		return
+	}
+
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+
+	r.timer = time.AfterFunc(d, fn)
+}
@@ -0,0 +1,75 @@
+package mpesa
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMpesa_WithAutoRefreshToken(t *testing.T) {
+	ctx := context.Background()
+
+	newApp := func(t *testing.T, enabled bool) (*Mpesa, *atomic.Int32) {
+		var (
+			cl       = newMockHttpClient()
+			app      = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithAutoRefreshToken(enabled))
+			requests atomic.Int32
+		)
+
+		cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+			n := requests.Add(1)
+			return http.StatusOK, fmt.Sprintf(`{"access_token": "token-%d", "expires_in": "3599"}`, n)
+		})
+
+		if app.tokenRefresher != nil {
+			app.tokenRefresher.ahead = 3599*time.Second - accessTokenExpiryMargin - 20*time.Millisecond
+		}
+
+		t.Cleanup(app.Close)
+		return app, &requests
+	}
+
+	t.Run("it refreshes the token before it expires", func(t *testing.T) {
+		app, requests := newApp(t, true)
+
+		token, err := app.GenerateAccessToken(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "token-1", token)
+
+		require.Eventually(t, func() bool {
+			return requests.Load() >= 2
+		}, time.Second, time.Millisecond)
+
+		require.Eventually(t, func() bool {
+			token, ok, _ := app.tokens.Get(ctx, app.consumerKey)
+			return ok && token != "token-1"
+		}, time.Second, time.Millisecond)
+	})
+
+	t.Run("it stops refreshing the token once closed", func(t *testing.T) {
+		app, requests := newApp(t, true)
+
+		_, err := app.GenerateAccessToken(ctx)
+		require.NoError(t, err)
+
+		app.Close()
+		time.Sleep(100 * time.Millisecond)
+		require.EqualValues(t, 1, requests.Load())
+	})
+
+	t.Run("it does not refresh the token when disabled", func(t *testing.T) {
+		app, requests := newApp(t, false)
+		require.Nil(t, app.tokenRefresher)
+
+		_, err := app.GenerateAccessToken(ctx)
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+		require.EqualValues(t, 1, requests.Load())
+	})
+}
@@ -0,0 +1,160 @@
+package mpesa
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// TokenStore caches the access tokens keyed by consumer key. Implement it on top of a shared store such as Redis
+	// or a database so that the app instances of a deployment share the token instead of each re-authenticating.
+	// Implementations must be safe for concurrent use.
+	TokenStore interface {
+		// Get returns the cached token of the consumer key. It returns false if there is none or it expired.
+		Get(ctx context.Context, consumerKey string) (string, bool, error)
+
+		// Set caches the token of the consumer key for ttl.
+		Set(ctx context.Context, consumerKey, token string, ttl time.Duration) error
+
+		// Delete removes the token of the consumer key, e.g. once Daraja rejected it.
+		Delete(ctx context.Context, consumerKey string) error
+	}
+
+	// TokenExpiryStore is a TokenStore that also returns when the cached tokens expire, which Mpesa.Token reports.
+	TokenExpiryStore interface {
+		TokenStore
+
+		// GetWithExpiry returns the cached token of the consumer key and its expiry. It returns false if there is
+		// none or it expired.
+		GetWithExpiry(ctx context.Context, consumerKey string) (string, time.Time, bool, error)
+	}
+
+	// AccessToken is an access token returned by Mpesa.Token along with the time it stops being used.
+	AccessToken struct {
+		Token     string
+		ExpiresAt time.Time
+	}
+
+	// MemoryTokenStore is an in-memory TokenStore. It is the default store of an app.
+	MemoryTokenStore struct {
+		mu      sync.Mutex
+		entries map[string]tokenStoreEntry
+		now     func() time.Time
+	}
+
+	tokenStoreEntry struct {
+		token     string
+		expiresAt time.Time
+	}
+)
+
+// NewMemoryTokenStore creates an empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		entries: make(map[string]tokenStoreEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached token of the consumer key.
+func (s *MemoryTokenStore) Get(ctx context.Context, consumerKey string) (string, bool, error) {
+	token, _, ok, err := s.GetWithExpiry(ctx, consumerKey)
+	return token, ok, err
+}
+
+// GetWithExpiry returns the cached token of the consumer key and its expiry.
+func (s *MemoryTokenStore) GetWithExpiry(_ context.Context, consumerKey string) (string, time.Time, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[consumerKey]
+	if !ok {
+		return "", time.Time{}, false, nil
+	}
+
+	if !s.now().Before(entry.expiresAt) {
+		delete(s.entries, consumerKey)
+		return "", time.Time{}, false, nil
+	}
+
+	return entry.token, entry.expiresAt, true, nil
+}
+
+// Set caches the token of the consumer key for ttl.
+func (s *MemoryTokenStore) Set(_ context.Context, consumerKey, token string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[consumerKey] = tokenStoreEntry{token: token, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+// Delete removes the token of the consumer key.
+func (s *MemoryTokenStore) Delete(_ context.Context, consumerKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, consumerKey)
+	return nil
+}
+
+// WithTokenStore makes the app cache its access tokens in the store instead of in memory. Tokens are cached until 5
+// minutes before the expires_in returned by Daraja, or for 55 minutes when it does not return a valid one.
+func WithTokenStore(store TokenStore) Option {
+	return func(m *Mpesa) {
+		m.tokens = store
+	}
+}
+
+// tokenRequestGroup makes sure that a single access token request is in flight per consumer key. The apps created by
+// RegisterShortcode share the group of their parent.
+type tokenRequestGroup struct {
+	mu       sync.Mutex
+	inFlight map[string]*tokenRequest
+}
+
+// tokenRequest is an access token request shared by the callers awaiting it.
+type tokenRequest struct {
+	done      chan struct{}
+	token     string
+	expiresAt time.Time
+	err       error
+}
+
+func newTokenRequestGroup() *tokenRequestGroup {
+	return &tokenRequestGroup{inFlight: make(map[string]*tokenRequest)}
+}
+
+// do calls fn unless a call for the consumer key is already in flight, and returns its result to every caller, which
+// caches the token in its own TokenStore. fn is not cancelled when the caller that started it gives up, as the others
+// are still waiting for the token, so it should be bounded by the timeout of the client. A caller stops waiting once
+// its context is done.
+func (g *tokenRequestGroup) do(
+	ctx context.Context, consumerKey string, fn func(ctx context.Context) (string, time.Time, error),
+) (string, time.Time, error) {
+	g.mu.Lock()
+	req, ok := g.inFlight[consumerKey]
+	if !ok {
+		req = &tokenRequest{done: make(chan struct{})}
+		g.inFlight[consumerKey] = req
+
+		go func() {
+			req.token, req.expiresAt, req.err = fn(context.WithoutCancel(ctx))
+
+			g.mu.Lock()
+			delete(g.inFlight, consumerKey)
+			g.mu.Unlock()
+
+			close(req.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-req.done:
+		return req.token, req.expiresAt, req.err
+	case <-ctx.Done():
+		return "", time.Time{}, ctx.Err()
+	}
+}
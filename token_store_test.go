@@ -0,0 +1,261 @@
+package mpesa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// cachedAccessToken returns the token cached by the app.
+func cachedAccessToken(t *testing.T, app *Mpesa) string {
+	t.Helper()
+
+	token, ok, err := app.tokens.Get(context.Background(), app.consumerKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	return token
+}
+
+type failingTokenStore struct{}
+
+func (failingTokenStore) Get(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("store unavailable")
+}
+
+func (failingTokenStore) Set(context.Context, string, string, time.Duration) error {
+	return errors.New("store unavailable")
+}
+
+func (failingTokenStore) Delete(context.Context, string) error {
+	return errors.New("store unavailable")
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		now   = time.Now()
+		store = NewMemoryTokenStore()
+	)
+
+	store.now = func() time.Time { return now }
+
+	_, ok, err := store.Get(ctx, testConsumerKey)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, store.Set(ctx, testConsumerKey, "0A0v8OgxqqoocblflR58m9chMdnU", time.Minute))
+
+	token, ok, err := store.Get(ctx, testConsumerKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+
+	token, expiresAt, ok, err := store.GetWithExpiry(ctx, testConsumerKey)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+	require.Equal(t, now.Add(time.Minute), expiresAt)
+
+	require.NoError(t, store.Delete(ctx, testConsumerKey))
+
+	_, ok, err = store.Get(ctx, testConsumerKey)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, store.Set(ctx, testConsumerKey, "0A0v8OgxqqoocblflR58m9chMdnU", time.Minute))
+	now = now.Add(time.Minute)
+
+	_, ok, err = store.Get(ctx, testConsumerKey)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestWithTokenStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it shares the token between apps using the same store", func(t *testing.T) {
+		var (
+			store = NewMemoryTokenStore()
+			cl    = newMockHttpClient()
+			calls int
+		)
+
+		appA := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithTokenStore(store))
+		appB := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithTokenStore(store))
+
+		cl.MockRequest(appA.endpointAuth(), func() (status int, body string) {
+			calls++
+			return http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`
+		})
+
+		tokenA, err := appA.GenerateAccessToken(ctx)
+		require.NoError(t, err)
+
+		tokenB, err := appB.GenerateAccessToken(ctx)
+		require.NoError(t, err)
+
+		require.Equal(t, tokenA, tokenB)
+		require.Equal(t, 1, calls)
+	})
+
+	t.Run("it generates a new token if the store fails", func(t *testing.T) {
+		cl := newMockHttpClient()
+		app := NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox, WithTokenStore(failingTokenStore{}))
+
+		cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+			return http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`
+		})
+
+		token, err := app.GenerateAccessToken(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+	})
+}
+
+func TestMpesa_ReAuthenticatesOnInvalidAccessToken(t *testing.T) {
+	ctx := context.Background()
+
+	queryReq := STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"}
+
+	newApp := func() (*Mpesa, *mockHttpClient, *int) {
+		var (
+			cl        = newMockHttpClient()
+			app       = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			authCalls int
+			tokens    = []string{"0A0v8OgxqqoocblflR58m9chMdnU", "R58m9chMdnU0A0v8Ogxqqoocblfl"}
+		)
+
+		cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+			token := tokens[authCalls%len(tokens)]
+			authCalls++
+			return http.StatusOK, `{"access_token": "` + token + `", "expires_in": "3599"}`
+		})
+
+		return app, cl, &authCalls
+	}
+
+	t.Run("it regenerates the token and retries the request once", func(t *testing.T) {
+		app, cl, authCalls := newApp()
+
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			if cl.requests[len(cl.requests)-1].Header.Get("Authorization") == "Bearer 0A0v8OgxqqoocblflR58m9chMdnU" {
+				return http.StatusNotFound, `{"requestId": "1", "errorCode": "404.001.03", "errorMessage": "Invalid Access Token"}`
+			}
+			return http.StatusOK, `{"ResponseCode": "0", "ResultCode": "0"}`
+		})
+
+		res, err := app.STKQuery(ctx, "passkey", queryReq)
+		require.NoError(t, err)
+		require.Equal(t, "0", res.ResultCode)
+		require.Equal(t, 2, *authCalls)
+		require.Equal(t, "R58m9chMdnU0A0v8Ogxqqoocblfl", cachedAccessToken(t, app))
+	})
+
+	t.Run("it returns the error if the new token is rejected too", func(t *testing.T) {
+		app, cl, authCalls := newApp()
+
+		calls := 0
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			calls++
+			return http.StatusUnauthorized, `{"requestId": "1", "errorCode": "401.002.01", "errorMessage": "Invalid Access Token"}`
+		})
+
+		_, err := app.STKQuery(ctx, "passkey", queryReq)
+
+		var apiErr *Error
+		require.True(t, errors.As(err, &apiErr))
+		require.True(t, apiErr.IsInvalidAccessToken())
+		require.Equal(t, 2, calls)
+		require.Equal(t, 2, *authCalls)
+	})
+
+	t.Run("it does not regenerate the token on other errors", func(t *testing.T) {
+		app, cl, authCalls := newApp()
+
+		cl.MockRequest(app.endpointSTKQuery(), func() (status int, body string) {
+			return http.StatusNotFound, `{"requestId": "1", "errorCode": "404.001.01", "errorMessage": "Resource not found"}`
+		})
+
+		_, err := app.STKQuery(ctx, "passkey", queryReq)
+		require.ErrorContains(t, err, "404.001.01")
+		require.Equal(t, 1, *authCalls)
+	})
+}
+
+func TestMpesa_GenerateAccessTokenConcurrently(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("it makes a single request for the concurrent callers", func(t *testing.T) {
+		var (
+			cl      = newMockHttpClient()
+			app     = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			release = make(chan struct{})
+			calls   int32
+		)
+
+		app.RegisterShortcode("c2b", ShortcodeProfile{ShortCode: 174379})
+		profile := app.WithShortcode("c2b")
+
+		cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			client := app
+			if i%2 == 0 {
+				client = profile
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				token, err := client.GenerateAccessToken(ctx)
+				require.NoError(t, err)
+				require.Equal(t, "0A0v8OgxqqoocblflR58m9chMdnU", token)
+			}()
+		}
+
+		require.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("it stops waiting once the context is done", func(t *testing.T) {
+		var (
+			cl      = newMockHttpClient()
+			app     = NewApp(cl, testConsumerKey, testConsumerSecret, EnvironmentSandbox)
+			release = make(chan struct{})
+		)
+
+		cl.MockRequest(app.endpointAuth(), func() (status int, body string) {
+			<-release
+			return http.StatusOK, `{"access_token": "0A0v8OgxqqoocblflR58m9chMdnU", "expires_in": "3599"}`
+		})
+
+		waitCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		_, err := app.GenerateAccessToken(waitCtx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		// The request carries on for the other callers and caches the token.
+		close(release)
+		require.Eventually(t, func() bool {
+			_, ok, _ := app.tokens.Get(ctx, app.consumerKey)
+			return ok
+		}, time.Second, time.Millisecond)
+	})
+}
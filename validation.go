@@ -0,0 +1,360 @@
+package mpesa
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Limits of the STKPushRequest fields.
+const (
+	maxAccountReferenceLength = 12
+	maxTransactionDescLength  = 13
+)
+
+// Limits of the DynamicQRRequest size.
+const (
+	minDynamicQRSize = 100
+	maxDynamicQRSize = 1000
+)
+
+// ErrInvalidRequest indicates that a request failed validation. The returned error is a *ValidationError listing the
+// invalid fields.
+var ErrInvalidRequest = errors.New("mpesa: invalid request")
+
+type (
+	// FieldError describes why a field of a request is invalid.
+	FieldError struct {
+		// Field is the name of the field, e.g. PhoneNumber.
+		Field string `json:"field"`
+
+		// Message describes the problem, e.g. "must be in the format 2547XXXXXXXX".
+		Message string `json:"message"`
+	}
+
+	// ValidationError is returned when a request is rejected before it is sent to Daraja.
+	ValidationError struct {
+		Fields []FieldError `json:"fields"`
+	}
+
+	// validator collects the FieldError of a request.
+	validator struct {
+		fields []FieldError
+	}
+)
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		msgs[i] = field.Error()
+	}
+
+	return ErrInvalidRequest.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+// Unwrap makes errors.Is match ErrInvalidRequest.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidRequest
+}
+
+// ValidationErrors returns one error per invalid field of the request that failed validation with err, e.g. the error
+// returned by STKPush, like the Validate method of the request. It returns nil if err is not a *ValidationError.
+func ValidationErrors(err error) []error {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil
+	}
+
+	errs := make([]error, len(validationErr.Fields))
+	for i, field := range validationErr.Fields {
+		errs[i] = field
+	}
+
+	return errs
+}
+
+func (v *validator) add(field, format string, args ...interface{}) {
+	v.fields = append(v.fields, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// shortCode checks that the value is a 5 to 7 digit shortcode.
+func (v *validator) shortCode(field string, value uint) {
+	if n := len(strconv.FormatUint(uint64(value), 10)); value == 0 || n < 5 || n > 7 {
+		v.add(field, "must be a 5 to 7 digit shortcode")
+	}
+}
+
+// phoneNumber checks that the value is a Safaricom phone number in the format 2547XXXXXXXX or 2541XXXXXXXX.
+func (v *validator) phoneNumber(field string, value uint64) {
+	s := strconv.FormatUint(value, 10)
+	if len(s) != 12 || !strings.HasPrefix(s, "254") || (s[3] != '7' && s[3] != '1') {
+		v.add(field, "must be in the format 2547XXXXXXXX or 2541XXXXXXXX")
+	}
+}
+
+// identifierType checks that the value is one of the allowed identifier types. Zero is allowed as the methods default
+// it to ShortcodeIdentifierType.
+func (v *validator) identifierType(field string, value IdentifierType, allowed ...IdentifierType) {
+	if value == 0 {
+		return
+	}
+
+	names := make([]string, len(allowed))
+	for i, identifierType := range allowed {
+		if value == identifierType {
+			return
+		}
+		names[i] = strconv.Itoa(int(identifierType))
+	}
+
+	v.add(field, "must be one of %s", strings.Join(names, ", "))
+}
+
+func (v *validator) amount(field string, value uint) {
+	if value == 0 {
+		v.add(field, "must be greater than 0")
+	}
+}
+
+func (v *validator) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+	}
+}
+
+func (v *validator) maxLength(field, value string, max int) {
+	if n := len([]rune(value)); n == 0 || n > max {
+		v.add(field, "must be between 1 and %d characters", max)
+	}
+}
+
+func (v *validator) url(field, value string) {
+	if err := validateURL(value); err != nil {
+		v.add(field, "must be a valid %s URL", requiredURLScheme)
+	}
+}
+
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: v.fields}
+}
+
+// errs returns one FieldError per invalid field.
+func (v *validator) errs() []error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(v.fields))
+	for i, field := range v.fields {
+		errs[i] = field
+	}
+
+	return errs
+}
+
+// validate returns a *ValidationError listing the problems found by the Validate method of the request, or nil if it
+// is valid. The Validate method of every request returns one error per field that Daraja would reject, so that they
+// can all be surfaced at once, and nil if the request is valid.
+func validate(req interface{ Validate() []error }) error {
+	var v validator
+	for _, err := range req.Validate() {
+		var field FieldError
+		if !errors.As(err, &field) {
+			field = FieldError{Message: err.Error()}
+		}
+		v.fields = append(v.fields, field)
+	}
+
+	return v.err()
+}
+
+// Validate checks the shortcodes, phone numbers, amount and CallBackURL, and the length limits of the AccountReference
+// and TransactionDesc.
+func (r STKPushRequest) Validate() []error {
+	var v validator
+
+	v.shortCode("BusinessShortCode", r.BusinessShortCode)
+	v.amount("Amount", r.Amount)
+	v.phoneNumber("PartyA", uint64(r.PartyA))
+	v.shortCode("PartyB", r.PartyB)
+	v.phoneNumber("PhoneNumber", r.PhoneNumber)
+	v.url("CallBackURL", r.CallBackURL)
+	v.maxLength("AccountReference", r.AccountReference, maxAccountReferenceLength)
+	v.maxLength("TransactionDesc", r.TransactionDesc, maxTransactionDescLength)
+
+	return v.errs()
+}
+
+// Validate checks the amount, the PartyA shortcode, the PartyB phone number and the result URLs.
+func (r B2CRequest) Validate() []error {
+	var v validator
+
+	v.amount("Amount", r.Amount)
+	v.shortCode("PartyA", r.PartyA)
+	v.phoneNumber("PartyB", r.PartyB)
+	v.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	v.url("ResultURL", r.ResultURL)
+
+	return v.errs()
+}
+
+// Validate checks the amount, the PartyA and PartyB shortcodes, the optional Requester phone number and the result
+// URLs.
+func (r BusinessPayBillRequest) Validate() []error {
+	var v validator
+
+	v.amount("Amount", r.Amount)
+	v.shortCode("PartyA", r.PartyA)
+	v.shortCode("PartyB", r.PartyB)
+	if r.Requester != 0 {
+		v.phoneNumber("Requester", uint64(r.Requester))
+	}
+	v.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	v.url("ResultURL", r.ResultURL)
+
+	return v.errs()
+}
+
+// Validate checks the amount, the PartyA and PartyB shortcodes, that the receiver is a till or a shortcode, the
+// optional Requester phone number and the result URLs.
+func (r BusinessBuyGoodsRequest) Validate() []error {
+	var v validator
+
+	v.amount("Amount", r.Amount)
+	v.shortCode("PartyA", r.PartyA)
+	v.shortCode("PartyB", r.PartyB)
+	v.identifierType("RecieverIdentifierType", r.RecieverIdentifierType, MerchantTillIdentifierType,
+		ShortcodeIdentifierType)
+	if r.Requester != 0 {
+		v.phoneNumber("Requester", uint64(r.Requester))
+	}
+	v.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	v.url("ResultURL", r.ResultURL)
+
+	return v.errs()
+}
+
+// Validate checks the BusinessShortCode and that the CheckoutRequestID is set.
+func (r STKQueryRequest) Validate() []error {
+	var v validator
+
+	v.shortCode("BusinessShortCode", r.BusinessShortCode)
+	v.required("CheckoutRequestID", r.CheckoutRequestID)
+
+	return v.errs()
+}
+
+// Validate checks the ShortCode, ResponseType, APIVersion and ConfirmationURL, and the ValidationURL unless
+// ConfirmationOnly is set.
+func (r RegisterC2BURLRequest) Validate() []error {
+	var v validator
+
+	v.shortCode("ShortCode", r.ShortCode)
+	if r.ResponseType != ResponseTypeComplete && r.ResponseType != ResponseTypeCanceled {
+		v.add("ResponseType", "must be %s or %s", ResponseTypeComplete, ResponseTypeCanceled)
+	}
+	v.url("ConfirmationURL", r.ConfirmationURL)
+	if !r.ConfirmationOnly || r.ValidationURL != "" {
+		v.url("ValidationURL", r.ValidationURL)
+	}
+	switch r.APIVersion {
+	case "", C2BRegisterAPIVersion1, C2BRegisterAPIVersion2:
+	default:
+		v.add("APIVersion", "must be %s or %s", C2BRegisterAPIVersion1, C2BRegisterAPIVersion2)
+	}
+
+	return v.errs()
+}
+
+// Validate checks the required fields, the Size and that the CreditPartyIdentifier matches the TransactionType: a
+// phone number when sending money and a shortcode otherwise.
+func (r DynamicQRRequest) Validate() []error {
+	var v validator
+
+	v.amount("Amount", r.Amount)
+	v.required("CreditPartyIdentifier", r.CreditPartyIdentifier)
+	v.required("MerchantName", r.MerchantName)
+	v.required("ReferenceNo", r.ReferenceNo)
+	if r.Size != 0 && (r.Size < minDynamicQRSize || r.Size > maxDynamicQRSize) {
+		v.add("Size", "must be between %d and %d", minDynamicQRSize, maxDynamicQRSize)
+	}
+
+	cpi := r.CreditPartyIdentifier
+	switch r.TransactionType {
+	case SendMoneyViaMobileNumber, SentToBusiness:
+		if _, err := ParsePhoneNumber(cpi); cpi != "" && err != nil {
+			v.add("CreditPartyIdentifier", "must be a phone number for %s transactions", r.TransactionType)
+		}
+	case PayMerchantBuyGoods, PaybillOrBusinessNumber, WithdrawCashAtAgentTill:
+		if cpi != "" {
+			n, _ := strconv.ParseUint(cpi, 10, 64)
+			v.shortCode("CreditPartyIdentifier", uint(n))
+		}
+	default:
+		v.add("TransactionType", "must be one of BG, PB, SM, SB or WA")
+	}
+
+	return v.errs()
+}
+
+// Validate requires a TransactionID or an OriginatorConversationID, and checks that PartyA is a phone number for the
+// MSISDNIdentifierType and a shortcode otherwise.
+func (r TransactionStatusRequest) Validate() []error {
+	var v validator
+
+	if r.TransactionID == "" && r.OriginatorConversationID == "" {
+		v.add("TransactionID", "or OriginatorConversationID is required")
+	}
+	v.identifierType("IdentifierType", r.IdentifierType, MSISDNIdentifierType, TillNumberIdentifierType,
+		ShortcodeIdentifierType)
+	switch {
+	case r.PartyA == 0:
+		v.add("PartyA", "is required")
+	case r.IdentifierType == MSISDNIdentifierType:
+		v.phoneNumber("PartyA", uint64(r.PartyA))
+	default:
+		v.shortCode("PartyA", r.PartyA)
+	}
+	v.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	v.url("ResultURL", r.ResultURL)
+
+	return v.errs()
+}
+
+// Validate checks the TransactionID, the amount, the ReceiverParty shortcode and the result URLs.
+func (r ReversalRequest) Validate() []error {
+	var v validator
+
+	v.required("TransactionID", r.TransactionID)
+	v.amount("Amount", r.Amount)
+	v.shortCode("ReceiverParty", r.ReceiverParty)
+	v.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	v.url("ResultURL", r.ResultURL)
+
+	return v.errs()
+}
+
+// Validate checks the PartyA shortcode, that the IdentifierType is a till or a shortcode and the result URLs.
+func (r AccountBalanceRequest) Validate() []error {
+	var v validator
+
+	if r.PartyA < 0 {
+		v.add("PartyA", "must be a 5 to 7 digit shortcode")
+	} else {
+		v.shortCode("PartyA", uint(r.PartyA))
+	}
+	v.identifierType("IdentifierType", r.IdentifierType, TillNumberIdentifierType, ShortcodeIdentifierType)
+	v.url("QueueTimeOutURL", r.QueueTimeOutURL)
+	v.url("ResultURL", r.ResultURL)
+
+	return v.errs()
+}
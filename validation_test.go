@@ -0,0 +1,372 @@
+package mpesa
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSTKPushRequest_Validate(t *testing.T) {
+	valid := STKPushRequest{
+		BusinessShortCode: 174379,
+		TransactionType:   CustomerPayBillOnlineTransactionType,
+		Amount:            10,
+		PartyA:            254708374149,
+		PartyB:            174379,
+		PhoneNumber:       254708374149,
+		CallBackURL:       "https://example.com/callback",
+		AccountReference:  "INV-001",
+		TransactionDesc:   "Payment",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(req *STKPushRequest)
+		want   []FieldError
+	}{
+		{
+			name:   "it accepts a valid request",
+			modify: func(req *STKPushRequest) {},
+		},
+		{
+			name: "it accepts 2541 phone numbers",
+			modify: func(req *STKPushRequest) {
+				req.PartyA = 254110000000
+				req.PhoneNumber = 254110000000
+			},
+		},
+		{
+			name: "it rejects invalid shortcodes",
+			modify: func(req *STKPushRequest) {
+				req.BusinessShortCode = 1234
+				req.PartyB = 12345678
+			},
+			want: []FieldError{
+				{Field: "BusinessShortCode", Message: "must be a 5 to 7 digit shortcode"},
+				{Field: "PartyB", Message: "must be a 5 to 7 digit shortcode"},
+			},
+		},
+		{
+			name: "it rejects invalid phone numbers",
+			modify: func(req *STKPushRequest) {
+				req.PartyA = 708374149
+				req.PhoneNumber = 254208374149
+			},
+			want: []FieldError{
+				{Field: "PartyA", Message: "must be in the format 2547XXXXXXXX or 2541XXXXXXXX"},
+				{Field: "PhoneNumber", Message: "must be in the format 2547XXXXXXXX or 2541XXXXXXXX"},
+			},
+		},
+		{
+			name: "it rejects a zero amount, an insecure callback url and long descriptions",
+			modify: func(req *STKPushRequest) {
+				req.Amount = 0
+				req.CallBackURL = "http://example.com/callback"
+				req.AccountReference = "INV-0000000001"
+				req.TransactionDesc = ""
+			},
+			want: []FieldError{
+				{Field: "Amount", Message: "must be greater than 0"},
+				{Field: "CallBackURL", Message: "must be a valid https URL"},
+				{Field: "AccountReference", Message: "must be between 1 and 12 characters"},
+				{Field: "TransactionDesc", Message: "must be between 1 and 13 characters"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			req := valid
+			tc.modify(&req)
+
+			err := validate(req)
+			if tc.want == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			require.True(t, errors.Is(err, ErrInvalidRequest))
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			require.Equal(t, tc.want, validationErr.Fields)
+		})
+	}
+}
+
+func TestB2CRequest_Validate(t *testing.T) {
+	req := B2CRequest{
+		CommandID:       BusinessPaymentCommandID,
+		Amount:          10,
+		PartyA:          600123,
+		PartyB:          254728762287,
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	}
+	require.NoError(t, validate(req))
+
+	req.PartyB = 600123
+	req.ResultURL = "example.com/result"
+
+	err := validate(req)
+	require.EqualError(t, err, "mpesa: invalid request: PartyB must be in the format 2547XXXXXXXX or 2541XXXXXXXX; "+
+		"ResultURL must be a valid https URL")
+}
+
+func TestBusinessPayBillRequest_Validate(t *testing.T) {
+	req := BusinessPayBillRequest{
+		AccountReference: "600992",
+		Amount:           10,
+		PartyA:           600992,
+		PartyB:           600000,
+		QueueTimeOutURL:  "https://example.com/timeout",
+		ResultURL:        "https://example.com/result",
+	}
+	require.NoError(t, validate(req))
+
+	req.Requester = 254700000000
+	require.NoError(t, validate(req))
+
+	req.Requester = 700000000
+	req.Amount = 0
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(validate(req), &validationErr))
+	require.Equal(t, []FieldError{
+		{Field: "Amount", Message: "must be greater than 0"},
+		{Field: "Requester", Message: "must be in the format 2547XXXXXXXX or 2541XXXXXXXX"},
+	}, validationErr.Fields)
+}
+
+func TestValidationErrors(t *testing.T) {
+	req := STKPushRequest{}
+
+	errs := req.Validate()
+	require.Len(t, errs, 8)
+	require.EqualError(t, errs[0], "BusinessShortCode must be a 5 to 7 digit shortcode")
+	require.EqualError(t, errs[7], "TransactionDesc must be between 1 and 13 characters")
+
+	err := validate(req)
+	require.ErrorIs(t, err, ErrInvalidRequest)
+	require.Equal(t, errs, ValidationErrors(err))
+
+	require.Nil(t, ValidationErrors(nil))
+	require.Nil(t, ValidationErrors(ErrInvalidPasskey))
+}
+
+func TestBusinessBuyGoodsRequest_Validate(t *testing.T) {
+	req := BusinessBuyGoodsRequest{
+		Amount:          10,
+		PartyA:          600992,
+		PartyB:          600000,
+		QueueTimeOutURL: "https://example.com/timeout",
+		ResultURL:       "https://example.com/result",
+	}
+	require.NoError(t, validate(req))
+
+	req.RecieverIdentifierType = MerchantTillIdentifierType
+	require.NoError(t, validate(req))
+
+	req.RecieverIdentifierType = 1
+	req.Requester = 700000000
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(validate(req), &validationErr))
+	require.Equal(t, []FieldError{
+		{Field: "RecieverIdentifierType", Message: "must be one of 2, 4"},
+		{Field: "Requester", Message: "must be in the format 2547XXXXXXXX or 2541XXXXXXXX"},
+	}, validationErr.Fields)
+}
+
+func TestDynamicQRRequest_Validate(t *testing.T) {
+	req := DynamicQRRequest{
+		Amount:                10,
+		CreditPartyIdentifier: "174379",
+		MerchantName:          "jwambugu",
+		ReferenceNo:           "INV-001",
+		TransactionType:       PayMerchantBuyGoods,
+	}
+	require.NoError(t, validate(req))
+
+	req.Size = 50
+	req.CreditPartyIdentifier = "0708374149"
+	require.EqualError(t, validate(req), "mpesa: invalid request: Size must be between 100 and 1000; "+
+		"CreditPartyIdentifier must be a 5 to 7 digit shortcode")
+
+	req.Size = 500
+	req.TransactionType = SendMoneyViaMobileNumber
+	require.NoError(t, validate(req))
+
+	req.TransactionType = SentToBusiness
+	req.CreditPartyIdentifier = "174379"
+	require.EqualError(t, validate(req), "mpesa: invalid request: CreditPartyIdentifier must be a phone number "+
+		"for SB transactions")
+
+	b, err := json.Marshal(DynamicQRRequest{Size: 300})
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"Size":"300"`)
+}
+
+func TestRequests_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    interface{ Validate() []error }
+		fields []string
+	}{
+		{
+			name: "stk query",
+			req:  STKQueryRequest{BusinessShortCode: 174379, CheckoutRequestID: "ws_CO_1"},
+		},
+		{
+			name:   "invalid stk query",
+			req:    STKQueryRequest{},
+			fields: []string{"BusinessShortCode", "CheckoutRequestID"},
+		},
+		{
+			name: "register c2b url",
+			req: RegisterC2BURLRequest{
+				ShortCode:       600638,
+				ResponseType:    ResponseTypeComplete,
+				ConfirmationURL: "https://example.com/confirm",
+				ValidationURL:   "https://example.com/validate",
+			},
+		},
+		{
+			name: "register c2b confirmation url only",
+			req: RegisterC2BURLRequest{
+				ShortCode:        600638,
+				ResponseType:     ResponseTypeComplete,
+				ConfirmationURL:  "https://example.com/confirm",
+				ConfirmationOnly: true,
+			},
+		},
+		{
+			name: "insecure register c2b url",
+			req: RegisterC2BURLRequest{
+				ShortCode:        600638,
+				ResponseType:     ResponseTypeComplete,
+				ConfirmationURL:  "http://example.com/confirm",
+				ValidationURL:    "http://example.com/validate",
+				ConfirmationOnly: true,
+				APIVersion:       "v3",
+			},
+			fields: []string{"ConfirmationURL", "ValidationURL", "APIVersion"},
+		},
+		{
+			name:   "invalid register c2b url",
+			req:    RegisterC2BURLRequest{ShortCode: 600638, ResponseType: "Foo", ConfirmationURL: "example.com"},
+			fields: []string{"ResponseType", "ConfirmationURL", "ValidationURL"},
+		},
+		{
+			name: "dynamic qr",
+			req: DynamicQRRequest{
+				Amount:                10,
+				CreditPartyIdentifier: "174379",
+				MerchantName:          "jwambugu",
+				ReferenceNo:           "INV-001",
+				TransactionType:       PayMerchantBuyGoods,
+			},
+		},
+		{
+			name:   "invalid dynamic qr",
+			req:    DynamicQRRequest{TransactionType: "XX"},
+			fields: []string{"Amount", "CreditPartyIdentifier", "MerchantName", "ReferenceNo", "TransactionType"},
+		},
+		{
+			name: "transaction status",
+			req: TransactionStatusRequest{
+				PartyA:                   600426,
+				OriginatorConversationID: "16740-34861180-1",
+				QueueTimeOutURL:          "https://example.com/timeout",
+				ResultURL:                "https://example.com/result",
+			},
+		},
+		{
+			name: "transaction status of a phone number",
+			req: TransactionStatusRequest{
+				IdentifierType:  MSISDNIdentifierType,
+				PartyA:          600426,
+				TransactionID:   "SB162HIYLY",
+				QueueTimeOutURL: "https://example.com/timeout",
+				ResultURL:       "https://example.com/result",
+			},
+			fields: []string{"PartyA"},
+		},
+		{
+			name: "transaction status of a till number",
+			req: TransactionStatusRequest{
+				IdentifierType:  TillNumberIdentifierType,
+				PartyA:          254708374149,
+				TransactionID:   "SB162HIYLY",
+				QueueTimeOutURL: "https://example.com/timeout",
+				ResultURL:       "https://example.com/result",
+			},
+			fields: []string{"PartyA"},
+		},
+		{
+			name:   "invalid transaction status",
+			req:    TransactionStatusRequest{},
+			fields: []string{"TransactionID", "PartyA", "QueueTimeOutURL", "ResultURL"},
+		},
+		{
+			name: "reversal",
+			req: ReversalRequest{
+				TransactionID:   "SB162HIYLY",
+				Amount:          10,
+				ReceiverParty:   600426,
+				QueueTimeOutURL: "https://example.com/timeout",
+				ResultURL:       "https://example.com/result",
+			},
+		},
+		{
+			name:   "invalid reversal",
+			req:    ReversalRequest{Amount: 10, ReceiverParty: 600426},
+			fields: []string{"TransactionID", "QueueTimeOutURL", "ResultURL"},
+		},
+		{
+			name: "account balance",
+			req: AccountBalanceRequest{
+				PartyA:          600426,
+				QueueTimeOutURL: "https://example.com/timeout",
+				ResultURL:       "https://example.com/result",
+			},
+		},
+		{
+			name: "account balance of a till number",
+			req: AccountBalanceRequest{
+				IdentifierType:  TillNumberIdentifierType,
+				PartyA:          5174379,
+				QueueTimeOutURL: "https://example.com/timeout",
+				ResultURL:       "https://example.com/result",
+			},
+		},
+		{
+			name:   "invalid account balance",
+			req:    AccountBalanceRequest{PartyA: -1, IdentifierType: MSISDNIdentifierType},
+			fields: []string{"PartyA", "IdentifierType", "QueueTimeOutURL", "ResultURL"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := validate(tc.req)
+			if tc.fields == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+
+			var fields []string
+			for _, field := range validationErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			require.Equal(t, tc.fields, fields)
+		})
+	}
+}